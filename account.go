@@ -489,6 +489,8 @@ func (a *accounts) addUser(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
+			audit(r, model.AuditActionUserAdd, assoc.UserID, assoc.Email)
+
 			respond(w, http.StatusOK, model.User{
 				ID:        assoc.UserID,
 				AccountID: assoc.AccountID,
@@ -500,12 +502,21 @@ func (a *accounts) addUser(w http.ResponseWriter, r *http.Request) {
 		}
 
 		mship := backend.Membership(conf)
+		if err := mship.ValidatePassword(data.Email, data.Password); respondPasswordPolicy(w, err) {
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		_, newUser, err := mship.CreateUser(auth.AccountID, data.Email, data.Password, 0)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		audit(r, model.AuditActionUserAdd, newUser.ID, newUser.Email)
+
 		respond(w, http.StatusOK, newUser)
 		return
 	}
@@ -549,7 +560,7 @@ func (a *accounts) deleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if auth.Role < 50 {
+	if !auth.CanManageUsers() {
 		http.Error(w, "insufficient priviledges", http.StatusUnauthorized)
 		return
 	}
@@ -564,6 +575,8 @@ func (a *accounts) deleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	audit(r, model.AuditActionUserDelete, id, u.Email)
+
 	respond(w, http.StatusOK, true)
 }
 
@@ -637,8 +650,7 @@ func (a *accounts) getUserAccounts(w http.ResponseWriter, r *http.Request) {
 		Token     string `json:"token,omitempty"`
 	}
 
-	homeToken := fmt.Sprintf("%s|%s", user.ID, user.Token)
-	jwtBytes, err := backend.GetJWT(homeToken)
+	jwtBytes, err := backend.Membership(conf).GetAuthToken(user)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
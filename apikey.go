@@ -0,0 +1,62 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+func sudoAPIKeys(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := backend.ListAPIKeys(conf.Name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, keys)
+	case http.MethodPost:
+		var data model.NewAPIKey
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		key, err := backend.CreateAPIKey(auth, conf.Name, data)
+		if err != nil {
+			if errors.Is(err, model.ErrInvalidAPIKey) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, key)
+	case http.MethodDelete:
+		if err := backend.RevokeAPIKey(conf.Name, r.URL.Query().Get("id")); err != nil {
+			if errors.Is(err, backend.ErrAPIKeyNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
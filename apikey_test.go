@@ -0,0 +1,156 @@
+package staticbackend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+func apiKeyReq(t *testing.T, key string, scope *middleware.RouteScope, hf func(http.ResponseWriter, *http.Request), method, path string, v any) *http.Response {
+	t.Helper()
+
+	var payload []byte
+	if v != nil {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal("error marshaling post data:", err)
+		}
+		payload = b
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	w := httptest.NewRecorder()
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Set("SB-PUBLIC-KEY", pubKey)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", key))
+
+	chain := []middleware.Middleware{
+		middleware.WithDB(backend.DB, backend.Cache, getStripePortalURL),
+		middleware.RequireAuth(backend.DB, backend.Cache),
+	}
+	if scope != nil {
+		chain = append([]middleware.Middleware{middleware.WithScope(*scope)}, chain...)
+	}
+	middleware.Chain(http.HandlerFunc(hf), chain...).ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	nk := model.NewAPIKey{
+		Name: "read tasks",
+		Scopes: model.APIKeyScopes{
+			Collections: []model.CollectionScope{{Name: "apikeytasks", Read: true}},
+		},
+	}
+	resp := dbReq(t, sudoAPIKeys, "POST", "/sudo/apikeys", nk, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var created model.CreatedAPIKey
+	if err := parseBody(resp.Body, &created); err != nil {
+		t.Fatal(err)
+	}
+
+	scope := middleware.RouteScope{Scope: model.APIKeyScopeCollections, NamePart: 2}
+
+	resp = apiKeyReq(t, created.Key, &scope, db.dbreq, "GET", "/db/apikeytasks", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = apiKeyReq(t, created.Key, &scope, db.dbreq, "POST", "/db/apikeytasks", map[string]any{"title": "nope"})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a write with a read-only key to be forbidden got status %d", resp.StatusCode)
+	}
+
+	resp = apiKeyReq(t, created.Key, &scope, db.dbreq, "GET", "/db/othercol", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected another collection to be forbidden got status %d", resp.StatusCode)
+	}
+
+	resp = apiKeyReq(t, created.Key, nil, mship.me, "GET", "/me", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a route without scope to be forbidden got status %d", resp.StatusCode)
+	}
+
+	resp = dbReq(t, sudoAPIKeys, "GET", "/sudo/apikeys", nil, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var keys []model.APIKey
+	if err := parseBody(resp.Body, &keys); err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, k := range keys {
+		if k.ID == created.ID {
+			found = k.LastUsed != nil
+		}
+	}
+	if !found {
+		t.Errorf("expected the key to be listed with its last used time got %v", keys)
+	}
+
+	resp = dbReq(t, sudoAPIKeys, "DELETE", "/sudo/apikeys?id="+created.ID, nil, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = apiKeyReq(t, created.Key, &scope, db.dbreq, "GET", "/db/apikeytasks", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a revoked key to be refused got status %d", resp.StatusCode)
+	}
+}
+
+func TestAPIKeyAllowedIPs(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := middleware.ValidateRootToken(backend.DB, conf.Name, rootToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := model.Auth{AccountID: root.AccountID, UserID: root.ID}
+	nk := model.NewAPIKey{
+		Name:       "office only",
+		Scopes:     model.APIKeyScopes{Storage: true},
+		AllowedIPs: []string{"10.0.0.0/8"},
+	}
+	created, err := backend.CreateAPIKey(auth, conf.Name, nk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = backend.RevokeAPIKey(conf.Name, created.ID)
+	})
+
+	scope := middleware.RouteScope{Scope: model.APIKeyScopeStorage}
+
+	resp := apiKeyReq(t, created.Key, &scope, storageUsage, "GET", "/storage/usage", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a request from outside the allowlist to be forbidden got status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,143 @@
+package staticbackend
+
+import (
+	"encoding/csv"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// defaultAuditLimit is the number of audit entries returned when the limit
+// is not specified
+const defaultAuditLimit = 500
+
+// audit records an action of the request's user in the audit log, failing to
+// record it does not fail the request
+func audit(r *http.Request, action, target, details string) {
+	entry := model.AuditEntry{Action: action, Target: target, Details: details}
+	if err := middleware.RecordAudit(backend.DB, backend.Cache, r, entry); err != nil {
+		slog.Error("error recording action in the audit log", "action", action, "error", err)
+	}
+}
+
+// auditFilter parses the filter of the audit log from the query string
+func auditFilter(r *http.Request) (filter model.AuditFilter, err error) {
+	qs := r.URL.Query()
+
+	filter.Action = qs.Get("action")
+	filter.ActorID = qs.Get("actor")
+	filter.Target = qs.Get("target")
+	filter.Limit = defaultAuditLimit
+
+	if s := qs.Get("since"); len(s) > 0 {
+		if filter.Since, err = time.Parse(time.RFC3339, s); err != nil {
+			return
+		}
+	}
+	if s := qs.Get("until"); len(s) > 0 {
+		if filter.Until, err = time.Parse(time.RFC3339, s); err != nil {
+			return
+		}
+	}
+	if s := qs.Get("limit"); len(s) > 0 {
+		filter.Limit, err = strconv.Atoi(s)
+	}
+	return
+}
+
+// sudoAudit returns the audit log entries matching the action, actor,
+// target, since and until query string parameters. With format=csv the
+// entries are exported as a CSV file.
+func (m *membership) sudoAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := auditFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := backend.Membership(conf).ListAuditEntries(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") != "csv" {
+		respond(w, http.StatusOK, entries)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-log.csv"`)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"created", "action", "target", "details", "actorId", "actorEmail", "apiKeyId", "accountId", "ip"})
+	for _, e := range entries {
+		_ = cw.Write([]string{
+			e.Created.UTC().Format(time.RFC3339),
+			e.Action,
+			e.Target,
+			e.Details,
+			e.ActorID,
+			e.ActorEmail,
+			e.APIKeyID,
+			e.AccountID,
+			e.IP,
+		})
+	}
+	cw.Flush()
+}
+
+func (m *membership) sudoAuditPolicy(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := mship.GetAuditPolicy()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	case http.MethodPost:
+		var policy model.AuditPolicy
+		if err := parseBody(r.Body, &policy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if policy.RetentionDays < 0 {
+			http.Error(w, "retention days cannot be negative", http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.SetAuditPolicy(policy); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	default:
+		http.Error(w, "method not implemented", http.StatusNotImplemented)
+	}
+}
@@ -0,0 +1,82 @@
+package staticbackend
+
+import (
+	"encoding/csv"
+	"net/http"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestAuditLogImpersonation(t *testing.T) {
+	resp := dbReq(t, mship.sudoGetTokenFromAccountID, "GET", "/sudogettoken/"+testAccountID, nil, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, mship.sudoAudit, "GET", "/sudo/audit?action=impersonate&limit=1", nil, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var entries []model.AuditEntry
+	if err := parseBody(resp.Body, &entries); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 {
+		t.Fatalf("expected one impersonate entry got %v", entries)
+	} else if entries[0].Details != "account "+testAccountID || len(entries[0].ActorID) == 0 {
+		t.Errorf("expected root impersonating %s got %v", testAccountID, entries[0])
+	}
+
+	resp = dbReq(t, mship.sudoAudit, "GET", "/sudo/audit?action=impersonate&limit=1&format=csv", nil, true)
+	defer func() { _ = resp.Body.Close() }()
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(records) != 2 || records[0][0] != "created" || records[1][1] != model.AuditActionImpersonate {
+		t.Errorf("expected a header and the impersonate entry got %v", records)
+	}
+
+	resp = dbReq(t, mship.sudoAudit, "GET", "/sudo/audit?since=yesterday", nil, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an invalid date to be refused got %d", resp.StatusCode)
+	}
+}
+
+func TestAuditLogRoleChange(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, user, err := backend.Membership(conf).CreateUser(testAccountID, "audit-role@test.com", userPassword, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = backend.DB.RemoveUser(model.Auth{AccountID: testAccountID, UserID: user.ID, Role: 100}, dbName, user.ID)
+	})
+
+	resp := authReqWithToken(t, string(adminToken), mship.setRole, "POST", "/setrole", map[string]any{
+		"email": user.Email,
+		"role":  10,
+	})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	entries, err := backend.Membership(conf).ListAuditEntries(model.AuditFilter{Target: user.Email})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 || entries[0].Action != model.AuditActionRoleChange {
+		t.Fatalf("expected a role change entry got %v", entries)
+	} else if entries[0].AccountID != testAccountID || len(entries[0].IP) == 0 {
+		t.Errorf("expected the account and IP to be recorded got %v", entries[0])
+	}
+}
@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// CreateAPIKey creates an API key acting as the root user of auth and
+// limited to the requested scopes. The key is only returned here, only its
+// hash is stored.
+func CreateAPIKey(auth model.Auth, dbName string, nk model.NewAPIKey) (model.CreatedAPIKey, error) {
+	if err := nk.Validate(); err != nil {
+		return model.CreatedAPIKey{}, err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return model.CreatedAPIKey{}, err
+	}
+	secret := hex.EncodeToString(b)
+
+	k := model.APIKey{
+		AccountID:  auth.AccountID,
+		UserID:     auth.UserID,
+		Name:       nk.Name,
+		KeyHash:    middleware.HashAPIKeySecret(secret),
+		Scopes:     nk.Scopes,
+		AllowedIPs: nk.AllowedIPs,
+		Expires:    nk.Expires,
+		Created:    time.Now(),
+	}
+
+	id, err := DB.CreateAPIKey(dbName, k)
+	if err != nil {
+		return model.CreatedAPIKey{}, err
+	}
+	k.ID = id
+
+	return model.CreatedAPIKey{
+		APIKey: k,
+		Key:    model.APIKeyPrefix + id + "." + secret,
+	}, nil
+}
+
+// ListAPIKeys returns the API keys of a database
+func ListAPIKeys(dbName string) ([]model.APIKey, error) {
+	return DB.ListAPIKeys(dbName)
+}
+
+// RevokeAPIKey deletes an API key, requests using it are refused right away
+func RevokeAPIKey(dbName, id string) error {
+	if _, err := DB.GetAPIKey(dbName, id); err != nil {
+		return ErrAPIKeyNotFound
+	}
+
+	if err := DB.DeleteAPIKey(dbName, id); err != nil {
+		return err
+	}
+	return Cache.Delete(middleware.APIKeyCacheKey(id))
+}
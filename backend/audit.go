@@ -0,0 +1,39 @@
+package backend
+
+import (
+	"encoding/json"
+
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// GetAuditPolicy returns the audit log policy of the database
+func (u User) GetAuditPolicy() (model.AuditPolicy, error) {
+	return middleware.LoadAuditPolicy(DB, Cache, u.conf.Name)
+}
+
+// SetAuditPolicy sets the audit log policy of the database, entries older
+// than the new retention are removed on the next recorded action.
+func (u User) SetAuditPolicy(policy model.AuditPolicy) error {
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	if err := DB.SetSetting(u.conf.Name, model.SettingAuditPolicy, b); err != nil {
+		return err
+	}
+	return Cache.SetTyped(middleware.AuditPolicyCacheKey(u.conf.Name), policy)
+}
+
+// ListAuditEntries returns the audit log entries of the database matching
+// the filter, most recent first
+func (u User) ListAuditEntries(filter model.AuditFilter) ([]model.AuditEntry, error) {
+	entries, err := DB.ListAuditEntries(u.conf.Name, filter)
+	if err != nil {
+		return nil, err
+	} else if entries == nil {
+		entries = []model.AuditEntry{}
+	}
+	return entries, nil
+}
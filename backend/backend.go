@@ -33,6 +33,7 @@
 //   - [DB]: a raw [github.com/staticbackendhq/core/database.Persister] instance (see below for when to use it)
 //   - [Filestore]: raw blob storage
 //   - [Emailer]: to send emails
+//   - [SMSer]: to send text-messages
 //   - [Config]: the config that was passed to [Setup]
 //
 // You may see those services as raw building blocks that give you the most
@@ -135,6 +136,7 @@ import (
 	"github.com/staticbackendhq/core/logger"
 	"github.com/staticbackendhq/core/model"
 	"github.com/staticbackendhq/core/search"
+	"github.com/staticbackendhq/core/sms"
 	"github.com/staticbackendhq/core/storage"
 	mongodrv "go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -153,7 +155,9 @@ var (
 	DB database.Persister
 	// Emailer initialized Mailer for sending emails
 	Emailer email.Mailer
-	// Filestore initialized Storer for raw save/delete blob file
+	// SMSer initialized Sender for sending text-messages
+	SMSer sms.Sender
+	// Filestore initialized Storer for raw save/get/delete blob file
 	Filestore storage.Storer
 	// Cache initialized Volatilizer for cache and pub/sub
 	Cache  cache.Volatilizer
@@ -163,6 +167,10 @@ var (
 	// account and user functionalities.
 	Membership func(model.DatabaseConfig) User
 
+	// IdentityProvider exposes the OpenID Connect provider of a database
+	// letting other apps sign their users in with its membership.
+	IdentityProvider func(model.DatabaseConfig) OpenIDProvider
+
 	// Storage exposes file storage functionalities. It wraps the blob
 	// storage as well as the database storage.
 	Storage func(model.Auth, model.DatabaseConfig) FileStore
@@ -198,20 +206,20 @@ func Setup(cfg config.AppConfig) {
 
 	persister := config.Current.DataStore
 	if strings.EqualFold(cfg.DatabaseURL, "mem") {
-		DB = memory.New(Cache.PublishDocument)
+		DB = memory.New(publishDocument)
 	} else if strings.EqualFold(persister, "mongo") {
 		cl, err := openMongoDatabase(cfg.DatabaseURL)
 		if err != nil {
 			logger.FatalError("failed to create connection with mongodb", err)
 		}
-		DB = mongo.New(cl, Cache.PublishDocument)
+		DB = mongo.New(cl, publishDocument)
 	} else if strings.EqualFold(persister, "sqlite") {
 		cl, err := openSQLite(cfg.DatabaseURL)
 		if err != nil {
 			logger.FatalError("failed to create connection with SQLite", err)
 		}
 
-		DB = sqlite.New(cl, Cache.PublishDocument)
+		DB = sqlite.New(cl, publishDocument)
 	} else {
 		cl, err := openPGDatabase(cfg.DatabaseURL, cfg)
 		if err != nil {
@@ -224,9 +232,28 @@ func Setup(cfg config.AppConfig) {
 			"max_lifetime_seconds", pool.maxLifetimeSeconds,
 			"max_idle_time_seconds", pool.maxIdleTimeSeconds)
 
-		DB = postgresql.New(cl, Cache.PublishDocument)
+		var replicas []*sql.DB
+		for i, dsn := range cfg.DatabaseReplicaURLs {
+			rcl, err := openPGDatabase(dsn, cfg)
+			if err != nil {
+				// an unreachable replica should not prevent the server from
+				// starting, reads are served by the primary instead
+				slog.Warn("unable to connect to postgres replica", "replica", i+1, "error", err)
+				continue
+			}
+			replicas = append(replicas, rcl)
+		}
+		if len(replicas) > 0 {
+			slog.Info("postgres read replicas configured", "replicas", len(replicas))
+		}
+
+		DB = postgresql.NewWithReplicas(cl, replicas, publishDocument)
 	}
 
+	// defaults are set before encryption so generated values get encrypted,
+	// the group scope is checked on the document as sent by the client
+	DB = newGroupsPersister(newDefaultsPersister(newEncryptedPersister(DB)))
+
 	mp := cfg.MailProvider
 	if strings.EqualFold(mp, email.MailProviderSES) {
 		Emailer = email.AWSSES{}
@@ -236,6 +263,12 @@ func Setup(cfg config.AppConfig) {
 		Emailer = email.Dev{}
 	}
 
+	if strings.EqualFold(cfg.SMSProvider, sms.SMSProviderTwilio) {
+		SMSer = sms.Twilio{}
+	} else {
+		SMSer = sms.Dev{}
+	}
+
 	sp := cfg.StorageProvider
 	if strings.EqualFold(sp, storage.StorageProviderS3) {
 		Filestore = storage.S3{}
@@ -296,6 +329,15 @@ func Setup(cfg config.AppConfig) {
 		sub.StartContext(subCtx)
 	}()
 
+	// keep the known collections in sync with the other instances
+	if reg, ok := rawDB().(database.CollectionRegistrar); ok {
+		go reg.CollectionRegistry().Watch(subCtx, Cache)
+	}
+
+	if err := setupJWTKeys(subCtx, isPrimary); err != nil {
+		logger.FatalError("unable to load the token signing keys", err)
+	}
+
 	// for primary instance, we start the job scheduler
 	if isPrimary {
 		runner := &function.TaskScheduler{
@@ -311,6 +353,7 @@ func Setup(cfg config.AppConfig) {
 	}
 
 	Membership = newUser
+	IdentityProvider = newOpenIDProvider
 	Storage = newFile
 }
 
@@ -361,7 +404,7 @@ func closeServices(ctx context.Context) error {
 		Search = nil
 	}
 
-	if err := closeResource(ctx, DB); err != nil {
+	if err := closeResource(ctx, rawDB()); err != nil {
 		errs = append(errs, err)
 	}
 	if err := closeResource(ctx, Cache); err != nil {
@@ -451,6 +494,17 @@ func configurePostgresPool(db *sql.DB, cfg config.AppConfig) postgresPoolConfig
 	return pool
 }
 
+// DatabasePoolStats returns the connection pool statistics of the data store,
+// one entry per pool when PostgreSQL read replicas are configured. It returns
+// nil when the data store does not expose pool statistics.
+func DatabasePoolStats() []database.PoolStats {
+	ps, ok := rawDB().(database.PoolStatser)
+	if !ok {
+		return nil
+	}
+	return ps.PoolStats()
+}
+
 func openPGDatabase(dbHost string, cfg config.AppConfig) (*sql.DB, error) {
 	//connStr := "user=postgres password=example dbname=test sslmode=disable"
 	dbConn, err := sql.Open("postgres", dbHost)
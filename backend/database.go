@@ -64,6 +64,8 @@ type PagedResult[T any] struct {
 	Size    int64
 	Total   int64
 	Results []T
+	// Scores holds the full-text relevance score by id for Search results
+	Scores map[string]float64
 }
 
 // List returns records from a collection/repository using paging/sorting params
@@ -117,6 +119,36 @@ func (d Database[T]) Query(filters [][]any, lp model.ListParams) (res PagedResul
 	return
 }
 
+// Search returns records matching the full-text keywords and the provided
+// filters, sorted by relevance unless lp.SortBy is set.
+func (d Database[T]) Search(keywords string, filters [][]any, lp model.ListParams) (res PagedResult[T], err error) {
+	clauses, err := DB.ParseQuery(filters)
+	if err != nil {
+		return
+	}
+
+	r, err := SearchDocuments(d.auth, d.conf.Name, d.col, keywords, clauses, lp)
+	if err != nil {
+		return
+	}
+
+	for _, doc := range r.Results {
+		var v T
+		if err = fromDoc(doc, &v); err != nil {
+			return
+		}
+
+		res.Results = append(res.Results, v)
+	}
+
+	res.Page = r.Page
+	res.Size = r.Size
+	res.Total = r.Total
+	res.Scores = r.Scores
+
+	return
+}
+
 // GetByID returns a specific record from a collection/repository
 func (d Database[T]) GetByID(id string) (entity T, err error) {
 	doc, err := DB.GetDocumentByID(d.auth, d.conf.Name, d.col, id)
@@ -0,0 +1,187 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/staticbackendhq/core/database"
+	sbquery "github.com/staticbackendhq/core/internal/query"
+	"github.com/staticbackendhq/core/model"
+)
+
+func fieldDefaultsCacheKey(dbName string) string {
+	return "fielddefaults_" + dbName
+}
+
+// defaultsPersister wraps the data store and sets the field defaults root
+// configured for a collection on new documents missing those fields.
+type defaultsPersister struct {
+	database.Persister
+}
+
+func newDefaultsPersister(p database.Persister) database.Persister {
+	return &defaultsPersister{Persister: p}
+}
+
+func (dp *defaultsPersister) unwrap() database.Persister {
+	return dp.Persister
+}
+
+func (dp *defaultsPersister) CreateTypedIndex(dbName, col, field string, typ database.IndexType) error {
+	typed, ok := dp.Persister.(database.TypedIndexer)
+	if !ok {
+		return errTypedIndexNotSupported
+	}
+	return typed.CreateTypedIndex(dbName, col, field, typ)
+}
+
+func (dp *defaultsPersister) defaults(dbName string) (fd model.FieldDefaults, err error) {
+	if err := Cache.GetTyped(fieldDefaultsCacheKey(dbName), &fd); err == nil {
+		return fd, nil
+	}
+
+	fd, err = loadFieldDefaults(rawDB(), dbName)
+	if err != nil {
+		return
+	}
+
+	err = Cache.SetTyped(fieldDefaultsCacheKey(dbName), fd)
+	return
+}
+
+func (dp *defaultsPersister) CreateDocument(auth model.Auth, dbName, col string, doc map[string]any) (map[string]any, error) {
+	fd, err := dp.defaults(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc != nil {
+		if err := applyFieldDefaults(dbName, col, fd.Fields(col), doc); err != nil {
+			return nil, err
+		}
+	}
+	return dp.Persister.CreateDocument(auth, dbName, col, doc)
+}
+
+func (dp *defaultsPersister) BulkCreateDocument(auth model.Auth, dbName, col string, docs []any) error {
+	fd, err := dp.defaults(dbName)
+	if err != nil {
+		return err
+	}
+
+	if defs := fd.Fields(col); len(defs) > 0 {
+		for _, v := range docs {
+			// let the driver report the invalid document
+			doc, ok := v.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			if err := applyFieldDefaults(dbName, col, defs, doc); err != nil {
+				return err
+			}
+		}
+	}
+	return dp.Persister.BulkCreateDocument(auth, dbName, col, docs)
+}
+
+// applyFieldDefaults sets the defaults on the document fields that are
+// missing or null.
+func applyFieldDefaults(dbName, col string, defs []model.FieldDefault, doc map[string]any) error {
+	for _, def := range defs {
+		if v, ok := doc[def.Field]; ok && v != nil {
+			continue
+		}
+
+		switch def.Generator {
+		case model.GeneratorUUID:
+			doc[def.Field] = uuid.NewString()
+		case model.GeneratorNow:
+			doc[def.Field] = time.Now()
+		case model.GeneratorSequence:
+			n, err := rawDB().NextSequence(dbName, sequenceName(col, def.Field))
+			if err != nil {
+				return err
+			}
+			doc[def.Field] = n
+		case model.GeneratorSlugify:
+			v, ok := doc[def.From]
+			if !ok || v == nil {
+				continue
+			}
+
+			s, ok := v.(string)
+			if !ok {
+				s = fmt.Sprint(v)
+			}
+			doc[def.Field] = model.Slugify(s)
+		default:
+			doc[def.Field] = def.Value
+		}
+	}
+	return nil
+}
+
+func sequenceName(col, field string) string {
+	return model.CleanCollectionName(col) + "." + field
+}
+
+func loadFieldDefaults(db database.Persister, dbName string) (fd model.FieldDefaults, err error) {
+	b, err := db.GetSetting(dbName, model.SettingFieldDefaults)
+	if err != nil || b == nil {
+		return
+	}
+
+	err = json.Unmarshal(b, &fd)
+	return
+}
+
+// GetFieldDefaults returns the field defaults of all collections for a
+// database.
+func GetFieldDefaults(dbName string) (model.FieldDefaults, error) {
+	return loadFieldDefaults(rawDB(), dbName)
+}
+
+// SetFieldDefaults replaces the field defaults of a collection. The defaults
+// are applied to documents created from now on, existing documents are left
+// as is.
+func SetFieldDefaults(dbName, col string, defaults []model.FieldDefault) (model.FieldDefaults, error) {
+	db := rawDB()
+
+	fd, err := loadFieldDefaults(db, dbName)
+	if err != nil {
+		return fd, err
+	}
+
+	for _, def := range defaults {
+		if err := def.Validate(); err != nil {
+			return fd, err
+		} else if err := sbquery.ValidateField(def.Field); err != nil {
+			return fd, err
+		}
+	}
+
+	if fd.Collections == nil {
+		fd.Collections = make(map[string][]model.FieldDefault)
+	}
+
+	col = model.CleanCollectionName(col)
+	if len(defaults) == 0 {
+		delete(fd.Collections, col)
+	} else {
+		fd.Collections[col] = defaults
+	}
+
+	b, err := json.Marshal(fd)
+	if err != nil {
+		return fd, err
+	}
+
+	if err := db.SetSetting(dbName, model.SettingFieldDefaults, b); err != nil {
+		return fd, err
+	}
+
+	return fd, Cache.SetTyped(fieldDefaultsCacheKey(dbName), fd)
+}
@@ -0,0 +1,79 @@
+package backend_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+type Post struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Slug      string    `json:"slug"`
+	Status    string    `json:"status"`
+	Number    int64     `json:"number"`
+	Ref       string    `json:"ref"`
+	Published time.Time `json:"published"`
+}
+
+func TestFieldDefaults(t *testing.T) {
+	defaults := []model.FieldDefault{
+		{Field: "status", Value: "draft"},
+		{Field: "slug", Generator: model.GeneratorSlugify, From: "title"},
+		{Field: "number", Generator: model.GeneratorSequence},
+		{Field: "ref", Generator: model.GeneratorUUID},
+		{Field: "published", Generator: model.GeneratorNow},
+	}
+
+	fd, err := backend.SetFieldDefaults(base.Name, "posts", defaults)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(fd.Fields("posts")) != len(defaults) {
+		t.Fatalf("expected %d defaults got %d", len(defaults), len(fd.Fields("posts")))
+	}
+
+	db := backend.Collection[map[string]any](adminAuth, base, "posts")
+
+	first, err := db.Create(map[string]any{"title": "Hello, World!"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	posts := backend.Collection[Post](adminAuth, base, "posts")
+
+	p, err := posts.GetByID(first["id"].(string))
+	if err != nil {
+		t.Fatal(err)
+	} else if p.Status != "draft" {
+		t.Errorf("expected status draft got %s", p.Status)
+	} else if p.Slug != "hello-world" {
+		t.Errorf("expected slug hello-world got %s", p.Slug)
+	} else if len(p.Ref) == 0 {
+		t.Error("expected a generated ref")
+	} else if p.Published.IsZero() {
+		t.Error("expected published to be set")
+	}
+
+	second, err := db.Create(map[string]any{"title": "second", "status": "published"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p2, err := posts.GetByID(second["id"].(string))
+	if err != nil {
+		t.Fatal(err)
+	} else if p2.Status != "published" {
+		t.Errorf("expected provided status to be kept got %s", p2.Status)
+	} else if p2.Number != p.Number+1 {
+		t.Errorf("expected number %d got %d", p.Number+1, p2.Number)
+	}
+}
+
+func TestSetFieldDefaultsInvalid(t *testing.T) {
+	_, err := backend.SetFieldDefaults(base.Name, "posts", []model.FieldDefault{{Field: "slug", Generator: model.GeneratorSlugify}})
+	if err == nil {
+		t.Error("expected an error for slugify without a from field")
+	}
+}
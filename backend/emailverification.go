@@ -0,0 +1,266 @@
+package backend
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/email"
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	emailVerificationTTL         = 48 * time.Hour
+	emailVerificationMaxAttempts = 5
+
+	// verification emails can be sent every minute, up to 5 per hour
+	emailVerificationResendDelay  = time.Minute
+	emailVerificationResendWindow = time.Hour
+	emailVerificationResendMax    = 5
+
+	defaultEmailVerificationSubject = "Verify your email address"
+	defaultEmailVerificationBody    = `<p>Please confirm your email address by following this link:</p><p><a href="[link]">[link]</a></p>`
+)
+
+var (
+	ErrInvalidVerificationCode   = errors.New("invalid or expired verification code")
+	ErrTooManyVerificationEmails = errors.New("too many verification emails sent, please try again later")
+)
+
+// emailVerificationState is a verification email waiting for its code.
+// NewEmail is set when it confirms an email change, Email being the current
+// address of the user.
+type emailVerificationState struct {
+	UserID    string    `json:"userId"`
+	AccountID string    `json:"accountId"`
+	Token     string    `json:"token"`
+	Email     string    `json:"email"`
+	NewEmail  string    `json:"newEmail"`
+	Code      string    `json:"code"`
+	Attempts  int       `json:"attempts"`
+	Expires   time.Time `json:"expires"`
+}
+
+// to returns the address being verified
+func (st emailVerificationState) to() string {
+	if len(st.NewEmail) > 0 {
+		return st.NewEmail
+	}
+	return st.Email
+}
+
+// emailVerificationSent counts the verification emails sent to a user in
+// the current window.
+type emailVerificationSent struct {
+	Count  int       `json:"count"`
+	Window time.Time `json:"window"`
+	Last   time.Time `json:"last"`
+}
+
+func emailVerificationCacheKey(dbName, email string) string {
+	return "email-verify-" + dbName + "-" + email
+}
+
+func emailVerificationSentCacheKey(dbName, userID string) string {
+	return "email-verify-sent-" + dbName + "-" + userID
+}
+
+// GetEmailVerificationPolicy returns the email verification policy of the
+// database
+func (u User) GetEmailVerificationPolicy() (model.EmailVerificationPolicy, error) {
+	return middleware.LoadEmailVerificationPolicy(DB, Cache, u.conf.Name)
+}
+
+// SetEmailVerificationPolicy sets the email verification policy of the
+// database. Existing users are unverified until they verify their email.
+func (u User) SetEmailVerificationPolicy(policy model.EmailVerificationPolicy) error {
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	if err := DB.SetSetting(u.conf.Name, model.SettingEmailVerification, b); err != nil {
+		return err
+	}
+	return Cache.SetTyped(middleware.EmailVerificationPolicyCacheKey(u.conf.Name), policy)
+}
+
+// checkEmailVerifiedLogin returns model.ErrEmailNotVerified when the user must
+// verify their email before logging in
+func (u User) checkEmailVerifiedLogin(tok model.User) error {
+	policy, err := u.GetEmailVerificationPolicy()
+	if err != nil || !policy.Required || policy.AllowLogin {
+		return err
+	}
+
+	ok, err := middleware.IsEmailVerified(DB, u.conf.Name, tok.ID, tok.Email)
+	if err != nil {
+		return err
+	} else if !ok {
+		return model.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// verifyNewUser sends the verification email of a newly registered user
+// when the database requires it. model.ErrEmailNotVerified is returned when
+// they cannot log in until verified.
+func (u User) verifyNewUser(tok model.User) error {
+	policy, err := u.GetEmailVerificationPolicy()
+	if err != nil || !policy.Required {
+		return err
+	}
+
+	st := emailVerificationState{UserID: tok.ID, AccountID: tok.AccountID, Email: tok.Email}
+	if err := u.sendEmailVerification(policy, st); err != nil {
+		return err
+	} else if !policy.AllowLogin {
+		return model.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// ResendEmailVerification sends a new verification email to a user who has
+// not verified their email yet
+func (u User) ResendEmailVerification(email string) error {
+	email = strings.ToLower(email)
+
+	tok, err := DB.FindUserByEmail(u.conf.Name, email)
+	if err != nil {
+		return err
+	}
+
+	ok, err := middleware.IsEmailVerified(DB, u.conf.Name, tok.ID, tok.Email)
+	if err != nil || ok {
+		return err
+	}
+
+	policy, err := u.GetEmailVerificationPolicy()
+	if err != nil {
+		return err
+	}
+
+	st := emailVerificationState{UserID: tok.ID, AccountID: tok.AccountID, Email: tok.Email}
+	return u.sendEmailVerification(policy, st)
+}
+
+// sendEmailVerification emails a verification link, replacing any pending
+// verification of the same address
+func (u User) sendEmailVerification(policy model.EmailVerificationPolicy, st emailVerificationState) error {
+	if err := u.countVerificationEmail(st.UserID); err != nil {
+		return err
+	}
+
+	st.Code = internal.RandStringRunes(32)
+	// to accomodate unit test, we hard code the code in dev mode
+	if Config.AppEnv == "dev" {
+		st.Code = "666333"
+	}
+	st.Expires = time.Now().Add(emailVerificationTTL)
+
+	if err := Cache.SetTyped(emailVerificationCacheKey(u.conf.Name, st.to()), st); err != nil {
+		return err
+	}
+
+	link := policy.Link
+	qs := url.Values{"code": {st.Code}, "email": {st.to()}}
+	if len(link) == 0 {
+		link = Config.AppURL + "/email/verify"
+		qs.Set("sbpk", u.conf.ID)
+	}
+
+	if strings.Contains(link, "?") {
+		link += "&" + qs.Encode()
+	} else {
+		link += "?" + qs.Encode()
+	}
+
+	mail := email.SendMailData{
+		From:     policy.FromEmail,
+		FromName: policy.FromName,
+		To:       st.to(),
+		Subject:  policy.Subject,
+		HTMLBody: strings.ReplaceAll(policy.Body, "[link]", link),
+	}
+	if len(mail.From) == 0 {
+		mail.From, mail.FromName = Config.FromEmail, Config.FromName
+	}
+	if len(mail.Subject) == 0 {
+		mail.Subject = defaultEmailVerificationSubject
+	}
+	if len(policy.Body) == 0 {
+		mail.HTMLBody = strings.ReplaceAll(defaultEmailVerificationBody, "[link]", link)
+	}
+	return Emailer.Send(mail)
+}
+
+// countVerificationEmail returns ErrTooManyVerificationEmails when the user
+// was sent too many verification emails recently
+func (u User) countVerificationEmail(userID string) error {
+	key := emailVerificationSentCacheKey(u.conf.Name, userID)
+	now := time.Now()
+
+	var sent emailVerificationSent
+	if err := Cache.GetTyped(key, &sent); err != nil || now.Sub(sent.Window) >= emailVerificationResendWindow {
+		sent = emailVerificationSent{Window: now}
+	} else if now.Sub(sent.Last) < emailVerificationResendDelay || sent.Count >= emailVerificationResendMax {
+		return ErrTooManyVerificationEmails
+	}
+
+	sent.Count++
+	sent.Last = now
+	return Cache.SetTyped(key, sent)
+}
+
+// VerifyEmail validates the code of a verification email and marks the
+// address as verified. For an email change, the user's email is switched to
+// the new address and all their sessions are revoked.
+func (u User) VerifyEmail(email, code string) error {
+	email = strings.ToLower(email)
+	key := emailVerificationCacheKey(u.conf.Name, email)
+
+	var st emailVerificationState
+	if err := Cache.GetTyped(key, &st); err != nil || time.Now().After(st.Expires) {
+		return ErrInvalidVerificationCode
+	}
+
+	if subtle.ConstantTimeCompare([]byte(code), []byte(st.Code)) != 1 {
+		st.Attempts++
+		if st.Attempts >= emailVerificationMaxAttempts {
+			_ = Cache.Delete(key)
+		} else if err := Cache.SetTyped(key, st); err != nil {
+			return err
+		}
+		return ErrInvalidVerificationCode
+	}
+
+	if err := Cache.Delete(key); err != nil {
+		return err
+	}
+
+	v := model.EmailVerification{UserID: st.UserID, Email: email, Verified: time.Now()}
+
+	if len(st.NewEmail) > 0 {
+		auth := model.Auth{UserID: st.UserID, AccountID: st.AccountID, Email: st.Email, Token: st.Token}
+		return u.switchEmail(auth, st.NewEmail, &v)
+	}
+
+	tok, err := DB.FindUserByEmail(u.conf.Name, email)
+	if err != nil {
+		return err
+	} else if tok.ID != st.UserID {
+		return ErrInvalidVerificationCode
+	}
+
+	if err := DB.SaveEmailVerification(u.conf.Name, v); err != nil {
+		return err
+	}
+
+	// the cached user is reloaded with their verified email
+	return Cache.Delete(tok.ID + "|" + tok.Token)
+}
@@ -0,0 +1,57 @@
+package backend_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestChangeEmailVerifiesNewAddress(t *testing.T) {
+	const (
+		email    = "verify-change@test.com"
+		newEmail = "verify-change-new@test.com"
+		password = "test1234!"
+	)
+
+	usr := backend.Membership(base)
+	if err := usr.SetEmailVerificationPolicy(model.EmailVerificationPolicy{Required: true, AllowLogin: true}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.SetEmailVerificationPolicy(model.EmailVerificationPolicy{}) })
+
+	_, tok, err := usr.CreateAccountAndUser(email, password, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := model.Auth{AccountID: tok.AccountID, UserID: tok.ID, Email: tok.Email, Token: tok.Token}
+	if err := usr.ChangeEmail(auth, newEmail); !errors.Is(err, backend.ErrEmailChangePending) {
+		t.Fatalf("expected ErrEmailChangePending got %v", err)
+	}
+
+	if _, err := usr.Authenticate(email, password); err != nil {
+		t.Fatalf("expected the email to change once verified got %v", err)
+	}
+
+	// in dev mode, the code is always 666333
+	if err := usr.VerifyEmail(newEmail, "666333"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := usr.Authenticate(newEmail, password); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := backend.DB.GetEmailVerification(base.Name, tok.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if !v.Verifies(newEmail) {
+		t.Errorf("expected %s to be verified got %v", newEmail, v)
+	}
+
+	if err := usr.VerifyEmail(newEmail, "666333"); !errors.Is(err, backend.ErrInvalidVerificationCode) {
+		t.Errorf("expected a used code to be refused got %v", err)
+	}
+}
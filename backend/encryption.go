@@ -0,0 +1,641 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/staticbackendhq/core/database"
+	sbquery "github.com/staticbackendhq/core/internal/query"
+	"github.com/staticbackendhq/core/model"
+)
+
+var errTypedIndexNotSupported = errors.New("typed indexes are not supported by this database provider")
+
+// fieldEncryptionState is the encryption configuration of a database. The
+// tenant is stored with it since encryption keys are derived per tenant.
+// Enabled is false when field encryption was never configured.
+type fieldEncryptionState struct {
+	Enabled  bool                  `json:"enabled"`
+	TenantID string                `json:"tenantId"`
+	Config   model.FieldEncryption `json:"config"`
+}
+
+func (st fieldEncryptionState) cipher() model.FieldCipher {
+	return model.FieldCipher{TenantID: st.TenantID}
+}
+
+func fieldEncryptionCacheKey(dbName string) string {
+	return "fieldenc_" + dbName
+}
+
+// encryptedPersister wraps the data store and encrypts the document fields
+// root marked as encrypted before they reach the driver. Those fields are
+// decrypted on read and equality queries are rewritten to use their blind
+// index.
+type encryptedPersister struct {
+	database.Persister
+}
+
+func newEncryptedPersister(p database.Persister) database.Persister {
+	return &encryptedPersister{Persister: p}
+}
+
+func (ep *encryptedPersister) unwrap() database.Persister {
+	return ep.Persister
+}
+
+func (ep *encryptedPersister) CreateTypedIndex(dbName, col, field string, typ database.IndexType) error {
+	typed, ok := ep.Persister.(database.TypedIndexer)
+	if !ok {
+		return errTypedIndexNotSupported
+	}
+	return typed.CreateTypedIndex(dbName, col, field, typ)
+}
+
+func (ep *encryptedPersister) state(dbName string) (st fieldEncryptionState, err error) {
+	if err := Cache.GetTyped(fieldEncryptionCacheKey(dbName), &st); err == nil {
+		return st, nil
+	}
+
+	st, err = loadFieldEncryption(ep.Persister, dbName)
+	if err != nil {
+		return
+	}
+
+	err = Cache.SetTyped(fieldEncryptionCacheKey(dbName), st)
+	return
+}
+
+// publishDocument decrypts the encrypted fields of database events before
+// they reach the realtime subscribers.
+func publishDocument(auth model.Auth, dbName, channel, typ string, v any) {
+	if doc, ok := v.(map[string]any); ok {
+		if ep, ok := encryptionLayer(); ok {
+			st, err := ep.state(dbName)
+			if err != nil {
+				slog.Error("unable to decrypt database event", "error", err)
+				return
+			}
+
+			col := strings.TrimPrefix(channel, "db-")
+			v = ep.decrypt(st, col, maps.Clone(doc))
+		}
+	}
+
+	Cache.PublishDocument(auth, dbName, channel, typ, v)
+}
+
+func loadFieldEncryption(db database.Persister, dbName string) (st fieldEncryptionState, err error) {
+	b, err := db.GetSetting(dbName, model.SettingFieldEncryption)
+	if err != nil || b == nil {
+		return
+	}
+
+	err = json.Unmarshal(b, &st)
+	st.Enabled = true
+	return
+}
+
+func (ep *encryptedPersister) encrypt(st fieldEncryptionState, col string, doc map[string]any) (map[string]any, error) {
+	fields := st.Config.Fields(col)
+	if len(fields) == 0 || doc == nil {
+		return doc, nil
+	}
+
+	fc := st.cipher()
+
+	enc := make(map[string]any)
+	for k, v := range doc {
+		// blind indexes are never set by the caller
+		if strings.HasPrefix(k, model.BlindIndexPrefix) {
+			continue
+		}
+		enc[k] = v
+	}
+
+	for _, field := range fields {
+		v, ok := enc[field]
+		if !ok || v == nil {
+			continue
+		}
+
+		s, err := fc.Encrypt(v, st.Config.KeyVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		idx, err := fc.BlindIndex(v)
+		if err != nil {
+			return nil, err
+		}
+
+		enc[field] = s
+		enc[model.BlindIndexPrefix+field] = idx
+	}
+	return enc, nil
+}
+
+// decrypt decrypts the encrypted fields of a collection's document. A value
+// that cannot be decrypted is returned as stored instead of failing the read.
+func (ep *encryptedPersister) decrypt(st fieldEncryptionState, col string, doc map[string]any) map[string]any {
+	if !st.Enabled || doc == nil {
+		return doc
+	}
+
+	for k := range doc {
+		if strings.HasPrefix(k, model.BlindIndexPrefix) {
+			delete(doc, k)
+		}
+	}
+
+	fc := st.cipher()
+	for _, field := range st.Config.Fields(col) {
+		v, ok := doc[field]
+		if !ok {
+			continue
+		} else if _, ok := model.EncryptedValueVersion(v); !ok {
+			continue
+		}
+
+		val, err := fc.Decrypt(v.(string))
+		if err != nil {
+			slog.Warn("unable to decrypt field", "collection", col, "field", field, "error", err)
+			continue
+		}
+		doc[field] = val
+	}
+	return doc
+}
+
+func (ep *encryptedPersister) decryptAll(st fieldEncryptionState, col string, docs []map[string]any) {
+	for i, doc := range docs {
+		docs[i] = ep.decrypt(st, col, doc)
+	}
+}
+
+// rewriteFilter replaces equality clauses on encrypted fields with a clause
+// on their blind index.
+func (ep *encryptedPersister) rewriteFilter(st fieldEncryptionState, col string, filter map[string]any) (map[string]any, error) {
+	if len(st.Config.Fields(col)) == 0 {
+		return filter, nil
+	}
+
+	q, ok := sbquery.FromFilter(filter)
+	if !ok {
+		return filter, nil
+	}
+
+	fc := st.cipher()
+
+	rewritten := make(sbquery.Query, 0, len(q))
+	for _, clause := range q {
+		if clause.Value.Kind == sbquery.OperandField && st.Config.IsEncrypted(col, clause.Value.Field) {
+			return nil, model.ErrEncryptedFieldOperator
+		}
+
+		if !st.Config.IsEncrypted(col, clause.Field) {
+			rewritten = append(rewritten, clause)
+			continue
+		}
+
+		if clause.Value.Kind != sbquery.OperandLiteral ||
+			(clause.Operator != sbquery.OpEqual && clause.Operator != sbquery.OpNotEqual) {
+			return nil, model.ErrEncryptedFieldOperator
+		}
+
+		idx, err := fc.BlindIndex(clause.Value.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		rewritten = append(rewritten, sbquery.Clause{
+			Field:    model.BlindIndexPrefix + clause.Field,
+			Operator: clause.Operator,
+			Value:    sbquery.Operand{Kind: sbquery.OperandLiteral, Value: idx},
+		})
+	}
+
+	f := maps.Clone(filter)
+	f[sbquery.FilterKey] = rewritten
+	return f, nil
+}
+
+func (ep *encryptedPersister) CreateDocument(auth model.Auth, dbName, col string, doc map[string]any) (map[string]any, error) {
+	st, err := ep.state(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err = ep.encrypt(st, col, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	inserted, err := ep.Persister.CreateDocument(auth, dbName, col, doc)
+	if err != nil {
+		return nil, err
+	}
+	return ep.decrypt(st, col, inserted), nil
+}
+
+func (ep *encryptedPersister) BulkCreateDocument(auth model.Auth, dbName, col string, docs []any) error {
+	st, err := ep.state(dbName)
+	if err != nil {
+		return err
+	}
+
+	if len(st.Config.Fields(col)) == 0 {
+		return ep.Persister.BulkCreateDocument(auth, dbName, col, docs)
+	}
+
+	encrypted := make([]any, 0, len(docs))
+	for _, v := range docs {
+		doc, ok := v.(map[string]any)
+		if !ok {
+			// let the driver report the invalid document
+			encrypted = append(encrypted, v)
+			continue
+		}
+
+		enc, err := ep.encrypt(st, col, doc)
+		if err != nil {
+			return err
+		}
+		encrypted = append(encrypted, enc)
+	}
+	return ep.Persister.BulkCreateDocument(auth, dbName, col, encrypted)
+}
+
+func (ep *encryptedPersister) ListDocuments(auth model.Auth, dbName, col string, params model.ListParams) (result model.PagedResult, err error) {
+	st, err := ep.state(dbName)
+	if err != nil {
+		return
+	}
+
+	result, err = ep.Persister.ListDocuments(auth, dbName, col, params)
+	if err != nil {
+		return
+	}
+
+	ep.decryptAll(st, col, result.Results)
+	return
+}
+
+func (ep *encryptedPersister) QueryDocuments(auth model.Auth, dbName, col string, filter map[string]any, params model.ListParams) (result model.PagedResult, err error) {
+	st, err := ep.state(dbName)
+	if err != nil {
+		return
+	}
+
+	filter, err = ep.rewriteFilter(st, col, filter)
+	if err != nil {
+		return
+	}
+
+	result, err = ep.Persister.QueryDocuments(auth, dbName, col, filter, params)
+	if err != nil {
+		return
+	}
+
+	ep.decryptAll(st, col, result.Results)
+	return
+}
+
+func (ep *encryptedPersister) GetDocumentByID(auth model.Auth, dbName, col, id string) (map[string]any, error) {
+	st, err := ep.state(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := ep.Persister.GetDocumentByID(auth, dbName, col, id)
+	if err != nil {
+		return nil, err
+	}
+	return ep.decrypt(st, col, doc), nil
+}
+
+func (ep *encryptedPersister) GetDocumentsByIDs(auth model.Auth, dbName, col string, ids []string) ([]map[string]any, error) {
+	st, err := ep.state(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := ep.Persister.GetDocumentsByIDs(auth, dbName, col, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	ep.decryptAll(st, col, docs)
+	return docs, nil
+}
+
+func (ep *encryptedPersister) UpdateDocument(auth model.Auth, dbName, col, id string, doc map[string]any) (map[string]any, error) {
+	st, err := ep.state(dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err = ep.encrypt(st, col, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := ep.Persister.UpdateDocument(auth, dbName, col, id, doc)
+	if err != nil {
+		return nil, err
+	}
+	return ep.decrypt(st, col, updated), nil
+}
+
+func (ep *encryptedPersister) UpdateDocuments(auth model.Auth, dbName, col string, filters map[string]any, updateFields map[string]any) (int64, error) {
+	st, err := ep.state(dbName)
+	if err != nil {
+		return 0, err
+	}
+
+	filters, err = ep.rewriteFilter(st, col, filters)
+	if err != nil {
+		return 0, err
+	}
+
+	updateFields, err = ep.encrypt(st, col, updateFields)
+	if err != nil {
+		return 0, err
+	}
+
+	return ep.Persister.UpdateDocuments(auth, dbName, col, filters, updateFields)
+}
+
+func (ep *encryptedPersister) IncrementValue(auth model.Auth, dbName, col, id, field string, n int) error {
+	st, err := ep.state(dbName)
+	if err != nil {
+		return err
+	}
+
+	if st.Config.IsEncrypted(col, field) {
+		return model.ErrEncryptedFieldIncrement
+	}
+
+	return ep.Persister.IncrementValue(auth, dbName, col, id, field, n)
+}
+
+func (ep *encryptedPersister) DeleteDocuments(auth model.Auth, dbName, col string, filters map[string]any) (int64, error) {
+	st, err := ep.state(dbName)
+	if err != nil {
+		return 0, err
+	}
+
+	filters, err = ep.rewriteFilter(st, col, filters)
+	if err != nil {
+		return 0, err
+	}
+
+	return ep.Persister.DeleteDocuments(auth, dbName, col, filters)
+}
+
+func (ep *encryptedPersister) Count(auth model.Auth, dbName, col string, filters map[string]any) (int64, error) {
+	st, err := ep.state(dbName)
+	if err != nil {
+		return 0, err
+	}
+
+	filters, err = ep.rewriteFilter(st, col, filters)
+	if err != nil {
+		return 0, err
+	}
+
+	return ep.Persister.Count(auth, dbName, col, filters)
+}
+
+// GetFieldEncryption returns which collection fields are encrypted for a
+// database.
+func GetFieldEncryption(dbName string) (model.FieldEncryption, error) {
+	st, err := loadFieldEncryption(rawDB(), dbName)
+	return st.Config, err
+}
+
+// SetEncryptedFields marks the fields of a collection as encrypted. Existing
+// documents of this collection are encrypted right away and fields removed
+// from the list are decrypted back to their plain value.
+func SetEncryptedFields(auth model.Auth, conf model.DatabaseConfig, col string, fields []string) (model.FieldEncryption, error) {
+	db := rawDB()
+
+	st, err := loadFieldEncryption(db, conf.Name)
+	if err != nil {
+		return model.FieldEncryption{}, err
+	}
+
+	if !st.Enabled {
+		st.TenantID = conf.TenantID
+	}
+	if st.Config.Collections == nil {
+		st.Config.Collections = make(map[string][]string)
+	}
+	if st.Config.KeyVersion == 0 {
+		st.Config.KeyVersion = 1
+	}
+
+	for _, field := range fields {
+		if err := sbquery.ValidateField(field); err != nil {
+			return st.Config, err
+		}
+	}
+
+	col = model.CleanCollectionName(col)
+	if len(fields) == 0 {
+		delete(st.Config.Collections, col)
+	} else {
+		st.Config.Collections[col] = slices.Compact(slices.Sorted(slices.Values(fields)))
+	}
+
+	if err := saveFieldEncryption(db, conf.Name, st); err != nil {
+		return st.Config, err
+	}
+
+	_, err = reencryptCollection(auth, conf, col, st)
+	return st.Config, err
+}
+
+// RotateFieldEncryptionKey moves the database to a new encryption key version
+// and re-encrypts every encrypted field of existing documents with it. It
+// returns the number of documents that were re-encrypted.
+func RotateFieldEncryptionKey(auth model.Auth, conf model.DatabaseConfig) (n int64, err error) {
+	db := rawDB()
+
+	st, err := loadFieldEncryption(db, conf.Name)
+	if err != nil {
+		return
+	}
+
+	if !st.Enabled {
+		return
+	}
+
+	st.Config.KeyVersion++
+
+	if err = saveFieldEncryption(db, conf.Name, st); err != nil {
+		return
+	}
+
+	for col := range st.Config.Collections {
+		count, err := reencryptCollection(auth, conf, col, st)
+		if err != nil {
+			return n, err
+		}
+		n += count
+	}
+	return
+}
+
+func saveFieldEncryption(db database.Persister, dbName string, st fieldEncryptionState) error {
+	st.Enabled = true
+
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	if err := db.SetSetting(dbName, model.SettingFieldEncryption, b); err != nil {
+		return err
+	}
+
+	return Cache.SetTyped(fieldEncryptionCacheKey(dbName), st)
+}
+
+// reencryptCollection makes sure every document of the collection has its
+// encrypted fields encrypted with the current key version and that fields
+// no longer encrypted are stored as plain value.
+func reencryptCollection(auth model.Auth, conf model.DatabaseConfig, col string, st fieldEncryptionState) (n int64, err error) {
+	db := rawDB()
+
+	names, err := db.ListCollections(conf.Name)
+	if err != nil {
+		return
+	}
+
+	fc := st.cipher()
+	for _, name := range names {
+		if strings.HasPrefix(name, "sb_") || model.CleanCollectionName(name) != col {
+			continue
+		}
+
+		params := model.ListParams{Page: 1, Size: 100}
+		for {
+			result, err := db.ListDocuments(auth, conf.Name, name, params)
+			if err != nil {
+				return n, err
+			}
+
+			for _, doc := range result.Results {
+				update, err := reencryptDocument(fc, st.Config, col, doc)
+				if err != nil {
+					return n, err
+				} else if len(update) == 0 {
+					continue
+				}
+
+				id, _ := doc["id"].(string)
+				if _, err := db.UpdateDocument(auth, conf.Name, name, id, update); err != nil {
+					return n, err
+				}
+				n++
+			}
+
+			if params.Page*params.Size >= result.Total {
+				break
+			}
+			params.Page++
+		}
+	}
+	return
+}
+
+func reencryptDocument(fc model.FieldCipher, cfg model.FieldEncryption, col string, doc map[string]any) (map[string]any, error) {
+	update := make(map[string]any)
+
+	for k, v := range doc {
+		if strings.HasPrefix(k, model.BlindIndexPrefix) {
+			continue
+		}
+
+		version, isEncrypted := model.EncryptedValueVersion(v)
+		mustEncrypt := cfg.IsEncrypted(col, k)
+
+		if isEncrypted && mustEncrypt && version == cfg.KeyVersion {
+			continue
+		} else if !isEncrypted && !mustEncrypt {
+			continue
+		}
+
+		val := v
+		if isEncrypted {
+			// like on read, a value that cannot be decrypted is kept as is
+			dec, err := fc.Decrypt(v.(string))
+			if err != nil {
+				slog.Warn("unable to decrypt field", "collection", col, "field", k, "error", err)
+				continue
+			}
+			val = dec
+		}
+
+		if !mustEncrypt {
+			update[k] = val
+			update[model.BlindIndexPrefix+k] = nil
+			continue
+		} else if val == nil {
+			continue
+		}
+
+		s, err := fc.Encrypt(val, cfg.KeyVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		idx, err := fc.BlindIndex(val)
+		if err != nil {
+			return nil, err
+		}
+
+		update[k] = s
+		update[model.BlindIndexPrefix+k] = idx
+	}
+	return update, nil
+}
+
+// persisterLayer is implemented by the backend layers wrapping the data
+// store.
+type persisterLayer interface {
+	unwrap() database.Persister
+}
+
+// rawDB returns the data store without the backend layers, i.e. field
+// defaults and encryption
+func rawDB() database.Persister {
+	db := DB
+	for {
+		layer, ok := db.(persisterLayer)
+		if !ok {
+			return db
+		}
+		db = layer.unwrap()
+	}
+}
+
+func encryptionLayer() (*encryptedPersister, bool) {
+	db := DB
+	for {
+		if ep, ok := db.(*encryptedPersister); ok {
+			return ep, true
+		}
+
+		layer, ok := db.(persisterLayer)
+		if !ok {
+			return nil, false
+		}
+		db = layer.unwrap()
+	}
+}
@@ -0,0 +1,147 @@
+package backend_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/model"
+)
+
+type Patient struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	SSN  string `json:"ssn"`
+}
+
+func TestEncryptedFields(t *testing.T) {
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+
+	db := backend.Collection[Patient](adminAuth, base, "patients")
+
+	existing, err := db.Create(Patient{Name: "before", SSN: "111-11-1111"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fe, err := backend.SetEncryptedFields(adminAuth, base, "patients", []string{"ssn"})
+	if err != nil {
+		t.Fatal(err)
+	} else if !fe.IsEncrypted("patients", "ssn") {
+		t.Fatal("expected ssn to be encrypted")
+	}
+
+	p, err := db.Create(Patient{Name: "after", SSN: "222-22-2222"})
+	if err != nil {
+		t.Fatal(err)
+	} else if p.SSN != "222-22-2222" {
+		t.Errorf("expected decrypted ssn got %s", p.SSN)
+	}
+
+	check, err := db.GetByID(existing.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.SSN != "111-11-1111" {
+		t.Errorf("expected existing ssn to be readable got %s", check.SSN)
+	}
+
+	filters, err := backend.BuildQueryFilters("ssn", "==", "222-22-2222")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := db.Query(filters, model.ListParams{Page: 1, Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	} else if res.Total != 1 {
+		t.Fatalf("expected 1 result got %d", res.Total)
+	} else if res.Results[0].ID != p.ID {
+		t.Errorf("expected patient %s got %s", p.ID, res.Results[0].ID)
+	}
+
+	filters, err = backend.BuildQueryFilters("ssn", "contains", "222")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Query(filters, model.ListParams{Page: 1, Size: 10}); err != model.ErrEncryptedFieldOperator {
+		t.Errorf("expected ErrEncryptedFieldOperator got %v", err)
+	}
+}
+
+func TestRotateFieldEncryptionKey(t *testing.T) {
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+
+	db := backend.Collection[Patient](adminAuth, base, "rotated")
+
+	if _, err := backend.SetEncryptedFields(adminAuth, base, "rotated", []string{"ssn"}); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := db.Create(Patient{Name: "rotate", SSN: "333-33-3333"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := backend.GetFieldEncryption(base.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := backend.RotateFieldEncryptionKey(adminAuth, base)
+	if err != nil {
+		t.Fatal(err)
+	} else if n < 1 {
+		t.Errorf("expected at least 1 document to be re-encrypted got %d", n)
+	}
+
+	after, err := backend.GetFieldEncryption(base.Name)
+	if err != nil {
+		t.Fatal(err)
+	} else if after.KeyVersion != before.KeyVersion+1 {
+		t.Errorf("expected key version %d got %d", before.KeyVersion+1, after.KeyVersion)
+	}
+
+	check, err := db.GetByID(p.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.SSN != "333-33-3333" {
+		t.Errorf("expected ssn to be readable after rotation got %s", check.SSN)
+	}
+}
+
+func TestEncryptedFieldsPassThrough(t *testing.T) {
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+
+	fe, err := backend.GetFieldEncryption(base.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version := fe.KeyVersion
+	if version == 0 {
+		version = 1
+	}
+
+	// values looking encrypted but that are not
+	name := "sbenc:v1:not-encrypted"
+	ssn := fmt.Sprintf("sbenc:v%d:not-decryptable", version)
+
+	db := backend.Collection[Patient](adminAuth, base, "passthrough")
+	p, err := db.Create(Patient{Name: name, SSN: ssn})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := backend.SetEncryptedFields(adminAuth, base, "passthrough", []string{"ssn"}); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := db.GetByID(p.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.Name != name || check.SSN != ssn {
+		t.Errorf("expected the values to be returned as stored got %v", check)
+	}
+}
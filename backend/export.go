@@ -0,0 +1,555 @@
+package backend
+
+import (
+	"archive/zip"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+var (
+	ErrExportNotFound = errors.New("data export not found")
+	ErrExportPending  = errors.New("a data export is already being generated")
+	ErrExportNotReady = errors.New("data export is not ready")
+	ErrInvalidExport  = errors.New("invalid or expired download link")
+	ErrExportScope    = errors.New("export scope must be user or account")
+)
+
+// exportsMu serializes the changes to the exports index of a database on
+// this instance
+var exportsMu sync.Mutex
+
+// exportKey signs the download links of exports, it is derived from
+// APP_SECRET so links survive restarts
+var exportKey = sync.OnceValue(func() []byte {
+	if len(Config.AppSecret) > 0 {
+		key, err := hkdf.Key(sha256.New, []byte(Config.AppSecret), nil, "exports", 32)
+		if err == nil {
+			return key
+		}
+	}
+
+	slog.Warn("APP_SECRET is not set, data export links will be invalid after a restart")
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+})
+
+// exportRecord is a data export as kept in the cache, the archive's storage
+// key is never returned to clients
+type exportRecord struct {
+	Export  model.DataExport `json:"export"`
+	FileKey string           `json:"fileKey"`
+}
+
+func exportCacheKey(dbName, id string) string {
+	return "export-" + dbName + "-" + id
+}
+
+func exportsCacheKey(dbName string) string {
+	return "exports-" + dbName
+}
+
+// StartExport starts generating the archive of a user or an account in the
+// background. ErrExportPending is returned while a previous export of the
+// same user or account is being generated.
+func (u User) StartExport(scope, accountID, userID string) (model.DataExport, error) {
+	switch scope {
+	case model.DataExportScopeUser:
+		if _, err := DB.GetUserByID(u.conf.Name, accountID, userID); err != nil {
+			return model.DataExport{}, ErrExportNotFound
+		}
+	case model.DataExportScopeAccount:
+		users, err := DB.ListUsers(u.conf.Name, accountID)
+		if err != nil {
+			return model.DataExport{}, err
+		} else if len(users) == 0 {
+			return model.DataExport{}, ErrExportNotFound
+		}
+		userID = ""
+	default:
+		return model.DataExport{}, ErrExportScope
+	}
+
+	exportsMu.Lock()
+	defer exportsMu.Unlock()
+
+	records, err := u.exportRecords()
+	if err != nil {
+		return model.DataExport{}, err
+	}
+
+	for _, rec := range records {
+		e := rec.Export
+		if e.Status == model.DataExportPending && e.Scope == scope && e.AccountID == accountID && e.UserID == userID {
+			return model.DataExport{}, ErrExportPending
+		}
+	}
+
+	now := time.Now()
+	rec := exportRecord{
+		Export: model.DataExport{
+			ID:        DB.NewID(),
+			Scope:     scope,
+			AccountID: accountID,
+			UserID:    userID,
+			Status:    model.DataExportPending,
+			Created:   now,
+			Expires:   now.Add(model.DataExportRetention),
+		},
+	}
+
+	if err := u.saveExport(rec); err != nil {
+		return model.DataExport{}, err
+	}
+
+	ids := []string{rec.Export.ID}
+	for _, r := range records {
+		ids = append(ids, r.Export.ID)
+	}
+	if err := Cache.SetTyped(exportsCacheKey(u.conf.Name), ids); err != nil {
+		return model.DataExport{}, err
+	}
+
+	go u.generateExport(rec)
+
+	return rec.Export, nil
+}
+
+// GetExport returns an export, its URL is the signed download link once the
+// archive is ready
+func (u User) GetExport(id string) (model.DataExport, error) {
+	rec, err := u.getExport(id)
+	if err != nil {
+		return model.DataExport{}, err
+	}
+	return u.withLink(rec.Export), nil
+}
+
+// ListExports returns the exports of the database not expired yet, most
+// recent first
+func (u User) ListExports() ([]model.DataExport, error) {
+	exportsMu.Lock()
+	records, err := u.exportRecords()
+	exportsMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	exports := make([]model.DataExport, 0, len(records))
+	for _, rec := range records {
+		exports = append(exports, u.withLink(rec.Export))
+	}
+	return exports, nil
+}
+
+// OpenExport returns the archive of a signed download link, the caller
+// closes the returned reader
+func (u User) OpenExport(token string) (model.DataExport, io.ReadCloser, error) {
+	id, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return model.DataExport{}, nil, ErrInvalidExport
+	}
+
+	rec, err := u.getExport(id)
+	if err != nil {
+		return model.DataExport{}, nil, ErrInvalidExport
+	} else if !hmac.Equal([]byte(token), []byte(u.exportToken(rec.Export))) {
+		return model.DataExport{}, nil, ErrInvalidExport
+	} else if rec.Export.Status != model.DataExportReady {
+		return model.DataExport{}, nil, ErrExportNotReady
+	}
+
+	rc, err := Filestore.Get(rec.FileKey)
+	if err != nil {
+		return model.DataExport{}, nil, err
+	}
+	return rec.Export, rc, nil
+}
+
+// getExport returns an export record, expired exports are removed
+func (u User) getExport(id string) (exportRecord, error) {
+	var rec exportRecord
+	if err := Cache.GetTyped(exportCacheKey(u.conf.Name, id), &rec); err != nil || len(rec.Export.ID) == 0 {
+		return exportRecord{}, ErrExportNotFound
+	}
+
+	if rec.Export.Expired(time.Now()) {
+		if err := u.removeExport(rec); err != nil {
+			return exportRecord{}, err
+		}
+		return exportRecord{}, ErrExportNotFound
+	}
+	return rec, nil
+}
+
+// exportRecords returns the exports of the index not expired, the expired
+// ones are removed. The caller holds exportsMu.
+func (u User) exportRecords() ([]exportRecord, error) {
+	var ids []string
+	if err := Cache.GetTyped(exportsCacheKey(u.conf.Name), &ids); err != nil {
+		// no exports requested yet
+		return nil, nil
+	}
+
+	var records []exportRecord
+	for _, id := range ids {
+		rec, err := u.getExport(id)
+		if errors.Is(err, ErrExportNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func (u User) saveExport(rec exportRecord) error {
+	return Cache.SetTyped(exportCacheKey(u.conf.Name, rec.Export.ID), rec)
+}
+
+// removeExport deletes the archive and the record of an export
+func (u User) removeExport(rec exportRecord) error {
+	if len(rec.FileKey) > 0 {
+		if err := Filestore.Delete(rec.FileKey); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return Cache.Delete(exportCacheKey(u.conf.Name, rec.Export.ID))
+}
+
+// exportToken returns the signed token of an export's download link
+func (u User) exportToken(e model.DataExport) string {
+	mac := hmac.New(sha256.New, exportKey())
+	fmt.Fprintf(mac, "%s|%s|%s|%s|%d", u.conf.Name, e.ID, e.AccountID, e.UserID, e.Expires.Unix())
+	return e.ID + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// withLink sets the signed download link of a ready export
+func (u User) withLink(e model.DataExport) model.DataExport {
+	if e.Status != model.DataExportReady {
+		return e
+	}
+
+	qs := url.Values{"token": {u.exportToken(e)}, "sbpk": {u.conf.ID}}
+	e.URL = Config.AppURL + "/exports/download?" + qs.Encode()
+	return e
+}
+
+// generateExport writes the archive of an export to the file storage and
+// marks the export ready, or failed with its error
+func (u User) generateExport(rec exportRecord) {
+	size, err := u.writeExport(&rec)
+	if err != nil {
+		slog.Error("error generating data export", "db", u.conf.Name, "id", rec.Export.ID, "error", err)
+		rec.Export.Status = model.DataExportFailed
+		rec.Export.Error = err.Error()
+	} else {
+		rec.Export.Status = model.DataExportReady
+		rec.Export.Size = size
+	}
+	rec.Export.Completed = time.Now()
+
+	if err := u.saveExport(rec); err != nil {
+		slog.Error("error saving data export", "db", u.conf.Name, "id", rec.Export.ID, "error", err)
+	}
+}
+
+func (u User) writeExport(rec *exportRecord) (int64, error) {
+	tmp, err := os.CreateTemp("", "sb-export-*.zip")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	zw := zip.NewWriter(tmp)
+	if err := u.archive(zw, rec.Export); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	rec.FileKey = fmt.Sprintf("%s/exports/%s.zip", u.conf.Name, rec.Export.ID)
+	upData := model.UploadFileData{
+		FileKey:  rec.FileKey,
+		File:     tmp,
+		Size:     size,
+		Mimetype: "application/zip",
+		Private:  true,
+	}
+	if _, err := Filestore.Save(upData); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// archive writes the membership records, documents, files and form
+// submissions of an export, files are only part of account exports
+func (u User) archive(zw *zip.Writer, e model.DataExport) error {
+	users, err := u.exportUsers(e)
+	if err != nil {
+		return err
+	}
+
+	if err := writeJSON(zw, "export.json", e); err != nil {
+		return err
+	}
+	if err := u.archiveMembership(zw, users); err != nil {
+		return err
+	}
+	if err := u.archiveDocuments(zw, e); err != nil {
+		return err
+	}
+	// files are only tracked per account, a user export cannot tell which
+	// ones the user uploaded
+	if e.Scope == model.DataExportScopeAccount {
+		if err := u.archiveFiles(zw, e.AccountID); err != nil {
+			return err
+		}
+	}
+
+	emails := make([]string, 0, len(users))
+	for _, usr := range users {
+		emails = append(emails, usr.Email)
+	}
+	return u.archiveForms(zw, emails)
+}
+
+// exportUsers returns the users of an export, their auth tokens removed
+func (u User) exportUsers(e model.DataExport) ([]model.User, error) {
+	var users []model.User
+	if e.Scope == model.DataExportScopeUser {
+		tok, err := DB.GetUserByID(u.conf.Name, e.AccountID, e.UserID)
+		if err != nil {
+			return nil, err
+		}
+		users = []model.User{tok}
+	} else {
+		list, err := DB.ListUsers(u.conf.Name, e.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		users = list
+	}
+
+	for i := range users {
+		users[i].Token = ""
+	}
+	return users, nil
+}
+
+func (u User) archiveMembership(zw *zip.Writer, users []model.User) error {
+	for _, usr := range users {
+		memberships, err := DB.ListAccountUsers(u.conf.Name, usr.ID)
+		if err != nil {
+			return err
+		}
+		for i := range memberships {
+			memberships[i].Token = ""
+		}
+
+		profile, err := DB.GetUserProfile(u.conf.Name, usr.ID)
+		if err != nil {
+			return err
+		}
+
+		phone, err := DB.GetUserPhone(u.conf.Name, usr.ID)
+		if err != nil {
+			return err
+		}
+
+		sessions, err := DB.ListSessions(u.conf.Name, usr.ID)
+		if err != nil {
+			return err
+		}
+
+		record := struct {
+			User        model.User          `json:"user"`
+			Memberships []model.AccountUser `json:"memberships"`
+			Profile     model.UserProfile   `json:"profile"`
+			Phone       *model.UserPhone    `json:"phone,omitempty"`
+			Sessions    []model.Session     `json:"sessions"`
+		}{User: usr, Memberships: memberships, Profile: profile, Sessions: sessions}
+		if len(phone.UserID) > 0 {
+			record.Phone = &phone
+		}
+
+		if err := writeJSON(zw, "membership/"+usr.ID+".json", record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveDocuments writes the documents of every collection owned by the
+// user or belonging to the account
+func (u User) archiveDocuments(zw *zip.Writer, e model.DataExport) error {
+	names, err := DB.ListCollections(u.conf.Name)
+	if err != nil {
+		return err
+	}
+
+	field, id := "accountId", e.AccountID
+	if e.Scope == model.DataExportScopeUser {
+		field, id = "sb_ownerId", e.UserID
+	}
+
+	auth := model.Auth{AccountID: e.AccountID, UserID: e.UserID, Role: 100}
+	for _, name := range names {
+		if strings.HasPrefix(name, "sb_") {
+			continue
+		}
+
+		var docs []map[string]any
+		params := model.ListParams{Page: 1, Size: 100}
+		for {
+			result, err := DB.ListDocuments(auth, u.conf.Name, name, params)
+			if err != nil {
+				return err
+			}
+
+			for _, doc := range result.Results {
+				if v, _ := doc[field].(string); v == id {
+					docs = append(docs, doc)
+				}
+			}
+
+			if params.Page*params.Size >= result.Total {
+				break
+			}
+			params.Page++
+		}
+
+		if len(docs) == 0 {
+			continue
+		}
+		if err := writeJSON(zw, "documents/"+model.CleanCollectionName(name)+".json", docs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveFiles writes the files of the account and their details
+func (u User) archiveFiles(zw *zip.Writer, accountID string) error {
+	files, err := DB.ListAllFiles(u.conf.Name, accountID)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := archiveFile(zw, file); err != nil {
+			return err
+		}
+	}
+
+	if len(files) == 0 {
+		return nil
+	}
+	return writeJSON(zw, "files.json", files)
+}
+
+func archiveFile(zw *zip.Writer, file model.File) error {
+	rc, err := Filestore.Get(file.Key)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+
+	w, err := zw.Create("files/" + file.ID + "_" + path.Base(file.Key))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, rc)
+	return err
+}
+
+// archiveForms writes the form submissions having an email field matching
+// one of the emails
+func (u User) archiveForms(zw *zip.Writer, emails []string) error {
+	forms, err := DB.GetForms(u.conf.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range forms {
+		entries, err := DB.ListFormSubmissions(u.conf.Name, name)
+		if err != nil {
+			return err
+		}
+
+		var matches []map[string]any
+		for _, entry := range entries {
+			if submittedBy(entry, emails) {
+				matches = append(matches, entry)
+			}
+		}
+
+		if len(matches) == 0 {
+			continue
+		}
+		if err := writeJSON(zw, "forms/"+name+".json", matches); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func submittedBy(entry map[string]any, emails []string) bool {
+	for k, v := range entry {
+		s, ok := v.(string)
+		if !ok || !strings.EqualFold(k, "email") {
+			continue
+		}
+
+		for _, email := range emails {
+			if strings.EqualFold(strings.TrimSpace(s), email) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writeJSON(zw *zip.Writer, name string, v any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,175 @@
+package backend_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+// waitExport returns the export once it is not pending anymore
+func waitExport(t *testing.T, usr backend.User, id string) model.DataExport {
+	t.Helper()
+
+	for i := 0; i < 100; i++ {
+		e, err := usr.GetExport(id)
+		if err != nil {
+			t.Fatal(err)
+		} else if e.Status != model.DataExportPending {
+			return e
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("export %s is still pending", id)
+	return model.DataExport{}
+}
+
+// exportEntries returns the content of the archive of a ready export by
+// file name
+func exportEntries(t *testing.T, usr backend.User, e model.DataExport) map[string]string {
+	t.Helper()
+
+	link, err := url.Parse(e.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, rc, err := usr.OpenExport(link.Query().Get("token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		_ = r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[f.Name] = string(data)
+	}
+	return entries
+}
+
+func TestDataExport(t *testing.T) {
+	usr := backend.Membership(base)
+
+	_, tok, err := usr.CreateAccountAndUser("Export@test.com", "export1234!", 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := model.Auth{AccountID: tok.AccountID, UserID: tok.ID, Email: tok.Email, Role: tok.Role, Token: tok.Token}
+
+	notes := backend.Collection[map[string]any](auth, base, "export_notes")
+	if _, err := notes.Create(map[string]any{"text": "mine"}); err != nil {
+		t.Fatal(err)
+	}
+	others := backend.Collection[map[string]any](adminAuth, base, "export_notes")
+	if _, err := others.Create(map[string]any{"text": "not mine"}); err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("exported file")
+	if _, err := backend.Storage(auth, base).Save("export.txt", "", bytes.NewReader(content), int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backend.DB.AddFormSubmission(base.Name, "export_contact", map[string]any{"email": "export@test.com", "msg": "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.DB.AddFormSubmission(base.Name, "export_contact", map[string]any{"email": "someone@test.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := usr.StartExport("everything", auth.AccountID, auth.UserID); !errors.Is(err, backend.ErrExportScope) {
+		t.Errorf("expected ErrExportScope got %v", err)
+	}
+
+	e, err := usr.StartExport(model.DataExportScopeUser, auth.AccountID, auth.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e = waitExport(t, usr, e.ID)
+	if e.Status != model.DataExportReady {
+		t.Fatalf("expected the export to be ready got %v", e)
+	}
+
+	link, err := url.Parse(e.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := link.Query().Get("token")
+
+	if _, _, err := usr.OpenExport(token + "x"); !errors.Is(err, backend.ErrInvalidExport) {
+		t.Errorf("expected ErrInvalidExport got %v", err)
+	}
+
+	entries := exportEntries(t, usr, e)
+
+	var docs []map[string]any
+	if err := json.Unmarshal([]byte(entries["documents/export_notes.json"]), &docs); err != nil {
+		t.Fatal(err)
+	} else if len(docs) != 1 || docs[0]["text"] != "mine" {
+		t.Errorf("expected only the user's document got %v", docs)
+	}
+
+	if !strings.Contains(entries["forms/export_contact.json"], "hello") || strings.Contains(entries["forms/export_contact.json"], "someone@test.com") {
+		t.Errorf("expected only the user's form submission got %s", entries["forms/export_contact.json"])
+	}
+
+	if s, ok := entries["membership/"+auth.UserID+".json"]; !ok {
+		t.Error("expected the membership records of the user")
+	} else if strings.Contains(s, auth.Token) {
+		t.Error("expected the auth token to be removed from the membership records")
+	}
+
+	for name := range entries {
+		if strings.HasPrefix(name, "files/") || name == "files.json" {
+			t.Errorf("expected the files of the account not to be in a user export got %s", name)
+		}
+	}
+
+	e, err = usr.StartExport(model.DataExportScopeAccount, auth.AccountID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e = waitExport(t, usr, e.ID)
+	if e.Status != model.DataExportReady {
+		t.Fatalf("expected the export to be ready got %v", e)
+	}
+
+	var found bool
+	for name, data := range exportEntries(t, usr, e) {
+		if strings.HasPrefix(name, "files/") && data == string(content) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected the account's file in the archive")
+	}
+}
@@ -0,0 +1,233 @@
+package backend
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
+)
+
+var (
+	ErrGroupNotFound    = errors.New("group not found")
+	ErrGroupName        = errors.New("group name is required")
+	ErrNotAccountMember = errors.New("user is not a member of the account")
+)
+
+// groupsPersister wraps the data store and refuses the documents scoped to a
+// group the user is not a member of, see model.FieldGroupID.
+type groupsPersister struct {
+	database.Persister
+}
+
+func newGroupsPersister(p database.Persister) database.Persister {
+	return &groupsPersister{Persister: p}
+}
+
+func (gp *groupsPersister) unwrap() database.Persister {
+	return gp.Persister
+}
+
+func (gp *groupsPersister) CreateTypedIndex(dbName, col, field string, typ database.IndexType) error {
+	typed, ok := gp.Persister.(database.TypedIndexer)
+	if !ok {
+		return errTypedIndexNotSupported
+	}
+	return typed.CreateTypedIndex(dbName, col, field, typ)
+}
+
+func (gp *groupsPersister) CreateDocument(auth model.Auth, dbName, col string, doc map[string]any) (map[string]any, error) {
+	if err := checkDocumentGroup(auth, dbName, doc); err != nil {
+		return nil, err
+	}
+	return gp.Persister.CreateDocument(auth, dbName, col, doc)
+}
+
+func (gp *groupsPersister) BulkCreateDocument(auth model.Auth, dbName, col string, docs []any) error {
+	for _, v := range docs {
+		// let the driver report the invalid document
+		doc, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if err := checkDocumentGroup(auth, dbName, doc); err != nil {
+			return err
+		}
+	}
+	return gp.Persister.BulkCreateDocument(auth, dbName, col, docs)
+}
+
+func (gp *groupsPersister) UpdateDocument(auth model.Auth, dbName, col, id string, doc map[string]any) (map[string]any, error) {
+	if err := checkDocumentGroup(auth, dbName, doc); err != nil {
+		return nil, err
+	}
+	return gp.Persister.UpdateDocument(auth, dbName, col, id, doc)
+}
+
+func (gp *groupsPersister) UpdateDocuments(auth model.Auth, dbName, col string, filters map[string]any, updateFields map[string]any) (int64, error) {
+	if err := checkDocumentGroup(auth, dbName, updateFields); err != nil {
+		return 0, err
+	}
+	return gp.Persister.UpdateDocuments(auth, dbName, col, filters, updateFields)
+}
+
+// checkDocumentGroup returns model.ErrNotGroupMember when a document is
+// scoped to a group the user cannot share data with. Root scopes documents
+// to any group and account managers to the groups of their account.
+func checkDocumentGroup(auth model.Auth, dbName string, doc map[string]any) error {
+	v, ok := doc[model.FieldGroupID]
+	if !ok || v == nil {
+		return nil
+	}
+
+	groupID, ok := v.(string)
+	if !ok {
+		return errors.New(model.FieldGroupID + " must be a string")
+	}
+
+	switch {
+	case len(groupID) == 0, auth.Role == 100, auth.InGroup(groupID):
+		return nil
+	case internal.SeesAllGroups(auth):
+		if _, err := rawDB().GetGroup(dbName, auth.AccountID, groupID); err == nil {
+			return nil
+		}
+	}
+	return model.ErrNotGroupMember
+}
+
+// CreateGroup adds a group to an account
+func (u User) CreateGroup(accountID, name string) (model.Group, error) {
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		return model.Group{}, ErrGroupName
+	}
+
+	g := model.Group{AccountID: accountID, Name: name, Created: time.Now()}
+
+	id, err := DB.CreateGroup(u.conf.Name, g)
+	if err != nil {
+		return model.Group{}, err
+	}
+
+	g.ID = id
+	return g, nil
+}
+
+// GetGroup returns a group of an account
+func (u User) GetGroup(accountID, groupID string) (model.Group, error) {
+	g, err := DB.GetGroup(u.conf.Name, accountID, groupID)
+	if err != nil || len(g.ID) == 0 {
+		return model.Group{}, ErrGroupNotFound
+	}
+	return g, nil
+}
+
+// ListGroups returns the groups of an account
+func (u User) ListGroups(accountID string) ([]model.Group, error) {
+	return DB.ListGroups(u.conf.Name, accountID)
+}
+
+// DeleteGroup removes a group of an account, the documents scoped to the
+// group are kept and only visible to their owners and the account managers
+func (u User) DeleteGroup(accountID, groupID string) error {
+	if _, err := u.GetGroup(accountID, groupID); err != nil {
+		return err
+	}
+
+	members, err := DB.ListGroupMembers(u.conf.Name, groupID)
+	if err != nil {
+		return err
+	}
+
+	if err := DB.DeleteGroup(u.conf.Name, accountID, groupID); err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if err := u.refreshGroups(accountID, member.UserID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListGroupMembers returns the members of a group of an account
+func (u User) ListGroupMembers(accountID, groupID string) ([]model.GroupMember, error) {
+	if _, err := u.GetGroup(accountID, groupID); err != nil {
+		return nil, err
+	}
+	return DB.ListGroupMembers(u.conf.Name, groupID)
+}
+
+// AddGroupMember adds a user of the account to one of its groups
+func (u User) AddGroupMember(accountID, groupID, userID string) error {
+	if _, err := u.GetGroup(accountID, groupID); err != nil {
+		return err
+	}
+
+	if _, err := u.membershipToken(accountID, userID); err != nil {
+		return ErrNotAccountMember
+	}
+
+	member := model.GroupMember{GroupID: groupID, AccountID: accountID, UserID: userID, Added: time.Now()}
+	if err := DB.AddGroupMember(u.conf.Name, member); err != nil {
+		return err
+	}
+	return u.refreshGroups(accountID, userID)
+}
+
+// RemoveGroupMember removes a user from a group of the account
+func (u User) RemoveGroupMember(accountID, groupID, userID string) error {
+	if _, err := u.GetGroup(accountID, groupID); err != nil {
+		return err
+	}
+
+	if err := DB.RemoveGroupMember(u.conf.Name, groupID, userID); err != nil {
+		return err
+	}
+	return u.refreshGroups(accountID, userID)
+}
+
+// membershipToken returns the token of a user's membership in an account
+func (u User) membershipToken(accountID, userID string) (string, error) {
+	tok, err := DB.GetUserByID(u.conf.Name, accountID, userID)
+	if err == nil && tok.AccountID == accountID {
+		return tok.Token, nil
+	}
+
+	assoc, err := DB.GetAccountUser(u.conf.Name, userID, accountID)
+	if err != nil {
+		return "", err
+	}
+	return assoc.Token, nil
+}
+
+// refreshGroups updates the groups of the cached user of a membership so
+// their requests use the new groups right away
+func (u User) refreshGroups(accountID, userID string) error {
+	token, err := u.membershipToken(accountID, userID)
+	if err != nil {
+		// the user was removed from the account
+		return nil
+	}
+
+	key := userID + "|" + token
+
+	var auth model.Auth
+	if err := Cache.GetTyped(key, &auth); err != nil {
+		// not cached, the groups are loaded on the next request
+		return nil
+	}
+
+	groups, err := DB.ListUserGroups(u.conf.Name, accountID, userID)
+	if err != nil {
+		return err
+	}
+
+	auth.Groups = groups
+	return Cache.SetTyped(key, auth)
+}
@@ -0,0 +1,62 @@
+package backend_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestGroupScopedCollection(t *testing.T) {
+	usr := backend.Membership(base)
+
+	_, manager, err := usr.CreateAccountAndUser("groups-manager@test.com", "groups1234!", 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, member, err := usr.CreateUser(manager.AccountID, "groups-member@test.com", "groups1234!", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := usr.CreateGroup(manager.AccountID, "Support")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := usr.AddGroupMember(manager.AccountID, g.ID, adminAuth.UserID); !errors.Is(err, backend.ErrNotAccountMember) {
+		t.Errorf("expected ErrNotAccountMember got %v", err)
+	}
+
+	memberAuth := model.Auth{AccountID: member.AccountID, UserID: member.ID, Email: member.Email, Role: member.Role, Token: member.Token}
+
+	doc := map[string]any{"title": "shared", model.FieldGroupID: g.ID}
+	if _, err := backend.Collection[map[string]any](memberAuth, base, "group_tickets").Create(doc); !errors.Is(err, model.ErrNotGroupMember) {
+		t.Errorf("expected ErrNotGroupMember got %v", err)
+	}
+
+	if err := usr.AddGroupMember(manager.AccountID, g.ID, member.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	memberAuth.Groups = []string{g.ID}
+	if _, err := backend.Collection[map[string]any](memberAuth, base, "group_tickets").Create(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	members, err := usr.ListGroupMembers(manager.AccountID, g.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(members) != 1 || members[0].UserID != member.ID {
+		t.Errorf("expected the member to be listed got %v", members)
+	}
+
+	if err := usr.DeleteGroup(manager.AccountID, g.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := usr.ListGroupMembers(manager.AccountID, g.ID); !errors.Is(err, backend.ErrGroupNotFound) {
+		t.Errorf("expected ErrGroupNotFound got %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
+)
+
+// guestPurgeInterval is how often the abandoned guests of a database are
+// removed
+const guestPurgeInterval = time.Hour
+
+var (
+	ErrGuestsDisabled = errors.New("guest users are not enabled for this database")
+	ErrNotGuest       = errors.New("user is not a guest")
+	ErrGuestUpgrade   = errors.New("guest users must be upgraded to change their email")
+)
+
+// guestsPurged holds the last time the abandoned guests of a database were
+// removed
+var guestsPurged sync.Map
+
+func guestPolicyCacheKey(dbName string) string {
+	return "guest-policy-" + dbName
+}
+
+func guestMagicLinkCacheKey(dbName, email string) string {
+	return "ml-guest-" + dbName + "-" + email
+}
+
+// GetGuestPolicy returns the guest policy of the database, it is cached
+// until changed.
+func (u User) GetGuestPolicy() (policy model.GuestPolicy, err error) {
+	if err = Cache.GetTyped(guestPolicyCacheKey(u.conf.Name), &policy); err == nil {
+		return
+	}
+
+	b, err := DB.GetSetting(u.conf.Name, model.SettingGuestPolicy)
+	if err != nil {
+		return
+	} else if b != nil {
+		if err = json.Unmarshal(b, &policy); err != nil {
+			return
+		}
+	}
+
+	err = Cache.SetTyped(guestPolicyCacheKey(u.conf.Name), policy)
+	return
+}
+
+// SetGuestPolicy sets the guest policy of the database. Existing guests keep
+// working when guests are disabled.
+func (u User) SetGuestPolicy(policy model.GuestPolicy) error {
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	if err := DB.SetSetting(u.conf.Name, model.SettingGuestPolicy, b); err != nil {
+		return err
+	}
+	return Cache.SetTyped(guestPolicyCacheKey(u.conf.Name), policy)
+}
+
+// CreateGuest creates an anonymous user with its own account and returns
+// its session tokens. Guests own their data like any user until they are
+// upgraded with UpgradeGuest, keeping their user and account ids.
+func (u User) CreateGuest() (model.SessionTokens, error) {
+	policy, err := u.GetGuestPolicy()
+	if err != nil {
+		return model.SessionTokens{}, err
+	} else if !policy.Enabled {
+		return model.SessionTokens{}, ErrGuestsDisabled
+	}
+
+	if err := u.purgeGuestsEvery(policy); err != nil {
+		slog.Error("error purging abandoned guests", "db", u.conf.Name, "error", err)
+	}
+
+	email := "guest-" + strings.ToLower(internal.RandStringRunes(24)) + "@" + model.GuestEmailDomain
+
+	// guests cannot sign in with a password until upgraded
+	tok, err := u.createAccountAndUser(email, internal.RandStringRunes(32), 50)
+	if err != nil {
+		return model.SessionTokens{}, err
+	}
+
+	now := time.Now()
+	g := model.Guest{UserID: tok.ID, AccountID: tok.AccountID, Created: now, LastSeen: now}
+	if err := DB.SaveGuest(u.conf.Name, g); err != nil {
+		return model.SessionTokens{}, err
+	}
+
+	return u.issueSession(tok, "", true)
+}
+
+// UpgradeGuest turns a guest into a regular user signing in with an email
+// and password, the guest's user and account ids and data are kept. A new
+// session token is returned, model.ErrEmailNotVerified is returned when the
+// user must verify their email first.
+func (u User) UpgradeGuest(auth model.Auth, email, password string) (string, error) {
+	tok, err := u.upgradeGuest(auth, email, password, false)
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.verifyNewUser(tok); err != nil {
+		return "", err
+	}
+
+	tokens, err := u.issueSession(tok, "", false)
+	if err != nil {
+		return "", err
+	}
+	return tokens.Token, nil
+}
+
+// UpgradeGuestVerified upgrades a guest with an email verified by an
+// external provider, like OAuth sign-ins, and returns a new session token
+func (u User) UpgradeGuestVerified(auth model.Auth, email, password string) (string, error) {
+	tok, err := u.upgradeGuest(auth, email, password, true)
+	if err != nil {
+		return "", err
+	}
+
+	return u.externalSession(tok)
+}
+
+// SetupGuestMagicLink sends a magic link upgrading the guest with the email
+// once validated by ValidateMagicLink
+func (u User) SetupGuestMagicLink(auth model.Auth, data MagicLinkData) error {
+	data.Email = strings.ToLower(data.Email)
+
+	if err := u.checkGuestUpgrade(auth, data.Email); err != nil {
+		return err
+	}
+
+	if err := Cache.Set(guestMagicLinkCacheKey(u.conf.Name, data.Email), auth.UserID); err != nil {
+		return err
+	}
+	return u.SetupMagicLink(data)
+}
+
+// magicLinkGuest returns the guest upgraded by a validated magic link of an
+// email not used by any user
+func (u User) magicLinkGuest(email string) (model.User, error) {
+	key := guestMagicLinkCacheKey(u.conf.Name, email)
+
+	userID, err := Cache.Get(key)
+	if err != nil || len(userID) == 0 {
+		return model.User{}, ErrNotGuest
+	}
+
+	if err := Cache.Delete(key); err != nil {
+		return model.User{}, err
+	}
+
+	g, err := DB.GetGuest(u.conf.Name, userID)
+	if err != nil {
+		return model.User{}, err
+	} else if len(g.UserID) == 0 {
+		return model.User{}, ErrNotGuest
+	}
+
+	tok, err := DB.GetUserByID(u.conf.Name, g.AccountID, g.UserID)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	auth := model.Auth{AccountID: tok.AccountID, UserID: tok.ID, Email: tok.Email, Role: tok.Role, Token: tok.Token}
+	return u.upgradeGuest(auth, email, internal.RandStringRunes(32), true)
+}
+
+// checkGuestUpgrade returns an error if the user is not a guest or the email
+// cannot be used
+func (u User) checkGuestUpgrade(auth model.Auth, email string) error {
+	g, err := DB.GetGuest(u.conf.Name, auth.UserID)
+	if err != nil {
+		return err
+	} else if len(g.UserID) == 0 {
+		return ErrNotGuest
+	}
+
+	if !strings.Contains(email, "@") || model.IsGuestEmail(email) {
+		return errors.New("invalid email")
+	}
+
+	exists, err := DB.UserEmailExists(u.conf.Name, email)
+	if err != nil {
+		return err
+	} else if exists {
+		return ErrEmailAlreadyInUse
+	}
+	return nil
+}
+
+// upgradeGuest sets the email and password of a guest and removes its guest
+// record, verified saves the email as verified
+func (u User) upgradeGuest(auth model.Auth, email, password string, verified bool) (model.User, error) {
+	email = strings.ToLower(email)
+
+	if err := u.checkGuestUpgrade(auth, email); err != nil {
+		return model.User{}, err
+	}
+
+	if err := u.ValidatePassword(email, password); err != nil {
+		return model.User{}, err
+	}
+
+	hash, err := u.HashPassword(password)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if err := DB.UserSetPassword(u.conf.Name, auth.UserID, hash); err != nil {
+		return model.User{}, err
+	}
+
+	var v *model.EmailVerification
+	if verified {
+		v = &model.EmailVerification{UserID: auth.UserID, Email: email, Verified: time.Now()}
+	}
+
+	if err := u.switchEmail(auth, email, v); err != nil {
+		return model.User{}, err
+	}
+
+	if err := DB.DeleteGuest(u.conf.Name, auth.UserID); err != nil {
+		return model.User{}, err
+	}
+
+	return DB.FindUserByEmail(u.conf.Name, email)
+}
+
+// touchGuest updates the last time a guest was seen, users who are not
+// guests are ignored
+func (u User) touchGuest(userID string, seen time.Time) error {
+	g, err := DB.GetGuest(u.conf.Name, userID)
+	if err != nil || len(g.UserID) == 0 {
+		return err
+	}
+
+	g.LastSeen = seen
+	return DB.SaveGuest(u.conf.Name, g)
+}
+
+// purgeGuestsEvery removes the abandoned guests at most once per
+// guestPurgeInterval
+func (u User) purgeGuestsEvery(policy model.GuestPolicy) error {
+	now := time.Now()
+	if last, ok := guestsPurged.Load(u.conf.Name); ok && now.Sub(last.(time.Time)) < guestPurgeInterval {
+		return nil
+	}
+	guestsPurged.Store(u.conf.Name, now)
+
+	_, err := u.PurgeGuests(now.Add(-policy.Retention()))
+	return err
+}
+
+// PurgeGuests removes the accounts and data of the guests not seen since a
+// time and returns how many were removed
+func (u User) PurgeGuests(before time.Time) (int, error) {
+	guests, err := DB.ListGuestsSeenBefore(u.conf.Name, before)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, g := range guests {
+		if err := u.RevokeAllSessions(g.UserID); err != nil {
+			return i, err
+		}
+		if err := u.DeleteAccount(g.AccountID); err != nil {
+			return i, err
+		}
+		if err := DB.DeleteGuest(u.conf.Name, g.UserID); err != nil {
+			return i, err
+		}
+	}
+	return len(guests), nil
+}
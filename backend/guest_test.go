@@ -0,0 +1,180 @@
+package backend_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+// guestAuth returns the authenticated guest of a session token
+func guestAuth(t *testing.T, tokens model.SessionTokens) model.Auth {
+	t.Helper()
+
+	s, err := backend.DB.GetSession(base.Name, tokens.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := backend.DB.GetUserByID(base.Name, s.AccountID, s.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return model.Auth{AccountID: tok.AccountID, UserID: tok.ID, Email: tok.Email, Role: tok.Role, Token: tok.Token}
+}
+
+func TestGuestUpgrade(t *testing.T) {
+	usr := backend.Membership(base)
+
+	if _, err := usr.CreateGuest(); !errors.Is(err, backend.ErrGuestsDisabled) {
+		t.Fatalf("expected ErrGuestsDisabled got %v", err)
+	}
+
+	if err := usr.SetGuestPolicy(model.GuestPolicy{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.SetGuestPolicy(model.GuestPolicy{}) })
+
+	tokens, err := usr.CreateGuest()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(tokens.Token) == 0 || len(tokens.RefreshToken) == 0 {
+		t.Fatalf("expected session tokens with a refresh token got %v", tokens)
+	}
+
+	auth := guestAuth(t, tokens)
+	if !model.IsGuestEmail(auth.Email) {
+		t.Errorf("expected a guest email got %s", auth.Email)
+	}
+
+	if err := usr.ChangeEmail(auth, "guest-change@test.com"); !errors.Is(err, backend.ErrGuestUpgrade) {
+		t.Errorf("expected ErrGuestUpgrade got %v", err)
+	}
+
+	if _, _, err := usr.CreateAccountAndUser("guest-taken@test.com", "guest1234!", 50); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := usr.UpgradeGuest(auth, "guest-taken@test.com", "guest1234!"); !errors.Is(err, backend.ErrEmailAlreadyInUse) {
+		t.Errorf("expected ErrEmailAlreadyInUse got %v", err)
+	}
+
+	if _, err := usr.UpgradeGuest(auth, "guest-upgrade@test.com", "guest1234!"); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := backend.DB.FindUserByEmail(base.Name, "guest-upgrade@test.com")
+	if err != nil {
+		t.Fatal(err)
+	} else if tok.ID != auth.UserID || tok.AccountID != auth.AccountID {
+		t.Errorf("expected the guest ids to be kept got %v", tok)
+	}
+
+	if g, err := backend.DB.GetGuest(base.Name, auth.UserID); err != nil {
+		t.Fatal(err)
+	} else if len(g.UserID) > 0 {
+		t.Errorf("expected the guest record to be removed got %v", g)
+	}
+
+	if _, err := usr.Authenticate("guest-upgrade@test.com", "guest1234!"); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := usr.UpgradeGuest(auth, "guest-again@test.com", "guest1234!"); !errors.Is(err, backend.ErrNotGuest) {
+		t.Errorf("expected ErrNotGuest got %v", err)
+	}
+}
+
+func TestGuestUpgradeMagicLink(t *testing.T) {
+	mailer := &inviteMailer{}
+	prev := backend.Emailer
+	backend.Emailer = mailer
+	t.Cleanup(func() { backend.Emailer = prev })
+
+	usr := backend.Membership(base)
+	if err := usr.SetGuestPolicy(model.GuestPolicy{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.SetGuestPolicy(model.GuestPolicy{}) })
+
+	tokens, err := usr.CreateGuest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := guestAuth(t, tokens)
+
+	const email = "guest-magic@test.com"
+	data := backend.MagicLinkData{Email: email, Body: "[link]", MagicLink: "https://app.test/magic"}
+	if err := usr.SetupGuestMagicLink(auth, data); err != nil {
+		t.Fatal(err)
+	}
+
+	// in dev mode, the code is always 666333
+	if _, err := usr.ValidateMagicLink(email, "666333"); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := backend.DB.FindUserByEmail(base.Name, email)
+	if err != nil {
+		t.Fatal(err)
+	} else if tok.ID != auth.UserID {
+		t.Errorf("expected the guest %s to be upgraded got %s", auth.UserID, tok.ID)
+	}
+
+	if v, err := backend.DB.GetEmailVerification(base.Name, tok.ID); err != nil {
+		t.Fatal(err)
+	} else if !v.Verifies(email) {
+		t.Errorf("expected %s to be verified got %v", email, v)
+	}
+}
+
+func TestPurgeGuests(t *testing.T) {
+	usr := backend.Membership(base)
+	if err := usr.SetGuestPolicy(model.GuestPolicy{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.SetGuestPolicy(model.GuestPolicy{}) })
+
+	abandoned, err := usr.CreateGuest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	active, err := usr.CreateGuest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := guestAuth(t, abandoned)
+	for _, tokens := range []model.SessionTokens{abandoned, active} {
+		g, err := backend.DB.GetGuest(base.Name, guestAuth(t, tokens).UserID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.LastSeen = time.Now().Add(-48 * time.Hour)
+		if err := backend.DB.SaveGuest(base.Name, g); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// refreshing the session marks the guest as seen
+	refreshed, err := usr.RefreshSession(active.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := usr.PurgeGuests(time.Now().Add(-24 * time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if n == 0 {
+		t.Error("expected the abandoned guest to be removed")
+	}
+
+	if _, err := backend.DB.FindUserByEmail(base.Name, old.Email); err == nil {
+		t.Error("expected the abandoned guest user to be removed")
+	}
+
+	if _, err := backend.DB.FindUserByEmail(base.Name, guestAuth(t, refreshed).Email); err != nil {
+		t.Errorf("expected the active guest to be kept got %v", err)
+	}
+}
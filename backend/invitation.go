@@ -0,0 +1,420 @@
+package backend
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/staticbackendhq/core/email"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	// systemInvitationTrigger is the function trigger receiving the
+	// invite_sent and invite_accepted events
+	systemInvitationTrigger = "sys-sb_invitations"
+
+	// an invitation can be resent once per minute
+	invitationResendDelay = time.Minute
+
+	defaultInvitationSubject = "You have been invited"
+	defaultInvitationBody    = `<p>You have been invited to join an account, follow this link to accept the invitation:</p><p><a href="[link]">[link]</a></p>`
+)
+
+var (
+	ErrInvalidInvitation      = errors.New("invalid or expired invitation")
+	ErrAlreadyInvited         = errors.New("this email already has a pending invitation")
+	ErrAlreadyMember          = errors.New("this email is already a member of the account")
+	ErrInvitationRole         = errors.New("cannot invite with a role higher than yours")
+	ErrInvitationRecentlySent = errors.New("the invitation was sent less than a minute ago")
+)
+
+// invitationKey signs the invitation links, it is derived from APP_SECRET
+// so links survive restarts
+var invitationKey = sync.OnceValue(func() []byte {
+	if len(Config.AppSecret) > 0 {
+		key, err := hkdf.Key(sha256.New, []byte(Config.AppSecret), nil, "invitations", 32)
+		if err == nil {
+			return key
+		}
+	}
+
+	slog.Warn("APP_SECRET is not set, invitation links will be invalid after a restart")
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+})
+
+// GetInvitationPolicy returns the invitation policy of the database
+func (u User) GetInvitationPolicy() (policy model.InvitationPolicy, err error) {
+	b, err := DB.GetSetting(u.conf.Name, model.SettingInvitations)
+	if err != nil || b == nil {
+		return
+	}
+
+	err = json.Unmarshal(b, &policy)
+	return
+}
+
+// SetInvitationPolicy sets the invitation policy of the database
+func (u User) SetInvitationPolicy(policy model.InvitationPolicy) error {
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	return DB.SetSetting(u.conf.Name, model.SettingInvitations, b)
+}
+
+// Invite emails a signed link inviting an email to join the account of auth
+// with a role no higher than the inviter's.
+func (u User) Invite(auth model.Auth, data model.NewInvitation) (model.Invitation, error) {
+	data.Email = strings.ToLower(data.Email)
+
+	if data.Role > auth.Role {
+		return model.Invitation{}, ErrInvitationRole
+	}
+
+	if err := u.checkNotMember(auth.AccountID, data.Email); err != nil {
+		return model.Invitation{}, err
+	}
+
+	pending, err := DB.ListInvitations(u.conf.Name, auth.AccountID)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+
+	now := time.Now().UTC()
+	for _, inv := range pending {
+		if inv.Email != data.Email {
+			continue
+		} else if now.Before(inv.Expires) {
+			return model.Invitation{}, ErrAlreadyInvited
+		}
+
+		// an expired invitation is replaced by the new one
+		if err := DB.DeleteInvitation(u.conf.Name, inv.ID); err != nil {
+			return model.Invitation{}, err
+		}
+	}
+
+	policy, err := u.GetInvitationPolicy()
+	if err != nil {
+		return model.Invitation{}, err
+	}
+
+	inv := model.Invitation{
+		AccountID: auth.AccountID,
+		Email:     data.Email,
+		Role:      data.Role,
+		InvitedBy: auth.UserID,
+		Created:   now,
+		Sent:      now,
+		Expires:   now.Add(policy.TTL()),
+	}
+
+	id, err := DB.CreateInvitation(u.conf.Name, inv)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+	inv.ID = id
+
+	if err := u.sendInvitation(policy, inv); err != nil {
+		return inv, err
+	}
+
+	u.publishInvitationEvent(model.MsgTypeInviteSent, auth, inv)
+	return inv, nil
+}
+
+// ListInvitations returns the pending invitations of an account
+func (u User) ListInvitations(accountID string) ([]model.Invitation, error) {
+	invitations, err := DB.ListInvitations(u.conf.Name, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if invitations == nil {
+		invitations = []model.Invitation{}
+	}
+	return invitations, nil
+}
+
+// ResendInvitation emails a new link for an invitation of the account of
+// auth and extends its expiry, the previous link stops working.
+func (u User) ResendInvitation(auth model.Auth, id string) (model.Invitation, error) {
+	inv, err := u.getAccountInvitation(auth.AccountID, id)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+
+	now := time.Now().UTC()
+	if now.Sub(inv.Sent) < invitationResendDelay {
+		return model.Invitation{}, ErrInvitationRecentlySent
+	}
+
+	policy, err := u.GetInvitationPolicy()
+	if err != nil {
+		return model.Invitation{}, err
+	}
+
+	inv.Sent = now
+	inv.Expires = now.Add(policy.TTL())
+	if err := DB.UpdateInvitation(u.conf.Name, inv); err != nil {
+		return model.Invitation{}, err
+	}
+
+	if err := u.sendInvitation(policy, inv); err != nil {
+		return inv, err
+	}
+
+	u.publishInvitationEvent(model.MsgTypeInviteSent, auth, inv)
+	return inv, nil
+}
+
+// RevokeInvitation removes an invitation of the account of auth, its link
+// stops working.
+func (u User) RevokeInvitation(auth model.Auth, id string) error {
+	if _, err := u.getAccountInvitation(auth.AccountID, id); err != nil {
+		return err
+	}
+	return DB.DeleteInvitation(u.conf.Name, id)
+}
+
+// GetInvitationByToken returns the invitation of a signed link, it returns
+// ErrInvalidInvitation when the link was tampered with, resent, revoked or
+// is expired.
+func (u User) GetInvitationByToken(token string) (model.Invitation, error) {
+	id, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return model.Invitation{}, ErrInvalidInvitation
+	}
+
+	inv, err := DB.GetInvitation(u.conf.Name, id)
+	if err != nil {
+		return model.Invitation{}, ErrInvalidInvitation
+	}
+
+	if !hmac.Equal([]byte(token), []byte(u.invitationToken(inv))) {
+		return model.Invitation{}, ErrInvalidInvitation
+	} else if time.Now().After(inv.Expires) {
+		return model.Invitation{}, ErrInvalidInvitation
+	}
+	return inv, nil
+}
+
+// AcceptInvitation accepts an invitation and returns a session token in the
+// invited account. A user is created with the password when the email is
+// not registered, otherwise the password must be the one of the existing
+// user who is added to the account.
+func (u User) AcceptInvitation(data model.AcceptInvitation) (string, error) {
+	inv, err := u.GetInvitationByToken(data.Token)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data.Password) == 0 {
+		return "", errors.New("missing password")
+	}
+
+	exists, err := DB.UserEmailExists(u.conf.Name, inv.Email)
+	if err != nil {
+		return "", err
+	}
+
+	var tok model.User
+	if exists {
+		tok, err = u.joinAccount(inv, data.Password)
+	} else if err = u.ValidatePassword(inv.Email, data.Password); err == nil {
+		tok, err = u.createUser(inv.AccountID, inv.Email, data.Password, inv.Role)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := DB.DeleteInvitation(u.conf.Name, inv.ID); err != nil {
+		return "", err
+	}
+
+	// the invitation link proves the user owns their email
+	if err := u.markInvitedEmailVerified(tok); err != nil {
+		return "", err
+	}
+
+	auth := model.Auth{
+		AccountID: inv.AccountID,
+		UserID:    tok.ID,
+		Email:     tok.Email,
+		Role:      inv.Role,
+	}
+	u.publishInvitationEvent(model.MsgTypeInviteAccepted, auth, inv)
+
+	if err := u.challengeIfRequired(tok, inv.AccountID, false); err != nil {
+		return "", err
+	}
+
+	tokens, err := u.issueSession(tok, inv.AccountID, false)
+	if err != nil {
+		return "", err
+	}
+	return tokens.Token, nil
+}
+
+// joinAccount checks the password of the existing user invited and adds
+// them to the account with the invited role
+func (u User) joinAccount(inv model.Invitation, password string) (model.User, error) {
+	guard, err := u.GuardLogin(inv.Email)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	tok, err := DB.FindUserByEmail(u.conf.Name, inv.Email)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if err := u.verifyPassword(tok, password); err != nil {
+		return model.User{}, guard.Fail(err)
+	}
+	guard.Succeed()
+
+	if err := u.checkNotMember(inv.AccountID, inv.Email); err != nil {
+		return model.User{}, err
+	}
+
+	assoc := model.AccountUser{
+		UserID:    tok.ID,
+		AccountID: inv.AccountID,
+		Email:     tok.Email,
+		Role:      inv.Role,
+		Token:     DB.NewID(),
+	}
+	if _, err := DB.AddAccountUser(u.conf.Name, assoc); err != nil {
+		return model.User{}, err
+	}
+	return tok, nil
+}
+
+// checkNotMember returns ErrAlreadyMember if the email belongs to a user of
+// the account, either its home account or an association
+func (u User) checkNotMember(accountID, email string) error {
+	exists, err := DB.UserEmailExists(u.conf.Name, email)
+	if err != nil || !exists {
+		return err
+	}
+
+	tok, err := DB.FindUserByEmail(u.conf.Name, email)
+	if err != nil {
+		return err
+	} else if tok.AccountID == accountID {
+		return ErrAlreadyMember
+	}
+
+	associated, err := DB.AssociationExists(u.conf.Name, tok.ID, accountID)
+	if err != nil {
+		return err
+	} else if associated {
+		return ErrAlreadyMember
+	}
+	return nil
+}
+
+func (u User) markInvitedEmailVerified(tok model.User) error {
+	v, err := DB.GetEmailVerification(u.conf.Name, tok.ID)
+	if err != nil {
+		return err
+	} else if v.Verifies(tok.Email) {
+		return nil
+	}
+
+	v = model.EmailVerification{UserID: tok.ID, Email: tok.Email, Verified: time.Now()}
+	if err := DB.SaveEmailVerification(u.conf.Name, v); err != nil {
+		return err
+	}
+
+	// the cached user is reloaded with their verified email
+	return Cache.Delete(tok.ID + "|" + tok.Token)
+}
+
+func (u User) getAccountInvitation(accountID, id string) (model.Invitation, error) {
+	inv, err := DB.GetInvitation(u.conf.Name, id)
+	if err != nil || inv.AccountID != accountID {
+		return model.Invitation{}, ErrInvalidInvitation
+	}
+	return inv, nil
+}
+
+// invitationToken returns the signed token of an invitation's link. The
+// expiry is signed, resending an invitation invalidates its previous link.
+func (u User) invitationToken(inv model.Invitation) string {
+	mac := hmac.New(sha256.New, invitationKey())
+	fmt.Fprintf(mac, "%s|%s|%s|%s|%d|%d", u.conf.Name, inv.ID, inv.AccountID, inv.Email, inv.Role, inv.Expires.Unix())
+	return inv.ID + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (u User) sendInvitation(policy model.InvitationPolicy, inv model.Invitation) error {
+	link := policy.Link
+	qs := url.Values{"token": {u.invitationToken(inv)}}
+	if len(link) == 0 {
+		link = Config.AppURL + "/invitations/accept"
+		qs.Set("sbpk", u.conf.ID)
+	}
+
+	if strings.Contains(link, "?") {
+		link += "&" + qs.Encode()
+	} else {
+		link += "?" + qs.Encode()
+	}
+
+	body := policy.Body
+	if len(body) == 0 {
+		body = defaultInvitationBody
+	}
+
+	mail := email.SendMailData{
+		From:     policy.FromEmail,
+		FromName: policy.FromName,
+		To:       inv.Email,
+		Subject:  policy.Subject,
+		HTMLBody: strings.ReplaceAll(body, "[link]", link),
+	}
+	if len(mail.From) == 0 {
+		mail.From, mail.FromName = Config.FromEmail, Config.FromName
+	}
+	if len(mail.Subject) == 0 {
+		mail.Subject = defaultInvitationSubject
+	}
+	return Emailer.Send(mail)
+}
+
+// publishInvitationEvent triggers the functions of the sys-sb_invitations
+// topic, typ is invite_sent or invite_accepted
+func (u User) publishInvitationEvent(typ string, auth model.Auth, inv model.Invitation) {
+	b, err := json.Marshal(inv)
+	if err != nil {
+		slog.Error("error marshaling invitation event", "error", err)
+		return
+	}
+
+	if err := Cache.Publish(model.Command{
+		Channel: systemInvitationTrigger,
+		Data:    string(b),
+		Type:    typ,
+		Auth:    auth,
+		Base:    u.conf.Name,
+	}); err != nil {
+		slog.Error("error publishing invitation event", "error", err)
+	}
+}
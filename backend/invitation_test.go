@@ -0,0 +1,186 @@
+package backend_test
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/email"
+	"github.com/staticbackendhq/core/model"
+)
+
+type inviteMailer struct {
+	links []string
+}
+
+var inviteLinkRe = regexp.MustCompile(`href="([^"]+)"`)
+
+func (m *inviteMailer) Send(data email.SendMailData) error {
+	if match := inviteLinkRe.FindStringSubmatch(data.HTMLBody); match != nil {
+		m.links = append(m.links, match[1])
+	}
+	return nil
+}
+
+// lastToken returns the token of the last invitation link sent
+func (m *inviteMailer) lastToken(t *testing.T) string {
+	t.Helper()
+
+	if len(m.links) == 0 {
+		t.Fatal("no invitation email was sent")
+	}
+
+	u, err := url.Parse(m.links[len(m.links)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query().Get("token")
+}
+
+func TestInvitations(t *testing.T) {
+	mailer := &inviteMailer{}
+	prev := backend.Emailer
+	backend.Emailer = mailer
+	t.Cleanup(func() { backend.Emailer = prev })
+
+	events := make(chan model.Command, 10)
+	closeSub := make(chan bool)
+	go backend.Cache.Subscribe(events, "", "sys-sb_invitations", closeSub)
+	t.Cleanup(func() { close(closeSub) })
+	time.Sleep(10 * time.Millisecond)
+
+	usr := backend.Membership(base)
+
+	member := model.Auth{AccountID: adminAuth.AccountID, UserID: adminAuth.UserID, Role: 50}
+	if _, err := usr.Invite(member, model.NewInvitation{Email: "invite-owner@test.com", Role: 100}); !errors.Is(err, backend.ErrInvitationRole) {
+		t.Errorf("expected ErrInvitationRole got %v", err)
+	}
+
+	inv, err := usr.Invite(adminAuth, model.NewInvitation{Email: "Invite-New@test.com", Role: 50})
+	if err != nil {
+		t.Fatal(err)
+	} else if inv.Email != "invite-new@test.com" {
+		t.Errorf("expected the email to be lowercased got %s", inv.Email)
+	}
+
+	if _, err := usr.Invite(adminAuth, model.NewInvitation{Email: "invite-new@test.com"}); !errors.Is(err, backend.ErrAlreadyInvited) {
+		t.Errorf("expected ErrAlreadyInvited got %v", err)
+	}
+
+	if _, err := usr.ResendInvitation(adminAuth, inv.ID); !errors.Is(err, backend.ErrInvitationRecentlySent) {
+		t.Errorf("expected ErrInvitationRecentlySent got %v", err)
+	}
+
+	token := mailer.lastToken(t)
+	if _, err := usr.GetInvitationByToken(token + "x"); !errors.Is(err, backend.ErrInvalidInvitation) {
+		t.Errorf("expected a tampered link to be refused got %v", err)
+	}
+
+	jwt, err := usr.AcceptInvitation(model.AcceptInvitation{Token: token, Password: "invited1234!"})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(jwt) == 0 {
+		t.Error("expected a session token")
+	}
+
+	tok, err := backend.DB.FindUserByEmail(base.Name, "invite-new@test.com")
+	if err != nil {
+		t.Fatal(err)
+	} else if tok.AccountID != adminAuth.AccountID || tok.Role != 50 {
+		t.Errorf("expected the user to join %s with role 50 got %v", adminAuth.AccountID, tok)
+	}
+
+	if v, err := backend.DB.GetEmailVerification(base.Name, tok.ID); err != nil {
+		t.Fatal(err)
+	} else if !v.Verifies(tok.Email) {
+		t.Errorf("expected the invited email to be verified got %v", v)
+	}
+
+	if _, err := usr.AcceptInvitation(model.AcceptInvitation{Token: token, Password: "invited1234!"}); !errors.Is(err, backend.ErrInvalidInvitation) {
+		t.Errorf("expected an accepted invitation to be refused got %v", err)
+	}
+
+	var types []string
+	for len(types) < 2 {
+		select {
+		case msg := <-events:
+			types = append(types, msg.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("expected invite_sent and invite_accepted events got %v", types)
+		}
+	}
+	if strings.Join(types, ",") != "invite_sent,invite_accepted" {
+		t.Errorf("expected invite_sent and invite_accepted events got %v", types)
+	}
+}
+
+func TestInvitationExistingUser(t *testing.T) {
+	mailer := &inviteMailer{}
+	prev := backend.Emailer
+	backend.Emailer = mailer
+	t.Cleanup(func() { backend.Emailer = prev })
+
+	const (
+		email    = "invite-existing@test.com"
+		password = "existing1234!"
+	)
+
+	usr := backend.Membership(base)
+
+	// a failed attempt would delay the next one
+	if err := usr.SetLoginProtectionPolicy(model.LoginProtectionPolicy{Disabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.SetLoginProtectionPolicy(model.LoginProtectionPolicy{}) })
+
+	if _, _, err := usr.CreateAccountAndUser(email, password, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	inv, err := usr.Invite(adminAuth, model.NewInvitation{Email: email, Role: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := usr.RevokeInvitation(adminAuth, inv.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	revoked := mailer.lastToken(t)
+	if _, err := usr.AcceptInvitation(model.AcceptInvitation{Token: revoked, Password: password}); !errors.Is(err, backend.ErrInvalidInvitation) {
+		t.Errorf("expected a revoked invitation to be refused got %v", err)
+	}
+
+	if _, err := usr.Invite(adminAuth, model.NewInvitation{Email: email, Role: 10}); err != nil {
+		t.Fatal(err)
+	}
+	token := mailer.lastToken(t)
+
+	if _, err := usr.AcceptInvitation(model.AcceptInvitation{Token: token, Password: "wrong"}); err == nil {
+		t.Error("expected a wrong password to be refused")
+	}
+
+	if _, err := usr.AcceptInvitation(model.AcceptInvitation{Token: token, Password: password}); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := backend.DB.FindUserByEmail(base.Name, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assoc, err := backend.DB.GetAccountUser(base.Name, tok.ID, adminAuth.AccountID)
+	if err != nil {
+		t.Fatal(err)
+	} else if assoc.Role != 10 {
+		t.Errorf("expected the association role to be 10 got %d", assoc.Role)
+	}
+
+	if _, err := usr.Invite(adminAuth, model.NewInvitation{Email: email}); !errors.Is(err, backend.ErrAlreadyMember) {
+		t.Errorf("expected ErrAlreadyMember got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	// jwtKeysRefreshInterval is how often instances reload the signing keys,
+	// a new key is only used for signing after this delay.
+	jwtKeysRefreshInterval = 5 * time.Minute
+
+	// jwtLegacyWindow is how long HS256 tokens are accepted once the first
+	// signing key is created, all tokens signed before then have expired.
+	jwtLegacyWindow = sessionTokenTTL + jwtKeysRefreshInterval
+)
+
+// jwtSigningAlg returns the configured session token signing algorithm
+func jwtSigningAlg() string {
+	if len(config.Current.JWTSigningAlg) == 0 {
+		return model.JWTAlgRS256
+	}
+	return config.Current.JWTSigningAlg
+}
+
+// jwtKeyRotation returns how long a signing key is used before rotation
+func jwtKeyRotation() time.Duration {
+	days := config.Current.JWTKeyRotationDays
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// setupJWTKeys loads the session token signing keys and keeps them in sync
+// with the other instances. The primary instance creates and rotates the keys.
+func setupJWTKeys(ctx context.Context, isPrimary bool) error {
+	switch alg := jwtSigningAlg(); {
+	case alg == model.JWTAlgHS256:
+		return nil
+	case len(config.Current.AppSecret) == 0:
+		slog.Warn("APP_SECRET is required to store the token signing keys, tokens are signed with HS256", "alg", alg)
+		return nil
+	}
+
+	model.JWTKeys.Reload = ReloadJWTKeys
+
+	syncKeys := ReloadJWTKeys
+	if isPrimary {
+		syncKeys = RotateJWTKeys
+	}
+
+	if err := syncKeys(); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(jwtKeysRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := syncKeys(); err != nil {
+					slog.Error("error syncing token signing keys", "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// ReloadJWTKeys loads the session token signing keys from the database
+func ReloadJWTKeys() error {
+	keys, err := DB.ListSigningKeys()
+	if err != nil {
+		return err
+	}
+
+	start, err := jwtMigrationStart(keys)
+	if err != nil {
+		return err
+	}
+	return model.JWTKeys.Load(keys, jwtKeysRefreshInterval, start.Add(jwtLegacyWindow))
+}
+
+// jwtMigrationStart returns when the first signing key was created. It is
+// stored once since the oldest keys are removed by the rotation, the oldest
+// remaining key is used until the primary instance stores it.
+func jwtMigrationStart(keys []model.SigningKey) (time.Time, error) {
+	var start time.Time
+
+	b, err := DB.GetSetting(model.SystemID, model.SettingJWTMigrationStart)
+	if err != nil {
+		return start, err
+	} else if len(b) > 0 {
+		err := json.Unmarshal(b, &start)
+		return start, err
+	}
+
+	if len(keys) > 0 {
+		start = keys[0].Created
+	}
+	return start, nil
+}
+
+// RotateJWTKeys creates a signing key when the current one is older than
+// JWT_KEY_ROTATION_DAYS or uses another algorithm. Replaced keys are removed
+// once all tokens they signed have expired.
+func RotateJWTKeys() error {
+	keys, err := DB.ListSigningKeys()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if n := len(keys); n == 0 || now.Sub(keys[n-1].Created) >= jwtKeyRotation() || keys[n-1].Algorithm != jwtSigningAlg() {
+		k, err := model.NewSigningKey(jwtSigningAlg())
+		if err != nil {
+			return err
+		}
+
+		if err := DB.AddSigningKey(k); err != nil {
+			return err
+		}
+
+		slog.Info("new token signing key", "kid", k.ID, "alg", k.Algorithm)
+		keys = append(keys, k)
+	}
+
+	// the migration start is only stored once, before the first key can be
+	// removed
+	b, err := json.Marshal(keys[0].Created)
+	if err != nil {
+		return err
+	}
+	if _, err := DB.AddSetting(model.SystemID, model.SettingJWTMigrationStart, b); err != nil {
+		return err
+	}
+
+	// a key stops signing once the next one is published
+	for i := 0; i < len(keys)-1; i++ {
+		if now.Before(keys[i+1].Created.Add(jwtKeysRefreshInterval + sessionTokenTTL)) {
+			break
+		}
+
+		if err := DB.DeleteSigningKey(keys[i].ID); err != nil {
+			return err
+		}
+	}
+
+	return ReloadJWTKeys()
+}
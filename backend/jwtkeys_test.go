@@ -0,0 +1,139 @@
+package backend_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestRotateJWTKeys(t *testing.T) {
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+
+	t.Cleanup(func() {
+		config.Current.JWTSigningAlg = ""
+
+		keys, _ := backend.DB.ListSigningKeys()
+		for _, k := range keys {
+			_ = backend.DB.DeleteSigningKey(k.ID)
+		}
+		_ = backend.ReloadJWTKeys()
+	})
+
+	// the current key is due for rotation and the one it replaced has no
+	// valid tokens left
+	for _, age := range []time.Duration{60 * 24 * time.Hour, 40 * 24 * time.Hour} {
+		k, err := model.NewSigningKey(model.JWTAlgRS256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.Created = time.Now().Add(-age)
+
+		if err := backend.DB.AddSigningKey(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := backend.RotateJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := backend.DB.ListSigningKeys()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(keys) != 2 {
+		t.Fatalf("expected the expired key to be removed and a new key created got %d keys", len(keys))
+	} else if time.Since(keys[1].Created) > time.Minute {
+		t.Errorf("expected a new key to be created got %v", keys[1])
+	}
+
+	// the previous key keeps signing until the new one is published
+	if kid := model.JWTKeys.KeyID(); kid != keys[0].ID {
+		t.Errorf("expected %s to sign until %s is published got %s", keys[0].ID, keys[1].ID, kid)
+	}
+
+	token, err := backend.GetJWT("user-id|token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pl model.JWTPayload
+	if hd, err := model.JWTKeys.Verify(token, &pl); err != nil {
+		t.Fatal(err)
+	} else if hd.KeyID != keys[0].ID || hd.Algorithm != model.JWTAlgRS256 {
+		t.Errorf("expected token signed by %s got %v", keys[0].ID, hd)
+	}
+
+	if err := backend.RotateJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	if check, err := backend.DB.ListSigningKeys(); err != nil {
+		t.Fatal(err)
+	} else if len(check) != 2 {
+		t.Errorf("expected no rotation before the rotation period got %d keys", len(check))
+	}
+
+	config.Current.JWTSigningAlg = model.JWTAlgEdDSA
+	if err := backend.RotateJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err = backend.DB.ListSigningKeys()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(keys) != 3 || keys[2].Algorithm != model.JWTAlgEdDSA {
+		t.Errorf("expected an EdDSA key when changing the algorithm got %v", keys)
+	}
+}
+
+func TestJWTMigrationWindow(t *testing.T) {
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+
+	t.Cleanup(func() {
+		keys, _ := backend.DB.ListSigningKeys()
+		for _, k := range keys {
+			_ = backend.DB.DeleteSigningKey(k.ID)
+		}
+		_ = backend.ReloadJWTKeys()
+	})
+
+	legacy, err := backend.GetJWT("user-id|token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first key was removed by the rotation, the one left is recent
+	start, err := json.Marshal(time.Now().Add(-48 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	} else if err := backend.DB.SetSetting(model.SystemID, model.SettingJWTMigrationStart, start); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := model.NewSigningKey(model.JWTAlgRS256)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := backend.DB.AddSigningKey(k); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backend.RotateJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	var pl model.JWTPayload
+	if _, err := model.JWTKeys.Verify(legacy, &pl); !errors.Is(err, model.ErrLegacyJWTNotAccepted) {
+		t.Errorf("expected HS256 tokens to be refused after the migration window got %v", err)
+	}
+
+	if b, err := backend.DB.GetSetting(model.SystemID, model.SettingJWTMigrationStart); err != nil {
+		t.Fatal(err)
+	} else if string(b) != string(start) {
+		t.Errorf("expected the migration start to be kept got %s", string(b))
+	}
+}
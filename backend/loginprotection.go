@@ -0,0 +1,350 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/email"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	// loginMaxBackoff caps the delay between two failed attempts
+	loginMaxBackoff = 30 * time.Second
+
+	defaultLockoutSubject = "Too many sign in attempts on your account"
+	defaultLockoutBody    = `<p>We blocked sign in attempts to your account from [ip] after too many failures, they are blocked until [until].</p><p>If it was not you, consider changing your password.</p>`
+)
+
+var ErrTooManyLoginAttempts = errors.New("too many failed attempts, please try again later")
+
+// LoginThrottledError is returned when an email and IP address must wait
+// before another attempt. Locked is set during a lockout.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	return ErrTooManyLoginAttempts.Error()
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrTooManyLoginAttempts
+}
+
+// loginAttempts are the recent failures of an email and IP address
+type loginAttempts struct {
+	Email       string    `json:"email"`
+	IP          string    `json:"ip"`
+	Failures    int       `json:"failures"`
+	Last        time.Time `json:"last"`
+	NextAttempt time.Time `json:"nextAttempt"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+func loginAttemptsCacheKey(dbName, email, ip string) string {
+	return fmt.Sprintf("login-attempts-%s-%s|%s", dbName, email, ip)
+}
+
+// loginFailuresCacheKey counts the failures of an email and IP address, it is
+// incremented atomically so concurrent attempts are all counted
+func loginFailuresCacheKey(dbName, email, ip string) string {
+	return fmt.Sprintf("login-failures-%s-%s|%s", dbName, email, ip)
+}
+
+// loginLockCacheKey holds the end of the lockout of an email and IP address,
+// it is only written by the failures reaching the limit
+func loginLockCacheKey(dbName, email, ip string) string {
+	return fmt.Sprintf("login-lock-%s-%s|%s", dbName, email, ip)
+}
+
+func loginProtectionCacheKey(dbName string) string {
+	return "login-protection-" + dbName
+}
+
+// loginLocksCacheKey holds the active lockouts of a database so root can
+// list them
+func loginLocksCacheKey(dbName string) string {
+	return "login-locks-" + dbName
+}
+
+// GetLoginProtectionPolicy returns the brute-force protection policy of the
+// database, it is cached until changed.
+func (u User) GetLoginProtectionPolicy() (policy model.LoginProtectionPolicy, err error) {
+	// requests not tied to a database use the default policy
+	if len(u.conf.Name) == 0 {
+		return
+	}
+
+	if err = Cache.GetTyped(loginProtectionCacheKey(u.conf.Name), &policy); err == nil {
+		return
+	}
+
+	b, err := DB.GetSetting(u.conf.Name, model.SettingLoginProtection)
+	if err != nil {
+		return
+	} else if b != nil {
+		if err = json.Unmarshal(b, &policy); err != nil {
+			return
+		}
+	}
+
+	err = Cache.SetTyped(loginProtectionCacheKey(u.conf.Name), policy)
+	return
+}
+
+// SetLoginProtectionPolicy sets the brute-force protection policy of the
+// database
+func (u User) SetLoginProtectionPolicy(policy model.LoginProtectionPolicy) error {
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	if err := DB.SetSetting(u.conf.Name, model.SettingLoginProtection, b); err != nil {
+		return err
+	}
+	return Cache.SetTyped(loginProtectionCacheKey(u.conf.Name), policy)
+}
+
+// LoginGuard counts the failed attempts of an email from the client IP
+// address, see GuardLogin.
+type LoginGuard struct {
+	u      User
+	policy model.LoginProtectionPolicy
+	key    string
+	st     loginAttempts
+}
+
+// GuardLogin returns the guard of an attempt to sign in as email, or a
+// *LoginThrottledError when the client must wait before trying again. The
+// IP address is the one set with WithClient.
+func (u User) GuardLogin(email string) (*LoginGuard, error) {
+	policy, err := u.GetLoginProtectionPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	email = strings.ToLower(email)
+	g := &LoginGuard{
+		u:      u,
+		policy: policy,
+		key:    loginAttemptsCacheKey(u.conf.Name, email, u.ip),
+		st:     loginAttempts{Email: email, IP: u.ip},
+	}
+	if policy.Disabled {
+		return g, nil
+	}
+
+	now := time.Now()
+
+	var lockedUntil time.Time
+	if err := Cache.GetTyped(loginLockCacheKey(u.conf.Name, email, u.ip), &lockedUntil); err == nil {
+		if now.Before(lockedUntil) {
+			return nil, &LoginThrottledError{RetryAfter: lockedUntil.Sub(now), Locked: true}
+		}
+
+		// failures are forgotten once the lockout is over
+		if err := u.clearLoginAttempts(email, u.ip); err != nil {
+			return nil, err
+		}
+		return g, nil
+	}
+
+	if err := Cache.GetTyped(g.key, &g.st); err != nil {
+		return g, nil
+	}
+
+	if now.Before(g.st.NextAttempt) {
+		return nil, &LoginThrottledError{RetryAfter: g.st.NextAttempt.Sub(now)}
+	}
+
+	// failures are forgotten after a lockout period without attempts
+	if now.Sub(g.st.Last) >= policy.Lockout() {
+		if err := u.clearLoginAttempts(email, u.ip); err != nil {
+			return nil, err
+		}
+		g.st = loginAttempts{Email: email, IP: u.ip}
+	}
+	return g, nil
+}
+
+// Fail records a failed attempt and returns err, or a *LoginThrottledError
+// when the failure locks the email out.
+func (g *LoginGuard) Fail(err error) error {
+	if g.policy.Disabled {
+		return err
+	}
+
+	n, cerr := Cache.Inc(loginFailuresCacheKey(g.u.conf.Name, g.st.Email, g.st.IP), 1)
+	if cerr != nil {
+		return cerr
+	}
+
+	now := time.Now()
+	g.st.Failures = int(n)
+	g.st.Last = now
+
+	backoff := loginMaxBackoff
+	if g.st.Failures <= 5 {
+		backoff = min(time.Second<<(g.st.Failures-1), loginMaxBackoff)
+	}
+	g.st.NextAttempt = now.Add(backoff)
+
+	locked := g.st.Failures >= g.policy.MaxFailures()
+	if locked {
+		g.st.LockedUntil = now.Add(g.policy.Lockout())
+
+		lockKey := loginLockCacheKey(g.u.conf.Name, g.st.Email, g.st.IP)
+		if cerr := Cache.SetTyped(lockKey, g.st.LockedUntil); cerr != nil {
+			return cerr
+		}
+	}
+
+	if cerr := Cache.SetTyped(g.key, g.st); cerr != nil {
+		return cerr
+	}
+
+	if !locked {
+		return err
+	}
+
+	if lerr := g.u.addLoginLock(g.st); lerr != nil {
+		slog.Error("error saving login lockout", "error", lerr)
+	}
+	g.u.notifyLockout(g.policy, g.st)
+
+	return &LoginThrottledError{RetryAfter: g.policy.Lockout(), Locked: true}
+}
+
+// Succeed forgets the failed attempts
+func (g *LoginGuard) Succeed() {
+	if g.policy.Disabled || g.st.Failures == 0 {
+		return
+	}
+
+	if err := g.u.clearLoginAttempts(g.st.Email, g.st.IP); err != nil {
+		slog.Error("error clearing login attempts", "error", err)
+	}
+}
+
+// clearLoginAttempts forgets the failures and lockout of an email and IP
+// address
+func (u User) clearLoginAttempts(email, ip string) error {
+	keys := []string{
+		loginAttemptsCacheKey(u.conf.Name, email, ip),
+		loginFailuresCacheKey(u.conf.Name, email, ip),
+		loginLockCacheKey(u.conf.Name, email, ip),
+	}
+	for _, key := range keys {
+		if err := Cache.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u User) addLoginLock(st loginAttempts) error {
+	locks, err := u.ListLoginLocks()
+	if err != nil {
+		return err
+	}
+
+	locks = slices.DeleteFunc(locks, func(l model.LoginLock) bool {
+		return l.Email == st.Email && l.IP == st.IP
+	})
+	locks = append(locks, model.LoginLock{
+		Email:       st.Email,
+		IP:          st.IP,
+		Failures:    st.Failures,
+		LockedUntil: st.LockedUntil,
+	})
+	return Cache.SetTyped(loginLocksCacheKey(u.conf.Name), locks)
+}
+
+// ListLoginLocks returns the active lockouts of the database
+func (u User) ListLoginLocks() ([]model.LoginLock, error) {
+	var locks []model.LoginLock
+	if err := Cache.GetTyped(loginLocksCacheKey(u.conf.Name), &locks); err != nil {
+		return []model.LoginLock{}, nil
+	}
+
+	now := time.Now()
+	return slices.DeleteFunc(locks, func(l model.LoginLock) bool {
+		return now.After(l.LockedUntil)
+	}), nil
+}
+
+// ClearLoginLocks removes the lockouts and failed attempts of an email for
+// an IP address, or for all IP addresses when ip is empty.
+func (u User) ClearLoginLocks(email, ip string) error {
+	email = strings.ToLower(email)
+
+	locks, err := u.ListLoginLocks()
+	if err != nil {
+		return err
+	}
+
+	ips := []string{ip}
+	if len(ip) == 0 {
+		ips = nil
+		for _, l := range locks {
+			if l.Email == email {
+				ips = append(ips, l.IP)
+			}
+		}
+	}
+
+	for _, addr := range ips {
+		if err := u.clearLoginAttempts(email, addr); err != nil {
+			return err
+		}
+	}
+
+	locks = slices.DeleteFunc(locks, func(l model.LoginLock) bool {
+		return l.Email == email && (len(ip) == 0 || l.IP == ip)
+	})
+	return Cache.SetTyped(loginLocksCacheKey(u.conf.Name), locks)
+}
+
+// notifyLockout emails the user whose email was locked out, if any
+func (u User) notifyLockout(policy model.LoginProtectionPolicy, st loginAttempts) {
+	if policy.DisableLockoutEmail || len(u.conf.Name) == 0 {
+		return
+	}
+
+	if exists, err := DB.UserEmailExists(u.conf.Name, st.Email); err != nil || !exists {
+		return
+	}
+
+	body := policy.Body
+	if len(body) == 0 {
+		body = defaultLockoutBody
+	}
+	body = strings.ReplaceAll(body, "[ip]", st.IP)
+	body = strings.ReplaceAll(body, "[until]", st.LockedUntil.UTC().Format(time.RFC1123))
+
+	mail := email.SendMailData{
+		From:     policy.FromEmail,
+		FromName: policy.FromName,
+		To:       st.Email,
+		Subject:  policy.Subject,
+		HTMLBody: body,
+	}
+	if len(mail.From) == 0 {
+		mail.From, mail.FromName = Config.FromEmail, Config.FromName
+	}
+	if len(mail.Subject) == 0 {
+		mail.Subject = defaultLockoutSubject
+	}
+
+	if err := Emailer.Send(mail); err != nil {
+		slog.Error("error sending lockout email", "error", err)
+	}
+}
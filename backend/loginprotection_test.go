@@ -0,0 +1,140 @@
+package backend_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestLoginProtection(t *testing.T) {
+	const (
+		email    = "login-protection@test.com"
+		password = "test1234!"
+	)
+
+	usr := backend.Membership(base).WithClient("unit test", "10.0.0.1")
+	if err := usr.SetLoginProtectionPolicy(model.LoginProtectionPolicy{MaxAttempts: 2}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.SetLoginProtectionPolicy(model.LoginProtectionPolicy{}) })
+
+	if _, _, err := usr.CreateAccountAndUser(email, password, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	var te *backend.LoginThrottledError
+	if _, err := usr.Authenticate(email, "wrong"); err == nil || errors.As(err, &te) {
+		t.Fatalf("expected invalid credentials got %v", err)
+	}
+
+	if _, err := usr.Authenticate(email, password); !errors.As(err, &te) || te.Locked {
+		t.Fatalf("expected to wait before the next attempt got %v", err)
+	}
+
+	other := backend.Membership(base).WithClient("unit test", "10.0.0.2")
+	if _, err := other.Authenticate(email, password); err != nil {
+		t.Errorf("expected other IP addresses to sign in got %v", err)
+	}
+
+	if err := usr.ClearLoginLocks(email, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := usr.SetLoginProtectionPolicy(model.LoginProtectionPolicy{MaxAttempts: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := usr.Authenticate(email, "wrong"); !errors.As(err, &te) || !te.Locked {
+		t.Fatalf("expected a lockout got %v", err)
+	}
+
+	if _, err := usr.ValidateMagicLink(email, "123456"); !errors.As(err, &te) || !te.Locked {
+		t.Errorf("expected magic links to be locked out got %v", err)
+	}
+
+	locks, err := usr.ListLoginLocks()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(locks) != 1 || locks[0].Email != email || locks[0].IP != "10.0.0.1" {
+		t.Fatalf("unexpected locks %v", locks)
+	}
+
+	if err := usr.ClearLoginLocks(email, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if locks, err := usr.ListLoginLocks(); err != nil {
+		t.Fatal(err)
+	} else if len(locks) > 0 {
+		t.Errorf("expected the locks to be cleared got %v", locks)
+	}
+
+	if _, err := usr.Authenticate(email, password); err != nil {
+		t.Errorf("expected to sign in once cleared got %v", err)
+	}
+}
+
+func TestLoginProtectionRegister(t *testing.T) {
+	const (
+		email    = "login-protection-register@test.com"
+		password = "test1234!"
+	)
+
+	usr := backend.Membership(base).WithClient("unit test", "10.0.3.1")
+	if _, _, err := usr.CreateAccountAndUser(email, password, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	otherAccountID, err := backend.DB.CreateAccount(base.Name, "login-protection-register-account@test.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var te *backend.LoginThrottledError
+	if _, err := usr.Register(email, "wrong", otherAccountID); err == nil || errors.As(err, &te) {
+		t.Fatalf("expected invalid credentials got %v", err)
+	}
+
+	if _, err := usr.Register(email, password, otherAccountID); !errors.As(err, &te) {
+		t.Errorf("expected to wait before the next attempt got %v", err)
+	}
+}
+
+func TestLoginProtectionConcurrentFailures(t *testing.T) {
+	const email = "login-protection-concurrent@test.com"
+
+	usr := backend.Membership(base).WithClient("unit test", "10.0.4.1")
+	policy, err := usr.GetLoginProtectionPolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.ClearLoginLocks(email, "") })
+
+	// every attempt passed the guard before any of them failed
+	guards := make([]*backend.LoginGuard, policy.MaxFailures())
+	for i := range guards {
+		g, err := usr.GuardLogin(email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		guards[i] = g
+	}
+
+	var wg sync.WaitGroup
+	for _, g := range guards {
+		wg.Add(1)
+		go func(g *backend.LoginGuard) {
+			defer wg.Done()
+			_ = g.Fail(backend.ErrInvalidPassword)
+		}(g)
+	}
+	wg.Wait()
+
+	var te *backend.LoginThrottledError
+	if _, err := usr.GuardLogin(email); !errors.As(err, &te) || !te.Locked {
+		t.Errorf("expected every concurrent failure to count towards the lockout got %v", err)
+	}
+}
@@ -0,0 +1,459 @@
+package backend
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	mfaChallengeTTL     = 5 * time.Minute
+	mfaMaxAttempts      = 5
+	mfaRecoveryCodesLen = 10
+)
+
+var (
+	ErrMFARequired         = errors.New("multi-factor authentication code required")
+	ErrMFAPolicyRequired   = errors.New("multi-factor authentication is required for this database")
+	ErrMFANotEnrolled      = errors.New("multi-factor authentication is not enrolled")
+	ErrMFAAlreadyEnabled   = errors.New("multi-factor authentication is already enabled")
+	ErrInvalidMFACode      = errors.New("invalid multi-factor authentication code")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired multi-factor authentication challenge")
+)
+
+// MFAChallengeError is returned by logins requiring a TOTP code. The
+// challenge is exchanged with a valid code via CompleteMFALogin.
+type MFAChallengeError struct {
+	Challenge model.MFAChallenge
+}
+
+func (e *MFAChallengeError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFAChallengeError) Unwrap() error {
+	return ErrMFARequired
+}
+
+// mfaChallengeState is the login waiting for a TOTP code. AccountID is the
+// cross-account association requested at login, if any, and Refresh is set
+// when the login asked for a refresh token.
+type mfaChallengeState struct {
+	DBName        string    `json:"dbName"`
+	UserID        string    `json:"userId"`
+	HomeAccountID string    `json:"homeAccountId"`
+	AccountID     string    `json:"accountId"`
+	Refresh       bool      `json:"refresh"`
+	Expires       time.Time `json:"expires"`
+}
+
+func mfaChallengeCacheKey(challenge string) string {
+	return "mfa-challenge-" + challenge
+}
+
+// mfaAttemptsCacheKey counts the codes tried for a challenge
+func mfaAttemptsCacheKey(challenge string) string {
+	return "mfa-attempts-" + challenge
+}
+
+// GetMFAPolicy returns the multi-factor authentication policy of the database
+func (u User) GetMFAPolicy() (policy model.MFAPolicy, err error) {
+	b, err := DB.GetSetting(u.conf.Name, model.SettingMFAPolicy)
+	if err != nil || b == nil {
+		return
+	}
+
+	err = json.Unmarshal(b, &policy)
+	return
+}
+
+// SetMFAPolicy sets the multi-factor authentication policy of the database.
+// When required, users without MFA must enroll during their next login.
+func (u User) SetMFAPolicy(policy model.MFAPolicy) error {
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	return DB.SetSetting(u.conf.Name, model.SettingMFAPolicy, b)
+}
+
+// GetMFAStatus returns whether the user has MFA enabled
+func (u User) GetMFAStatus(auth model.Auth) (status model.MFAStatus, err error) {
+	policy, err := u.GetMFAPolicy()
+	if err != nil {
+		return
+	}
+
+	mfa, err := DB.GetUserMFA(u.conf.Name, auth.UserID)
+	if err != nil {
+		return
+	}
+
+	status.Required = policy.Required
+	status.Enabled = mfa.Enabled
+	status.RecoveryCodesLeft = len(mfa.RecoveryCodes)
+	return
+}
+
+// EnrollMFA generates a new TOTP secret for the user. MFA is enabled once
+// the user confirms a code from their authenticator app with ConfirmMFA.
+func (u User) EnrollMFA(auth model.Auth) (model.MFAEnrollment, error) {
+	return u.enrollMFA(auth.UserID, auth.Email)
+}
+
+// ConfirmMFA enables MFA for the user if the code is valid for the enrolled
+// secret and returns the recovery codes. Those codes are only returned once.
+func (u User) ConfirmMFA(auth model.Auth, code string) ([]string, error) {
+	mfa, err := DB.GetUserMFA(u.conf.Name, auth.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.confirmMFA(mfa, code)
+}
+
+// DisableMFA removes the TOTP enrollment of the user after validating a TOTP
+// or recovery code. MFA cannot be disabled when the database requires it.
+func (u User) DisableMFA(auth model.Auth, code string) error {
+	policy, err := u.GetMFAPolicy()
+	if err != nil {
+		return err
+	} else if policy.Required {
+		return ErrMFAPolicyRequired
+	}
+
+	mfa, err := DB.GetUserMFA(u.conf.Name, auth.UserID)
+	if err != nil {
+		return err
+	} else if !mfa.Enabled {
+		return ErrMFANotEnrolled
+	}
+
+	if err := u.verifyMFACode(&mfa, code); err != nil {
+		return err
+	}
+
+	return DB.DeleteUserMFA(u.conf.Name, auth.UserID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after validating
+// a TOTP code.
+func (u User) RegenerateRecoveryCodes(auth model.Auth, code string) ([]string, error) {
+	mfa, err := DB.GetUserMFA(u.conf.Name, auth.UserID)
+	if err != nil {
+		return nil, err
+	} else if !mfa.Enabled {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if ok, err := u.validateTOTP(&mfa, code); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	mfa.RecoveryCodes = hashes
+	if err := DB.SaveUserMFA(u.conf.Name, mfa); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ResetMFA removes the TOTP enrollment of a user, i.e. when root helps a user
+// who lost their device and recovery codes.
+func (u User) ResetMFA(userID string) error {
+	return DB.DeleteUserMFA(u.conf.Name, userID)
+}
+
+// EnrollMFAChallenge generates a TOTP secret for a user who must enroll to
+// complete their login. The challenge is then completed with the first code.
+func (u User) EnrollMFAChallenge(challenge string) (model.MFAEnrollment, error) {
+	st, err := u.getMFAChallenge(challenge)
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	tok, err := DB.GetUserByID(u.conf.Name, st.HomeAccountID, st.UserID)
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	return u.enrollMFA(tok.ID, tok.Email)
+}
+
+// CompleteMFALogin exchanges a login challenge and a valid TOTP or recovery
+// code for a session token. If the login completes an enrollment the
+// recovery codes are returned.
+func (u User) CompleteMFALogin(challenge, code string) (result model.MFALoginResult, err error) {
+	st, err := u.getMFAChallenge(challenge)
+	if err != nil {
+		return
+	}
+
+	tok, err := DB.GetUserByID(u.conf.Name, st.HomeAccountID, st.UserID)
+	if err != nil {
+		return
+	}
+
+	mfa, err := DB.GetUserMFA(u.conf.Name, tok.ID)
+	if err != nil {
+		return
+	}
+
+	guard, err := u.GuardLogin(tok.Email)
+	if err != nil {
+		return
+	}
+
+	// attempts are counted before the code is checked so concurrent ones
+	// cannot exceed the limit
+	attempts, err := Cache.Inc(mfaAttemptsCacheKey(challenge), 1)
+	if err != nil {
+		return
+	} else if attempts > mfaMaxAttempts {
+		return result, errors.Join(ErrInvalidMFAChallenge, deleteMFAChallenge(challenge))
+	}
+
+	if mfa.Enabled {
+		err = u.verifyMFACode(&mfa, code)
+	} else {
+		result.RecoveryCodes, err = u.confirmMFA(mfa, code)
+	}
+
+	if errors.Is(err, ErrInvalidMFACode) {
+		err = guard.Fail(err)
+		if attempts == mfaMaxAttempts {
+			return result, errors.Join(err, deleteMFAChallenge(challenge))
+		}
+		return
+	} else if err != nil {
+		return
+	}
+
+	guard.Succeed()
+
+	if err = deleteMFAChallenge(challenge); err != nil {
+		return
+	}
+
+	tokens, err := u.issueSession(tok, st.AccountID, st.Refresh)
+	if err != nil {
+		return
+	}
+
+	result.Token = tokens.Token
+	result.RefreshToken = tokens.RefreshToken
+	return
+}
+
+// challengeIfRequired returns an *MFAChallengeError if the user has MFA
+// enabled or if the database requires MFA.
+func (u User) challengeIfRequired(tok model.User, accountID string, refresh bool) error {
+	mfa, err := DB.GetUserMFA(u.conf.Name, tok.ID)
+	if err != nil {
+		return err
+	}
+
+	enroll := false
+	if !mfa.Enabled {
+		policy, err := u.GetMFAPolicy()
+		if err != nil {
+			return err
+		} else if !policy.Required {
+			return nil
+		}
+		enroll = true
+	}
+
+	challenge, err := newMFAChallengeToken()
+	if err != nil {
+		return err
+	}
+
+	st := mfaChallengeState{
+		DBName:        u.conf.Name,
+		UserID:        tok.ID,
+		HomeAccountID: tok.AccountID,
+		AccountID:     accountID,
+		Refresh:       refresh,
+		Expires:       time.Now().Add(mfaChallengeTTL),
+	}
+	if err := Cache.SetTyped(mfaChallengeCacheKey(challenge), st); err != nil {
+		return err
+	}
+
+	return &MFAChallengeError{Challenge: model.MFAChallenge{
+		MFARequired:        true,
+		EnrollmentRequired: enroll,
+		Challenge:          challenge,
+	}}
+}
+
+func (u User) getMFAChallenge(challenge string) (st mfaChallengeState, err error) {
+	if len(challenge) == 0 {
+		return st, ErrInvalidMFAChallenge
+	}
+
+	if err := Cache.GetTyped(mfaChallengeCacheKey(challenge), &st); err != nil {
+		return st, ErrInvalidMFAChallenge
+	}
+
+	if st.DBName != u.conf.Name || time.Now().After(st.Expires) {
+		return st, errors.Join(ErrInvalidMFAChallenge, deleteMFAChallenge(challenge))
+	}
+	return st, nil
+}
+
+// deleteMFAChallenge removes a challenge and its attempts count
+func deleteMFAChallenge(challenge string) error {
+	return errors.Join(
+		Cache.Delete(mfaChallengeCacheKey(challenge)),
+		Cache.Delete(mfaAttemptsCacheKey(challenge)),
+	)
+}
+
+func (u User) enrollMFA(userID, email string) (model.MFAEnrollment, error) {
+	mfa, err := DB.GetUserMFA(u.conf.Name, userID)
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	} else if mfa.Enabled {
+		return model.MFAEnrollment{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := internal.NewTOTPSecret()
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	enc, err := u.mfaCipher().Encrypt(secret, 1)
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	mfa = model.UserMFA{
+		UserID:  userID,
+		Secret:  enc,
+		Created: time.Now(),
+	}
+	if err := DB.SaveUserMFA(u.conf.Name, mfa); err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	return model.MFAEnrollment{
+		Secret: secret,
+		URI:    internal.TOTPURI(u.conf.Name, email, secret),
+	}, nil
+}
+
+func (u User) confirmMFA(mfa model.UserMFA, code string) ([]string, error) {
+	if len(mfa.UserID) == 0 {
+		return nil, ErrMFANotEnrolled
+	} else if mfa.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	if ok, err := u.validateTOTP(&mfa, code); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	mfa.Enabled = true
+	mfa.RecoveryCodes = hashes
+	if err := DB.SaveUserMFA(u.conf.Name, mfa); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifyMFACode accepts a TOTP code or a recovery code. The step of a TOTP
+// code is saved and a recovery code is removed once used.
+func (u User) verifyMFACode(mfa *model.UserMFA, code string) error {
+	if ok, err := u.validateTOTP(mfa, code); err != nil {
+		return err
+	} else if ok {
+		return DB.SaveUserMFA(u.conf.Name, *mfa)
+	}
+
+	hash := hashRecoveryCode(code)
+	idx := slices.IndexFunc(mfa.RecoveryCodes, func(h string) bool {
+		return subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1
+	})
+	if idx < 0 {
+		return ErrInvalidMFACode
+	}
+
+	mfa.RecoveryCodes = slices.Delete(mfa.RecoveryCodes, idx, idx+1)
+	return DB.SaveUserMFA(u.conf.Name, *mfa)
+}
+
+// validateTOTP returns true if the code is valid and was not used before. The
+// step of the code is set on mfa, callers must save it to prevent replays.
+func (u User) validateTOTP(mfa *model.UserMFA, code string) (bool, error) {
+	v, err := u.mfaCipher().Decrypt(mfa.Secret)
+	if err != nil {
+		return false, err
+	}
+
+	secret, ok := v.(string)
+	if !ok {
+		return false, errors.New("invalid TOTP secret")
+	}
+
+	step, ok := internal.ValidateTOTP(secret, code, time.Now(), mfa.LastTOTPStep)
+	if ok {
+		mfa.LastTOTPStep = step
+	}
+	return ok, nil
+}
+
+func (u User) mfaCipher() model.FieldCipher {
+	return model.FieldCipher{TenantID: u.conf.TenantID}
+}
+
+func newMFAChallengeToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// newRecoveryCodes returns the recovery codes to show the user once and their
+// hashes to store.
+func newRecoveryCodes() (codes []string, hashes []string, err error) {
+	for range mfaRecoveryCodesLen {
+		code, err := internal.NewRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
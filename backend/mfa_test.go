@@ -0,0 +1,331 @@
+package backend_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
+)
+
+// totpAt returns the code of the secret a number of periods from now
+func totpAt(t *testing.T, secret string, periods int) string {
+	t.Helper()
+
+	code, err := internal.TOTPCode(secret, time.Now().Add(time.Duration(periods*internal.TOTPPeriod)*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestMFALoginChallenge(t *testing.T) {
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+
+	const (
+		email    = "mfa-login@test.com"
+		password = "test1234!"
+	)
+
+	usr := backend.Membership(base)
+
+	// the invalid codes tried here would delay the next attempts, see
+	// TestMFALoginProtection
+	if err := usr.SetLoginProtectionPolicy(model.LoginProtectionPolicy{Disabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.SetLoginProtectionPolicy(model.LoginProtectionPolicy{}) })
+	_, tok, err := usr.CreateAccountAndUser(email, password, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := model.Auth{AccountID: tok.AccountID, UserID: tok.ID, Email: email}
+
+	enrollment, err := usr.EnrollMFA(auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := usr.ConfirmMFA(auth, "000000"); !errors.Is(err, backend.ErrInvalidMFACode) {
+		t.Fatalf("expected ErrInvalidMFACode got %v", err)
+	}
+
+	// codes are single use, each step uses a different code
+	recovery, err := usr.ConfirmMFA(auth, totpAt(t, enrollment.Secret, -1))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(recovery) != 10 {
+		t.Fatalf("expected 10 recovery codes got %d", len(recovery))
+	}
+
+	_, err = usr.Authenticate(email, password)
+
+	var ch *backend.MFAChallengeError
+	if !errors.As(err, &ch) {
+		t.Fatalf("expected an MFA challenge got %v", err)
+	} else if ch.Challenge.EnrollmentRequired {
+		t.Error("expected no enrollment for an enrolled user")
+	}
+
+	if _, err := usr.CompleteMFALogin(ch.Challenge.Challenge, "000000"); !errors.Is(err, backend.ErrInvalidMFACode) {
+		t.Fatalf("expected ErrInvalidMFACode got %v", err)
+	}
+
+	result, err := usr.CompleteMFALogin(ch.Challenge.Challenge, totpAt(t, enrollment.Secret, 0))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(result.Token) == 0 {
+		t.Fatal("expected a session token")
+	}
+
+	if _, err := usr.CompleteMFALogin(ch.Challenge.Challenge, totpAt(t, enrollment.Secret, 0)); !errors.Is(err, backend.ErrInvalidMFAChallenge) {
+		t.Errorf("expected the challenge to be single use got %v", err)
+	}
+
+	_, err = usr.Authenticate(email, password)
+	if !errors.As(err, &ch) {
+		t.Fatalf("expected an MFA challenge got %v", err)
+	}
+
+	if _, err := usr.CompleteMFALogin(ch.Challenge.Challenge, totpAt(t, enrollment.Secret, 0)); !errors.Is(err, backend.ErrInvalidMFACode) {
+		t.Errorf("expected a used TOTP code to be rejected got %v", err)
+	}
+
+	// recovery codes are single use
+	for i, want := range []error{nil, backend.ErrInvalidMFACode} {
+		_, err = usr.Authenticate(email, password)
+		if !errors.As(err, &ch) {
+			t.Fatalf("expected an MFA challenge got %v", err)
+		}
+
+		if _, err := usr.CompleteMFALogin(ch.Challenge.Challenge, recovery[0]); !errors.Is(err, want) {
+			t.Errorf("attempt %d: expected %v got %v", i, want, err)
+		}
+	}
+
+	status, err := usr.GetMFAStatus(auth)
+	if err != nil {
+		t.Fatal(err)
+	} else if !status.Enabled || status.RecoveryCodesLeft != 9 {
+		t.Errorf("expected enabled with 9 recovery codes left got %v", status)
+	}
+
+	if err := usr.DisableMFA(auth, totpAt(t, enrollment.Secret, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := usr.Authenticate(email, password); err != nil {
+		t.Errorf("expected login without MFA got %v", err)
+	}
+}
+
+func TestMFAPolicyRequiresEnrollment(t *testing.T) {
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+
+	const (
+		email    = "mfa-required@test.com"
+		password = "test1234!"
+	)
+
+	usr := backend.Membership(base)
+	if err := usr.SetMFAPolicy(model.MFAPolicy{Required: true}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := usr.SetMFAPolicy(model.MFAPolicy{}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	_, tok, err := usr.CreateAccountAndUser(email, password, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherAccountID, err := backend.DB.CreateAccount(base.Name, "mfa-required-account@test.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = usr.Authenticate(email, password, otherAccountID)
+
+	var ch *backend.MFAChallengeError
+	if !errors.As(err, &ch) {
+		t.Fatalf("expected an MFA challenge got %v", err)
+	} else if !ch.Challenge.EnrollmentRequired {
+		t.Fatal("expected enrollment to be required")
+	}
+
+	enrollment, err := usr.EnrollMFAChallenge(ch.Challenge.Challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := usr.CompleteMFALogin(ch.Challenge.Challenge, totpAt(t, enrollment.Secret, 0))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(result.Token) == 0 {
+		t.Fatal("expected a session token")
+	} else if len(result.RecoveryCodes) != 10 {
+		t.Errorf("expected 10 recovery codes got %d", len(result.RecoveryCodes))
+	}
+
+	if exists, err := backend.DB.AssociationExists(base.Name, tok.ID, otherAccountID); err != nil {
+		t.Fatal(err)
+	} else if !exists {
+		t.Error("expected the cross-account association to be created")
+	}
+
+	auth := model.Auth{AccountID: tok.AccountID, UserID: tok.ID, Email: email}
+	if err := usr.DisableMFA(auth, totpAt(t, enrollment.Secret, 1)); !errors.Is(err, backend.ErrMFAPolicyRequired) {
+		t.Errorf("expected ErrMFAPolicyRequired got %v", err)
+	}
+}
+
+func TestMFARegisterIntoAccount(t *testing.T) {
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+
+	const (
+		email    = "mfa-register@test.com"
+		password = "test1234!"
+	)
+
+	usr := backend.Membership(base)
+	_, tok, err := usr.CreateAccountAndUser(email, password, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := model.Auth{AccountID: tok.AccountID, UserID: tok.ID, Email: email}
+
+	enrollment, err := usr.EnrollMFA(auth)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := usr.ConfirmMFA(auth, totpAt(t, enrollment.Secret, -1)); err != nil {
+		t.Fatal(err)
+	}
+
+	otherAccountID, err := backend.DB.CreateAccount(base.Name, "mfa-register-account@test.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := usr.Register(email, password, otherAccountID)
+
+	var ch *backend.MFAChallengeError
+	if !errors.As(err, &ch) {
+		t.Fatalf("expected an MFA challenge got token %q and error %v", token, err)
+	}
+
+	if exists, err := backend.DB.AssociationExists(base.Name, tok.ID, otherAccountID); err != nil {
+		t.Fatal(err)
+	} else if exists {
+		t.Error("expected no association before the TOTP code")
+	}
+
+	result, err := usr.CompleteMFALogin(ch.Challenge.Challenge, totpAt(t, enrollment.Secret, 0))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(result.Token) == 0 {
+		t.Fatal("expected a session token")
+	}
+
+	if exists, err := backend.DB.AssociationExists(base.Name, tok.ID, otherAccountID); err != nil {
+		t.Fatal(err)
+	} else if !exists {
+		t.Error("expected the association to be created")
+	}
+}
+
+func TestMFALoginProtection(t *testing.T) {
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+
+	const (
+		email    = "mfa-login-protection@test.com"
+		password = "test1234!"
+	)
+
+	usr := backend.Membership(base).WithClient("unit test", "10.0.5.1")
+	_, tok, err := usr.CreateAccountAndUser(email, password, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.ClearLoginLocks(email, "10.0.5.1") })
+
+	auth := model.Auth{AccountID: tok.AccountID, UserID: tok.ID, Email: email}
+
+	enrollment, err := usr.EnrollMFA(auth)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := usr.ConfirmMFA(auth, totpAt(t, enrollment.Secret, -1)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = usr.Authenticate(email, password)
+
+	var ch *backend.MFAChallengeError
+	if !errors.As(err, &ch) {
+		t.Fatalf("expected an MFA challenge got %v", err)
+	}
+
+	if _, err := usr.CompleteMFALogin(ch.Challenge.Challenge, "000000"); !errors.Is(err, backend.ErrInvalidMFACode) {
+		t.Fatalf("expected ErrInvalidMFACode got %v", err)
+	}
+
+	var te *backend.LoginThrottledError
+	if _, err := usr.CompleteMFALogin(ch.Challenge.Challenge, totpAt(t, enrollment.Secret, 0)); !errors.As(err, &te) || te.Locked {
+		t.Errorf("expected to wait before the next code got %v", err)
+	}
+
+	if _, err := usr.Authenticate(email, password); !errors.As(err, &te) {
+		t.Errorf("expected the failed code to delay the next sign in got %v", err)
+	}
+}
+
+func TestMFAUserSetPassword(t *testing.T) {
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+
+	const (
+		email    = "mfa-set-password@test.com"
+		password = "test1234!"
+	)
+
+	usr := backend.Membership(base).WithClient("unit test", "10.0.5.2")
+	_, tok, err := usr.CreateAccountAndUser(email, password, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.ClearLoginLocks(email, "10.0.5.2") })
+
+	auth := model.Auth{AccountID: tok.AccountID, UserID: tok.ID, Email: email}
+
+	enrollment, err := usr.EnrollMFA(auth)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := usr.ConfirmMFA(auth, totpAt(t, enrollment.Secret, -1)); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := backend.DB.ListSessions(base.Name, tok.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := usr.UserSetPassword(email, password, "test5678!"); err != nil {
+		t.Fatalf("expected an MFA user to change their password got %v", err)
+	}
+
+	if check, err := backend.DB.ListSessions(base.Name, tok.ID); err != nil {
+		t.Fatal(err)
+	} else if len(check) != len(sessions) {
+		t.Errorf("expected no session to be started got %d sessions", len(check))
+	}
+
+	if err := usr.UserSetPassword(email, password, "test9012!"); !errors.Is(err, backend.ErrInvalidPassword) {
+		t.Errorf("expected ErrInvalidPassword for the previous password got %v", err)
+	}
+}
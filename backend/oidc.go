@@ -0,0 +1,590 @@
+package backend
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	// oidcCodeTTL is how long an authorization code can be exchanged
+	oidcCodeTTL = 5 * time.Minute
+	// oidcIDTokenTTL is the lifetime of the id_tokens
+	oidcIDTokenTTL = 1 * time.Hour
+)
+
+var (
+	ErrOAuthClientNotFound = errors.New("OAuth client not found")
+	ErrInvalidRedirectURI  = errors.New("redirect_uri is not registered for this client")
+
+	errOAuthOtherClient = &model.OAuthError{Code: "invalid_grant", Description: "the token was not issued to this client"}
+
+	// oidcSigningKeys caches the decrypted id_token signing key per database
+	oidcSigningKeys sync.Map
+)
+
+// OpenIDProvider lets a database act as an OpenID Connect provider so other
+// apps sign their users in with the database's membership. The access tokens
+// issued to clients are bound to the client and only accepted by the
+// userinfo endpoint.
+type OpenIDProvider struct {
+	conf model.DatabaseConfig
+
+	// Issuer identifies the provider, the discovery document is served
+	// under Issuer/.well-known/openid-configuration
+	Issuer string
+}
+
+func newOpenIDProvider(conf model.DatabaseConfig) OpenIDProvider {
+	return OpenIDProvider{
+		conf:   conf,
+		Issuer: strings.TrimSuffix(config.Current.AppURL, "/") + "/oidc/" + conf.ID,
+	}
+}
+
+// oidcCodeState is an authorization code waiting to be exchanged by the
+// client at the token endpoint
+type oidcCodeState struct {
+	DBName      string    `json:"dbName"`
+	ClientID    string    `json:"clientId"`
+	RedirectURI string    `json:"redirectUri"`
+	UserID      string    `json:"userId"`
+	AccountID   string    `json:"accountId"`
+	Scopes      []string  `json:"scopes"`
+	Nonce       string    `json:"nonce"`
+	Challenge   string    `json:"challenge"`
+	Expires     time.Time `json:"expires"`
+}
+
+type oidcSigningKey struct {
+	id  string
+	key *rsa.PrivateKey
+}
+
+type oidcIDTokenPayload struct {
+	jwt.Payload
+	Nonce string `json:"nonce,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+func oidcCodeCacheKey(code string) string {
+	return "oidc-code-" + code
+}
+
+// Discovery returns the OpenID Connect discovery document of the provider
+func (p OpenIDProvider) Discovery() map[string]any {
+	return map[string]any{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Issuer + "/authorize",
+		"token_endpoint":                        p.Issuer + "/token",
+		"userinfo_endpoint":                     p.Issuer + "/userinfo",
+		"revocation_endpoint":                   p.Issuer + "/revoke",
+		"jwks_uri":                              p.Issuer + "/jwks",
+		"scopes_supported":                      model.OIDCScopes,
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "nonce", "email"},
+	}
+}
+
+// JWKS returns the public keys verifying the id_tokens, every stored key is
+// published so tokens signed by any instance can be verified.
+func (p OpenIDProvider) JWKS() (map[string]any, error) {
+	keys, err := p.storedSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	cipher := model.FieldCipher{TenantID: p.conf.TenantID}
+
+	var jwks []map[string]string
+	for _, k := range keys {
+		sk, err := parseSigningKey(cipher, k)
+		if err != nil {
+			return nil, err
+		}
+
+		pub := sk.key.PublicKey
+		jwks = append(jwks, map[string]string{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": sk.id,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		})
+	}
+
+	return map[string]any{"keys": jwks}, nil
+}
+
+// CreateClient registers an OAuth client. Confidential clients receive a
+// secret which is only returned once.
+func (p OpenIDProvider) CreateClient(nc model.NewOAuthClient) (model.CreatedOAuthClient, error) {
+	if err := nc.Validate(); err != nil {
+		return model.CreatedOAuthClient{}, err
+	}
+
+	c := model.OAuthClient{
+		Name:         nc.Name,
+		RedirectURIs: nc.RedirectURIs,
+		Public:       nc.Public,
+		Trusted:      nc.Trusted,
+		Created:      time.Now(),
+	}
+
+	secret := ""
+	if !c.Public {
+		var err error
+		secret, err = newRefreshSecret()
+		if err != nil {
+			return model.CreatedOAuthClient{}, err
+		}
+		c.SecretHash = hashRefreshSecret(secret)
+	}
+
+	id, err := DB.CreateOAuthClient(p.conf.Name, c)
+	if err != nil {
+		return model.CreatedOAuthClient{}, err
+	}
+
+	c.ID = id
+	return model.CreatedOAuthClient{OAuthClient: c, Secret: secret}, nil
+}
+
+// ListClients returns the OAuth clients of the database
+func (p OpenIDProvider) ListClients() ([]model.OAuthClient, error) {
+	return DB.ListOAuthClients(p.conf.Name)
+}
+
+// DeleteClient removes an OAuth client and the consents granted to it. The
+// sessions already started by the client stay valid until revoked.
+func (p OpenIDProvider) DeleteClient(id string) error {
+	if _, err := DB.GetOAuthClient(p.conf.Name, id); err != nil {
+		return ErrOAuthClientNotFound
+	}
+	return DB.DeleteOAuthClient(p.conf.Name, id)
+}
+
+// ValidateAuthorization checks an authorization request and returns its
+// client. ErrOAuthClientNotFound and ErrInvalidRedirectURI must be shown to
+// the user, other errors are *model.OAuthError to send to the redirect URI.
+func (p OpenIDProvider) ValidateAuthorization(req model.OAuthAuthorizeRequest) (model.OAuthClient, error) {
+	client, err := DB.GetOAuthClient(p.conf.Name, req.ClientID)
+	if err != nil {
+		return client, ErrOAuthClientNotFound
+	} else if !client.AllowsRedirect(req.RedirectURI) {
+		return client, ErrInvalidRedirectURI
+	}
+
+	if req.ResponseType != "code" {
+		return client, &model.OAuthError{Code: "unsupported_response_type", Description: "only the code response type is supported"}
+	}
+
+	scopes := req.Scopes()
+	if !slices.Contains(scopes, model.OIDCScopeOpenID) {
+		return client, &model.OAuthError{Code: "invalid_scope", Description: "the openid scope is required"}
+	}
+	for _, s := range scopes {
+		if !slices.Contains(model.OIDCScopes, s) {
+			return client, &model.OAuthError{Code: "invalid_scope", Description: "unsupported scope " + s}
+		}
+	}
+
+	if len(req.CodeChallenge) == 0 && client.Public {
+		return client, &model.OAuthError{Code: "invalid_request", Description: "public clients must use PKCE"}
+	} else if len(req.CodeChallenge) > 0 && req.CodeChallengeMethod != "S256" {
+		return client, &model.OAuthError{Code: "invalid_request", Description: "only the S256 code challenge method is supported"}
+	}
+
+	switch req.Prompt {
+	case "", "none", "login", "consent":
+	default:
+		return client, &model.OAuthError{Code: "invalid_request", Description: "unsupported prompt " + req.Prompt}
+	}
+	return client, nil
+}
+
+// NeedsConsent returns true if the user has not granted all the scopes to
+// the client yet. Trusted clients never need consent.
+func (p OpenIDProvider) NeedsConsent(auth model.Auth, client model.OAuthClient, scopes []string) (bool, error) {
+	if client.Trusted {
+		return false, nil
+	}
+
+	consents, err := DB.ListOAuthConsents(p.conf.Name, auth.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range consents {
+		if c.ClientID == client.ID && c.Covers(scopes) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Consent records that the user granted the scopes to the client
+func (p OpenIDProvider) Consent(auth model.Auth, client model.OAuthClient, scopes []string) error {
+	c := model.OAuthConsent{
+		UserID:   auth.UserID,
+		ClientID: client.ID,
+		Scopes:   scopes,
+		Created:  time.Now(),
+	}
+	return DB.SaveOAuthConsent(p.conf.Name, c)
+}
+
+// ListConsents returns the clients the user granted access to
+func (p OpenIDProvider) ListConsents(auth model.Auth) ([]model.OAuthConsent, error) {
+	return DB.ListOAuthConsents(p.conf.Name, auth.UserID)
+}
+
+// RevokeConsent removes the access the user granted to a client, the
+// consent screen is shown again on the next sign in.
+func (p OpenIDProvider) RevokeConsent(auth model.Auth, clientID string) error {
+	return DB.DeleteOAuthConsent(p.conf.Name, auth.UserID, clientID)
+}
+
+// IssueCode returns an authorization code for the authenticated user to
+// send to the client's redirect URI
+func (p OpenIDProvider) IssueCode(auth model.Auth, req model.OAuthAuthorizeRequest) (string, error) {
+	code, err := newRefreshSecret()
+	if err != nil {
+		return "", err
+	}
+
+	st := oidcCodeState{
+		DBName:      p.conf.Name,
+		ClientID:    req.ClientID,
+		RedirectURI: req.RedirectURI,
+		UserID:      auth.UserID,
+		AccountID:   auth.AccountID,
+		Scopes:      req.Scopes(),
+		Nonce:       req.Nonce,
+		Challenge:   req.CodeChallenge,
+		Expires:     time.Now().Add(oidcCodeTTL),
+	}
+	if err := Cache.SetTyped(oidcCodeCacheKey(code), st); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// Exchange redeems an authorization code for tokens. A session is started
+// for the user and the client, with a refresh token when the offline_access
+// scope was granted. The code can only be used once.
+func (p OpenIDProvider) Exchange(clientID, secret, code, redirectURI, verifier, ip string) (model.OAuthTokens, error) {
+	client, err := p.authenticateClient(clientID, secret)
+	if err != nil {
+		return model.OAuthTokens{}, err
+	}
+
+	invalidGrant := &model.OAuthError{Code: "invalid_grant", Description: "invalid or expired authorization code"}
+
+	var st oidcCodeState
+	if err := Cache.GetTyped(oidcCodeCacheKey(code), &st); err != nil || len(code) == 0 {
+		return model.OAuthTokens{}, invalidGrant
+	}
+	if err := Cache.Delete(oidcCodeCacheKey(code)); err != nil {
+		return model.OAuthTokens{}, err
+	}
+
+	if st.DBName != p.conf.Name || st.ClientID != client.ID || time.Now().After(st.Expires) {
+		return model.OAuthTokens{}, invalidGrant
+	} else if st.RedirectURI != redirectURI {
+		return model.OAuthTokens{}, &model.OAuthError{Code: "invalid_grant", Description: "redirect_uri does not match the authorization request"}
+	}
+
+	if len(st.Challenge) > 0 {
+		sum := sha256.Sum256([]byte(verifier))
+		challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+		if subtle.ConstantTimeCompare([]byte(challenge), []byte(st.Challenge)) != 1 {
+			return model.OAuthTokens{}, &model.OAuthError{Code: "invalid_grant", Description: "invalid code_verifier"}
+		}
+	}
+
+	u := newUser(p.conf).WithClient(client.Name, ip)
+
+	auth, err := u.sessionAuth(model.Session{UserID: st.UserID, AccountID: st.AccountID})
+	if err != nil {
+		return model.OAuthTokens{}, invalidGrant
+	}
+
+	scope := strings.Join(st.Scopes, " ")
+	tokens, err := u.startClientSession(auth, slices.Contains(st.Scopes, model.OIDCScopeOfflineAccess), client.ID, scope)
+	if err != nil {
+		return model.OAuthTokens{}, err
+	}
+
+	idToken, err := p.signIDToken(client, auth, st)
+	if err != nil {
+		return model.OAuthTokens{}, err
+	}
+
+	return model.OAuthTokens{
+		AccessToken:  tokens.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(tokens.Expires).Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		IDToken:      idToken,
+		Scope:        scope,
+	}, nil
+}
+
+// Refresh exchanges a refresh token issued to a client for new tokens
+func (p OpenIDProvider) Refresh(clientID, secret, refreshToken string) (model.OAuthTokens, error) {
+	client, err := p.authenticateClient(clientID, secret)
+	if err != nil {
+		return model.OAuthTokens{}, err
+	}
+
+	id, _, _ := strings.Cut(refreshToken, ".")
+	if s, err := DB.GetSession(p.conf.Name, id); err == nil && s.ClientID != client.ID {
+		return model.OAuthTokens{}, errOAuthOtherClient
+	}
+
+	tokens, err := newUser(p.conf).RefreshSession(refreshToken)
+	if errors.Is(err, ErrInvalidRefreshToken) || errors.Is(err, ErrRefreshTokenReused) {
+		return model.OAuthTokens{}, &model.OAuthError{Code: "invalid_grant", Description: err.Error()}
+	} else if err != nil {
+		return model.OAuthTokens{}, err
+	}
+
+	return model.OAuthTokens{
+		AccessToken:  tokens.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(tokens.Expires).Seconds()),
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+// Revoke signs out the session of an access or refresh token. Invalid
+// tokens are ignored as required by RFC 7009, tokens issued to another
+// client are refused.
+func (p OpenIDProvider) Revoke(clientID, secret, token string) error {
+	client, err := p.authenticateClient(clientID, secret)
+	if err != nil {
+		return err
+	}
+
+	u := newUser(p.conf)
+
+	// refresh tokens are "{sessionID}.{secret}", access tokens are JWTs
+	if id, rt, ok := strings.Cut(token, "."); ok && !strings.Contains(rt, ".") {
+		s, err := DB.GetSession(p.conf.Name, id)
+		if err != nil || subtle.ConstantTimeCompare([]byte(hashRefreshSecret(rt)), []byte(s.RefreshToken)) != 1 {
+			return nil
+		} else if s.ClientID != client.ID {
+			return errOAuthOtherClient
+		}
+		return u.revokeSession(s.ID)
+	}
+
+	var pl model.JWTPayload
+	if _, err := model.JWTKeys.Verify([]byte(token), &pl); err != nil || len(pl.SessionID) == 0 {
+		return nil
+	}
+
+	s, err := DB.GetSession(p.conf.Name, pl.SessionID)
+	if err != nil {
+		return nil
+	} else if s.ClientID != client.ID {
+		return errOAuthOtherClient
+	}
+	return u.revokeSession(s.ID)
+}
+
+// UserInfo returns the claims of the user of an access token granted the
+// scope
+func (p OpenIDProvider) UserInfo(auth model.Auth, scope string) map[string]any {
+	info := map[string]any{"sub": auth.UserID}
+	if slices.Contains(strings.Fields(scope), model.OIDCScopeEmail) {
+		info["email"] = auth.Email
+	}
+	return info
+}
+
+func (p OpenIDProvider) authenticateClient(clientID, secret string) (model.OAuthClient, error) {
+	invalidClient := &model.OAuthError{Code: "invalid_client", Description: "client authentication failed"}
+
+	client, err := DB.GetOAuthClient(p.conf.Name, clientID)
+	if err != nil || len(clientID) == 0 {
+		return client, invalidClient
+	}
+
+	if client.Public {
+		return client, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashRefreshSecret(secret)), []byte(client.SecretHash)) != 1 {
+		return client, invalidClient
+	}
+	return client, nil
+}
+
+func (p OpenIDProvider) signIDToken(client model.OAuthClient, auth model.Auth, st oidcCodeState) (string, error) {
+	sk, err := p.signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	pl := oidcIDTokenPayload{
+		Payload: jwt.Payload{
+			Issuer:         p.Issuer,
+			Subject:        auth.UserID,
+			Audience:       jwt.Audience{client.ID},
+			ExpirationTime: jwt.NumericDate(now.Add(oidcIDTokenTTL)),
+			IssuedAt:       jwt.NumericDate(now),
+		},
+		Nonce: st.Nonce,
+	}
+	if slices.Contains(st.Scopes, model.OIDCScopeEmail) {
+		pl.Email = auth.Email
+	}
+
+	b, err := jwt.Sign(pl, jwt.NewRS256(jwt.RSAPrivateKey(sk.key)), jwt.KeyID(sk.id))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// signingKey returns the key signing the id_tokens, it is generated on
+// first use and stored encrypted in the database settings.
+func (p OpenIDProvider) signingKey() (oidcSigningKey, error) {
+	if sk, ok := oidcSigningKeys.Load(p.conf.Name); ok {
+		return sk.(oidcSigningKey), nil
+	}
+
+	keys, err := p.storedSigningKeys()
+	if err != nil {
+		return oidcSigningKey{}, err
+	}
+
+	sk, err := parseSigningKey(model.FieldCipher{TenantID: p.conf.TenantID}, keys[len(keys)-1])
+	if err != nil {
+		return oidcSigningKey{}, err
+	}
+
+	oidcSigningKeys.Store(p.conf.Name, sk)
+	return sk, nil
+}
+
+// storedSigningKeys returns the signing keys of the database, the first key
+// is only added if no other instance stored one concurrently.
+func (p OpenIDProvider) storedSigningKeys() ([]model.OIDCSigningKey, error) {
+	keys, err := p.readSigningKeys()
+	if err != nil || len(keys) > 0 {
+		return keys, err
+	}
+
+	k, err := newOIDCSigningKey(model.FieldCipher{TenantID: p.conf.TenantID})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal([]model.OIDCSigningKey{k})
+	if err != nil {
+		return nil, err
+	}
+
+	added, err := DB.AddSetting(p.conf.Name, model.SettingOIDCSigningKeys, b)
+	if err != nil {
+		return nil, err
+	} else if added {
+		return []model.OIDCSigningKey{k}, nil
+	}
+
+	keys, err = p.readSigningKeys()
+	if err != nil {
+		return nil, err
+	} else if len(keys) == 0 {
+		return nil, errors.New("no id_token signing key stored")
+	}
+	return keys, nil
+}
+
+func (p OpenIDProvider) readSigningKeys() ([]model.OIDCSigningKey, error) {
+	b, err := DB.GetSetting(p.conf.Name, model.SettingOIDCSigningKeys)
+	if err != nil || len(b) == 0 {
+		return nil, err
+	}
+
+	var keys []model.OIDCSigningKey
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func parseSigningKey(cipher model.FieldCipher, k model.OIDCSigningKey) (oidcSigningKey, error) {
+	v, err := cipher.Decrypt(k.PrivateKey)
+	if err != nil {
+		return oidcSigningKey{}, err
+	}
+
+	s, _ := v.(string)
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return oidcSigningKey{}, errors.New("invalid id_token signing key")
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return oidcSigningKey{}, err
+	}
+
+	return oidcSigningKey{id: k.ID, key: key}, nil
+}
+
+func newOIDCSigningKey(cipher model.FieldCipher) (model.OIDCSigningKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return model.OIDCSigningKey{}, err
+	}
+
+	kid := make([]byte, 8)
+	if _, err := rand.Read(kid); err != nil {
+		return model.OIDCSigningKey{}, err
+	}
+
+	block := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+
+	encrypted, err := cipher.Encrypt(string(block), 1)
+	if err != nil {
+		return model.OIDCSigningKey{}, fmt.Errorf("unable to encrypt the id_token signing key: %w", err)
+	}
+
+	return model.OIDCSigningKey{
+		ID:         hex.EncodeToString(kid),
+		PrivateKey: encrypted,
+		Created:    time.Now(),
+	}, nil
+}
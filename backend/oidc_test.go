@@ -0,0 +1,56 @@
+package backend_test
+
+import (
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestOIDCJWKSPublishesStoredKeys(t *testing.T) {
+	secret := config.Current.AppSecret
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+	t.Cleanup(func() { config.Current.AppSecret = secret })
+
+	first, err := backend.DB.CreateDatabase(model.DatabaseConfig{TenantID: base.TenantID, Name: "dev_memory_jwks1", IsActive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks, err := backend.IdentityProvider(first).JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := jwks["keys"].([]map[string]string)
+	if len(keys) != 1 {
+		t.Fatalf("expected one key got %d", len(keys))
+	}
+
+	// another instance stored its key before this one needed one
+	b, err := backend.DB.GetSetting(first.Name, model.SettingOIDCSigningKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := backend.DB.CreateDatabase(model.DatabaseConfig{TenantID: base.TenantID, Name: "dev_memory_jwks2", IsActive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backend.DB.SetSetting(second.Name, model.SettingOIDCSigningKeys, b); err != nil {
+		t.Fatal(err)
+	}
+
+	jwks, err = backend.IdentityProvider(second).JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other := jwks["keys"].([]map[string]string); len(other) != 1 {
+		t.Fatalf("expected the stored key only got %d keys", len(other))
+	} else if other[0]["kid"] != keys[0]["kid"] {
+		t.Errorf("expected the stored key %s got %s", keys[0]["kid"], other[0]["kid"])
+	}
+}
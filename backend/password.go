@@ -0,0 +1,368 @@
+package backend
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/model"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+
+	// bounds of the Argon2id parameters of a password policy, the memory
+	// is in KiB
+	argon2MinMemory      = 8 * 1024
+	argon2MaxMemory      = 1024 * 1024
+	argon2MaxIterations  = 10
+	argon2MaxParallelism = 16
+)
+
+var (
+	ErrWeakPassword    = errors.New("password does not meet the password policy")
+	ErrInvalidPassword = errors.New("invalid email/password")
+	ErrPasswordHash    = errors.New("unsupported password hash")
+	ErrBannedListFile  = errors.New("banned list file must be a file name inside PASSWORD_BANNED_LISTS_DIR")
+)
+
+// PasswordPolicyError is returned when a password does not meet the
+// password policy of the database, Violations lists the rules not met.
+type PasswordPolicyError struct {
+	Violations []model.PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Message)
+	}
+	return ErrWeakPassword.Error() + ": " + strings.Join(msgs, ", ")
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// bannedPasswords holds the loaded banned password lists by file name
+var bannedPasswords sync.Map
+
+func passwordPolicyCacheKey(dbName string) string {
+	return "password-policy-" + dbName
+}
+
+// GetPasswordPolicy returns the password policy of the database, it is
+// cached until changed.
+func (u User) GetPasswordPolicy() (policy model.PasswordPolicy, err error) {
+	if err = Cache.GetTyped(passwordPolicyCacheKey(u.conf.Name), &policy); err == nil {
+		return
+	}
+
+	b, err := DB.GetSetting(u.conf.Name, model.SettingPasswordPolicy)
+	if err != nil {
+		return
+	} else if b != nil {
+		if err = json.Unmarshal(b, &policy); err != nil {
+			return
+		}
+	}
+
+	err = Cache.SetTyped(passwordPolicyCacheKey(u.conf.Name), policy)
+	return
+}
+
+// SetPasswordPolicy sets the password policy of the database. The banned
+// list file is a file name inside the PASSWORD_BANNED_LISTS_DIR directory,
+// it is reloaded and must be readable.
+func (u User) SetPasswordPolicy(policy model.PasswordPolicy) error {
+	if len(policy.Hash) > 0 && policy.Hash != model.PasswordHashBcrypt && policy.Hash != model.PasswordHashArgon2id {
+		return ErrPasswordHash
+	} else if policy.BcryptCost != 0 && (policy.BcryptCost < bcrypt.MinCost || policy.BcryptCost > bcrypt.MaxCost) {
+		return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	} else if policy.Argon2Memory != 0 && (policy.Argon2Memory < argon2MinMemory || policy.Argon2Memory > argon2MaxMemory) {
+		return fmt.Errorf("argon2 memory must be between %d and %d KiB", argon2MinMemory, argon2MaxMemory)
+	} else if policy.Argon2Iterations > argon2MaxIterations {
+		return fmt.Errorf("argon2 iterations must be between 1 and %d", argon2MaxIterations)
+	} else if policy.Argon2Parallelism > argon2MaxParallelism {
+		return fmt.Errorf("argon2 parallelism must be between 1 and %d", argon2MaxParallelism)
+	}
+
+	if len(policy.BannedListFile) > 0 {
+		bannedPasswords.Delete(policy.BannedListFile)
+		if _, err := loadBannedPasswords(policy.BannedListFile); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	if err := DB.SetSetting(u.conf.Name, model.SettingPasswordPolicy, b); err != nil {
+		return err
+	}
+	return Cache.SetTyped(passwordPolicyCacheKey(u.conf.Name), policy)
+}
+
+// ValidatePassword returns a *PasswordPolicyError if the password chosen by
+// the user of email does not meet the password policy of the database
+func (u User) ValidatePassword(email, password string) error {
+	policy, err := u.GetPasswordPolicy()
+	if err != nil {
+		return err
+	}
+
+	var violations []model.PasswordViolation
+	add := func(code, msg string) {
+		violations = append(violations, model.PasswordViolation{Code: code, Message: msg})
+	}
+
+	if utf8.RuneCountInString(password) < policy.MinLength {
+		add(model.PasswordViolationMinLength, fmt.Sprintf("must be at least %d characters", policy.MinLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r), unicode.IsSymbol(r), unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	if policy.RequireUppercase && !upper {
+		add(model.PasswordViolationUppercase, "must contain an uppercase letter")
+	}
+	if policy.RequireLowercase && !lower {
+		add(model.PasswordViolationLowercase, "must contain a lowercase letter")
+	}
+	if policy.RequireDigit && !digit {
+		add(model.PasswordViolationDigit, "must contain a digit")
+	}
+	if policy.RequireSymbol && !symbol {
+		add(model.PasswordViolationSymbol, "must contain a symbol")
+	}
+
+	if len(policy.BannedListFile) > 0 {
+		banned, err := loadBannedPasswords(policy.BannedListFile)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := banned[strings.ToLower(password)]; ok {
+			add(model.PasswordViolationBanned, "is too common")
+		}
+	}
+
+	if policy.RejectEmail && strings.EqualFold(password, strings.TrimSpace(email)) {
+		add(model.PasswordViolationEmail, "must not be your email")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// bannedListPath returns the path of a banned password list, only the files
+// of the directory configured by the operator can be used
+func bannedListPath(name string) (string, error) {
+	dir := config.Current.PasswordBannedListsDir
+	if len(dir) == 0 || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", ErrBannedListFile
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// loadBannedPasswords returns the lowercased passwords of a banned list
+// file, it is read once
+func loadBannedPasswords(name string) (map[string]struct{}, error) {
+	if v, ok := bannedPasswords.Load(name); ok {
+		return v.(map[string]struct{}), nil
+	}
+
+	path, err := bannedListPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	banned := make(map[string]struct{})
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); len(line) > 0 {
+			banned[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	bannedPasswords.Store(name, banned)
+	return banned, nil
+}
+
+// HashPassword returns the hash of a password with the algorithm and cost
+// of the password policy of the database
+func (u User) HashPassword(password string) (string, error) {
+	policy, err := u.GetPasswordPolicy()
+	if err != nil {
+		return "", err
+	}
+	return hashPassword(policy, password)
+}
+
+func hashPassword(policy model.PasswordPolicy, password string) (string, error) {
+	if policy.Algorithm() == model.PasswordHashBcrypt {
+		b, err := bcrypt.GenerateFromPassword([]byte(password), policy.Cost())
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	memory, iterations, parallelism := policy.Argon2Params()
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, argon2KeyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		memory,
+		iterations,
+		parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// argon2Hash is a decoded Argon2id password hash
+type argon2Hash struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func decodeArgon2Hash(hash string) (h argon2Hash, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != model.PasswordHashArgon2id {
+		return h, ErrPasswordHash
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return
+	} else if version != argon2.Version {
+		return h, ErrPasswordHash
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.iterations, &h.parallelism); err != nil {
+		return
+	}
+
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return
+	}
+	h.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	return
+}
+
+// comparePassword returns ErrInvalidPassword when the password does not
+// match the hash
+func comparePassword(hash, password string) error {
+	if !strings.HasPrefix(hash, "$"+model.PasswordHashArgon2id+"$") {
+		if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+			return ErrInvalidPassword
+		}
+		return nil
+	}
+
+	h, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return ErrInvalidPassword
+	}
+
+	key := argon2.IDKey([]byte(password), h.salt, h.iterations, h.memory, h.parallelism, uint32(len(h.key)))
+	if subtle.ConstantTimeCompare(key, h.key) != 1 {
+		return ErrInvalidPassword
+	}
+	return nil
+}
+
+// needsRehash returns true if a hash does not use the algorithm and cost of
+// the password policy
+func needsRehash(policy model.PasswordPolicy, hash string) bool {
+	if policy.Algorithm() == model.PasswordHashBcrypt {
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != policy.Cost()
+	}
+
+	h, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return true
+	}
+
+	memory, iterations, parallelism := policy.Argon2Params()
+	return h.memory != memory || h.iterations != iterations || h.parallelism != parallelism
+}
+
+// verifyPassword checks the password of a user and upgrades its hash to the
+// password policy once verified. A failed upgrade does not fail the sign in.
+func (u User) verifyPassword(tok model.User, password string) error {
+	if err := comparePassword(tok.Password, password); err != nil {
+		return err
+	}
+
+	policy, err := u.GetPasswordPolicy()
+	if err != nil {
+		slog.Error("error getting password policy", "db", u.conf.Name, "error", err)
+		return nil
+	} else if !needsRehash(policy, tok.Password) {
+		return nil
+	}
+
+	hash, err := hashPassword(policy, password)
+	if err != nil {
+		slog.Error("error rehashing password", "db", u.conf.Name, "error", err)
+		return nil
+	}
+
+	if err := DB.UserSetPassword(u.conf.Name, tok.ID, hash); err != nil {
+		slog.Error("error saving rehashed password", "db", u.conf.Name, "error", err)
+	}
+	return nil
+}
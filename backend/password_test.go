@@ -0,0 +1,134 @@
+package backend_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	usr := backend.Membership(base)
+
+	dir := t.TempDir()
+	config.Current.PasswordBannedListsDir = dir
+	t.Cleanup(func() { config.Current.PasswordBannedListsDir = "" })
+
+	if err := os.WriteFile(filepath.Join(dir, "banned.txt"), []byte("Password1!\nletmein\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{filepath.Join(dir, "banned.txt"), "../banned.txt", ".."} {
+		if err := usr.SetPasswordPolicy(model.PasswordPolicy{BannedListFile: name}); !errors.Is(err, backend.ErrBannedListFile) {
+			t.Errorf("expected ErrBannedListFile for %s got %v", name, err)
+		}
+	}
+
+	policy := model.PasswordPolicy{
+		MinLength:        10,
+		RequireUppercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		BannedListFile:   "banned.txt",
+		RejectEmail:      true,
+	}
+	if err := usr.SetPasswordPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.SetPasswordPolicy(model.PasswordPolicy{}) })
+
+	codes := func(err error) []string {
+		var pe *backend.PasswordPolicyError
+		if !errors.As(err, &pe) {
+			t.Fatalf("expected a PasswordPolicyError got %v", err)
+		}
+
+		var list []string
+		for _, v := range pe.Violations {
+			list = append(list, v.Code)
+		}
+		return list
+	}
+
+	got := codes(usr.ValidatePassword("policy@test.com", "short"))
+	want := []string{model.PasswordViolationMinLength, model.PasswordViolationUppercase, model.PasswordViolationDigit, model.PasswordViolationSymbol}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected violations %v got %v", want, got)
+	}
+
+	if got := codes(usr.ValidatePassword("policy@test.com", "PASSWORD1!")); len(got) != 1 || got[0] != model.PasswordViolationBanned {
+		t.Errorf("expected the banned password to be reported got %v", got)
+	}
+
+	if got := codes(usr.ValidatePassword("Policy1@test.com", "policy1@test.com")); len(got) != 2 || got[1] != model.PasswordViolationEmail {
+		t.Errorf("expected the email to be refused got %v", got)
+	}
+
+	if err := usr.ValidatePassword("policy@test.com", "Correct-horse-42"); err != nil {
+		t.Errorf("expected a valid password got %v", err)
+	}
+
+	if _, err := usr.Register("policy@test.com", "weak"); !errors.Is(err, backend.ErrWeakPassword) {
+		t.Errorf("expected ErrWeakPassword on register got %v", err)
+	}
+
+	if err := usr.SetPasswordPolicy(model.PasswordPolicy{Hash: "md5"}); !errors.Is(err, backend.ErrPasswordHash) {
+		t.Errorf("expected ErrPasswordHash got %v", err)
+	}
+
+	for _, p := range []model.PasswordPolicy{
+		{Hash: model.PasswordHashArgon2id, Argon2Memory: 2 * 1024 * 1024},
+		{Hash: model.PasswordHashArgon2id, Argon2Memory: 1024},
+		{Hash: model.PasswordHashArgon2id, Argon2Iterations: 100},
+		{Hash: model.PasswordHashArgon2id, Argon2Parallelism: 255},
+	} {
+		if err := usr.SetPasswordPolicy(p); err == nil {
+			t.Errorf("expected the argon2 parameters to be refused %v", p)
+		}
+	}
+}
+
+func TestPasswordRehashOnLogin(t *testing.T) {
+	usr := backend.Membership(base)
+
+	_, tok, err := usr.CreateAccountAndUser("rehash@test.com", "rehash1234!", 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.HasPrefix(tok.Password, "$2") {
+		t.Fatalf("expected a bcrypt hash by default got %s", tok.Password)
+	}
+
+	// small Argon2id parameters keep the test fast
+	if err := usr.SetPasswordPolicy(model.PasswordPolicy{Hash: model.PasswordHashArgon2id, Argon2Memory: 8 * 1024, Argon2Iterations: 1}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.SetPasswordPolicy(model.PasswordPolicy{}) })
+
+	if _, err := usr.Authenticate("rehash@test.com", "rehash1234!"); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := backend.DB.FindUserByEmail(base.Name, "rehash@test.com")
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.HasPrefix(check.Password, "$argon2id$v=19$m=8192,t=1,") {
+		t.Fatalf("expected the hash to be upgraded to Argon2id got %s", check.Password)
+	}
+
+	if _, err := usr.Authenticate("rehash@test.com", "rehash1234!"); err != nil {
+		t.Errorf("expected the Argon2id hash to be verified got %v", err)
+	}
+
+	if err := usr.UserSetPassword("rehash@test.com", "rehash1234!", "rehash5678!"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := usr.Authenticate("rehash@test.com", "rehash5678!"); err != nil {
+		t.Errorf("expected the new password to be accepted got %v", err)
+	}
+}
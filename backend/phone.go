@@ -0,0 +1,248 @@
+package backend
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+	"github.com/staticbackendhq/core/sms"
+)
+
+const (
+	phoneCodeTTL         = 10 * time.Minute
+	phoneCodeMaxAttempts = 5
+
+	// codes can be sent to a number every minute, up to 5 per hour
+	phoneCodeResendDelay  = time.Minute
+	phoneCodeResendWindow = time.Hour
+	phoneCodeResendMax    = 5
+
+	defaultPhoneCodeBody = "Your verification code is [code]"
+
+	phoneCodeVerify = "verify"
+	phoneCodeLogin  = "login"
+)
+
+var (
+	ErrInvalidPhoneCode  = errors.New("invalid or expired phone code")
+	ErrTooManyPhoneCodes = errors.New("too many codes sent to this phone number, please try again later")
+)
+
+// phoneCodeState is a one-time code sent to a phone number waiting to be
+// entered by the user
+type phoneCodeState struct {
+	UserID   string    `json:"userId"`
+	Number   string    `json:"number"`
+	Code     string    `json:"code"`
+	Attempts int       `json:"attempts"`
+	Expires  time.Time `json:"expires"`
+}
+
+// phoneCodeSent counts the codes sent to a number in the current window
+type phoneCodeSent struct {
+	Count  int       `json:"count"`
+	Window time.Time `json:"window"`
+	Last   time.Time `json:"last"`
+}
+
+func phoneCodeCacheKey(dbName, purpose, number string) string {
+	return "phone-code-" + purpose + "-" + dbName + "-" + number
+}
+
+func phoneCodeSentCacheKey(dbName, number string) string {
+	return "phone-code-sent-" + dbName + "-" + number
+}
+
+// GetPhone returns the verified phone number of a user, UserID is empty if
+// they have none
+func (u User) GetPhone(userID string) (model.UserPhone, error) {
+	return DB.GetUserPhone(u.conf.Name, userID)
+}
+
+// SendPhoneVerification texts a code to a phone number the user wants to
+// use, the number is theirs once the code is verified with VerifyPhone
+func (u User) SendPhoneVerification(auth model.Auth, data model.PhoneCodeData) error {
+	number, err := model.NormalizePhone(data.Phone)
+	if err != nil {
+		return err
+	}
+
+	if tok, err := DB.FindUserByPhone(u.conf.Name, number); err == nil && tok.ID != auth.UserID {
+		return model.ErrPhoneInUse
+	}
+
+	st := phoneCodeState{UserID: auth.UserID, Number: number}
+	return u.sendPhoneCode(phoneCodeVerify, st, data.Body)
+}
+
+// VerifyPhone validates the code sent by SendPhoneVerification and saves the
+// number as the user's phone, replacing their previous one
+func (u User) VerifyPhone(auth model.Auth, data model.PhoneCode) (model.UserPhone, error) {
+	number, err := model.NormalizePhone(data.Phone)
+	if err != nil {
+		return model.UserPhone{}, err
+	}
+
+	st, err := u.checkPhoneCode(phoneCodeVerify, number, data.Code)
+	if err != nil {
+		return model.UserPhone{}, err
+	} else if st.UserID != auth.UserID {
+		return model.UserPhone{}, ErrInvalidPhoneCode
+	}
+
+	if tok, err := DB.FindUserByPhone(u.conf.Name, number); err == nil && tok.ID != auth.UserID {
+		return model.UserPhone{}, model.ErrPhoneInUse
+	}
+
+	phone := model.UserPhone{UserID: auth.UserID, Number: number, Verified: time.Now()}
+	if err := DB.SaveUserPhone(u.conf.Name, phone); err != nil {
+		return model.UserPhone{}, err
+	}
+	return phone, nil
+}
+
+// RemovePhone removes the phone number of a user, they cannot sign in with
+// text-message codes anymore
+func (u User) RemovePhone(auth model.Auth) error {
+	return DB.DeleteUserPhone(u.conf.Name, auth.UserID)
+}
+
+// SetupPhoneLogin texts a sign-in code to a verified phone number. Nothing is
+// sent to unknown numbers but no error is returned so numbers of users
+// cannot be discovered.
+func (u User) SetupPhoneLogin(data model.PhoneCodeData) error {
+	number, err := model.NormalizePhone(data.Phone)
+	if err != nil {
+		return err
+	}
+
+	tok, err := DB.FindUserByPhone(u.conf.Name, number)
+	if err != nil {
+		return nil
+	}
+
+	st := phoneCodeState{UserID: tok.ID, Number: number}
+	return u.sendPhoneCode(phoneCodeLogin, st, data.Body)
+}
+
+// ValidatePhoneLogin validates a sign-in code sent by SetupPhoneLogin and
+// returns a session token on success. Codes can be used once and wrong
+// codes count as failed login attempts.
+func (u User) ValidatePhoneLogin(phone, code string) (string, error) {
+	number, err := model.NormalizePhone(phone)
+	if err != nil {
+		return "", err
+	}
+
+	guard, err := u.GuardLogin(number)
+	if err != nil {
+		return "", err
+	}
+
+	st, err := u.checkPhoneCode(phoneCodeLogin, number, code)
+	if err != nil {
+		return "", guard.Fail(err)
+	}
+
+	guard.Succeed()
+
+	tok, err := DB.FindUserByPhone(u.conf.Name, number)
+	if err != nil {
+		return "", err
+	} else if tok.ID != st.UserID {
+		return "", ErrInvalidPhoneCode
+	}
+
+	if err := u.checkEmailVerifiedLogin(tok); err != nil {
+		return "", err
+	}
+
+	if err := u.challengeIfRequired(tok, "", false); err != nil {
+		return "", err
+	}
+
+	tokens, err := u.issueSession(tok, "", false)
+	if err != nil {
+		return "", err
+	}
+
+	return tokens.Token, nil
+}
+
+// sendPhoneCode texts a new code, replacing any pending code of the same
+// number and purpose. [code] in body is replaced by the code.
+func (u User) sendPhoneCode(purpose string, st phoneCodeState, body string) error {
+	if err := u.countPhoneCode(st.Number); err != nil {
+		return err
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return err
+	}
+
+	st.Code = fmt.Sprintf("%06d", n.Int64())
+	// to accomodate unit test, we hard code the code in dev mode
+	if Config.AppEnv == "dev" {
+		st.Code = "666333"
+	}
+	st.Expires = time.Now().Add(phoneCodeTTL)
+
+	if err := Cache.SetTyped(phoneCodeCacheKey(u.conf.Name, purpose, st.Number), st); err != nil {
+		return err
+	}
+
+	if !strings.Contains(body, "[code]") {
+		body = defaultPhoneCodeBody
+	}
+
+	return SMSer.Send(sms.SMSData{
+		ToNumber: st.Number,
+		Body:     strings.ReplaceAll(body, "[code]", st.Code),
+	})
+}
+
+// countPhoneCode returns ErrTooManyPhoneCodes when the number was sent too
+// many codes recently
+func (u User) countPhoneCode(number string) error {
+	key := phoneCodeSentCacheKey(u.conf.Name, number)
+	now := time.Now()
+
+	var sent phoneCodeSent
+	if err := Cache.GetTyped(key, &sent); err != nil || now.Sub(sent.Window) >= phoneCodeResendWindow {
+		sent = phoneCodeSent{Window: now}
+	} else if now.Sub(sent.Last) < phoneCodeResendDelay || sent.Count >= phoneCodeResendMax {
+		return ErrTooManyPhoneCodes
+	}
+
+	sent.Count++
+	sent.Last = now
+	return Cache.SetTyped(key, sent)
+}
+
+// checkPhoneCode returns the pending code of a number if it matches code.
+// The pending code is removed once used or after too many wrong attempts.
+func (u User) checkPhoneCode(purpose, number, code string) (st phoneCodeState, err error) {
+	key := phoneCodeCacheKey(u.conf.Name, purpose, number)
+
+	if err := Cache.GetTyped(key, &st); err != nil || time.Now().After(st.Expires) {
+		return st, ErrInvalidPhoneCode
+	}
+
+	if subtle.ConstantTimeCompare([]byte(code), []byte(st.Code)) != 1 {
+		st.Attempts++
+		if st.Attempts >= phoneCodeMaxAttempts {
+			_ = Cache.Delete(key)
+		} else if err := Cache.SetTyped(key, st); err != nil {
+			return st, err
+		}
+		return st, ErrInvalidPhoneCode
+	}
+
+	return st, Cache.Delete(key)
+}
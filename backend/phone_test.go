@@ -0,0 +1,125 @@
+package backend_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+	"github.com/staticbackendhq/core/sms"
+)
+
+var smsCodeRe = regexp.MustCompile(`[0-9]{6}`)
+
+// smsStandIn captures the text-messages instead of sending them
+type smsStandIn struct {
+	sent []sms.SMSData
+}
+
+func (s *smsStandIn) Send(data sms.SMSData) error {
+	s.sent = append(s.sent, data)
+	return nil
+}
+
+// lastCode returns the code of the last text-message sent to number
+func (s *smsStandIn) lastCode(t *testing.T, number string) string {
+	t.Helper()
+
+	for i := len(s.sent) - 1; i >= 0; i-- {
+		if s.sent[i].ToNumber == number {
+			return smsCodeRe.FindString(s.sent[i].Body)
+		}
+	}
+	t.Fatalf("no text-message was sent to %s", number)
+	return ""
+}
+
+func TestPhoneLogin(t *testing.T) {
+	sender := &smsStandIn{}
+	prev := backend.SMSer
+	backend.SMSer = sender
+	t.Cleanup(func() { backend.SMSer = prev })
+
+	const (
+		email  = "phone-login@test.com"
+		number = "+15145550101"
+	)
+
+	usr := backend.Membership(base)
+
+	// a failed attempt would delay the next one
+	if err := usr.SetLoginProtectionPolicy(model.LoginProtectionPolicy{Disabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.SetLoginProtectionPolicy(model.LoginProtectionPolicy{}) })
+
+	_, tok, err := usr.CreateAccountAndUser(email, "test1234!", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := model.Auth{AccountID: tok.AccountID, UserID: tok.ID, Email: tok.Email, Token: tok.Token}
+
+	if err := usr.SendPhoneVerification(auth, model.PhoneCodeData{Phone: "514-555"}); !errors.Is(err, model.ErrInvalidPhone) {
+		t.Errorf("expected ErrInvalidPhone got %v", err)
+	}
+
+	// nothing is sent to numbers not verified by a user
+	if err := usr.SetupPhoneLogin(model.PhoneCodeData{Phone: number}); err != nil {
+		t.Fatal(err)
+	} else if len(sender.sent) > 0 {
+		t.Fatalf("expected no text-message got %v", sender.sent)
+	}
+
+	data := model.PhoneCodeData{Phone: "+1 514 555 0101", Body: "Code: [code]"}
+	if err := usr.SendPhoneVerification(auth, data); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := usr.SendPhoneVerification(auth, data); !errors.Is(err, backend.ErrTooManyPhoneCodes) {
+		t.Errorf("expected an immediate resend to be refused got %v", err)
+	}
+
+	code := sender.lastCode(t, number)
+	if _, err := usr.VerifyPhone(auth, model.PhoneCode{Phone: number, Code: "000000"}); !errors.Is(err, backend.ErrInvalidPhoneCode) {
+		t.Errorf("expected ErrInvalidPhoneCode got %v", err)
+	}
+
+	phone, err := usr.VerifyPhone(auth, model.PhoneCode{Phone: number, Code: code})
+	if err != nil {
+		t.Fatal(err)
+	} else if phone.Number != number || phone.UserID != tok.ID {
+		t.Errorf("expected %s to be verified for %s got %v", number, tok.ID, phone)
+	}
+
+	// skips the resend delay of the number
+	_ = backend.Cache.Delete("phone-code-sent-" + base.Name + "-" + number)
+
+	if err := usr.SetupPhoneLogin(model.PhoneCodeData{Phone: number}); err != nil {
+		t.Fatal(err)
+	}
+	code = sender.lastCode(t, number)
+
+	for i := 0; i < 5; i++ {
+		if _, err := usr.ValidatePhoneLogin(number, "000000"); !errors.Is(err, backend.ErrInvalidPhoneCode) {
+			t.Fatalf("expected ErrInvalidPhoneCode got %v", err)
+		}
+	}
+
+	if _, err := usr.ValidatePhoneLogin(number, code); !errors.Is(err, backend.ErrInvalidPhoneCode) {
+		t.Errorf("expected the code to be discarded after too many attempts got %v", err)
+	}
+
+	_ = backend.Cache.Delete("phone-code-sent-" + base.Name + "-" + number)
+
+	if err := usr.SetupPhoneLogin(model.PhoneCodeData{Phone: number}); err != nil {
+		t.Fatal(err)
+	}
+
+	jwt, err := usr.ValidatePhoneLogin(number, sender.lastCode(t, number))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(jwt) == 0 {
+		t.Error("expected a session token")
+	}
+}
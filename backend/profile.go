@@ -0,0 +1,131 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func profilePolicyCacheKey(dbName string) string {
+	return "profile-policy-" + dbName
+}
+
+// GetProfilePolicy returns the profile policy of the database, it is cached
+// until changed.
+func (u User) GetProfilePolicy() (policy model.ProfilePolicy, err error) {
+	// requests not tied to a database have no custom claims
+	if len(u.conf.Name) == 0 {
+		return
+	}
+
+	if err = Cache.GetTyped(profilePolicyCacheKey(u.conf.Name), &policy); err == nil {
+		return
+	}
+
+	b, err := DB.GetSetting(u.conf.Name, model.SettingProfilePolicy)
+	if err != nil {
+		return
+	} else if b != nil {
+		if err = json.Unmarshal(b, &policy); err != nil {
+			return
+		}
+	}
+
+	err = Cache.SetTyped(profilePolicyCacheKey(u.conf.Name), policy)
+	return
+}
+
+// SetProfilePolicy sets the profile policy of the database. Session tokens
+// issued before keep their claims until they expire.
+func (u User) SetProfilePolicy(policy model.ProfilePolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	if err := DB.SetSetting(u.conf.Name, model.SettingProfilePolicy, b); err != nil {
+		return err
+	}
+	return Cache.SetTyped(profilePolicyCacheKey(u.conf.Name), policy)
+}
+
+// GetProfile returns the profile of a user
+func (u User) GetProfile(userID string) (model.UserProfile, error) {
+	p, err := DB.GetUserProfile(u.conf.Name, userID)
+	if err != nil {
+		return p, err
+	}
+
+	if p.Public == nil {
+		p.Public = make(map[string]any)
+	}
+	if p.Private == nil {
+		p.Private = make(map[string]any)
+	}
+	return p, nil
+}
+
+// UpdateProfile changes the fields of a user profile. Users cannot change
+// the read-only fields of the profile policy, root can with asRoot.
+func (u User) UpdateProfile(userID string, update model.ProfileUpdate, asRoot bool) (model.UserProfile, error) {
+	if !asRoot {
+		policy, err := u.GetProfilePolicy()
+		if err != nil {
+			return model.UserProfile{}, err
+		}
+
+		for _, field := range update.Fields() {
+			if slices.Contains(policy.ReadOnly, field) {
+				return model.UserProfile{}, fmt.Errorf("%w: %s", model.ErrProfileFieldReadOnly, field)
+			}
+		}
+	}
+
+	p, err := u.GetProfile(userID)
+	if err != nil {
+		return p, err
+	}
+
+	p = update.Apply(p)
+	p.Updated = time.Now()
+
+	if err := DB.SaveUserProfile(u.conf.Name, p); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// profileClaims returns the custom claims of a user's session tokens
+func (u User) profileClaims(userID string) (map[string]any, error) {
+	policy, err := u.GetProfilePolicy()
+	if err != nil || len(policy.Claims) == 0 {
+		return nil, err
+	}
+
+	p, err := u.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	return policy.ClaimsOf(p), nil
+}
+
+// GetJWT signs a token including the custom claims of the user's profile,
+// see SetProfilePolicy. The token has no session and is only accepted as a
+// user's session token while signed with HS256, see GetAuthToken.
+func (u User) GetJWT(token string) ([]byte, error) {
+	userID, _, _ := strings.Cut(token, "|")
+
+	claims, err := u.profileClaims(userID)
+	if err != nil {
+		return nil, err
+	}
+	return signJWT(token, "", sessionTokenTTL, claims)
+}
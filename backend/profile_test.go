@@ -0,0 +1,56 @@
+package backend_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestProfileClaims(t *testing.T) {
+	usr := backend.Membership(base)
+
+	policy := model.ProfilePolicy{
+		Claims:   []string{"private.tier"},
+		ReadOnly: []string{"private.tier"},
+	}
+	if err := usr.SetProfilePolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.SetProfilePolicy(model.ProfilePolicy{}) })
+
+	_, user, err := usr.CreateUser(adminAuth.AccountID, "profile-claims@test.com", "profile1234!", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	update := model.ProfileUpdate{Private: map[string]any{"tier": "gold"}}
+	if _, err := usr.UpdateProfile(user.ID, update, false); !errors.Is(err, model.ErrProfileFieldReadOnly) {
+		t.Errorf("expected ErrProfileFieldReadOnly got %v", err)
+	}
+
+	if _, err := usr.UpdateProfile(user.ID, update, true); err != nil {
+		t.Fatal(err)
+	}
+
+	update = model.ProfileUpdate{Public: map[string]any{"displayName": "Gold"}}
+	p, err := usr.UpdateProfile(user.ID, update, false)
+	if err != nil {
+		t.Fatal(err)
+	} else if p.Public["displayName"] != "Gold" || p.Private["tier"] != "gold" {
+		t.Errorf("expected both parts to be kept got %v", p)
+	}
+
+	token, err := usr.GetJWT(user.ID + "|" + user.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pl model.JWTPayload
+	if _, err := model.JWTKeys.Verify(token, &pl); err != nil {
+		t.Fatal(err)
+	} else if len(pl.Claims) != 1 || pl.Claims["tier"] != "gold" {
+		t.Errorf("expected the tier claim got %v", pl.Claims)
+	}
+}
@@ -0,0 +1,52 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// GetCustomRoles returns the custom roles of the database
+func (u User) GetCustomRoles() (model.CustomRoles, error) {
+	roles, err := middleware.LoadCustomRoles(DB, Cache, u.conf.Name)
+	if err != nil {
+		return nil, err
+	} else if roles == nil {
+		roles = model.CustomRoles{}
+	}
+	return roles, nil
+}
+
+// GetCustomRole returns a custom role of the database
+func (u User) GetCustomRole(name string) (model.CustomRole, error) {
+	roles, err := u.GetCustomRoles()
+	if err != nil {
+		return model.CustomRole{}, err
+	}
+
+	role, ok := roles.Find(name)
+	if !ok {
+		return model.CustomRole{}, fmt.Errorf("%w: %s does not exist", model.ErrInvalidRole, name)
+	}
+	return role, nil
+}
+
+// SetCustomRoles replaces the custom roles of the database. Users of a
+// removed role fall back to their integer role.
+func (u User) SetCustomRoles(roles model.CustomRoles) error {
+	if err := roles.Validate(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(roles)
+	if err != nil {
+		return err
+	}
+
+	if err := DB.SetSetting(u.conf.Name, model.SettingCustomRoles, b); err != nil {
+		return err
+	}
+	return Cache.SetTyped(middleware.CustomRolesCacheKey(u.conf.Name), roles)
+}
@@ -0,0 +1,95 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	sbquery "github.com/staticbackendhq/core/internal/query"
+	"github.com/staticbackendhq/core/model"
+)
+
+// maxSearchMatches is the maximum number of full-text matches combined with
+// the query filters
+const maxSearchMatches = 1000
+
+var ErrSearchNotEnabled = errors.New("full-text search is not enabled")
+
+// SearchDocuments returns the documents matching the full-text keywords and
+// the filters with the read permissions of auth. Results are sorted by
+// relevance unless params.SortBy is set, the Scores of the returned
+// PagedResult holds the relevance score of each result.
+func SearchDocuments(auth model.Auth, dbName, col, keywords string, filters map[string]any, params model.ListParams) (model.PagedResult, error) {
+	result := model.PagedResult{
+		Page:    params.Page,
+		Size:    params.Size,
+		Results: []map[string]any{},
+		Scores:  make(map[string]float64),
+	}
+
+	if Search == nil {
+		return result, ErrSearchNotEnabled
+	}
+
+	sr, err := Search.SearchWithSize(dbName, col, keywords, maxSearchMatches)
+	if err != nil {
+		return result, err
+	} else if len(sr.IDs) == 0 {
+		return result, nil
+	}
+
+	if len(params.SortBy) > 0 {
+		r, err := DB.QueryDocuments(auth, dbName, col, sbquery.WithIDs(filters, sr.IDs), params)
+		if err != nil {
+			return result, err
+		}
+
+		result.Total = r.Total
+		if r.Results != nil {
+			result.Results = r.Results
+		}
+		addScores(&result, sr.Scores)
+		return result, nil
+	}
+
+	// sorting by relevance, all permitted matches are fetched to be paged
+	// in the full-text order
+	all, err := DB.QueryDocuments(auth, dbName, col, sbquery.WithIDs(filters, sr.IDs), model.ListParams{
+		Page: 1,
+		Size: int64(len(sr.IDs)),
+	})
+	if err != nil {
+		return result, err
+	}
+
+	rank := make(map[string]int, len(sr.IDs))
+	for i, id := range sr.IDs {
+		rank[id] = i
+	}
+
+	docs := all.Results
+	slices.SortStableFunc(docs, func(a, b map[string]any) int {
+		return rank[docID(a)] - rank[docID(b)]
+	})
+
+	result.Total = int64(len(docs))
+
+	page, size := max(params.Page, 1), max(params.Size, 1)
+	start := min((page-1)*size, result.Total)
+	end := min(start+size, result.Total)
+	result.Results = append(result.Results, docs[start:end]...)
+
+	addScores(&result, sr.Scores)
+	return result, nil
+}
+
+func addScores(result *model.PagedResult, scores map[string]float64) {
+	for _, doc := range result.Results {
+		id := docID(doc)
+		result.Scores[id] = scores[id]
+	}
+}
+
+func docID(doc map[string]any) string {
+	return fmt.Sprintf("%v", doc["id"])
+}
@@ -0,0 +1,272 @@
+package backend
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	// sessionTokenTTL is the lifetime of session tokens issued without a
+	// refresh token
+	sessionTokenTTL = 12 * time.Hour
+	// sessionAccessTTL is the lifetime of access tokens issued with a
+	// refresh token
+	sessionAccessTTL = 15 * time.Minute
+	// sessionRefreshTTL is how long a session stays valid without being
+	// refreshed
+	sessionRefreshTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token already used, the session has been revoked")
+)
+
+// WithClient returns a User recording the user agent and IP address of the
+// device on the sessions it starts.
+func (u User) WithClient(userAgent, ip string) User {
+	u.userAgent = userAgent
+	u.ip = ip
+	return u
+}
+
+// startSession creates a session for an authenticated user and returns its
+// tokens. Without refresh the session token is valid for 12 hours.
+func (u User) startSession(auth model.Auth, refresh bool) (tokens model.SessionTokens, err error) {
+	return u.startClientSession(auth, refresh, "", "")
+}
+
+// startClientSession is like startSession for an OAuth client of the
+// database identity provider. The access tokens of the session are bound to
+// the client and its scope.
+func (u User) startClientSession(auth model.Auth, refresh bool, clientID, scope string) (tokens model.SessionTokens, err error) {
+	now := time.Now()
+
+	s := model.Session{
+		UserID:    auth.UserID,
+		AccountID: auth.AccountID,
+		UserAgent: u.userAgent,
+		IP:        u.ip,
+		Created:   now,
+		LastSeen:  now,
+		Expires:   now.Add(sessionTokenTTL),
+		ClientID:  clientID,
+		Scope:     scope,
+	}
+
+	ttl := sessionTokenTTL
+	secret := ""
+	if refresh {
+		secret, err = newRefreshSecret()
+		if err != nil {
+			return
+		}
+
+		ttl = sessionAccessTTL
+		s.RefreshToken = hashRefreshSecret(secret)
+		s.Expires = now.Add(sessionRefreshTTL)
+	}
+
+	s.ID, err = DB.CreateSession(u.conf.Name, s)
+	if err != nil {
+		return
+	}
+
+	return u.sessionTokens(auth, s, secret, ttl)
+}
+
+func (u User) sessionTokens(auth model.Auth, s model.Session, secret string, ttl time.Duration) (tokens model.SessionTokens, err error) {
+	token := auth.ReconstructToken()
+
+	var jwtBytes []byte
+	if len(s.ClientID) > 0 {
+		jwtBytes, err = signClientJWT(token, s, ttl, newOpenIDProvider(u.conf).Issuer)
+	} else {
+		var claims map[string]any
+		claims, err = u.profileClaims(auth.UserID)
+		if err != nil {
+			return
+		}
+
+		jwtBytes, err = signJWT(token, s.ID, ttl, claims)
+	}
+	if err != nil {
+		return
+	}
+
+	if err = u.cacheAuth(token, auth); err != nil {
+		return
+	}
+
+	tokens = model.SessionTokens{
+		SessionID: s.ID,
+		Token:     string(jwtBytes),
+		Expires:   time.Now().Add(ttl),
+	}
+	if len(secret) > 0 {
+		tokens.RefreshToken = s.ID + "." + secret
+	}
+	return
+}
+
+// RefreshSession exchanges a refresh token for a new access token and a new
+// refresh token. A refresh token can only be used once, using it again
+// revokes the session as the token has likely been stolen.
+func (u User) RefreshSession(refreshToken string) (model.SessionTokens, error) {
+	id, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || len(secret) == 0 {
+		return model.SessionTokens{}, ErrInvalidRefreshToken
+	}
+
+	s, err := DB.GetSession(u.conf.Name, id)
+	if err != nil || len(s.RefreshToken) == 0 {
+		return model.SessionTokens{}, ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(s.Expires) {
+		return model.SessionTokens{}, errors.Join(ErrInvalidRefreshToken, u.revokeSession(s.ID))
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashRefreshSecret(secret)), []byte(s.RefreshToken)) != 1 {
+		return model.SessionTokens{}, errors.Join(ErrRefreshTokenReused, u.revokeSession(s.ID))
+	}
+
+	auth, err := u.sessionAuth(s)
+	if err != nil {
+		return model.SessionTokens{}, errors.Join(ErrInvalidRefreshToken, u.revokeSession(s.ID))
+	}
+
+	secret, err = newRefreshSecret()
+	if err != nil {
+		return model.SessionTokens{}, err
+	}
+
+	now := time.Now()
+	s.RefreshToken = hashRefreshSecret(secret)
+	s.LastSeen = now
+	s.Expires = now.Add(sessionRefreshTTL)
+	if err := DB.UpdateSession(u.conf.Name, s); err != nil {
+		return model.SessionTokens{}, err
+	}
+	if err := u.touchGuest(s.UserID, now); err != nil {
+		return model.SessionTokens{}, err
+	}
+
+	return u.sessionTokens(auth, s, secret, sessionAccessTTL)
+}
+
+// sessionAuth returns the authenticated user of a session, either from a
+// cross-account association or from the user's home account.
+func (u User) sessionAuth(s model.Session) (model.Auth, error) {
+	if assoc, err := DB.GetAccountUser(u.conf.Name, s.UserID, s.AccountID); err == nil {
+		return model.Auth{
+			AccountID: assoc.AccountID,
+			UserID:    assoc.UserID,
+			Email:     assoc.Email,
+			Role:      assoc.Role,
+			Token:     assoc.Token,
+		}, nil
+	}
+
+	tok, err := DB.GetUserByID(u.conf.Name, s.AccountID, s.UserID)
+	if err != nil {
+		return model.Auth{}, err
+	} else if tok.AccountID != s.AccountID {
+		return model.Auth{}, ErrSessionNotFound
+	}
+
+	return model.Auth{
+		AccountID: tok.AccountID,
+		UserID:    tok.ID,
+		Email:     tok.Email,
+		Role:      tok.Role,
+		Token:     tok.Token,
+	}, nil
+}
+
+// ListSessions returns the active sessions of the authenticated user. The
+// session of the current token is flagged as current.
+func (u User) ListSessions(auth model.Auth) ([]model.Session, error) {
+	list, err := DB.ListSessions(u.conf.Name, auth.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sessions := make([]model.Session, 0, len(list))
+	for _, s := range list {
+		if now.After(s.Expires) {
+			continue
+		}
+
+		s.Current = s.ID == auth.SessionID
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs out one of the authenticated user's sessions
+func (u User) RevokeSession(auth model.Auth, id string) error {
+	s, err := DB.GetSession(u.conf.Name, id)
+	if err != nil || s.UserID != auth.UserID {
+		return ErrSessionNotFound
+	}
+
+	return u.revokeSession(id)
+}
+
+// Logout revokes the session of the current token
+func (u User) Logout(auth model.Auth) error {
+	if len(auth.SessionID) == 0 {
+		return nil
+	}
+	return u.revokeSession(auth.SessionID)
+}
+
+// RevokeAllSessions signs out all devices of a user
+func (u User) RevokeAllSessions(userID string) error {
+	sessions, err := DB.ListSessions(u.conf.Name, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := DB.DeleteUserSessions(u.conf.Name, userID); err != nil {
+		return err
+	}
+
+	for _, s := range sessions {
+		if err := Cache.Delete(middleware.SessionCacheKey(s.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u User) revokeSession(id string) error {
+	if err := DB.DeleteSession(u.conf.Name, id); err != nil {
+		return err
+	}
+	return Cache.Delete(middleware.SessionCacheKey(id))
+}
+
+func newRefreshSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashRefreshSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,173 @@
+package backend_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestSessionRefreshRotation(t *testing.T) {
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+
+	const (
+		email    = "session-refresh@test.com"
+		password = "test1234!"
+	)
+
+	usr := backend.Membership(base).WithClient("unit test", "127.0.0.1")
+	if _, _, err := usr.CreateAccountAndUser(email, password, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := usr.Login(email, password)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(tokens.Token) == 0 || len(tokens.RefreshToken) == 0 {
+		t.Fatalf("expected access and refresh tokens got %v", tokens)
+	}
+
+	refreshed, err := usr.RefreshSession(tokens.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	} else if refreshed.SessionID != tokens.SessionID {
+		t.Errorf("expected session %s got %s", tokens.SessionID, refreshed.SessionID)
+	} else if refreshed.RefreshToken == tokens.RefreshToken {
+		t.Error("expected the refresh token to be rotated")
+	}
+
+	// using a rotated refresh token again revokes the session
+	if _, err := usr.RefreshSession(tokens.RefreshToken); !errors.Is(err, backend.ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused got %v", err)
+	}
+
+	if _, err := usr.RefreshSession(refreshed.RefreshToken); !errors.Is(err, backend.ErrInvalidRefreshToken) {
+		t.Errorf("expected ErrInvalidRefreshToken got %v", err)
+	}
+}
+
+func TestRevokeSessions(t *testing.T) {
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+
+	const (
+		email    = "session-revoke@test.com"
+		password = "test1234!"
+	)
+
+	usr := backend.Membership(base).WithClient("unit test", "127.0.0.1")
+	_, tok, err := usr.CreateAccountAndUser(email, password, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := usr.Login(email, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := usr.Authenticate(email, password); err != nil {
+		t.Fatal(err)
+	}
+
+	auth := model.Auth{AccountID: tok.AccountID, UserID: tok.ID, Email: email, SessionID: current.SessionID}
+
+	sessions, err := usr.ListSessions(auth)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(sessions) != 3 {
+		// creating the user started a session too
+		t.Fatalf("expected 3 sessions got %d", len(sessions))
+	}
+
+	other := sessions[0]
+	if other.Current {
+		other = sessions[1]
+	}
+	if other.Current || other.UserAgent != "unit test" || other.IP != "127.0.0.1" {
+		t.Fatalf("unexpected session %v", other)
+	}
+
+	if err := usr.RevokeSession(model.Auth{UserID: "someone-else"}, other.ID); !errors.Is(err, backend.ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound got %v", err)
+	}
+
+	if err := usr.RevokeSession(auth, other.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := usr.ChangeEmail(auth, "session-revoke-new@test.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err = usr.ListSessions(auth)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(sessions) != 0 {
+		t.Errorf("expected the email change to revoke all sessions got %d", len(sessions))
+	}
+}
+
+func TestRevokeAllSessionsAuthToken(t *testing.T) {
+	usr := backend.Membership(base)
+	_, tok, err := usr.CreateAccountAndUser("session-authtoken@test.com", "test1234!", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := usr.GetAuthToken(tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.WithValue(context.Background(), middleware.ContextBase, base)
+	if _, err := middleware.ValidateAuthKey(backend.DB, backend.Cache, ctx, string(token)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := usr.RevokeAllSessions(tok.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := middleware.ValidateAuthKey(backend.DB, backend.Cache, ctx, string(token)); err == nil {
+		t.Error("expected the token to be revoked with the sessions")
+	}
+
+	// tokens issued before sessions have none, they are accepted as long as
+	// HS256 tokens are
+	legacy, err := backend.GetJWT(tok.ID + "|" + tok.Token)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := middleware.ValidateAuthKey(backend.DB, backend.Cache, ctx, string(legacy)); err != nil {
+		t.Errorf("expected an HS256 token without a session to be accepted got %v", err)
+	}
+
+	secret := config.Current.AppSecret
+	config.Current.AppSecret = "a-very-long-key-should-be-32long"
+	t.Cleanup(func() {
+		config.Current.AppSecret = secret
+		_ = backend.ReloadJWTKeys()
+	})
+
+	k, err := model.NewSigningKey(model.JWTAlgRS256)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := model.JWTKeys.Load([]model.SigningKey{k}, 0, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := middleware.ValidateAuthKey(backend.DB, backend.Cache, ctx, string(legacy)); err == nil {
+		t.Error("expected the HS256 token to be rejected after the migration window")
+	}
+
+	unbound, err := backend.GetJWT(tok.ID + "|" + tok.Token)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := middleware.ValidateAuthKey(backend.DB, backend.Cache, ctx, string(unbound)); err == nil {
+		t.Error("expected a signing key token without a session to be rejected")
+	}
+}
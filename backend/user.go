@@ -1,6 +1,7 @@
 package backend
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,17 +13,30 @@ import (
 	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/staticbackendhq/core/email"
 	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/middleware"
 	"github.com/staticbackendhq/core/model"
-	"golang.org/x/crypto/bcrypt"
 )
 
-const systemAccountTrigger = "sys-sb_accounts"
+const (
+	systemAccountTrigger = "sys-sb_accounts"
 
-var ErrEmailAlreadyInUse = errors.New("email already in use")
+	// a magic link code is removed after this number of wrong codes
+	magicLinkMaxAttempts = 5
+)
+
+var (
+	ErrEmailAlreadyInUse    = errors.New("email already in use")
+	ErrEmailChangePending   = errors.New("a verification email was sent to the new address")
+	ErrInvalidMagicLinkCode = errors.New("invalid or expired magic link code")
+)
 
 // User handles everything related to accounts and users inside a database
 type User struct {
 	conf model.DatabaseConfig
+
+	// client describes the device signing in, see WithClient
+	userAgent string
+	ip        string
 }
 
 func newUser(base model.DatabaseConfig) User {
@@ -32,49 +46,90 @@ func newUser(base model.DatabaseConfig) User {
 // Authenticate tries to authenticate an email/password and return a session token.
 // An optional accountID can be provided to log into a cross-account association
 // instead of the user's home account.
+//
+// When the user must provide a TOTP code the returned error is an
+// *MFAChallengeError holding the challenge to complete with CompleteMFALogin.
 func (u User) Authenticate(email, password string, accountID ...string) (string, error) {
+	tokens, err := u.authenticate(email, password, false, accountID...)
+	if err != nil {
+		return "", err
+	}
+	return tokens.Token, nil
+}
+
+// Login is like Authenticate but returns a short-lived access token and a
+// refresh token to exchange for new tokens with RefreshSession.
+func (u User) Login(email, password string, accountID ...string) (model.SessionTokens, error) {
+	return u.authenticate(email, password, true, accountID...)
+}
+
+func (u User) authenticate(email, password string, refresh bool, accountID ...string) (model.SessionTokens, error) {
 	email = strings.ToLower(email)
 
+	guard, err := u.GuardLogin(email)
+	if err != nil {
+		return model.SessionTokens{}, err
+	}
+
 	tok, err := DB.FindUserByEmail(u.conf.Name, email)
 	if err != nil {
-		return "", err
+		return model.SessionTokens{}, guard.Fail(err)
+	}
+
+	if err = u.verifyPassword(tok, password); err != nil {
+		return model.SessionTokens{}, guard.Fail(err)
 	}
+	guard.Succeed()
 
-	if err = bcrypt.CompareHashAndPassword([]byte(tok.Password), []byte(password)); err != nil {
-		return "", errors.New("invalid email/password")
+	target := ""
+	if len(accountID) > 0 {
+		target = accountID[0]
 	}
 
-	var auth model.Auth
+	if err := u.checkEmailVerifiedLogin(tok); err != nil {
+		return model.SessionTokens{}, err
+	}
+
+	if err := u.challengeIfRequired(tok, target, refresh); err != nil {
+		return model.SessionTokens{}, err
+	}
+
+	return u.issueSession(tok, target, refresh)
+}
+
+// issueSession starts a session for the user's home account or for a
+// cross-account association when accountID differs from the home account.
+func (u User) issueSession(tok model.User, accountID string, refresh bool) (model.SessionTokens, error) {
+	auth := model.Auth{
+		AccountID: tok.AccountID,
+		UserID:    tok.ID,
+		Email:     tok.Email,
+		Role:      tok.Role,
+		Token:     tok.Token,
+	}
 
 	// if an accountID is provided and differs from the home account, look up the association
-	if len(accountID) > 0 && accountID[0] != "" && accountID[0] != tok.AccountID {
-		exists, err := DB.AssociationExists(u.conf.Name, tok.ID, accountID[0])
+	if accountID != "" && accountID != tok.AccountID {
+		exists, err := DB.AssociationExists(u.conf.Name, tok.ID, accountID)
 		if err != nil {
-			return "", err
+			return model.SessionTokens{}, err
 		}
 		if !exists {
 			assoc := model.AccountUser{
 				UserID:    tok.ID,
-				AccountID: accountID[0],
+				AccountID: accountID,
 				Email:     tok.Email,
 				Role:      0,
 				Token:     DB.NewID(),
 			}
 			if _, err := DB.AddAccountUser(u.conf.Name, assoc); err != nil {
-				return "", err
+				return model.SessionTokens{}, err
 			}
 		}
 
-		assoc, err := DB.GetAccountUser(u.conf.Name, tok.ID, accountID[0])
-		if err != nil {
-			return "", errors.New("invalid email/password")
-		}
-
-		token := fmt.Sprintf("%s|%s", tok.ID, assoc.Token)
-
-		jwtBytes, err := GetJWT(token)
+		assoc, err := DB.GetAccountUser(u.conf.Name, tok.ID, accountID)
 		if err != nil {
-			return "", err
+			return model.SessionTokens{}, errors.New("invalid email/password")
 		}
 
 		auth = model.Auth{
@@ -84,48 +139,17 @@ func (u User) Authenticate(email, password string, accountID ...string) (string,
 			Role:      assoc.Role,
 			Token:     assoc.Token,
 		}
-
-		if err = Cache.SetTyped(token, auth); err != nil {
-			return "", err
-		}
-		if err = Cache.SetTyped("base:"+token, u.conf); err != nil {
-			return "", err
-		}
-
-		return string(jwtBytes), nil
-	}
-
-	token := fmt.Sprintf("%s|%s", tok.ID, tok.Token)
-
-	jwtBytes, err := GetJWT(token)
-	if err != nil {
-		return "", err
 	}
 
-	auth = model.Auth{
-		AccountID: tok.AccountID,
-		UserID:    tok.ID,
-		Email:     tok.Email,
-		Role:      tok.Role,
-		Token:     tok.Token,
-	}
-
-	//TODO: find a good way to find all occurences of those two
-	// and make them easily callable via a shared function
-	if err = Cache.SetTyped(token, auth); err != nil {
-		return "", err
-	}
-	if err = Cache.SetTyped("base:"+token, u.conf); err != nil {
-		return "", err
-	}
-
-	return string(jwtBytes), nil
+	return u.startSession(auth, refresh)
 }
 
 // Register creates a new account and user.
 // An optional accountID can be provided when the email already exists in the schema
 // but the user wants to join an additional account. In that case the password is
 // verified against the existing record and a cross-account association is created.
+// The existing user signs in like with Authenticate, the error is an
+// *MFAChallengeError when they must provide a TOTP code.
 func (u User) Register(email, password string, accountID ...string) (string, error) {
 	email = strings.ToLower(email)
 
@@ -140,13 +164,24 @@ func (u User) Register(email, password string, accountID ...string) (string, err
 			return "", errors.New("invalid email")
 		}
 
-		// verify credentials against the existing record
-		tok, err := DB.FindUserByEmail(u.conf.Name, email)
+		// verify credentials against the existing record, the attempts are
+		// guarded like the logins
+		guard, err := u.GuardLogin(email)
 		if err != nil {
 			return "", err
 		}
-		if err = bcrypt.CompareHashAndPassword([]byte(tok.Password), []byte(password)); err != nil {
-			return "", errors.New("invalid email/password")
+
+		tok, err := DB.FindUserByEmail(u.conf.Name, email)
+		if err != nil {
+			return "", guard.Fail(err)
+		}
+		if err = u.verifyPassword(tok, password); err != nil {
+			return "", guard.Fail(err)
+		}
+		guard.Succeed()
+
+		if err := u.checkEmailVerifiedLogin(tok); err != nil {
+			return "", err
 		}
 
 		// refuse if already associated with this account
@@ -157,47 +192,32 @@ func (u User) Register(email, password string, accountID ...string) (string, err
 			return "", errors.New("already a member of this account")
 		}
 
-		assoc := model.AccountUser{
-			UserID:    tok.ID,
-			AccountID: accountID[0],
-			Email:     tok.Email,
-			Role:      0,
-			Token:     DB.NewID(),
-		}
-		if _, err := DB.AddAccountUser(u.conf.Name, assoc); err != nil {
+		// the association is created by issueSession, once the TOTP code is
+		// provided for users with MFA
+		if err := u.challengeIfRequired(tok, accountID[0], false); err != nil {
 			return "", err
 		}
 
-		token := fmt.Sprintf("%s|%s", tok.ID, assoc.Token)
-		jwtBytes, err := GetJWT(token)
+		tokens, err := u.issueSession(tok, accountID[0], false)
 		if err != nil {
 			return "", err
 		}
+		return tokens.Token, nil
+	}
 
-		auth := model.Auth{
-			AccountID: assoc.AccountID,
-			UserID:    tok.ID,
-			Email:     assoc.Email,
-			Role:      assoc.Role,
-			Token:     assoc.Token,
-		}
-		if err := Cache.SetTyped(token, auth); err != nil {
-			return "", err
-		}
-		if err := Cache.SetTyped("base:"+token, u.conf); err != nil {
-			return "", err
-		}
-
-		return string(jwtBytes), nil
+	if err := u.ValidatePassword(email, password); err != nil {
+		return "", err
 	}
 
 	// account creator has role=50 (Account Admin)
-	jwtBytes, tok, err := u.CreateAccountAndUser(email, password, 50)
+	tok, err := u.createAccountAndUser(email, password, 50)
 	if err != nil {
 		return "", err
 	}
 
-	token := string(jwtBytes)
+	if err := u.verifyNewUser(tok); err != nil {
+		return "", err
+	}
 
 	auth := model.Auth{
 		AccountID: tok.AccountID,
@@ -207,29 +227,42 @@ func (u User) Register(email, password string, accountID ...string) (string, err
 		Token:     tok.Token,
 	}
 
-	if err := Cache.SetTyped(token, auth); err != nil {
+	tokens, err := u.startSession(auth, false)
+	if err != nil {
 		return "", err
 	}
-	if err := Cache.SetTyped("base:"+token, u.conf); err != nil {
-		return "", err
+	return tokens.Token, nil
+}
+
+// CreateAccountAndUser creates an account with a user and starts a session
+// for the user, see GetAuthToken.
+func (u User) CreateAccountAndUser(email, password string, role int) ([]byte, model.User, error) {
+	tok, err := u.createAccountAndUser(email, password, role)
+	if err != nil {
+		return nil, model.User{}, err
 	}
 
-	return token, nil
+	jwtBytes, err := u.GetAuthToken(tok)
+	if err != nil {
+		return nil, tok, err
+	}
+	return jwtBytes, tok, nil
 }
 
-// CreateAccountAndUser creates an account with a user
-func (u User) CreateAccountAndUser(email, password string, role int) ([]byte, model.User, error) {
+// createAccountAndUser creates an account with a user without starting a
+// session, for the sign ups issuing their own session
+func (u User) createAccountAndUser(email, password string, role int) (model.User, error) {
 	acctID, err := DB.CreateAccount(u.conf.Name, email)
 	if err != nil {
-		return nil, model.User{}, err
+		return model.User{}, err
 	}
 
-	jwtBytes, tok, err := u.CreateUser(acctID, email, password, role)
+	tok, err := u.createUser(acctID, email, password, role)
 	if err != nil {
-		return nil, model.User{}, err
+		return model.User{}, err
 	}
 	u.publishAccountCreated(acctID, email, tok)
-	return jwtBytes, tok, nil
+	return tok, nil
 }
 
 func (u User) publishAccountCreated(accountID, email string, tok model.User) {
@@ -265,48 +298,115 @@ func (u User) publishAccountCreated(accountID, email string, tok model.User) {
 	}
 }
 
-// CreateUser creates a user for an Account
+// DeleteAccount removes an account with its users and files
+func (u User) DeleteAccount(accountID string) error {
+	files, err := DB.ListAllFiles(u.conf.Name, accountID)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := Filestore.Delete(file.Key); err != nil {
+			return err
+		}
+		if err := DB.DeleteFile(u.conf.Name, file.ID); err != nil {
+			return err
+		}
+	}
+
+	return DB.DeleteAccount(u.conf.Name, accountID)
+}
+
+// CreateUser creates a user for an Account and starts a session for the
+// user, see GetAuthToken. The password is not checked against the password
+// policy, see ValidatePassword.
 func (u User) CreateUser(accountID, email, password string, role int) ([]byte, model.User, error) {
-	b, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	tok, err := u.createUser(accountID, email, password, role)
 	if err != nil {
 		return nil, model.User{}, err
 	}
 
+	jwtBytes, err := u.GetAuthToken(tok)
+	if err != nil {
+		return nil, tok, err
+	}
+	return jwtBytes, tok, nil
+}
+
+func (u User) createUser(accountID, email, password string, role int) (model.User, error) {
+	hash, err := u.HashPassword(password)
+	if err != nil {
+		return model.User{}, err
+	}
+
 	tok := model.User{
 		AccountID: accountID,
 		Email:     email,
 		Token:     DB.NewID(),
-		Password:  string(b),
+		Password:  hash,
 		Role:      role,
 	}
 
 	tokID, err := DB.CreateUser(u.conf.Name, tok)
 	if err != nil {
-		return nil, model.User{}, err
+		return model.User{}, err
 	}
 
 	tok.ID = tokID
+	return tok, nil
+}
 
-	token := fmt.Sprintf("%s|%s", tokID, tok.Token)
+// ExternalLogin returns a session token for the user of an email confirmed by
+// an external identity provider. Like the other logins, the error is an
+// *MFAChallengeError when the user must provide a TOTP code.
+func (u User) ExternalLogin(email string) (string, error) {
+	tok, err := DB.FindUserByEmail(u.conf.Name, strings.ToLower(email))
+	if err != nil {
+		return "", err
+	}
 
-	// Get their JWT
-	jwtBytes, err := GetJWT(token)
+	return u.externalSession(tok)
+}
+
+// ExternalRegister creates the account and user of an email confirmed by an
+// external identity provider and returns their session token.
+func (u User) ExternalRegister(email, password string) (string, error) {
+	tok, err := u.createAccountAndUser(strings.ToLower(email), password, 0)
 	if err != nil {
-		return nil, tok, err
+		return "", err
 	}
 
-	auth := model.Auth{
-		AccountID: tok.AccountID,
-		UserID:    tok.ID,
-		Email:     tok.Email,
-		Role:      role,
-		Token:     tok.Token,
+	if err := u.verifyNewUser(tok); err != nil {
+		return "", err
 	}
-	if err := Cache.SetTyped(token, auth); err != nil {
-		return nil, tok, err
+
+	if err := u.challengeIfRequired(tok, "", false); err != nil {
+		return "", err
 	}
 
-	return jwtBytes, tok, nil
+	tokens, err := u.issueSession(tok, "", false)
+	if err != nil {
+		return "", err
+	}
+	return tokens.Token, nil
+}
+
+// externalSession applies the email verification and MFA requirements before
+// starting the session of an external login
+func (u User) externalSession(tok model.User) (string, error) {
+	if err := u.checkEmailVerifiedLogin(tok); err != nil {
+		return "", err
+	}
+
+	if err := u.challengeIfRequired(tok, "", false); err != nil {
+		return "", err
+	}
+
+	tokens, err := u.issueSession(tok, "", false)
+	if err != nil {
+		return "", err
+	}
+	return tokens.Token, nil
 }
 
 // SetPasswordResetCode sets the password forget code for a user
@@ -324,25 +424,90 @@ func (u User) SetPasswordResetCode(email, code string) error {
 	return nil
 }
 
-// ResetPassword resets the password of a matching email/code for a user
+// ResetPassword resets the password of a matching email/code for a user and
+// revokes all their sessions. Wrong codes count as failed login attempts.
 func (u User) ResetPassword(email, code, password string) error {
 	email = strings.ToLower(email)
 
-	b, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err := u.ValidatePassword(email, password); err != nil {
+		return err
+	}
+
+	hash, err := u.HashPassword(password)
 	if err != nil {
 		return err
 	}
 
-	return DB.ResetPassword(u.conf.Name, email, code, string(b))
+	guard, err := u.GuardLogin(email)
+	if err != nil {
+		return err
+	}
+
+	if err := DB.ResetPassword(u.conf.Name, email, code, hash); err != nil {
+		return guard.Fail(err)
+	}
+	guard.Succeed()
+
+	tok, err := DB.FindUserByEmail(u.conf.Name, email)
+	if err != nil {
+		return err
+	}
+	return u.RevokeAllSessions(tok.ID)
 }
 
 // SetUserRole changes the role of a user's membership in a specific account.
-func (u User) SetUserRole(accountID, email string, role int) error {
+// When a custom role name is provided, the user gets its capabilities and
+// its level replaces role. Without a name, the user's custom role is removed.
+func (u User) SetUserRole(accountID, email string, role int, roleName ...string) error {
 	email = strings.ToLower(email)
-	return DB.SetUserRole(u.conf.Name, accountID, email, role)
+
+	name := ""
+	if len(roleName) > 0 {
+		name = roleName[0]
+	}
+
+	if len(name) > 0 {
+		custom, err := u.GetCustomRole(name)
+		if err != nil {
+			return err
+		}
+		role = custom.Level
+	}
+
+	if err := DB.SetUserRole(u.conf.Name, accountID, email, role); err != nil {
+		return err
+	}
+
+	tok, err := DB.FindUserByEmail(u.conf.Name, email)
+	if err != nil {
+		return err
+	}
+
+	// the cached user of the membership is reloaded with the new role
+	token := tok.Token
+	if tok.AccountID != accountID {
+		assoc, err := DB.GetAccountUser(u.conf.Name, tok.ID, accountID)
+		if err != nil {
+			return err
+		}
+		token = assoc.Token
+	}
+
+	if len(name) == 0 {
+		err = DB.DeleteUserRole(u.conf.Name, tok.ID, accountID)
+	} else {
+		err = DB.SaveUserRole(u.conf.Name, model.UserRole{UserID: tok.ID, AccountID: accountID, Name: name})
+	}
+	if err != nil {
+		return err
+	}
+	return Cache.Delete(tok.ID + "|" + token)
 }
 
-// ChangeEmail changes the authenticated user's email address.
+// ChangeEmail changes the authenticated user's email address and revokes
+// all their sessions. When the database requires email verification, the
+// new address is verified first and ErrEmailChangePending is returned once
+// the verification email is sent.
 func (u User) ChangeEmail(auth model.Auth, newEmail string) error {
 	newEmail = strings.ToLower(newEmail)
 	oldEmail := strings.ToLower(auth.Email)
@@ -351,6 +516,43 @@ func (u User) ChangeEmail(auth model.Auth, newEmail string) error {
 		return nil
 	}
 
+	if model.IsGuestEmail(oldEmail) {
+		return ErrGuestUpgrade
+	}
+
+	exists, err := DB.UserEmailExists(u.conf.Name, newEmail)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrEmailAlreadyInUse
+	}
+
+	policy, err := u.GetEmailVerificationPolicy()
+	if err != nil {
+		return err
+	} else if policy.Required {
+		st := emailVerificationState{
+			UserID:    auth.UserID,
+			AccountID: auth.AccountID,
+			Token:     auth.Token,
+			Email:     oldEmail,
+			NewEmail:  newEmail,
+		}
+		if err := u.sendEmailVerification(policy, st); err != nil {
+			return err
+		}
+		return ErrEmailChangePending
+	}
+
+	return u.switchEmail(auth, newEmail, nil)
+}
+
+// switchEmail changes the user's email, saves the verification of the new
+// address if any and revokes all their sessions
+func (u User) switchEmail(auth model.Auth, newEmail string, v *model.EmailVerification) error {
+	oldEmail := strings.ToLower(auth.Email)
+
 	exists, err := DB.UserEmailExists(u.conf.Name, newEmail)
 	if err != nil {
 		return err
@@ -363,6 +565,12 @@ func (u User) ChangeEmail(auth model.Auth, newEmail string) error {
 		return err
 	}
 
+	if v != nil {
+		if err := DB.SaveEmailVerification(u.conf.Name, *v); err != nil {
+			return err
+		}
+	}
+
 	cacheKey := fmt.Sprintf("%s|%s", auth.UserID, auth.Token)
 	if err := Cache.Delete(cacheKey); err != nil {
 		return err
@@ -374,42 +582,49 @@ func (u User) ChangeEmail(auth model.Auth, newEmail string) error {
 	}
 	homeCacheKey := fmt.Sprintf("%s|%s", tok.ID, tok.Token)
 	if homeCacheKey != cacheKey {
-		return Cache.Delete(homeCacheKey)
+		if err := Cache.Delete(homeCacheKey); err != nil {
+			return err
+		}
 	}
-	return nil
+	return u.RevokeAllSessions(tok.ID)
 }
 
 // UserSetPassword password changes initiated by the user
 func (u User) UserSetPassword(email, oldpw, newpw string) error {
 	email = strings.ToLower(email)
 
-	tok, err := DB.FindUserByEmail(u.conf.Name, email)
+	guard, err := u.GuardLogin(email)
 	if err != nil {
 		return err
 	}
 
-	if _, err := u.Authenticate(email, oldpw); err != nil {
+	tok, err := DB.FindUserByEmail(u.conf.Name, email)
+	if err != nil {
+		return guard.Fail(err)
+	}
+
+	// the old password proves the change is wanted, it does not sign in so
+	// MFA and email verification do not apply
+	if err := u.verifyPassword(tok, oldpw); err != nil {
+		return guard.Fail(err)
+	}
+	guard.Succeed()
+
+	if err := u.ValidatePassword(email, newpw); err != nil {
 		return err
 	}
 
-	b, err := bcrypt.GenerateFromPassword([]byte(newpw), bcrypt.DefaultCost)
+	hash, err := u.HashPassword(newpw)
 	if err != nil {
 		return err
 	}
 
-	return DB.UserSetPassword(u.conf.Name, tok.ID, string(b))
+	return DB.UserSetPassword(u.conf.Name, tok.ID, hash)
 }
 
-// GetAuthToken returns a session token for a user
-func (u User) GetAuthToken(tok model.User) (jwtBytes []byte, err error) {
-	token := fmt.Sprintf("%s|%s", tok.ID, tok.Token)
-
-	// get their JWT
-	jwtBytes, err = GetJWT(token)
-	if err != nil {
-		return
-	}
-
+// GetAuthToken starts a session for a user and returns its token, the
+// session is revoked by Logout and RevokeAllSessions like the other logins.
+func (u User) GetAuthToken(tok model.User) ([]byte, error) {
 	auth := model.Auth{
 		AccountID: tok.AccountID,
 		UserID:    tok.ID,
@@ -418,16 +633,31 @@ func (u User) GetAuthToken(tok model.User) (jwtBytes []byte, err error) {
 		Token:     tok.Token,
 	}
 
-	//TODO: find a good way to find all occurences of those two
-	// and make them easily callable via a shared function
-	if err = Cache.SetTyped(token, auth); err != nil {
-		return
+	tokens, err := u.startSession(auth, false)
+	if err != nil {
+		return nil, err
 	}
-	if err = Cache.SetTyped("base:"+token, u.conf); err != nil {
-		return
+	return []byte(tokens.Token), nil
+}
+
+// cacheAuth caches the authenticated user and the database of a token
+func (u User) cacheAuth(token string, auth model.Auth) error {
+	verified, err := middleware.IsEmailVerified(DB, u.conf.Name, auth.UserID, auth.Email)
+	if err != nil {
+		return err
+	}
+
+	role, err := DB.GetUserRole(u.conf.Name, auth.UserID, auth.AccountID)
+	if err != nil {
+		return err
 	}
 
-	return
+	auth.EmailVerified = verified
+	auth.RoleName = role.Name
+	if err := Cache.SetTyped(token, auth); err != nil {
+		return err
+	}
+	return Cache.SetTyped("base:"+token, u.conf)
 }
 
 // GetUserByID returns a user by account and user IDs.
@@ -462,10 +692,8 @@ func (u User) PromoteToOwnAccount(auth model.Auth) (string, error) {
 		return "", err
 	}
 
-	// the old cached token will expire at its natural 12h TTL;
-	// the caller should treat the returned JWT as the new session token
-
-	// build and cache a fresh token for the new home account
+	// start a session for the new home account, the caller should treat
+	// the returned JWT as the new session token
 	updatedUser := model.User{
 		ID:        auth.UserID,
 		AccountID: newAcctID,
@@ -481,22 +709,51 @@ func (u User) PromoteToOwnAccount(auth model.Auth) (string, error) {
 	return string(jwtBytes), nil
 }
 
-// GetJWT returns a session token from a token
+// GetJWT signs a token without a session nor custom claims, like the tokens
+// issued before sessions it is only accepted as a user's session token while
+// signed with HS256, see User.GetAuthToken.
 func GetJWT(token string) ([]byte, error) {
+	return signJWT(token, "", sessionTokenTTL, nil)
+}
+
+func signJWT(token, sessionID string, ttl time.Duration, claims map[string]any) ([]byte, error) {
 	now := time.Now()
 	pl := model.JWTPayload{
 		Payload: jwt.Payload{
 			Issuer:         "StaticBackend",
-			ExpirationTime: jwt.NumericDate(now.Add(12 * time.Hour)),
+			ExpirationTime: jwt.NumericDate(now.Add(ttl)),
 			NotBefore:      jwt.NumericDate(now),
 			IssuedAt:       jwt.NumericDate(now),
 			JWTID:          internal.RandStringRunes(32),
 		},
-		Token: token,
+		Token:     token,
+		SessionID: sessionID,
+		Claims:    claims,
 	}
 
-	return jwt.Sign(pl, model.HashSecret)
+	return model.JWTKeys.Sign(pl)
+}
 
+// signClientJWT signs the access token of a session started by an OAuth
+// client, its audience is the identity provider issuing it
+func signClientJWT(token string, s model.Session, ttl time.Duration, issuer string) ([]byte, error) {
+	now := time.Now()
+	pl := model.JWTPayload{
+		Payload: jwt.Payload{
+			Issuer:         issuer,
+			Audience:       jwt.Audience{issuer},
+			ExpirationTime: jwt.NumericDate(now.Add(ttl)),
+			NotBefore:      jwt.NumericDate(now),
+			IssuedAt:       jwt.NumericDate(now),
+			JWTID:          internal.RandStringRunes(32),
+		},
+		Token:     token,
+		SessionID: s.ID,
+		ClientID:  s.ClientID,
+		Scope:     s.Scope,
+	}
+
+	return model.JWTKeys.Sign(pl)
 }
 
 // MagicLinkData magic links for no-password sign-in
@@ -509,6 +766,16 @@ type MagicLinkData struct {
 	MagicLink string `json:"link"`
 }
 
+// magicLinkState is a magic link code waiting to be used
+type magicLinkState struct {
+	Code     string `json:"code"`
+	Attempts int    `json:"attempts"`
+}
+
+func magicLinkCacheKey(dbName, email string) string {
+	return "ml-" + dbName + "-" + email
+}
+
 // SetupMagicLink initialize a magic link and send the email to the user
 func (u User) SetupMagicLink(data MagicLinkData) error {
 	data.Email = strings.ToLower(data.Email)
@@ -521,7 +788,8 @@ func (u User) SetupMagicLink(data MagicLinkData) error {
 	}
 	data.MagicLink += fmt.Sprintf("?code=%d&email=%s", code, data.Email)
 
-	if err := Cache.Set("ml-"+data.Email, fmt.Sprintf("%d a", code)); err != nil {
+	st := magicLinkState{Code: fmt.Sprintf("%d", code)}
+	if err := Cache.SetTyped(magicLinkCacheKey(u.conf.Name, data.Email), st); err != nil {
 		return err
 	}
 
@@ -538,44 +806,70 @@ func (u User) SetupMagicLink(data MagicLinkData) error {
 	return nil
 }
 
+// checkMagicLinkCode removes the code of the email once used or after too many
+// wrong codes
+func checkMagicLinkCode(dbName, email, code string) error {
+	key := magicLinkCacheKey(dbName, email)
+
+	var st magicLinkState
+	if err := Cache.GetTyped(key, &st); err != nil || len(st.Code) == 0 {
+		return ErrInvalidMagicLinkCode
+	}
+
+	if subtle.ConstantTimeCompare([]byte(code), []byte(st.Code)) != 1 {
+		st.Attempts++
+		if st.Attempts >= magicLinkMaxAttempts {
+			_ = Cache.Delete(key)
+		} else if err := Cache.SetTyped(key, st); err != nil {
+			return err
+		}
+		return ErrInvalidMagicLinkCode
+	}
+
+	return Cache.Delete(key)
+}
+
 // ValidateMagicLink validates a magic link code and returns a session token on
-// success
+// success. Codes can be used once and wrong codes count as failed login
+// attempts, the code is removed after magicLinkMaxAttempts wrong codes.
 func (u User) ValidateMagicLink(email, code string) (string, error) {
 	email = strings.ToLower(email)
 
-	val, err := Cache.Get("ml-" + email)
+	guard, err := u.GuardLogin(email)
 	if err != nil {
 		return "", err
 	}
 
-	parts := strings.Split(val, " ")
-	if len(parts) != 2 {
-		return "", errors.New("invalid code")
+	if err := checkMagicLinkCode(u.conf.Name, email, code); err != nil {
+		return "", guard.Fail(err)
 	}
 
-	// if the code isn't what was set we make sure they're not trying to
-	// "brute force" random code.
-	if parts[0] != code {
-		if len(parts[1]) >= 10 {
-			return "", errors.New("maximum retry reached")
-		}
+	guard.Succeed()
 
-		if err := Cache.Set("ml-"+email, val+"a"); err != nil {
+	tok, err := DB.FindUserByEmail(u.conf.Name, email)
+	if err != nil {
+		// the link may upgrade a guest with a new email
+		guest, gerr := u.magicLinkGuest(email)
+		if errors.Is(gerr, ErrNotGuest) {
 			return "", err
+		} else if gerr != nil {
+			return "", gerr
 		}
+		tok = guest
 	}
 
-	// they got the right code, return a session token
+	if err := u.checkEmailVerifiedLogin(tok); err != nil {
+		return "", err
+	}
 
-	tok, err := DB.FindUserByEmail(u.conf.Name, email)
-	if err != nil {
+	if err := u.challengeIfRequired(tok, "", false); err != nil {
 		return "", err
 	}
 
-	jwtBytes, err := u.GetAuthToken(tok)
+	tokens, err := u.issueSession(tok, "", false)
 	if err != nil {
 		return "", err
 	}
 
-	return string(jwtBytes), nil
+	return tokens.Token, nil
 }
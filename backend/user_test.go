@@ -1,6 +1,8 @@
 package backend_test
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/staticbackendhq/core/backend"
@@ -51,3 +53,40 @@ func TestAuthenticateCreatesMissingAccountAssociation(t *testing.T) {
 		t.Error("expected a non-empty association token")
 	}
 }
+
+func TestMagicLinkMaxAttempts(t *testing.T) {
+	const email = "magic-attempts@test.com"
+
+	usr := backend.Membership(base)
+	if _, _, err := usr.CreateAccountAndUser(email, "test1234!", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	data := backend.MagicLinkData{Email: email, Body: "[link]", MagicLink: "https://app.test/magic"}
+	if err := usr.SetupMagicLink(data); err != nil {
+		t.Fatal(err)
+	}
+
+	// changing IP address does not reset the attempts of the code
+	for i := 0; i < 5; i++ {
+		client := backend.Membership(base).WithClient("unit test", fmt.Sprintf("10.1.0.%d", i))
+		if _, err := client.ValidateMagicLink(email, "123456"); !errors.Is(err, backend.ErrInvalidMagicLinkCode) {
+			t.Fatalf("expected an invalid code got %v", err)
+		}
+	}
+
+	// in dev mode, the code is always 666333
+	client := backend.Membership(base).WithClient("unit test", "10.1.0.100")
+	if _, err := client.ValidateMagicLink(email, "666333"); !errors.Is(err, backend.ErrInvalidMagicLinkCode) {
+		t.Errorf("expected the code to be removed after too many attempts got %v", err)
+	}
+
+	if err := usr.SetupMagicLink(data); err != nil {
+		t.Fatal(err)
+	}
+
+	client = backend.Membership(base).WithClient("unit test", "10.1.0.101")
+	if _, err := client.ValidateMagicLink(email, "666333"); err != nil {
+		t.Errorf("expected a new code to sign in got %v", err)
+	}
+}
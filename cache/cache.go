@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"log/slog"
 	"time"
 
@@ -107,6 +106,42 @@ func (c *Cache) Dec(key string, by int64) (int64, error) {
 	return c.Rdb.DecrBy(c.Ctx, key, by).Result()
 }
 
+// SetField sets the value of a field of a Redis hash, the hash expires like
+// the other keys when not updated
+func (c *Cache) SetField(key, field, value string) error {
+	pipe := c.Rdb.TxPipeline()
+	pipe.HSet(c.Ctx, key, field, value)
+	pipe.Expire(c.Ctx, key, 12*time.Hour)
+	_, err := pipe.Exec(c.Ctx)
+	return err
+}
+
+// GetFields returns all the fields of a Redis hash
+func (c *Cache) GetFields(key string) (map[string]string, error) {
+	return c.Rdb.HGetAll(c.Ctx, key).Result()
+}
+
+// DeleteField removes a field of a Redis hash
+func (c *Cache) DeleteField(key, field string) error {
+	return c.Rdb.HDel(c.Ctx, key, field).Err()
+}
+
+// PushCapped appends a value to a Redis list trimmed to its last size values,
+// the list expires after ttl when no values are added
+func (c *Cache) PushCapped(key, value string, size int, ttl time.Duration) error {
+	pipe := c.Rdb.TxPipeline()
+	pipe.RPush(c.Ctx, key, value)
+	pipe.LTrim(c.Ctx, key, int64(-size), -1)
+	pipe.Expire(c.Ctx, key, ttl)
+	_, err := pipe.Exec(c.Ctx)
+	return err
+}
+
+// GetList returns the values of a Redis list
+func (c *Cache) GetList(key string) ([]string, error) {
+	return c.Rdb.LRange(c.Ctx, key, 0, -1).Result()
+}
+
 // Subscribe subscribes to a topic to receive messages on system/user events
 func (c *Cache) Subscribe(send chan model.Command, token, channel string, close chan bool) {
 	pubsub := c.Rdb.Subscribe(c.Ctx, channel)
@@ -176,6 +211,9 @@ func (c *Cache) sendMessage(send chan model.Command, close chan bool, msg model.
 // Publish sends a message and all subscribers will receive it if they're
 // subscribed to that topic
 func (c *Cache) Publish(msg model.Command) error {
+	// channel messages have an event id to be replayed after a reconnection
+	msg = recordHistory(c, msg)
+
 	b, err := json.Marshal(msg)
 	if err != nil {
 		return err
@@ -262,24 +300,7 @@ func (c *Cache) HasPermission(token, repo, payload string) bool {
 		return false
 	}
 
-	switch internal.ReadPermission(repo) {
-	case internal.PermGroup:
-		acctID, ok := docs["accountId"]
-		if !ok {
-			return false
-		}
-
-		return fmt.Sprintf("%v", acctID) == me.AccountID
-	case internal.PermOwner:
-		owner, ok := docs["ownerId"]
-		if !ok {
-			return false
-		}
-
-		return fmt.Sprintf("%v", owner) == me.UserID
-	default:
-		return true
-	}
+	return internal.CanReceiveEvent(me, repo, docs)
 }
 
 // QueueWork uses Redis's LIST (atomic) as a work queue
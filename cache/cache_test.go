@@ -3,6 +3,7 @@ package cache
 import (
 	"encoding/json"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -65,7 +66,12 @@ func TestCacheSubscribe(t *testing.T) {
 			timer := time.NewTimer(5 * time.Second)
 			select {
 			case res := <-receiver:
-				if res != payload {
+				// published messages are given an event id
+				if len(res.ID) == 0 {
+					t.Error("Expected the message to have an event id")
+				}
+				res.ID = ""
+				if !reflect.DeepEqual(res, payload) {
 					t.Error("Incorrect message is received")
 				}
 				break
@@ -109,7 +115,12 @@ func TestCacheSubscribeOnDBEvent(t *testing.T) {
 			defer timer.Stop()
 			select {
 			case res := <-receiver:
-				if res != payload {
+				// published messages are given an event id
+				if len(res.ID) == 0 {
+					t.Error("Expected the message to have an event id")
+				}
+				res.ID = ""
+				if !reflect.DeepEqual(res, payload) {
 					t.Error("Incorrect message is received")
 				}
 				break
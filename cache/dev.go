@@ -3,9 +3,9 @@ package cache
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/staticbackendhq/core/cache/observer"
 	"github.com/staticbackendhq/core/internal"
@@ -18,6 +18,8 @@ var ErrKeyNotFound = errors.New("key not found in cache")
 // CacheDev used in local dev mode and is memory-based
 type CacheDev struct {
 	data     map[string]string
+	hashes   map[string]map[string]string
+	lists    map[string][]string
 	observer observer.Observer
 	m        *sync.RWMutex
 }
@@ -26,6 +28,8 @@ type CacheDev struct {
 func NewDevCache() *CacheDev {
 	return &CacheDev{
 		data:     make(map[string]string),
+		hashes:   make(map[string]map[string]string),
+		lists:    make(map[string][]string),
 		observer: observer.NewObserver(),
 		m:        &sync.RWMutex{},
 	}
@@ -81,27 +85,95 @@ func (d *CacheDev) SetTyped(key string, v any) error {
 	return d.Set(key, string(b))
 }
 
-// Inc increments a value (non-atomic)
+// Inc increments a value
 func (d *CacheDev) Inc(key string, by int64) (n int64, err error) {
-	if err = d.GetTyped(key, &n); err != nil {
-		if errors.Is(err, ErrKeyNotFound) {
-			n = 0
-		} else {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if val, ok := d.data[key]; ok {
+		if err = json.Unmarshal([]byte(val), &n); err != nil {
 			return
 		}
 	}
 
 	n += by
 
-	err = d.SetTyped(key, n)
+	b, err := json.Marshal(n)
+	if err != nil {
+		return
+	}
+
+	d.data[key] = string(b)
 	return
 }
 
-// Dec decrements a value (non-atomic)
+// Dec decrements a value
 func (d *CacheDev) Dec(key string, by int64) (int64, error) {
 	return d.Inc(key, -1*by)
 }
 
+// SetField sets the value of a field of a hash
+func (d *CacheDev) SetField(key, field, value string) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	h, ok := d.hashes[key]
+	if !ok {
+		h = make(map[string]string)
+		d.hashes[key] = h
+	}
+	h[field] = value
+	return nil
+}
+
+// GetFields returns a copy of all the fields of a hash
+func (d *CacheDev) GetFields(key string) (map[string]string, error) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+
+	fields := make(map[string]string, len(d.hashes[key]))
+	for k, v := range d.hashes[key] {
+		fields[k] = v
+	}
+	return fields, nil
+}
+
+// DeleteField removes a field of a hash
+func (d *CacheDev) DeleteField(key, field string) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if h, ok := d.hashes[key]; ok {
+		delete(h, field)
+		if len(h) == 0 {
+			delete(d.hashes, key)
+		}
+	}
+	return nil
+}
+
+// PushCapped appends a value to a list keeping its last size values, lists
+// do not expire in memory
+func (d *CacheDev) PushCapped(key, value string, size int, _ time.Duration) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	list := append(d.lists[key], value)
+	if len(list) > size {
+		list = append([]string(nil), list[len(list)-size:]...)
+	}
+	d.lists[key] = list
+	return nil
+}
+
+// GetList returns a copy of the values of a list
+func (d *CacheDev) GetList(key string) ([]string, error) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+
+	return append([]string(nil), d.lists[key]...), nil
+}
+
 // Subscribe subscribes to a topic to receive messages on system/user events
 func (d *CacheDev) Subscribe(send chan model.Command, token, channel string, close chan bool) {
 	pubsub := d.observer.Subscribe(channel)
@@ -139,6 +211,9 @@ func (d *CacheDev) Subscribe(send chan model.Command, token, channel string, clo
 // Publish sends a message and all subscribers will receive it if they're
 // subscribed to that topic
 func (d *CacheDev) Publish(msg model.Command) error {
+	// channel messages have an event id to be replayed after a reconnection
+	msg = recordHistory(d, msg)
+
 	b, err := json.Marshal(msg)
 	if err != nil {
 		return err
@@ -212,24 +287,7 @@ func (d *CacheDev) HasPermission(token, repo, payload string) bool {
 		return false
 	}
 
-	switch internal.ReadPermission(repo) {
-	case internal.PermGroup:
-		acctID, ok := docs["accountId"]
-		if !ok {
-			return false
-		}
-
-		return fmt.Sprintf("%v", acctID) == me.AccountID
-	case internal.PermOwner:
-		owner, ok := docs["ownerId"]
-		if !ok {
-			return false
-		}
-
-		return fmt.Sprintf("%v", owner) == me.UserID
-	default:
-		return true
-	}
+	return internal.CanReceiveEvent(me, repo, docs)
 }
 
 // QueueWork uses a slice to replicate a work queue (non-atomic)
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
+)
+
+// ErrInvalidEventID is returned when a since or Last-Event-ID value is not an
+// event id
+var ErrInvalidEventID = errors.New("invalid event id")
+
+const (
+	// eventIDKey is the counter of the event ids, they are increasing across
+	// all the channels and instances
+	eventIDKey = "realtime-event-id"
+
+	defaultHistorySize = 100
+	defaultHistoryTTL  = time.Hour
+)
+
+// historyKey returns the key of the history of a channel, channel names are
+// shared by the databases
+func historyKey(dbName, channel string) string {
+	return "history-" + dbName + "-" + channel
+}
+
+// keepInHistory returns false for the system messages and the transient
+// events of a channel, the presence is queried instead of replayed
+func keepInHistory(msg model.Command) bool {
+	if msg.IsSystemEvent || len(msg.Channel) == 0 || msg.Channel == "sbsys" {
+		return false
+	}
+
+	switch msg.Type {
+	case model.MsgTypeJoined,
+		model.MsgTypePresenceJoin,
+		model.MsgTypePresenceLeave,
+		model.MsgTypePresenceUpdate:
+		return false
+	}
+	return true
+}
+
+// recordHistory assigns the event id of a message published to a channel and
+// adds it to the channel history of its database. The message is published
+// without an id when it cannot be recorded.
+func recordHistory(v Volatilizer, msg model.Command) model.Command {
+	if !keepInHistory(msg) {
+		return msg
+	}
+
+	id, err := v.Inc(eventIDKey, 1)
+	if err != nil {
+		slog.Error("error creating event id", "error", err)
+		return msg
+	}
+	msg.ID = strconv.FormatInt(id, 10)
+
+	// the session token of the sender is not kept
+	stored := msg
+	stored.Token = ""
+
+	b, err := json.Marshal(stored)
+	if err != nil {
+		slog.Error("error converting history message to JSON", "error", err)
+		return msg
+	}
+
+	size := config.Current.RealtimeHistorySize
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+
+	ttl := time.Duration(config.Current.RealtimeHistoryTTLSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = defaultHistoryTTL
+	}
+
+	if err := v.PushCapped(historyKey(msg.Base, msg.Channel), string(b), size, ttl); err != nil {
+		slog.Error("error saving channel history", "channel", msg.Channel, "error", err)
+	}
+	return msg
+}
+
+// History returns the messages of a channel published after the since event
+// id, all the kept messages when since is empty. Like for subscribers, the
+// database events are only returned when the user can read the document.
+func History(v Volatilizer, auth model.Auth, dbName, channel, since string) ([]model.Command, error) {
+	var after int64
+	if len(since) > 0 {
+		id, err := strconv.ParseInt(since, 10, 64)
+		if err != nil || id < 0 {
+			return nil, ErrInvalidEventID
+		}
+		after = id
+	}
+
+	list, err := v.GetList(historyKey(dbName, channel))
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]model.Command, 0, len(list))
+	for _, s := range list {
+		var msg model.Command
+		if err := json.Unmarshal([]byte(s), &msg); err != nil {
+			slog.Warn("invalid channel history message", "channel", channel, "error", err)
+			continue
+		}
+
+		id, err := strconv.ParseInt(msg.ID, 10, 64)
+		if err != nil || id <= after {
+			continue
+		}
+
+		if msg.Type == model.MsgTypeChanIn {
+			msg.Type = model.MsgTypeChanOut
+		} else if msg.IsDBEvent() {
+			docs := make(map[string]any)
+			if err := json.Unmarshal([]byte(msg.Data), &docs); err != nil {
+				continue
+			}
+
+			if !internal.CanReceiveEvent(auth, channel, docs) {
+				continue
+			}
+		}
+
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
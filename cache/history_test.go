@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestDevCacheHistory(t *testing.T) {
+	defer func(size int) { config.Current.RealtimeHistorySize = size }(config.Current.RealtimeHistorySize)
+	config.Current.RealtimeHistorySize = 3
+
+	cache := NewDevCache()
+
+	for i := 0; i < 4; i++ {
+		msg := model.Command{Type: model.MsgTypeChanIn, Channel: "chat", Data: strconv.Itoa(i), Token: "secret"}
+		if err := cache.Publish(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// transient events are not replayed
+	if err := cache.Publish(model.Command{Type: model.MsgTypeJoined, Channel: "chat"}); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := History(cache, model.Auth{}, "", "chat", "")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(msgs) != 3 {
+		t.Fatalf("expected the history to keep 3 messages got %d", len(msgs))
+	}
+
+	for i, msg := range msgs {
+		if msg.Data != strconv.Itoa(i+1) || msg.Type != model.MsgTypeChanOut {
+			t.Errorf("unexpected message %d: %v", i, msg)
+		} else if len(msg.Token) > 0 {
+			t.Error("expected the sender token to be removed")
+		}
+	}
+
+	msgs, err = History(cache, model.Auth{}, "", "chat", msgs[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(msgs) != 1 || msgs[0].Data != "3" {
+		t.Errorf("expected only the last message got %v", msgs)
+	}
+
+	if _, err := History(cache, model.Auth{}, "", "chat", "abc"); !errors.Is(err, ErrInvalidEventID) {
+		t.Errorf("expected ErrInvalidEventID got %v", err)
+	}
+}
+
+func TestDevCacheHistoryDatabaseEvents(t *testing.T) {
+	cache := NewDevCache()
+
+	owner := model.Auth{AccountID: "acct1", UserID: "user1"}
+	other := model.Auth{AccountID: "acct2", UserID: "user2"}
+
+	doc := map[string]any{"id": "doc1", "accountId": owner.AccountID, "ownerId": owner.UserID}
+	cache.PublishDocument(owner, "histdb", "db-notes", model.MsgTypeDBCreated, doc)
+	cache.PublishDocument(owner, "otherdb", "db-notes", model.MsgTypeDBCreated, doc)
+
+	msgs, err := History(cache, owner, "histdb", "db-notes", "")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(msgs) != 1 || msgs[0].Base != "histdb" {
+		t.Errorf("expected the event of the database got %v", msgs)
+	}
+
+	msgs, err = History(cache, other, "histdb", "db-notes", "")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(msgs) != 0 {
+		t.Errorf("expected no events for another account got %v", msgs)
+	}
+}
+
+func TestDevCacheHistoryPerDatabase(t *testing.T) {
+	cache := NewDevCache()
+
+	for _, db := range []string{"histdb", "otherdb"} {
+		msg := model.Command{Type: model.MsgTypeChanIn, Channel: "chat", Data: db, Base: db}
+		if err := cache.Publish(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msgs, err := History(cache, model.Auth{}, "histdb", "chat", "")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(msgs) != 1 || msgs[0].Data != "histdb" {
+		t.Errorf("expected only the message of the database got %v", msgs)
+	}
+}
@@ -22,11 +22,15 @@ type Subscriber interface {
 type memSubscriber struct {
 	closed bool
 	msgCh  chan interface{}
+	// done is closed instead of msgCh so pending publishes do not send on
+	// a closed channel
+	done chan struct{}
+	mx   sync.Mutex
 }
 
 func NewSubscriber() *memSubscriber {
 	ch := make(chan interface{})
-	sub := &memSubscriber{closed: false, msgCh: ch}
+	sub := &memSubscriber{closed: false, msgCh: ch, done: make(chan struct{})}
 	return sub
 }
 
@@ -35,10 +39,13 @@ func (ps *memSubscriber) Channel() <-chan interface{} {
 }
 
 func (ps *memSubscriber) Close() error {
+	ps.mx.Lock()
+	defer ps.mx.Unlock()
+
 	if ps.closed {
 		return errors.New("channel is already closed")
 	}
-	close(ps.msgCh)
+	close(ps.done)
 	ps.closed = true
 	return nil
 }
@@ -82,6 +89,8 @@ func (o *memObserver) Publish(channel string, msg interface{}) error {
 				if !timer.Stop() {
 					<-timer.C
 				}
+			case <-msub.done:
+				timer.Stop()
 			case <-timer.C:
 				slog.Error("the previous message is not read; dropping this message")
 				timer.Stop()
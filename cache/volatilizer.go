@@ -1,6 +1,10 @@
 package cache
 
-import "github.com/staticbackendhq/core/model"
+import (
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
 
 // PublishDocumentEvent used to publish database events
 type PublishDocumentEvent func(auth model.Auth, dbName, channel, typ string, v interface{})
@@ -21,6 +25,16 @@ type Volatilizer interface {
 	Inc(key string, by int64) (int64, error)
 	// Dec decrements a value for a key
 	Dec(key string, by int64) (int64, error)
+	// SetField sets the value of a field of a hash
+	SetField(key, field, value string) error
+	// GetFields returns all the fields of a hash
+	GetFields(key string) (map[string]string, error)
+	// DeleteField removes a field of a hash
+	DeleteField(key, field string) error
+	// PushCapped appends a value to a list keeping its last size values
+	PushCapped(key, value string, size int, ttl time.Duration) error
+	// GetList returns the values of a list
+	GetList(key string) ([]string, error)
 	// Subscribe subscribes to a pub/sub channel
 	Subscribe(send chan model.Command, token, channel string, close chan bool)
 	// Publish publishes a message to a channel
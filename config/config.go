@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -10,6 +11,9 @@ const (
 	defaultPostgresMaxIdleConns           = 5
 	defaultPostgresConnMaxLifetimeSeconds = 1800
 	defaultPostgresConnMaxIdleTimeSeconds = 300
+	defaultJWTKeyRotationDays             = 30
+	defaultRealtimeHistorySize            = 100
+	defaultRealtimeHistoryTTLSeconds      = 3600
 )
 
 var Current AppConfig
@@ -26,8 +30,17 @@ type AppConfig struct {
 	AppEnv string
 	// AppSecre used for encryption/decryption
 	AppSecret string
+	// JWTSigningAlg is the session token signing algorithm, RS256 (default),
+	// EdDSA or HS256 with the JWT_SECRET key
+	JWTSigningAlg string
+	// JWTKeyRotationDays is the number of days a signing key is used before
+	// a new one replaces it
+	JWTKeyRotationDays int
 	// AppURL is the full URL of the backend (important for social logins callbacks)
 	AppURL string
+	// TrustedProxies are the IP addresses or CIDR ranges of the reverse
+	// proxies allowed to set the X-Forwarded-For and X-Real-IP headers
+	TrustedProxies []string
 	// FromCLI if we're running in the CLI
 	FromCLI string
 
@@ -35,6 +48,9 @@ type AppConfig struct {
 	DataStore string
 	// DatabaseURL is the database URL
 	DatabaseURL string
+	// DatabaseReplicaURLs are PostgreSQL read replica URLs used for read-only
+	// queries, they share the primary connection pool settings.
+	DatabaseReplicaURLs []string
 	// PostgresMaxOpenConns is the maximum number of PostgreSQL connections this process opens.
 	PostgresMaxOpenConns int
 	// PostgresMaxIdleConns is the maximum number of idle PostgreSQL connections retained.
@@ -75,6 +91,8 @@ type AppConfig struct {
 	// StripeRedirectFromPortal used as portal redirection
 	StripeRedirectFromPortal string
 
+	// SMSProvider used as the sending text-messages implementation
+	SMSProvider string
 	// TwilioAccountID used when sending SMS text messages via Twilio API
 	TwilioAccountID string
 	// TwilioAuthToken used when sending SMS text messages via Twilio API
@@ -91,6 +109,13 @@ type AppConfig struct {
 	// RedisPassword if RedisURL is not used, password for Redis
 	RedisPassword string
 
+	// RealtimeHistorySize is the number of messages kept per realtime channel
+	// to replay missed messages
+	RealtimeHistorySize int
+	// RealtimeHistoryTTLSeconds is how long the history of an inactive
+	// realtime channel is kept
+	RealtimeHistoryTTLSeconds int
+
 	// S3AccessKey access key for S3 connection
 	S3AccessKey string
 	// S3SecretKey secret key for S3 connection
@@ -125,6 +150,12 @@ type AppConfig struct {
 	NoCustomerCreation bool
 	// PluginsPath is the full qualified path where plugins are stored
 	PluginsPath string
+	// PasswordBannedListsDir is the directory holding the banned password
+	// lists of password policies, they are disabled when empty
+	PasswordBannedListsDir string
+	// AdminPublicKey is the public key of the operator's database, only its
+	// root tokens can call the instance-wide endpoints like /sudo/dbstats
+	AdminPublicKey string
 }
 
 func LoadConfig() AppConfig {
@@ -133,10 +164,13 @@ func LoadConfig() AppConfig {
 		Port:                    os.Getenv("PORT"),
 		AppEnv:                  os.Getenv("APP_ENV"),
 		AppSecret:               os.Getenv("APP_SECRET"),
+		JWTSigningAlg:           os.Getenv("JWT_SIGNING_ALG"),
+		JWTKeyRotationDays:      envInt("JWT_KEY_ROTATION_DAYS", defaultJWTKeyRotationDays),
 		AppURL:                  os.Getenv("APP_URL"),
 		FromCLI:                 os.Getenv("SB_FROM_CLI"),
 		DataStore:               os.Getenv("DATA_STORE"),
 		DatabaseURL:             os.Getenv("DATABASE_URL"),
+		DatabaseReplicaURLs:     envList("DATABASE_REPLICA_URLS"),
 		PostgresMaxOpenConns:    envInt("POSTGRES_MAX_OPEN_CONNS", defaultPostgresMaxOpenConns),
 		PostgresMaxIdleConns:    envInt("POSTGRES_MAX_IDLE_CONNS", defaultPostgresMaxIdleConns),
 		PostgresConnMaxLifetimeSeconds: envInt(
@@ -162,6 +196,7 @@ func LoadConfig() AppConfig {
 		StripePriceIDGrowth:      os.Getenv("STRIPE_PRICEID_GROWTH"),
 		StripeWebhookSecret:      os.Getenv("STRIPE_WEBHOOK_SECRET"),
 		StripeRedirectFromPortal: os.Getenv("STRIPE_REDIRECT"),
+		SMSProvider:              os.Getenv("SMS_PROVIDER"),
 		TwilioAccountID:          os.Getenv("TWILIO_ACCOUNTSID"),
 		TwilioAuthToken:          os.Getenv("TWILIO_AUTHTOKEN"),
 		TwilioTestCellNumber:     os.Getenv("MY_CELL"),
@@ -179,7 +214,14 @@ func LoadConfig() AppConfig {
 		FullTextIndexFile:        os.Getenv("FTS_INDEX_FILE"),
 		ActivateFlag:             os.Getenv("ACTIVATE_FLAG"),
 		NoCustomerCreation:       os.Getenv("SB_NO_CUSTOMER_CREATION") == "true",
+		TrustedProxies:           envList("TRUSTED_PROXIES"),
 		PluginsPath:              os.Getenv("PLUGINS_PATH"),
+		PasswordBannedListsDir:   os.Getenv("PASSWORD_BANNED_LISTS_DIR"),
+		AdminPublicKey:           os.Getenv("ADMIN_PUBLIC_KEY"),
+		RealtimeHistorySize:      envInt("REALTIME_HISTORY_SIZE", defaultRealtimeHistorySize),
+		RealtimeHistoryTTLSeconds: envInt(
+			"REALTIME_HISTORY_TTL_SECONDS", defaultRealtimeHistoryTTLSeconds,
+		),
 	}
 }
 
@@ -191,3 +233,13 @@ func envInt(name string, fallback int) int {
 
 	return value
 }
+
+func envList(name string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(name), ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -62,3 +62,35 @@ func TestLoadConfigPostgresPoolInvalidValuesUseDefaults(t *testing.T) {
 		t.Fatalf("invalid pool values were not normalized: %+v", cfg)
 	}
 }
+
+func TestLoadConfigDatabaseReplicaURLs(t *testing.T) {
+	t.Setenv("DATABASE_REPLICA_URLS", "")
+	if urls := LoadConfig().DatabaseReplicaURLs; len(urls) != 0 {
+		t.Fatalf("expected no replica, got %v", urls)
+	}
+
+	t.Setenv("DATABASE_REPLICA_URLS", " postgres://replica-1/db , ,postgres://replica-2/db")
+	urls := LoadConfig().DatabaseReplicaURLs
+	if len(urls) != 2 || urls[0] != "postgres://replica-1/db" || urls[1] != "postgres://replica-2/db" {
+		t.Fatalf("unexpected replica URLs: %v", urls)
+	}
+}
+
+func TestLoadConfigRealtimeHistory(t *testing.T) {
+	t.Setenv("REALTIME_HISTORY_SIZE", "")
+	t.Setenv("REALTIME_HISTORY_TTL_SECONDS", "")
+
+	cfg := LoadConfig()
+	if cfg.RealtimeHistorySize != defaultRealtimeHistorySize ||
+		cfg.RealtimeHistoryTTLSeconds != defaultRealtimeHistoryTTLSeconds {
+		t.Fatalf("unexpected realtime history defaults: %d %d", cfg.RealtimeHistorySize, cfg.RealtimeHistoryTTLSeconds)
+	}
+
+	t.Setenv("REALTIME_HISTORY_SIZE", "25")
+	t.Setenv("REALTIME_HISTORY_TTL_SECONDS", "600")
+
+	cfg = LoadConfig()
+	if cfg.RealtimeHistorySize != 25 || cfg.RealtimeHistoryTTLSeconds != 600 {
+		t.Fatalf("unexpected realtime history config: %d %d", cfg.RealtimeHistorySize, cfg.RealtimeHistoryTTLSeconds)
+	}
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/model"
+)
+
+// ChannelCollectionsEvent is the pub/sub channel used to tell all instances
+// that the collections of a database were dropped.
+const ChannelCollectionsEvent = "sb-collections"
+
+// CollectionRegistry tracks the collections known to exist so data stores
+// create their tables once instead of on every insert.
+//
+// A nil registry tracks nothing and always runs the create function.
+type CollectionRegistry struct {
+	mu     sync.RWMutex
+	known  map[string]map[string]bool
+	create sync.Mutex
+
+	pubsub cache.Volatilizer
+}
+
+// CollectionRegistrar is implemented by data stores tracking their known
+// collections with a CollectionRegistry
+type CollectionRegistrar interface {
+	CollectionRegistry() *CollectionRegistry
+}
+
+// NewCollectionRegistry returns an empty collection registry
+func NewCollectionRegistry() *CollectionRegistry {
+	return &CollectionRegistry{known: make(map[string]map[string]bool)}
+}
+
+// Known returns true if the collection is known to exist
+func (r *CollectionRegistry) Known(dbName, col string) bool {
+	if r == nil {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.known[dbName][model.CleanCollectionName(col)]
+}
+
+// Ensure runs create once for a collection. Concurrent callers for an
+// unknown collection wait for the first create to complete.
+func (r *CollectionRegistry) Ensure(dbName, col string, create func() error) error {
+	if r == nil {
+		return create()
+	} else if r.Known(dbName, col) {
+		return nil
+	}
+
+	r.create.Lock()
+	defer r.create.Unlock()
+
+	if r.Known(dbName, col) {
+		return nil
+	}
+
+	if err := create(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.known[dbName]; !ok {
+		r.known[dbName] = make(map[string]bool)
+	}
+	r.known[dbName][model.CleanCollectionName(col)] = true
+	return nil
+}
+
+// Forget removes a collection from the known collections, i.e. when a write
+// fails because its table was dropped.
+func (r *CollectionRegistry) Forget(dbName, col string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.known[dbName], model.CleanCollectionName(col))
+}
+
+// Invalidate forgets all collections of a database on this instance and on
+// all instances watching the collections events.
+func (r *CollectionRegistry) Invalidate(dbName string) {
+	if r == nil {
+		return
+	}
+
+	r.forgetDatabase(dbName)
+
+	r.mu.RLock()
+	pubsub := r.pubsub
+	r.mu.RUnlock()
+
+	if pubsub == nil {
+		return
+	}
+
+	msg := model.Command{
+		SID:           "system",
+		Type:          "system",
+		Data:          dbName,
+		Channel:       ChannelCollectionsEvent,
+		Token:         "system",
+		IsSystemEvent: true,
+	}
+	if err := pubsub.Publish(msg); err != nil {
+		slog.Error("error publishing collections invalidation", "db", dbName, "error", err)
+	}
+}
+
+// Watch subscribes to the collections events published by other instances
+// until the context is done.
+func (r *CollectionRegistry) Watch(ctx context.Context, pubsub cache.Volatilizer) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	r.pubsub = pubsub
+	r.mu.Unlock()
+
+	receiver := make(chan model.Command)
+	closeSub := make(chan bool)
+
+	go pubsub.Subscribe(receiver, "system", ChannelCollectionsEvent, closeSub)
+
+	for {
+		select {
+		case msg := <-receiver:
+			r.forgetDatabase(msg.Data)
+		case <-ctx.Done():
+			close(closeSub)
+			return
+		}
+	}
+}
+
+func (r *CollectionRegistry) forgetDatabase(dbName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.known, dbName)
+}
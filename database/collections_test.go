@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/cache"
+)
+
+func TestCollectionRegistryEnsureOnce(t *testing.T) {
+	reg := NewCollectionRegistry()
+
+	calls := 0
+	create := func() error {
+		calls++
+		return nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := reg.Ensure("db1", "tasks", create); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("expected create to be called once got %d", calls)
+	} else if !reg.Known("db1", "tasks") {
+		t.Error("expected tasks to be known")
+	} else if reg.Known("db2", "tasks") {
+		t.Error("expected tasks to be unknown in db2")
+	}
+
+	reg.Forget("db1", "tasks")
+	if err := reg.Ensure("db1", "tasks", create); err != nil {
+		t.Fatal(err)
+	} else if calls != 2 {
+		t.Errorf("expected create to be called again after Forget got %d", calls)
+	}
+}
+
+func TestCollectionRegistryInvalidateOtherInstances(t *testing.T) {
+	pubsub := cache.NewDevCache()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	local, remote := NewCollectionRegistry(), NewCollectionRegistry()
+	go local.Watch(ctx, pubsub)
+	go remote.Watch(ctx, pubsub)
+
+	create := func() error { return nil }
+	if err := remote.Ensure("db1", "tasks", create); err != nil {
+		t.Fatal(err)
+	}
+
+	// give the subscriptions time to be established
+	time.Sleep(50 * time.Millisecond)
+
+	local.Invalidate("db1")
+
+	deadline := time.Now().Add(time.Second)
+	for remote.Known("db1", "tasks") {
+		if time.Now().After(deadline) {
+			t.Fatal("expected tasks to be invalidated on the other instance")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNilCollectionRegistry(t *testing.T) {
+	var reg *CollectionRegistry
+
+	calls := 0
+	for i := 0; i < 2; i++ {
+		if err := reg.Ensure("db1", "tasks", func() error { calls++; return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("expected a nil registry to always create got %d calls", calls)
+	}
+	reg.Forget("db1", "tasks")
+	reg.Invalidate("db1")
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) CreateAPIKey(dbName string, k model.APIKey) (id string, err error) {
+	id = m.NewID()
+	k.ID = id
+	err = create(m, dbName, "sb_api_keys", id, k)
+	return
+}
+
+func (m *Memory) GetAPIKey(dbName, id string) (k model.APIKey, err error) {
+	if err = getByID(m, dbName, "sb_api_keys", id, &k); err != nil {
+		return
+	} else if len(k.ID) == 0 {
+		err = errors.New("API key not found")
+	}
+	return
+}
+
+func (m *Memory) ListAPIKeys(dbName string) ([]model.APIKey, error) {
+	keys, err := all[model.APIKey](m, dbName, "sb_api_keys")
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Created.Before(keys[j].Created)
+	})
+	return keys, nil
+}
+
+func (m *Memory) TouchAPIKey(dbName, id string, lastUsed time.Time) error {
+	k, err := m.GetAPIKey(dbName, id)
+	if err != nil {
+		return err
+	}
+
+	k.LastUsed = &lastUsed
+	return create(m, dbName, "sb_api_keys", k.ID, k)
+}
+
+func (m *Memory) DeleteAPIKey(dbName, id string) error {
+	return deleteMemoryRecord(m, dbName, "sb_api_keys", id)
+}
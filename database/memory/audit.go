@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) AddAuditEntry(dbName string, entry model.AuditEntry) error {
+	entry.ID = m.NewID()
+	return create(m, dbName, "sb_audit_log", entry.ID, entry)
+}
+
+func (m *Memory) ListAuditEntries(dbName string, f model.AuditFilter) ([]model.AuditEntry, error) {
+	list, err := all[model.AuditEntry](m, dbName, "sb_audit_log")
+	if err != nil {
+		return nil, err
+	}
+
+	entries := filter(list, f.Matches)
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Created.After(entries[j].Created)
+	})
+
+	if f.Limit > 0 && len(entries) > f.Limit {
+		entries = entries[:f.Limit]
+	}
+	return entries, nil
+}
+
+func (m *Memory) DeleteAuditEntriesBefore(dbName string, before time.Time) (int64, error) {
+	list, err := all[model.AuditEntry](m, dbName, "sb_audit_log")
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	for _, entry := range list {
+		if !entry.Created.Before(before) {
+			continue
+		}
+
+		if err := deleteMemoryRecord(m, dbName, "sb_audit_log", entry.ID); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
@@ -15,6 +15,7 @@ const (
 	FieldAccountID = "accountId"
 	FieldOwnerID   = "sb_ownerId"
 	FieldCreated   = "sb_created"
+	FieldUpdated   = "sb_updated"
 )
 
 func (m *Memory) CreateDocument(auth model.Auth, dbName, col string, doc map[string]interface{}) (map[string]interface{}, error) {
@@ -25,6 +26,7 @@ func (m *Memory) CreateDocument(auth model.Auth, dbName, col string, doc map[str
 	doc[FieldAccountID] = auth.AccountID
 	doc[FieldOwnerID] = auth.UserID
 	doc[FieldCreated] = time.Now()
+	doc[FieldUpdated] = doc[FieldCreated]
 
 	if err := create(m, dbName, col, id, doc); err != nil {
 		return nil, err
@@ -245,6 +247,7 @@ func (m *Memory) UpdateDocument(auth model.Auth, dbName, col, id string, doc map
 	for k, v := range doc {
 		exists[k] = v
 	}
+	exists[FieldUpdated] = time.Now()
 
 	err = create(m, dbName, col, id, exists)
 
@@ -296,6 +299,7 @@ func (m *Memory) IncrementValue(auth model.Auth, dbName, col, id, field string,
 	i += n
 
 	doc[field] = i
+	doc[FieldUpdated] = time.Now()
 
 	m.PublishDocument(auth, dbName, "db-"+col, model.MsgTypeDBUpdated, doc)
 
@@ -365,10 +369,10 @@ func (m *Memory) DeleteDocuments(auth model.Auth, dbName, col string, filters ma
 }
 
 func (m *Memory) ListCollections(dbName string) (repos []string, err error) {
+	prefix := strings.ToLower(dbName) + "_"
 	for key := range m.DB {
-		pairs := strings.Split(key, "_")
-		if strings.EqualFold(pairs[0], dbName) {
-			repos = append(repos, strings.Join(pairs[1:], "_"))
+		if strings.HasPrefix(strings.ToLower(key), prefix) {
+			repos = append(repos, key[len(prefix):])
 		}
 	}
 
@@ -392,6 +396,7 @@ func removeNotEditableFields(m map[string]any) {
 	delete(m, FieldAccountID)
 	delete(m, FieldOwnerID)
 	delete(m, FieldCreated)
+	delete(m, FieldUpdated)
 }
 
 func equal(v any, val any) bool {
@@ -19,6 +19,7 @@ type Task struct {
 	Likes     int64     `json:"likes"`
 	Todos     []Todo    `json:"todos"`
 	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"sb_updated"`
 }
 
 type Todo struct {
@@ -685,3 +686,58 @@ func TestQueryDocumentsWithNonExistingDB(t *testing.T) {
 		t.Fatalf("expected empty result but got %v", result)
 	}
 }
+
+func TestUpdatedFieldIsMaintained(t *testing.T) {
+	col := "updates_tracking"
+	title := fmt.Sprintf("track updates %d", time.Now().UnixNano())
+	m, err := datastore.CreateDocument(adminAuth, confDBName, col, newTask(title, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inserted := dec(m)
+	if inserted.Updated.IsZero() {
+		t.Fatal("expected sb_updated to be set on create")
+	}
+
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"UpdateDocument", func() error {
+			_, err := datastore.UpdateDocument(adminAuth, confDBName, col, inserted.ID, map[string]any{"done": true})
+			return err
+		}},
+		{"IncrementValue", func() error {
+			return datastore.IncrementValue(adminAuth, confDBName, col, inserted.ID, "likes", 1)
+		}},
+		{"UpdateDocuments", func() error {
+			filters, err := datastore.ParseQuery([][]any{{"title", "=", title}})
+			if err != nil {
+				return err
+			}
+			_, err = datastore.UpdateDocuments(adminAuth, confDBName, col, filters, map[string]any{"done": false})
+			return err
+		}},
+	}
+
+	last := inserted.Updated
+	for _, step := range steps {
+		time.Sleep(5 * time.Millisecond)
+
+		if err := step.fn(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		m, err := datastore.GetDocumentByID(adminAuth, confDBName, col, inserted.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := dec(m)
+		if !got.Updated.After(last) {
+			t.Errorf("%s: expected sb_updated after %v got %v", step.name, last, got.Updated)
+		}
+		last = got.Updated
+	}
+}
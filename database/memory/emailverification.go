@@ -0,0 +1,20 @@
+package memory
+
+import (
+	"strings"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) GetEmailVerification(dbName, userID string) (v model.EmailVerification, err error) {
+	if err = getByID(m, dbName, "sb_email_verifications", userID, &v); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return model.EmailVerification{}, nil
+		}
+	}
+	return
+}
+
+func (m *Memory) SaveEmailVerification(dbName string, v model.EmailVerification) error {
+	return create(m, dbName, "sb_email_verifications", v.UserID, v)
+}
@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestEmailVerification(t *testing.T) {
+	v, err := datastore.GetEmailVerification(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if v.Verifies(adminToken.Email) {
+		t.Fatalf("expected no verification got %v", v)
+	}
+
+	v = model.EmailVerification{
+		UserID:   adminToken.ID,
+		Email:    "old@test.com",
+		Verified: time.Now(),
+	}
+	if err := datastore.SaveEmailVerification(confDBName, v); err != nil {
+		t.Fatal(err)
+	}
+
+	v.Email = adminToken.Email
+	if err := datastore.SaveEmailVerification(confDBName, v); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetEmailVerification(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if !check.Verifies(adminToken.Email) {
+		t.Errorf("expected %s to be verified got %v", adminToken.Email, check)
+	} else if check.Verifies("old@test.com") {
+		t.Error("expected the previous email to be replaced")
+	}
+}
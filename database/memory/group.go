@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func groupMemberKey(groupID, userID string) string {
+	return groupID + "_" + userID
+}
+
+func (m *Memory) CreateGroup(dbName string, g model.Group) (id string, err error) {
+	id = m.NewID()
+	g.ID = id
+	err = create(m, dbName, "sb_groups", id, g)
+	return
+}
+
+func (m *Memory) GetGroup(dbName, accountID, groupID string) (g model.Group, err error) {
+	if err = getByID(m, dbName, "sb_groups", groupID, &g); err != nil {
+		return
+	} else if len(g.ID) == 0 || g.AccountID != accountID {
+		return model.Group{}, errors.New("group not found")
+	}
+	return
+}
+
+func (m *Memory) ListGroups(dbName, accountID string) ([]model.Group, error) {
+	list, err := all[model.Group](m, dbName, "sb_groups")
+	if err != nil {
+		return nil, err
+	}
+
+	groups := filter(list, func(g model.Group) bool {
+		return g.AccountID == accountID
+	})
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups, nil
+}
+
+func (m *Memory) DeleteGroup(dbName, accountID, groupID string) error {
+	if _, err := m.GetGroup(dbName, accountID, groupID); err != nil {
+		return err
+	}
+
+	members, err := m.ListGroupMembers(dbName, groupID)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if err := m.RemoveGroupMember(dbName, groupID, member.UserID); err != nil {
+			return err
+		}
+	}
+	return deleteMemoryRecord(m, dbName, "sb_groups", groupID)
+}
+
+func (m *Memory) AddGroupMember(dbName string, member model.GroupMember) error {
+	var cur model.GroupMember
+	if err := getByID(m, dbName, "sb_group_members", groupMemberKey(member.GroupID, member.UserID), &cur); err == nil && len(cur.UserID) > 0 {
+		return nil
+	}
+	return create(m, dbName, "sb_group_members", groupMemberKey(member.GroupID, member.UserID), member)
+}
+
+func (m *Memory) RemoveGroupMember(dbName, groupID, userID string) error {
+	return deleteMemoryRecord(m, dbName, "sb_group_members", groupMemberKey(groupID, userID))
+}
+
+func (m *Memory) ListGroupMembers(dbName, groupID string) ([]model.GroupMember, error) {
+	list, err := all[model.GroupMember](m, dbName, "sb_group_members")
+	if err != nil {
+		return nil, err
+	}
+
+	members := filter(list, func(member model.GroupMember) bool {
+		return member.GroupID == groupID
+	})
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Added.Before(members[j].Added)
+	})
+	return members, nil
+}
+
+func (m *Memory) ListUserGroups(dbName, accountID, userID string) ([]string, error) {
+	list, err := all[model.GroupMember](m, dbName, "sb_group_members")
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, member := range list {
+		if member.AccountID == accountID && member.UserID == userID {
+			ids = append(ids, member.GroupID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
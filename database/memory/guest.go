@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) SaveGuest(dbName string, g model.Guest) error {
+	return create(m, dbName, "sb_guests", g.UserID, g)
+}
+
+func (m *Memory) GetGuest(dbName, userID string) (g model.Guest, err error) {
+	if err = getByID(m, dbName, "sb_guests", userID, &g); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return model.Guest{}, nil
+		}
+	}
+	return
+}
+
+func (m *Memory) ListGuestsSeenBefore(dbName string, before time.Time) ([]model.Guest, error) {
+	guests, err := all[model.Guest](m, dbName, "sb_guests")
+	if err != nil {
+		return nil, err
+	}
+
+	return filter(guests, func(g model.Guest) bool {
+		return g.LastSeen.Before(before)
+	}), nil
+}
+
+func (m *Memory) DeleteGuest(dbName, userID string) error {
+	return deleteMemoryRecord(m, dbName, "sb_guests", userID)
+}
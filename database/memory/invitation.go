@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) CreateInvitation(dbName string, inv model.Invitation) (id string, err error) {
+	id = m.NewID()
+	inv.ID = id
+	err = create(m, dbName, "sb_invitations", id, inv)
+	return
+}
+
+func (m *Memory) GetInvitation(dbName, id string) (inv model.Invitation, err error) {
+	if err = getByID(m, dbName, "sb_invitations", id, &inv); err != nil {
+		return
+	} else if len(inv.ID) == 0 {
+		err = errors.New("invitation not found")
+	}
+	return
+}
+
+func (m *Memory) ListInvitations(dbName, accountID string) ([]model.Invitation, error) {
+	list, err := all[model.Invitation](m, dbName, "sb_invitations")
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := filter(list, func(inv model.Invitation) bool {
+		return inv.AccountID == accountID
+	})
+
+	sort.Slice(invitations, func(i, j int) bool {
+		return invitations[i].Created.After(invitations[j].Created)
+	})
+	return invitations, nil
+}
+
+func (m *Memory) UpdateInvitation(dbName string, inv model.Invitation) error {
+	cur, err := m.GetInvitation(dbName, inv.ID)
+	if err != nil {
+		return err
+	}
+
+	cur.Sent = inv.Sent
+	cur.Expires = inv.Expires
+	return create(m, dbName, "sb_invitations", cur.ID, cur)
+}
+
+func (m *Memory) DeleteInvitation(dbName, id string) error {
+	return deleteMemoryRecord(m, dbName, "sb_invitations", id)
+}
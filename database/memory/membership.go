@@ -41,7 +41,7 @@ func (m *Memory) DeleteAccount(dbName, accountID string) error {
 		}
 	}
 
-	for _, col := range []string{"sb_files", "sb_tokens"} {
+	for _, col := range []string{"sb_files", "sb_tokens", "sb_invitations", "sb_user_roles", "sb_groups", "sb_group_members"} {
 		if err := deleteMemoryRecordsByAccountID(m, dbName, col, accountID); err != nil {
 			return err
 		}
@@ -49,6 +49,17 @@ func (m *Memory) DeleteAccount(dbName, accountID string) error {
 	if err := deleteMemoryAccountUsers(m, dbName, accountID, userIDs); err != nil {
 		return err
 	}
+	for userID := range userIDs {
+		if err := deleteMemoryRecord(m, dbName, "sb_user_profiles", userID); err != nil {
+			return err
+		}
+		if err := deleteMemoryRecord(m, dbName, "sb_user_phones", userID); err != nil {
+			return err
+		}
+		if err := deleteMemoryRecord(m, dbName, "sb_guests", userID); err != nil {
+			return err
+		}
+	}
 	return deleteMemoryRecord(m, dbName, "sb_accounts", accountID)
 }
 
@@ -206,5 +217,69 @@ func (m *Memory) RemoveUser(auth model.Auth, dbName, userID string) error {
 	mx.Lock()
 	m.DB[key] = docs
 	mx.Unlock()
-	return nil
+
+	if err := m.DeleteUserSessions(dbName, userID); err != nil {
+		return err
+	}
+
+	keys, err := m.ListAPIKeys(dbName)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if k.UserID != userID {
+			continue
+		} else if err := m.DeleteAPIKey(dbName, k.ID); err != nil {
+			return err
+		}
+	}
+
+	consents, err := m.ListOAuthConsents(dbName, userID)
+	if err != nil {
+		return err
+	}
+	for _, c := range consents {
+		if err := m.DeleteOAuthConsent(dbName, userID, c.ClientID); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteMemoryRecord(m, dbName, "sb_email_verifications", userID); err != nil {
+		return err
+	}
+	if err := deleteMemoryRecord(m, dbName, "sb_user_profiles", userID); err != nil {
+		return err
+	}
+	if err := deleteMemoryRecord(m, dbName, "sb_user_phones", userID); err != nil {
+		return err
+	}
+	if err := deleteMemoryRecord(m, dbName, "sb_guests", userID); err != nil {
+		return err
+	}
+
+	members, err := all[model.GroupMember](m, dbName, "sb_group_members")
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if member.UserID != userID {
+			continue
+		}
+		if err := m.RemoveGroupMember(dbName, member.GroupID, userID); err != nil {
+			return err
+		}
+	}
+
+	roles, err := all[model.UserRole](m, dbName, "sb_user_roles")
+	if err != nil {
+		return err
+	}
+	for _, r := range roles {
+		if r.UserID != userID {
+			continue
+		} else if err := m.DeleteUserRole(dbName, r.UserID, r.AccountID); err != nil {
+			return err
+		}
+	}
+	return m.DeleteUserMFA(dbName, userID)
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -52,6 +53,8 @@ func New(pubdoc cache.PublishDocumentEvent) database.Persister {
 func initDB(db map[string]map[string][]byte) error {
 	db["sb_customers"] = make(map[string][]byte)
 	db["sb_apps"] = make(map[string][]byte)
+	db["sb_signing_keys"] = make(map[string][]byte)
+	db["sb_sb_settings"] = make(map[string][]byte)
 	return nil
 }
 
@@ -209,6 +212,11 @@ func filter[T any](list []T, fn func(x T) bool) []T {
 }
 
 func filterByClauses(list []map[string]any, filter map[string]any) (filtered []map[string]any) {
+	if ids, rest, ok := sbquery.SplitIDs(filter); ok {
+		list = filterByIDs(list, ids)
+		filter = rest
+	}
+
 	if q, ok := sbquery.FromFilter(filter); ok {
 		for _, doc := range list {
 			if matchQuery(doc, q) {
@@ -265,6 +273,15 @@ func filterByClauses(list []map[string]any, filter map[string]any) (filtered []m
 	return
 }
 
+func filterByIDs(list []map[string]any, ids []string) (filtered []map[string]any) {
+	for _, doc := range list {
+		if slices.Contains(ids, fmt.Sprintf("%v", doc[FieldID])) {
+			filtered = append(filtered, doc)
+		}
+	}
+	return
+}
+
 func matchQuery(doc map[string]any, q sbquery.Query) bool {
 	for _, clause := range q {
 		left := doc[clause.Field]
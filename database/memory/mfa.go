@@ -0,0 +1,24 @@
+package memory
+
+import (
+	"strings"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) GetUserMFA(dbName, userID string) (mfa model.UserMFA, err error) {
+	if err = getByID(m, dbName, "sb_user_mfa", userID, &mfa); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return model.UserMFA{}, nil
+		}
+	}
+	return
+}
+
+func (m *Memory) SaveUserMFA(dbName string, mfa model.UserMFA) error {
+	return create(m, dbName, "sb_user_mfa", mfa.UserID, mfa)
+}
+
+func (m *Memory) DeleteUserMFA(dbName, userID string) error {
+	return deleteMemoryRecord(m, dbName, "sb_user_mfa", userID)
+}
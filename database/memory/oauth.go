@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) CreateOAuthClient(dbName string, c model.OAuthClient) (id string, err error) {
+	id = m.NewID()
+	c.ID = id
+	err = create(m, dbName, "sb_oauth_clients", id, c)
+	return
+}
+
+func (m *Memory) GetOAuthClient(dbName, id string) (c model.OAuthClient, err error) {
+	if err = getByID(m, dbName, "sb_oauth_clients", id, &c); err != nil {
+		return
+	} else if len(c.ID) == 0 {
+		err = errors.New("OAuth client not found")
+	}
+	return
+}
+
+func (m *Memory) ListOAuthClients(dbName string) ([]model.OAuthClient, error) {
+	clients, err := all[model.OAuthClient](m, dbName, "sb_oauth_clients")
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].Created.Before(clients[j].Created)
+	})
+	return clients, nil
+}
+
+func (m *Memory) DeleteOAuthClient(dbName, id string) error {
+	consents, err := all[model.OAuthConsent](m, dbName, "sb_oauth_consents")
+	if err != nil {
+		return err
+	}
+
+	for _, c := range consents {
+		if c.ClientID != id {
+			continue
+		} else if err := deleteMemoryRecord(m, dbName, "sb_oauth_consents", c.ID); err != nil {
+			return err
+		}
+	}
+
+	return deleteMemoryRecord(m, dbName, "sb_oauth_clients", id)
+}
+
+func (m *Memory) ListOAuthConsents(dbName, userID string) ([]model.OAuthConsent, error) {
+	consents, err := all[model.OAuthConsent](m, dbName, "sb_oauth_consents")
+	if err != nil {
+		return nil, err
+	}
+
+	consents = filter(consents, func(c model.OAuthConsent) bool {
+		return c.UserID == userID
+	})
+
+	sort.Slice(consents, func(i, j int) bool {
+		return consents[i].Created.Before(consents[j].Created)
+	})
+	return consents, nil
+}
+
+func (m *Memory) SaveOAuthConsent(dbName string, c model.OAuthConsent) error {
+	consents, err := m.ListOAuthConsents(dbName, c.UserID)
+	if err != nil {
+		return err
+	}
+
+	c.ID = m.NewID()
+	for _, existing := range consents {
+		if existing.ClientID == c.ClientID {
+			c.ID = existing.ID
+		}
+	}
+	return create(m, dbName, "sb_oauth_consents", c.ID, c)
+}
+
+func (m *Memory) DeleteOAuthConsent(dbName, userID, clientID string) error {
+	consents, err := m.ListOAuthConsents(dbName, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range consents {
+		if c.ClientID != clientID {
+			continue
+		} else if err := deleteMemoryRecord(m, dbName, "sb_oauth_consents", c.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
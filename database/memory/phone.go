@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) GetUserPhone(dbName, userID string) (phone model.UserPhone, err error) {
+	if err = getByID(m, dbName, "sb_user_phones", userID, &phone); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return model.UserPhone{}, nil
+		}
+	}
+	return
+}
+
+func (m *Memory) FindUserByPhone(dbName, number string) (tok model.User, err error) {
+	phones, err := all[model.UserPhone](m, dbName, "sb_user_phones")
+	if err != nil {
+		return
+	}
+
+	matches := filter(phones, func(p model.UserPhone) bool {
+		return p.Number == number
+	})
+
+	if len(matches) == 0 {
+		err = fmt.Errorf("cannot find token by phone")
+		return
+	}
+
+	err = getByID(m, dbName, "sb_tokens", matches[0].UserID, &tok)
+	return
+}
+
+func (m *Memory) SaveUserPhone(dbName string, phone model.UserPhone) error {
+	phones, err := all[model.UserPhone](m, dbName, "sb_user_phones")
+	if err != nil {
+		return err
+	}
+
+	for _, p := range phones {
+		if p.Number == phone.Number && p.UserID != phone.UserID {
+			return fmt.Errorf("phone number %s is already used", phone.Number)
+		}
+	}
+
+	return create(m, dbName, "sb_user_phones", phone.UserID, phone)
+}
+
+func (m *Memory) DeleteUserPhone(dbName, userID string) error {
+	return deleteMemoryRecord(m, dbName, "sb_user_phones", userID)
+}
@@ -0,0 +1,20 @@
+package memory
+
+import (
+	"strings"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) GetUserProfile(dbName, userID string) (p model.UserProfile, err error) {
+	if err = getByID(m, dbName, "sb_user_profiles", userID, &p); err != nil && !strings.Contains(err.Error(), "not found") {
+		return
+	}
+
+	p.UserID = userID
+	return p, nil
+}
+
+func (m *Memory) SaveUserProfile(dbName string, p model.UserProfile) error {
+	return create(m, dbName, "sb_user_profiles", p.UserID, p)
+}
@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserProfile(t *testing.T) {
+	p, err := datastore.GetUserProfile(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if p.UserID != adminToken.ID || len(p.Public) > 0 || len(p.Private) > 0 {
+		t.Fatalf("expected an empty profile got %v", p)
+	}
+
+	p.Public = map[string]any{"displayName": "Admin"}
+	p.Private = map[string]any{"tier": "gold"}
+	p.Updated = time.Now()
+	if err := datastore.SaveUserProfile(confDBName, p); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Public["displayName"] = "Root"
+	if err := datastore.SaveUserProfile(confDBName, p); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetUserProfile(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.Public["displayName"] != "Root" || check.Private["tier"] != "gold" {
+		t.Errorf("expected the saved profile got %v", check)
+	}
+}
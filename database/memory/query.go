@@ -18,40 +18,22 @@ func (m *Memory) ParseQuery(clauses [][]interface{}) (filter map[string]any, err
 func secureRead(auth model.Auth, col string, list []map[string]any) []map[string]any {
 	var filtered []map[string]any
 
-	filter := make(map[string]string)
-
-	switch internal.ReadScope(auth, col) {
-	case internal.RowScopeAccount:
-		filter[FieldAccountID] = auth.AccountID
-	case internal.RowScopeOwner:
-		filter[FieldAccountID] = auth.AccountID
-		filter[FieldOwnerID] = auth.UserID
-	}
-
+	scope := internal.ReadScope(auth, col)
 	for _, doc := range list {
-		matches := 0
-		for k, v := range filter {
-			if doc[k] == v {
-				matches++
-			}
-		}
-
-		if matches == len(filter) {
+		if rowAllowed(auth, scope, doc) {
 			filtered = append(filtered, doc)
 		}
-
 	}
 
 	return filtered
 }
 
 func canWrite(auth model.Auth, col string, doc map[string]any) bool {
-	switch internal.WriteScope(auth, col, false) {
-	case internal.RowScopeAccount:
-		return doc[FieldAccountID] == auth.AccountID
-	case internal.RowScopeOwner:
-		return doc[FieldAccountID] == auth.AccountID && doc[FieldOwnerID] == auth.UserID
-	}
+	return rowAllowed(auth, internal.WriteScope(auth, col, false), doc)
+}
 
-	return true
+func rowAllowed(auth model.Auth, scope internal.RowPermissionScope, doc map[string]any) bool {
+	accountID, _ := doc[FieldAccountID].(string)
+	ownerID, _ := doc[FieldOwnerID].(string)
+	return internal.RowAllowed(auth, scope, accountID, ownerID, model.DocumentGroup(doc))
 }
@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"strings"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func userRoleID(userID, accountID string) string {
+	return userID + "_" + accountID
+}
+
+func (m *Memory) GetUserRole(dbName, userID, accountID string) (role model.UserRole, err error) {
+	if err = getByID(m, dbName, "sb_user_roles", userRoleID(userID, accountID), &role); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return model.UserRole{}, nil
+		}
+	}
+	return
+}
+
+func (m *Memory) SaveUserRole(dbName string, role model.UserRole) error {
+	return create(m, dbName, "sb_user_roles", userRoleID(role.UserID, role.AccountID), role)
+}
+
+func (m *Memory) DeleteUserRole(dbName, userID, accountID string) error {
+	return deleteMemoryRecord(m, dbName, "sb_user_roles", userRoleID(userID, accountID))
+}
@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"strings"
+	"sync"
+)
+
+// seqMx serializes the read and write of sequences so concurrent callers
+// never receive the same value.
+var seqMx sync.Mutex
+
+type sequence struct {
+	Name  string
+	Value int64
+}
+
+func (m *Memory) NextSequence(dbName, name string) (int64, error) {
+	seqMx.Lock()
+	defer seqMx.Unlock()
+
+	var seq sequence
+	if err := getByID(m, dbName, "sb_sequences", name, &seq); err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			return 0, err
+		}
+	}
+
+	seq.Name = name
+	seq.Value++
+	if err := create(m, dbName, "sb_sequences", name, seq); err != nil {
+		return 0, err
+	}
+	return seq.Value, nil
+}
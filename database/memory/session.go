@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) CreateSession(dbName string, s model.Session) (id string, err error) {
+	id = m.NewID()
+	s.ID = id
+	err = create(m, dbName, "sb_sessions", id, s)
+	return
+}
+
+func (m *Memory) GetSession(dbName, id string) (s model.Session, err error) {
+	if err = getByID(m, dbName, "sb_sessions", id, &s); err != nil {
+		return
+	} else if len(s.ID) == 0 {
+		err = errors.New("session not found")
+	}
+	return
+}
+
+func (m *Memory) ListSessions(dbName, userID string) ([]model.Session, error) {
+	list, err := all[model.Session](m, dbName, "sb_sessions")
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := filter(list, func(s model.Session) bool {
+		return s.UserID == userID
+	})
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+	return sessions, nil
+}
+
+func (m *Memory) UpdateSession(dbName string, s model.Session) error {
+	cur, err := m.GetSession(dbName, s.ID)
+	if err != nil {
+		return err
+	}
+
+	cur.RefreshToken = s.RefreshToken
+	cur.LastSeen = s.LastSeen
+	cur.Expires = s.Expires
+	return create(m, dbName, "sb_sessions", cur.ID, cur)
+}
+
+func (m *Memory) TouchSession(dbName, id string, lastSeen time.Time) error {
+	cur, err := m.GetSession(dbName, id)
+	if err != nil {
+		return err
+	}
+
+	cur.LastSeen = lastSeen
+	return create(m, dbName, "sb_sessions", cur.ID, cur)
+}
+
+func (m *Memory) DeleteSession(dbName, id string) error {
+	return deleteMemoryRecord(m, dbName, "sb_sessions", id)
+}
+
+func (m *Memory) DeleteUserSessions(dbName, userID string) error {
+	sessions, err := m.ListSessions(dbName, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range sessions {
+		if err := m.DeleteSession(dbName, s.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
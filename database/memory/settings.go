@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type setting struct {
+	Key     string
+	Value   []byte
+	Updated time.Time
+}
+
+func (m *Memory) GetSetting(dbName, key string) ([]byte, error) {
+	var s setting
+	if err := getByID(m, dbName, "sb_settings", key, &s); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return s.Value, nil
+}
+
+func (m *Memory) SetSetting(dbName, key string, value []byte) error {
+	s := setting{Key: key, Value: value, Updated: time.Now()}
+	return create(m, dbName, "sb_settings", key, s)
+}
+
+func (m *Memory) AddSetting(dbName, key string, value []byte) (bool, error) {
+	col := fmt.Sprintf("%s_%s", dbName, "sb_settings")
+
+	mx.Lock()
+	defer mx.Unlock()
+
+	repo, ok := m.DB[col]
+	if !ok {
+		repo = make(map[string][]byte)
+		m.DB[col] = repo
+	} else if _, ok := repo[key]; ok {
+		return false, nil
+	}
+
+	repo[key] = mustEnc(setting{Key: key, Value: value, Updated: time.Now()})
+	return true, nil
+}
@@ -0,0 +1,27 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (m *Memory) ListSigningKeys() ([]model.SigningKey, error) {
+	keys, err := all[model.SigningKey](m, "sb", "signing_keys")
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Created.Before(keys[j].Created)
+	})
+	return keys, nil
+}
+
+func (m *Memory) AddSigningKey(k model.SigningKey) error {
+	return create(m, "sb", "signing_keys", k.ID, k)
+}
+
+func (m *Memory) DeleteSigningKey(id string) error {
+	return deleteMemoryRecord(m, "sb", "signing_keys", id)
+}
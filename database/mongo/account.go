@@ -15,6 +15,7 @@ const (
 	FieldOwnerID   = "sb_owner"
 	FieldSBOwnerID = "sb_ownerId"
 	FieldCreated   = "sb_created"
+	FieldUpdated   = "sb_updated"
 	FieldToken     = "token"
 	FieldIsActive  = "active"
 	FieldRole      = "role"
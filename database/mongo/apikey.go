@@ -0,0 +1,133 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalAPIKey struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	AccountID  primitive.ObjectID `bson:"accountId"`
+	UserID     primitive.ObjectID `bson:"userId"`
+	Name       string             `bson:"name"`
+	KeyHash    string             `bson:"keyHash"`
+	Scopes     model.APIKeyScopes `bson:"scopes"`
+	AllowedIPs []string           `bson:"allowedIps"`
+	Expires    *time.Time         `bson:"expires"`
+	LastUsed   *time.Time         `bson:"lastUsed"`
+	Created    time.Time          `bson:"created"`
+}
+
+func fromLocalAPIKey(lk LocalAPIKey) model.APIKey {
+	return model.APIKey{
+		ID:         lk.ID.Hex(),
+		AccountID:  lk.AccountID.Hex(),
+		UserID:     lk.UserID.Hex(),
+		Name:       lk.Name,
+		KeyHash:    lk.KeyHash,
+		Scopes:     lk.Scopes,
+		AllowedIPs: lk.AllowedIPs,
+		Expires:    lk.Expires,
+		LastUsed:   lk.LastUsed,
+		Created:    lk.Created,
+	}
+}
+
+func (mg *Mongo) CreateAPIKey(dbName string, k model.APIKey) (id string, err error) {
+	db := mg.Client.Database(dbName)
+
+	aid, err := primitive.ObjectIDFromHex(k.AccountID)
+	if err != nil {
+		return
+	}
+	uid, err := primitive.ObjectIDFromHex(k.UserID)
+	if err != nil {
+		return
+	}
+
+	lk := LocalAPIKey{
+		ID:         primitive.NewObjectID(),
+		AccountID:  aid,
+		UserID:     uid,
+		Name:       k.Name,
+		KeyHash:    k.KeyHash,
+		Scopes:     k.Scopes,
+		AllowedIPs: k.AllowedIPs,
+		Expires:    k.Expires,
+		Created:    k.Created,
+	}
+	if _, err = db.Collection("sb_api_keys").InsertOne(mg.Ctx, lk); err != nil {
+		return
+	}
+
+	id = lk.ID.Hex()
+	return
+}
+
+func (mg *Mongo) GetAPIKey(dbName, id string) (k model.APIKey, err error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return
+	}
+
+	var lk LocalAPIKey
+	if err = db.Collection("sb_api_keys").FindOne(mg.Ctx, bson.M{FieldID: oid}).Decode(&lk); err != nil {
+		return
+	}
+
+	k = fromLocalAPIKey(lk)
+	return
+}
+
+func (mg *Mongo) ListAPIKeys(dbName string) (results []model.APIKey, err error) {
+	db := mg.Client.Database(dbName)
+
+	opt := options.Find().SetSort(bson.M{"created": 1})
+	cur, err := db.Collection("sb_api_keys").Find(mg.Ctx, bson.M{}, opt)
+	if err != nil {
+		return
+	}
+	defer func() { _ = cur.Close(mg.Ctx) }()
+
+	for cur.Next(mg.Ctx) {
+		var lk LocalAPIKey
+		if err = cur.Decode(&lk); err != nil {
+			return
+		}
+		results = append(results, fromLocalAPIKey(lk))
+	}
+
+	err = cur.Err()
+	return
+}
+
+func (mg *Mongo) TouchAPIKey(dbName, id string, lastUsed time.Time) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"lastUsed": lastUsed}}
+	_, err = db.Collection("sb_api_keys").UpdateOne(mg.Ctx, bson.M{FieldID: oid}, update)
+	return err
+}
+
+func (mg *Mongo) DeleteAPIKey(dbName, id string) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("sb_api_keys").DeleteOne(mg.Ctx, bson.M{FieldID: oid})
+	return err
+}
@@ -0,0 +1,115 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalAuditEntry struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	AccountID  string             `bson:"accountId"`
+	ActorID    string             `bson:"actorId"`
+	ActorEmail string             `bson:"actorEmail"`
+	APIKeyID   string             `bson:"apiKeyId"`
+	Action     string             `bson:"action"`
+	Target     string             `bson:"target"`
+	Details    string             `bson:"details"`
+	IP         string             `bson:"ip"`
+	Created    time.Time          `bson:"created"`
+}
+
+func fromLocalAuditEntry(le LocalAuditEntry) model.AuditEntry {
+	return model.AuditEntry{
+		ID:         le.ID.Hex(),
+		AccountID:  le.AccountID,
+		ActorID:    le.ActorID,
+		ActorEmail: le.ActorEmail,
+		APIKeyID:   le.APIKeyID,
+		Action:     le.Action,
+		Target:     le.Target,
+		Details:    le.Details,
+		IP:         le.IP,
+		Created:    le.Created,
+	}
+}
+
+func (mg *Mongo) AddAuditEntry(dbName string, entry model.AuditEntry) error {
+	db := mg.Client.Database(dbName)
+
+	le := LocalAuditEntry{
+		ID:         primitive.NewObjectID(),
+		AccountID:  entry.AccountID,
+		ActorID:    entry.ActorID,
+		ActorEmail: entry.ActorEmail,
+		APIKeyID:   entry.APIKeyID,
+		Action:     entry.Action,
+		Target:     entry.Target,
+		Details:    entry.Details,
+		IP:         entry.IP,
+		Created:    entry.Created,
+	}
+	_, err := db.Collection("sb_audit_log").InsertOne(mg.Ctx, le)
+	return err
+}
+
+func (mg *Mongo) ListAuditEntries(dbName string, f model.AuditFilter) (results []model.AuditEntry, err error) {
+	db := mg.Client.Database(dbName)
+
+	filter := bson.M{}
+	if len(f.Action) > 0 {
+		filter["action"] = f.Action
+	}
+	if len(f.ActorID) > 0 {
+		filter["actorId"] = f.ActorID
+	}
+	if len(f.Target) > 0 {
+		filter["target"] = f.Target
+	}
+
+	created := bson.M{}
+	if !f.Since.IsZero() {
+		created["$gte"] = f.Since
+	}
+	if !f.Until.IsZero() {
+		created["$lt"] = f.Until
+	}
+	if len(created) > 0 {
+		filter["created"] = created
+	}
+
+	opt := options.Find().SetSort(bson.M{"created": -1})
+	if f.Limit > 0 {
+		opt.SetLimit(int64(f.Limit))
+	}
+
+	cur, err := db.Collection("sb_audit_log").Find(mg.Ctx, filter, opt)
+	if err != nil {
+		return
+	}
+	defer func() { _ = cur.Close(mg.Ctx) }()
+
+	for cur.Next(mg.Ctx) {
+		var le LocalAuditEntry
+		if err = cur.Decode(&le); err != nil {
+			return
+		}
+		results = append(results, fromLocalAuditEntry(le))
+	}
+
+	err = cur.Err()
+	return
+}
+
+func (mg *Mongo) DeleteAuditEntriesBefore(dbName string, before time.Time) (int64, error) {
+	db := mg.Client.Database(dbName)
+
+	res, err := db.Collection("sb_audit_log").DeleteMany(mg.Ctx, bson.M{"created": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
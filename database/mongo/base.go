@@ -27,6 +27,7 @@ func (mg *Mongo) CreateDocument(auth model.Auth, dbName, col string, doc map[str
 	delete(doc, FieldOwnerID)
 	delete(doc, FieldSBOwnerID)
 	delete(doc, FieldCreated)
+	delete(doc, FieldUpdated)
 
 	acctID, userID, err := parseObjectID(auth)
 	if err != nil {
@@ -39,6 +40,7 @@ func (mg *Mongo) CreateDocument(auth model.Auth, dbName, col string, doc map[str
 	doc[FieldAccountID] = acctID
 	doc[FieldOwnerID] = userID
 	doc[FieldCreated] = time.Now()
+	doc[FieldUpdated] = doc[FieldCreated]
 
 	if _, err := db.Collection(model.CleanCollectionName(col)).InsertOne(mg.Ctx, doc); err != nil {
 		return nil, err
@@ -138,11 +140,13 @@ func (mg *Mongo) BulkCreateDocument(auth model.Auth, dbName, col string, docs []
 		delete(doc, FieldOwnerID)
 		delete(doc, FieldSBOwnerID)
 		delete(doc, FieldCreated)
+		delete(doc, FieldUpdated)
 
 		doc[FieldID] = primitive.NewObjectID()
 		doc[FieldAccountID] = acctID
 		doc[FieldOwnerID] = userID
 		doc[FieldCreated] = time.Now()
+		doc[FieldUpdated] = doc[FieldCreated]
 	}
 
 	if _, err := db.Collection(model.CleanCollectionName(col)).InsertMany(mg.Ctx, docs); err != nil {
@@ -166,7 +170,7 @@ func (mg *Mongo) ListDocuments(auth model.Auth, dbName, col string, params model
 
 	filter := bson.M{}
 
-	secureRead(acctID, userID, auth.Role, col, filter)
+	secureRead(auth, acctID, userID, col, filter)
 
 	count, err := db.Collection(model.CleanCollectionName(col)).CountDocuments(mg.Ctx, filter)
 	if err != nil {
@@ -238,7 +242,8 @@ func (mg *Mongo) QueryDocuments(auth model.Auth, dbName, col string, filter map[
 		return result, err
 	}
 
-	secureRead(acctID, userID, auth.Role, col, filter)
+	secureRead(auth, acctID, userID, col, filter)
+	applyIDs(filter)
 
 	count, err := db.Collection(model.CleanCollectionName(col)).CountDocuments(mg.Ctx, filter)
 	if err != nil {
@@ -314,7 +319,7 @@ func (mg *Mongo) GetDocumentByID(auth model.Auth, dbName, col, id string) (map[s
 
 	filter := bson.M{FieldID: oid}
 
-	secureRead(acctID, userID, auth.Role, col, filter)
+	secureRead(auth, acctID, userID, col, filter)
 
 	sr := db.Collection(model.CleanCollectionName(col)).FindOne(mg.Ctx, filter)
 	if err := sr.Decode(&result); err != nil {
@@ -348,7 +353,7 @@ func (mg *Mongo) GetDocumentsByIDs(auth model.Auth, dbName, col string, ids []st
 
 	filter := bson.M{FieldID: bson.M{"$in": oids}}
 
-	secureRead(acctID, userID, auth.Role, col, filter)
+	secureRead(auth, acctID, userID, col, filter)
 
 	cur, err := db.Collection(model.CleanCollectionName(col)).Find(mg.Ctx, filter)
 	if err != nil {
@@ -382,12 +387,13 @@ func (mg *Mongo) UpdateDocument(auth model.Auth, dbName, col, id string, doc map
 
 	filter := bson.M{FieldID: oid}
 
-	secureWrite(acctID, userID, auth.Role, col, filter)
+	secureWrite(auth, acctID, userID, col, filter)
 
 	newProps := bson.M{}
 	for k, v := range doc {
 		newProps[k] = v
 	}
+	newProps[FieldUpdated] = time.Now()
 
 	update := bson.M{"$set": newProps}
 
@@ -419,7 +425,7 @@ func (mg *Mongo) UpdateDocuments(auth model.Auth, dbName, col string, filters ma
 		return 0, err
 	}
 
-	secureWrite(acctID, userID, auth.Role, col, filters)
+	secureWrite(auth, acctID, userID, col, filters)
 	removeNotEditableFields(updateFields)
 
 	var ids []string
@@ -447,6 +453,7 @@ func (mg *Mongo) UpdateDocuments(auth model.Auth, dbName, col string, filters ma
 	for k, v := range updateFields {
 		newProps[k] = v
 	}
+	newProps[FieldUpdated] = time.Now()
 
 	update := bson.M{"$set": newProps}
 
@@ -482,9 +489,12 @@ func (mg *Mongo) IncrementValue(auth model.Auth, dbName, col, id, field string,
 
 	filter := bson.M{FieldID: oid}
 
-	secureWrite(acctID, userID, auth.Role, col, filter)
+	secureWrite(auth, acctID, userID, col, filter)
 
-	update := bson.M{"$inc": bson.M{field: n}}
+	update := bson.M{
+		"$inc": bson.M{field: n},
+		"$set": bson.M{FieldUpdated: time.Now()},
+	}
 
 	res := db.Collection(model.CleanCollectionName(col)).FindOneAndUpdate(mg.Ctx, filter, update)
 	if err := res.Err(); err != nil {
@@ -516,7 +526,7 @@ func (mg *Mongo) DeleteDocument(auth model.Auth, dbName, col, id string) (int64,
 
 	filter := bson.M{FieldID: oid}
 
-	secureWrite(acctID, userID, auth.Role, col, filter)
+	secureWrite(auth, acctID, userID, col, filter)
 
 	res, err := db.Collection(model.CleanCollectionName(col)).DeleteOne(mg.Ctx, filter)
 	if err != nil {
@@ -536,7 +546,7 @@ func (mg *Mongo) DeleteDocuments(auth model.Auth, dbName, col string, filters ma
 		return 0, err
 	}
 
-	secureWrite(acctID, userID, auth.Role, col, filters)
+	secureWrite(auth, acctID, userID, col, filters)
 
 	res, err := db.Collection(model.CleanCollectionName(col)).DeleteMany(mg.Ctx, filters)
 	if err != nil {
@@ -632,4 +642,5 @@ func removeNotEditableFields(m map[string]any) {
 	delete(m, FieldOwnerID)
 	delete(m, FieldSBOwnerID)
 	delete(m, FieldCreated)
+	delete(m, FieldUpdated)
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	sbquery "github.com/staticbackendhq/core/internal/query"
 	"github.com/staticbackendhq/core/model"
 )
 
@@ -18,6 +19,7 @@ type Task struct {
 	Likes     int64     `json:"likes"`
 	Todos     []Todo    `json:"todos"`
 	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"sb_updated"`
 }
 
 type Todo struct {
@@ -211,6 +213,37 @@ func TestQueryDocuments(t *testing.T) {
 	}
 }
 
+func TestQueryDocumentsWithIDs(t *testing.T) {
+	var ids []string
+	for _, title := range []string{"search-match", "search-other"} {
+		res, err := datastore.CreateDocument(adminAuth, confDBName, colName, newTask(title, false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, res["id"].(string))
+	}
+
+	filters, err := datastore.ParseQuery([][]interface{}{{"done", "=", false}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the full-text search matched the first document and an invalid id
+	filters = sbquery.WithIDs(filters, []string{ids[0], "not-an-object-id"})
+
+	lp := model.ListParams{Page: 1, Size: 5}
+	result, err := datastore.QueryDocuments(adminAuth, confDBName, colName, filters, lp)
+	if err != nil {
+		t.Fatal(err)
+	} else if result.Total != 1 {
+		t.Fatalf("expected total to be 1 got %d", result.Total)
+	}
+
+	if r := dec(result.Results[0]); r.ID != ids[0] || r.Title != "search-match" {
+		t.Errorf("expected the matched document got %v", r)
+	}
+}
+
 func TestGetDocumentByID(t *testing.T) {
 	task1 := newTask("getbyid", false)
 
@@ -520,3 +553,58 @@ func TestQueryDocumentsWithNonExistingDB(t *testing.T) {
 		t.Fatalf("expected empty result\nActual: %#v\nExpected: %#v", result, expected)
 	}
 }
+
+func TestUpdatedFieldIsMaintained(t *testing.T) {
+	col := "updates_tracking"
+	title := fmt.Sprintf("track updates %d", time.Now().UnixNano())
+	m, err := datastore.CreateDocument(adminAuth, confDBName, col, newTask(title, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inserted := dec(m)
+	if inserted.Updated.IsZero() {
+		t.Fatal("expected sb_updated to be set on create")
+	}
+
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"UpdateDocument", func() error {
+			_, err := datastore.UpdateDocument(adminAuth, confDBName, col, inserted.ID, map[string]any{"done": true})
+			return err
+		}},
+		{"IncrementValue", func() error {
+			return datastore.IncrementValue(adminAuth, confDBName, col, inserted.ID, "likes", 1)
+		}},
+		{"UpdateDocuments", func() error {
+			filters, err := datastore.ParseQuery([][]any{{"title", "=", title}})
+			if err != nil {
+				return err
+			}
+			_, err = datastore.UpdateDocuments(adminAuth, confDBName, col, filters, map[string]any{"done": false})
+			return err
+		}},
+	}
+
+	last := inserted.Updated
+	for _, step := range steps {
+		time.Sleep(5 * time.Millisecond)
+
+		if err := step.fn(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		m, err := datastore.GetDocumentByID(adminAuth, confDBName, col, inserted.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := dec(m)
+		if !got.Updated.After(last) {
+			t.Errorf("%s: expected sb_updated after %v got %v", step.name, last, got.Updated)
+		}
+		last = got.Updated
+	}
+}
@@ -0,0 +1,49 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalEmailVerification struct {
+	UserID   string    `bson:"_id"`
+	Email    string    `bson:"email"`
+	Verified time.Time `bson:"verified"`
+}
+
+func (mg *Mongo) GetEmailVerification(dbName, userID string) (model.EmailVerification, error) {
+	db := mg.Client.Database(dbName)
+
+	var v LocalEmailVerification
+	if err := db.Collection("sb_email_verifications").FindOne(mg.Ctx, bson.M{"_id": userID}).Decode(&v); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.EmailVerification{}, nil
+		}
+		return model.EmailVerification{}, err
+	}
+
+	return model.EmailVerification{
+		UserID:   v.UserID,
+		Email:    v.Email,
+		Verified: v.Verified,
+	}, nil
+}
+
+func (mg *Mongo) SaveEmailVerification(dbName string, v model.EmailVerification) error {
+	db := mg.Client.Database(dbName)
+
+	doc := LocalEmailVerification{
+		UserID:   v.UserID,
+		Email:    v.Email,
+		Verified: v.Verified,
+	}
+
+	opt := options.Replace().SetUpsert(true)
+	_, err := db.Collection("sb_email_verifications").ReplaceOne(mg.Ctx, bson.M{"_id": v.UserID}, doc, opt)
+	return err
+}
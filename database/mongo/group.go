@@ -0,0 +1,184 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalGroup struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	AccountID string             `bson:"accountId"`
+	Name      string             `bson:"name"`
+	Created   time.Time          `bson:"created"`
+}
+
+type LocalGroupMember struct {
+	ID        string    `bson:"_id"`
+	GroupID   string    `bson:"groupId"`
+	AccountID string    `bson:"accountId"`
+	UserID    string    `bson:"userId"`
+	Added     time.Time `bson:"added"`
+}
+
+func fromLocalGroup(lg LocalGroup) model.Group {
+	return model.Group{
+		ID:        lg.ID.Hex(),
+		AccountID: lg.AccountID,
+		Name:      lg.Name,
+		Created:   lg.Created,
+	}
+}
+
+func fromLocalGroupMember(lm LocalGroupMember) model.GroupMember {
+	return model.GroupMember{
+		GroupID:   lm.GroupID,
+		AccountID: lm.AccountID,
+		UserID:    lm.UserID,
+		Added:     lm.Added,
+	}
+}
+
+func (mg *Mongo) CreateGroup(dbName string, g model.Group) (id string, err error) {
+	db := mg.Client.Database(dbName)
+
+	lg := LocalGroup{
+		ID:        primitive.NewObjectID(),
+		AccountID: g.AccountID,
+		Name:      g.Name,
+		Created:   g.Created,
+	}
+	if _, err = db.Collection("sb_groups").InsertOne(mg.Ctx, lg); err != nil {
+		return
+	}
+
+	id = lg.ID.Hex()
+	return
+}
+
+func (mg *Mongo) GetGroup(dbName, accountID, groupID string) (g model.Group, err error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(groupID)
+	if err != nil {
+		return
+	}
+
+	var lg LocalGroup
+	filter := bson.M{FieldID: oid, FieldAccountID: accountID}
+	if err = db.Collection("sb_groups").FindOne(mg.Ctx, filter).Decode(&lg); err != nil {
+		return
+	}
+
+	g = fromLocalGroup(lg)
+	return
+}
+
+func (mg *Mongo) ListGroups(dbName, accountID string) (results []model.Group, err error) {
+	db := mg.Client.Database(dbName)
+
+	opt := options.Find().SetSort(bson.M{"name": 1})
+	cur, err := db.Collection("sb_groups").Find(mg.Ctx, bson.M{FieldAccountID: accountID}, opt)
+	if err != nil {
+		return
+	}
+	defer func() { _ = cur.Close(mg.Ctx) }()
+
+	for cur.Next(mg.Ctx) {
+		var lg LocalGroup
+		if err = cur.Decode(&lg); err != nil {
+			return
+		}
+		results = append(results, fromLocalGroup(lg))
+	}
+
+	err = cur.Err()
+	return
+}
+
+func (mg *Mongo) DeleteGroup(dbName, accountID, groupID string) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(groupID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("sb_groups").DeleteOne(mg.Ctx, bson.M{FieldID: oid, FieldAccountID: accountID}); err != nil {
+		return err
+	}
+
+	_, err = db.Collection("sb_group_members").DeleteMany(mg.Ctx, bson.M{"groupId": groupID, FieldAccountID: accountID})
+	return err
+}
+
+func (mg *Mongo) AddGroupMember(dbName string, member model.GroupMember) error {
+	db := mg.Client.Database(dbName)
+
+	lm := LocalGroupMember{
+		ID:        member.GroupID + "_" + member.UserID,
+		GroupID:   member.GroupID,
+		AccountID: member.AccountID,
+		UserID:    member.UserID,
+		Added:     member.Added,
+	}
+
+	// adding an existing member keeps its original membership
+	opt := options.Update().SetUpsert(true)
+	_, err := db.Collection("sb_group_members").UpdateOne(mg.Ctx, bson.M{FieldID: lm.ID}, bson.M{"$setOnInsert": lm}, opt)
+	return err
+}
+
+func (mg *Mongo) RemoveGroupMember(dbName, groupID, userID string) error {
+	db := mg.Client.Database(dbName)
+
+	_, err := db.Collection("sb_group_members").DeleteOne(mg.Ctx, bson.M{FieldID: groupID + "_" + userID})
+	return err
+}
+
+func (mg *Mongo) ListGroupMembers(dbName, groupID string) (results []model.GroupMember, err error) {
+	db := mg.Client.Database(dbName)
+
+	opt := options.Find().SetSort(bson.M{"added": 1})
+	cur, err := db.Collection("sb_group_members").Find(mg.Ctx, bson.M{"groupId": groupID}, opt)
+	if err != nil {
+		return
+	}
+	defer func() { _ = cur.Close(mg.Ctx) }()
+
+	for cur.Next(mg.Ctx) {
+		var lm LocalGroupMember
+		if err = cur.Decode(&lm); err != nil {
+			return
+		}
+		results = append(results, fromLocalGroupMember(lm))
+	}
+
+	err = cur.Err()
+	return
+}
+
+func (mg *Mongo) ListUserGroups(dbName, accountID, userID string) (ids []string, err error) {
+	db := mg.Client.Database(dbName)
+
+	opt := options.Find().SetSort(bson.M{"groupId": 1})
+	cur, err := db.Collection("sb_group_members").Find(mg.Ctx, bson.M{FieldAccountID: accountID, "userId": userID}, opt)
+	if err != nil {
+		return
+	}
+	defer func() { _ = cur.Close(mg.Ctx) }()
+
+	for cur.Next(mg.Ctx) {
+		var lm LocalGroupMember
+		if err = cur.Decode(&lm); err != nil {
+			return
+		}
+		ids = append(ids, lm.GroupID)
+	}
+
+	err = cur.Err()
+	return
+}
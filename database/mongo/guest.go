@@ -0,0 +1,84 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalGuest struct {
+	UserID    string    `bson:"_id"`
+	AccountID string    `bson:"accountId"`
+	Created   time.Time `bson:"created"`
+	LastSeen  time.Time `bson:"lastSeen"`
+}
+
+func fromLocalGuest(lg LocalGuest) model.Guest {
+	return model.Guest{
+		UserID:    lg.UserID,
+		AccountID: lg.AccountID,
+		Created:   lg.Created,
+		LastSeen:  lg.LastSeen,
+	}
+}
+
+func (mg *Mongo) SaveGuest(dbName string, g model.Guest) error {
+	db := mg.Client.Database(dbName)
+
+	doc := LocalGuest{
+		UserID:    g.UserID,
+		AccountID: g.AccountID,
+		Created:   g.Created,
+		LastSeen:  g.LastSeen,
+	}
+
+	opt := options.Replace().SetUpsert(true)
+	_, err := db.Collection("sb_guests").ReplaceOne(mg.Ctx, bson.M{"_id": g.UserID}, doc, opt)
+	return err
+}
+
+func (mg *Mongo) GetGuest(dbName, userID string) (model.Guest, error) {
+	db := mg.Client.Database(dbName)
+
+	var lg LocalGuest
+	if err := db.Collection("sb_guests").FindOne(mg.Ctx, bson.M{"_id": userID}).Decode(&lg); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.Guest{}, nil
+		}
+		return model.Guest{}, err
+	}
+
+	return fromLocalGuest(lg), nil
+}
+
+func (mg *Mongo) ListGuestsSeenBefore(dbName string, before time.Time) (results []model.Guest, err error) {
+	db := mg.Client.Database(dbName)
+
+	cur, err := db.Collection("sb_guests").Find(mg.Ctx, bson.M{"lastSeen": bson.M{"$lt": before}})
+	if err != nil {
+		return
+	}
+	defer func() { _ = cur.Close(mg.Ctx) }()
+
+	for cur.Next(mg.Ctx) {
+		var lg LocalGuest
+		if err = cur.Decode(&lg); err != nil {
+			return
+		}
+		results = append(results, fromLocalGuest(lg))
+	}
+
+	err = cur.Err()
+	return
+}
+
+func (mg *Mongo) DeleteGuest(dbName, userID string) error {
+	db := mg.Client.Database(dbName)
+
+	_, err := db.Collection("sb_guests").DeleteOne(mg.Ctx, bson.M{"_id": userID})
+	return err
+}
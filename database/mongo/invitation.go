@@ -0,0 +1,132 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalInvitation struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	AccountID primitive.ObjectID `bson:"accountId"`
+	Email     string             `bson:"email"`
+	Role      int                `bson:"role"`
+	InvitedBy string             `bson:"invitedBy"`
+	Created   time.Time          `bson:"created"`
+	Sent      time.Time          `bson:"sent"`
+	Expires   time.Time          `bson:"expires"`
+}
+
+func fromLocalInvitation(li LocalInvitation) model.Invitation {
+	return model.Invitation{
+		ID:        li.ID.Hex(),
+		AccountID: li.AccountID.Hex(),
+		Email:     li.Email,
+		Role:      li.Role,
+		InvitedBy: li.InvitedBy,
+		Created:   li.Created,
+		Sent:      li.Sent,
+		Expires:   li.Expires,
+	}
+}
+
+func (mg *Mongo) CreateInvitation(dbName string, inv model.Invitation) (id string, err error) {
+	db := mg.Client.Database(dbName)
+
+	aid, err := primitive.ObjectIDFromHex(inv.AccountID)
+	if err != nil {
+		return
+	}
+
+	li := LocalInvitation{
+		ID:        primitive.NewObjectID(),
+		AccountID: aid,
+		Email:     inv.Email,
+		Role:      inv.Role,
+		InvitedBy: inv.InvitedBy,
+		Created:   inv.Created,
+		Sent:      inv.Sent,
+		Expires:   inv.Expires,
+	}
+	if _, err = db.Collection("sb_invitations").InsertOne(mg.Ctx, li); err != nil {
+		return
+	}
+
+	id = li.ID.Hex()
+	return
+}
+
+func (mg *Mongo) GetInvitation(dbName, id string) (inv model.Invitation, err error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return
+	}
+
+	var li LocalInvitation
+	if err = db.Collection("sb_invitations").FindOne(mg.Ctx, bson.M{FieldID: oid}).Decode(&li); err != nil {
+		return
+	}
+
+	inv = fromLocalInvitation(li)
+	return
+}
+
+func (mg *Mongo) ListInvitations(dbName, accountID string) (results []model.Invitation, err error) {
+	db := mg.Client.Database(dbName)
+
+	aid, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		return
+	}
+
+	opt := options.Find().SetSort(bson.M{"created": -1})
+	cur, err := db.Collection("sb_invitations").Find(mg.Ctx, bson.M{FieldAccountID: aid}, opt)
+	if err != nil {
+		return
+	}
+	defer func() { _ = cur.Close(mg.Ctx) }()
+
+	for cur.Next(mg.Ctx) {
+		var li LocalInvitation
+		if err = cur.Decode(&li); err != nil {
+			return
+		}
+		results = append(results, fromLocalInvitation(li))
+	}
+
+	err = cur.Err()
+	return
+}
+
+func (mg *Mongo) UpdateInvitation(dbName string, inv model.Invitation) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(inv.ID)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{
+		"sent":    inv.Sent,
+		"expires": inv.Expires,
+	}}
+	_, err = db.Collection("sb_invitations").UpdateOne(mg.Ctx, bson.M{FieldID: oid}, update)
+	return err
+}
+
+func (mg *Mongo) DeleteInvitation(dbName, id string) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("sb_invitations").DeleteOne(mg.Ctx, bson.M{FieldID: oid})
+	return err
+}
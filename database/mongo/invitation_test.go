@@ -0,0 +1,69 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestInvitations(t *testing.T) {
+	now := time.Now()
+
+	inv := model.Invitation{
+		AccountID: adminToken.AccountID,
+		Email:     "invited@test.com",
+		Role:      50,
+		InvitedBy: adminToken.ID,
+		Created:   now,
+		Sent:      now,
+		Expires:   now.Add(24 * time.Hour),
+	}
+
+	id, err := datastore.CreateInvitation(confDBName, inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inv.Email = "invited-later@test.com"
+	inv.Created = now.Add(time.Minute)
+	otherID, err := datastore.CreateInvitation(confDBName, inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inv.ID = id
+	inv.Sent = now.Add(2 * time.Minute)
+	inv.Expires = now.Add(48 * time.Hour)
+	if err := datastore.UpdateInvitation(confDBName, inv); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetInvitation(confDBName, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.Email != "invited@test.com" || check.Role != 50 {
+		t.Errorf("expected invited@test.com with role 50 got %v", check)
+	} else if check.Expires.Sub(inv.Expires).Abs() > time.Second {
+		t.Errorf("expected expiry %v got %v", inv.Expires, check.Expires)
+	}
+
+	invitations, err := datastore.ListInvitations(confDBName, adminToken.AccountID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(invitations) != 2 {
+		t.Fatalf("expected 2 invitations got %d", len(invitations))
+	} else if invitations[0].ID != otherID {
+		t.Errorf("expected most recent invitation %s first got %s", otherID, invitations[0].ID)
+	}
+
+	for _, invID := range []string{id, otherID} {
+		if err := datastore.DeleteInvitation(confDBName, invID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := datastore.GetInvitation(confDBName, id); err == nil {
+		t.Error("expected deleted invitation to be not found")
+	}
+}
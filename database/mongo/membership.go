@@ -94,11 +94,33 @@ func (mg *Mongo) DeleteAccount(dbName, accountID string) error {
 		return err
 	}
 
-	for _, col := range []string{"sb_tokens", "sb_files"} {
+	for _, col := range []string{"sb_tokens", "sb_files", "sb_invitations"} {
 		if _, err := db.Collection(col).DeleteMany(mg.Ctx, filter); err != nil {
 			return err
 		}
 	}
+	if _, err := db.Collection("sb_user_roles").DeleteMany(mg.Ctx, bson.M{FieldAccountID: accountID}); err != nil {
+		return err
+	}
+	for _, col := range []string{"sb_groups", "sb_group_members"} {
+		if _, err := db.Collection(col).DeleteMany(mg.Ctx, bson.M{FieldAccountID: accountID}); err != nil {
+			return err
+		}
+	}
+
+	hexUserIDs := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		hexUserIDs = append(hexUserIDs, id.Hex())
+	}
+	if _, err := db.Collection("sb_user_profiles").DeleteMany(mg.Ctx, bson.M{"_id": bson.M{"$in": hexUserIDs}}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("sb_user_phones").DeleteMany(mg.Ctx, bson.M{"_id": bson.M{"$in": hexUserIDs}}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("sb_guests").DeleteMany(mg.Ctx, bson.M{"_id": bson.M{"$in": hexUserIDs}}); err != nil {
+		return err
+	}
 
 	_, err = db.Collection("sb_accounts").DeleteOne(mg.Ctx, bson.M{FieldID: aid})
 	return err
@@ -277,5 +299,32 @@ func (mg *Mongo) RemoveUser(auth model.Auth, dbName, userID string) error {
 	if _, err := db.Collection("sb_tokens").DeleteOne(mg.Ctx, filter); err != nil {
 		return err
 	}
-	return nil
+	if err := mg.DeleteUserSessions(dbName, userID); err != nil {
+		return err
+	}
+	if _, err := db.Collection("sb_api_keys").DeleteMany(mg.Ctx, bson.M{"userId": uid}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("sb_oauth_consents").DeleteMany(mg.Ctx, bson.M{"userId": uid}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("sb_email_verifications").DeleteOne(mg.Ctx, bson.M{"_id": userID}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("sb_user_roles").DeleteMany(mg.Ctx, bson.M{"userId": userID}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("sb_user_profiles").DeleteOne(mg.Ctx, bson.M{"_id": userID}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("sb_user_phones").DeleteOne(mg.Ctx, bson.M{"_id": userID}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("sb_guests").DeleteOne(mg.Ctx, bson.M{"_id": userID}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("sb_group_members").DeleteMany(mg.Ctx, bson.M{"userId": userID}); err != nil {
+		return err
+	}
+	return mg.DeleteUserMFA(dbName, userID)
 }
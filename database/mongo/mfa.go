@@ -0,0 +1,65 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalUserMFA struct {
+	UserID        string    `bson:"_id"`
+	Secret        string    `bson:"secret"`
+	Enabled       bool      `bson:"enabled"`
+	RecoveryCodes []string  `bson:"codes"`
+	LastTOTPStep  int64     `bson:"lastStep"`
+	Created       time.Time `bson:"created"`
+}
+
+func (mg *Mongo) GetUserMFA(dbName, userID string) (model.UserMFA, error) {
+	db := mg.Client.Database(dbName)
+
+	var mfa LocalUserMFA
+	if err := db.Collection("sb_user_mfa").FindOne(mg.Ctx, bson.M{"_id": userID}).Decode(&mfa); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.UserMFA{}, nil
+		}
+		return model.UserMFA{}, err
+	}
+
+	return model.UserMFA{
+		UserID:        mfa.UserID,
+		Secret:        mfa.Secret,
+		Enabled:       mfa.Enabled,
+		RecoveryCodes: mfa.RecoveryCodes,
+		LastTOTPStep:  mfa.LastTOTPStep,
+		Created:       mfa.Created,
+	}, nil
+}
+
+func (mg *Mongo) SaveUserMFA(dbName string, mfa model.UserMFA) error {
+	db := mg.Client.Database(dbName)
+
+	doc := LocalUserMFA{
+		UserID:        mfa.UserID,
+		Secret:        mfa.Secret,
+		Enabled:       mfa.Enabled,
+		RecoveryCodes: mfa.RecoveryCodes,
+		LastTOTPStep:  mfa.LastTOTPStep,
+		Created:       mfa.Created,
+	}
+
+	opt := options.Replace().SetUpsert(true)
+	_, err := db.Collection("sb_user_mfa").ReplaceOne(mg.Ctx, bson.M{"_id": mfa.UserID}, doc, opt)
+	return err
+}
+
+func (mg *Mongo) DeleteUserMFA(dbName, userID string) error {
+	db := mg.Client.Database(dbName)
+
+	_, err := db.Collection("sb_user_mfa").DeleteOne(mg.Ctx, bson.M{"_id": userID})
+	return err
+}
@@ -0,0 +1,186 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalOAuthClient struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	Name         string             `bson:"name"`
+	SecretHash   string             `bson:"secretHash"`
+	RedirectURIs []string           `bson:"redirectUris"`
+	Public       bool               `bson:"public"`
+	Trusted      bool               `bson:"trusted"`
+	Created      time.Time          `bson:"created"`
+}
+
+type LocalOAuthConsent struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	UserID   primitive.ObjectID `bson:"userId"`
+	ClientID primitive.ObjectID `bson:"clientId"`
+	Scopes   []string           `bson:"scopes"`
+	Created  time.Time          `bson:"created"`
+}
+
+func fromLocalOAuthClient(lc LocalOAuthClient) model.OAuthClient {
+	return model.OAuthClient{
+		ID:           lc.ID.Hex(),
+		Name:         lc.Name,
+		SecretHash:   lc.SecretHash,
+		RedirectURIs: lc.RedirectURIs,
+		Public:       lc.Public,
+		Trusted:      lc.Trusted,
+		Created:      lc.Created,
+	}
+}
+
+func (mg *Mongo) CreateOAuthClient(dbName string, c model.OAuthClient) (id string, err error) {
+	db := mg.Client.Database(dbName)
+
+	lc := LocalOAuthClient{
+		ID:           primitive.NewObjectID(),
+		Name:         c.Name,
+		SecretHash:   c.SecretHash,
+		RedirectURIs: c.RedirectURIs,
+		Public:       c.Public,
+		Trusted:      c.Trusted,
+		Created:      c.Created,
+	}
+	if _, err = db.Collection("sb_oauth_clients").InsertOne(mg.Ctx, lc); err != nil {
+		return
+	}
+
+	id = lc.ID.Hex()
+	return
+}
+
+func (mg *Mongo) GetOAuthClient(dbName, id string) (c model.OAuthClient, err error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return
+	}
+
+	var lc LocalOAuthClient
+	if err = db.Collection("sb_oauth_clients").FindOne(mg.Ctx, bson.M{FieldID: oid}).Decode(&lc); err != nil {
+		return
+	}
+
+	c = fromLocalOAuthClient(lc)
+	return
+}
+
+func (mg *Mongo) ListOAuthClients(dbName string) (results []model.OAuthClient, err error) {
+	db := mg.Client.Database(dbName)
+
+	opt := options.Find().SetSort(bson.M{"created": 1})
+	cur, err := db.Collection("sb_oauth_clients").Find(mg.Ctx, bson.M{}, opt)
+	if err != nil {
+		return
+	}
+	defer func() { _ = cur.Close(mg.Ctx) }()
+
+	for cur.Next(mg.Ctx) {
+		var lc LocalOAuthClient
+		if err = cur.Decode(&lc); err != nil {
+			return
+		}
+		results = append(results, fromLocalOAuthClient(lc))
+	}
+
+	err = cur.Err()
+	return
+}
+
+func (mg *Mongo) DeleteOAuthClient(dbName, id string) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("sb_oauth_consents").DeleteMany(mg.Ctx, bson.M{"clientId": oid}); err != nil {
+		return err
+	}
+
+	_, err = db.Collection("sb_oauth_clients").DeleteOne(mg.Ctx, bson.M{FieldID: oid})
+	return err
+}
+
+func (mg *Mongo) ListOAuthConsents(dbName, userID string) (results []model.OAuthConsent, err error) {
+	db := mg.Client.Database(dbName)
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return
+	}
+
+	opt := options.Find().SetSort(bson.M{"created": 1})
+	cur, err := db.Collection("sb_oauth_consents").Find(mg.Ctx, bson.M{"userId": uid}, opt)
+	if err != nil {
+		return
+	}
+	defer func() { _ = cur.Close(mg.Ctx) }()
+
+	for cur.Next(mg.Ctx) {
+		var lc LocalOAuthConsent
+		if err = cur.Decode(&lc); err != nil {
+			return
+		}
+		results = append(results, model.OAuthConsent{
+			ID:       lc.ID.Hex(),
+			UserID:   lc.UserID.Hex(),
+			ClientID: lc.ClientID.Hex(),
+			Scopes:   lc.Scopes,
+			Created:  lc.Created,
+		})
+	}
+
+	err = cur.Err()
+	return
+}
+
+func (mg *Mongo) SaveOAuthConsent(dbName string, c model.OAuthConsent) error {
+	db := mg.Client.Database(dbName)
+
+	uid, err := primitive.ObjectIDFromHex(c.UserID)
+	if err != nil {
+		return err
+	}
+	cid, err := primitive.ObjectIDFromHex(c.ClientID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"userId": uid, "clientId": cid}
+	update := bson.M{
+		"$set":         bson.M{"scopes": c.Scopes, "created": c.Created},
+		"$setOnInsert": bson.M{FieldID: primitive.NewObjectID()},
+	}
+	opt := options.Update().SetUpsert(true)
+	_, err = db.Collection("sb_oauth_consents").UpdateOne(mg.Ctx, filter, update, opt)
+	return err
+}
+
+func (mg *Mongo) DeleteOAuthConsent(dbName, userID, clientID string) error {
+	db := mg.Client.Database(dbName)
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	cid, err := primitive.ObjectIDFromHex(clientID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("sb_oauth_consents").DeleteOne(mg.Ctx, bson.M{"userId": uid, "clientId": cid})
+	return err
+}
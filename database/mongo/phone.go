@@ -0,0 +1,79 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalUserPhone struct {
+	UserID   string    `bson:"_id"`
+	Number   string    `bson:"number"`
+	Verified time.Time `bson:"verified"`
+}
+
+func (mg *Mongo) GetUserPhone(dbName, userID string) (model.UserPhone, error) {
+	db := mg.Client.Database(dbName)
+
+	var phone LocalUserPhone
+	if err := db.Collection("sb_user_phones").FindOne(mg.Ctx, bson.M{"_id": userID}).Decode(&phone); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.UserPhone{}, nil
+		}
+		return model.UserPhone{}, err
+	}
+
+	return model.UserPhone{
+		UserID:   phone.UserID,
+		Number:   phone.Number,
+		Verified: phone.Verified,
+	}, nil
+}
+
+func (mg *Mongo) FindUserByPhone(dbName, number string) (tok model.User, err error) {
+	db := mg.Client.Database(dbName)
+
+	var phone LocalUserPhone
+	if err = db.Collection("sb_user_phones").FindOne(mg.Ctx, bson.M{"number": number}).Decode(&phone); err != nil {
+		return
+	}
+
+	uid, err := primitive.ObjectIDFromHex(phone.UserID)
+	if err != nil {
+		return
+	}
+
+	var lt LocalToken
+
+	sr := db.Collection("sb_tokens").FindOne(mg.Ctx, bson.M{FieldID: uid})
+	err = sr.Decode(&lt)
+
+	tok = fromLocalToken(lt)
+	return
+}
+
+func (mg *Mongo) SaveUserPhone(dbName string, phone model.UserPhone) error {
+	db := mg.Client.Database(dbName)
+
+	doc := LocalUserPhone{
+		UserID:   phone.UserID,
+		Number:   phone.Number,
+		Verified: phone.Verified,
+	}
+
+	opt := options.Replace().SetUpsert(true)
+	_, err := db.Collection("sb_user_phones").ReplaceOne(mg.Ctx, bson.M{"_id": phone.UserID}, doc, opt)
+	return err
+}
+
+func (mg *Mongo) DeleteUserPhone(dbName, userID string) error {
+	db := mg.Client.Database(dbName)
+
+	_, err := db.Collection("sb_user_phones").DeleteOne(mg.Ctx, bson.M{"_id": userID})
+	return err
+}
@@ -0,0 +1,52 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalUserProfile struct {
+	ID      string         `bson:"_id"`
+	Public  map[string]any `bson:"public"`
+	Private map[string]any `bson:"private"`
+	Updated time.Time      `bson:"updated"`
+}
+
+func (mg *Mongo) GetUserProfile(dbName, userID string) (model.UserProfile, error) {
+	db := mg.Client.Database(dbName)
+
+	var p LocalUserProfile
+	if err := db.Collection("sb_user_profiles").FindOne(mg.Ctx, bson.M{"_id": userID}).Decode(&p); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.UserProfile{UserID: userID}, nil
+		}
+		return model.UserProfile{}, err
+	}
+
+	return model.UserProfile{
+		UserID:  p.ID,
+		Public:  p.Public,
+		Private: p.Private,
+		Updated: p.Updated,
+	}, nil
+}
+
+func (mg *Mongo) SaveUserProfile(dbName string, p model.UserProfile) error {
+	db := mg.Client.Database(dbName)
+
+	doc := LocalUserProfile{
+		ID:      p.UserID,
+		Public:  p.Public,
+		Private: p.Private,
+		Updated: p.Updated,
+	}
+
+	opt := options.Replace().SetUpsert(true)
+	_, err := db.Collection("sb_user_profiles").ReplaceOne(mg.Ctx, bson.M{"_id": doc.ID}, doc, opt)
+	return err
+}
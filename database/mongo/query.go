@@ -87,22 +87,62 @@ func exprFilter(exprs []bson.M) bson.A {
 	return items
 }
 
-func secureRead(acctID, userID primitive.ObjectID, role int, col string, filter bson.M) {
-	switch internal.ReadScope(model.Auth{Role: role}, col) {
-	case internal.RowScopeAccount:
-		filter[FieldAccountID] = acctID
-	case internal.RowScopeOwner:
-		filter[FieldAccountID] = acctID
-		filter[FieldOwnerID] = userID
+func secureRead(auth model.Auth, acctID, userID primitive.ObjectID, col string, filter bson.M) {
+	secureFilter(auth, acctID, userID, internal.ReadScope(auth, col), filter)
+}
+
+// applyIDs replaces the document ids restriction of a filter by an _id $in
+// clause
+func applyIDs(filter bson.M) {
+	v, ok := filter[sbquery.IDsKey]
+	if !ok {
+		return
+	}
+
+	delete(filter, sbquery.IDsKey)
+
+	ids, _ := v.([]string)
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
 	}
+	filter[FieldID] = bson.M{"$in": oids}
+}
+
+func secureWrite(auth model.Auth, acctID, userID primitive.ObjectID, col string, filter bson.M) {
+	secureFilter(auth, acctID, userID, internal.WriteScope(auth, col, false), filter)
 }
 
-func secureWrite(acctID, userID primitive.ObjectID, role int, col string, filter bson.M) {
-	switch internal.WriteScope(model.Auth{Role: role}, col, false) {
+// secureFilter restricts a filter to the rows of the scope, group-scoped
+// rows are limited to the groups of the user
+func secureFilter(auth model.Auth, acctID, userID primitive.ObjectID, scope internal.RowPermissionScope, filter bson.M) {
+	switch scope {
 	case internal.RowScopeAccount:
 		filter[FieldAccountID] = acctID
+		if !internal.SeesAllGroups(auth) {
+			groups := bson.A{nil, ""}
+			for _, id := range auth.Groups {
+				groups = append(groups, id)
+			}
+			andFilter(filter, bson.M{model.FieldGroupID: bson.M{"$in": groups}})
+		}
 	case internal.RowScopeOwner:
 		filter[FieldAccountID] = acctID
-		filter[FieldOwnerID] = userID
+		if len(auth.Groups) == 0 {
+			filter[FieldOwnerID] = userID
+			return
+		}
+		andFilter(filter, bson.M{"$or": bson.A{
+			bson.M{FieldOwnerID: userID},
+			bson.M{model.FieldGroupID: bson.M{"$in": auth.Groups}},
+		}})
 	}
 }
+
+// andFilter adds a clause to the $and of a filter
+func andFilter(filter bson.M, clause bson.M) {
+	and, _ := filter["$and"].(bson.A)
+	filter["$and"] = append(and, clause)
+}
@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"errors"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalUserRole struct {
+	ID        string `bson:"_id"`
+	UserID    string `bson:"userId"`
+	AccountID string `bson:"accountId"`
+	Name      string `bson:"name"`
+}
+
+func userRoleID(userID, accountID string) string {
+	return userID + "_" + accountID
+}
+
+func (mg *Mongo) GetUserRole(dbName, userID, accountID string) (model.UserRole, error) {
+	db := mg.Client.Database(dbName)
+
+	var r LocalUserRole
+	filter := bson.M{"_id": userRoleID(userID, accountID)}
+	if err := db.Collection("sb_user_roles").FindOne(mg.Ctx, filter).Decode(&r); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.UserRole{}, nil
+		}
+		return model.UserRole{}, err
+	}
+
+	return model.UserRole{
+		UserID:    r.UserID,
+		AccountID: r.AccountID,
+		Name:      r.Name,
+	}, nil
+}
+
+func (mg *Mongo) SaveUserRole(dbName string, role model.UserRole) error {
+	db := mg.Client.Database(dbName)
+
+	doc := LocalUserRole{
+		ID:        userRoleID(role.UserID, role.AccountID),
+		UserID:    role.UserID,
+		AccountID: role.AccountID,
+		Name:      role.Name,
+	}
+
+	opt := options.Replace().SetUpsert(true)
+	_, err := db.Collection("sb_user_roles").ReplaceOne(mg.Ctx, bson.M{"_id": doc.ID}, doc, opt)
+	return err
+}
+
+func (mg *Mongo) DeleteUserRole(dbName, userID, accountID string) error {
+	db := mg.Client.Database(dbName)
+
+	_, err := db.Collection("sb_user_roles").DeleteOne(mg.Ctx, bson.M{"_id": userRoleID(userID, accountID)})
+	return err
+}
@@ -0,0 +1,24 @@
+package mongo
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalSequence struct {
+	Name  string `bson:"_id"`
+	Value int64  `bson:"v"`
+}
+
+func (mg *Mongo) NextSequence(dbName, name string) (int64, error) {
+	db := mg.Client.Database(dbName)
+
+	opt := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var seq LocalSequence
+	update := bson.M{"$inc": bson.M{"v": 1}}
+	if err := db.Collection("sb_sequences").FindOneAndUpdate(mg.Ctx, bson.M{"_id": name}, update, opt).Decode(&seq); err != nil {
+		return 0, err
+	}
+	return seq.Value, nil
+}
@@ -0,0 +1,171 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalSession struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	UserID       primitive.ObjectID `bson:"userId"`
+	AccountID    primitive.ObjectID `bson:"accountId"`
+	RefreshToken string             `bson:"refreshToken"`
+	UserAgent    string             `bson:"userAgent"`
+	IP           string             `bson:"ip"`
+	Created      time.Time          `bson:"created"`
+	LastSeen     time.Time          `bson:"lastSeen"`
+	Expires      time.Time          `bson:"expires"`
+	ClientID     string             `bson:"clientId"`
+	Scope        string             `bson:"scope"`
+}
+
+func fromLocalSession(ls LocalSession) model.Session {
+	return model.Session{
+		ID:           ls.ID.Hex(),
+		UserID:       ls.UserID.Hex(),
+		AccountID:    ls.AccountID.Hex(),
+		RefreshToken: ls.RefreshToken,
+		UserAgent:    ls.UserAgent,
+		IP:           ls.IP,
+		Created:      ls.Created,
+		LastSeen:     ls.LastSeen,
+		Expires:      ls.Expires,
+		ClientID:     ls.ClientID,
+		Scope:        ls.Scope,
+	}
+}
+
+func (mg *Mongo) CreateSession(dbName string, s model.Session) (id string, err error) {
+	db := mg.Client.Database(dbName)
+
+	uid, err := primitive.ObjectIDFromHex(s.UserID)
+	if err != nil {
+		return
+	}
+	aid, err := primitive.ObjectIDFromHex(s.AccountID)
+	if err != nil {
+		return
+	}
+
+	ls := LocalSession{
+		ID:           primitive.NewObjectID(),
+		UserID:       uid,
+		AccountID:    aid,
+		RefreshToken: s.RefreshToken,
+		UserAgent:    s.UserAgent,
+		IP:           s.IP,
+		Created:      s.Created,
+		LastSeen:     s.LastSeen,
+		Expires:      s.Expires,
+		ClientID:     s.ClientID,
+		Scope:        s.Scope,
+	}
+	if _, err = db.Collection("sb_sessions").InsertOne(mg.Ctx, ls); err != nil {
+		return
+	}
+
+	id = ls.ID.Hex()
+	return
+}
+
+func (mg *Mongo) GetSession(dbName, id string) (s model.Session, err error) {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return
+	}
+
+	var ls LocalSession
+	if err = db.Collection("sb_sessions").FindOne(mg.Ctx, bson.M{FieldID: oid}).Decode(&ls); err != nil {
+		return
+	}
+
+	s = fromLocalSession(ls)
+	return
+}
+
+func (mg *Mongo) ListSessions(dbName, userID string) (results []model.Session, err error) {
+	db := mg.Client.Database(dbName)
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return
+	}
+
+	opt := options.Find().SetSort(bson.M{"lastSeen": -1})
+	cur, err := db.Collection("sb_sessions").Find(mg.Ctx, bson.M{"userId": uid}, opt)
+	if err != nil {
+		return
+	}
+	defer func() { _ = cur.Close(mg.Ctx) }()
+
+	for cur.Next(mg.Ctx) {
+		var ls LocalSession
+		if err = cur.Decode(&ls); err != nil {
+			return
+		}
+		results = append(results, fromLocalSession(ls))
+	}
+
+	err = cur.Err()
+	return
+}
+
+func (mg *Mongo) UpdateSession(dbName string, s model.Session) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(s.ID)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{
+		"refreshToken": s.RefreshToken,
+		"lastSeen":     s.LastSeen,
+		"expires":      s.Expires,
+	}}
+	_, err = db.Collection("sb_sessions").UpdateOne(mg.Ctx, bson.M{FieldID: oid}, update)
+	return err
+}
+
+func (mg *Mongo) TouchSession(dbName, id string, lastSeen time.Time) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"lastSeen": lastSeen}}
+	_, err = db.Collection("sb_sessions").UpdateOne(mg.Ctx, bson.M{FieldID: oid}, update)
+	return err
+}
+
+func (mg *Mongo) DeleteSession(dbName, id string) error {
+	db := mg.Client.Database(dbName)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("sb_sessions").DeleteOne(mg.Ctx, bson.M{FieldID: oid})
+	return err
+}
+
+func (mg *Mongo) DeleteUserSessions(dbName, userID string) error {
+	db := mg.Client.Database(dbName)
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("sb_sessions").DeleteMany(mg.Ctx, bson.M{"userId": uid})
+	return err
+}
@@ -0,0 +1,52 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalSetting struct {
+	Key     string    `bson:"_id"`
+	Value   []byte    `bson:"v"`
+	Updated time.Time `bson:"u"`
+}
+
+func (mg *Mongo) GetSetting(dbName, key string) ([]byte, error) {
+	db := mg.Client.Database(dbName)
+
+	var s LocalSetting
+	if err := db.Collection("sb_settings").FindOne(mg.Ctx, bson.M{"_id": key}).Decode(&s); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return s.Value, nil
+}
+
+func (mg *Mongo) SetSetting(dbName, key string, value []byte) error {
+	db := mg.Client.Database(dbName)
+
+	s := LocalSetting{Key: key, Value: value, Updated: time.Now()}
+
+	opt := options.Replace().SetUpsert(true)
+	_, err := db.Collection("sb_settings").ReplaceOne(mg.Ctx, bson.M{"_id": key}, s, opt)
+	return err
+}
+
+func (mg *Mongo) AddSetting(dbName, key string, value []byte) (bool, error) {
+	db := mg.Client.Database(dbName)
+
+	s := LocalSetting{Key: key, Value: value, Updated: time.Now()}
+	if _, err := db.Collection("sb_settings").InsertOne(mg.Ctx, s); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,63 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LocalSigningKey struct {
+	ID         string    `bson:"_id"`
+	Algorithm  string    `bson:"algorithm"`
+	PrivateKey string    `bson:"privateKey"`
+	Created    time.Time `bson:"created"`
+}
+
+func (mg *Mongo) ListSigningKeys() ([]model.SigningKey, error) {
+	db := mg.Client.Database("sbsys")
+
+	opts := options.Find().SetSort(bson.M{"created": 1})
+	cur, err := db.Collection("sb_signing_keys").Find(mg.Ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cur.Close(mg.Ctx) }()
+
+	var results []model.SigningKey
+	for cur.Next(mg.Ctx) {
+		var lk LocalSigningKey
+		if err := cur.Decode(&lk); err != nil {
+			return nil, err
+		}
+
+		results = append(results, model.SigningKey{
+			ID:         lk.ID,
+			Algorithm:  lk.Algorithm,
+			PrivateKey: lk.PrivateKey,
+			Created:    lk.Created,
+		})
+	}
+	return results, cur.Err()
+}
+
+func (mg *Mongo) AddSigningKey(k model.SigningKey) error {
+	db := mg.Client.Database("sbsys")
+
+	lk := LocalSigningKey{
+		ID:         k.ID,
+		Algorithm:  k.Algorithm,
+		PrivateKey: k.PrivateKey,
+		Created:    k.Created,
+	}
+	_, err := db.Collection("sb_signing_keys").InsertOne(mg.Ctx, lk)
+	return err
+}
+
+func (mg *Mongo) DeleteSigningKey(id string) error {
+	db := mg.Client.Database("sbsys")
+
+	_, err := db.Collection("sb_signing_keys").DeleteOne(mg.Ctx, bson.M{FieldID: id})
+	return err
+}
@@ -0,0 +1,43 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestSigningKeys(t *testing.T) {
+	older := model.SigningKey{ID: "kid-older", Algorithm: model.JWTAlgRS256, PrivateKey: "enc-older", Created: time.Now().Add(-time.Hour)}
+	newer := model.SigningKey{ID: "kid-newer", Algorithm: model.JWTAlgEdDSA, PrivateKey: "enc-newer", Created: time.Now()}
+
+	for _, k := range []model.SigningKey{newer, older} {
+		if err := datastore.AddSigningKey(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		_ = datastore.DeleteSigningKey(older.ID)
+		_ = datastore.DeleteSigningKey(newer.ID)
+	})
+
+	keys, err := datastore.ListSigningKeys()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(keys) != 2 {
+		t.Fatalf("expected 2 signing keys got %d", len(keys))
+	} else if keys[0].ID != older.ID || keys[1].PrivateKey != newer.PrivateKey || keys[1].Algorithm != model.JWTAlgEdDSA {
+		t.Errorf("expected keys oldest first got %v", keys)
+	}
+
+	if err := datastore.DeleteSigningKey(older.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err = datastore.ListSigningKeys()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(keys) != 1 || keys[0].ID != newer.ID {
+		t.Errorf("expected only the newer key to remain got %v", keys)
+	}
+}
@@ -1,6 +1,9 @@
 package database
 
 import (
+	"database/sql"
+	"time"
+
 	"github.com/staticbackendhq/core/model"
 )
 
@@ -22,6 +25,20 @@ type TypedIndexer interface {
 	CreateTypedIndex(dbName, col, field string, typ IndexType) error
 }
 
+// PoolStats holds the statistics of one database connection pool
+type PoolStats struct {
+	Name    string      `json:"name"`
+	Primary bool        `json:"primary"`
+	Healthy bool        `json:"healthy"`
+	Stats   sql.DBStats `json:"stats"`
+}
+
+// PoolStatser is implemented by data stores exposing their connection pools
+// statistics, i.e. PostgreSQL with read replicas
+type PoolStatser interface {
+	PoolStats() []PoolStats
+}
+
 func IsSupportedIndexType(typ IndexType) bool {
 	switch typ {
 	case IndexTypeDefault, IndexTypeNumber, IndexTypeBoolean:
@@ -71,6 +88,14 @@ type Persister interface {
 	// note: this does not remove all the tenant's data
 	DeleteTenant(dbName, email string) error
 
+	// session token signing keys, shared by all databases
+	// ListSigningKeys returns the session token signing keys
+	ListSigningKeys() ([]model.SigningKey, error)
+	// AddSigningKey adds a session token signing key
+	AddSigningKey(k model.SigningKey) error
+	// DeleteSigningKey removes a session token signing key
+	DeleteSigningKey(id string) error
+
 	// system user account functions
 	// GetUserByID returns a User matching the accountID and userID
 	GetUserByID(dbName, accountID, userID string) (model.User, error)
@@ -127,6 +152,136 @@ type Persister interface {
 	// UpdateUserAccount changes the home account and role of a user (used by PromoteToOwnAccount)
 	UpdateUserAccount(dbName, userID, newAccountID string, role int) error
 
+	// multi-factor authentication functions
+	// GetUserMFA returns the TOTP enrollment of a user, UserID is empty if the user never enrolled
+	GetUserMFA(dbName, userID string) (model.UserMFA, error)
+	// SaveUserMFA creates or replaces the TOTP enrollment of a user
+	SaveUserMFA(dbName string, mfa model.UserMFA) error
+	// DeleteUserMFA removes the TOTP enrollment of a user
+	DeleteUserMFA(dbName, userID string) error
+
+	// custom role functions
+	// GetUserRole returns the custom role of a user in an account, Name is empty if the user has none
+	GetUserRole(dbName, userID, accountID string) (model.UserRole, error)
+	// SaveUserRole creates or replaces the custom role of a user in an account
+	SaveUserRole(dbName string, role model.UserRole) error
+	// DeleteUserRole removes the custom role of a user in an account
+	DeleteUserRole(dbName, userID, accountID string) error
+
+	// user profile functions
+	// GetUserProfile returns the profile of a user, its parts are empty if the user has none
+	GetUserProfile(dbName, userID string) (model.UserProfile, error)
+	// SaveUserProfile creates or replaces the profile of a user
+	SaveUserProfile(dbName string, profile model.UserProfile) error
+
+	// user phone functions
+	// GetUserPhone returns the verified phone number of a user, UserID is empty if the user has none
+	GetUserPhone(dbName, userID string) (model.UserPhone, error)
+	// FindUserByPhone returns the user having a verified phone number
+	FindUserByPhone(dbName, number string) (model.User, error)
+	// SaveUserPhone creates or replaces the verified phone number of a user
+	SaveUserPhone(dbName string, phone model.UserPhone) error
+	// DeleteUserPhone removes the phone number of a user
+	DeleteUserPhone(dbName, userID string) error
+
+	// guest user functions
+	// SaveGuest creates or replaces the guest record of an anonymous user
+	SaveGuest(dbName string, g model.Guest) error
+	// GetGuest returns the guest record of a user, UserID is empty if the user is not a guest
+	GetGuest(dbName, userID string) (model.Guest, error)
+	// ListGuestsSeenBefore returns the guests not seen since a time
+	ListGuestsSeenBefore(dbName string, before time.Time) ([]model.Guest, error)
+	// DeleteGuest removes the guest record of a user once upgraded
+	DeleteGuest(dbName, userID string) error
+
+	// account group functions
+	// CreateGroup adds a group to an account
+	CreateGroup(dbName string, g model.Group) (string, error)
+	// GetGroup returns a group of an account, an error is returned if it does not exist
+	GetGroup(dbName, accountID, groupID string) (model.Group, error)
+	// ListGroups returns the groups of an account ordered by name
+	ListGroups(dbName, accountID string) ([]model.Group, error)
+	// DeleteGroup removes a group and its memberships
+	DeleteGroup(dbName, accountID, groupID string) error
+	// AddGroupMember adds a user to a group, adding an existing member does nothing
+	AddGroupMember(dbName string, member model.GroupMember) error
+	// RemoveGroupMember removes a user from a group
+	RemoveGroupMember(dbName, groupID, userID string) error
+	// ListGroupMembers returns the members of a group
+	ListGroupMembers(dbName, groupID string) ([]model.GroupMember, error)
+	// ListUserGroups returns the ids of the groups of a user in an account
+	ListUserGroups(dbName, accountID, userID string) ([]string, error)
+
+	// audit log functions
+	// AddAuditEntry appends an entry to the audit log
+	AddAuditEntry(dbName string, entry model.AuditEntry) error
+	// ListAuditEntries returns the audit entries matching the filter, most recent first
+	ListAuditEntries(dbName string, filter model.AuditFilter) ([]model.AuditEntry, error)
+	// DeleteAuditEntriesBefore removes the entries created before a time and returns how many were removed
+	DeleteAuditEntriesBefore(dbName string, before time.Time) (int64, error)
+
+	// email verification functions
+	// GetEmailVerification returns the verified email of a user, UserID is empty if the user never verified an email
+	GetEmailVerification(dbName, userID string) (model.EmailVerification, error)
+	// SaveEmailVerification creates or replaces the verified email of a user
+	SaveEmailVerification(dbName string, v model.EmailVerification) error
+
+	// account invitation functions
+	// CreateInvitation adds a pending invitation to an account
+	CreateInvitation(dbName string, inv model.Invitation) (string, error)
+	// GetInvitation returns an invitation, an error is returned if it does not exist
+	GetInvitation(dbName, id string) (model.Invitation, error)
+	// ListInvitations returns the pending invitations of an account, most recent first
+	ListInvitations(dbName, accountID string) ([]model.Invitation, error)
+	// UpdateInvitation saves the last time an invitation was sent and its expiry
+	UpdateInvitation(dbName string, inv model.Invitation) error
+	// DeleteInvitation removes an invitation once accepted or revoked
+	DeleteInvitation(dbName, id string) error
+
+	// session functions
+	// CreateSession adds a signed-in session for a user
+	CreateSession(dbName string, s model.Session) (string, error)
+	// GetSession returns a session, an error is returned if it does not exist
+	GetSession(dbName, id string) (model.Session, error)
+	// ListSessions returns the sessions of a user, most recently seen first
+	ListSessions(dbName, userID string) ([]model.Session, error)
+	// UpdateSession saves the refresh token, last seen and expiry of a session
+	UpdateSession(dbName string, s model.Session) error
+	// TouchSession sets the last time a session was used
+	TouchSession(dbName, id string, lastSeen time.Time) error
+	// DeleteSession revokes a session
+	DeleteSession(dbName, id string) error
+	// DeleteUserSessions revokes all sessions of a user
+	DeleteUserSessions(dbName, userID string) error
+
+	// API key functions
+	// CreateAPIKey adds an API key
+	CreateAPIKey(dbName string, k model.APIKey) (string, error)
+	// GetAPIKey returns an API key, an error is returned if it does not exist
+	GetAPIKey(dbName, id string) (model.APIKey, error)
+	// ListAPIKeys returns all API keys of the database
+	ListAPIKeys(dbName string) ([]model.APIKey, error)
+	// TouchAPIKey sets the last time an API key was used
+	TouchAPIKey(dbName, id string, lastUsed time.Time) error
+	// DeleteAPIKey revokes an API key
+	DeleteAPIKey(dbName, id string) error
+
+	// OAuth client functions, used when the database is an OpenID Connect provider
+	// CreateOAuthClient registers an OAuth client
+	CreateOAuthClient(dbName string, c model.OAuthClient) (string, error)
+	// GetOAuthClient returns an OAuth client, an error is returned if it does not exist
+	GetOAuthClient(dbName, id string) (model.OAuthClient, error)
+	// ListOAuthClients returns all OAuth clients of the database
+	ListOAuthClients(dbName string) ([]model.OAuthClient, error)
+	// DeleteOAuthClient removes an OAuth client and the consents granted to it
+	DeleteOAuthClient(dbName, id string) error
+	// ListOAuthConsents returns the clients a user granted access to
+	ListOAuthConsents(dbName, userID string) ([]model.OAuthConsent, error)
+	// SaveOAuthConsent creates or replaces the consent of a user for a client
+	SaveOAuthConsent(dbName string, c model.OAuthConsent) error
+	// DeleteOAuthConsent revokes the consent of a user for a client
+	DeleteOAuthConsent(dbName, userID, clientID string) error
+
 	// base CRUD
 	// CreateDocument creates a record in a collection
 	CreateDocument(auth model.Auth, dbName, col string, doc map[string]interface{}) (map[string]interface{}, error)
@@ -154,6 +309,17 @@ type Persister interface {
 	// ParseQuery parses the filters into an internal query clauses
 	ParseQuery(clauses [][]interface{}) (map[string]interface{}, error)
 
+	// database settings
+	// GetSetting returns the JSON value of a database setting, nil if it was never set
+	GetSetting(dbName, key string) ([]byte, error)
+	// SetSetting creates or replaces the JSON value of a database setting
+	SetSetting(dbName, key string, value []byte) error
+	// AddSetting creates a database setting unless it exists, it returns
+	// false when the setting was already set
+	AddSetting(dbName, key string, value []byte) (bool, error)
+	// NextSequence increments and returns the named sequence, starting at 1
+	NextSequence(dbName, name string) (int64, error)
+
 	// form functions
 	// AddFormSubmission adds a form submission
 	AddFormSubmission(dbName, form string, doc map[string]interface{}) error
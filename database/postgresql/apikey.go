@@ -0,0 +1,115 @@
+package postgresql
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) CreateAPIKey(dbName string, k model.APIKey) (id string, err error) {
+	scopes, err := json.Marshal(k.Scopes)
+	if err != nil {
+		return
+	}
+
+	if k.AllowedIPs == nil {
+		k.AllowedIPs = []string{}
+	}
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_api_keys(account_id, user_id, name, key_hash, scopes, allowed_ips, expires, created)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id;
+	`, dbName)
+
+	err = pg.DB.QueryRow(qry,
+		k.AccountID,
+		k.UserID,
+		k.Name,
+		k.KeyHash,
+		scopes,
+		pq.Array(k.AllowedIPs),
+		k.Expires,
+		k.Created,
+	).Scan(&id)
+	return
+}
+
+func (pg *PostgreSQL) GetAPIKey(dbName, id string) (k model.APIKey, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, account_id, user_id, name, key_hash, scopes, allowed_ips, expires, last_used, created
+		FROM %s.sb_api_keys
+		WHERE id = $1;
+	`, dbName)
+
+	err = scanAPIKey(pg.DB.QueryRow(qry, id), &k)
+	return
+}
+
+func (pg *PostgreSQL) ListAPIKeys(dbName string) (results []model.APIKey, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, account_id, user_id, name, key_hash, scopes, allowed_ips, expires, last_used, created
+		FROM %s.sb_api_keys
+		ORDER BY created ASC;
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var k model.APIKey
+		if err = scanAPIKey(rows, &k); err != nil {
+			return
+		}
+		results = append(results, k)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) TouchAPIKey(dbName, id string, lastUsed time.Time) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s.sb_api_keys SET last_used = $2
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, id, lastUsed)
+	return err
+}
+
+func (pg *PostgreSQL) DeleteAPIKey(dbName, id string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s.sb_api_keys
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, id)
+	return err
+}
+
+func scanAPIKey(rows Scanner, k *model.APIKey) error {
+	var scopes []byte
+	if err := rows.Scan(
+		&k.ID,
+		&k.AccountID,
+		&k.UserID,
+		&k.Name,
+		&k.KeyHash,
+		&scopes,
+		pq.Array(&k.AllowedIPs),
+		&k.Expires,
+		&k.LastUsed,
+		&k.Created,
+	); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(scopes, &k.Scopes)
+}
@@ -0,0 +1,73 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestAPIKeys(t *testing.T) {
+	expires := time.Now().Add(24 * time.Hour)
+
+	k := model.APIKey{
+		AccountID: adminToken.AccountID,
+		UserID:    adminToken.ID,
+		Name:      "backend jobs",
+		KeyHash:   "hash",
+		Scopes: model.APIKeyScopes{
+			Collections: []model.CollectionScope{{Name: "tasks", Read: true}},
+			Storage:     true,
+		},
+		AllowedIPs: []string{"10.0.0.0/8"},
+		Expires:    &expires,
+		Created:    time.Now(),
+	}
+
+	id, err := datastore.CreateAPIKey(confDBName, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetAPIKey(confDBName, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.KeyHash != "hash" {
+		t.Errorf("expected key hash hash got %s", check.KeyHash)
+	} else if !check.Scopes.Allows(model.APIKeyScopeCollections, "tasks", false) {
+		t.Errorf("expected read access to tasks got %v", check.Scopes)
+	} else if len(check.AllowedIPs) != 1 || check.AllowedIPs[0] != "10.0.0.0/8" {
+		t.Errorf("expected allowed IPs [10.0.0.0/8] got %v", check.AllowedIPs)
+	} else if check.Expires == nil {
+		t.Error("expected an expiry")
+	} else if check.LastUsed != nil {
+		t.Errorf("expected the key to never be used got %v", check.LastUsed)
+	}
+
+	if err := datastore.TouchAPIKey(confDBName, id, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := datastore.ListAPIKeys(confDBName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, key := range keys {
+		if key.ID == id {
+			found = key.LastUsed != nil
+		}
+	}
+	if !found {
+		t.Fatalf("expected the used key in the list got %v", keys)
+	}
+
+	if err := datastore.DeleteAPIKey(confDBName, id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := datastore.GetAPIKey(confDBName, id); err == nil {
+		t.Error("expected deleted API key to be not found")
+	}
+}
@@ -0,0 +1,70 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestAuditLog(t *testing.T) {
+	old := time.Now().Add(-400 * 24 * time.Hour)
+
+	entry := model.AuditEntry{
+		AccountID:  adminToken.AccountID,
+		ActorID:    adminToken.ID,
+		ActorEmail: adminToken.Email,
+		Action:     model.AuditActionImpersonate,
+		Target:     "audit-target",
+		IP:         "127.0.0.1",
+		Created:    old,
+	}
+	if err := datastore.AddAuditEntry(confDBName, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	entry.Action = model.AuditActionRoleChange
+	entry.Details = "role 50"
+	entry.Created = old.Add(time.Minute)
+	if err := datastore.AddAuditEntry(confDBName, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := datastore.ListAuditEntries(confDBName, model.AuditFilter{Target: "audit-target"})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 2 {
+		t.Fatalf("expected 2 entries got %d", len(entries))
+	} else if entries[0].Action != model.AuditActionRoleChange || entries[0].Details != "role 50" {
+		t.Errorf("expected the most recent entry first got %v", entries[0])
+	} else if len(entries[0].ID) == 0 || entries[0].IP != "127.0.0.1" {
+		t.Errorf("expected an id and the ip got %v", entries[0])
+	}
+
+	filter := model.AuditFilter{Target: "audit-target", Action: model.AuditActionImpersonate}
+	if entries, err := datastore.ListAuditEntries(confDBName, filter); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 || entries[0].Action != model.AuditActionImpersonate {
+		t.Errorf("expected the impersonate entry got %v", entries)
+	}
+
+	filter = model.AuditFilter{Target: "audit-target", Since: old.Add(30 * time.Second), Limit: 1}
+	if entries, err := datastore.ListAuditEntries(confDBName, filter); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 || entries[0].Action != model.AuditActionRoleChange {
+		t.Errorf("expected the role change entry got %v", entries)
+	}
+
+	n, err := datastore.DeleteAuditEntriesBefore(confDBName, old.Add(30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Errorf("expected 1 entry removed got %d", n)
+	}
+
+	if entries, err := datastore.ListAuditEntries(confDBName, model.AuditFilter{Target: "audit-target"}); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 {
+		t.Errorf("expected 1 entry left got %d", len(entries))
+	}
+}
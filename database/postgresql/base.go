@@ -1,6 +1,7 @@
 package postgresql
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"github.com/staticbackendhq/core/model"
 )
@@ -18,6 +20,7 @@ const (
 	FieldAccountID = "accountId"
 	FieldOwnerID   = "sb_ownerId"
 	FieldCreated   = "sb_created"
+	FieldUpdated   = "sb_updated"
 	FieldFormName  = "sb_form"
 )
 
@@ -48,44 +51,26 @@ func (pg *PostgreSQL) CreateDocument(auth model.Auth, dbName, col string, doc ma
 	inserted = doc
 	removeNotEditableFields(inserted)
 
-	cleancol := model.CleanCollectionName(col)
-
-	//TODO: find a good way to prevent doing the create
-	// table if not exists each time
-
-	qry := fmt.Sprintf(`
-		CREATE TABLE IF NOT EXISTS %s.%s (
-			id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
-			account_id uuid REFERENCES %s.sb_accounts(id) ON DELETE CASCADE,
-			owner_id uuid REFERENCES %s.sb_tokens(id) ON DELETE CASCADE,
-			data jsonb NOT NULL,
-			created timestamp NOT NULL
-		);
-
-		CREATE INDEX IF NOT EXISTS %s_acctid_idx ON %s.%s (account_id);			
-	`, dbName, cleancol, dbName, dbName, cleancol, dbName, cleancol)
-
-	if _, err = pg.DB.Exec(qry); err != nil {
-		err = fmt.Errorf("error creating table: %w", err)
-		return
-	}
-
 	var id string
 
-	qry = fmt.Sprintf(`
+	qry := fmt.Sprintf(`
 		INSERT INTO %s.%s(account_id, owner_id, data, created)
 		VALUES($1, $2, $3, $4)
 		RETURNING id;
 	`, dbName, model.CleanCollectionName(col))
 
+	created := time.Now()
+	doc[FieldUpdated] = created
+
 	b, err := json.Marshal(doc)
 	if err != nil {
 		err = fmt.Errorf("error executing INSERT: %w", err)
 		return
 	}
 
-	created := time.Now()
-	err = pg.DB.QueryRow(qry, auth.AccountID, auth.UserID, b, created).Scan(&id)
+	err = pg.withCollection(dbName, col, func() error {
+		return pg.DB.QueryRow(qry, auth.AccountID, auth.UserID, b, created).Scan(&id)
+	})
 	if err != nil {
 		err = fmt.Errorf("error getting the new row ID: %w", err)
 	}
@@ -100,23 +85,96 @@ func (pg *PostgreSQL) CreateDocument(auth model.Auth, dbName, col string, doc ma
 	return
 }
 
+// BulkCreateDocument inserts all documents in a single transaction using
+// COPY, the ids are generated client-side since COPY does not return them.
 func (pg *PostgreSQL) BulkCreateDocument(auth model.Auth, dbName, col string, docs []interface{}) error {
-	//TODO: Naive implementation, not sure if PostgreSQL
-	// has a better way for bulk insert, but will suffice for now.
+	created := time.Now()
+
+	inserted := make([]map[string]interface{}, 0, len(docs))
+	ids := make([]string, 0, len(docs))
 	for _, doc := range docs {
 		d, ok := doc.(map[string]interface{})
 		if !ok {
 			return errors.New("unable to cast doc as map[string]interface{}")
 		}
 
-		if _, err := pg.CreateDocument(auth, dbName, col, d); err != nil {
+		removeNotEditableFields(d)
+		d[FieldUpdated] = created
+		inserted = append(inserted, d)
+		ids = append(ids, uuid.NewString())
+	}
+
+	if len(inserted) == 0 {
+		return nil
+	}
+
+	err := pg.withCollection(dbName, col, func() error {
+		return pg.copyDocuments(auth, dbName, col, ids, inserted, created)
+	})
+	if err != nil {
+		return fmt.Errorf("error inserting documents: %w", err)
+	}
+
+	for i, d := range inserted {
+		d[FieldID] = ids[i]
+		d[FieldAccountID] = auth.AccountID
+		d[FieldOwnerID] = auth.UserID
+		d[FieldCreated] = created
+
+		pg.PublishDocument(auth, dbName, "db-"+col, model.MsgTypeDBCreated, d)
+	}
+	return nil
+}
+
+func (pg *PostgreSQL) copyDocuments(auth model.Auth, dbName, col string, ids []string, docs []map[string]interface{}, created time.Time) error {
+	tx, err := pg.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.Prepare(pq.CopyInSchema(
+		dbName,
+		model.CleanCollectionName(col),
+		"id", "account_id", "owner_id", "data", "created",
+	))
+	if err != nil {
+		return err
+	}
+
+	for i, d := range docs {
+		b, err := json.Marshal(d)
+		if err != nil {
+			_ = stmt.Close()
+			return err
+		}
+
+		if _, err := stmt.Exec(ids[i], auth.AccountID, auth.UserID, string(b), created); err != nil {
+			_ = stmt.Close()
 			return err
 		}
 	}
-	return nil
+
+	if _, err := stmt.Exec(); err != nil {
+		_ = stmt.Close()
+		return err
+	}
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (pg *PostgreSQL) ListDocuments(auth model.Auth, dbName, col string, params model.ListParams) (result model.PagedResult, err error) {
+	err = pg.read(func(db *sql.DB) error {
+		result, err = pg.listDocuments(db, auth, dbName, col, params)
+		return err
+	})
+	return
+}
+
+func (pg *PostgreSQL) listDocuments(db *sql.DB, auth model.Auth, dbName, col string, params model.ListParams) (result model.PagedResult, err error) {
 	where := secureRead(auth, col)
 
 	paging := setPaging(params)
@@ -130,7 +188,7 @@ func (pg *PostgreSQL) ListDocuments(auth model.Auth, dbName, col string, params
 		%s
 	`, dbName, model.CleanCollectionName(col), where)
 
-	if err = pg.DB.QueryRow(qry, auth.AccountID, auth.UserID).Scan(&result.Total); err != nil {
+	if err = db.QueryRow(qry, auth.AccountID, auth.UserID).Scan(&result.Total); err != nil {
 		if !isTableExists(err) {
 			return result, nil
 		}
@@ -144,7 +202,7 @@ func (pg *PostgreSQL) ListDocuments(auth model.Auth, dbName, col string, params
 		%s
 	`, dbName, model.CleanCollectionName(col), where, paging)
 
-	rows, err := pg.DB.Query(qry, auth.AccountID, auth.UserID)
+	rows, err := db.Query(qry, auth.AccountID, auth.UserID)
 	if err != nil {
 		slog.Error("error in select", "error", err)
 		return
@@ -165,6 +223,14 @@ func (pg *PostgreSQL) ListDocuments(auth model.Auth, dbName, col string, params
 }
 
 func (pg *PostgreSQL) QueryDocuments(auth model.Auth, dbName, col string, filters map[string]interface{}, params model.ListParams) (result model.PagedResult, err error) {
+	err = pg.read(func(db *sql.DB) error {
+		result, err = pg.queryDocuments(db, auth, dbName, col, filters, params)
+		return err
+	})
+	return
+}
+
+func (pg *PostgreSQL) queryDocuments(db *sql.DB, auth model.Auth, dbName, col string, filters map[string]interface{}, params model.ListParams) (result model.PagedResult, err error) {
 	where := secureRead(auth, col)
 	where, filterArgs := applyFilter(where, filters, 3)
 	queryArgs := append([]any{auth.AccountID, auth.UserID}, filterArgs...)
@@ -180,7 +246,7 @@ func (pg *PostgreSQL) QueryDocuments(auth model.Auth, dbName, col string, filter
 		%s
 	`, dbName, model.CleanCollectionName(col), where)
 
-	if err = pg.DB.QueryRow(qry, queryArgs...).Scan(&result.Total); err != nil {
+	if err = db.QueryRow(qry, queryArgs...).Scan(&result.Total); err != nil {
 		if !isTableExists(err) {
 			return result, nil
 		}
@@ -194,7 +260,7 @@ func (pg *PostgreSQL) QueryDocuments(auth model.Auth, dbName, col string, filter
 		%s
 	`, dbName, model.CleanCollectionName(col), where, paging)
 
-	rows, err := pg.DB.Query(qry, queryArgs...)
+	rows, err := db.Query(qry, queryArgs...)
 	if err != nil {
 		return
 	}
@@ -213,7 +279,15 @@ func (pg *PostgreSQL) QueryDocuments(auth model.Auth, dbName, col string, filter
 	return
 }
 
-func (pg *PostgreSQL) GetDocumentByID(auth model.Auth, dbName, col, id string) (map[string]interface{}, error) {
+func (pg *PostgreSQL) GetDocumentByID(auth model.Auth, dbName, col, id string) (doc map[string]interface{}, err error) {
+	err = pg.read(func(db *sql.DB) error {
+		doc, err = pg.getDocumentByID(db, auth, dbName, col, id)
+		return err
+	})
+	return
+}
+
+func (pg *PostgreSQL) getDocumentByID(db *sql.DB, auth model.Auth, dbName, col, id string) (map[string]interface{}, error) {
 	where := secureRead(auth, col)
 
 	qry := fmt.Sprintf(`
@@ -222,7 +296,7 @@ func (pg *PostgreSQL) GetDocumentByID(auth model.Auth, dbName, col, id string) (
 		%s AND id = $3
 	`, dbName, model.CleanCollectionName(col), where)
 
-	row := pg.DB.QueryRow(qry, auth.AccountID, auth.UserID, id)
+	row := db.QueryRow(qry, auth.AccountID, auth.UserID, id)
 
 	var doc Document
 	if err := scanDocument(row, &doc); err != nil {
@@ -233,6 +307,14 @@ func (pg *PostgreSQL) GetDocumentByID(auth model.Auth, dbName, col, id string) (
 }
 
 func (pg *PostgreSQL) GetDocumentsByIDs(auth model.Auth, dbName, col string, ids []string) (docs []map[string]interface{}, err error) {
+	err = pg.read(func(db *sql.DB) error {
+		docs, err = pg.getDocumentsByIDs(db, auth, dbName, col, ids)
+		return err
+	})
+	return
+}
+
+func (pg *PostgreSQL) getDocumentsByIDs(db *sql.DB, auth model.Auth, dbName, col string, ids []string) (docs []map[string]interface{}, err error) {
 	where := secureRead(auth, col)
 
 	qry := fmt.Sprintf(`
@@ -241,7 +323,7 @@ func (pg *PostgreSQL) GetDocumentsByIDs(auth model.Auth, dbName, col string, ids
 		%s AND id in ('%s'::uuid)
 	`, dbName, model.CleanCollectionName(col), where, strings.Join(ids, "'::uuid,'"))
 
-	rows, err := pg.DB.Query(qry, auth.AccountID, auth.UserID)
+	rows, err := db.Query(qry, auth.AccountID, auth.UserID)
 	if err != nil {
 		return []map[string]interface{}{}, err
 	}
@@ -262,6 +344,7 @@ func (pg *PostgreSQL) GetDocumentsByIDs(auth model.Auth, dbName, col string, ids
 func (pg *PostgreSQL) UpdateDocument(auth model.Auth, dbName, col, id string, doc map[string]interface{}) (map[string]interface{}, error) {
 	where := secureWrite(auth, col)
 	removeNotEditableFields(doc)
+	doc[FieldUpdated] = time.Now()
 
 	qry := fmt.Sprintf(`
 		UPDATE %s.%s SET
@@ -278,7 +361,7 @@ func (pg *PostgreSQL) UpdateDocument(auth model.Auth, dbName, col, id string, do
 		return nil, err
 	}
 
-	updated, err := pg.GetDocumentByID(auth, dbName, col, id)
+	updated, err := pg.getDocumentByID(pg.DB, auth, dbName, col, id)
 	if err != nil {
 		return nil, err
 	}
@@ -293,6 +376,7 @@ func (pg *PostgreSQL) UpdateDocuments(auth model.Auth, dbName, col string, filte
 	where, filterArgs := applyFilter(where, filters, 3)
 	queryArgs := append([]any{auth.AccountID, auth.UserID}, filterArgs...)
 	removeNotEditableFields(updateFields)
+	updateFields[FieldUpdated] = time.Now()
 
 	var ids []string
 	qry := fmt.Sprintf(`
@@ -338,7 +422,7 @@ func (pg *PostgreSQL) UpdateDocuments(auth model.Auth, dbName, col string, filte
 	}
 
 	go func() {
-		docs, err := pg.GetDocumentsByIDs(auth, dbName, col, ids)
+		docs, err := pg.getDocumentsByIDs(pg.DB, auth, dbName, col, ids)
 		if err != nil {
 			slog.Error("the documents are not received for publishDocument event", "ids", ids, "error", err)
 		}
@@ -354,15 +438,23 @@ func (pg *PostgreSQL) IncrementValue(auth model.Auth, dbName, col, id, field str
 
 	qry := fmt.Sprintf(`
 		UPDATE %s.%s SET
-		data = jsonb_set(data, '{%s}', (COALESCE(data->>'%s','0')::int + $4)::text::jsonb)
+		data = jsonb_set(
+			jsonb_set(data, '{%s}', (COALESCE(data->>'%s','0')::int + $4)::text::jsonb),
+			'{%s}', $5::jsonb
+		)
 		%s AND id = $3
-	`, dbName, model.CleanCollectionName(col), field, field, where)
+	`, dbName, model.CleanCollectionName(col), field, field, FieldUpdated, where)
+
+	now, err := json.Marshal(time.Now())
+	if err != nil {
+		return err
+	}
 
-	if _, err := pg.DB.Exec(qry, auth.AccountID, auth.UserID, id, n); err != nil {
+	if _, err := pg.DB.Exec(qry, auth.AccountID, auth.UserID, id, n, string(now)); err != nil {
 		return err
 	}
 
-	updated, err := pg.GetDocumentByID(auth, dbName, col, id)
+	updated, err := pg.getDocumentByID(pg.DB, auth, dbName, col, id)
 	if err != nil {
 		return err
 	}
@@ -484,6 +576,7 @@ func removeNotEditableFields(m map[string]any) {
 	delete(m, FieldAccountID)
 	delete(m, FieldOwnerID)
 	delete(m, FieldCreated)
+	delete(m, FieldUpdated)
 }
 
 func isTableExists(err error) bool {
@@ -0,0 +1,56 @@
+package postgresql
+
+import (
+	"fmt"
+
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+)
+
+// CollectionRegistry returns the registry of collections known to exist
+func (pg *PostgreSQL) CollectionRegistry() *database.CollectionRegistry {
+	return pg.collections
+}
+
+// withCollection runs fn once the collection table exists. If fn fails because
+// the table was dropped since it was registered, it's created again and fn is
+// retried once.
+func (pg *PostgreSQL) withCollection(dbName, col string, fn func() error) error {
+	if err := pg.ensureCollection(dbName, col); err != nil {
+		return err
+	}
+
+	err := fn()
+	if err == nil || isTableExists(err) {
+		return err
+	}
+
+	pg.collections.Forget(dbName, col)
+	if err := pg.ensureCollection(dbName, col); err != nil {
+		return err
+	}
+	return fn()
+}
+
+func (pg *PostgreSQL) ensureCollection(dbName, col string) error {
+	return pg.collections.Ensure(dbName, col, func() error {
+		cleancol := model.CleanCollectionName(col)
+
+		qry := fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s.%s (
+				id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+				account_id uuid REFERENCES %s.sb_accounts(id) ON DELETE CASCADE,
+				owner_id uuid REFERENCES %s.sb_tokens(id) ON DELETE CASCADE,
+				data jsonb NOT NULL,
+				created timestamp NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS %s_acctid_idx ON %s.%s (account_id);
+		`, dbName, cleancol, dbName, dbName, cleancol, dbName, cleancol)
+
+		if _, err := pg.DB.Exec(qry); err != nil {
+			return fmt.Errorf("error creating table: %w", err)
+		}
+		return nil
+	})
+}
@@ -1,12 +1,21 @@
 package postgresql
 
 import (
+	"database/sql"
 	"fmt"
 
 	"github.com/staticbackendhq/core/model"
 )
 
 func (pg *PostgreSQL) Count(auth model.Auth, dbName, col string, filters map[string]interface{}) (count int64, err error) {
+	err = pg.read(func(db *sql.DB) error {
+		count, err = pg.count(db, auth, dbName, col, filters)
+		return err
+	})
+	return
+}
+
+func (pg *PostgreSQL) count(db *sql.DB, auth model.Auth, dbName, col string, filters map[string]interface{}) (count int64, err error) {
 	where := secureRead(auth, col)
 	where, filterArgs := applyFilter(where, filters, 3)
 
@@ -17,7 +26,7 @@ func (pg *PostgreSQL) Count(auth model.Auth, dbName, col string, filters map[str
     `, dbName, model.CleanCollectionName(col), where)
 
 	args := append([]any{auth.AccountID, auth.UserID}, filterArgs...)
-	err = pg.DB.QueryRow(query, args...).Scan(&count)
+	err = db.QueryRow(query, args...).Scan(&count)
 	if err != nil {
 		return -1, err
 	}
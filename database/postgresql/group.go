@@ -0,0 +1,156 @@
+package postgresql
+
+import (
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) CreateGroup(dbName string, g model.Group) (id string, err error) {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_groups(account_id, name, created)
+		VALUES($1, $2, $3)
+		RETURNING id;
+	`, dbName)
+
+	err = pg.DB.QueryRow(qry, g.AccountID, g.Name, g.Created).Scan(&id)
+	return
+}
+
+func (pg *PostgreSQL) GetGroup(dbName, accountID, groupID string) (g model.Group, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, account_id, name, created
+		FROM %s.sb_groups
+		WHERE id = $1 AND account_id = $2;
+	`, dbName)
+
+	err = scanGroup(pg.DB.QueryRow(qry, groupID, accountID), &g)
+	return
+}
+
+func (pg *PostgreSQL) ListGroups(dbName, accountID string) (results []model.Group, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, account_id, name, created
+		FROM %s.sb_groups
+		WHERE account_id = $1
+		ORDER BY name;
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry, accountID)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var g model.Group
+		if err = scanGroup(rows, &g); err != nil {
+			return
+		}
+		results = append(results, g)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) DeleteGroup(dbName, accountID, groupID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s.sb_groups
+		WHERE id = $1 AND account_id = $2;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, groupID, accountID)
+	return err
+}
+
+func (pg *PostgreSQL) AddGroupMember(dbName string, member model.GroupMember) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_group_members(group_id, account_id, user_id, added)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (group_id, user_id) DO NOTHING;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, member.GroupID, member.AccountID, member.UserID, member.Added)
+	return err
+}
+
+func (pg *PostgreSQL) RemoveGroupMember(dbName, groupID, userID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s.sb_group_members
+		WHERE group_id = $1 AND user_id = $2;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, groupID, userID)
+	return err
+}
+
+func (pg *PostgreSQL) ListGroupMembers(dbName, groupID string) (results []model.GroupMember, err error) {
+	qry := fmt.Sprintf(`
+		SELECT group_id, account_id, user_id, added
+		FROM %s.sb_group_members
+		WHERE group_id = $1
+		ORDER BY added;
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry, groupID)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var member model.GroupMember
+		if err = scanGroupMember(rows, &member); err != nil {
+			return
+		}
+		results = append(results, member)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) ListUserGroups(dbName, accountID, userID string) (ids []string, err error) {
+	qry := fmt.Sprintf(`
+		SELECT group_id
+		FROM %s.sb_group_members
+		WHERE account_id = $1 AND user_id = $2
+		ORDER BY group_id;
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry, accountID, userID)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func scanGroup(rows Scanner, g *model.Group) error {
+	return rows.Scan(
+		&g.ID,
+		&g.AccountID,
+		&g.Name,
+		&g.Created,
+	)
+}
+
+func scanGroupMember(rows Scanner, member *model.GroupMember) error {
+	return rows.Scan(
+		&member.GroupID,
+		&member.AccountID,
+		&member.UserID,
+		&member.Added,
+	)
+}
@@ -0,0 +1,80 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) SaveGuest(dbName string, g model.Guest) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_guests(user_id, account_id, created, last_seen)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			last_seen = EXCLUDED.last_seen;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, g.UserID, g.AccountID, g.Created, g.LastSeen)
+	return err
+}
+
+func (pg *PostgreSQL) GetGuest(dbName, userID string) (g model.Guest, err error) {
+	qry := fmt.Sprintf(`
+		SELECT user_id, account_id, created, last_seen
+		FROM %s.sb_guests
+		WHERE user_id = $1;
+	`, dbName)
+
+	err = scanGuest(pg.DB.QueryRow(qry, userID), &g)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Guest{}, nil
+	}
+	return
+}
+
+func (pg *PostgreSQL) ListGuestsSeenBefore(dbName string, before time.Time) (results []model.Guest, err error) {
+	qry := fmt.Sprintf(`
+		SELECT user_id, account_id, created, last_seen
+		FROM %s.sb_guests
+		WHERE last_seen < $1;
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry, before)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var g model.Guest
+		if err = scanGuest(rows, &g); err != nil {
+			return
+		}
+		results = append(results, g)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) DeleteGuest(dbName, userID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s.sb_guests
+		WHERE user_id = $1;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, userID)
+	return err
+}
+
+func scanGuest(rows Scanner, g *model.Guest) error {
+	return rows.Scan(
+		&g.UserID,
+		&g.AccountID,
+		&g.Created,
+		&g.LastSeen,
+	)
+}
@@ -0,0 +1,98 @@
+package postgresql
+
+import (
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) CreateInvitation(dbName string, inv model.Invitation) (id string, err error) {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_invitations(account_id, email, role, invited_by, created, sent, expires)
+		VALUES($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id;
+	`, dbName)
+
+	err = pg.DB.QueryRow(qry,
+		inv.AccountID,
+		inv.Email,
+		inv.Role,
+		inv.InvitedBy,
+		inv.Created,
+		inv.Sent,
+		inv.Expires,
+	).Scan(&id)
+	return
+}
+
+func (pg *PostgreSQL) GetInvitation(dbName, id string) (inv model.Invitation, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, account_id, email, role, invited_by, created, sent, expires
+		FROM %s.sb_invitations
+		WHERE id = $1;
+	`, dbName)
+
+	err = scanInvitation(pg.DB.QueryRow(qry, id), &inv)
+	return
+}
+
+func (pg *PostgreSQL) ListInvitations(dbName, accountID string) (results []model.Invitation, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, account_id, email, role, invited_by, created, sent, expires
+		FROM %s.sb_invitations
+		WHERE account_id = $1
+		ORDER BY created DESC;
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry, accountID)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var inv model.Invitation
+		if err = scanInvitation(rows, &inv); err != nil {
+			return
+		}
+		results = append(results, inv)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) UpdateInvitation(dbName string, inv model.Invitation) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s.sb_invitations SET
+			sent = $2,
+			expires = $3
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, inv.ID, inv.Sent, inv.Expires)
+	return err
+}
+
+func (pg *PostgreSQL) DeleteInvitation(dbName, id string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s.sb_invitations
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, id)
+	return err
+}
+
+func scanInvitation(rows Scanner, inv *model.Invitation) error {
+	return rows.Scan(
+		&inv.ID,
+		&inv.AccountID,
+		&inv.Email,
+		&inv.Role,
+		&inv.InvitedBy,
+		&inv.Created,
+		&inv.Sent,
+		&inv.Expires,
+	)
+}
@@ -0,0 +1,64 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) GetUserMFA(dbName, userID string) (mfa model.UserMFA, err error) {
+	qry := fmt.Sprintf(`
+		SELECT user_id, secret, enabled, recovery_codes, last_totp_step, created
+		FROM %s.sb_user_mfa
+		WHERE user_id = $1;
+	`, dbName)
+
+	err = pg.DB.QueryRow(qry, userID).Scan(
+		&mfa.UserID,
+		&mfa.Secret,
+		&mfa.Enabled,
+		pq.Array(&mfa.RecoveryCodes),
+		&mfa.LastTOTPStep,
+		&mfa.Created,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.UserMFA{}, nil
+	}
+	return
+}
+
+func (pg *PostgreSQL) SaveUserMFA(dbName string, mfa model.UserMFA) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_user_mfa(user_id, secret, enabled, recovery_codes, last_totp_step, created)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			enabled = EXCLUDED.enabled,
+			recovery_codes = EXCLUDED.recovery_codes,
+			last_totp_step = EXCLUDED.last_totp_step,
+			created = EXCLUDED.created;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry,
+		mfa.UserID,
+		mfa.Secret,
+		mfa.Enabled,
+		pq.Array(mfa.RecoveryCodes),
+		mfa.LastTOTPStep,
+		mfa.Created,
+	)
+	return err
+}
+
+func (pg *PostgreSQL) DeleteUserMFA(dbName, userID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s.sb_user_mfa
+		WHERE user_id = $1;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, userID)
+	return err
+}
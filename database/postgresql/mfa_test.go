@@ -0,0 +1,58 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestUserMFA(t *testing.T) {
+	mfa, err := datastore.GetUserMFA(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(mfa.UserID) > 0 {
+		t.Fatalf("expected no enrollment got %v", mfa)
+	}
+
+	mfa = model.UserMFA{
+		UserID:        adminToken.ID,
+		Secret:        "encrypted-secret",
+		RecoveryCodes: []string{"hash-1", "hash-2"},
+		Created:       time.Now(),
+	}
+	if err := datastore.SaveUserMFA(confDBName, mfa); err != nil {
+		t.Fatal(err)
+	}
+
+	mfa.Enabled = true
+	mfa.RecoveryCodes = mfa.RecoveryCodes[1:]
+	mfa.LastTOTPStep = 55555555
+	if err := datastore.SaveUserMFA(confDBName, mfa); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetUserMFA(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if !check.Enabled {
+		t.Error("expected enrollment to be enabled")
+	} else if check.Secret != "encrypted-secret" {
+		t.Errorf("expected secret encrypted-secret got %s", check.Secret)
+	} else if len(check.RecoveryCodes) != 1 || check.RecoveryCodes[0] != "hash-2" {
+		t.Errorf("expected recovery codes [hash-2] got %v", check.RecoveryCodes)
+	} else if check.LastTOTPStep != 55555555 {
+		t.Errorf("expected last TOTP step 55555555 got %d", check.LastTOTPStep)
+	}
+
+	if err := datastore.DeleteUserMFA(confDBName, adminToken.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err = datastore.GetUserMFA(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(check.UserID) > 0 {
+		t.Error("expected enrollment to be deleted")
+	}
+}
@@ -0,0 +1,131 @@
+package postgresql
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) CreateOAuthClient(dbName string, c model.OAuthClient) (id string, err error) {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_oauth_clients(name, secret_hash, redirect_uris, public, trusted, created)
+		VALUES($1, $2, $3, $4, $5, $6)
+		RETURNING id;
+	`, dbName)
+
+	err = pg.DB.QueryRow(qry,
+		c.Name,
+		c.SecretHash,
+		pq.Array(c.RedirectURIs),
+		c.Public,
+		c.Trusted,
+		c.Created,
+	).Scan(&id)
+	return
+}
+
+func (pg *PostgreSQL) GetOAuthClient(dbName, id string) (c model.OAuthClient, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, name, secret_hash, redirect_uris, public, trusted, created
+		FROM %s.sb_oauth_clients
+		WHERE id = $1;
+	`, dbName)
+
+	err = scanOAuthClient(pg.DB.QueryRow(qry, id), &c)
+	return
+}
+
+func (pg *PostgreSQL) ListOAuthClients(dbName string) (results []model.OAuthClient, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, name, secret_hash, redirect_uris, public, trusted, created
+		FROM %s.sb_oauth_clients
+		ORDER BY created ASC;
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var c model.OAuthClient
+		if err = scanOAuthClient(rows, &c); err != nil {
+			return
+		}
+		results = append(results, c)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) DeleteOAuthClient(dbName, id string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s.sb_oauth_clients
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, id)
+	return err
+}
+
+func (pg *PostgreSQL) ListOAuthConsents(dbName, userID string) (results []model.OAuthConsent, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, user_id, client_id, scopes, created
+		FROM %s.sb_oauth_consents
+		WHERE user_id = $1
+		ORDER BY created ASC;
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry, userID)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var c model.OAuthConsent
+		if err = rows.Scan(&c.ID, &c.UserID, &c.ClientID, pq.Array(&c.Scopes), &c.Created); err != nil {
+			return
+		}
+		results = append(results, c)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) SaveOAuthConsent(dbName string, c model.OAuthConsent) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_oauth_consents(user_id, client_id, scopes, created)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (user_id, client_id) DO UPDATE SET scopes = EXCLUDED.scopes, created = EXCLUDED.created;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, c.UserID, c.ClientID, pq.Array(c.Scopes), c.Created)
+	return err
+}
+
+func (pg *PostgreSQL) DeleteOAuthConsent(dbName, userID, clientID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s.sb_oauth_consents
+		WHERE user_id = $1 AND client_id = $2;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, userID, clientID)
+	return err
+}
+
+func scanOAuthClient(rows Scanner, c *model.OAuthClient) error {
+	return rows.Scan(
+		&c.ID,
+		&c.Name,
+		&c.SecretHash,
+		pq.Array(&c.RedirectURIs),
+		&c.Public,
+		&c.Trusted,
+		&c.Created,
+	)
+}
@@ -0,0 +1,90 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestOAuthClientsAndConsents(t *testing.T) {
+	c := model.OAuthClient{
+		Name:         "billing app",
+		SecretHash:   "hash",
+		RedirectURIs: []string{"https://billing.test/callback"},
+		Trusted:      true,
+		Created:      time.Now(),
+	}
+
+	clientID, err := datastore.CreateOAuthClient(confDBName, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetOAuthClient(confDBName, clientID)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.SecretHash != "hash" || !check.Trusted || check.Public {
+		t.Errorf("unexpected client %v", check)
+	} else if !check.AllowsRedirect("https://billing.test/callback") {
+		t.Errorf("expected the redirect URI to be registered got %v", check.RedirectURIs)
+	}
+
+	clients, err := datastore.ListOAuthClients(confDBName)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(clients) == 0 {
+		t.Fatal("expected the client to be listed")
+	}
+
+	consent := model.OAuthConsent{
+		UserID:   adminToken.ID,
+		ClientID: clientID,
+		Scopes:   []string{model.OIDCScopeOpenID},
+		Created:  time.Now(),
+	}
+	if err := datastore.SaveOAuthConsent(confDBName, consent); err != nil {
+		t.Fatal(err)
+	}
+
+	// saving again replaces the granted scopes
+	consent.Scopes = []string{model.OIDCScopeOpenID, model.OIDCScopeEmail}
+	if err := datastore.SaveOAuthConsent(confDBName, consent); err != nil {
+		t.Fatal(err)
+	}
+
+	consents, err := datastore.ListOAuthConsents(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found []model.OAuthConsent
+	for _, c := range consents {
+		if c.ClientID == clientID {
+			found = append(found, c)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("expected one consent for the client got %v", found)
+	} else if !found[0].Covers([]string{model.OIDCScopeEmail}) {
+		t.Errorf("expected the consent to cover the email scope got %v", found[0].Scopes)
+	}
+
+	if err := datastore.DeleteOAuthClient(confDBName, clientID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := datastore.GetOAuthClient(confDBName, clientID); err == nil {
+		t.Error("expected the client to be deleted")
+	}
+
+	consents, err = datastore.ListOAuthConsents(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range consents {
+		if c.ClientID == clientID {
+			t.Errorf("expected the client's consents to be deleted got %v", c)
+		}
+	}
+}
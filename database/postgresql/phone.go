@@ -0,0 +1,60 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) GetUserPhone(dbName, userID string) (phone model.UserPhone, err error) {
+	qry := fmt.Sprintf(`
+		SELECT user_id, number, verified
+		FROM %s.sb_user_phones
+		WHERE user_id = $1;
+	`, dbName)
+
+	err = pg.DB.QueryRow(qry, userID).Scan(&phone.UserID, &phone.Number, &phone.Verified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.UserPhone{}, nil
+	}
+	return
+}
+
+func (pg *PostgreSQL) FindUserByPhone(dbName, number string) (tok model.User, err error) {
+	qry := fmt.Sprintf(`
+		SELECT t.*
+		FROM %s.sb_tokens t
+		JOIN %s.sb_user_phones p ON p.user_id = t.id
+		WHERE p.number = $1;
+	`, dbName, dbName)
+
+	row := pg.DB.QueryRow(qry, number)
+
+	err = scanToken(row, &tok)
+	return
+}
+
+func (pg *PostgreSQL) SaveUserPhone(dbName string, phone model.UserPhone) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_user_phones(user_id, number, verified)
+		VALUES($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			number = EXCLUDED.number,
+			verified = EXCLUDED.verified;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, phone.UserID, phone.Number, phone.Verified)
+	return err
+}
+
+func (pg *PostgreSQL) DeleteUserPhone(dbName, userID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s.sb_user_phones
+		WHERE user_id = $1;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, userID)
+	return err
+}
@@ -3,6 +3,7 @@ package postgresql
 import (
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
@@ -17,12 +18,22 @@ import (
 type PostgreSQL struct {
 	DB              *sql.DB
 	PublishDocument cache.PublishDocumentEvent
+
+	replicas    *replicaSet
+	collections *database.CollectionRegistry
 }
 
 //go:embed sql
 var migrationFS embed.FS
 
 func New(db *sql.DB, pubdoc cache.PublishDocumentEvent) database.Persister {
+	return NewWithReplicas(db, nil, pubdoc)
+}
+
+// NewWithReplicas returns a PostgreSQL data store routing list, query, count
+// and get requests to the read replicas. Writes and reads following a write
+// always use the primary.
+func NewWithReplicas(db *sql.DB, replicas []*sql.DB, pubdoc cache.PublishDocumentEvent) database.Persister {
 	// run migrations
 	if err := migrate(db); err != nil {
 		fmt.Println("=== MIGRATION FAILED ===")
@@ -31,16 +42,21 @@ func New(db *sql.DB, pubdoc cache.PublishDocumentEvent) database.Persister {
 		os.Exit(1)
 	}
 
-	return &PostgreSQL{DB: db, PublishDocument: pubdoc}
+	return &PostgreSQL{
+		DB:              db,
+		PublishDocument: pubdoc,
+		replicas:        newReplicaSet(replicas),
+		collections:     database.NewCollectionRegistry(),
+	}
 }
 
 func (pg *PostgreSQL) Ping() error {
 	return pg.DB.Ping()
 }
 
-// Close closes the underlying database connection pool.
+// Close closes the underlying database connection pools.
 func (pg *PostgreSQL) Close() error {
-	return pg.DB.Close()
+	return errors.Join(pg.replicas.close(), pg.DB.Close())
 }
 
 func (pg *PostgreSQL) CreateIndex(dbName, col, field string) error {
@@ -10,6 +10,7 @@ import (
 
 	_ "github.com/lib/pq"
 	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/database"
 	"github.com/staticbackendhq/core/model"
 )
 
@@ -49,7 +50,7 @@ func TestMain(m *testing.M) {
 	}
 	defer func() { _ = dbConn.Close() }()
 
-	datastore = &PostgreSQL{DB: dbConn, PublishDocument: fakePubDocEvent}
+	datastore = &PostgreSQL{DB: dbConn, PublishDocument: fakePubDocEvent, collections: database.NewCollectionRegistry()}
 
 	if err := datastore.Ping(); err != nil {
 		log.Fatal(err)
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/staticbackendhq/core/internal"
 	sbquery "github.com/staticbackendhq/core/internal/query"
 	"github.com/staticbackendhq/core/model"
@@ -18,6 +20,17 @@ func (pg *PostgreSQL) ParseQuery(clauses [][]interface{}) (map[string]interface{
 }
 
 func applyFilter(where string, filters map[string]interface{}, startAt int) (string, []any) {
+	ids, filters, hasIDs := sbquery.SplitIDs(filters)
+
+	where, args := applyClauses(where, filters, startAt)
+	if hasIDs {
+		where += fmt.Sprintf(" AND id = ANY($%d::uuid[])", startAt+len(args))
+		args = append(args, pq.Array(validUUIDs(ids)))
+	}
+	return where, args
+}
+
+func applyClauses(where string, filters map[string]interface{}, startAt int) (string, []any) {
 	q, ok := sbquery.FromFilter(filters)
 	if !ok {
 		return applyLegacyFilter(where, filters), nil
@@ -34,6 +47,17 @@ func applyFilter(where string, filters map[string]interface{}, startAt int) (str
 	return where, args
 }
 
+// validUUIDs drops the ids that would make the uuid[] cast fail
+func validUUIDs(ids []string) []string {
+	valid := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err == nil {
+			valid = append(valid, id)
+		}
+	}
+	return valid
+}
+
 func buildClause(clause sbquery.Clause, startAt int) (string, []any) {
 	left := fieldExpr(clause.Field, clause.Value.Type)
 
@@ -116,25 +140,32 @@ func escapeLikePattern(s string) string {
 }
 
 func secureRead(auth model.Auth, col string) string {
-	switch internal.ReadScope(auth, col) {
-	case internal.RowScopeAccount:
-		return "WHERE account_id = $1 AND $2=$2 "
-	case internal.RowScopeOwner:
-		return "WHERE account_id = $1 AND owner_id = $2 "
-	default:
-		//for read permission to everyone i.e. col-name_774_
-		return "WHERE $1=$1 AND $2=$2 "
-	}
+	return secureWhere(auth, internal.ReadScope(auth, col))
 }
 
 func secureWrite(auth model.Auth, col string) string {
-	switch internal.WriteScope(auth, col, true) {
+	return secureWhere(auth, internal.WriteScope(auth, col, true))
+}
+
+func secureWhere(auth model.Auth, scope internal.RowPermissionScope) string {
+	group := fmt.Sprintf("data->>'%s'", model.FieldGroupID)
+
+	switch scope {
 	case internal.RowScopeAccount:
-		return "WHERE account_id = $1 AND $2=$2 "
+		if internal.SeesAllGroups(auth) {
+			return "WHERE account_id = $1 AND $2=$2 "
+		} else if len(auth.Groups) == 0 {
+			return fmt.Sprintf("WHERE account_id = $1 AND $2=$2 AND COALESCE(%s, '') = '' ", group)
+		}
+		return fmt.Sprintf("WHERE account_id = $1 AND $2=$2 AND (COALESCE(%s, '') = '' OR %s IN (%s)) ", group, group, groupList(auth.Groups))
 	case internal.RowScopeOwner:
-		return "WHERE account_id = $1 AND owner_id = $2 "
+		if len(auth.Groups) == 0 {
+			return "WHERE account_id = $1 AND owner_id = $2 "
+		}
+		return fmt.Sprintf("WHERE account_id = $1 AND (owner_id = $2 OR %s IN (%s)) ", group, groupList(auth.Groups))
 	default:
-		//for write permission to everyone i.e. col-name_776_
+		//for read permission to everyone i.e. col-name_774_ or write
+		// permission to everyone i.e. col-name_776_.
 		// This should probably get more warning in the doc.
 		// All logged-in users can update/delete data.
 		// There's use cases for that, and it's certainly opt-in
@@ -143,6 +174,16 @@ func secureWrite(auth model.Auth, col string) string {
 	}
 }
 
+// groupList returns the quoted group ids of a user for an IN clause, they
+// come from the data store and not from the request
+func groupList(groups []string) string {
+	quoted := make([]string, 0, len(groups))
+	for _, id := range groups {
+		quoted = append(quoted, "'"+strings.ReplaceAll(id, "'", "''")+"'")
+	}
+	return strings.Join(quoted, ", ")
+}
+
 func setPaging(params model.ListParams) string {
 	if len(params.SortBy) == 0 {
 		params.SortBy = "created"
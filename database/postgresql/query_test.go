@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/lib/pq"
 	sbquery "github.com/staticbackendhq/core/internal/query"
 )
 
@@ -88,3 +89,24 @@ func TestApplyFilterFieldReferenceWithBooleanType(t *testing.T) {
 		t.Fatalf("expected boolean field comparison, got %s", where)
 	}
 }
+
+func TestApplyFilterWithIDs(t *testing.T) {
+	q, err := sbquery.Parse([][]interface{}{{"status", "=", "unpaid"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := "c7a8f3a2-5b4e-4d7f-9d1a-0c2b3e4f5a6b"
+	filters := sbquery.WithIDs(map[string]interface{}{sbquery.FilterKey: q}, []string{id, "not-a-uuid"})
+
+	where, args := applyFilter("WHERE $1=$1 AND $2=$2 ", filters, 3)
+	if len(args) != 2 {
+		t.Fatalf("expected the status and ids args got %v", args)
+	}
+	if !strings.Contains(where, "id = ANY($4::uuid[])") {
+		t.Fatalf("expected ids restriction, got %s", where)
+	}
+	if ids, ok := args[1].(*pq.StringArray); !ok || len(*ids) != 1 || (*ids)[0] != id {
+		t.Fatalf("expected only the valid uuid got %v", args[1])
+	}
+}
@@ -0,0 +1,183 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/staticbackendhq/core/database"
+)
+
+const replicaHealthCheckInterval = 10 * time.Second
+
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// replicaSet routes read-only queries to healthy read replicas in a
+// round-robin fashion. Unhealthy replicas are skipped until a health check
+// succeeds again.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	cancel   context.CancelFunc
+}
+
+func newReplicaSet(dbs []*sql.DB) *replicaSet {
+	if len(dbs) == 0 {
+		return nil
+	}
+
+	rs := &replicaSet{}
+	for i, db := range dbs {
+		r := &replica{name: fmt.Sprintf("replica-%d", i+1), db: db}
+		r.healthy.Store(true)
+		rs.replicas = append(rs.replicas, r)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rs.cancel = cancel
+	go rs.monitor(ctx, replicaHealthCheckInterval)
+
+	return rs
+}
+
+// pick returns the next healthy replica or nil if there's none
+func (rs *replicaSet) pick() *replica {
+	if rs == nil {
+		return nil
+	}
+
+	n := uint64(len(rs.replicas))
+	start := rs.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		r := rs.replicas[(start+i)%n]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+func (rs *replicaSet) monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rs.checkHealth(ctx)
+		}
+	}
+}
+
+func (rs *replicaSet) checkHealth(ctx context.Context) {
+	for _, r := range rs.replicas {
+		pctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		err := r.db.PingContext(pctx)
+		cancel()
+
+		if err != nil {
+			r.markUnhealthy(err)
+		} else if !r.healthy.Swap(true) {
+			slog.Info("postgres replica is back online", "replica", r.name)
+		}
+	}
+}
+
+func (r *replica) markUnhealthy(err error) {
+	if r.healthy.Swap(false) {
+		slog.Warn("postgres replica marked unhealthy", "replica", r.name, "error", err)
+	}
+}
+
+func (rs *replicaSet) close() error {
+	if rs == nil {
+		return nil
+	}
+
+	rs.cancel()
+
+	var errs []error
+	for _, r := range rs.replicas {
+		errs = append(errs, r.db.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// read runs a read-only query on a healthy replica. The primary is used when
+// no replica is configured or healthy, and when the replica connection fails.
+func (pg *PostgreSQL) read(fn func(db *sql.DB) error) error {
+	r := pg.replicas.pick()
+	if r == nil {
+		return fn(pg.DB)
+	}
+
+	err := fn(r.db)
+	if err == nil || !isConnectionError(err) {
+		return err
+	}
+
+	r.markUnhealthy(err)
+	return fn(pg.DB)
+}
+
+// PoolStats returns the connection pool statistics of the primary and all
+// read replicas.
+func (pg *PostgreSQL) PoolStats() []database.PoolStats {
+	stats := []database.PoolStats{{
+		Name:    "primary",
+		Primary: true,
+		Healthy: true,
+		Stats:   pg.DB.Stats(),
+	}}
+
+	if pg.replicas == nil {
+		return stats
+	}
+
+	for _, r := range pg.replicas.replicas {
+		stats = append(stats, database.PoolStats{
+			Name:    r.name,
+			Healthy: r.healthy.Load(),
+			Stats:   r.db.Stats(),
+		})
+	}
+	return stats
+}
+
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// 08: connection exception, 57: operator intervention (shutdown,
+		// starting up, etc)
+		class := pqErr.Code.Class()
+		return class == "08" || class == "57"
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/staticbackendhq/core/model"
+)
+
+func newReplicaDatastore(t *testing.T, replicas ...*sql.DB) *PostgreSQL {
+	pg := &PostgreSQL{
+		DB:              datastore.DB,
+		PublishDocument: fakePubDocEvent,
+		replicas:        newReplicaSet(replicas),
+	}
+	t.Cleanup(pg.replicas.cancel)
+	return pg
+}
+
+// unreachableReplica returns a replica refusing its connections
+func unreachableReplica(t *testing.T) *sql.DB {
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 connect_timeout=1 sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestReplicaReadsFailOverToPrimary(t *testing.T) {
+	pg := newReplicaDatastore(t, unreachableReplica(t))
+
+	if _, err := pg.Count(adminAuth, confDBName, colName, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := pg.replicas.pick(); r != nil {
+		t.Fatalf("expected no healthy replica, got %s", r.name)
+	}
+
+	stats := pg.PoolStats()
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 pools got %d", len(stats))
+	} else if !stats[0].Primary || stats[1].Primary {
+		t.Errorf("expected primary pool first got %v", stats)
+	} else if stats[1].Healthy {
+		t.Error("expected replica to be unhealthy")
+	}
+}
+
+func TestReplicaReadsUseHealthyReplica(t *testing.T) {
+	pg := newReplicaDatastore(t, datastore.DB)
+
+	res, err := pg.ListDocuments(adminAuth, confDBName, colName, model.ListParams{Page: 1, Size: 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := pg.replicas.pick(); r == nil {
+		t.Fatal("expected replica to stay healthy")
+	}
+
+	primary, err := datastore.ListDocuments(adminAuth, confDBName, colName, model.ListParams{Page: 1, Size: 25})
+	if err != nil {
+		t.Fatal(err)
+	} else if primary.Total != res.Total {
+		t.Errorf("expected %d documents got %d", primary.Total, res.Total)
+	}
+}
+
+func TestReplicaHealthCheck(t *testing.T) {
+	pg := newReplicaDatastore(t, datastore.DB, unreachableReplica(t))
+
+	pg.replicas.checkHealth(context.Background())
+
+	for i := 0; i < 4; i++ {
+		if r := pg.replicas.pick(); r == nil || r.name != "replica-1" {
+			t.Fatalf("expected replica-1 to be picked got %v", r)
+		}
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{driver.ErrBadConn, true},
+		{sql.ErrConnDone, true},
+		{&pq.Error{Code: "57P01"}, true},
+		{&pq.Error{Code: "08006"}, true},
+		{&pq.Error{Code: "42P01"}, false},
+		{sql.ErrNoRows, false},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{errors.New("sql: database is closed"), false},
+	}
+
+	for _, tc := range tests {
+		if got := isConnectionError(tc.err); got != tc.want {
+			t.Errorf("isConnectionError(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
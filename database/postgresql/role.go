@@ -0,0 +1,45 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) GetUserRole(dbName, userID, accountID string) (role model.UserRole, err error) {
+	qry := fmt.Sprintf(`
+		SELECT user_id, account_id, name
+		FROM %s.sb_user_roles
+		WHERE user_id = $1 AND account_id = $2;
+	`, dbName)
+
+	err = pg.DB.QueryRow(qry, userID, accountID).Scan(&role.UserID, &role.AccountID, &role.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.UserRole{}, nil
+	}
+	return
+}
+
+func (pg *PostgreSQL) SaveUserRole(dbName string, role model.UserRole) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_user_roles(user_id, account_id, name)
+		VALUES($1, $2, $3)
+		ON CONFLICT(user_id, account_id) DO UPDATE SET
+			name = excluded.name;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, role.UserID, role.AccountID, role.Name)
+	return err
+}
+
+func (pg *PostgreSQL) DeleteUserRole(dbName, userID, accountID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s.sb_user_roles
+		WHERE user_id = $1 AND account_id = $2;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, userID, accountID)
+	return err
+}
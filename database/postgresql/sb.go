@@ -140,6 +140,153 @@ func (pg *PostgreSQL) createSystemTables(schema string) error {
 			created    TIMESTAMP NOT NULL,
 			UNIQUE(user_id, account_id)
 		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_settings (
+			key     TEXT PRIMARY KEY,
+			value   JSONB NOT NULL,
+			updated TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_sequences (
+			name    TEXT PRIMARY KEY,
+			value   BIGINT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_user_mfa (
+			user_id         uuid PRIMARY KEY REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			secret          TEXT NOT NULL,
+			enabled         BOOLEAN NOT NULL,
+			recovery_codes  TEXT[] NOT NULL,
+			last_totp_step  BIGINT NOT NULL DEFAULT 0,
+			created         TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_email_verifications (
+			user_id         uuid PRIMARY KEY REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			email           TEXT NOT NULL,
+			verified        TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_invitations (
+			id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+			account_id      uuid NOT NULL REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+			email           TEXT NOT NULL,
+			role            INTEGER NOT NULL DEFAULT 0,
+			invited_by      TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL,
+			sent            TIMESTAMP NOT NULL,
+			expires         TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_user_roles (
+			user_id         uuid NOT NULL REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			account_id      uuid NOT NULL REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+			name            TEXT NOT NULL,
+			PRIMARY KEY (user_id, account_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_user_profiles (
+			user_id         uuid PRIMARY KEY REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			public          JSONB NOT NULL,
+			private         JSONB NOT NULL,
+			updated         TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_user_phones (
+			user_id         uuid PRIMARY KEY REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			number          TEXT NOT NULL UNIQUE,
+			verified        TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_guests (
+			user_id         uuid PRIMARY KEY REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			account_id      uuid NOT NULL,
+			created         TIMESTAMP NOT NULL,
+			last_seen       TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS sb_guests_last_seen_idx ON {schema}.sb_guests (last_seen);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_groups (
+			id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+			account_id      uuid NOT NULL REFERENCES {schema}.sb_accounts(id) ON DELETE CASCADE,
+			name            TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS sb_groups_account_id_idx ON {schema}.sb_groups (account_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_group_members (
+			group_id        uuid NOT NULL REFERENCES {schema}.sb_groups(id) ON DELETE CASCADE,
+			account_id      uuid NOT NULL,
+			user_id         uuid NOT NULL REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			added           TIMESTAMP NOT NULL,
+			PRIMARY KEY (group_id, user_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS sb_group_members_user_id_idx ON {schema}.sb_group_members (user_id, account_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_audit_log (
+			id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+			account_id      TEXT NOT NULL,
+			actor_id        TEXT NOT NULL,
+			actor_email     TEXT NOT NULL,
+			api_key_id      TEXT NOT NULL,
+			action          TEXT NOT NULL,
+			target          TEXT NOT NULL,
+			details         TEXT NOT NULL,
+			ip              TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS sb_audit_log_created_idx ON {schema}.sb_audit_log (created);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_sessions (
+			id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id         uuid NOT NULL REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			account_id      uuid NOT NULL,
+			refresh_token   TEXT NOT NULL,
+			user_agent      TEXT NOT NULL,
+			ip              TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL,
+			last_seen       TIMESTAMP NOT NULL,
+			expires         TIMESTAMP NOT NULL,
+			client_id       TEXT NOT NULL DEFAULT '',
+			scope           TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS sb_sessions_user_id_idx ON {schema}.sb_sessions (user_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_api_keys (
+			id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+			account_id      uuid NOT NULL,
+			user_id         uuid NOT NULL REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			name            TEXT NOT NULL,
+			key_hash        TEXT NOT NULL,
+			scopes          JSONB NOT NULL,
+			allowed_ips     TEXT[] NOT NULL,
+			expires         TIMESTAMP NULL,
+			last_used       TIMESTAMP NULL,
+			created         TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_oauth_clients (
+			id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+			name            TEXT NOT NULL,
+			secret_hash     TEXT NOT NULL,
+			redirect_uris   TEXT[] NOT NULL,
+			public          BOOLEAN NOT NULL,
+			trusted         BOOLEAN NOT NULL,
+			created         TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}.sb_oauth_consents (
+			id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+			user_id         uuid NOT NULL REFERENCES {schema}.sb_tokens(id) ON DELETE CASCADE,
+			client_id       uuid NOT NULL REFERENCES {schema}.sb_oauth_clients(id) ON DELETE CASCADE,
+			scopes          TEXT[] NOT NULL,
+			created         TIMESTAMP NOT NULL,
+			UNIQUE (user_id, client_id)
+		);
 `, "{schema}", schema)
 
 	if _, err := pg.DB.Exec(qry); err != nil {
@@ -299,6 +446,7 @@ func (pg *PostgreSQL) DeleteTenant(dbName, email string) error {
 	if err != nil {
 		return err
 	}
+	pg.collections.Invalidate(dbName)
 
 	_, err = pg.DB.Exec(`
 		DELETE FROM sb.customers WHERE email = $1;
@@ -0,0 +1,15 @@
+package postgresql
+
+import "fmt"
+
+func (pg *PostgreSQL) NextSequence(dbName, name string) (n int64, err error) {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_sequences(name, value)
+		VALUES($1, 1)
+		ON CONFLICT (name) DO UPDATE SET value = %s.sb_sequences.value + 1
+		RETURNING value;
+	`, dbName, dbName)
+
+	err = pg.DB.QueryRow(qry, name).Scan(&n)
+	return
+}
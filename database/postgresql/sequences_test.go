@@ -0,0 +1,21 @@
+package postgresql
+
+import "testing"
+
+func TestNextSequence(t *testing.T) {
+	for i := int64(1); i <= 3; i++ {
+		n, err := datastore.NextSequence(confDBName, "unittest.number")
+		if err != nil {
+			t.Fatal(err)
+		} else if n != i {
+			t.Errorf("expected %d got %d", i, n)
+		}
+	}
+
+	n, err := datastore.NextSequence(confDBName, "unittest.other")
+	if err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Errorf("expected other sequence to start at 1 got %d", n)
+	}
+}
@@ -0,0 +1,126 @@
+package postgresql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) CreateSession(dbName string, s model.Session) (id string, err error) {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_sessions(user_id, account_id, refresh_token, user_agent, ip, created, last_seen, expires, client_id, scope)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id;
+	`, dbName)
+
+	err = pg.DB.QueryRow(qry,
+		s.UserID,
+		s.AccountID,
+		s.RefreshToken,
+		s.UserAgent,
+		s.IP,
+		s.Created,
+		s.LastSeen,
+		s.Expires,
+		s.ClientID,
+		s.Scope,
+	).Scan(&id)
+	return
+}
+
+func (pg *PostgreSQL) GetSession(dbName, id string) (s model.Session, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, user_id, account_id, refresh_token, user_agent, ip, created, last_seen, expires, client_id, scope
+		FROM %s.sb_sessions
+		WHERE id = $1;
+	`, dbName)
+
+	err = scanSession(pg.DB.QueryRow(qry, id), &s)
+	return
+}
+
+func (pg *PostgreSQL) ListSessions(dbName, userID string) (results []model.Session, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, user_id, account_id, refresh_token, user_agent, ip, created, last_seen, expires, client_id, scope
+		FROM %s.sb_sessions
+		WHERE user_id = $1
+		ORDER BY last_seen DESC;
+	`, dbName)
+
+	rows, err := pg.DB.Query(qry, userID)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var s model.Session
+		if err = scanSession(rows, &s); err != nil {
+			return
+		}
+		results = append(results, s)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) UpdateSession(dbName string, s model.Session) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s.sb_sessions SET
+			refresh_token = $2,
+			last_seen = $3,
+			expires = $4
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, s.ID, s.RefreshToken, s.LastSeen, s.Expires)
+	return err
+}
+
+func (pg *PostgreSQL) TouchSession(dbName, id string, lastSeen time.Time) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s.sb_sessions SET last_seen = $2
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, id, lastSeen)
+	return err
+}
+
+func (pg *PostgreSQL) DeleteSession(dbName, id string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s.sb_sessions
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, id)
+	return err
+}
+
+func (pg *PostgreSQL) DeleteUserSessions(dbName, userID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s.sb_sessions
+		WHERE user_id = $1;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, userID)
+	return err
+}
+
+func scanSession(rows Scanner, s *model.Session) error {
+	return rows.Scan(
+		&s.ID,
+		&s.UserID,
+		&s.AccountID,
+		&s.RefreshToken,
+		&s.UserAgent,
+		&s.IP,
+		&s.Created,
+		&s.LastSeen,
+		&s.Expires,
+		&s.ClientID,
+		&s.Scope,
+	)
+}
@@ -0,0 +1,51 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (pg *PostgreSQL) GetSetting(dbName, key string) (value []byte, err error) {
+	qry := fmt.Sprintf(`
+		SELECT value
+		FROM %s.sb_settings
+		WHERE key = $1;
+	`, dbName)
+
+	if err = pg.DB.QueryRow(qry, key).Scan(&value); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return
+	}
+	return
+}
+
+func (pg *PostgreSQL) SetSetting(dbName, key string, value []byte) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_settings(key, value, updated)
+		VALUES($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated = EXCLUDED.updated;
+	`, dbName)
+
+	_, err := pg.DB.Exec(qry, key, value, time.Now())
+	return err
+}
+
+func (pg *PostgreSQL) AddSetting(dbName, key string, value []byte) (bool, error) {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s.sb_settings(key, value, updated)
+		VALUES($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING;
+	`, dbName)
+
+	res, err := pg.DB.Exec(qry, key, value, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -0,0 +1,40 @@
+package postgresql
+
+import (
+	"github.com/staticbackendhq/core/model"
+)
+
+func (pg *PostgreSQL) ListSigningKeys() (results []model.SigningKey, err error) {
+	rows, err := pg.DB.Query(`
+		SELECT id, algorithm, private_key, created
+		FROM sb.signing_keys
+		ORDER BY created ASC;
+	`)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var k model.SigningKey
+		if err = rows.Scan(&k.ID, &k.Algorithm, &k.PrivateKey, &k.Created); err != nil {
+			return
+		}
+		results = append(results, k)
+	}
+	err = rows.Err()
+	return
+}
+
+func (pg *PostgreSQL) AddSigningKey(k model.SigningKey) error {
+	_, err := pg.DB.Exec(`
+		INSERT INTO sb.signing_keys(id, algorithm, private_key, created)
+		VALUES($1, $2, $3, $4);
+	`, k.ID, k.Algorithm, k.PrivateKey, k.Created)
+	return err
+}
+
+func (pg *PostgreSQL) DeleteSigningKey(id string) error {
+	_, err := pg.DB.Exec(`DELETE FROM sb.signing_keys WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,12 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_settings (
+                key     TEXT PRIMARY KEY,
+                value   JSONB NOT NULL,
+                updated TIMESTAMP NOT NULL
+            )', r.name);
+    END LOOP;
+END $$;
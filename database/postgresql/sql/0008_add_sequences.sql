@@ -0,0 +1,11 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_sequences (
+                name    TEXT PRIMARY KEY,
+                value   BIGINT NOT NULL
+            )', r.name);
+    END LOOP;
+END $$;
@@ -0,0 +1,14 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_user_mfa (
+                user_id         uuid PRIMARY KEY REFERENCES %I.sb_tokens(id) ON DELETE CASCADE,
+                secret          TEXT NOT NULL,
+                enabled         BOOLEAN NOT NULL,
+                recovery_codes  TEXT[] NOT NULL,
+                created         TIMESTAMP NOT NULL
+            )', r.name, r.name);
+    END LOOP;
+END $$;
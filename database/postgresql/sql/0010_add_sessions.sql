@@ -0,0 +1,19 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_sessions (
+                id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+                user_id         uuid NOT NULL REFERENCES %I.sb_tokens(id) ON DELETE CASCADE,
+                account_id      uuid NOT NULL,
+                refresh_token   TEXT NOT NULL,
+                user_agent      TEXT NOT NULL,
+                ip              TEXT NOT NULL,
+                created         TIMESTAMP NOT NULL,
+                last_seen       TIMESTAMP NOT NULL,
+                expires         TIMESTAMP NOT NULL
+            );
+            CREATE INDEX IF NOT EXISTS sb_sessions_user_id_idx ON %I.sb_sessions (user_id)', r.name, r.name, r.name);
+    END LOOP;
+END $$;
@@ -0,0 +1,19 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_api_keys (
+                id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+                account_id      uuid NOT NULL,
+                user_id         uuid NOT NULL REFERENCES %I.sb_tokens(id) ON DELETE CASCADE,
+                name            TEXT NOT NULL,
+                key_hash        TEXT NOT NULL,
+                scopes          JSONB NOT NULL,
+                allowed_ips     TEXT[] NOT NULL,
+                expires         TIMESTAMP NULL,
+                last_used       TIMESTAMP NULL,
+                created         TIMESTAMP NOT NULL
+            )', r.name, r.name);
+    END LOOP;
+END $$;
@@ -0,0 +1,26 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_oauth_clients (
+                id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+                name            TEXT NOT NULL,
+                secret_hash     TEXT NOT NULL,
+                redirect_uris   TEXT[] NOT NULL,
+                public          BOOLEAN NOT NULL,
+                trusted         BOOLEAN NOT NULL,
+                created         TIMESTAMP NOT NULL
+            )', r.name);
+
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_oauth_consents (
+                id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+                user_id         uuid NOT NULL REFERENCES %I.sb_tokens(id) ON DELETE CASCADE,
+                client_id       uuid NOT NULL REFERENCES %I.sb_oauth_clients(id) ON DELETE CASCADE,
+                scopes          TEXT[] NOT NULL,
+                created         TIMESTAMP NOT NULL,
+                UNIQUE (user_id, client_id)
+            )', r.name, r.name, r.name);
+    END LOOP;
+END $$;
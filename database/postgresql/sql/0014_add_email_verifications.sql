@@ -0,0 +1,12 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_email_verifications (
+                user_id         uuid PRIMARY KEY REFERENCES %I.sb_tokens(id) ON DELETE CASCADE,
+                email           TEXT NOT NULL,
+                verified        TIMESTAMP NOT NULL
+            )', r.name, r.name);
+    END LOOP;
+END $$;
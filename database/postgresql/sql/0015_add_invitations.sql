@@ -0,0 +1,17 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_invitations (
+                id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+                account_id      uuid NOT NULL REFERENCES %I.sb_accounts(id) ON DELETE CASCADE,
+                email           TEXT NOT NULL,
+                role            INTEGER NOT NULL DEFAULT 0,
+                invited_by      TEXT NOT NULL,
+                created         TIMESTAMP NOT NULL,
+                sent            TIMESTAMP NOT NULL,
+                expires         TIMESTAMP NOT NULL
+            )', r.name, r.name);
+    END LOOP;
+END $$;
@@ -0,0 +1,13 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_user_roles (
+                user_id         uuid NOT NULL REFERENCES %I.sb_tokens(id) ON DELETE CASCADE,
+                account_id      uuid NOT NULL REFERENCES %I.sb_accounts(id) ON DELETE CASCADE,
+                name            TEXT NOT NULL,
+                PRIMARY KEY (user_id, account_id)
+            )', r.name, r.name, r.name);
+    END LOOP;
+END $$;
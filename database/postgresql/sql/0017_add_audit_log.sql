@@ -0,0 +1,20 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_audit_log (
+                id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+                account_id      TEXT NOT NULL,
+                actor_id        TEXT NOT NULL,
+                actor_email     TEXT NOT NULL,
+                api_key_id      TEXT NOT NULL,
+                action          TEXT NOT NULL,
+                target          TEXT NOT NULL,
+                details         TEXT NOT NULL,
+                ip              TEXT NOT NULL,
+                created         TIMESTAMP NOT NULL
+            )', r.name);
+        EXECUTE format('CREATE INDEX IF NOT EXISTS sb_audit_log_created_idx ON %I.sb_audit_log (created)', r.name);
+    END LOOP;
+END $$;
@@ -0,0 +1,12 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_user_phones (
+                user_id         uuid PRIMARY KEY REFERENCES %I.sb_tokens(id) ON DELETE CASCADE,
+                number          TEXT NOT NULL UNIQUE,
+                verified        TIMESTAMP NOT NULL
+            )', r.name, r.name);
+    END LOOP;
+END $$;
@@ -0,0 +1,14 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_guests (
+                user_id         uuid PRIMARY KEY REFERENCES %I.sb_tokens(id) ON DELETE CASCADE,
+                account_id      uuid NOT NULL,
+                created         TIMESTAMP NOT NULL,
+                last_seen       TIMESTAMP NOT NULL
+            )', r.name, r.name);
+        EXECUTE format('CREATE INDEX IF NOT EXISTS sb_guests_last_seen_idx ON %I.sb_guests (last_seen)', r.name);
+    END LOOP;
+END $$;
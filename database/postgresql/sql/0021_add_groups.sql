@@ -0,0 +1,23 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_groups (
+                id              uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+                account_id      uuid NOT NULL REFERENCES %I.sb_accounts(id) ON DELETE CASCADE,
+                name            TEXT NOT NULL,
+                created         TIMESTAMP NOT NULL
+            )', r.name, r.name);
+        EXECUTE format('CREATE INDEX IF NOT EXISTS sb_groups_account_id_idx ON %I.sb_groups (account_id)', r.name);
+        EXECUTE format('
+            CREATE TABLE IF NOT EXISTS %I.sb_group_members (
+                group_id        uuid NOT NULL REFERENCES %I.sb_groups(id) ON DELETE CASCADE,
+                account_id      uuid NOT NULL,
+                user_id         uuid NOT NULL REFERENCES %I.sb_tokens(id) ON DELETE CASCADE,
+                added           TIMESTAMP NOT NULL,
+                PRIMARY KEY (group_id, user_id)
+            )', r.name, r.name, r.name);
+        EXECUTE format('CREATE INDEX IF NOT EXISTS sb_group_members_user_id_idx ON %I.sb_group_members (user_id, account_id)', r.name);
+    END LOOP;
+END $$;
@@ -0,0 +1,10 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            ALTER TABLE %I.sb_user_mfa
+            ADD COLUMN IF NOT EXISTS last_totp_step BIGINT NOT NULL DEFAULT 0
+        ', r.name);
+    END LOOP;
+END $$;
@@ -0,0 +1,11 @@
+DO $$
+DECLARE r RECORD;
+BEGIN
+    FOR r IN SELECT lower(name) AS name FROM sb.apps LOOP
+        EXECUTE format('
+            ALTER TABLE %I.sb_sessions
+            ADD COLUMN IF NOT EXISTS client_id TEXT NOT NULL DEFAULT '''',
+            ADD COLUMN IF NOT EXISTS scope TEXT NOT NULL DEFAULT ''''
+        ', r.name);
+    END LOOP;
+END $$;
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS sb.sb_settings (
+	key TEXT PRIMARY KEY,
+	value JSONB NOT NULL,
+	updated timestamp NOT NULL
+);
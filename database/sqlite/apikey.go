@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) CreateAPIKey(dbName string, k model.APIKey) (id string, err error) {
+	scopes, err := json.Marshal(k.Scopes)
+	if err != nil {
+		return
+	}
+
+	if k.AllowedIPs == nil {
+		k.AllowedIPs = []string{}
+	}
+
+	ips, err := json.Marshal(k.AllowedIPs)
+	if err != nil {
+		return
+	}
+
+	id = sl.NewID()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_api_keys(id, account_id, user_id, name, key_hash, scopes, allowed_ips, expires, created)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9);
+	`, dbName)
+
+	_, err = sl.DB.Exec(qry,
+		id,
+		k.AccountID,
+		k.UserID,
+		k.Name,
+		k.KeyHash,
+		string(scopes),
+		string(ips),
+		k.Expires,
+		k.Created,
+	)
+	return
+}
+
+func (sl *SQLite) GetAPIKey(dbName, id string) (k model.APIKey, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, account_id, user_id, name, key_hash, scopes, allowed_ips, expires, last_used, created
+		FROM %s_sb_api_keys
+		WHERE id = $1;
+	`, dbName)
+
+	err = scanAPIKey(sl.DB.QueryRow(qry, id), &k)
+	return
+}
+
+func (sl *SQLite) ListAPIKeys(dbName string) (results []model.APIKey, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, account_id, user_id, name, key_hash, scopes, allowed_ips, expires, last_used, created
+		FROM %s_sb_api_keys
+		ORDER BY created ASC;
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var k model.APIKey
+		if err = scanAPIKey(rows, &k); err != nil {
+			return
+		}
+		results = append(results, k)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) TouchAPIKey(dbName, id string, lastUsed time.Time) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s_sb_api_keys SET last_used = $2
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, id, lastUsed)
+	return err
+}
+
+func (sl *SQLite) DeleteAPIKey(dbName, id string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_api_keys
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, id)
+	return err
+}
+
+func scanAPIKey(rows Scanner, k *model.APIKey) error {
+	var scopes, ips string
+	if err := rows.Scan(
+		&k.ID,
+		&k.AccountID,
+		&k.UserID,
+		&k.Name,
+		&k.KeyHash,
+		&scopes,
+		&ips,
+		&k.Expires,
+		&k.LastUsed,
+		&k.Created,
+	); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(scopes), &k.Scopes); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(ips), &k.AllowedIPs)
+}
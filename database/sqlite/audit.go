@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) AddAuditEntry(dbName string, entry model.AuditEntry) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_audit_log(id, account_id, actor_id, actor_email, api_key_id, action, target, details, ip, created)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry,
+		sl.NewID(),
+		entry.AccountID,
+		entry.ActorID,
+		entry.ActorEmail,
+		entry.APIKeyID,
+		entry.Action,
+		entry.Target,
+		entry.Details,
+		entry.IP,
+		entry.Created,
+	)
+	return err
+}
+
+func (sl *SQLite) ListAuditEntries(dbName string, f model.AuditFilter) (results []model.AuditEntry, err error) {
+	where, args := auditWhere(f)
+
+	limit := ""
+	if f.Limit > 0 {
+		limit = fmt.Sprintf("LIMIT %d", f.Limit)
+	}
+
+	qry := fmt.Sprintf(`
+		SELECT id, account_id, actor_id, actor_email, api_key_id, action, target, details, ip, created
+		FROM %s_sb_audit_log
+		%s
+		ORDER BY created DESC
+		%s;
+	`, dbName, where, limit)
+
+	rows, err := sl.DB.Query(qry, args...)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var entry model.AuditEntry
+		if err = scanAuditEntry(rows, &entry); err != nil {
+			return
+		}
+		results = append(results, entry)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) DeleteAuditEntriesBefore(dbName string, before time.Time) (int64, error) {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_audit_log
+		WHERE created < $1;
+	`, dbName)
+
+	res, err := sl.DB.Exec(qry, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// auditWhere returns the WHERE clause and its arguments of a filter
+func auditWhere(f model.AuditFilter) (string, []any) {
+	var clauses []string
+	var args []any
+
+	add := func(clause string, v any) {
+		args = append(args, v)
+		clauses = append(clauses, fmt.Sprintf(clause, len(args)))
+	}
+
+	if len(f.Action) > 0 {
+		add("action = $%d", f.Action)
+	}
+	if len(f.ActorID) > 0 {
+		add("actor_id = $%d", f.ActorID)
+	}
+	if len(f.Target) > 0 {
+		add("target = $%d", f.Target)
+	}
+	if !f.Since.IsZero() {
+		add("created >= $%d", f.Since)
+	}
+	if !f.Until.IsZero() {
+		add("created < $%d", f.Until)
+	}
+
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func scanAuditEntry(rows Scanner, entry *model.AuditEntry) error {
+	return rows.Scan(
+		&entry.ID,
+		&entry.AccountID,
+		&entry.ActorID,
+		&entry.ActorEmail,
+		&entry.APIKeyID,
+		&entry.Action,
+		&entry.Target,
+		&entry.Details,
+		&entry.IP,
+		&entry.Created,
+	)
+}
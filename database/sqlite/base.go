@@ -8,7 +8,6 @@ import (
 	"log/slog"
 	"reflect"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/staticbackendhq/core/model"
@@ -19,6 +18,7 @@ const (
 	FieldAccountID = "accountId"
 	FieldOwnerID   = "sb_ownerId"
 	FieldCreated   = "sb_created"
+	FieldUpdated   = "sb_updated"
 	FieldFormName  = "sb_form"
 )
 
@@ -54,40 +54,6 @@ func (sl *SQLite) CreateDocument(auth model.Auth, dbName, col string, doc map[st
 	inserted = doc
 	removeNotEditableFields(inserted)
 
-	cleancol := model.CleanCollectionName(col)
-
-	//TODO: find a good way to prevent doing the create
-	// table if not exists each time
-
-	// for SQLite, this seems to cause issue with tests
-	// so I'm using a map to hold if the collection was already
-	// created
-
-	m := &sync.RWMutex{}
-	m.Lock()
-	defer m.Unlock()
-
-	if _, ok := sl.collections[col]; !ok {
-		qry := fmt.Sprintf(`
-		CREATE TABLE IF NOT EXISTS %s_%s (
-			id TEXT PRIMARY KEY,
-			account_id TEXT REFERENCES %s_sb_accounts(id) ON DELETE CASCADE,
-			owner_id TEXT REFERENCES %s_sb_tokens(id) ON DELETE CASCADE,
-			data JSON NOT NULL,
-			created timestamp NOT NULL
-		);
-
-		CREATE INDEX IF NOT EXISTS %s_%s_acctid_idx ON %s_%s (account_id);			
-	`, dbName, cleancol, dbName, dbName, dbName, cleancol, dbName, cleancol)
-
-		if _, err = sl.DB.Exec(qry); err != nil {
-			err = fmt.Errorf("error creating table: %w", err)
-			return
-		}
-
-		sl.collections[col] = true
-	}
-
 	id := sl.NewID()
 
 	qry := fmt.Sprintf(`
@@ -95,6 +61,9 @@ func (sl *SQLite) CreateDocument(auth model.Auth, dbName, col string, doc map[st
 		VALUES($1, $2, $3, $4, $5);
 	`, dbName, model.CleanCollectionName(col))
 
+	created := time.Now()
+	doc[FieldUpdated] = created
+
 	b, err := json.Marshal(doc)
 	if err != nil {
 		err = fmt.Errorf("error executing INSERT: %w", err)
@@ -104,8 +73,10 @@ func (sl *SQLite) CreateDocument(auth model.Auth, dbName, col string, doc map[st
 	// TODO: sqlite BUSY error in unit test
 	time.Sleep(10 * time.Millisecond)
 
-	created := time.Now()
-	_, err = sl.DB.Exec(qry, id, auth.AccountID, auth.UserID, b, created)
+	err = sl.withCollection(dbName, col, func() error {
+		_, err := sl.DB.Exec(qry, id, auth.AccountID, auth.UserID, b, created)
+		return err
+	})
 	if err != nil {
 		err = fmt.Errorf("error getting the new row ID: %w", err)
 	}
@@ -120,20 +91,86 @@ func (sl *SQLite) CreateDocument(auth model.Auth, dbName, col string, doc map[st
 	return
 }
 
+// bulkInsertBatchSize is the number of rows per multi-row INSERT, keeping the
+// statement well under SQLite's bound parameters limit.
+const bulkInsertBatchSize = 500
+
+// BulkCreateDocument inserts all documents in a single transaction using
+// multi-row INSERT statements.
 func (sl *SQLite) BulkCreateDocument(auth model.Auth, dbName, col string, docs []interface{}) error {
-	//TODO: Naive implementation, not sure if SQLite
-	// has a better way for bulk insert, but will suffice for now.
+	created := time.Now()
+
+	inserted := make([]map[string]interface{}, 0, len(docs))
+	ids := make([]string, 0, len(docs))
 	for _, doc := range docs {
 		d, ok := doc.(map[string]interface{})
 		if !ok {
 			return errors.New("unable to cast doc as map[string]interface{}")
 		}
 
-		if _, err := sl.CreateDocument(auth, dbName, col, d); err != nil {
+		removeNotEditableFields(d)
+		d[FieldUpdated] = created
+		inserted = append(inserted, d)
+		ids = append(ids, sl.NewID())
+	}
+
+	if len(inserted) == 0 {
+		return nil
+	}
+
+	err := sl.withCollection(dbName, col, func() error {
+		return sl.insertDocuments(auth, dbName, col, ids, inserted, created)
+	})
+	if err != nil {
+		return fmt.Errorf("error inserting documents: %w", err)
+	}
+
+	for i, d := range inserted {
+		d[FieldID] = ids[i]
+		d[FieldAccountID] = auth.AccountID
+		d[FieldOwnerID] = auth.UserID
+		d[FieldCreated] = created
+
+		sl.PublishDocument(auth, dbName, "db-"+col, model.MsgTypeDBCreated, d)
+	}
+	return nil
+}
+
+func (sl *SQLite) insertDocuments(auth model.Auth, dbName, col string, ids []string, docs []map[string]interface{}, created time.Time) error {
+	tx, err := sl.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for start := 0; start < len(docs); start += bulkInsertBatchSize {
+		end := min(start+bulkInsertBatchSize, len(docs))
+
+		// $1, $2 and $3 are the account, owner and created values shared
+		// by all rows
+		args := []any{auth.AccountID, auth.UserID, created}
+		values := make([]string, 0, end-start)
+		for i := start; i < end; i++ {
+			b, err := json.Marshal(docs[i])
+			if err != nil {
+				return err
+			}
+
+			args = append(args, ids[i], b)
+			values = append(values, fmt.Sprintf("($%d, $1, $2, $%d, $3)", len(args)-1, len(args)))
+		}
+
+		qry := fmt.Sprintf(`
+			INSERT INTO %s_%s(id, account_id, owner_id, data, created)
+			VALUES %s;
+		`, dbName, model.CleanCollectionName(col), strings.Join(values, ","))
+
+		if _, err := tx.Exec(qry, args...); err != nil {
 			return err
 		}
 	}
-	return nil
+
+	return tx.Commit()
 }
 
 func (sl *SQLite) ListDocuments(auth model.Auth, dbName, col string, params model.ListParams) (result model.PagedResult, err error) {
@@ -289,6 +326,7 @@ func (sl *SQLite) UpdateDocument(auth model.Auth, dbName, col, id string, doc ma
 		orig[key] = val
 	}
 	removeNotEditableFields(orig)
+	orig[FieldUpdated] = time.Now()
 
 	where := secureWrite(auth, col)
 
@@ -323,6 +361,7 @@ func (sl *SQLite) UpdateDocuments(auth model.Auth, dbName, col string, filters m
 	where, filterArgs := applyFilter(where, filters, 3)
 	queryArgs := append([]any{auth.AccountID, auth.UserID}, filterArgs...)
 	removeNotEditableFields(updateFields)
+	updateFields[FieldUpdated] = time.Now()
 
 	var ids []string
 	qry := fmt.Sprintf(`
@@ -529,6 +568,7 @@ func removeNotEditableFields(m map[string]any) {
 	delete(m, FieldAccountID)
 	delete(m, FieldOwnerID)
 	delete(m, FieldCreated)
+	delete(m, FieldUpdated)
 }
 
 func isTableExists(err error) bool {
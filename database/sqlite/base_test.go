@@ -20,6 +20,7 @@ type Task struct {
 	Todos     []Todo    `json:"todos"`
 	Tags      []string  `json:"tags"`
 	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"sb_updated"`
 }
 
 type Todo struct {
@@ -141,6 +142,74 @@ func TestBulkCreateDocument(t *testing.T) {
 	}
 }
 
+func TestBulkCreateDocumentRecreatesDroppedTable(t *testing.T) {
+	col := "bulkdropped"
+	if _, err := datastore.CreateDocument(adminAuth, confDBName, col, newTask("before drop", false)); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulates another instance dropping the table
+	if _, err := datastore.DB.Exec(fmt.Sprintf("DROP TABLE %s_%s", confDBName, col)); err != nil {
+		t.Fatal(err)
+	}
+
+	var many []interface{}
+	for i := 0; i < 3; i++ {
+		many = append(many, newTask(fmt.Sprintf("after drop %d", i), false))
+	}
+
+	if err := datastore.BulkCreateDocument(adminAuth, confDBName, col, many); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, doc := range many {
+		id, ok := doc.(map[string]interface{})[FieldID].(string)
+		if !ok || len(id) == 0 {
+			t.Fatalf("expected an id to be set got %v", doc)
+		}
+
+		if _, err := datastore.GetDocumentByID(adminAuth, confDBName, col, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count, err := datastore.Count(adminAuth, confDBName, col, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if count != 3 {
+		t.Errorf("expected 3 documents got %d", count)
+	}
+}
+
+func benchmarkDocuments(n int) []interface{} {
+	docs := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		docs = append(docs, newTask(fmt.Sprintf("bench %d", i), false))
+	}
+	return docs
+}
+
+// BenchmarkCreateDocumentLoop inserts 50 documents one by one, as
+// BulkCreateDocument used to do.
+func BenchmarkCreateDocumentLoop(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, doc := range benchmarkDocuments(50) {
+			if _, err := datastore.CreateDocument(adminAuth, confDBName, "bench", doc.(map[string]interface{})); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkBulkCreateDocument inserts 50 documents in one batch
+func BenchmarkBulkCreateDocument(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if err := datastore.BulkCreateDocument(adminAuth, confDBName, "bench", benchmarkDocuments(50)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func TestListDocuments(t *testing.T) {
 	task1 := newTask("should be in list", false)
 	inserted, err := datastore.CreateDocument(adminAuth, confDBName, colName, task1)
@@ -664,3 +733,58 @@ func TestQueryWithInOperator(t *testing.T) {
 		t.Errorf("expected to find blueTask ID in result set")
 	}
 }
+
+func TestUpdatedFieldIsMaintained(t *testing.T) {
+	col := "updates_tracking"
+	title := fmt.Sprintf("track updates %d", time.Now().UnixNano())
+	m, err := datastore.CreateDocument(adminAuth, confDBName, col, newTask(title, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inserted := dec(m)
+	if inserted.Updated.IsZero() {
+		t.Fatal("expected sb_updated to be set on create")
+	}
+
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"UpdateDocument", func() error {
+			_, err := datastore.UpdateDocument(adminAuth, confDBName, col, inserted.ID, map[string]any{"done": true})
+			return err
+		}},
+		{"IncrementValue", func() error {
+			return datastore.IncrementValue(adminAuth, confDBName, col, inserted.ID, "likes", 1)
+		}},
+		{"UpdateDocuments", func() error {
+			filters, err := datastore.ParseQuery([][]any{{"title", "=", title}})
+			if err != nil {
+				return err
+			}
+			_, err = datastore.UpdateDocuments(adminAuth, confDBName, col, filters, map[string]any{"done": false})
+			return err
+		}},
+	}
+
+	last := inserted.Updated
+	for _, step := range steps {
+		time.Sleep(5 * time.Millisecond)
+
+		if err := step.fn(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		m, err := datastore.GetDocumentByID(adminAuth, confDBName, col, inserted.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := dec(m)
+		if !got.Updated.After(last) {
+			t.Errorf("%s: expected sb_updated after %v got %v", step.name, last, got.Updated)
+		}
+		last = got.Updated
+	}
+}
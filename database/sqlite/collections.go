@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+)
+
+// CollectionRegistry returns the registry of collections known to exist
+func (sl *SQLite) CollectionRegistry() *database.CollectionRegistry {
+	return sl.collections
+}
+
+// withCollection runs fn once the collection table exists. If fn fails because
+// the table was dropped since it was registered, it's created again and fn is
+// retried once.
+func (sl *SQLite) withCollection(dbName, col string, fn func() error) error {
+	if err := sl.ensureCollection(dbName, col); err != nil {
+		return err
+	}
+
+	err := fn()
+	if err == nil || isTableExists(err) {
+		return err
+	}
+
+	sl.collections.Forget(dbName, col)
+	if err := sl.ensureCollection(dbName, col); err != nil {
+		return err
+	}
+	return fn()
+}
+
+func (sl *SQLite) ensureCollection(dbName, col string) error {
+	return sl.collections.Ensure(dbName, col, func() error {
+		cleancol := model.CleanCollectionName(col)
+
+		qry := fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s_%s (
+				id TEXT PRIMARY KEY,
+				account_id TEXT REFERENCES %s_sb_accounts(id) ON DELETE CASCADE,
+				owner_id TEXT REFERENCES %s_sb_tokens(id) ON DELETE CASCADE,
+				data JSON NOT NULL,
+				created timestamp NOT NULL
+			);
+
+			CREATE INDEX IF NOT EXISTS %s_%s_acctid_idx ON %s_%s (account_id);
+		`, dbName, cleancol, dbName, dbName, dbName, cleancol, dbName, cleancol)
+
+		if _, err := sl.DB.Exec(qry); err != nil {
+			return fmt.Errorf("error creating table: %w", err)
+		}
+		return nil
+	})
+}
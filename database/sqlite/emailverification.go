@@ -0,0 +1,36 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) GetEmailVerification(dbName, userID string) (v model.EmailVerification, err error) {
+	qry := fmt.Sprintf(`
+		SELECT user_id, email, verified
+		FROM %s_sb_email_verifications
+		WHERE user_id = $1;
+	`, dbName)
+
+	err = sl.DB.QueryRow(qry, userID).Scan(&v.UserID, &v.Email, &v.Verified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.EmailVerification{}, nil
+	}
+	return
+}
+
+func (sl *SQLite) SaveEmailVerification(dbName string, v model.EmailVerification) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_email_verifications(user_id, email, verified)
+		VALUES($1, $2, $3)
+		ON CONFLICT(user_id) DO UPDATE SET
+			email = excluded.email,
+			verified = excluded.verified;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, v.UserID, v.Email, v.Verified)
+	return err
+}
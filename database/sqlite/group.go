@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) CreateGroup(dbName string, g model.Group) (id string, err error) {
+	id = sl.NewID()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_groups(id, account_id, name, created)
+		VALUES($1, $2, $3, $4);
+	`, dbName)
+
+	_, err = sl.DB.Exec(qry, id, g.AccountID, g.Name, g.Created)
+	return
+}
+
+func (sl *SQLite) GetGroup(dbName, accountID, groupID string) (g model.Group, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, account_id, name, created
+		FROM %s_sb_groups
+		WHERE id = $1 AND account_id = $2;
+	`, dbName)
+
+	err = scanGroup(sl.DB.QueryRow(qry, groupID, accountID), &g)
+	return
+}
+
+func (sl *SQLite) ListGroups(dbName, accountID string) (results []model.Group, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, account_id, name, created
+		FROM %s_sb_groups
+		WHERE account_id = $1
+		ORDER BY name;
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry, accountID)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var g model.Group
+		if err = scanGroup(rows, &g); err != nil {
+			return
+		}
+		results = append(results, g)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) DeleteGroup(dbName, accountID, groupID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_groups
+		WHERE id = $1 AND account_id = $2;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, groupID, accountID)
+	return err
+}
+
+func (sl *SQLite) AddGroupMember(dbName string, member model.GroupMember) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_group_members(group_id, account_id, user_id, added)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT(group_id, user_id) DO NOTHING;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, member.GroupID, member.AccountID, member.UserID, member.Added)
+	return err
+}
+
+func (sl *SQLite) RemoveGroupMember(dbName, groupID, userID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_group_members
+		WHERE group_id = $1 AND user_id = $2;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, groupID, userID)
+	return err
+}
+
+func (sl *SQLite) ListGroupMembers(dbName, groupID string) (results []model.GroupMember, err error) {
+	qry := fmt.Sprintf(`
+		SELECT group_id, account_id, user_id, added
+		FROM %s_sb_group_members
+		WHERE group_id = $1
+		ORDER BY added;
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry, groupID)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var member model.GroupMember
+		if err = scanGroupMember(rows, &member); err != nil {
+			return
+		}
+		results = append(results, member)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) ListUserGroups(dbName, accountID, userID string) (ids []string, err error) {
+	qry := fmt.Sprintf(`
+		SELECT group_id
+		FROM %s_sb_group_members
+		WHERE account_id = $1 AND user_id = $2
+		ORDER BY group_id;
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry, accountID, userID)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func scanGroup(rows Scanner, g *model.Group) error {
+	return rows.Scan(
+		&g.ID,
+		&g.AccountID,
+		&g.Name,
+		&g.Created,
+	)
+}
+
+func scanGroupMember(rows Scanner, member *model.GroupMember) error {
+	return rows.Scan(
+		&member.GroupID,
+		&member.AccountID,
+		&member.UserID,
+		&member.Added,
+	)
+}
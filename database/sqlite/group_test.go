@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestGroups(t *testing.T) {
+	g := model.Group{AccountID: adminAuth.AccountID, Name: "Sales", Created: time.Now()}
+	id, err := datastore.CreateGroup(confDBName, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetGroup(confDBName, adminAuth.AccountID, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.ID != id || check.Name != g.Name {
+		t.Fatalf("expected group %s named %s got %v", id, g.Name, check)
+	}
+
+	if _, err := datastore.GetGroup(confDBName, "other-account", id); err == nil {
+		t.Error("expected the group to be hidden from other accounts")
+	}
+
+	groups, err := datastore.ListGroups(confDBName, adminAuth.AccountID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(groups) == 0 {
+		t.Fatal("expected the group to be listed")
+	}
+
+	member := model.GroupMember{GroupID: id, AccountID: adminAuth.AccountID, UserID: adminAuth.UserID, Added: time.Now()}
+	if err := datastore.AddGroupMember(confDBName, member); err != nil {
+		t.Fatal(err)
+	}
+	// adding a member twice is a no-op
+	if err := datastore.AddGroupMember(confDBName, member); err != nil {
+		t.Fatal(err)
+	}
+
+	members, err := datastore.ListGroupMembers(confDBName, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(members) != 1 || members[0].UserID != adminAuth.UserID {
+		t.Fatalf("expected the user to be the only member got %v", members)
+	}
+
+	ids, err := datastore.ListUserGroups(confDBName, adminAuth.AccountID, adminAuth.UserID)
+	if err != nil {
+		t.Fatal(err)
+	} else if !slices.Contains(ids, id) {
+		t.Errorf("expected the user groups to contain %s got %v", id, ids)
+	}
+
+	if err := datastore.RemoveGroupMember(confDBName, id, adminAuth.UserID); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err = datastore.ListUserGroups(confDBName, adminAuth.AccountID, adminAuth.UserID)
+	if err != nil {
+		t.Fatal(err)
+	} else if slices.Contains(ids, id) {
+		t.Errorf("expected the user to be removed from the group got %v", ids)
+	}
+
+	if err := datastore.DeleteGroup(confDBName, adminAuth.AccountID, id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := datastore.GetGroup(confDBName, adminAuth.AccountID, id); err == nil {
+		t.Error("expected the group to be deleted")
+	}
+}
+
+func TestGroupScopedDocuments(t *testing.T) {
+	col := "group_scoped_tasks"
+	groupID := "group-scoped-id"
+
+	memberAuth := model.Auth{AccountID: adminAuth.AccountID, UserID: "group-member", Role: 10, Groups: []string{groupID}}
+	otherAuth := model.Auth{AccountID: adminAuth.AccountID, UserID: "group-other", Role: 10}
+	managerAuth := model.Auth{AccountID: adminAuth.AccountID, UserID: "group-manager", Role: 50}
+
+	doc := newTask("group scoped", false)
+	doc[model.FieldGroupID] = groupID
+
+	inserted, err := datastore.CreateDocument(memberAuth, confDBName, col, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := inserted["id"].(string)
+
+	if _, err := datastore.GetDocumentByID(memberAuth, confDBName, col, id); err != nil {
+		t.Errorf("expected a group member to read the document: %v", err)
+	}
+	if _, err := datastore.GetDocumentByID(managerAuth, confDBName, col, id); err != nil {
+		t.Errorf("expected an account manager to read the document: %v", err)
+	}
+	if _, err := datastore.GetDocumentByID(otherAuth, confDBName, col, id); err == nil {
+		t.Error("expected a user outside the group to be denied")
+	}
+
+	result, err := datastore.ListDocuments(otherAuth, confDBName, col, model.ListParams{Page: 1, Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(result.Results) != 0 {
+		t.Errorf("expected no documents for a user outside the group got %v", result.Results)
+	}
+
+	// owner-scoped collections are shared with the group members
+	owned, err := datastore.CreateDocument(otherAuth, confDBName, col+"_700_", map[string]any{"title": "owned", model.FieldGroupID: groupID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := datastore.UpdateDocument(memberAuth, confDBName, col+"_700_", owned["id"].(string), map[string]any{"title": "by member"}); err != nil {
+		t.Errorf("expected a group member to update the document: %v", err)
+	}
+}
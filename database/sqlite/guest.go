@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) SaveGuest(dbName string, g model.Guest) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_guests(user_id, account_id, created, last_seen)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT(user_id) DO UPDATE SET
+			last_seen = excluded.last_seen;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, g.UserID, g.AccountID, g.Created, g.LastSeen)
+	return err
+}
+
+func (sl *SQLite) GetGuest(dbName, userID string) (g model.Guest, err error) {
+	qry := fmt.Sprintf(`
+		SELECT user_id, account_id, created, last_seen
+		FROM %s_sb_guests
+		WHERE user_id = $1;
+	`, dbName)
+
+	err = scanGuest(sl.DB.QueryRow(qry, userID), &g)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Guest{}, nil
+	}
+	return
+}
+
+func (sl *SQLite) ListGuestsSeenBefore(dbName string, before time.Time) (results []model.Guest, err error) {
+	qry := fmt.Sprintf(`
+		SELECT user_id, account_id, created, last_seen
+		FROM %s_sb_guests
+		WHERE last_seen < $1;
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry, before)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var g model.Guest
+		if err = scanGuest(rows, &g); err != nil {
+			return
+		}
+		results = append(results, g)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) DeleteGuest(dbName, userID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_guests
+		WHERE user_id = $1;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, userID)
+	return err
+}
+
+func scanGuest(rows Scanner, g *model.Guest) error {
+	return rows.Scan(
+		&g.UserID,
+		&g.AccountID,
+		&g.Created,
+		&g.LastSeen,
+	)
+}
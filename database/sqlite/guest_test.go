@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestGuests(t *testing.T) {
+	g, err := datastore.GetGuest(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(g.UserID) > 0 {
+		t.Fatalf("expected no guest got %v", g)
+	}
+
+	created := time.Now().Add(-48 * time.Hour)
+	g = model.Guest{UserID: adminToken.ID, AccountID: adminToken.AccountID, Created: created, LastSeen: created}
+	if err := datastore.SaveGuest(confDBName, g); err != nil {
+		t.Fatal(err)
+	}
+
+	guests, err := datastore.ListGuestsSeenBefore(confDBName, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(guests) != 1 || guests[0].UserID != adminToken.ID || guests[0].AccountID != adminToken.AccountID {
+		t.Fatalf("expected the guest to be abandoned got %v", guests)
+	}
+
+	g.LastSeen = time.Now()
+	if err := datastore.SaveGuest(confDBName, g); err != nil {
+		t.Fatal(err)
+	}
+
+	guests, err = datastore.ListGuestsSeenBefore(confDBName, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(guests) != 0 {
+		t.Errorf("expected a guest seen recently to be kept got %v", guests)
+	}
+
+	if err := datastore.DeleteGuest(confDBName, adminToken.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetGuest(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(check.UserID) > 0 {
+		t.Errorf("expected the guest to be deleted got %v", check)
+	}
+}
@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) CreateInvitation(dbName string, inv model.Invitation) (id string, err error) {
+	id = sl.NewID()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_invitations(id, account_id, email, role, invited_by, created, sent, expires)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8);
+	`, dbName)
+
+	_, err = sl.DB.Exec(qry,
+		id,
+		inv.AccountID,
+		inv.Email,
+		inv.Role,
+		inv.InvitedBy,
+		inv.Created,
+		inv.Sent,
+		inv.Expires,
+	)
+	return
+}
+
+func (sl *SQLite) GetInvitation(dbName, id string) (inv model.Invitation, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, account_id, email, role, invited_by, created, sent, expires
+		FROM %s_sb_invitations
+		WHERE id = $1;
+	`, dbName)
+
+	err = scanInvitation(sl.DB.QueryRow(qry, id), &inv)
+	return
+}
+
+func (sl *SQLite) ListInvitations(dbName, accountID string) (results []model.Invitation, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, account_id, email, role, invited_by, created, sent, expires
+		FROM %s_sb_invitations
+		WHERE account_id = $1
+		ORDER BY created DESC;
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry, accountID)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var inv model.Invitation
+		if err = scanInvitation(rows, &inv); err != nil {
+			return
+		}
+		results = append(results, inv)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) UpdateInvitation(dbName string, inv model.Invitation) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s_sb_invitations SET
+			sent = $2,
+			expires = $3
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, inv.ID, inv.Sent, inv.Expires)
+	return err
+}
+
+func (sl *SQLite) DeleteInvitation(dbName, id string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_invitations
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, id)
+	return err
+}
+
+func scanInvitation(rows Scanner, inv *model.Invitation) error {
+	return rows.Scan(
+		&inv.ID,
+		&inv.AccountID,
+		&inv.Email,
+		&inv.Role,
+		&inv.InvitedBy,
+		&inv.Created,
+		&inv.Sent,
+		&inv.Expires,
+	)
+}
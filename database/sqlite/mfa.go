@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) GetUserMFA(dbName, userID string) (mfa model.UserMFA, err error) {
+	qry := fmt.Sprintf(`
+		SELECT user_id, secret, enabled, recovery_codes, last_totp_step, created
+		FROM %s_sb_user_mfa
+		WHERE user_id = $1;
+	`, dbName)
+
+	var codes string
+	err = sl.DB.QueryRow(qry, userID).Scan(
+		&mfa.UserID,
+		&mfa.Secret,
+		&mfa.Enabled,
+		&codes,
+		&mfa.LastTOTPStep,
+		&mfa.Created,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.UserMFA{}, nil
+	} else if err != nil {
+		return
+	}
+
+	err = json.Unmarshal([]byte(codes), &mfa.RecoveryCodes)
+	return
+}
+
+func (sl *SQLite) SaveUserMFA(dbName string, mfa model.UserMFA) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_user_mfa(user_id, secret, enabled, recovery_codes, last_totp_step, created)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT(user_id) DO UPDATE SET
+			secret = excluded.secret,
+			enabled = excluded.enabled,
+			recovery_codes = excluded.recovery_codes,
+			last_totp_step = excluded.last_totp_step,
+			created = excluded.created;
+	`, dbName)
+
+	if mfa.RecoveryCodes == nil {
+		mfa.RecoveryCodes = []string{}
+	}
+
+	codes, err := json.Marshal(mfa.RecoveryCodes)
+	if err != nil {
+		return err
+	}
+
+	_, err = sl.DB.Exec(qry,
+		mfa.UserID,
+		mfa.Secret,
+		mfa.Enabled,
+		string(codes),
+		mfa.LastTOTPStep,
+		mfa.Created,
+	)
+	return err
+}
+
+func (sl *SQLite) DeleteUserMFA(dbName, userID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_user_mfa
+		WHERE user_id = $1;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, userID)
+	return err
+}
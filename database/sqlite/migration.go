@@ -108,6 +108,98 @@ func ensureVersion(db *sql.DB) error {
 				return err
 			}
 		}
+		if i == 5 {
+			if err := migrateAddSettings(db); err != nil {
+				return err
+			}
+		}
+
+		if i == 6 {
+			if err := migrateAddSequences(db); err != nil {
+				return err
+			}
+		}
+
+		if i == 7 {
+			if err := migrateAddUserMFA(db); err != nil {
+				return err
+			}
+		}
+
+		if i == 8 {
+			if err := migrateAddSessions(db); err != nil {
+				return err
+			}
+		}
+
+		if i == 9 {
+			if err := migrateAddAPIKeys(db); err != nil {
+				return err
+			}
+		}
+
+		if i == 10 {
+			if err := migrateAddOAuthClients(db); err != nil {
+				return err
+			}
+		}
+
+		if i == 12 {
+			if err := migrateAddEmailVerifications(db); err != nil {
+				return err
+			}
+		}
+
+		if i == 13 {
+			if err := migrateAddInvitations(db); err != nil {
+				return err
+			}
+		}
+
+		if i == 14 {
+			if err := migrateAddUserRoles(db); err != nil {
+				return err
+			}
+		}
+
+		if i == 15 {
+			if err := migrateAddAuditLog(db); err != nil {
+				return err
+			}
+		}
+
+		if i == 16 {
+			if err := migrateAddUserProfiles(db); err != nil {
+				return err
+			}
+		}
+
+		if i == 17 {
+			if err := migrateAddUserPhones(db); err != nil {
+				return err
+			}
+		}
+
+		if i == 18 {
+			if err := migrateAddGuests(db); err != nil {
+				return err
+			}
+		}
+		if i == 19 {
+			if err := migrateAddGroups(db); err != nil {
+				return err
+			}
+		}
+		if i == 20 {
+			if err := migrateAddMFALastStep(db); err != nil {
+				return err
+			}
+		}
+		if i == 21 {
+			if err := migrateAddSessionClient(db); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -185,6 +277,283 @@ func migrateAddFunctionSecrets(db *sql.DB) error {
 	return nil
 }
 
+func migrateAddSettings(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_settings (
+			key     TEXT PRIMARY KEY,
+			value   JSON NOT NULL,
+			updated TIMESTAMP NOT NULL
+		);
+	`)
+}
+
+func migrateAddSequences(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_sequences (
+			name    TEXT PRIMARY KEY,
+			value   INTEGER NOT NULL
+		);
+	`)
+}
+
+func migrateAddUserMFA(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_user_mfa (
+			user_id         TEXT PRIMARY KEY REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			secret          TEXT NOT NULL,
+			enabled         BOOLEAN NOT NULL,
+			recovery_codes  JSON NOT NULL,
+			created         TIMESTAMP NOT NULL
+		);
+	`)
+}
+
+func migrateAddSessions(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_sessions (
+			id              TEXT PRIMARY KEY,
+			user_id         TEXT NOT NULL REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			account_id      TEXT NOT NULL,
+			refresh_token   TEXT NOT NULL,
+			user_agent      TEXT NOT NULL,
+			ip              TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL,
+			last_seen       TIMESTAMP NOT NULL,
+			expires         TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS {schema}_sb_sessions_user_id_idx ON {schema}_sb_sessions (user_id);
+	`)
+}
+
+func migrateAddAPIKeys(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_api_keys (
+			id              TEXT PRIMARY KEY,
+			account_id      TEXT NOT NULL,
+			user_id         TEXT NOT NULL REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			name            TEXT NOT NULL,
+			key_hash        TEXT NOT NULL,
+			scopes          JSON NOT NULL,
+			allowed_ips     JSON NOT NULL,
+			expires         TIMESTAMP NULL,
+			last_used       TIMESTAMP NULL,
+			created         TIMESTAMP NOT NULL
+		);
+	`)
+}
+
+func migrateAddOAuthClients(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_oauth_clients (
+			id              TEXT PRIMARY KEY,
+			name            TEXT NOT NULL,
+			secret_hash     TEXT NOT NULL,
+			redirect_uris   JSON NOT NULL,
+			public          BOOLEAN NOT NULL,
+			trusted         BOOLEAN NOT NULL,
+			created         TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_oauth_consents (
+			id              TEXT PRIMARY KEY,
+			user_id         TEXT NOT NULL REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			client_id       TEXT NOT NULL REFERENCES {schema}_sb_oauth_clients(id) ON DELETE CASCADE,
+			scopes          JSON NOT NULL,
+			created         TIMESTAMP NOT NULL,
+			UNIQUE (user_id, client_id)
+		);
+	`)
+}
+
+func migrateAddEmailVerifications(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_email_verifications (
+			user_id         TEXT PRIMARY KEY REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			email           TEXT NOT NULL,
+			verified        TIMESTAMP NOT NULL
+		);
+	`)
+}
+
+func migrateAddInvitations(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_invitations (
+			id              TEXT PRIMARY KEY,
+			account_id      TEXT NOT NULL REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+			email           TEXT NOT NULL,
+			role            INTEGER NOT NULL DEFAULT 0,
+			invited_by      TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL,
+			sent            TIMESTAMP NOT NULL,
+			expires         TIMESTAMP NOT NULL
+		);
+	`)
+}
+
+func migrateAddUserRoles(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_user_roles (
+			user_id         TEXT NOT NULL REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			account_id      TEXT NOT NULL REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+			name            TEXT NOT NULL,
+			PRIMARY KEY (user_id, account_id)
+		);
+	`)
+}
+
+func migrateAddAuditLog(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_audit_log (
+			id              TEXT PRIMARY KEY,
+			account_id      TEXT NOT NULL,
+			actor_id        TEXT NOT NULL,
+			actor_email     TEXT NOT NULL,
+			api_key_id      TEXT NOT NULL,
+			action          TEXT NOT NULL,
+			target          TEXT NOT NULL,
+			details         TEXT NOT NULL,
+			ip              TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS {schema}_sb_audit_log_created_idx ON {schema}_sb_audit_log (created);
+	`)
+}
+
+func migrateAddUserProfiles(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_user_profiles (
+			user_id         TEXT PRIMARY KEY REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			public          JSON NOT NULL,
+			private         JSON NOT NULL,
+			updated         TIMESTAMP NOT NULL
+		);
+	`)
+}
+
+func migrateAddUserPhones(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_user_phones (
+			user_id         TEXT PRIMARY KEY REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			number          TEXT NOT NULL UNIQUE,
+			verified        TIMESTAMP NOT NULL
+		);
+	`)
+}
+
+func migrateAddGuests(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_guests (
+			user_id         TEXT PRIMARY KEY REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			account_id      TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL,
+			last_seen       TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS {schema}_sb_guests_last_seen_idx ON {schema}_sb_guests (last_seen);
+	`)
+}
+
+func migrateAddGroups(db *sql.DB) error {
+	return execForEachApp(db, `
+		CREATE TABLE IF NOT EXISTS {schema}_sb_groups (
+			id              TEXT PRIMARY KEY,
+			account_id      TEXT NOT NULL REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+			name            TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS {schema}_sb_groups_account_id_idx ON {schema}_sb_groups (account_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_group_members (
+			group_id        TEXT NOT NULL REFERENCES {schema}_sb_groups(id) ON DELETE CASCADE,
+			account_id      TEXT NOT NULL,
+			user_id         TEXT NOT NULL REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			added           TIMESTAMP NOT NULL,
+			PRIMARY KEY (group_id, user_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS {schema}_sb_group_members_user_id_idx ON {schema}_sb_group_members (user_id, account_id);
+	`)
+}
+
+func migrateAddMFALastStep(db *sql.DB) error {
+	return addColumnForEachApp(db, `
+		ALTER TABLE {schema}_sb_user_mfa
+		ADD COLUMN last_totp_step INTEGER NOT NULL DEFAULT 0;
+	`)
+}
+
+func migrateAddSessionClient(db *sql.DB) error {
+	return addColumnForEachApp(db,
+		`ALTER TABLE {schema}_sb_sessions ADD COLUMN client_id TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE {schema}_sb_sessions ADD COLUMN scope TEXT NOT NULL DEFAULT '';`,
+	)
+}
+
+// addColumnForEachApp executes ALTER TABLE ... ADD COLUMN statements for
+// every app, the columns already created with the app's tables are skipped.
+func addColumnForEachApp(db *sql.DB, ddls ...string) error {
+	rows, err := db.Query(`SELECT name FROM sb_apps`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		for _, ddl := range ddls {
+			if _, err := db.Exec(strings.ReplaceAll(ddl, "{schema}", name)); err != nil {
+				if strings.Contains(err.Error(), "duplicate column name") {
+					continue
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// execForEachApp executes the DDL for every app replacing {schema} with the
+// app name.
+func execForEachApp(db *sql.DB, ddl string) error {
+	rows, err := db.Query(`SELECT name FROM sb_apps`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if _, err := db.Exec(strings.ReplaceAll(ddl, "{schema}", name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func getDBLastMigration(db *sql.DB) (dbVersion int, err error) {
 	err = db.QueryRow(`
 		SELECT MAX(version)
@@ -0,0 +1,161 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) CreateOAuthClient(dbName string, c model.OAuthClient) (id string, err error) {
+	uris, err := json.Marshal(c.RedirectURIs)
+	if err != nil {
+		return
+	}
+
+	id = sl.NewID()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_oauth_clients(id, name, secret_hash, redirect_uris, public, trusted, created)
+		VALUES($1, $2, $3, $4, $5, $6, $7);
+	`, dbName)
+
+	_, err = sl.DB.Exec(qry,
+		id,
+		c.Name,
+		c.SecretHash,
+		string(uris),
+		c.Public,
+		c.Trusted,
+		c.Created,
+	)
+	return
+}
+
+func (sl *SQLite) GetOAuthClient(dbName, id string) (c model.OAuthClient, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, name, secret_hash, redirect_uris, public, trusted, created
+		FROM %s_sb_oauth_clients
+		WHERE id = $1;
+	`, dbName)
+
+	err = scanOAuthClient(sl.DB.QueryRow(qry, id), &c)
+	return
+}
+
+func (sl *SQLite) ListOAuthClients(dbName string) (results []model.OAuthClient, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, name, secret_hash, redirect_uris, public, trusted, created
+		FROM %s_sb_oauth_clients
+		ORDER BY created ASC;
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var c model.OAuthClient
+		if err = scanOAuthClient(rows, &c); err != nil {
+			return
+		}
+		results = append(results, c)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) DeleteOAuthClient(dbName, id string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_oauth_consents
+		WHERE client_id = $1;
+	`, dbName)
+
+	if _, err := sl.DB.Exec(qry, id); err != nil {
+		return err
+	}
+
+	qry = fmt.Sprintf(`
+		DELETE FROM %s_sb_oauth_clients
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, id)
+	return err
+}
+
+func (sl *SQLite) ListOAuthConsents(dbName, userID string) (results []model.OAuthConsent, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, user_id, client_id, scopes, created
+		FROM %s_sb_oauth_consents
+		WHERE user_id = $1
+		ORDER BY created ASC;
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry, userID)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var c model.OAuthConsent
+		var scopes string
+		if err = rows.Scan(&c.ID, &c.UserID, &c.ClientID, &scopes, &c.Created); err != nil {
+			return
+		}
+		if err = json.Unmarshal([]byte(scopes), &c.Scopes); err != nil {
+			return
+		}
+		results = append(results, c)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) SaveOAuthConsent(dbName string, c model.OAuthConsent) error {
+	scopes, err := json.Marshal(c.Scopes)
+	if err != nil {
+		return err
+	}
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_oauth_consents(id, user_id, client_id, scopes, created)
+		VALUES($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, client_id) DO UPDATE SET scopes = excluded.scopes, created = excluded.created;
+	`, dbName)
+
+	_, err = sl.DB.Exec(qry, sl.NewID(), c.UserID, c.ClientID, string(scopes), c.Created)
+	return err
+}
+
+func (sl *SQLite) DeleteOAuthConsent(dbName, userID, clientID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_oauth_consents
+		WHERE user_id = $1 AND client_id = $2;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, userID, clientID)
+	return err
+}
+
+func scanOAuthClient(rows Scanner, c *model.OAuthClient) error {
+	var uris string
+	if err := rows.Scan(
+		&c.ID,
+		&c.Name,
+		&c.SecretHash,
+		&uris,
+		&c.Public,
+		&c.Trusted,
+		&c.Created,
+	); err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(uris), &c.RedirectURIs)
+}
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) GetUserPhone(dbName, userID string) (phone model.UserPhone, err error) {
+	qry := fmt.Sprintf(`
+		SELECT user_id, number, verified
+		FROM %s_sb_user_phones
+		WHERE user_id = $1;
+	`, dbName)
+
+	err = sl.DB.QueryRow(qry, userID).Scan(&phone.UserID, &phone.Number, &phone.Verified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.UserPhone{}, nil
+	}
+	return
+}
+
+func (sl *SQLite) FindUserByPhone(dbName, number string) (tok model.User, err error) {
+	qry := fmt.Sprintf(`
+		SELECT t.*
+		FROM %s_sb_tokens t
+		JOIN %s_sb_user_phones p ON p.user_id = t.id
+		WHERE p.number = $1;
+	`, dbName, dbName)
+
+	row := sl.DB.QueryRow(qry, number)
+
+	err = scanToken(row, &tok)
+	return
+}
+
+func (sl *SQLite) SaveUserPhone(dbName string, phone model.UserPhone) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_user_phones(user_id, number, verified)
+		VALUES($1, $2, $3)
+		ON CONFLICT(user_id) DO UPDATE SET
+			number = excluded.number,
+			verified = excluded.verified;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, phone.UserID, phone.Number, phone.Verified)
+	return err
+}
+
+func (sl *SQLite) DeleteUserPhone(dbName, userID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_user_phones
+		WHERE user_id = $1;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, userID)
+	return err
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestUserPhone(t *testing.T) {
+	phone, err := datastore.GetUserPhone(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(phone.UserID) > 0 {
+		t.Fatalf("expected no phone got %v", phone)
+	}
+
+	phone = model.UserPhone{UserID: adminToken.ID, Number: "+15145550000", Verified: time.Now()}
+	if err := datastore.SaveUserPhone(confDBName, phone); err != nil {
+		t.Fatal(err)
+	}
+
+	phone.Number = "+15145551234"
+	if err := datastore.SaveUserPhone(confDBName, phone); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := datastore.FindUserByPhone(confDBName, "+15145550000"); err == nil {
+		t.Error("expected the previous number to be replaced")
+	}
+
+	tok, err := datastore.FindUserByPhone(confDBName, phone.Number)
+	if err != nil {
+		t.Fatal(err)
+	} else if tok.ID != adminToken.ID || tok.Email != adminToken.Email {
+		t.Errorf("expected user %s got %v", adminToken.ID, tok)
+	}
+
+	if err := datastore.DeleteUserPhone(confDBName, adminToken.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetUserPhone(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(check.UserID) > 0 {
+		t.Errorf("expected the phone to be deleted got %v", check)
+	}
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) GetUserProfile(dbName, userID string) (model.UserProfile, error) {
+	qry := fmt.Sprintf(`
+		SELECT user_id, public, private, updated
+		FROM %s_sb_user_profiles
+		WHERE user_id = $1;
+	`, dbName)
+
+	p := model.UserProfile{UserID: userID}
+
+	var public, private string
+	err := sl.DB.QueryRow(qry, userID).Scan(&p.UserID, &public, &private, &p.Updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, nil
+	} else if err != nil {
+		return p, err
+	}
+
+	if err := json.Unmarshal([]byte(public), &p.Public); err != nil {
+		return p, err
+	}
+	err = json.Unmarshal([]byte(private), &p.Private)
+	return p, err
+}
+
+func (sl *SQLite) SaveUserProfile(dbName string, p model.UserProfile) error {
+	public, err := json.Marshal(p.Public)
+	if err != nil {
+		return err
+	}
+
+	private, err := json.Marshal(p.Private)
+	if err != nil {
+		return err
+	}
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_user_profiles(user_id, public, private, updated)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT(user_id) DO UPDATE SET
+			public = excluded.public,
+			private = excluded.private,
+			updated = excluded.updated;
+	`, dbName)
+
+	_, err = sl.DB.Exec(qry, p.UserID, string(public), string(private), p.Updated)
+	return err
+}
@@ -18,6 +18,24 @@ func (sl *SQLite) ParseQuery(clauses [][]interface{}) (map[string]interface{}, e
 }
 
 func applyFilter(where string, filters map[string]interface{}, startAt int) (string, []any) {
+	ids, filters, hasIDs := sbquery.SplitIDs(filters)
+
+	where, args := applyClauses(where, filters, startAt)
+	if !hasIDs {
+		return where, args
+	} else if len(ids) == 0 {
+		return where + " AND FALSE", args
+	}
+
+	placeholders := make([]string, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", startAt+len(args)-1))
+	}
+	return where + fmt.Sprintf(" AND id IN (%s)", strings.Join(placeholders, ", ")), args
+}
+
+func applyClauses(where string, filters map[string]interface{}, startAt int) (string, []any) {
 	q, ok := sbquery.FromFilter(filters)
 	if !ok {
 		return applyLegacyFilter(where, filters), nil
@@ -140,25 +158,32 @@ func escapeLikePattern(s string) string {
 }
 
 func secureRead(auth model.Auth, col string) string {
-	switch internal.ReadScope(auth, col) {
-	case internal.RowScopeAccount:
-		return "WHERE account_id = $1 AND $2=$2 "
-	case internal.RowScopeOwner:
-		return "WHERE account_id = $1 AND owner_id = $2 "
-	default:
-		//for read permission to everyone i.e. col-name_774_
-		return "WHERE $1=$1 AND $2=$2 "
-	}
+	return secureWhere(auth, internal.ReadScope(auth, col))
 }
 
 func secureWrite(auth model.Auth, col string) string {
-	switch internal.WriteScope(auth, col, true) {
+	return secureWhere(auth, internal.WriteScope(auth, col, true))
+}
+
+func secureWhere(auth model.Auth, scope internal.RowPermissionScope) string {
+	group := fmt.Sprintf("json_extract(data, '$.%s')", model.FieldGroupID)
+
+	switch scope {
 	case internal.RowScopeAccount:
-		return "WHERE account_id = $1 AND $2=$2 "
+		if internal.SeesAllGroups(auth) {
+			return "WHERE account_id = $1 AND $2=$2 "
+		} else if len(auth.Groups) == 0 {
+			return fmt.Sprintf("WHERE account_id = $1 AND $2=$2 AND COALESCE(%s, '') = '' ", group)
+		}
+		return fmt.Sprintf("WHERE account_id = $1 AND $2=$2 AND (COALESCE(%s, '') = '' OR %s IN (%s)) ", group, group, groupList(auth.Groups))
 	case internal.RowScopeOwner:
-		return "WHERE account_id = $1 AND owner_id = $2 "
+		if len(auth.Groups) == 0 {
+			return "WHERE account_id = $1 AND owner_id = $2 "
+		}
+		return fmt.Sprintf("WHERE account_id = $1 AND (owner_id = $2 OR %s IN (%s)) ", group, groupList(auth.Groups))
 	default:
-		//for write permission to everyone i.e. col-name_776_
+		//for read permission to everyone i.e. col-name_774_ or write
+		// permission to everyone i.e. col-name_776_.
 		// This should probably get more warning in the doc.
 		// All logged-in users can update/delete data.
 		// There's use cases for that, and it's certainly opt-in
@@ -167,6 +192,16 @@ func secureWrite(auth model.Auth, col string) string {
 	}
 }
 
+// groupList returns the quoted group ids of a user for an IN clause, they
+// come from the data store and not from the request
+func groupList(groups []string) string {
+	quoted := make([]string, 0, len(groups))
+	for _, id := range groups {
+		quoted = append(quoted, "'"+strings.ReplaceAll(id, "'", "''")+"'")
+	}
+	return strings.Join(quoted, ", ")
+}
+
 func setPaging(params model.ListParams) string {
 	if len(params.SortBy) == 0 {
 		params.SortBy = "created"
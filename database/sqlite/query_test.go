@@ -30,3 +30,20 @@ func TestApplyFilterFieldReferenceWithNumberType(t *testing.T) {
 		t.Fatalf("expected numeric field comparison, got %s", where)
 	}
 }
+
+func TestApplyFilterWithIDs(t *testing.T) {
+	q, err := sbquery.Parse([][]interface{}{{"status", "=", "unpaid"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filters := sbquery.WithIDs(map[string]interface{}{sbquery.FilterKey: q}, []string{"id-1", "id-2"})
+
+	where, args := applyFilter("WHERE $1=$1 AND $2=$2 ", filters, 3)
+	if len(args) != 3 || args[1] != "id-1" || args[2] != "id-2" {
+		t.Fatalf("expected the status and ids args got %v", args)
+	}
+	if !strings.Contains(where, "id IN ($4, $5)") {
+		t.Fatalf("expected ids restriction, got %s", where)
+	}
+}
@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) GetUserRole(dbName, userID, accountID string) (role model.UserRole, err error) {
+	qry := fmt.Sprintf(`
+		SELECT user_id, account_id, name
+		FROM %s_sb_user_roles
+		WHERE user_id = $1 AND account_id = $2;
+	`, dbName)
+
+	err = sl.DB.QueryRow(qry, userID, accountID).Scan(&role.UserID, &role.AccountID, &role.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.UserRole{}, nil
+	}
+	return
+}
+
+func (sl *SQLite) SaveUserRole(dbName string, role model.UserRole) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_user_roles(user_id, account_id, name)
+		VALUES($1, $2, $3)
+		ON CONFLICT(user_id, account_id) DO UPDATE SET
+			name = excluded.name;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, role.UserID, role.AccountID, role.Name)
+	return err
+}
+
+func (sl *SQLite) DeleteUserRole(dbName, userID, accountID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_user_roles
+		WHERE user_id = $1 AND account_id = $2;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, userID, accountID)
+	return err
+}
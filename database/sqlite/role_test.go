@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestUserRole(t *testing.T) {
+	role, err := datastore.GetUserRole(confDBName, adminToken.ID, adminToken.AccountID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(role.Name) > 0 {
+		t.Fatalf("expected no custom role got %v", role)
+	}
+
+	role = model.UserRole{UserID: adminToken.ID, AccountID: adminToken.AccountID, Name: "editor"}
+	if err := datastore.SaveUserRole(confDBName, role); err != nil {
+		t.Fatal(err)
+	}
+
+	role.Name = "billing-admin"
+	if err := datastore.SaveUserRole(confDBName, role); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetUserRole(confDBName, adminToken.ID, adminToken.AccountID)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.Name != "billing-admin" {
+		t.Errorf("expected role billing-admin got %s", check.Name)
+	}
+
+	if err := datastore.DeleteUserRole(confDBName, adminToken.ID, adminToken.AccountID); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err = datastore.GetUserRole(confDBName, adminToken.ID, adminToken.AccountID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(check.Name) > 0 {
+		t.Errorf("expected the custom role to be removed got %v", check)
+	}
+}
@@ -129,6 +129,153 @@ func (sl *SQLite) createSystemTables(schema string) error {
 			created    TIMESTAMP NOT NULL,
 			UNIQUE(user_id, account_id)
 		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_settings (
+			key     TEXT PRIMARY KEY,
+			value   JSON NOT NULL,
+			updated TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_sequences (
+			name    TEXT PRIMARY KEY,
+			value   INTEGER NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_user_mfa (
+			user_id         TEXT PRIMARY KEY REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			secret          TEXT NOT NULL,
+			enabled         BOOLEAN NOT NULL,
+			recovery_codes  JSON NOT NULL,
+			last_totp_step  INTEGER NOT NULL DEFAULT 0,
+			created         TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_email_verifications (
+			user_id         TEXT PRIMARY KEY REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			email           TEXT NOT NULL,
+			verified        TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_invitations (
+			id              TEXT PRIMARY KEY,
+			account_id      TEXT NOT NULL REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+			email           TEXT NOT NULL,
+			role            INTEGER NOT NULL DEFAULT 0,
+			invited_by      TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL,
+			sent            TIMESTAMP NOT NULL,
+			expires         TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_user_roles (
+			user_id         TEXT NOT NULL REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			account_id      TEXT NOT NULL REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+			name            TEXT NOT NULL,
+			PRIMARY KEY (user_id, account_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_user_profiles (
+			user_id         TEXT PRIMARY KEY REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			public          JSON NOT NULL,
+			private         JSON NOT NULL,
+			updated         TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_user_phones (
+			user_id         TEXT PRIMARY KEY REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			number          TEXT NOT NULL UNIQUE,
+			verified        TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_guests (
+			user_id         TEXT PRIMARY KEY REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			account_id      TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL,
+			last_seen       TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS {schema}_sb_guests_last_seen_idx ON {schema}_sb_guests (last_seen);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_groups (
+			id              TEXT PRIMARY KEY,
+			account_id      TEXT NOT NULL REFERENCES {schema}_sb_accounts(id) ON DELETE CASCADE,
+			name            TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS {schema}_sb_groups_account_id_idx ON {schema}_sb_groups (account_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_group_members (
+			group_id        TEXT NOT NULL REFERENCES {schema}_sb_groups(id) ON DELETE CASCADE,
+			account_id      TEXT NOT NULL,
+			user_id         TEXT NOT NULL REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			added           TIMESTAMP NOT NULL,
+			PRIMARY KEY (group_id, user_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS {schema}_sb_group_members_user_id_idx ON {schema}_sb_group_members (user_id, account_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_audit_log (
+			id              TEXT PRIMARY KEY,
+			account_id      TEXT NOT NULL,
+			actor_id        TEXT NOT NULL,
+			actor_email     TEXT NOT NULL,
+			api_key_id      TEXT NOT NULL,
+			action          TEXT NOT NULL,
+			target          TEXT NOT NULL,
+			details         TEXT NOT NULL,
+			ip              TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS {schema}_sb_audit_log_created_idx ON {schema}_sb_audit_log (created);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_sessions (
+			id              TEXT PRIMARY KEY,
+			user_id         TEXT NOT NULL REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			account_id      TEXT NOT NULL,
+			refresh_token   TEXT NOT NULL,
+			user_agent      TEXT NOT NULL,
+			ip              TEXT NOT NULL,
+			created         TIMESTAMP NOT NULL,
+			last_seen       TIMESTAMP NOT NULL,
+			expires         TIMESTAMP NOT NULL,
+			client_id       TEXT NOT NULL DEFAULT '',
+			scope           TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS {schema}_sb_sessions_user_id_idx ON {schema}_sb_sessions (user_id);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_api_keys (
+			id              TEXT PRIMARY KEY,
+			account_id      TEXT NOT NULL,
+			user_id         TEXT NOT NULL REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			name            TEXT NOT NULL,
+			key_hash        TEXT NOT NULL,
+			scopes          JSON NOT NULL,
+			allowed_ips     JSON NOT NULL,
+			expires         TIMESTAMP NULL,
+			last_used       TIMESTAMP NULL,
+			created         TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_oauth_clients (
+			id              TEXT PRIMARY KEY,
+			name            TEXT NOT NULL,
+			secret_hash     TEXT NOT NULL,
+			redirect_uris   JSON NOT NULL,
+			public          BOOLEAN NOT NULL,
+			trusted         BOOLEAN NOT NULL,
+			created         TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS {schema}_sb_oauth_consents (
+			id              TEXT PRIMARY KEY,
+			user_id         TEXT NOT NULL REFERENCES {schema}_sb_tokens(id) ON DELETE CASCADE,
+			client_id       TEXT NOT NULL REFERENCES {schema}_sb_oauth_clients(id) ON DELETE CASCADE,
+			scopes          JSON NOT NULL,
+			created         TIMESTAMP NOT NULL,
+			UNIQUE (user_id, client_id)
+		);
 `, "{schema}", schema)
 
 	if _, err := sl.DB.Exec(qry); err != nil {
@@ -293,6 +440,7 @@ func (sl *SQLite) DeleteTenant(dbName, email string) error {
 			return err
 		}
 	}
+	sl.collections.Invalidate(dbName)
 	return nil
 }
 
@@ -0,0 +1,15 @@
+package sqlite
+
+import "fmt"
+
+func (sl *SQLite) NextSequence(dbName, name string) (n int64, err error) {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_sequences(name, value)
+		VALUES($1, 1)
+		ON CONFLICT(name) DO UPDATE SET value = value + 1
+		RETURNING value;
+	`, dbName)
+
+	err = sl.DB.QueryRow(qry, name).Scan(&n)
+	return
+}
@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) CreateSession(dbName string, s model.Session) (id string, err error) {
+	id = sl.NewID()
+
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_sessions(id, user_id, account_id, refresh_token, user_agent, ip, created, last_seen, expires, client_id, scope)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+	`, dbName)
+
+	_, err = sl.DB.Exec(qry,
+		id,
+		s.UserID,
+		s.AccountID,
+		s.RefreshToken,
+		s.UserAgent,
+		s.IP,
+		s.Created,
+		s.LastSeen,
+		s.Expires,
+		s.ClientID,
+		s.Scope,
+	)
+	return
+}
+
+func (sl *SQLite) GetSession(dbName, id string) (s model.Session, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, user_id, account_id, refresh_token, user_agent, ip, created, last_seen, expires, client_id, scope
+		FROM %s_sb_sessions
+		WHERE id = $1;
+	`, dbName)
+
+	err = scanSession(sl.DB.QueryRow(qry, id), &s)
+	return
+}
+
+func (sl *SQLite) ListSessions(dbName, userID string) (results []model.Session, err error) {
+	qry := fmt.Sprintf(`
+		SELECT id, user_id, account_id, refresh_token, user_agent, ip, created, last_seen, expires, client_id, scope
+		FROM %s_sb_sessions
+		WHERE user_id = $1
+		ORDER BY last_seen DESC;
+	`, dbName)
+
+	rows, err := sl.DB.Query(qry, userID)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var s model.Session
+		if err = scanSession(rows, &s); err != nil {
+			return
+		}
+		results = append(results, s)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) UpdateSession(dbName string, s model.Session) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s_sb_sessions SET
+			refresh_token = $2,
+			last_seen = $3,
+			expires = $4
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, s.ID, s.RefreshToken, s.LastSeen, s.Expires)
+	return err
+}
+
+func (sl *SQLite) TouchSession(dbName, id string, lastSeen time.Time) error {
+	qry := fmt.Sprintf(`
+		UPDATE %s_sb_sessions SET last_seen = $2
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, id, lastSeen)
+	return err
+}
+
+func (sl *SQLite) DeleteSession(dbName, id string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_sessions
+		WHERE id = $1;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, id)
+	return err
+}
+
+func (sl *SQLite) DeleteUserSessions(dbName, userID string) error {
+	qry := fmt.Sprintf(`
+		DELETE FROM %s_sb_sessions
+		WHERE user_id = $1;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, userID)
+	return err
+}
+
+func scanSession(rows Scanner, s *model.Session) error {
+	return rows.Scan(
+		&s.ID,
+		&s.UserID,
+		&s.AccountID,
+		&s.RefreshToken,
+		&s.UserAgent,
+		&s.IP,
+		&s.Created,
+		&s.LastSeen,
+		&s.Expires,
+		&s.ClientID,
+		&s.Scope,
+	)
+}
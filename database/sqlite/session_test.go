@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestSessions(t *testing.T) {
+	now := time.Now()
+
+	s := model.Session{
+		UserID:       adminToken.ID,
+		AccountID:    adminToken.AccountID,
+		RefreshToken: "hash-1",
+		UserAgent:    "unit test",
+		IP:           "127.0.0.1",
+		Created:      now,
+		LastSeen:     now,
+		Expires:      now.Add(24 * time.Hour),
+		ClientID:     "client-1",
+		Scope:        "openid email",
+	}
+
+	id, err := datastore.CreateSession(confDBName, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.LastSeen = now.Add(time.Minute)
+	otherID, err := datastore.CreateSession(confDBName, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.ID = id
+	s.RefreshToken = "hash-2"
+	s.LastSeen = now.Add(2 * time.Minute)
+	if err := datastore.UpdateSession(confDBName, s); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := datastore.GetSession(confDBName, id)
+	if err != nil {
+		t.Fatal(err)
+	} else if check.RefreshToken != "hash-2" {
+		t.Errorf("expected refresh token hash-2 got %s", check.RefreshToken)
+	} else if check.UserAgent != "unit test" {
+		t.Errorf("expected user agent unit test got %s", check.UserAgent)
+	} else if check.ClientID != "client-1" || check.Scope != "openid email" {
+		t.Errorf("expected client client-1 with scope openid email got %s %s", check.ClientID, check.Scope)
+	}
+
+	sessions, err := datastore.ListSessions(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions got %d", len(sessions))
+	} else if sessions[0].ID != id {
+		t.Errorf("expected most recently seen session %s first got %s", id, sessions[0].ID)
+	}
+
+	if err := datastore.TouchSession(confDBName, otherID, now.Add(3*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err = datastore.ListSessions(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if sessions[0].ID != otherID {
+		t.Errorf("expected touched session %s first got %s", otherID, sessions[0].ID)
+	}
+
+	if err := datastore.DeleteSession(confDBName, otherID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := datastore.GetSession(confDBName, otherID); err == nil {
+		t.Error("expected deleted session to be not found")
+	}
+
+	if err := datastore.DeleteUserSessions(confDBName, adminToken.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err = datastore.ListSessions(confDBName, adminToken.ID)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(sessions) != 0 {
+		t.Errorf("expected no sessions got %d", len(sessions))
+	}
+}
@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (sl *SQLite) GetSetting(dbName, key string) (value []byte, err error) {
+	qry := fmt.Sprintf(`
+		SELECT value
+		FROM %s_sb_settings
+		WHERE key = $1;
+	`, dbName)
+
+	var s string
+	if err = sl.DB.QueryRow(qry, key).Scan(&s); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return
+	}
+
+	value = []byte(s)
+	return
+}
+
+func (sl *SQLite) SetSetting(dbName, key string, value []byte) error {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_settings(key, value, updated)
+		VALUES($1, $2, $3)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated = excluded.updated;
+	`, dbName)
+
+	_, err := sl.DB.Exec(qry, key, string(value), time.Now())
+	return err
+}
+
+func (sl *SQLite) AddSetting(dbName, key string, value []byte) (bool, error) {
+	qry := fmt.Sprintf(`
+		INSERT INTO %s_sb_settings(key, value, updated)
+		VALUES($1, $2, $3)
+		ON CONFLICT(key) DO NOTHING;
+	`, dbName)
+
+	res, err := sl.DB.Exec(qry, key, string(value), time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
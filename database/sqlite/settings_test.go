@@ -0,0 +1,50 @@
+package sqlite
+
+import "testing"
+
+func TestGetSettingNotSet(t *testing.T) {
+	b, err := datastore.GetSetting(confDBName, "never_set")
+	if err != nil {
+		t.Fatal(err)
+	} else if b != nil {
+		t.Errorf("expected nil value got %s", string(b))
+	}
+}
+
+func TestSetSetting(t *testing.T) {
+	if err := datastore.SetSetting(confDBName, "unittest", []byte(`"value-1"`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := datastore.SetSetting(confDBName, "unittest", []byte(`"value-2"`)); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := datastore.GetSetting(confDBName, "unittest")
+	if err != nil {
+		t.Fatal(err)
+	} else if string(b) != `"value-2"` {
+		t.Errorf(`expected "value-2" got %s`, string(b))
+	}
+}
+
+func TestAddSetting(t *testing.T) {
+	if added, err := datastore.AddSetting(confDBName, "unittest_add", []byte(`"value-1"`)); err != nil {
+		t.Fatal(err)
+	} else if !added {
+		t.Fatal("expected the setting to be added")
+	}
+
+	if added, err := datastore.AddSetting(confDBName, "unittest_add", []byte(`"value-2"`)); err != nil {
+		t.Fatal(err)
+	} else if added {
+		t.Error("expected an existing setting not to be replaced")
+	}
+
+	b, err := datastore.GetSetting(confDBName, "unittest_add")
+	if err != nil {
+		t.Fatal(err)
+	} else if string(b) != `"value-1"` {
+		t.Errorf(`expected "value-1" got %s`, string(b))
+	}
+}
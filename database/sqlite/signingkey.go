@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"github.com/staticbackendhq/core/model"
+)
+
+func (sl *SQLite) ListSigningKeys() (results []model.SigningKey, err error) {
+	rows, err := sl.DB.Query(`
+		SELECT id, algorithm, private_key, created
+		FROM sb_signing_keys
+		ORDER BY created ASC;
+	`)
+	if err != nil {
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var k model.SigningKey
+		if err = rows.Scan(&k.ID, &k.Algorithm, &k.PrivateKey, &k.Created); err != nil {
+			return
+		}
+		results = append(results, k)
+	}
+	err = rows.Err()
+	return
+}
+
+func (sl *SQLite) AddSigningKey(k model.SigningKey) error {
+	_, err := sl.DB.Exec(`
+		INSERT INTO sb_signing_keys(id, algorithm, private_key, created)
+		VALUES($1, $2, $3, $4);
+	`, k.ID, k.Algorithm, k.PrivateKey, k.Created)
+	return err
+}
+
+func (sl *SQLite) DeleteSigningKey(id string) error {
+	_, err := sl.DB.Exec(`DELETE FROM sb_signing_keys WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,4 @@
+-- v5: add per-app settings table
+-- actual DDL is applied programmatically in migration.go:migrateAddSettings
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
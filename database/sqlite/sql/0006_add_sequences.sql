@@ -0,0 +1,4 @@
+-- v6: add per-app sequences table
+-- actual DDL is applied programmatically in migration.go:migrateAddSequences
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
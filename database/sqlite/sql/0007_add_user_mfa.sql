@@ -0,0 +1,4 @@
+-- v7: add per-app user MFA table
+-- actual DDL is applied programmatically in migration.go:migrateAddUserMFA
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
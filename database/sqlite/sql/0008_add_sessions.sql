@@ -0,0 +1,4 @@
+-- v8: add per-app sessions table
+-- actual DDL is applied programmatically in migration.go:migrateAddSessions
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
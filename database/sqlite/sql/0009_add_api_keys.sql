@@ -0,0 +1,4 @@
+-- v9: add per-app API keys table
+-- actual DDL is applied programmatically in migration.go:migrateAddAPIKeys
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
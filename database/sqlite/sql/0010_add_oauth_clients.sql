@@ -0,0 +1,4 @@
+-- v10: add per-app OAuth clients and consents tables
+-- actual DDL is applied programmatically in migration.go:migrateAddOAuthClients
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS sb_signing_keys (
+	id TEXT PRIMARY KEY,
+	algorithm TEXT NOT NULL,
+	private_key TEXT NOT NULL,
+	created TIMESTAMP NOT NULL
+);
@@ -0,0 +1,4 @@
+-- v12: add per-app email verifications table
+-- actual DDL is applied programmatically in migration.go:migrateAddEmailVerifications
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
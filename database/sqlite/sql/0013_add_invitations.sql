@@ -0,0 +1,4 @@
+-- v13: add per-app invitations table
+-- actual DDL is applied programmatically in migration.go:migrateAddInvitations
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
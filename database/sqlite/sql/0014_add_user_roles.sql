@@ -0,0 +1,4 @@
+-- v14: add per-app custom user roles table
+-- actual DDL is applied programmatically in migration.go:migrateAddUserRoles
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
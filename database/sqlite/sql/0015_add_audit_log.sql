@@ -0,0 +1,4 @@
+-- v15: add per-app audit log table
+-- actual DDL is applied programmatically in migration.go:migrateAddAuditLog
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
@@ -0,0 +1,4 @@
+-- v16: add per-app user profiles table
+-- actual DDL is applied programmatically in migration.go:migrateAddUserProfiles
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
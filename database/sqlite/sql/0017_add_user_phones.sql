@@ -0,0 +1,4 @@
+-- v17: add per-app user phones table
+-- actual DDL is applied programmatically in migration.go:migrateAddUserPhones
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
@@ -0,0 +1,4 @@
+-- v18: add per-app guests table
+-- actual DDL is applied programmatically in migration.go:migrateAddGuests
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
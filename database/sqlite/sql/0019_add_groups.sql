@@ -0,0 +1,4 @@
+-- v19: add per-app account groups tables
+-- actual DDL is applied programmatically in migration.go:migrateAddGroups
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
@@ -0,0 +1,4 @@
+-- v20: add the last accepted TOTP step to the per-app user MFA table
+-- actual DDL is applied programmatically in migration.go:migrateAddMFALastStep
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
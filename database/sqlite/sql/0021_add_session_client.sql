@@ -0,0 +1,4 @@
+-- v21: add the OAuth client and scope to the per-app sessions table
+-- actual DDL is applied programmatically in migration.go:migrateAddSessionClient
+-- because SQLite has no dynamic SQL for iterating app schemas
+SELECT 1;
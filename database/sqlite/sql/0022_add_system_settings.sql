@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS sb_sb_settings (
+	key     TEXT PRIMARY KEY,
+	value   JSON NOT NULL,
+	updated TIMESTAMP NOT NULL
+);
@@ -17,7 +17,7 @@ type SQLite struct {
 	DB              *sql.DB
 	PublishDocument cache.PublishDocumentEvent
 
-	collections map[string]bool
+	collections *database.CollectionRegistry
 }
 
 func New(db *sql.DB, pubdoc cache.PublishDocumentEvent) database.Persister {
@@ -33,7 +33,7 @@ func New(db *sql.DB, pubdoc cache.PublishDocumentEvent) database.Persister {
 	return &SQLite{
 		DB:              db,
 		PublishDocument: pubdoc,
-		collections:     make(map[string]bool),
+		collections:     database.NewCollectionRegistry(),
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/database"
 	"github.com/staticbackendhq/core/model"
 	_ "modernc.org/sqlite"
 )
@@ -45,7 +46,7 @@ func TestMain(m *testing.M) {
 	}
 	defer func() { _ = dbConn.Close() }()
 
-	datastore = &SQLite{DB: dbConn, PublishDocument: fakePubDocEvent, collections: make(map[string]bool)}
+	datastore = &SQLite{DB: dbConn, PublishDocument: fakePubDocEvent, collections: database.NewCollectionRegistry()}
 
 	if err := datastore.Ping(); err != nil {
 		log.Fatal(err)
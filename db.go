@@ -3,6 +3,7 @@ package staticbackend
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/url"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/staticbackendhq/core/backend"
 	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/config"
 	dbpkg "github.com/staticbackendhq/core/database"
 	"github.com/staticbackendhq/core/middleware"
 	"github.com/staticbackendhq/core/model"
@@ -60,6 +62,14 @@ func (database *Database) dbreq(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// writeErrorStatus returns the status of a failed document write
+func writeErrorStatus(err error) int {
+	if errors.Is(err, model.ErrNotGroupMember) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
 func (database *Database) add(w http.ResponseWriter, r *http.Request) {
 	conf, auth, err := middleware.Extract(r, true)
 	if err != nil {
@@ -83,7 +93,7 @@ func (database *Database) add(w http.ResponseWriter, r *http.Request) {
 
 	doc, err = backend.DB.CreateDocument(auth, conf.Name, col, doc)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), writeErrorStatus(err))
 		return
 	}
 
@@ -106,7 +116,7 @@ func (database *Database) bulkAdd(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := backend.DB.BulkCreateDocument(auth, conf.Name, col, v); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), writeErrorStatus(err))
 		return
 	}
 
@@ -227,6 +237,21 @@ func (database *Database) query(w http.ResponseWriter, r *http.Request) {
 
 	col := getURLPart(r.URL.Path, 2)
 
+	// the search mode combines full-text keywords with the filters
+	if keywords := r.URL.Query().Get("search"); len(keywords) > 0 {
+		result, err := backend.SearchDocuments(auth, conf.Name, col, keywords, filter, params)
+		if errors.Is(err, backend.ErrSearchNotEnabled) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, result)
+		return
+	}
+
 	result, err := backend.DB.QueryDocuments(auth, conf.Name, col, filter, params)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -289,7 +314,7 @@ func (database *Database) update(w http.ResponseWriter, r *http.Request) {
 
 	result, err := backend.DB.UpdateDocument(auth, conf.Name, col, id, doc)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), writeErrorStatus(err))
 		return
 	}
 
@@ -322,7 +347,7 @@ func (database *Database) bulkUpdate(w http.ResponseWriter, r *http.Request) {
 
 	count, err := backend.DB.UpdateDocuments(auth, conf.Name, col, filter, v.UpdateFields)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), writeErrorStatus(err))
 		return
 	}
 
@@ -484,6 +509,140 @@ func (database *Database) index(w http.ResponseWriter, r *http.Request) {
 	respond(w, http.StatusOK, true)
 }
 
+type EncryptedFieldsData struct {
+	Col    string   `json:"col"`
+	Fields []string `json:"fields"`
+}
+
+func (database *Database) encryption(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		fe, err := backend.GetFieldEncryption(conf.Name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, fe)
+	case http.MethodPost:
+		var data EncryptedFieldsData
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if len(data.Col) == 0 {
+			http.Error(w, "col is required", http.StatusBadRequest)
+			return
+		}
+
+		fe, err := backend.SetEncryptedFields(auth, conf, data.Col, data.Fields)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, fe)
+	default:
+		http.Error(w, "method not implemented", http.StatusNotImplemented)
+	}
+}
+
+type FieldDefaultsData struct {
+	Col      string               `json:"col"`
+	Defaults []model.FieldDefault `json:"defaults"`
+}
+
+func (database *Database) fieldDefaults(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		fd, err := backend.GetFieldDefaults(conf.Name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, fd)
+	case http.MethodPost:
+		var data FieldDefaultsData
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if len(data.Col) == 0 {
+			http.Error(w, "col is required", http.StatusBadRequest)
+			return
+		}
+
+		fd, err := backend.SetFieldDefaults(conf.Name, data.Col, data.Defaults)
+		if errors.Is(err, model.ErrInvalidFieldDefault) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, fd)
+	default:
+		http.Error(w, "method not implemented", http.StatusNotImplemented)
+	}
+}
+
+func (database *Database) rotateEncryptionKey(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not implemented", http.StatusNotImplemented)
+		return
+	}
+
+	n, err := backend.RotateFieldEncryptionKey(auth, conf)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, n)
+}
+
+func (database *Database) poolStats(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the pools are shared by every tenant of the instance
+	if len(config.Current.AdminPublicKey) == 0 || conf.ID != config.Current.AdminPublicKey {
+		http.Error(w, "only the admin database can view the pool statistics", http.StatusForbidden)
+		return
+	}
+
+	stats := backend.DatabasePoolStats()
+	if stats == nil {
+		stats = []dbpkg.PoolStats{}
+	}
+	respond(w, http.StatusOK, stats)
+}
+
 func getPagination(u *url.URL) (page int64, size int64) {
 	var err error
 
@@ -14,6 +14,8 @@ import (
 	"time"
 
 	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/config"
+	dbpkg "github.com/staticbackendhq/core/database"
 	"github.com/staticbackendhq/core/internal"
 	"github.com/staticbackendhq/core/middleware"
 	"github.com/staticbackendhq/core/model"
@@ -633,6 +635,90 @@ func TestDBSearchIndexAndQuery(t *testing.T) {
 	}
 }
 
+func TestDBQueryWithSearch(t *testing.T) {
+	invoices := []Task{
+		{Title: "invoice unpaid first", Done: false, Count: 1},
+		{Title: "invoice unpaid second", Done: false, Count: 2},
+		{Title: "invoice paid", Done: true, Count: 3},
+		{Title: "receipt unpaid", Done: false, Count: 4},
+	}
+
+	for _, invoice := range invoices {
+		resp := dbReq(t, db.add, "POST", "/db/invoices", invoice)
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode > 299 {
+			t.Fatal(GetResponseBody(t, resp))
+		}
+
+		var created Task
+		if err := parseBody(resp.Body, &created); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := backend.Search.Index(dbName, "invoices", created.ID, created.Title); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clauses := [][]any{{"done", "=", false}}
+	resp := dbReq(t, db.query, "POST", "/query/invoices?search=invoice&sort=count&desc=1&page=1&size=1", clauses)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var result model.PagedResult
+	if err := parseBody(resp.Body, &result); err != nil {
+		t.Fatal(err)
+	} else if result.Total != 2 {
+		t.Fatalf("expected 2 unpaid invoices got %d", result.Total)
+	} else if len(result.Results) != 1 {
+		t.Fatalf("expected 1 result on the page got %d", len(result.Results))
+	} else if result.Results[0]["title"] != "invoice unpaid second" {
+		t.Errorf("expected the invoice with the highest count got %v", result.Results[0]["title"])
+	}
+
+	id := fmt.Sprintf("%v", result.Results[0]["id"])
+	if score, ok := result.Scores[id]; !ok || score <= 0 {
+		t.Errorf("expected a relevance score for %s got %v", id, result.Scores)
+	}
+
+	resp = dbReq(t, db.query, "POST", "/query/invoices?search=invoice%20unpaid", clauses)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var ranked model.PagedResult
+	if err := parseBody(resp.Body, &ranked); err != nil {
+		t.Fatal(err)
+	} else if ranked.Total != 2 || len(ranked.Results) != 2 {
+		t.Fatalf("expected 2 unpaid invoices got %d", ranked.Total)
+	}
+
+	first, second := fmt.Sprintf("%v", ranked.Results[0]["id"]), fmt.Sprintf("%v", ranked.Results[1]["id"])
+	if ranked.Scores[first] < ranked.Scores[second] {
+		t.Errorf("expected results sorted by relevance got %v", ranked.Scores)
+	}
+
+	// documents from another account are not visible
+	otherAuth := model.Auth{AccountID: "other-account", UserID: "other-user", Role: 0}
+	filter, err := backend.DB.ParseQuery(clauses)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := backend.SearchDocuments(otherAuth, dbName, "invoices", "invoice", filter, model.ListParams{Page: 1, Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	} else if other.Total != 0 {
+		t.Errorf("expected no visible invoices for another account got %d", other.Total)
+	}
+}
+
 func TestGetPaginationSanitizesValues(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -665,3 +751,130 @@ func TestGetPaginationSanitizesValues(t *testing.T) {
 		})
 	}
 }
+
+func TestDBEncryptedFields(t *testing.T) {
+	data := EncryptedFieldsData{Col: "patients", Fields: []string{"ssn"}}
+	resp := dbReq(t, db.encryption, "POST", "/sudo/encryption", data, true)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	doc := map[string]any{"name": "encrypted", "ssn": "123-45-6789"}
+	resp = dbReq(t, db.add, "POST", "/db/patients", doc)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var saved map[string]any
+	if err := parseBody(resp.Body, &saved); err != nil {
+		t.Fatal(err)
+	} else if saved["ssn"] != "123-45-6789" {
+		t.Errorf("expected decrypted ssn got %v", saved["ssn"])
+	}
+
+	clauses := [][]any{{"ssn", "=", "123-45-6789"}}
+	resp = dbReq(t, db.query, "POST", "/query/patients", clauses)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var result model.PagedResult
+	if err := parseBody(resp.Body, &result); err != nil {
+		t.Fatal(err)
+	} else if result.Total != 1 {
+		t.Fatalf("expected 1 result got %d", result.Total)
+	} else if result.Results[0]["ssn"] != "123-45-6789" {
+		t.Errorf("expected decrypted ssn got %v", result.Results[0]["ssn"])
+	} else if _, ok := result.Results[0][model.BlindIndexPrefix+"ssn"]; ok {
+		t.Error("expected blind index to be removed from results")
+	}
+
+	resp = dbReq(t, db.rotateEncryptionKey, "POST", "/sudo/encryption/rotate", nil, true)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var n int64
+	if err := parseBody(resp.Body, &n); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Errorf("expected 1 document to be re-encrypted got %d", n)
+	}
+}
+
+func TestDBFieldDefaults(t *testing.T) {
+	data := FieldDefaultsData{
+		Col: "articles",
+		Defaults: []model.FieldDefault{
+			{Field: "status", Value: "draft"},
+			{Field: "slug", Generator: model.GeneratorSlugify, From: "title"},
+		},
+	}
+	resp := dbReq(t, db.fieldDefaults, "POST", "/sudo/defaults", data, true)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	doc := map[string]any{"title": "My First Article"}
+	resp = dbReq(t, db.add, "POST", "/db/articles", doc)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var saved map[string]any
+	if err := parseBody(resp.Body, &saved); err != nil {
+		t.Fatal(err)
+	} else if saved["status"] != "draft" {
+		t.Errorf("expected status draft got %v", saved["status"])
+	} else if saved["slug"] != "my-first-article" {
+		t.Errorf("expected slug my-first-article got %v", saved["slug"])
+	} else if _, ok := saved["sb_updated"]; !ok {
+		t.Error("expected sb_updated to be set")
+	}
+
+	invalid := FieldDefaultsData{Col: "articles", Defaults: []model.FieldDefault{{Field: "status"}}}
+	resp = dbReq(t, db.fieldDefaults, "POST", "/sudo/defaults", invalid, true)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 got %d", resp.StatusCode)
+	}
+}
+
+func TestDBPoolStats(t *testing.T) {
+	resp := dbReq(t, db.poolStats, "GET", "/sudo/dbstats", nil, true)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403 without an admin database got %d", resp.StatusCode)
+	}
+
+	config.Current.AdminPublicKey = pubKey
+	t.Cleanup(func() { config.Current.AdminPublicKey = "" })
+
+	resp = dbReq(t, db.poolStats, "GET", "/sudo/dbstats", nil, true)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var stats []dbpkg.PoolStats
+	if err := parseBody(resp.Body, &stats); err != nil {
+		t.Fatal(err)
+	} else if len(stats) != 0 {
+		t.Errorf("expected no pool stats for the memory data store got %d", len(stats))
+	}
+}
@@ -0,0 +1,125 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// verifyEmail validates the code of a verification email, GET is used by
+// the default link of the email.
+func (m *membership) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	var data = new(struct {
+		Email string `json:"email"`
+		Code  string `json:"code"`
+	})
+
+	switch r.Method {
+	case http.MethodGet:
+		data.Email = r.URL.Query().Get("email")
+		data.Code = r.URL.Query().Get("code")
+	case http.MethodPost:
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mship := backend.Membership(conf)
+	if err := mship.VerifyEmail(data.Email, data.Code); err != nil {
+		if errors.Is(err, backend.ErrInvalidVerificationCode) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if errors.Is(err, backend.ErrEmailAlreadyInUse) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, true)
+}
+
+func (m *membership) resendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	var data = new(struct {
+		Email string `json:"email"`
+	})
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+	if err := mship.ResendEmailVerification(data.Email); err != nil {
+		if errors.Is(err, backend.ErrTooManyVerificationEmails) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, true)
+}
+
+func (m *membership) sudoEmailVerificationPolicy(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := mship.GetEmailVerificationPolicy()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	case http.MethodPost:
+		var policy model.EmailVerificationPolicy
+		if err := parseBody(r.Body, &policy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.SetEmailVerificationPolicy(policy); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	default:
+		http.Error(w, "method not implemented", http.StatusNotImplemented)
+	}
+}
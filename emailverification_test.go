@@ -0,0 +1,118 @@
+package staticbackend
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+func setEmailVerificationPolicy(t *testing.T, policy model.EmailVerificationPolicy) {
+	t.Helper()
+
+	resp := dbReq(t, mship.sudoEmailVerificationPolicy, "POST", "/sudo/emailverification", policy, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+}
+
+func TestEmailVerificationRegister(t *testing.T) {
+	setEmailVerificationPolicy(t, model.EmailVerificationPolicy{Required: true})
+	t.Cleanup(func() { setEmailVerificationPolicy(t, model.EmailVerificationPolicy{}) })
+
+	email := "verify-register@test.com"
+	login := model.Login{Email: email, Password: userPassword}
+
+	resp := dbReq(t, mship.register, "POST", "/register", login)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected the registration to wait for verification got %s", GetResponseBody(t, resp))
+	}
+	t.Cleanup(func() {
+		if tok, err := backend.DB.FindUserByEmail(dbName, email); err == nil {
+			_ = backend.DB.RemoveUser(model.Auth{AccountID: tok.AccountID, UserID: tok.ID, Role: 100}, dbName, tok.ID)
+		}
+	})
+
+	resp = dbReq(t, mship.login, "POST", "/login", login)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected unverified users to be refused got %d", resp.StatusCode)
+	}
+
+	resend := map[string]string{"email": email}
+	resp = dbReq(t, mship.resendEmailVerification, "POST", "/email/verify/resend", resend)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected an immediate resend to be rate limited got %d", resp.StatusCode)
+	}
+
+	qs := url.Values{"email": {email}, "code": {"wrong"}}
+	resp = dbReq(t, mship.verifyEmail, "GET", "/email/verify?"+qs.Encode(), nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an invalid code to be refused got %d", resp.StatusCode)
+	}
+
+	// in dev mode, the code is always 666333
+	qs.Set("code", "666333")
+	resp = dbReq(t, mship.verifyEmail, "GET", "/email/verify?"+qs.Encode(), nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, mship.login, "POST", "/login", login)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var token string
+	if err := parseBody(resp.Body, &token); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = authReqWithToken(t, token, mship.me, "GET", "/me", nil)
+	defer func() { _ = resp.Body.Close() }()
+
+	var me model.Auth
+	if err := parseBody(resp.Body, &me); err != nil {
+		t.Fatal(err)
+	} else if !me.EmailVerified {
+		t.Errorf("expected the user to be verified got %v", me)
+	}
+}
+
+func TestEmailVerificationLimitsUnverifiedUsers(t *testing.T) {
+	setEmailVerificationPolicy(t, model.EmailVerificationPolicy{Required: true, AllowLogin: true})
+	t.Cleanup(func() { setEmailVerificationPolicy(t, model.EmailVerificationPolicy{}) })
+
+	scope := middleware.RouteScope{Scope: model.APIKeyScopeCollections, NamePart: 2}
+
+	task := Task{Title: "unverified", Created: time.Now()}
+	resp := apiKeyReq(t, userToken, &scope, db.add, "POST", "/db/tasks", task)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected unverified users to be refused writes got %d", resp.StatusCode)
+	}
+
+	resp = apiKeyReq(t, userToken, &scope, db.list, "GET", "/db/tasks", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected unverified users to read got %s", GetResponseBody(t, resp))
+	}
+
+	setEmailVerificationPolicy(t, model.EmailVerificationPolicy{Required: true, AllowLogin: true, AllowWrite: true})
+
+	resp = apiKeyReq(t, userToken, &scope, db.add, "POST", "/db/tasks", task)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Errorf("expected writes to be allowed by the policy got %s", GetResponseBody(t, resp))
+	}
+}
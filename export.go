@@ -0,0 +1,162 @@
+package staticbackend
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+func exportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, backend.ErrExportNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, backend.ErrExportPending), errors.Is(err, backend.ErrExportNotReady):
+		return http.StatusConflict
+	case errors.Is(err, backend.ErrExportScope):
+		return http.StatusBadRequest
+	case errors.Is(err, backend.ErrInvalidExport):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// exports starts an export of the current user's data with POST /me/exports,
+// account owners export their whole account, files included, with
+// ?scope=account. The status and download link of an export are returned by
+// GET /me/exports/{id}.
+func (m *membership) exports(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		e, err := mship.GetExport(getURLPart(r.URL.Path, 3))
+		if err != nil {
+			http.Error(w, err.Error(), exportErrorStatus(err))
+			return
+		}
+
+		owned := e.UserID == auth.UserID
+		if e.Scope == model.DataExportScopeAccount {
+			owned = e.AccountID == auth.AccountID && auth.Role >= 50
+		}
+		if !owned {
+			http.Error(w, backend.ErrExportNotFound.Error(), http.StatusNotFound)
+			return
+		}
+
+		respond(w, http.StatusOK, e)
+	case http.MethodPost:
+		scope := r.URL.Query().Get("scope")
+		if len(scope) == 0 {
+			scope = model.DataExportScopeUser
+		}
+
+		if scope == model.DataExportScopeAccount && auth.Role < 50 {
+			http.Error(w, "insufficient priviledges", http.StatusUnauthorized)
+			return
+		}
+
+		e, err := mship.StartExport(scope, auth.AccountID, auth.UserID)
+		if err != nil {
+			http.Error(w, err.Error(), exportErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusAccepted, e)
+	default:
+		http.Error(w, "method not implemented", http.StatusNotImplemented)
+	}
+}
+
+// sudoExports lists the exports with GET /sudo/exports or returns one with
+// GET /sudo/exports/{id}, POST /sudo/exports starts the export of any user
+// or account
+func (m *membership) sudoExports(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		if id := getURLPart(r.URL.Path, 3); len(id) > 0 {
+			e, err := mship.GetExport(id)
+			if err != nil {
+				http.Error(w, err.Error(), exportErrorStatus(err))
+				return
+			}
+
+			respond(w, http.StatusOK, e)
+			return
+		}
+
+		exports, err := mship.ListExports()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, exports)
+	case http.MethodPost:
+		var data model.DataExportRequest
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if len(data.AccountID) == 0 {
+			http.Error(w, "missing account id", http.StatusBadRequest)
+			return
+		}
+
+		e, err := mship.StartExport(data.Scope, data.AccountID, data.UserID)
+		if err != nil {
+			http.Error(w, err.Error(), exportErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusAccepted, e)
+	default:
+		http.Error(w, "method not implemented", http.StatusNotImplemented)
+	}
+}
+
+// downloadExport streams the archive of a signed export download link
+func (m *membership) downloadExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	e, rc, err := backend.Membership(conf).OpenExport(r.URL.Query().Get("token"))
+	if err != nil {
+		http.Error(w, err.Error(), exportErrorStatus(err))
+		return
+	}
+	defer func() { _ = rc.Close() }()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="export-`+e.ID+`.zip"`)
+	w.Header().Set("Content-Length", strconv.FormatInt(e.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, rc)
+}
@@ -0,0 +1,105 @@
+package staticbackend
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestDataExportDownload(t *testing.T) {
+	resp := authReqWithToken(t, userToken, mship.exports, "POST", "/me/exports", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var e model.DataExport
+	if err := parseBody(resp.Body, &e); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100 && e.Status == model.DataExportPending; i++ {
+		time.Sleep(20 * time.Millisecond)
+
+		resp := authReqWithToken(t, userToken, mship.exports, "GET", "/me/exports/"+e.ID, nil)
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatal(GetResponseBody(t, resp))
+		}
+		if err := parseBody(resp.Body, &e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if e.Status != model.DataExportReady {
+		t.Fatalf("expected the export to be ready got %v", e)
+	}
+
+	// other users do not see the export
+	resp = dbReq(t, mship.exports, "GET", "/me/exports/"+e.ID, nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for another user got %d", resp.StatusCode)
+	}
+
+	link, err := url.Parse(e.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = dbReq(t, mship.downloadExport, "GET", "/exports/download?token=invalid.link", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for an invalid link got %d", resp.StatusCode)
+	}
+
+	resp = dbReq(t, mship.downloadExport, "GET", link.RequestURI(), nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := zip.NewReader(bytes.NewReader(b), int64(len(b))); err != nil {
+		t.Errorf("expected a zip archive got %v", err)
+	}
+}
+
+func TestSudoDataExport(t *testing.T) {
+	resp := dbReq(t, mship.sudoExports, "POST", "/sudo/exports", model.DataExportRequest{Scope: model.DataExportScopeAccount, AccountID: testAccountID}, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var e model.DataExport
+	if err := parseBody(resp.Body, &e); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = dbReq(t, mship.sudoExports, "GET", "/sudo/exports", nil, true)
+	defer func() { _ = resp.Body.Close() }()
+
+	var exports []model.DataExport
+	if err := parseBody(resp.Body, &exports); err != nil {
+		t.Fatal(err)
+	} else if len(exports) == 0 || exports[0].ID != e.ID {
+		t.Errorf("expected the export %s to be listed first got %v", e.ID, exports)
+	}
+
+	resp = dbReq(t, mship.sudoExports, "POST", "/sudo/exports", model.DataExportRequest{Scope: "all", AccountID: testAccountID}, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid scope got %d", resp.StatusCode)
+	}
+}
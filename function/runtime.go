@@ -59,6 +59,9 @@ func (env *ExecutionEnvironment) Execute(data interface{}) error {
 	if err := env.addSecrets(vm); err != nil {
 		return err
 	}
+	if err := env.addClaims(vm); err != nil {
+		return err
+	}
 
 	if _, err := vm.RunString(env.Data.Code); err != nil {
 		return err
@@ -111,6 +114,23 @@ func (env *ExecutionEnvironment) addSecrets(vm *goja.Runtime) error {
 	return err
 }
 
+// addClaims exposes the custom claims of the caller's session token as the
+// read-only claims object
+func (env *ExecutionEnvironment) addClaims(vm *goja.Runtime) error {
+	obj := vm.NewObject()
+	for key, value := range env.Auth.Claims {
+		if err := obj.Set(key, value); err != nil {
+			return err
+		}
+	}
+
+	if err := vm.Set("claims", obj); err != nil {
+		return err
+	}
+	_, err := vm.RunString("Object.freeze(claims)")
+	return err
+}
+
 func (env *ExecutionEnvironment) prepareArguments(vm *goja.Runtime, data interface{}) ([]goja.Value, error) {
 	var args []goja.Value
 
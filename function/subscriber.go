@@ -65,7 +65,9 @@ func (sub *Subscriber) process(msg model.Command) {
 		model.MsgTypeDBCreated,
 		model.MsgTypeDBUpdated,
 		model.MsgTypeDBDeleted,
-		model.MsgTypeTelemetryLongRequest:
+		model.MsgTypeTelemetryLongRequest,
+		model.MsgTypeInviteSent,
+		model.MsgTypeInviteAccepted:
 		sub.handleRealtimeEvents(msg, &wg)
 	default:
 		// for user triggered events, we enforce a max of 5 msg / 60 secs
@@ -0,0 +1,141 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+func groupErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, backend.ErrGroupNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, backend.ErrGroupName),
+		errors.Is(err, backend.ErrNotAccountMember):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// groups lists the groups of the account and creates a group with POST
+func (a *accounts) groups(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		groups, err := mship.ListGroups(auth.AccountID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, groups)
+	case http.MethodPost:
+		if !auth.CanManageUsers() {
+			http.Error(w, "insufficient priviledges", http.StatusUnauthorized)
+			return
+		}
+
+		var data model.Group
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		g, err := mship.CreateGroup(auth.AccountID, data.Name)
+		if err != nil {
+			http.Error(w, err.Error(), groupErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusCreated, g)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// group deletes a group with DELETE /account/groups/{id}, lists its members
+// with GET /account/groups/{id}/members, adds a member with
+// POST /account/groups/{id}/members and removes one with
+// DELETE /account/groups/{id}/members/{userId}
+func (a *accounts) group(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	id := getURLPart(r.URL.Path, 3)
+	if len(id) == 0 {
+		http.Error(w, "missing group id", http.StatusBadRequest)
+		return
+	}
+
+	members := getURLPart(r.URL.Path, 4) == "members"
+	userID := getURLPart(r.URL.Path, 5)
+
+	// members can list the members of their groups
+	if !auth.CanManageUsers() && !(r.Method == http.MethodGet && members && auth.InGroup(id)) {
+		http.Error(w, "insufficient priviledges", http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch {
+	case r.Method == http.MethodDelete && !members:
+		if err := mship.DeleteGroup(auth.AccountID, id); err != nil {
+			http.Error(w, err.Error(), groupErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	case r.Method == http.MethodGet && members:
+		list, err := mship.ListGroupMembers(auth.AccountID, id)
+		if err != nil {
+			http.Error(w, err.Error(), groupErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, list)
+	case r.Method == http.MethodPost && members:
+		var data model.GroupMember
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if len(data.UserID) == 0 {
+			http.Error(w, "missing user id", http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.AddGroupMember(auth.AccountID, id, data.UserID); err != nil {
+			http.Error(w, err.Error(), groupErrorStatus(err))
+			return
+		}
+
+		audit(r, model.AuditActionGroupAdd, data.UserID, "group "+id)
+
+		respond(w, http.StatusOK, true)
+	case r.Method == http.MethodDelete && members && len(userID) > 0:
+		if err := mship.RemoveGroupMember(auth.AccountID, id, userID); err != nil {
+			http.Error(w, err.Error(), groupErrorStatus(err))
+			return
+		}
+
+		audit(r, model.AuditActionGroupRemove, userID, "group "+id)
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
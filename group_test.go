@@ -0,0 +1,96 @@
+package staticbackend
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestGroupScopedDocuments(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memberToken, member, err := backend.Membership(conf).CreateUser(testAccountID, "group-member@test.com", userPassword, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherToken, _, err := backend.Membership(conf).CreateUser(testAccountID, "group-other@test.com", userPassword, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := authReqWithToken(t, string(memberToken), acct.groups, "POST", "/account/groups", model.Group{Name: "Denied"})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a role 0 user got %d", resp.StatusCode)
+	}
+
+	resp = dbReq(t, acct.groups, "POST", "/account/groups", model.Group{Name: "Field team"})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var g model.Group
+	if err := parseBody(resp.Body, &g); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = dbReq(t, acct.group, "POST", "/account/groups/"+g.ID+"/members", model.GroupMember{UserID: member.ID})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = authReqWithToken(t, string(otherToken), db.add, "POST", "/db/group_notes", map[string]any{"title": "denied", model.FieldGroupID: g.ID})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for a user outside the group got %d", resp.StatusCode)
+	}
+
+	resp = authReqWithToken(t, string(memberToken), db.add, "POST", "/db/group_notes", map[string]any{"title": "shared", model.FieldGroupID: g.ID})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var doc map[string]any
+	if err := parseBody(resp.Body, &doc); err != nil {
+		t.Fatal(err)
+	}
+	id, _ := doc["id"].(string)
+
+	resp = authReqWithToken(t, string(memberToken), db.get, "GET", "/db/group_notes/"+id, nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected a group member to read the document got %s", GetResponseBody(t, resp))
+	}
+
+	resp = authReqWithToken(t, string(otherToken), db.get, "GET", "/db/group_notes/"+id, nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusOK {
+		t.Error("expected a user outside the group to be denied")
+	}
+
+	resp = authReqWithToken(t, string(memberToken), acct.group, "GET", "/account/groups/"+g.ID+"/members", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected a member to list the group members got %s", GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, acct.group, "DELETE", "/account/groups/"+g.ID+"/members/"+member.ID, nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = authReqWithToken(t, string(memberToken), db.get, "GET", "/db/group_notes/"+id, nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusOK {
+		t.Error("expected a removed member to be denied right away")
+	}
+}
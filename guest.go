@@ -0,0 +1,139 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+func guestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, backend.ErrGuestsDisabled):
+		return http.StatusForbidden
+	case errors.Is(err, backend.ErrEmailAlreadyInUse):
+		return http.StatusConflict
+	case errors.Is(err, backend.ErrNotGuest), errors.Is(err, backend.ErrTooManyVerificationEmails):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// createGuest creates an anonymous user and returns its session tokens
+func (m *membership) createGuest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf).WithClient(r.UserAgent(), middleware.ClientIP(r))
+
+	tokens, err := mship.CreateGuest()
+	if err != nil {
+		http.Error(w, err.Error(), guestErrorStatus(err))
+		return
+	}
+
+	respond(w, http.StatusOK, tokens)
+}
+
+// upgradeGuest turns the current guest into a regular user with an email and
+// password with POST /guest/upgrade, or sends a magic link upgrading it with
+// POST /guest/upgrade/magic
+func (m *membership) upgradeGuest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf).WithClient(r.UserAgent(), middleware.ClientIP(r))
+
+	if getURLPart(r.URL.Path, 3) == "magic" {
+		var data backend.MagicLinkData
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.SetupGuestMagicLink(auth, data); err != nil {
+			http.Error(w, err.Error(), guestErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+		return
+	}
+
+	var data model.Login
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if len(data.Password) == 0 {
+		http.Error(w, "password is required", http.StatusBadRequest)
+		return
+	}
+
+	token, err := mship.UpgradeGuest(auth, data.Email, data.Password)
+	if respondPasswordPolicy(w, err) {
+		return
+	} else if errors.Is(err, model.ErrEmailNotVerified) {
+		respond(w, http.StatusAccepted, model.EmailVerificationPending{EmailVerificationRequired: true})
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), guestErrorStatus(err))
+		return
+	}
+
+	respond(w, http.StatusOK, token)
+}
+
+func (m *membership) sudoGuestPolicy(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := mship.GetGuestPolicy()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	case http.MethodPost:
+		var policy model.GuestPolicy
+		if err := parseBody(r.Body, &policy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.SetGuestPolicy(policy); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	default:
+		http.Error(w, "method not implemented", http.StatusNotImplemented)
+	}
+}
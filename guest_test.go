@@ -0,0 +1,93 @@
+package staticbackend
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestGuestDataKeptOnUpgrade(t *testing.T) {
+	resp := dbReq(t, mship.createGuest, "POST", "/guest", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected guests to be disabled by default got %d", resp.StatusCode)
+	}
+
+	resp = dbReq(t, mship.sudoGuestPolicy, "POST", "/sudo/guestpolicy", model.GuestPolicy{Enabled: true}, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+	t.Cleanup(func() {
+		if conf, err := backend.DB.FindDatabase(pubKey); err == nil {
+			_ = backend.Membership(conf).SetGuestPolicy(model.GuestPolicy{})
+		}
+	})
+
+	resp = dbReq(t, mship.createGuest, "POST", "/guest", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var tokens model.SessionTokens
+	if err := parseBody(resp.Body, &tokens); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = authReqWithToken(t, tokens.Token, db.add, "POST", "/db/guest_notes", map[string]any{"text": "draft"})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var note map[string]any
+	if err := parseBody(resp.Body, &note); err != nil {
+		t.Fatal(err)
+	}
+	noteID, _ := note["id"].(string)
+
+	resp = authReqWithToken(t, tokens.Token, mship.me, "GET", "/me", nil)
+	defer func() { _ = resp.Body.Close() }()
+
+	var guest model.Auth
+	if err := parseBody(resp.Body, &guest); err != nil {
+		t.Fatal(err)
+	}
+
+	upgrade := model.Login{Email: "guest-upgraded@test.com", Password: userPassword}
+	resp = authReqWithToken(t, tokens.Token, mship.upgradeGuest, "POST", "/guest/upgrade", upgrade)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var token string
+	if err := parseBody(resp.Body, &token); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = authReqWithToken(t, token, mship.me, "GET", "/me", nil)
+	defer func() { _ = resp.Body.Close() }()
+
+	var me model.Auth
+	if err := parseBody(resp.Body, &me); err != nil {
+		t.Fatal(err)
+	} else if me.UserID != guest.UserID || me.AccountID != guest.AccountID || me.Email != upgrade.Email {
+		t.Errorf("expected the guest %s to keep its ids got %v", guest.UserID, me)
+	}
+
+	resp = authReqWithToken(t, token, db.get, "GET", "/db/guest_notes/"+noteID, nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the guest data to be kept got %s", GetResponseBody(t, resp))
+	}
+
+	resp = authReqWithToken(t, token, mship.upgradeGuest, "POST", "/guest/upgrade", model.Login{Email: "guest-twice@test.com", Password: userPassword})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a regular user upgrade to be refused got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,43 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/middleware"
+)
+
+// channelHistory lists the recent messages of the realtime channel of the
+// URL, i.e. GET /history/{channel}?since={eventId}
+func channelHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	channel := strings.TrimPrefix(r.URL.Path, "/history/")
+	if len(channel) == 0 {
+		http.Error(w, "missing channel", http.StatusBadRequest)
+		return
+	}
+
+	msgs, err := cache.History(backend.Cache, auth, conf.Name, channel, r.URL.Query().Get("since"))
+	if errors.Is(err, cache.ErrInvalidEventID) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, msgs)
+}
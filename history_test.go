@@ -0,0 +1,50 @@
+package staticbackend
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestChannelHistory(t *testing.T) {
+	for _, data := range []string{"first", "second"} {
+		msg := model.Command{Type: model.MsgTypeChanIn, Channel: "history-room", Data: data, Base: dbName}
+		if err := backend.Cache.Publish(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp := dbReq(t, channelHistory, "GET", "/history/history-room", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var msgs []model.Command
+	if err := parseBody(resp.Body, &msgs); err != nil {
+		t.Fatal(err)
+	} else if len(msgs) != 2 || msgs[0].Data != "first" || msgs[0].Type != model.MsgTypeChanOut {
+		t.Fatalf("expected the 2 messages of the channel got %v", msgs)
+	}
+
+	resp2 := dbReq(t, channelHistory, "GET", "/history/history-room?since="+msgs[0].ID, nil)
+	defer func() { _ = resp2.Body.Close() }()
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp2))
+	}
+
+	var since []model.Command
+	if err := parseBody(resp2.Body, &since); err != nil {
+		t.Fatal(err)
+	} else if len(since) != 1 || since[0].Data != "second" {
+		t.Errorf("expected the message after the since id got %v", since)
+	}
+
+	resp3 := dbReq(t, channelHistory, "GET", "/history/history-room?since=abc", nil)
+	defer func() { _ = resp3.Body.Close() }()
+	if resp3.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid event id got %d", resp3.StatusCode)
+	}
+}
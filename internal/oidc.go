@@ -0,0 +1,630 @@
+package internal
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markbates/goth"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// oidcCacheTTL is how long discovery documents and JWKS are reused
+	// before being fetched again
+	oidcCacheTTL = 1 * time.Hour
+	// oidcClockSkew is the tolerance applied to the id_token expiry
+	oidcClockSkew = 2 * time.Minute
+)
+
+var (
+	ErrInvalidIDToken = errors.New("invalid id_token")
+
+	// OIDCClient is the HTTP client used to reach OpenID Connect providers
+	OIDCClient = &http.Client{Timeout: 10 * time.Second}
+
+	// DefaultOIDCClaims maps the external user fields to the standard
+	// OpenID Connect claims
+	DefaultOIDCClaims = map[string]string{
+		"email":     "email",
+		"name":      "name",
+		"first":     "given_name",
+		"last":      "family_name",
+		"avatarUrl": "picture",
+	}
+
+	oidcDiscoveries = &oidcCache[OIDCDiscovery]{}
+	oidcKeys        = &oidcCache[map[string]crypto.PublicKey]{}
+)
+
+// OIDCDiscovery is the part of an issuer's discovery document needed to sign
+// users in
+type OIDCDiscovery struct {
+	Issuer      string `json:"issuer"`
+	AuthURL     string `json:"authorization_endpoint"`
+	TokenURL    string `json:"token_endpoint"`
+	UserInfoURL string `json:"userinfo_endpoint"`
+	JWKSURL     string `json:"jwks_uri"`
+}
+
+// DiscoverOIDC returns the discovery document of an issuer. Documents are
+// cached for an hour.
+func DiscoverOIDC(issuer string) (d OIDCDiscovery, err error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	if d, ok := oidcDiscoveries.get(issuer); ok {
+		return d, nil
+	}
+
+	if err = getJSON(issuer+"/.well-known/openid-configuration", "", &d); err != nil {
+		return
+	}
+
+	if strings.TrimSuffix(d.Issuer, "/") != issuer {
+		err = fmt.Errorf("discovery issuer %s does not match %s", d.Issuer, issuer)
+		return
+	} else if len(d.AuthURL) == 0 || len(d.TokenURL) == 0 || len(d.JWKSURL) == 0 {
+		err = fmt.Errorf("discovery document of %s is missing endpoints", issuer)
+		return
+	}
+
+	oidcDiscoveries.set(issuer, d)
+	return
+}
+
+// VerifyIDToken validates the signature of an id_token with the issuer's
+// JWKS and checks its issuer, audience, expiry and nonce. It returns the
+// token claims.
+func VerifyIDToken(token string, d OIDCDiscovery, clientID, nonce string, now time.Time) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidIDToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	key, err := oidcPublicKey(d.JWKSURL, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	claims := make(map[string]any)
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != strings.TrimSuffix(d.Issuer, "/") {
+		return nil, fmt.Errorf("%w: unexpected issuer %s", ErrInvalidIDToken, iss)
+	}
+
+	var aud []string
+	switch v := claims["aud"].(type) {
+	case string:
+		aud = []string{v}
+	case []any:
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				aud = append(aud, s)
+			}
+		}
+	}
+	if !slices.Contains(aud, clientID) {
+		return nil, fmt.Errorf("%w: token was not issued for this client", ErrInvalidIDToken)
+	} else if azp, ok := claims["azp"].(string); ok && azp != clientID {
+		return nil, fmt.Errorf("%w: token was authorized for another party", ErrInvalidIDToken)
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.Add(-oidcClockSkew).After(time.Unix(int64(exp), 0)) {
+		return nil, fmt.Errorf("%w: token is expired", ErrInvalidIDToken)
+	}
+
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// OIDCProvider is a goth provider signing users in with any OpenID Connect
+// issuer. The authorization code flow uses PKCE and a nonce, the id_token
+// is validated with the issuer's JWKS.
+type OIDCProvider struct {
+	name      string
+	discovery OIDCDiscovery
+	config    *oauth2.Config
+	claims    map[string]string
+
+	// TrustEmails accepts the emails without an email_verified claim, for
+	// the issuers only returning verified emails
+	TrustEmails bool
+}
+
+// NewOIDCProvider returns a provider for an issuer using its discovery
+// document. The openid scope is always requested and claims overrides the
+// DefaultOIDCClaims mapping.
+func NewOIDCProvider(name, issuer, clientID, secret, callbackURL string, scopes []string, claims map[string]string) (*OIDCProvider, error) {
+	d, err := DiscoverOIDC(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	} else if !slices.Contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+
+	p := &OIDCProvider{
+		name:      name,
+		discovery: d,
+		config: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: secret,
+			RedirectURL:  callbackURL,
+			Scopes:       scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  d.AuthURL,
+				TokenURL: d.TokenURL,
+			},
+		},
+		claims: make(map[string]string),
+	}
+
+	for field, claim := range DefaultOIDCClaims {
+		p.claims[field] = claim
+	}
+	for field, claim := range claims {
+		if len(claim) > 0 {
+			p.claims[field] = claim
+		}
+	}
+	return p, nil
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+func (p *OIDCProvider) SetName(name string) {
+	p.name = name
+}
+
+func (p *OIDCProvider) Debug(bool) {}
+
+// BeginAuth returns a session holding the authorization URL with a new PKCE
+// challenge and nonce
+func (p *OIDCProvider) BeginAuth(state string) (goth.Session, error) {
+	nonce, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	verifier := oauth2.GenerateVerifier()
+	url := p.config.AuthCodeURL(
+		state,
+		oauth2.S256ChallengeOption(verifier),
+		oauth2.SetAuthURLParam("nonce", nonce),
+	)
+
+	return &OIDCSession{AuthURL: url, Verifier: verifier, Nonce: nonce}, nil
+}
+
+func (p *OIDCProvider) UnmarshalSession(data string) (goth.Session, error) {
+	s := &OIDCSession{}
+	err := json.Unmarshal([]byte(data), s)
+	return s, err
+}
+
+// FetchUser maps the id_token claims of an authorized session to a user.
+// Missing claims are read from the userinfo endpoint when available.
+func (p *OIDCProvider) FetchUser(session goth.Session) (user goth.User, err error) {
+	s, ok := session.(*OIDCSession)
+	if !ok || len(s.IDToken) == 0 {
+		err = errors.New("session must be authorized before fetching the user")
+		return
+	}
+
+	claims := s.Claims
+	if len(p.claim(claims, "email")) == 0 && len(p.discovery.UserInfoURL) > 0 {
+		info := make(map[string]any)
+		if err = getJSON(p.discovery.UserInfoURL, s.AccessToken, &info); err != nil {
+			return
+		} else if info["sub"] != claims["sub"] {
+			err = errors.New("userinfo subject does not match the id_token")
+			return
+		}
+
+		for k, v := range info {
+			if _, ok := claims[k]; !ok {
+				claims[k] = v
+			}
+		}
+	}
+
+	if !p.emailVerified(claims) {
+		err = errors.New("the provider has not verified this email")
+		return
+	}
+
+	user = goth.User{
+		RawData:      claims,
+		Provider:     p.name,
+		Email:        p.claim(claims, "email"),
+		Name:         p.claim(claims, "name"),
+		FirstName:    p.claim(claims, "first"),
+		LastName:     p.claim(claims, "last"),
+		AvatarURL:    p.claim(claims, "avatarUrl"),
+		AccessToken:  s.AccessToken,
+		RefreshToken: s.RefreshToken,
+		ExpiresAt:    s.ExpiresAt,
+		IDToken:      s.IDToken,
+	}
+	user.UserID, _ = claims["sub"].(string)
+
+	if len(user.Email) == 0 {
+		err = errors.New("the provider did not return an email")
+	}
+	return
+}
+
+// emailVerified returns true when the email_verified claim is true, a missing
+// claim is only accepted when the issuer's emails are trusted
+func (p *OIDCProvider) emailVerified(claims map[string]any) bool {
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		return v
+	case string:
+		// some issuers send the claim as a string
+		return v == "true"
+	case nil:
+		return p.TrustEmails
+	}
+	return false
+}
+
+func (p *OIDCProvider) RefreshTokenAvailable() bool {
+	return true
+}
+
+func (p *OIDCProvider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
+	ts := p.config.TokenSource(p.ctx(), &oauth2.Token{RefreshToken: refreshToken})
+	return ts.Token()
+}
+
+func (p *OIDCProvider) claim(claims map[string]any, field string) string {
+	s, _ := claims[p.claims[field]].(string)
+	return s
+}
+
+func (p *OIDCProvider) ctx() context.Context {
+	return context.WithValue(context.Background(), oauth2.HTTPClient, OIDCClient)
+}
+
+// OIDCSession keeps the PKCE verifier and nonce between the login redirect
+// and the callback, then the tokens and claims once authorized.
+type OIDCSession struct {
+	AuthURL      string         `json:"authUrl"`
+	Verifier     string         `json:"verifier"`
+	Nonce        string         `json:"nonce"`
+	AccessToken  string         `json:"accessToken,omitempty"`
+	RefreshToken string         `json:"refreshToken,omitempty"`
+	IDToken      string         `json:"idToken,omitempty"`
+	ExpiresAt    time.Time      `json:"expiresAt,omitempty"`
+	Claims       map[string]any `json:"claims,omitempty"`
+}
+
+func (s *OIDCSession) GetAuthURL() (string, error) {
+	if len(s.AuthURL) == 0 {
+		return "", errors.New(goth.NoAuthUrlErrorMessage)
+	}
+	return s.AuthURL, nil
+}
+
+func (s *OIDCSession) Marshal() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// Authorize exchanges the authorization code with the PKCE verifier and
+// validates the returned id_token
+func (s *OIDCSession) Authorize(provider goth.Provider, params goth.Params) (string, error) {
+	p, ok := provider.(*OIDCProvider)
+	if !ok {
+		return "", errors.New("session does not belong to an OpenID Connect provider")
+	}
+
+	if e := params.Get("error"); len(e) > 0 {
+		return "", fmt.Errorf("provider returned an error: %s %s", e, params.Get("error_description"))
+	}
+
+	code := params.Get("code")
+	if len(code) == 0 {
+		return "", errors.New("missing authorization code")
+	}
+
+	tok, err := p.config.Exchange(p.ctx(), code, oauth2.VerifierOption(s.Verifier))
+	if err != nil {
+		return "", err
+	}
+
+	idToken, _ := tok.Extra("id_token").(string)
+	if len(idToken) == 0 {
+		return "", fmt.Errorf("%w: missing from the token response", ErrInvalidIDToken)
+	}
+
+	claims, err := VerifyIDToken(idToken, p.discovery, p.config.ClientID, s.Nonce, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	s.AccessToken = tok.AccessToken
+	s.RefreshToken = tok.RefreshToken
+	s.ExpiresAt = tok.Expiry
+	s.IDToken = idToken
+	s.Claims = claims
+	return s.AccessToken, nil
+}
+
+// oidcPublicKey returns the JWKS key matching kid. The JWKS is fetched
+// again once when the key is unknown so rotated keys are picked up.
+func oidcPublicKey(jwksURL, kid string) (crypto.PublicKey, error) {
+	find := func(keys map[string]crypto.PublicKey) (crypto.PublicKey, bool) {
+		if len(kid) == 0 && len(keys) == 1 {
+			for _, k := range keys {
+				return k, true
+			}
+		}
+		k, ok := keys[kid]
+		return k, ok
+	}
+
+	if keys, ok := oidcKeys.get(jwksURL); ok {
+		if k, ok := find(keys); ok {
+			return k, nil
+		}
+	}
+
+	keys, err := fetchJWKS(jwksURL)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcKeys.set(jwksURL, keys)
+
+	if k, ok := find(keys); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %s", ErrInvalidIDToken, kid)
+}
+
+func fetchJWKS(url string) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := getJSON(url, "", &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use == "enc" {
+			continue
+		}
+
+		switch jwk.Kty {
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+			if err != nil {
+				return nil, err
+			}
+			e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+			if err != nil {
+				return nil, err
+			}
+			keys[jwk.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "EC":
+			var curve elliptic.Curve
+			switch jwk.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+			if err != nil {
+				return nil, err
+			}
+			y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+			if err != nil {
+				return nil, err
+			}
+			keys[jwk.Kid] = &ecdsa.PublicKey{
+				Curve: curve,
+				X:     new(big.Int).SetBytes(x),
+				Y:     new(big.Int).SetBytes(y),
+			}
+		case "OKP":
+			if jwk.Crv != "Ed25519" {
+				continue
+			}
+			x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+			if err != nil {
+				return nil, err
+			}
+			keys[jwk.Kid] = ed25519.PublicKey(x)
+		}
+	}
+	return keys, nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	var h crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		h = crypto.SHA256
+	case "RS384", "ES384":
+		h = crypto.SHA384
+	case "RS512", "ES512":
+		h = crypto.SHA512
+	case "EdDSA":
+		k, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(k, signed, sig) {
+			return errors.New("signature verification failed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+
+	digest := hashBytes(h, signed)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("algorithm %s does not match an RSA key", alg)
+		}
+		return rsa.VerifyPKCS1v15(k, h, digest, sig)
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") || len(sig) != 2*size {
+			return fmt.Errorf("algorithm %s does not match an EC key", alg)
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("signature verification failed")
+		}
+		return nil
+	}
+	return fmt.Errorf("algorithm %s does not match the key type", alg)
+}
+
+func hashBytes(h crypto.Hash, b []byte) []byte {
+	switch h {
+	case crypto.SHA384:
+		sum := sha512.Sum384(b)
+		return sum[:]
+	case crypto.SHA512:
+		sum := sha512.Sum512(b)
+		return sum[:]
+	}
+	sum := sha256.Sum256(b)
+	return sum[:]
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func getJSON(url, bearer string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if len(bearer) > 0 {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	resp, err := OIDCClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+type oidcCache[T any] struct {
+	mu      sync.Mutex
+	entries map[string]oidcCacheEntry[T]
+}
+
+type oidcCacheEntry[T any] struct {
+	value   T
+	fetched time.Time
+}
+
+func (c *oidcCache[T]) get(key string) (v T, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || time.Since(e.fetched) > oidcCacheTTL {
+		return v, false
+	}
+	return e.value, true
+}
+
+func (c *oidcCache[T]) set(key string, v T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]oidcCacheEntry[T])
+	}
+	c.entries[key] = oidcCacheEntry[T]{value: v, fetched: time.Now()}
+}
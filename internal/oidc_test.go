@@ -0,0 +1,441 @@
+package internal
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testClientID = "sb-client"
+
+type testOIDCCode struct {
+	challenge string
+	nonce     string
+	claims    map[string]any
+}
+
+// testOIDCServer is a stand-in OpenID Connect provider serving discovery,
+// JWKS, token and userinfo endpoints
+type testOIDCServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	key      *rsa.PrivateKey
+	kid      string
+	codes    map[string]testOIDCCode
+	userinfo map[string]any
+}
+
+func newTestOIDCServer(t *testing.T) *testOIDCServer {
+	t.Helper()
+
+	srv := &testOIDCServer{codes: make(map[string]testOIDCCode)}
+	srv.rotate(t, "key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(OIDCDiscovery{
+			Issuer:      srv.URL,
+			AuthURL:     srv.URL + "/authorize",
+			TokenURL:    srv.URL + "/token",
+			UserInfoURL: srv.URL + "/userinfo",
+			JWKSURL:     srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+
+		pub := srv.key.PublicKey
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": srv.kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		srv.mu.Lock()
+		code, ok := srv.codes[r.Form.Get("code")]
+		delete(srv.codes, r.Form.Get("code"))
+		srv.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != code.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		claims := map[string]any{
+			"iss":   srv.URL,
+			"sub":   "user-123",
+			"aud":   testClientID,
+			"exp":   time.Now().Add(5 * time.Minute).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": code.nonce,
+		}
+		for k, v := range code.claims {
+			claims[k] = v
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access-123",
+			"token_type":   "Bearer",
+			"expires_in":   300,
+			"id_token":     srv.sign(t, claims),
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(srv.userinfo)
+	})
+
+	srv.Server = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (srv *testOIDCServer) rotate(t *testing.T, kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.key = key
+	srv.kid = kid
+}
+
+func (srv *testOIDCServer) sign(t *testing.T, claims map[string]any) string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	return signTestToken(t, srv.key, srv.kid, claims)
+}
+
+// authorize plays the user approving the login at the provider and returns
+// the authorization code sent to the callback
+func (srv *testOIDCServer) authorize(t *testing.T, authURL string, claims map[string]any) string {
+	t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || len(q.Get("code_challenge")) == 0 {
+		t.Fatalf("expected a S256 PKCE challenge got %s", u.RawQuery)
+	} else if len(q.Get("nonce")) == 0 {
+		t.Fatalf("expected a nonce got %s", u.RawQuery)
+	}
+
+	code := "code-" + q.Get("state")
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.codes[code] = testOIDCCode{
+		challenge: q.Get("code_challenge"),
+		nonce:     q.Get("nonce"),
+		claims:    claims,
+	}
+	return code
+}
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestOIDCProviderLogin(t *testing.T) {
+	srv := newTestOIDCServer(t)
+
+	p, err := NewOIDCProvider("keycloak", srv.URL, testClientID, "secret", "http://localhost/oauth/callback", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess, err := p.BeginAuth("state1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, err := sess.GetAuthURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := srv.authorize(t, authURL, map[string]any{
+		"email":          "oidc@test.com",
+		"email_verified": true,
+		"given_name":     "Oidc",
+		"family_name":    "User",
+	})
+
+	// the session is stored between the redirect and the callback
+	sess, err = p.UnmarshalSession(sess.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sess.Authorize(p, url.Values{"code": {code}}); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := p.FetchUser(sess)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.Email != "oidc@test.com" || user.FirstName != "Oidc" || user.LastName != "User" {
+		t.Errorf("unexpected user %v", user)
+	} else if user.UserID != "user-123" || user.Provider != "keycloak" {
+		t.Errorf("expected subject user-123 from keycloak got %s from %s", user.UserID, user.Provider)
+	}
+}
+
+func TestOIDCProviderRejectsWrongVerifier(t *testing.T) {
+	srv := newTestOIDCServer(t)
+
+	p, err := NewOIDCProvider("okta", srv.URL, testClientID, "secret", "http://localhost/oauth/callback", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess, err := p.BeginAuth("state2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, _ := sess.GetAuthURL()
+	code := srv.authorize(t, authURL, map[string]any{"email": "oidc@test.com"})
+
+	// an intercepted code cannot be redeemed without the verifier
+	other, err := p.BeginAuth("state2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := other.Authorize(p, url.Values{"code": {code}}); err == nil {
+		t.Error("expected the code exchange to fail with another verifier")
+	}
+}
+
+func TestOIDCProviderClaimMappingAndUserInfo(t *testing.T) {
+	srv := newTestOIDCServer(t)
+	srv.userinfo = map[string]any{"sub": "user-123", "upn": "azure@test.com"}
+
+	claims := map[string]string{"email": "upn", "name": "display_name"}
+	p, err := NewOIDCProvider("azuread", srv.URL, testClientID, "secret", "http://localhost/oauth/callback", []string{"email"}, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the upn claim has no email_verified claim
+	p.TrustEmails = true
+
+	sess, err := p.BeginAuth("state3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, _ := sess.GetAuthURL()
+	if u, _ := url.Parse(authURL); u.Query().Get("scope") != "openid email" {
+		t.Errorf("expected the openid scope to be added got %s", u.Query().Get("scope"))
+	}
+
+	code := srv.authorize(t, authURL, map[string]any{"display_name": "Azure User"})
+	if _, err := sess.Authorize(p, url.Values{"code": {code}}); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := p.FetchUser(sess)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.Email != "azure@test.com" || user.Name != "Azure User" {
+		t.Errorf("expected mapped claims got email %s name %s", user.Email, user.Name)
+	}
+}
+
+func TestOIDCProviderUnverifiedEmail(t *testing.T) {
+	srv := newTestOIDCServer(t)
+
+	p, err := NewOIDCProvider("keycloak", srv.URL, testClientID, "secret", "http://localhost/oauth/callback", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess, _ := p.BeginAuth("state4")
+	authURL, _ := sess.GetAuthURL()
+	code := srv.authorize(t, authURL, map[string]any{"email": "oidc@test.com", "email_verified": false})
+
+	if _, err := sess.Authorize(p, url.Values{"code": {code}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.FetchUser(sess); err == nil {
+		t.Error("expected an unverified email to be refused")
+	}
+}
+
+func TestOIDCProviderMissingEmailVerified(t *testing.T) {
+	srv := newTestOIDCServer(t)
+
+	p, err := NewOIDCProvider("keycloak", srv.URL, testClientID, "secret", "http://localhost/oauth/callback", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, trusted := range []bool{false, true} {
+		p.TrustEmails = trusted
+
+		sess, _ := p.BeginAuth("state5")
+		authURL, _ := sess.GetAuthURL()
+		code := srv.authorize(t, authURL, map[string]any{"email": "oidc@test.com"})
+
+		if _, err := sess.Authorize(p, url.Values{"code": {code}}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := p.FetchUser(sess); trusted && err != nil {
+			t.Errorf("expected the trusted issuer's email to be accepted got %v", err)
+		} else if !trusted && err == nil {
+			t.Error("expected an email without email_verified to be refused")
+		}
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	srv := newTestOIDCServer(t)
+
+	d, err := DiscoverOIDC(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := func() map[string]any {
+		return map[string]any{
+			"iss":   srv.URL,
+			"sub":   "user-123",
+			"aud":   []string{testClientID, "other"},
+			"azp":   testClientID,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"nonce": "n1",
+		}
+	}
+
+	if _, err := VerifyIDToken(srv.sign(t, valid()), d, testClientID, "n1", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	forged, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]func() string{
+		"forged signature": func() string { return signTestToken(t, forged, "key-1", valid()) },
+		"wrong audience": func() string {
+			c := valid()
+			c["aud"] = "someone-else"
+			delete(c, "azp")
+			return srv.sign(t, c)
+		},
+		"wrong issuer": func() string {
+			c := valid()
+			c["iss"] = "https://evil.test"
+			return srv.sign(t, c)
+		},
+		"expired": func() string {
+			c := valid()
+			c["exp"] = time.Now().Add(-time.Hour).Unix()
+			return srv.sign(t, c)
+		},
+		"wrong nonce": func() string {
+			c := valid()
+			c["nonce"] = "replayed"
+			return srv.sign(t, c)
+		},
+		"unsigned": func() string {
+			header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"key-1"}`))
+			payload, _ := json.Marshal(valid())
+			return header + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+		},
+	}
+
+	for name, token := range tests {
+		if _, err := VerifyIDToken(token(), d, testClientID, "n1", time.Now()); !errors.Is(err, ErrInvalidIDToken) {
+			t.Errorf("%s: expected ErrInvalidIDToken got %v", name, err)
+		}
+	}
+}
+
+func TestVerifyIDTokenKeyRotation(t *testing.T) {
+	srv := newTestOIDCServer(t)
+
+	d, err := DiscoverOIDC(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := map[string]any{
+		"iss": srv.URL,
+		"aud": testClientID,
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+
+	if _, err := VerifyIDToken(srv.sign(t, claims), d, testClientID, "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the cached JWKS does not know the new key and is fetched again
+	srv.rotate(t, "key-2")
+
+	if _, err := VerifyIDToken(srv.sign(t, claims), d, testClientID, "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+}
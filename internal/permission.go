@@ -81,6 +81,11 @@ func ReadScope(auth model.Auth, col string) RowPermissionScope {
 		return RowScopeEveryone
 	}
 
+	// custom roles reading a collection read all rows of the account
+	if auth.Capabilities != nil && auth.Capabilities.Allows(model.APIKeyScopeCollections, col, false) {
+		return RowScopeAccount
+	}
+
 	if config.Current.RoleAwareRowPermissions {
 		if auth.Role >= 50 {
 			return RowScopeAccount
@@ -98,6 +103,10 @@ func WriteScope(auth model.Auth, col string, publicWrite bool) RowPermissionScop
 		return RowScopeEveryone
 	}
 
+	if auth.Capabilities != nil && !strings.HasPrefix(col, "pub_") && auth.Capabilities.Allows(model.APIKeyScopeCollections, col, true) {
+		return RowScopeAccount
+	}
+
 	if config.Current.RoleAwareRowPermissions && !strings.HasPrefix(col, "pub_") {
 		if auth.Role >= 50 {
 			return RowScopeAccount
@@ -110,6 +119,49 @@ func WriteScope(auth model.Auth, col string, publicWrite bool) RowPermissionScop
 	return scopeFromPermission(WritePermission(col))
 }
 
+// RowAllowed returns true if a row of an account, owned by ownerID and
+// scoped to groupID, is in the row scope of the user. Group-scoped rows are
+// shared with the members of the group and hidden from the other users of
+// the account, except its managers.
+func RowAllowed(auth model.Auth, scope RowPermissionScope, accountID, ownerID, groupID string) bool {
+	switch scope {
+	case RowScopeAccount:
+		return accountID == auth.AccountID && (len(groupID) == 0 || SeesAllGroups(auth) || auth.InGroup(groupID))
+	case RowScopeOwner:
+		return accountID == auth.AccountID && (ownerID == auth.UserID || auth.InGroup(groupID))
+	default:
+		return true
+	}
+}
+
+// CanReceiveEvent returns true if a user receives the realtime events of a
+// document of a collection, group-scoped documents are only sent to the
+// members of their group and the account managers
+func CanReceiveEvent(auth model.Auth, col string, doc map[string]any) bool {
+	accountID := fmt.Sprintf("%v", doc["accountId"])
+
+	owner, ok := doc["sb_ownerId"]
+	if !ok {
+		owner = doc["ownerId"]
+	}
+	ownerID := fmt.Sprintf("%v", owner)
+
+	switch ReadPermission(col) {
+	case PermGroup:
+		return RowAllowed(auth, RowScopeAccount, accountID, ownerID, model.DocumentGroup(doc))
+	case PermOwner:
+		return RowAllowed(auth, RowScopeOwner, accountID, ownerID, model.DocumentGroup(doc))
+	default:
+		return true
+	}
+}
+
+// SeesAllGroups returns true if the user accesses the group-scoped rows of
+// their account without being a member of the groups
+func SeesAllGroups(auth model.Auth) bool {
+	return auth.CanManageUsers()
+}
+
 func scopeFromPermission(perm PermissionLevel) RowPermissionScope {
 	switch perm {
 	case PermGroup:
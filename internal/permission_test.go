@@ -148,3 +148,39 @@ func withRoleAwarePermissions(t *testing.T, enabled bool) {
 		config.Current.RoleAwareRowPermissions = orig
 	})
 }
+
+func TestRowAllowedGroups(t *testing.T) {
+	member := model.Auth{AccountID: "acct", UserID: "member", Role: 10, Groups: []string{"grp"}}
+	other := model.Auth{AccountID: "acct", UserID: "other", Role: 10}
+	manager := model.Auth{AccountID: "acct", UserID: "manager", Role: 50}
+
+	if !RowAllowed(member, RowScopeAccount, "acct", "owner", "grp") {
+		t.Error("expected a group member to access the row")
+	}
+	if RowAllowed(other, RowScopeAccount, "acct", "owner", "grp") {
+		t.Error("expected a user outside the group to be denied")
+	}
+	if !RowAllowed(manager, RowScopeAccount, "acct", "owner", "grp") {
+		t.Error("expected an account manager to access the row")
+	}
+	if !RowAllowed(other, RowScopeAccount, "acct", "owner", "") {
+		t.Error("expected rows without a group to follow the account scope")
+	}
+	if !RowAllowed(member, RowScopeOwner, "acct", "owner", "grp") {
+		t.Error("expected a group member to access an owner-scoped row of the group")
+	}
+	if RowAllowed(member, RowScopeOwner, "other-acct", "owner", "grp") {
+		t.Error("expected rows of another account to be denied")
+	}
+}
+
+func TestCanReceiveEventGroups(t *testing.T) {
+	doc := map[string]any{"accountId": "acct", "sb_ownerId": "owner", model.FieldGroupID: "grp"}
+
+	if !CanReceiveEvent(model.Auth{AccountID: "acct", UserID: "member", Groups: []string{"grp"}}, "tasks", doc) {
+		t.Error("expected a group member to receive the event")
+	}
+	if CanReceiveEvent(model.Auth{AccountID: "acct", UserID: "other"}, "tasks", doc) {
+		t.Error("expected a user outside the group not to receive the event")
+	}
+}
@@ -10,6 +10,10 @@ import (
 
 const FilterKey = "__sb_query__"
 
+// IDsKey restricts a filter to a set of document ids, i.e. the matches of a
+// full-text search.
+const IDsKey = "__sb_ids__"
+
 type Operator string
 
 const (
@@ -110,6 +114,35 @@ func FromFilter(filter map[string]interface{}) (Query, bool) {
 	return q, ok
 }
 
+// WithIDs returns a copy of the filter restricted to the document ids
+func WithIDs(filter map[string]interface{}, ids []string) map[string]interface{} {
+	f := make(map[string]interface{}, len(filter)+1)
+	for k, v := range filter {
+		f[k] = v
+	}
+	f[IDsKey] = ids
+	return f
+}
+
+// SplitIDs returns the document ids restriction of a filter and a copy of the
+// filter without it. ok is false if the filter has no ids restriction.
+func SplitIDs(filter map[string]interface{}) (ids []string, rest map[string]interface{}, ok bool) {
+	v, ok := filter[IDsKey]
+	if !ok {
+		return nil, filter, false
+	}
+
+	ids, _ = v.([]string)
+
+	rest = make(map[string]interface{}, len(filter)-1)
+	for k, v := range filter {
+		if k != IDsKey {
+			rest[k] = v
+		}
+	}
+	return ids, rest, true
+}
+
 func ParseOperator(op string) (Operator, error) {
 	switch op {
 	case "=", "==":
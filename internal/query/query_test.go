@@ -72,3 +72,31 @@ func TestParseRejectsFieldReferenceForContains(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestSplitIDs(t *testing.T) {
+	q, err := Parse([][]interface{}{{"status", "=", "unpaid"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filter := map[string]interface{}{FilterKey: q}
+
+	if _, _, ok := SplitIDs(filter); ok {
+		t.Fatal("expected no ids restriction")
+	}
+
+	ids, rest, ok := SplitIDs(WithIDs(filter, []string{"a", "b"}))
+	if !ok {
+		t.Fatal("expected an ids restriction")
+	} else if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("unexpected ids %v", ids)
+	} else if _, found := rest[IDsKey]; found {
+		t.Error("expected ids restriction to be removed from the filter")
+	} else if _, found := rest[FilterKey]; !found {
+		t.Error("expected clauses to be kept")
+	}
+
+	if _, found := filter[IDsKey]; found {
+		t.Error("expected WithIDs to not modify the original filter")
+	}
+}
@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// TOTPPeriod is the number of seconds a TOTP code is valid for
+	TOTPPeriod = 30
+	// TOTPDigits is the number of digits of a TOTP code
+	TOTPDigits = 6
+)
+
+var (
+	totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+	// recoveryCodeChars excludes the characters easily confused when typed,
+	// its length of 32 keeps the random selection unbiased
+	recoveryCodeChars = []byte("abcdefghijkmnpqrstuvwxyz23456789")
+)
+
+// NewTOTPSecret returns a random base32 encoded TOTP secret
+func NewTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPCode returns the RFC 6238 code of a base32 secret at a specific time
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(t.Unix()/TOTPPeriod))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", TOTPDigits, code%1000000), nil
+}
+
+// ValidateTOTP returns the time step of the code if it matches the secret at
+// time t. The previous and next codes are accepted to tolerate clock drift.
+// Codes of a step at or before last are rejected so an accepted code cannot
+// be replayed.
+func ValidateTOTP(secret, code string, t time.Time, last int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	for _, skew := range []int64{-1, 0, 1} {
+		step := t.Unix()/TOTPPeriod + skew
+		if step <= last {
+			continue
+		}
+
+		expected, err := TOTPCode(secret, time.Unix(step*TOTPPeriod, 0))
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// TOTPURI returns the otpauth URI authenticator apps use to register a
+// secret, usually displayed as a QR code.
+func TOTPURI(issuer, account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(TOTPDigits))
+	v.Set("period", fmt.Sprint(TOTPPeriod))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, v.Encode())
+}
+
+// NewRecoveryCode returns a random single-use recovery code formatted as
+// xxxxx-xxxxx
+func NewRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	for i := range b {
+		b[i] = recoveryCodeChars[int(b[i])%len(recoveryCodeChars)]
+	}
+	return fmt.Sprintf("%s-%s", b[:5], b[5:]), nil
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// RFC 6238 appendix B SHA1 test vectors truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for ts, want := range tests {
+		got, err := TOTPCode(secret, time.Unix(ts, 0))
+		if err != nil {
+			t.Fatal(err)
+		} else if got != want {
+			t.Errorf("at %d expected %s got %s", ts, want, got)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	code, err := TOTPCode(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := ValidateTOTP(secret, code, now, 0)
+	if !ok {
+		t.Fatal("expected current code to be valid")
+	} else if step != now.Unix()/TOTPPeriod {
+		t.Errorf("expected step %d got %d", now.Unix()/TOTPPeriod, step)
+	}
+
+	if _, ok := ValidateTOTP(secret, code, now.Add(TOTPPeriod*time.Second), 0); !ok {
+		t.Error("expected previous code to be accepted for clock drift")
+	} else if _, ok := ValidateTOTP(secret, code, now.Add(5*TOTPPeriod*time.Second), 0); ok {
+		t.Error("expected an old code to be rejected")
+	} else if _, ok := ValidateTOTP(secret, "12345", now, 0); ok {
+		t.Error("expected a short code to be rejected")
+	} else if _, ok := ValidateTOTP(secret, code, now, step); ok {
+		t.Error("expected an accepted code to be rejected when replayed")
+	}
+
+	next, err := TOTPCode(secret, now.Add(TOTPPeriod*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := ValidateTOTP(secret, next, now, step); !ok {
+		t.Error("expected the code of a later step to be accepted")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("myapp", "user@test.com", "ABCDEF")
+	if !strings.HasPrefix(uri, "otpauth://totp/myapp:user@test.com?") {
+		t.Errorf("unexpected uri %s", uri)
+	} else if !strings.Contains(uri, "secret=ABCDEF") {
+		t.Errorf("expected secret in uri %s", uri)
+	}
+}
+
+func TestNewRecoveryCode(t *testing.T) {
+	code, err := NewRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(code) != 11 || code[5] != '-' {
+		t.Errorf("expected xxxxx-xxxxx format got %s", code)
+	}
+}
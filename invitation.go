@@ -0,0 +1,201 @@
+package staticbackend
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+func invitationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, backend.ErrInvalidInvitation):
+		return http.StatusNotFound
+	case errors.Is(err, backend.ErrInvitationRecentlySent):
+		return http.StatusTooManyRequests
+	case errors.Is(err, backend.ErrAlreadyInvited),
+		errors.Is(err, backend.ErrAlreadyMember),
+		errors.Is(err, backend.ErrInvitationRole):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// invitations lists the pending invitations of the account and invites an
+// email to join it
+func (a *accounts) invitations(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil || !auth.CanManageUsers() {
+		http.Error(w, "insufficient priviledges", http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		invitations, err := mship.ListInvitations(auth.AccountID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, invitations)
+	case http.MethodPost:
+		var data model.NewInvitation
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if !strings.Contains(data.Email, "@") || !strings.Contains(data.Email, ".") {
+			http.Error(w, "invalid email", http.StatusBadRequest)
+			return
+		}
+
+		inv, err := mship.Invite(auth, data)
+		if err != nil {
+			http.Error(w, err.Error(), invitationErrorStatus(err))
+			return
+		}
+
+		audit(r, model.AuditActionInvite, inv.Email, fmt.Sprintf("role %d", inv.Role))
+
+		respond(w, http.StatusOK, inv)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// invitation revokes an invitation with DELETE /account/invitations/{id}
+// and resends it with POST /account/invitations/{id}/resend
+func (a *accounts) invitation(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil || !auth.CanManageUsers() {
+		http.Error(w, "insufficient priviledges", http.StatusUnauthorized)
+		return
+	}
+
+	id := getURLPart(r.URL.Path, 3)
+	if len(id) == 0 {
+		http.Error(w, "missing invitation id", http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch {
+	case r.Method == http.MethodDelete:
+		if err := mship.RevokeInvitation(auth, id); err != nil {
+			http.Error(w, err.Error(), invitationErrorStatus(err))
+			return
+		}
+
+		audit(r, model.AuditActionInviteRevoke, id, "")
+
+		respond(w, http.StatusOK, true)
+	case r.Method == http.MethodPost && getURLPart(r.URL.Path, 4) == "resend":
+		inv, err := mship.ResendInvitation(auth, id)
+		if err != nil {
+			http.Error(w, err.Error(), invitationErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, inv)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// acceptInvitation returns the invitation of a link with GET so the client
+// asks the invitee for a new password or their existing one, and accepts it
+// with POST returning a session token in the invited account.
+func (m *membership) acceptInvitation(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf).WithClient(r.UserAgent(), middleware.ClientIP(r))
+
+	switch r.Method {
+	case http.MethodGet:
+		inv, err := mship.GetInvitationByToken(r.URL.Query().Get("token"))
+		if err != nil {
+			http.Error(w, err.Error(), invitationErrorStatus(err))
+			return
+		}
+
+		exists, err := backend.DB.UserEmailExists(conf.Name, inv.Email)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, struct {
+			model.Invitation
+			ExistingUser bool `json:"existingUser"`
+		}{inv, exists})
+	case http.MethodPost:
+		var data model.AcceptInvitation
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		token, err := mship.AcceptInvitation(data)
+		if respondMFAChallenge(w, err) || respondLoginThrottled(w, err) || respondPasswordPolicy(w, err) {
+			return
+		} else if errors.Is(err, backend.ErrInvalidInvitation) || errors.Is(err, backend.ErrAlreadyMember) {
+			http.Error(w, err.Error(), invitationErrorStatus(err))
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		respond(w, http.StatusOK, token)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (m *membership) sudoInvitationPolicy(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := mship.GetInvitationPolicy()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	case http.MethodPost:
+		var policy model.InvitationPolicy
+		if err := parseBody(r.Body, &policy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.SetInvitationPolicy(policy); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	default:
+		http.Error(w, "method not implemented", http.StatusNotImplemented)
+	}
+}
@@ -0,0 +1,145 @@
+package staticbackend
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/email"
+	"github.com/staticbackendhq/core/model"
+)
+
+var invitationTokenRe = regexp.MustCompile(`token=([^"&]+)`)
+
+type invitationMailer struct {
+	token string
+}
+
+func (m *invitationMailer) Send(data email.SendMailData) error {
+	if match := invitationTokenRe.FindStringSubmatch(data.HTMLBody); match != nil {
+		m.token, _ = url.QueryUnescape(match[1])
+	}
+	return nil
+}
+
+func TestInvitationAccept(t *testing.T) {
+	mailer := &invitationMailer{}
+	prev := backend.Emailer
+	backend.Emailer = mailer
+	t.Cleanup(func() { backend.Emailer = prev })
+
+	const invited = "invitation-accept@test.com"
+
+	resp := authReqWithToken(t, userToken, acct.invitations, "POST", "/account/invitations", model.NewInvitation{Email: invited})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected users with role < 50 to be refused got %d", resp.StatusCode)
+	}
+
+	resp = dbReq(t, acct.invitations, "POST", "/account/invitations", model.NewInvitation{Email: invited, Role: 50})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var inv model.Invitation
+	if err := parseBody(resp.Body, &inv); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = dbReq(t, acct.invitations, "GET", "/account/invitations", nil)
+	defer func() { _ = resp.Body.Close() }()
+
+	var pending []model.Invitation
+	if err := parseBody(resp.Body, &pending); err != nil {
+		t.Fatal(err)
+	} else if len(pending) == 0 || pending[0].ID != inv.ID {
+		t.Errorf("expected the invitation %s to be pending got %v", inv.ID, pending)
+	}
+
+	resp = dbReq(t, acct.invitation, "POST", "/account/invitations/"+inv.ID+"/resend", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected an immediate resend to be refused got %d", resp.StatusCode)
+	}
+
+	qs := url.Values{"token": {mailer.token}}
+	resp = dbReq(t, mship.acceptInvitation, "GET", "/invitations/accept?"+qs.Encode(), nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var details struct {
+		Email        string `json:"email"`
+		ExistingUser bool   `json:"existingUser"`
+	}
+	if err := parseBody(resp.Body, &details); err != nil {
+		t.Fatal(err)
+	} else if details.Email != invited || details.ExistingUser {
+		t.Errorf("expected a new user invitation for %s got %v", invited, details)
+	}
+
+	accept := model.AcceptInvitation{Token: mailer.token, Password: userPassword}
+	resp = dbReq(t, mship.acceptInvitation, "POST", "/invitations/accept", accept)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+	t.Cleanup(func() {
+		if tok, err := backend.DB.FindUserByEmail(dbName, invited); err == nil {
+			_ = backend.DB.RemoveUser(model.Auth{AccountID: tok.AccountID, UserID: tok.ID, Role: 100}, dbName, tok.ID)
+		}
+	})
+
+	var token string
+	if err := parseBody(resp.Body, &token); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = authReqWithToken(t, token, mship.me, "GET", "/me", nil)
+	defer func() { _ = resp.Body.Close() }()
+
+	var me model.Auth
+	if err := parseBody(resp.Body, &me); err != nil {
+		t.Fatal(err)
+	} else if me.Email != invited || me.Role != 50 {
+		t.Errorf("expected %s with role 50 got %v", invited, me)
+	}
+
+	resp = dbReq(t, mship.acceptInvitation, "POST", "/invitations/accept", accept)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected an accepted invitation to be gone got %d", resp.StatusCode)
+	}
+}
+
+func TestInvitationRevoke(t *testing.T) {
+	mailer := &invitationMailer{}
+	prev := backend.Emailer
+	backend.Emailer = mailer
+	t.Cleanup(func() { backend.Emailer = prev })
+
+	resp := dbReq(t, acct.invitations, "POST", "/account/invitations", model.NewInvitation{Email: "invitation-revoke@test.com"})
+	defer func() { _ = resp.Body.Close() }()
+
+	var inv model.Invitation
+	if err := parseBody(resp.Body, &inv); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = dbReq(t, acct.invitation, "DELETE", "/account/invitations/"+inv.ID, nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	qs := url.Values{"token": {mailer.token}}
+	resp = dbReq(t, mship.acceptInvitation, "GET", "/invitations/accept?"+qs.Encode(), nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a revoked invitation to be gone got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,99 @@
+package staticbackend
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// respondLoginThrottled writes a 429 with the Retry-After header when the
+// client made too many failed attempts and returns false for any other error.
+func respondLoginThrottled(w http.ResponseWriter, err error) bool {
+	var te *backend.LoginThrottledError
+	if !errors.As(err, &te) {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(te.RetryAfter.Seconds()))))
+	http.Error(w, err.Error(), http.StatusTooManyRequests)
+	return true
+}
+
+func (m *membership) sudoLoginProtection(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := mship.GetLoginProtectionPolicy()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	case http.MethodPost:
+		var policy model.LoginProtectionPolicy
+		if err := parseBody(r.Body, &policy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.SetLoginProtectionPolicy(policy); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	default:
+		http.Error(w, "method not implemented", http.StatusNotImplemented)
+	}
+}
+
+// sudoLoginLocks lists the active lockouts and clears the ones of an email,
+// for all IP addresses unless the ip parameter is set.
+func (m *membership) sudoLoginLocks(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		locks, err := mship.ListLoginLocks()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, locks)
+	case http.MethodDelete:
+		email := r.URL.Query().Get("email")
+		if len(email) == 0 {
+			http.Error(w, "missing email", http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.ClearLoginLocks(email, r.URL.Query().Get("ip")); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,72 @@
+package staticbackend
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestLoginLockout(t *testing.T) {
+	resp := dbReq(t, mship.sudoLoginProtection, "POST", "/sudo/loginprotection", model.LoginProtectionPolicy{MaxAttempts: 1}, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+	t.Cleanup(func() {
+		resp := dbReq(t, mship.sudoLoginProtection, "POST", "/sudo/loginprotection", model.LoginProtectionPolicy{}, true)
+		_ = resp.Body.Close()
+	})
+
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := "lockout-http@test.com"
+	_, user, err := backend.Membership(conf).CreateUser(testAccountID, email, userPassword, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = backend.DB.RemoveUser(model.Auth{AccountID: testAccountID, UserID: user.ID, Role: 100}, dbName, user.ID)
+	})
+
+	resp = dbReq(t, mship.login, "POST", "/login", model.Login{Email: email, Password: "wrong"})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected a lockout got %d", resp.StatusCode)
+	} else if len(resp.Header.Get("Retry-After")) == 0 {
+		t.Error("expected a Retry-After header")
+	}
+
+	login := model.Login{Email: email, Password: userPassword}
+	resp = dbReq(t, mship.login, "POST", "/login", login)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected locked out users to be refused got %d", resp.StatusCode)
+	}
+
+	resp = dbReq(t, mship.sudoLoginLocks, "GET", "/sudo/loginprotection/locks", nil, true)
+	defer func() { _ = resp.Body.Close() }()
+
+	var locks []model.LoginLock
+	if err := parseBody(resp.Body, &locks); err != nil {
+		t.Fatal(err)
+	} else if len(locks) != 1 || locks[0].Email != email {
+		t.Fatalf("expected the lockout of %s got %v", email, locks)
+	}
+
+	resp = dbReq(t, mship.sudoLoginLocks, "DELETE", "/sudo/loginprotection/locks?email="+email, nil, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, mship.login, "POST", "/login", login)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected to sign in once the lock is cleared got %s", GetResponseBody(t, resp))
+	}
+}
@@ -54,17 +54,43 @@ func (m *membership) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mship := backend.Membership(conf)
+	mship := backend.Membership(conf).WithClient(r.UserAgent(), middleware.ClientIP(r))
+
+	// clients asking for a refresh token receive the session tokens
+	// instead of a single session token
+	if l.Refresh {
+		tokens, err := mship.Login(l.Email, l.Password, l.AccountID)
+		if respondMFAChallenge(w, err) || respondLoginThrottled(w, err) {
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), loginErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, tokens)
+		return
+	}
 
 	token, err := mship.Authenticate(l.Email, l.Password, l.AccountID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+	if respondMFAChallenge(w, err) || respondLoginThrottled(w, err) {
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), loginErrorStatus(err))
 		return
 	}
 
 	respond(w, http.StatusOK, token)
 }
 
+// loginErrorStatus returns 403 when the user must verify their email and
+// 401 for invalid credentials
+func loginErrorStatus(err error) int {
+	if errors.Is(err, model.ErrEmailNotVerified) {
+		return http.StatusForbidden
+	}
+	return http.StatusUnauthorized
+}
+
 func (m *membership) register(w http.ResponseWriter, r *http.Request) {
 	conf, _, err := middleware.Extract(r, false)
 	if err != nil {
@@ -79,9 +105,14 @@ func (m *membership) register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mship := backend.Membership(conf)
+	mship := backend.Membership(conf).WithClient(r.UserAgent(), middleware.ClientIP(r))
 	token, err := mship.Register(l.Email, l.Password, l.AccountID)
-	if err != nil {
+	if respondMFAChallenge(w, err) || respondLoginThrottled(w, err) || respondPasswordPolicy(w, err) {
+		return
+	} else if errors.Is(err, model.ErrEmailNotVerified) {
+		respond(w, http.StatusAccepted, model.EmailVerificationPending{EmailVerificationRequired: true})
+		return
+	} else if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -130,8 +161,10 @@ func (m *membership) resetPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mship := backend.Membership(conf)
-	if err := mship.ResetPassword(data.Email, data.Code, data.Password); err != nil {
+	mship := backend.Membership(conf).WithClient(r.UserAgent(), middleware.ClientIP(r))
+	if err := mship.ResetPassword(data.Email, data.Code, data.Password); respondLoginThrottled(w, err) || respondPasswordPolicy(w, err) {
+		return
+	} else if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -141,7 +174,7 @@ func (m *membership) resetPassword(w http.ResponseWriter, r *http.Request) {
 
 func (m *membership) setRole(w http.ResponseWriter, r *http.Request) {
 	conf, a, err := middleware.Extract(r, true)
-	if err != nil || a.Role < 50 {
+	if err != nil || !a.CanManageUsers() {
 		http.Error(w, "insufficient priviledges", http.StatusUnauthorized)
 		return
 	}
@@ -150,12 +183,25 @@ func (m *membership) setRole(w http.ResponseWriter, r *http.Request) {
 		AccountID string `json:"accountId"`
 		Email     string `json:"email"`
 		Role      int    `json:"role"`
+		// RoleName assigns a custom role, its level replaces Role
+		RoleName string `json:"roleName"`
 	})
 	if err := parseBody(r.Body, &data); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	mship := backend.Membership(conf)
+
+	if len(data.RoleName) > 0 {
+		role, err := mship.GetCustomRole(data.RoleName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data.Role = role.Level
+	}
+
 	if len(data.Email) == 0 {
 		http.Error(w, "missing account id or email", http.StatusBadRequest)
 		return
@@ -169,12 +215,13 @@ func (m *membership) setRole(w http.ResponseWriter, r *http.Request) {
 		data.AccountID = a.AccountID
 	}
 
-	mship := backend.Membership(conf)
-	if err := mship.SetUserRole(data.AccountID, data.Email, data.Role); err != nil {
+	if err := mship.SetUserRole(data.AccountID, data.Email, data.Role, data.RoleName); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	audit(r, model.AuditActionRoleChange, data.Email, fmt.Sprintf("account %s role %d %s", data.AccountID, data.Role, data.RoleName))
+
 	respond(w, http.StatusOK, true)
 }
 
@@ -221,25 +268,13 @@ func (m *membership) sudoGetTokenFromAccountID(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	token := fmt.Sprintf("%s|%s", tok.ID, tok.Token)
-
-	jwtBytes, err := backend.GetJWT(token)
+	jwtBytes, err := backend.Membership(conf).GetAuthToken(tok)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	auth := model.Auth{
-		AccountID: tok.AccountID,
-		UserID:    tok.ID,
-		Email:     tok.Email,
-		Role:      tok.Role,
-		Token:     tok.Token,
-	}
-	if err := backend.Cache.SetTyped(token, auth); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+	audit(r, model.AuditActionImpersonate, tok.ID, "account "+tok.AccountID)
 
 	respond(w, http.StatusOK, string(jwtBytes))
 }
@@ -287,6 +322,8 @@ func (m *membership) getAuthTokenByUserID(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	audit(r, model.AuditActionImpersonate, user.ID, "account "+accountID)
+
 	respond(w, http.StatusOK, string(jwtBytes))
 }
 
@@ -352,15 +389,24 @@ func (m *membership) changeEmail(w http.ResponseWriter, r *http.Request) {
 
 	mship := backend.Membership(conf)
 	if err := mship.ChangeEmail(auth, data.Email); err != nil {
-		if errors.Is(err, backend.ErrEmailAlreadyInUse) {
+		if errors.Is(err, backend.ErrEmailChangePending) {
+			audit(r, model.AuditActionEmailChange, auth.UserID, data.Email+" pending verification")
+			respond(w, http.StatusAccepted, model.EmailVerificationPending{EmailVerificationRequired: true})
+			return
+		} else if errors.Is(err, backend.ErrEmailAlreadyInUse) {
 			http.Error(w, err.Error(), http.StatusConflict)
 			return
+		} else if errors.Is(err, backend.ErrTooManyVerificationEmails) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
 		}
 
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	audit(r, model.AuditActionEmailChange, auth.UserID, data.Email)
+
 	respond(w, http.StatusOK, true)
 }
 
@@ -381,27 +427,12 @@ func (m *membership) deleteAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	files, err := backend.DB.ListAllFiles(conf.Name, auth.AccountID)
-	if err != nil {
+	if err := backend.Membership(conf).DeleteAccount(auth.AccountID); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	for _, file := range files {
-		if err := backend.Filestore.Delete(file.Key); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		if err := backend.DB.DeleteFile(conf.Name, file.ID); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-	}
-
-	if err := backend.DB.DeleteAccount(conf.Name, auth.AccountID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+	audit(r, model.AuditActionAccountDelete, auth.AccountID, "")
 
 	respond(w, http.StatusOK, true)
 }
@@ -413,7 +444,7 @@ func (m *membership) magicLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mship := backend.Membership(conf)
+	mship := backend.Membership(conf).WithClient(r.UserAgent(), middleware.ClientIP(r))
 
 	if r.Method == http.MethodGet {
 		// we use GET to validate magic link code
@@ -421,12 +452,9 @@ func (m *membership) magicLink(w http.ResponseWriter, r *http.Request) {
 		code := r.URL.Query().Get("code")
 
 		token, err := mship.ValidateMagicLink(email, code)
-		if err != nil {
-			if strings.Contains(err.Error(), "maximum") {
-				http.Error(w, err.Error(), http.StatusTooManyRequests)
-				return
-			}
-
+		if respondMFAChallenge(w, err) || respondLoginThrottled(w, err) {
+			return
+		} else if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
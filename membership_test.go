@@ -105,8 +105,20 @@ func TestChangeEmail(t *testing.T) {
 		t.Fatalf("expected auth cache to be cleared, found cached email %s", cached.Email)
 	}
 
+	// changing the email signs out all devices
 	resp = authReqWithToken(t, string(token), mship.me, "GET", "/me", nil)
 	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode <= 299 {
+		t.Fatal("expected the session to be revoked after the email change")
+	}
+
+	newToken, err := backend.Membership(conf).Authenticate(newEmail, userPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = authReqWithToken(t, newToken, mship.me, "GET", "/me", nil)
+	defer func() { _ = resp.Body.Close() }()
 	if resp.StatusCode > 299 {
 		t.Fatal(GetResponseBody(t, resp))
 	}
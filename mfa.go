@@ -0,0 +1,279 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+type MFACodeData struct {
+	Code string `json:"code"`
+}
+
+// respondMFAChallenge writes the challenge when the login requires a TOTP
+// code and returns false for any other error.
+func respondMFAChallenge(w http.ResponseWriter, err error) bool {
+	var ch *backend.MFAChallengeError
+	if !errors.As(err, &ch) {
+		return false
+	}
+
+	respond(w, http.StatusOK, ch.Challenge)
+	return true
+}
+
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, backend.ErrInvalidMFACode), errors.Is(err, backend.ErrInvalidMFAChallenge):
+		return http.StatusUnauthorized
+	case errors.Is(err, backend.ErrMFAPolicyRequired):
+		return http.StatusForbidden
+	case errors.Is(err, backend.ErrMFANotEnrolled), errors.Is(err, backend.ErrMFAAlreadyEnabled):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (m *membership) loginMFA(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	var data model.MFALogin
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf).WithClient(r.UserAgent(), middleware.ClientIP(r))
+	result, err := mship.CompleteMFALogin(data.Challenge, data.Code)
+	if respondLoginThrottled(w, err) {
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), mfaErrorStatus(err))
+		return
+	}
+
+	respond(w, http.StatusOK, result)
+}
+
+func (m *membership) loginMFAEnroll(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	var data model.MFALogin
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+	enrollment, err := mship.EnrollMFAChallenge(data.Challenge)
+	if err != nil {
+		http.Error(w, err.Error(), mfaErrorStatus(err))
+		return
+	}
+
+	respond(w, http.StatusOK, enrollment)
+}
+
+func (m *membership) mfaStatus(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf)
+	status, err := mship.GetMFAStatus(auth)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, status)
+}
+
+func (m *membership) mfaEnroll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf)
+	enrollment, err := mship.EnrollMFA(auth)
+	if err != nil {
+		http.Error(w, err.Error(), mfaErrorStatus(err))
+		return
+	}
+
+	respond(w, http.StatusOK, enrollment)
+}
+
+func (m *membership) mfaConfirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var data MFACodeData
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+	codes, err := mship.ConfirmMFA(auth, data.Code)
+	if err != nil {
+		http.Error(w, err.Error(), mfaErrorStatus(err))
+		return
+	}
+
+	respond(w, http.StatusOK, codes)
+}
+
+func (m *membership) mfaDisable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var data MFACodeData
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+	if err := mship.DisableMFA(auth, data.Code); err != nil {
+		http.Error(w, err.Error(), mfaErrorStatus(err))
+		return
+	}
+
+	respond(w, http.StatusOK, true)
+}
+
+func (m *membership) mfaRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var data MFACodeData
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+	codes, err := mship.RegenerateRecoveryCodes(auth, data.Code)
+	if err != nil {
+		http.Error(w, err.Error(), mfaErrorStatus(err))
+		return
+	}
+
+	respond(w, http.StatusOK, codes)
+}
+
+func (m *membership) sudoMFAPolicy(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := mship.GetMFAPolicy()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	case http.MethodPost:
+		var policy model.MFAPolicy
+		if err := parseBody(r.Body, &policy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.SetMFAPolicy(policy); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	default:
+		http.Error(w, "method not implemented", http.StatusNotImplemented)
+	}
+}
+
+func (m *membership) sudoResetMFA(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var data = new(struct {
+		UserID string `json:"userId"`
+	})
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(data.UserID) == 0 {
+		http.Error(w, "userId is required", http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+	if err := mship.ResetMFA(data.UserID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, true)
+}
@@ -0,0 +1,128 @@
+package staticbackend
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestMFALogin(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := "mfa-http@test.com"
+	token, user, err := backend.Membership(conf).CreateUser(testAccountID, email, userPassword, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = backend.DB.RemoveUser(model.Auth{AccountID: testAccountID, UserID: user.ID, Role: 100}, dbName, user.ID)
+	})
+
+	resp := authReqWithToken(t, string(token), mship.mfaEnroll, "POST", "/me/mfa/enroll", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var enrollment model.MFAEnrollment
+	if err := parseBody(resp.Body, &enrollment); err != nil {
+		t.Fatal(err)
+	}
+
+	// a TOTP code is single use, the previous code confirms the enrollment
+	// and the current one completes the login
+	prev, err := internal.TOTPCode(enrollment.Secret, time.Now().Add(-internal.TOTPPeriod*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := internal.TOTPCode(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = authReqWithToken(t, string(token), mship.mfaConfirm, "POST", "/me/mfa/confirm", MFACodeData{Code: prev})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	login := model.Login{Email: email, Password: userPassword}
+	resp = dbReq(t, mship.login, "POST", "/login", login)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var ch model.MFAChallenge
+	if err := parseBody(resp.Body, &ch); err != nil {
+		t.Fatal(err)
+	} else if !ch.MFARequired || len(ch.Challenge) == 0 {
+		t.Fatalf("expected an MFA challenge got %v", ch)
+	}
+
+	resp = dbReq(t, mship.loginMFA, "POST", "/login/mfa", model.MFALogin{Challenge: ch.Challenge, Code: "000000"})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 got %d", resp.StatusCode)
+	}
+
+	// the failed code delays the next attempt
+	time.Sleep(time.Second)
+
+	resp = dbReq(t, mship.loginMFA, "POST", "/login/mfa", model.MFALogin{Challenge: ch.Challenge, Code: code})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var result model.MFALoginResult
+	if err := parseBody(resp.Body, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = authReqWithToken(t, result.Token, mship.mfaStatus, "GET", "/me/mfa", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var status model.MFAStatus
+	if err := parseBody(resp.Body, &status); err != nil {
+		t.Fatal(err)
+	} else if !status.Enabled {
+		t.Error("expected MFA to be enabled")
+	}
+}
+
+func TestMFAPolicy(t *testing.T) {
+	resp := dbReq(t, mship.sudoMFAPolicy, "POST", "/sudo/mfa", model.MFAPolicy{Required: true}, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+	t.Cleanup(func() {
+		resp := dbReq(t, mship.sudoMFAPolicy, "POST", "/sudo/mfa", model.MFAPolicy{}, true)
+		_ = resp.Body.Close()
+	})
+
+	resp = dbReq(t, mship.sudoMFAPolicy, "GET", "/sudo/mfa", nil, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var policy model.MFAPolicy
+	if err := parseBody(resp.Body, &policy); err != nil {
+		t.Fatal(err)
+	} else if !policy.Required {
+		t.Error("expected MFA to be required")
+	}
+}
@@ -0,0 +1,239 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+)
+
+// apiKeyUsedInterval is how often the last used time of an API key is saved
+const apiKeyUsedInterval = time.Minute
+
+var ErrAPIKeyForbidden = errors.New("API key is not allowed to access this resource")
+
+// RouteScope is the API key scope required by a route. NamePart is the URL
+// part holding the collection or function name and Read flags routes only
+// reading data whatever their HTTP method.
+type RouteScope struct {
+	Scope    string
+	NamePart int
+	Read     bool
+}
+
+// WithScope sets the scope API keys need to access the route. It must come
+// before RequireAuth or RequireRoot in the chain. Routes without a scope
+// refuse API keys.
+func WithScope(scope RouteScope) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), ContextScope, scope)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// APIKeyCacheKey returns the cache key holding an API key while it is valid
+func APIKeyCacheKey(id string) string {
+	return "apikey-" + id
+}
+
+// HashAPIKeySecret returns the hash stored for the secret part of an API key
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ClientIP returns the IP address of the client. The forwarding headers are
+// only used when the request comes from a trusted proxy, the client is then
+// the right-most address of X-Forwarded-For that is not a trusted proxy.
+func ClientIP(r *http.Request) string {
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+
+	trusted := trustedProxies()
+	if !isTrustedProxy(trusted, peer) {
+		return peer
+	}
+
+	if fwd := r.Header.Get("X-Forwarded-For"); len(fwd) > 0 {
+		hops := strings.Split(fwd, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(hops[i])
+			if net.ParseIP(ip) == nil {
+				break
+			} else if !isTrustedProxy(trusted, ip) {
+				return ip
+			}
+		}
+	} else if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+	return peer
+}
+
+// trustedProxies returns the networks of the configured trusted proxies, a
+// single IP address is a network of one address.
+func trustedProxies() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, v := range config.Current.TrustedProxies {
+		if !strings.Contains(v, "/") {
+			if ip := net.ParseIP(v); ip != nil && ip.To4() != nil {
+				v += "/32"
+			} else {
+				v += "/128"
+			}
+		}
+
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+func isTrustedProxy(trusted []*net.IPNet, addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveWithAPIKey calls the next handler authenticated with an API key
+func serveWithAPIKey(datastore database.Persister, volatile cache.Volatilizer, next http.Handler, w http.ResponseWriter, r *http.Request, key string) {
+	auth, err := ValidateAPIKey(datastore, volatile, r, key)
+	if errors.Is(err, ErrAPIKeyForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	} else if err != nil {
+		http.Error(w, fmt.Sprintf("error validating API key: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), ContextAuth, auth)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// apiKeyState is the cached API key with its hash, which is not part of the
+// API key JSON.
+type apiKeyState struct {
+	Key  model.APIKey `json:"key"`
+	Hash string       `json:"hash"`
+}
+
+// ValidateAPIKey validates an API key for the request. The key must not be
+// expired, the client IP must be allowed and the key scopes must grant the
+// route scope set by WithScope. An error wrapping ErrAPIKeyForbidden is
+// returned when the key is valid but not allowed.
+func ValidateAPIKey(datastore database.Persister, volatile cache.Volatilizer, r *http.Request, key string) (model.Auth, error) {
+	ctx := r.Context()
+
+	conf, ok := ctx.Value(ContextBase).(model.DatabaseConfig)
+	if !ok {
+		return model.Auth{}, fmt.Errorf("invalid StaticBackend public token")
+	}
+
+	id, secret, ok := strings.Cut(strings.TrimPrefix(key, model.APIKeyPrefix), ".")
+	if !ok || len(secret) == 0 {
+		return model.Auth{}, model.ErrInvalidAPIKey
+	}
+
+	st, err := loadAPIKey(datastore, volatile, conf.Name, id)
+	if err != nil {
+		return model.Auth{}, err
+	}
+
+	k := st.Key
+	if subtle.ConstantTimeCompare([]byte(HashAPIKeySecret(secret)), []byte(st.Hash)) != 1 {
+		return model.Auth{}, model.ErrInvalidAPIKey
+	} else if k.Expires != nil && time.Now().After(*k.Expires) {
+		return model.Auth{}, fmt.Errorf("API key has expired")
+	} else if !k.AllowsIP(ClientIP(r)) {
+		return model.Auth{}, fmt.Errorf("%w: IP address not allowed", ErrAPIKeyForbidden)
+	}
+
+	scope, ok := ctx.Value(ContextScope).(RouteScope)
+	if !ok {
+		return model.Auth{}, ErrAPIKeyForbidden
+	}
+
+	name, write := routeResource(r, scope)
+	if !k.Scopes.Allows(scope.Scope, name, write) {
+		return model.Auth{}, ErrAPIKeyForbidden
+	}
+
+	auth := model.Auth{
+		AccountID: k.AccountID,
+		UserID:    k.UserID,
+		Role:      RootRole,
+		Token:     model.APIKeyPrefix + k.ID,
+		APIKeyID:  k.ID,
+	}
+
+	if st.needsTouch(time.Now()) {
+		if err := touchAPIKey(datastore, volatile, conf.Name, st); err != nil {
+			return auth, err
+		}
+
+		// set base:token useful when executing pubsub event message / function
+		token := auth.ReconstructToken()
+		if err := volatile.SetTyped(token, auth); err != nil {
+			return auth, err
+		}
+		if err := volatile.SetTyped("base:"+token, conf); err != nil {
+			return auth, err
+		}
+	}
+	return auth, nil
+}
+
+// loadAPIKey returns the cached API key or loads it from the database when
+// its last used time needs to be saved, which picks up revoked keys.
+func loadAPIKey(datastore database.Persister, volatile cache.Volatilizer, dbName, id string) (st apiKeyState, err error) {
+	if err := volatile.GetTyped(APIKeyCacheKey(id), &st); err == nil && !st.needsTouch(time.Now()) {
+		return st, nil
+	}
+
+	k, err := datastore.GetAPIKey(dbName, id)
+	if err != nil {
+		return st, model.ErrInvalidAPIKey
+	}
+	return apiKeyState{Key: k, Hash: k.KeyHash}, nil
+}
+
+// touchAPIKey saves the last used time of a valid API key
+func touchAPIKey(datastore database.Persister, volatile cache.Volatilizer, dbName string, st apiKeyState) error {
+	now := time.Now()
+	if err := datastore.TouchAPIKey(dbName, st.Key.ID, now); err != nil {
+		return err
+	}
+
+	st.Key.LastUsed = &now
+	return volatile.SetTyped(APIKeyCacheKey(st.Key.ID), st)
+}
+
+func (st apiKeyState) needsTouch(now time.Time) bool {
+	return st.Key.LastUsed == nil || now.Sub(*st.Key.LastUsed) >= apiKeyUsedInterval
+}
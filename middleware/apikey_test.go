@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/staticbackendhq/core/config"
+)
+
+func TestClientIP(t *testing.T) {
+	defer func(v []string) { config.Current.TrustedProxies = v }(config.Current.TrustedProxies)
+	config.Current.TrustedProxies = []string{"10.0.0.1", "192.168.0.0/16"}
+
+	tests := []struct {
+		name   string
+		remote string
+		fwd    string
+		realIP string
+		want   string
+	}{
+		{name: "direct client", remote: "1.2.3.4:1000", want: "1.2.3.4"},
+		{name: "spoofed header from untrusted peer", remote: "1.2.3.4:1000", fwd: "9.9.9.9", realIP: "8.8.8.8", want: "1.2.3.4"},
+		{name: "trusted proxy", remote: "10.0.0.1:1000", fwd: "5.6.7.8", want: "5.6.7.8"},
+		{name: "right-most untrusted hop", remote: "10.0.0.1:1000", fwd: "9.9.9.9, 5.6.7.8, 192.168.1.1", want: "5.6.7.8"},
+		{name: "real ip from trusted proxy", remote: "10.0.0.1:1000", realIP: "5.6.7.8", want: "5.6.7.8"},
+		{name: "invalid hop", remote: "10.0.0.1:1000", fwd: "garbage", want: "10.0.0.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tc.remote
+			if len(tc.fwd) > 0 {
+				req.Header.Set("X-Forwarded-For", tc.fwd)
+			}
+			if len(tc.realIP) > 0 {
+				req.Header.Set("X-Real-IP", tc.realIP)
+			}
+
+			if ip := ClientIP(req); ip != tc.want {
+				t.Errorf("expected %s got %s", tc.want, ip)
+			}
+		})
+	}
+}
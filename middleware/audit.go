@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+)
+
+// auditPurgeInterval is how often entries older than the retention are
+// removed from a database audit log
+const auditPurgeInterval = time.Hour
+
+// auditPurged holds the last time the audit log of a database was purged
+var auditPurged sync.Map
+
+// AuditPolicyCacheKey returns the cache key holding the audit policy of a
+// database
+func AuditPolicyCacheKey(dbName string) string {
+	return "audit-policy-" + dbName
+}
+
+// LoadAuditPolicy returns the audit policy of a database, it is cached until
+// changed.
+func LoadAuditPolicy(datastore database.Persister, volatile cache.Volatilizer, dbName string) (policy model.AuditPolicy, err error) {
+	if err = volatile.GetTyped(AuditPolicyCacheKey(dbName), &policy); err == nil {
+		return
+	}
+
+	b, err := datastore.GetSetting(dbName, model.SettingAuditPolicy)
+	if err != nil {
+		return
+	} else if b != nil {
+		if err = json.Unmarshal(b, &policy); err != nil {
+			return
+		}
+	}
+
+	err = volatile.SetTyped(AuditPolicyCacheKey(dbName), policy)
+	return
+}
+
+// RecordAudit appends an entry to the audit log of the request's database.
+// The actor and IP are the ones of the request. Entries older than the
+// retention are removed at most once per auditPurgeInterval.
+func RecordAudit(datastore database.Persister, volatile cache.Volatilizer, r *http.Request, entry model.AuditEntry) error {
+	conf, ok := r.Context().Value(ContextBase).(model.DatabaseConfig)
+	if !ok {
+		return nil
+	}
+
+	auth, _ := r.Context().Value(ContextAuth).(model.Auth)
+	if len(entry.AccountID) == 0 {
+		entry.AccountID = auth.AccountID
+	}
+	entry.ActorID = auth.UserID
+	entry.ActorEmail = auth.Email
+	entry.APIKeyID = auth.APIKeyID
+	entry.IP = ClientIP(r)
+	entry.Created = time.Now()
+
+	if err := datastore.AddAuditEntry(conf.Name, entry); err != nil {
+		return err
+	}
+
+	if last, ok := auditPurged.Load(conf.Name); ok && time.Since(last.(time.Time)) < auditPurgeInterval {
+		return nil
+	}
+	auditPurged.Store(conf.Name, entry.Created)
+
+	policy, err := LoadAuditPolicy(datastore, volatile, conf.Name)
+	if err != nil {
+		return err
+	}
+
+	_, err = datastore.DeleteAuditEntriesBefore(conf.Name, entry.Created.Add(-policy.Retention()))
+	return err
+}
+
+// AuditRoot records the root requests changing data once they succeed. Root
+// requests reading data are not recorded, handlers impersonating users
+// record their own entry.
+func AuditRoot(datastore database.Persister, volatile cache.Volatilizer) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tw := &telemetryResponseWriter{ResponseWriter: w}
+			ww := http.ResponseWriter(tw)
+			if _, ok := w.(http.Flusher); ok {
+				ww = &telemetryFlushResponseWriter{telemetryResponseWriter: tw}
+			}
+
+			next.ServeHTTP(ww, r)
+
+			if tw.statusCode >= http.StatusBadRequest {
+				return
+			}
+
+			entry := model.AuditEntry{
+				Action:  model.AuditActionSudo,
+				Target:  r.URL.Path,
+				Details: r.Method,
+			}
+			if err := RecordAudit(datastore, volatile, r, entry); err != nil {
+				slog.Error("error recording root request in the audit log", "error", err)
+			}
+		})
+	}
+}
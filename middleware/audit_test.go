@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/database/memory"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestAuditRootRecordsSuccessfulWrites(t *testing.T) {
+	datastore := memory.New(nil)
+	vol := &telemetryCache{}
+
+	status := http.StatusOK
+	h := AuditRoot(datastore, vol)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	serve := func(method string) {
+		req := telemetryRequest("/sudo/tasks", true)
+		req.Method = method
+		req.RemoteAddr = "10.0.0.1:1234"
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	serve(http.MethodGet)
+	serve(http.MethodPost)
+	status = http.StatusBadRequest
+	serve(http.MethodDelete)
+
+	entries, err := datastore.ListAuditEntries("testbase", model.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 {
+		t.Fatalf("expected only the successful write to be recorded got %v", entries)
+	}
+
+	e := entries[0]
+	if e.Action != model.AuditActionSudo || e.Target != "/sudo/tasks" || e.Details != http.MethodPost {
+		t.Errorf("expected a sudo POST /sudo/tasks entry got %v", e)
+	} else if e.ActorID != "user-1" || e.AccountID != "account-1" || e.IP != "10.0.0.1" {
+		t.Errorf("expected the actor and IP of the request got %v", e)
+	}
+}
+
+func TestRecordAuditRemovesExpiredEntries(t *testing.T) {
+	datastore := memory.New(nil)
+	vol := &telemetryCache{}
+
+	expired := model.AuditEntry{Action: model.AuditActionImpersonate, Created: time.Now().Add(-model.DefaultAuditRetention - time.Hour)}
+	if err := datastore.AddAuditEntry("testbase", expired); err != nil {
+		t.Fatal(err)
+	}
+
+	auditPurged.Delete("testbase")
+
+	req := telemetryRequest("/setrole", true)
+	if err := RecordAudit(datastore, vol, req, model.AuditEntry{Action: model.AuditActionRoleChange}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := datastore.ListAuditEntries("testbase", model.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 || entries[0].Action != model.AuditActionRoleChange {
+		t.Errorf("expected the expired entry to be removed got %v", entries)
+	}
+}
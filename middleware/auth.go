@@ -2,8 +2,10 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -15,8 +17,17 @@ import (
 
 const (
 	RootRole = 100
+
+	// sessionSeenInterval is how often the last seen time of a session is
+	// saved
+	sessionSeenInterval = 5 * time.Minute
 )
 
+// SessionCacheKey returns the cache key holding a session while it is valid
+func SessionCacheKey(id string) string {
+	return "session-" + id
+}
+
 // RequireAuth validates that a session token is valid.
 // If not valid a 401 HTTP error is returned.
 //
@@ -57,6 +68,11 @@ func RequireAuth(datastore database.Persister, volatile cache.Volatilizer) Middl
 
 			key = strings.ReplaceAll(key, "Bearer ", "")
 
+			if strings.HasPrefix(key, model.APIKeyPrefix) {
+				serveWithAPIKey(datastore, volatile, next, w, r, key)
+				return
+			}
+
 			ctx := r.Context()
 
 			auth, err := ValidateAuthKey(datastore, volatile, ctx, key)
@@ -66,6 +82,24 @@ func RequireAuth(datastore database.Persister, volatile cache.Volatilizer) Middl
 				return
 			}
 
+			conf, _ := ctx.Value(ContextBase).(model.DatabaseConfig)
+			if err := requireVerifiedEmail(datastore, volatile, r, conf, auth); errors.Is(err, model.ErrEmailNotVerified) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			} else if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			auth, err = applyCustomRole(datastore, volatile, r, conf, auth)
+			if errors.Is(err, model.ErrRoleNotAllowed) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			} else if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
 			ctx = context.WithValue(ctx, ContextAuth, auth)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -75,29 +109,60 @@ func RequireAuth(datastore database.Persister, volatile cache.Volatilizer) Middl
 
 // ValidateAuthKey validates a session token
 func ValidateAuthKey(datastore database.Persister, volatile cache.Volatilizer, ctx context.Context, key string) (model.Auth, error) {
-	a := model.Auth{}
+	var pl model.JWTPayload
+	hd, err := verifyJWT(key, &pl)
+	if err != nil {
+		return model.Auth{}, err
+	} else if len(pl.ClientID) > 0 {
+		return model.Auth{}, fmt.Errorf("the access tokens of OAuth clients are only accepted by the userinfo endpoint")
+	}
 
-	now := time.Now()
+	// tokens issued before sessions have none, they are accepted as long as
+	// their HS256 signature is
+	if len(pl.SessionID) == 0 && hd.Algorithm != model.JWTAlgHS256 {
+		return model.Auth{}, fmt.Errorf("session token required, please sign in again")
+	}
+
+	return validatePayload(datastore, volatile, ctx, pl)
+}
+
+// ValidateClientAccessToken validates an access token issued to an OAuth
+// client by the identity provider of the audience and returns its scope
+func ValidateClientAccessToken(datastore database.Persister, volatile cache.Volatilizer, ctx context.Context, key, audience string) (model.Auth, string, error) {
 	var pl model.JWTPayload
-	if _, err := jwt.Verify([]byte(key), model.HashSecret, &pl,
+	if _, err := verifyJWT(key, &pl); err != nil {
+		return model.Auth{}, "", err
+	} else if len(pl.ClientID) == 0 || len(pl.SessionID) == 0 || !slices.Contains(pl.Audience, audience) {
+		return model.Auth{}, "", fmt.Errorf("invalid access token")
+	}
+
+	auth, err := validatePayload(datastore, volatile, ctx, pl)
+	return auth, pl.Scope, err
+}
+
+func verifyJWT(key string, pl *model.JWTPayload) (jwt.Header, error) {
+	now := time.Now()
+	hd, err := model.JWTKeys.Verify([]byte(key), pl,
 		jwt.ValidatePayload(&pl.Payload,
 			jwt.ExpirationTimeValidator(now),
 			jwt.NotBeforeValidator(now),
 		),
-	); err != nil {
-		return a, fmt.Errorf("could not verify your authentication token: %s", err.Error())
+	)
+	if err != nil {
+		return hd, fmt.Errorf("could not verify your authentication token: %s", err.Error())
 	}
+	return hd, nil
+}
+
+// validatePayload returns the user of a verified token payload
+func validatePayload(datastore database.Persister, volatile cache.Volatilizer, ctx context.Context, pl model.JWTPayload) (model.Auth, error) {
+	a := model.Auth{}
 
 	conf, ok := ctx.Value(ContextBase).(model.DatabaseConfig)
 	if !ok {
 		return a, fmt.Errorf("invalid StaticBackend public token")
 	}
 
-	var auth model.Auth
-	if err := volatile.GetTyped(pl.Token, &auth); err == nil {
-		return auth, nil
-	}
-
 	// pl.Token format is "{userID}|{token}"
 	parts := strings.Split(pl.Token, "|")
 	if len(parts) != 2 {
@@ -105,6 +170,19 @@ func ValidateAuthKey(datastore database.Persister, volatile cache.Volatilizer, c
 	}
 	userID, rawToken := parts[0], parts[1]
 
+	if len(pl.SessionID) > 0 {
+		if err := validateSession(datastore, volatile, conf.Name, pl.SessionID, userID); err != nil {
+			return a, err
+		}
+	}
+
+	var auth model.Auth
+	if err := volatile.GetTyped(pl.Token, &auth); err == nil {
+		auth.SessionID = pl.SessionID
+		auth.Claims = pl.Claims
+		return auth, nil
+	}
+
 	// TODO: This was datastore.FindAccount(token.AccountID) before the
 	// backend refactor, this is very strange and should not have worked.....
 	// I changed it to use the tenant's ID from current database, which was what
@@ -125,13 +203,31 @@ func ValidateAuthKey(datastore database.Persister, volatile cache.Volatilizer, c
 			return a, fmt.Errorf("invalid user id in token")
 		}
 
+		verified, err := IsEmailVerified(datastore, conf.Name, assoc.UserID, assoc.Email)
+		if err != nil {
+			return a, err
+		}
+
+		roleName, err := loadRoleName(datastore, conf.Name, assoc.UserID, assoc.AccountID)
+		if err != nil {
+			return a, err
+		}
+
+		groups, err := datastore.ListUserGroups(conf.Name, assoc.AccountID, assoc.UserID)
+		if err != nil {
+			return a, err
+		}
+
 		a = model.Auth{
-			AccountID: assoc.AccountID,
-			UserID:    assoc.UserID,
-			Email:     assoc.Email,
-			Role:      assoc.Role,
-			Token:     assoc.Token,
-			Plan:      cus.Plan,
+			AccountID:     assoc.AccountID,
+			UserID:        assoc.UserID,
+			Email:         assoc.Email,
+			Role:          assoc.Role,
+			Token:         assoc.Token,
+			Plan:          cus.Plan,
+			EmailVerified: verified,
+			RoleName:      roleName,
+			Groups:        groups,
 		}
 		if err := volatile.SetTyped(pl.Token, a); err != nil {
 			return a, err
@@ -139,16 +235,37 @@ func ValidateAuthKey(datastore database.Persister, volatile cache.Volatilizer, c
 		if err := volatile.SetTyped("base:"+pl.Token, conf); err != nil {
 			return a, err
 		}
+
+		a.SessionID = pl.SessionID
+		a.Claims = pl.Claims
 		return a, nil
 	}
 
+	verified, err := IsEmailVerified(datastore, conf.Name, tok.ID, tok.Email)
+	if err != nil {
+		return a, err
+	}
+
+	roleName, err := loadRoleName(datastore, conf.Name, tok.ID, tok.AccountID)
+	if err != nil {
+		return a, err
+	}
+
+	groups, err := datastore.ListUserGroups(conf.Name, tok.AccountID, tok.ID)
+	if err != nil {
+		return a, err
+	}
+
 	a = model.Auth{
-		AccountID: tok.AccountID,
-		UserID:    tok.ID,
-		Email:     tok.Email,
-		Role:      tok.Role,
-		Token:     tok.Token,
-		Plan:      cus.Plan,
+		AccountID:     tok.AccountID,
+		UserID:        tok.ID,
+		Email:         tok.Email,
+		Role:          tok.Role,
+		Token:         tok.Token,
+		Plan:          cus.Plan,
+		EmailVerified: verified,
+		RoleName:      roleName,
+		Groups:        groups,
 	}
 	if err := volatile.SetTyped(pl.Token, a); err != nil {
 		return a, err
@@ -159,9 +276,44 @@ func ValidateAuthKey(datastore database.Persister, volatile cache.Volatilizer, c
 		return a, err
 	}
 
+	a.SessionID = pl.SessionID
+	a.Claims = pl.Claims
 	return a, nil
 }
 
+// validateSession returns an error if the session of an access token was
+// revoked or expired. Sessions are cached and reloaded from the database
+// when their last seen time is saved.
+func validateSession(datastore database.Persister, volatile cache.Volatilizer, dbName, id, userID string) error {
+	now := time.Now()
+
+	var s model.Session
+	if err := volatile.GetTyped(SessionCacheKey(id), &s); err != nil || now.Sub(s.LastSeen) >= sessionSeenInterval {
+		s, err = datastore.GetSession(dbName, id)
+		if err != nil {
+			return fmt.Errorf("session has been revoked")
+		}
+
+		if now.Sub(s.LastSeen) >= sessionSeenInterval {
+			if err := datastore.TouchSession(dbName, id, now); err != nil {
+				return err
+			}
+			s.LastSeen = now
+		}
+
+		if err := volatile.SetTyped(SessionCacheKey(id), s); err != nil {
+			return err
+		}
+	}
+
+	if s.UserID != userID {
+		return fmt.Errorf("invalid session for this user")
+	} else if now.After(s.Expires) {
+		return fmt.Errorf("session has expired")
+	}
+	return nil
+}
+
 // RequireRoot validates that the token provided is for a "root" user.
 func RequireRoot(datastore database.Persister, volatile cache.Volatilizer) Middleware {
 	return func(next http.Handler) http.Handler {
@@ -189,6 +341,11 @@ func RequireRoot(datastore database.Persister, volatile cache.Volatilizer) Middl
 
 			key = strings.ReplaceAll(key, "Bearer ", "")
 
+			if strings.HasPrefix(key, model.APIKeyPrefix) {
+				serveWithAPIKey(datastore, volatile, next, w, r, key)
+				return
+			}
+
 			// in dev mode the cache will have a key called:
 			// dev-root-token which hold the dynamically changing root token
 			// which changes each stop/start of the CLI. Using
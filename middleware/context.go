@@ -15,6 +15,7 @@ type ContextKey int
 const (
 	ContextAuth ContextKey = iota
 	ContextBase
+	ContextScope
 )
 
 // Extract extracts the DatabaseConfig and Auth for the request
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+)
+
+// EmailVerificationPolicyCacheKey returns the cache key holding the email
+// verification policy of a database
+func EmailVerificationPolicyCacheKey(dbName string) string {
+	return "email-verification-policy-" + dbName
+}
+
+// LoadEmailVerificationPolicy returns the email verification policy of a
+// database, it is cached until changed.
+func LoadEmailVerificationPolicy(datastore database.Persister, volatile cache.Volatilizer, dbName string) (policy model.EmailVerificationPolicy, err error) {
+	if err = volatile.GetTyped(EmailVerificationPolicyCacheKey(dbName), &policy); err == nil {
+		return
+	}
+
+	b, err := datastore.GetSetting(dbName, model.SettingEmailVerification)
+	if err != nil {
+		return
+	} else if b != nil {
+		if err = json.Unmarshal(b, &policy); err != nil {
+			return
+		}
+	}
+
+	err = volatile.SetTyped(EmailVerificationPolicyCacheKey(dbName), policy)
+	return
+}
+
+// IsEmailVerified returns true if the user verified their current email
+func IsEmailVerified(datastore database.Persister, dbName, userID, email string) (bool, error) {
+	v, err := datastore.GetEmailVerification(dbName, userID)
+	if err != nil {
+		return false, err
+	}
+	return v.Verifies(email), nil
+}
+
+// requireVerifiedEmail returns model.ErrEmailNotVerified when the database
+// requires email verification and the route is not allowed to unverified
+// users.
+func requireVerifiedEmail(datastore database.Persister, volatile cache.Volatilizer, r *http.Request, conf model.DatabaseConfig, auth model.Auth) error {
+	if auth.EmailVerified {
+		return nil
+	}
+
+	policy, err := LoadEmailVerificationPolicy(datastore, volatile, conf.Name)
+	if err != nil {
+		return err
+	}
+
+	scope, _ := r.Context().Value(ContextScope).(RouteScope)
+	if policy.Allows(scope.Scope, !scope.Read && r.Method != http.MethodGet) {
+		return nil
+	}
+
+	// the cached user might have verified their email since
+	ok, err := IsEmailVerified(datastore, conf.Name, auth.UserID, auth.Email)
+	if err != nil {
+		return err
+	} else if !ok {
+		return model.ErrEmailNotVerified
+	}
+	return nil
+}
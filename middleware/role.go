@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/database"
+	"github.com/staticbackendhq/core/model"
+)
+
+// CustomRolesCacheKey returns the cache key holding the custom roles of a
+// database
+func CustomRolesCacheKey(dbName string) string {
+	return "custom-roles-" + dbName
+}
+
+// LoadCustomRoles returns the custom roles of a database, they are cached
+// until changed.
+func LoadCustomRoles(datastore database.Persister, volatile cache.Volatilizer, dbName string) (roles model.CustomRoles, err error) {
+	if err = volatile.GetTyped(CustomRolesCacheKey(dbName), &roles); err == nil {
+		return
+	}
+
+	b, err := datastore.GetSetting(dbName, model.SettingCustomRoles)
+	if err != nil {
+		return
+	} else if b != nil {
+		if err = json.Unmarshal(b, &roles); err != nil {
+			return
+		}
+	}
+
+	err = volatile.SetTyped(CustomRolesCacheKey(dbName), roles)
+	return
+}
+
+// routeResource returns the collection or function name of the request for
+// its route scope and if the request writes data.
+func routeResource(r *http.Request, scope RouteScope) (name string, write bool) {
+	if scope.NamePart > 0 {
+		if parts := strings.Split(r.URL.Path, "/"); len(parts) > scope.NamePart {
+			name = parts[scope.NamePart]
+		}
+	}
+	return name, !scope.Read && r.Method != http.MethodGet
+}
+
+// applyCustomRole sets the capabilities of the user's custom role and
+// returns model.ErrRoleNotAllowed when they do not grant the route scope.
+// Users without a custom role, or whose role was removed, keep their
+// integer role.
+func applyCustomRole(datastore database.Persister, volatile cache.Volatilizer, r *http.Request, conf model.DatabaseConfig, auth model.Auth) (model.Auth, error) {
+	if len(auth.RoleName) == 0 {
+		return auth, nil
+	}
+
+	roles, err := LoadCustomRoles(datastore, volatile, conf.Name)
+	if err != nil {
+		return auth, err
+	}
+
+	role, ok := roles.Find(auth.RoleName)
+	if !ok {
+		return auth, nil
+	}
+	auth.Capabilities = &role.Capabilities
+
+	scope, _ := r.Context().Value(ContextScope).(RouteScope)
+	name, write := routeResource(r, scope)
+	if !role.Capabilities.Allows(scope.Scope, name, write) {
+		return auth, model.ErrRoleNotAllowed
+	}
+	return auth, nil
+}
+
+// loadRoleName returns the custom role of a user in an account
+func loadRoleName(datastore database.Persister, dbName, userID, accountID string) (string, error) {
+	role, err := datastore.GetUserRole(dbName, userID, accountID)
+	if err != nil {
+		return "", err
+	}
+	return role.Name, nil
+}
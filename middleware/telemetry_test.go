@@ -22,6 +22,11 @@ func (c *telemetryCache) GetTyped(key string, v any) error
 func (c *telemetryCache) SetTyped(key string, v any) error                                          { return nil }
 func (c *telemetryCache) Inc(key string, by int64) (int64, error)                                   { return 0, nil }
 func (c *telemetryCache) Dec(key string, by int64) (int64, error)                                   { return 0, nil }
+func (c *telemetryCache) SetField(key, field, value string) error                                   { return nil }
+func (c *telemetryCache) GetFields(key string) (map[string]string, error)                           { return nil, nil }
+func (c *telemetryCache) DeleteField(key, field string) error                                       { return nil }
+func (c *telemetryCache) PushCapped(key, value string, size int, ttl time.Duration) error           { return nil }
+func (c *telemetryCache) GetList(key string) ([]string, error)                                      { return nil, nil }
 func (c *telemetryCache) Subscribe(send chan model.Command, token, channel string, close chan bool) {}
 func (c *telemetryCache) Publish(msg model.Command) error {
 	c.published = append(c.published, msg)
@@ -8,7 +8,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"strings"
 	"time"
 
 	"github.com/gbrlsnchs/jwt/v3"
@@ -23,19 +22,51 @@ type Auth struct {
 	Role      int    `json:"role"`
 	Token     string `json:"-"`
 	Plan      int    `json:"-"`
+	SessionID string `json:"-"`
+	APIKeyID  string `json:"-"`
+
+	// EmailVerified is set when the user verified their current email
+	EmailVerified bool `json:"emailVerified"`
+
+	// RoleName is the custom role of the user in the account, its
+	// capabilities are set by the middleware when the role exists.
+	RoleName     string            `json:"roleName,omitempty"`
+	Capabilities *RoleCapabilities `json:"-"`
+
+	// Groups are the ids of the groups of the user in the account, see
+	// FieldGroupID
+	Groups []string `json:"groups,omitempty"`
+
+	// Claims are the profile fields embedded in the session token, see
+	// ProfilePolicy. They are the values when the token was issued.
+	Claims map[string]any `json:"claims,omitempty"`
 }
 
-func (auth Auth) ReconstructToken() string {
-	if strings.HasPrefix(auth.Token, "__tmp__experimental_public") {
-		return auth.Token
+// CanManageUsers returns true if the user can manage the users of their
+// account, custom roles must allow it, otherwise the role must be 50 or more.
+func (auth Auth) CanManageUsers() bool {
+	if auth.Capabilities != nil {
+		return auth.Capabilities.ManageUsers || auth.Role == 100
 	}
+	return auth.Role >= 50
+}
+
+func (auth Auth) ReconstructToken() string {
 	return fmt.Sprintf("%s|%s", auth.UserID, auth.Token)
 }
 
 // JWTPayload contains the current user token
 type JWTPayload struct {
 	jwt.Payload
-	Token string `json:"token,omitempty"`
+	Token     string `json:"token,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+	// Claims are the profile fields selected by the database ProfilePolicy
+	Claims map[string]any `json:"claims,omitempty"`
+	// ClientID and Scope are set on the access tokens issued to the OAuth
+	// clients of the database identity provider, those tokens are only
+	// accepted by its userinfo endpoint
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
 }
 
 type Account struct {
@@ -59,6 +90,9 @@ type Login struct {
 	Email     string `json:"email"`
 	Password  string `json:"password"`
 	AccountID string `json:"accountId,omitempty"`
+	// Refresh requests a short-lived access token and a refresh token
+	// instead of a single session token
+	Refresh bool `json:"refresh,omitempty"`
 }
 
 // AccountUser represents a cross-account membership for a user.
@@ -167,7 +201,23 @@ func (cus *Tenant) GetProvider(provider string) (cfg OAuthConfig, ok bool) {
 	return
 }
 
+// OAuthConfig holds the credentials of an external login provider. Setting
+// OIDC makes it a generic OpenID Connect provider.
 type OAuthConfig struct {
 	ConsumerKey    string
 	ConsumerSecret string
+	OIDC           *OIDCConfig `json:",omitempty"`
+}
+
+// OIDCConfig configures a generic OpenID Connect provider. Its endpoints are
+// read from the issuer's discovery document.
+type OIDCConfig struct {
+	IssuerURL string
+	Scopes    []string
+	// Claims maps the external user fields (email, name, first, last and
+	// avatarUrl) to the id_token claims holding them
+	Claims map[string]string
+	// TrustEmails accepts the emails without an email_verified claim, only
+	// for the issuers verifying all their emails
+	TrustEmails bool
 }
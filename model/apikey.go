@@ -0,0 +1,156 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// APIKeyPrefix starts all API keys, it tells them apart from session
+	// and root tokens
+	APIKeyPrefix = "sbk_"
+
+	APIKeyScopeCollections = "collections"
+	APIKeyScopeFunctions   = "functions"
+	APIKeyScopeStorage     = "storage"
+	APIKeyScopeSendMail    = "sendmail"
+	APIKeyScopeCache       = "cache"
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid API key")
+)
+
+// CollectionScope grants read and/or write access to a collection, "*"
+// matches all collections.
+type CollectionScope struct {
+	Name  string `json:"name"`
+	Read  bool   `json:"read"`
+	Write bool   `json:"write"`
+}
+
+// APIKeyScopes are the resources an API key can access. Functions lists the
+// functions the key can execute, "*" matches all functions.
+type APIKeyScopes struct {
+	Collections []CollectionScope `json:"collections,omitempty"`
+	Functions   []string          `json:"functions,omitempty"`
+	Storage     bool              `json:"storage"`
+	SendMail    bool              `json:"sendmail"`
+	Cache       bool              `json:"cache"`
+}
+
+// Allows returns true if the scopes grant access to a resource. The name is
+// the collection or function for those scopes and write is only used for
+// collections.
+func (s APIKeyScopes) Allows(scope, name string, write bool) bool {
+	switch scope {
+	case APIKeyScopeCollections:
+		name = CleanCollectionName(name)
+		for _, col := range s.Collections {
+			if col.Name != "*" && CleanCollectionName(col.Name) != name {
+				continue
+			} else if (write && col.Write) || (!write && col.Read) {
+				return true
+			}
+		}
+		return false
+	case APIKeyScopeFunctions:
+		return slices.Contains(s.Functions, "*") || slices.Contains(s.Functions, name)
+	case APIKeyScopeStorage:
+		return s.Storage
+	case APIKeyScopeSendMail:
+		return s.SendMail
+	case APIKeyScopeCache:
+		return s.Cache
+	}
+	return false
+}
+
+// APIKey is a credential for server-to-server access limited to its scopes.
+// It acts as the root user who created it. KeyHash is the hash of the
+// secret part of the key, the key itself is only returned at creation.
+type APIKey struct {
+	ID         string       `json:"id"`
+	AccountID  string       `json:"accountId"`
+	UserID     string       `json:"userId"`
+	Name       string       `json:"name"`
+	KeyHash    string       `json:"-"`
+	Scopes     APIKeyScopes `json:"scopes"`
+	AllowedIPs []string     `json:"allowedIps"`
+	Expires    *time.Time   `json:"expires"`
+	LastUsed   *time.Time   `json:"lastUsed"`
+	Created    time.Time    `json:"created"`
+}
+
+// AllowsIP returns true when the key has no IP allowlist or when ip matches
+// one of its addresses or CIDR ranges.
+func (k APIKey) AllowsIP(ip string) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, allowed := range k.AllowedIPs {
+		if strings.Contains(allowed, "/") {
+			if _, cidr, err := net.ParseCIDR(allowed); err == nil && cidr.Contains(addr) {
+				return true
+			}
+		} else if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewAPIKey is a request to create an API key. Expires is optional.
+type NewAPIKey struct {
+	Name       string       `json:"name"`
+	Scopes     APIKeyScopes `json:"scopes"`
+	AllowedIPs []string     `json:"allowedIps"`
+	Expires    *time.Time   `json:"expires"`
+}
+
+// Validate returns an error if the API key cannot be created
+func (nk NewAPIKey) Validate() error {
+	if len(strings.TrimSpace(nk.Name)) == 0 {
+		return fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	} else if nk.Expires != nil && nk.Expires.Before(time.Now()) {
+		return fmt.Errorf("%w: expiry is in the past", ErrInvalidAPIKey)
+	}
+
+	s := nk.Scopes
+	if len(s.Collections) == 0 && len(s.Functions) == 0 && !s.Storage && !s.SendMail && !s.Cache {
+		return fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKey)
+	}
+
+	for _, col := range s.Collections {
+		if len(col.Name) == 0 {
+			return fmt.Errorf("%w: collection name is required", ErrInvalidAPIKey)
+		} else if !col.Read && !col.Write {
+			return fmt.Errorf("%w: collection %s needs read or write access", ErrInvalidAPIKey, col.Name)
+		}
+	}
+
+	for _, ip := range nk.AllowedIPs {
+		if _, _, err := net.ParseCIDR(ip); err == nil {
+			continue
+		} else if net.ParseIP(ip) == nil {
+			return fmt.Errorf("%w: %s is not an IP address or CIDR range", ErrInvalidAPIKey, ip)
+		}
+	}
+	return nil
+}
+
+// CreatedAPIKey is a new API key. The key is only returned once.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAPIKeyScopesAllows(t *testing.T) {
+	s := APIKeyScopes{
+		Collections: []CollectionScope{
+			{Name: "tasks", Read: true},
+			{Name: "logs_760_", Read: true, Write: true},
+		},
+		Functions: []string{"daily-report"},
+		Cache:     true,
+	}
+
+	tests := []struct {
+		scope string
+		name  string
+		write bool
+		want  bool
+	}{
+		{APIKeyScopeCollections, "tasks", false, true},
+		{APIKeyScopeCollections, "tasks", true, false},
+		{APIKeyScopeCollections, "logs", true, true},
+		{APIKeyScopeCollections, "users", false, false},
+		{APIKeyScopeFunctions, "daily-report", false, true},
+		{APIKeyScopeFunctions, "other", false, false},
+		{APIKeyScopeStorage, "", true, false},
+		{APIKeyScopeCache, "", true, true},
+	}
+	for _, tc := range tests {
+		if got := s.Allows(tc.scope, tc.name, tc.write); got != tc.want {
+			t.Errorf("%s %s write=%v: expected %v got %v", tc.scope, tc.name, tc.write, tc.want, got)
+		}
+	}
+
+	all := APIKeyScopes{Collections: []CollectionScope{{Name: "*", Read: true}}}
+	if !all.Allows(APIKeyScopeCollections, "anything", false) {
+		t.Error("expected * to match all collections")
+	}
+}
+
+func TestAPIKeyAllowsIP(t *testing.T) {
+	k := APIKey{AllowedIPs: []string{"10.0.0.0/8", "192.168.1.10"}}
+
+	for ip, want := range map[string]bool{
+		"10.1.2.3":     true,
+		"192.168.1.10": true,
+		"192.168.1.11": false,
+		"not-an-ip":    false,
+	} {
+		if got := k.AllowsIP(ip); got != want {
+			t.Errorf("%s: expected %v got %v", ip, want, got)
+		}
+	}
+
+	if !(APIKey{}).AllowsIP("8.8.8.8") {
+		t.Error("expected a key without allowlist to allow any IP")
+	}
+}
+
+func TestNewAPIKeyValidate(t *testing.T) {
+	valid := NewAPIKey{Name: "jobs", Scopes: APIKeyScopes{Storage: true}, AllowedIPs: []string{"10.0.0.0/8"}}
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []NewAPIKey{
+		{Scopes: APIKeyScopes{Storage: true}},
+		{Name: "no scopes"},
+		{Name: "no access", Scopes: APIKeyScopes{Collections: []CollectionScope{{Name: "tasks"}}}},
+		{Name: "bad ip", Scopes: APIKeyScopes{Cache: true}, AllowedIPs: []string{"10.0.0"}},
+	}
+	for _, nk := range invalid {
+		if err := nk.Validate(); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("%s: expected ErrInvalidAPIKey got %v", nk.Name, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package model
+
+import "time"
+
+// SettingAuditPolicy is the database setting key holding the AuditPolicy
+const SettingAuditPolicy = "audit_policy"
+
+// DefaultAuditRetention is how long audit entries are kept when the policy
+// does not set it
+const DefaultAuditRetention = 90 * 24 * time.Hour
+
+// Actions recorded in the audit log
+const (
+	AuditActionSudo          = "sudo"
+	AuditActionImpersonate   = "impersonate"
+	AuditActionUserAdd       = "user_add"
+	AuditActionUserDelete    = "user_delete"
+	AuditActionRoleChange    = "role_change"
+	AuditActionEmailChange   = "email_change"
+	AuditActionAccountDelete = "account_delete"
+	AuditActionInvite        = "invite"
+	AuditActionInviteRevoke  = "invite_revoke"
+	AuditActionGroupAdd      = "group_member_add"
+	AuditActionGroupRemove   = "group_member_remove"
+)
+
+// AuditActions lists the actions recorded in the audit log
+var AuditActions = []string{
+	AuditActionSudo,
+	AuditActionImpersonate,
+	AuditActionUserAdd,
+	AuditActionUserDelete,
+	AuditActionRoleChange,
+	AuditActionEmailChange,
+	AuditActionAccountDelete,
+	AuditActionInvite,
+	AuditActionInviteRevoke,
+	AuditActionGroupAdd,
+	AuditActionGroupRemove,
+}
+
+// AuditEntry records an action of root or an account manager. Entries are
+// append-only, they are only removed once older than the retention.
+type AuditEntry struct {
+	ID         string    `json:"id"`
+	AccountID  string    `json:"accountId"`
+	ActorID    string    `json:"actorId"`
+	ActorEmail string    `json:"actorEmail"`
+	APIKeyID   string    `json:"apiKeyId,omitempty"`
+	Action     string    `json:"action"`
+	Target     string    `json:"target"`
+	Details    string    `json:"details,omitempty"`
+	IP         string    `json:"ip"`
+	Created    time.Time `json:"created"`
+}
+
+// AuditFilter narrows the audit entries returned, zero values match all
+// entries. Entries are returned most recent first.
+type AuditFilter struct {
+	Action  string
+	ActorID string
+	Target  string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+}
+
+// Matches returns true if the entry is selected by the filter, the Limit is
+// not considered.
+func (f AuditFilter) Matches(e AuditEntry) bool {
+	switch {
+	case len(f.Action) > 0 && e.Action != f.Action:
+		return false
+	case len(f.ActorID) > 0 && e.ActorID != f.ActorID:
+		return false
+	case len(f.Target) > 0 && e.Target != f.Target:
+		return false
+	case !f.Since.IsZero() && e.Created.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.Created.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// AuditPolicy configures the audit log of a database
+type AuditPolicy struct {
+	// RetentionDays is how long entries are kept, DefaultAuditRetention
+	// when 0
+	RetentionDays int `json:"retentionDays"`
+}
+
+// Retention returns how long entries are kept
+func (p AuditPolicy) Retention() time.Duration {
+	if p.RetentionDays <= 0 {
+		return DefaultAuditRetention
+	}
+	return time.Duration(p.RetentionDays) * 24 * time.Hour
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuditFilterMatches(t *testing.T) {
+	now := time.Now()
+	e := AuditEntry{Action: AuditActionImpersonate, ActorID: "root", Target: "user-1", Created: now}
+
+	tests := []struct {
+		filter   AuditFilter
+		expected bool
+	}{
+		{AuditFilter{}, true},
+		{AuditFilter{Action: AuditActionImpersonate, ActorID: "root", Target: "user-1"}, true},
+		{AuditFilter{Action: AuditActionRoleChange}, false},
+		{AuditFilter{ActorID: "other"}, false},
+		{AuditFilter{Since: now.Add(-time.Minute), Until: now.Add(time.Minute)}, true},
+		{AuditFilter{Since: now.Add(time.Minute)}, false},
+		{AuditFilter{Until: now}, false},
+	}
+	for _, tc := range tests {
+		if got := tc.filter.Matches(e); got != tc.expected {
+			t.Errorf("Matches(%v) expected %v got %v", tc.filter, tc.expected, got)
+		}
+	}
+}
+
+func TestAuditPolicyRetention(t *testing.T) {
+	if r := (AuditPolicy{}).Retention(); r != DefaultAuditRetention {
+		t.Errorf("expected the default retention got %v", r)
+	}
+	if r := (AuditPolicy{RetentionDays: 30}).Retention(); r != 30*24*time.Hour {
+		t.Errorf("expected 30 days got %v", r)
+	}
+}
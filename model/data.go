@@ -1,7 +1,10 @@
 package model
 
 import (
-	"fmt"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"log/slog"
 	"os"
 	"regexp"
 	"time"
@@ -25,6 +28,9 @@ type PagedResult struct {
 	Size    int64                    `json:"size"`
 	Total   int64                    `json:"total"`
 	Results []map[string]interface{} `json:"results"`
+	// Scores holds the full-text relevance score of each result id when
+	// the query includes search keywords
+	Scores map[string]float64 `json:"scores,omitempty"`
 }
 
 type ListParams struct {
@@ -39,38 +45,69 @@ var (
 )
 
 func init() {
-	secret := os.Getenv("JWT_SECRET")
-	if len(secret) == 0 {
-		secret = fmt.Sprintf("%d", time.Now().UnixNano())
+	HashSecret = jwt.NewHS256(hashSecretKey())
+}
+
+// hashSecretKey returns the HS256 key from JWT_SECRET or derived from
+// APP_SECRET. A random key is only used when both are missing, tokens are
+// then invalidated on restart.
+func hashSecretKey() []byte {
+	if secret := os.Getenv("JWT_SECRET"); len(secret) > 0 {
+		return []byte(secret)
+	}
+
+	if secret := os.Getenv("APP_SECRET"); len(secret) > 0 {
+		key, err := hkdf.Key(sha256.New, []byte(secret), nil, "jwt-hs256", 32)
+		if err == nil {
+			return key
+		}
 	}
-	HashSecret = jwt.NewHS256([]byte(secret))
+
+	slog.Warn("JWT_SECRET and APP_SECRET are not set, session tokens will be invalid after a restart")
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
 }
 
 const (
 	SystemID = "sb"
 
-	MsgTypeError        = "error"
-	MsgTypeOk           = "ok"
-	MsgTypeEcho         = "echo"
-	MsgTypeInit         = "init"
-	MsgTypeAuth         = "auth"
-	MsgTypeToken        = "token"
-	MsgTypeJoin         = "join"
-	MsgTypeJoined       = "joined"
-	MsgTypePresence     = "presence"
-	MsgTypeChanIn       = "chan_in"
-	MsgTypeChanOut      = "chan_out"
-	MsgTypeDBCreated    = "db_created"
-	MsgTypeDBUpdated    = "db_updated"
-	MsgTypeDBDeleted    = "db_deleted"
-	MsgTypeFunctionCall = "fn_call"
-	MsgTypeHTTPResponse = "http_response"
+	MsgTypeError          = "error"
+	MsgTypeOk             = "ok"
+	MsgTypeEcho           = "echo"
+	MsgTypeInit           = "init"
+	MsgTypeAuth           = "auth"
+	MsgTypeToken          = "token"
+	MsgTypeJoin           = "join"
+	MsgTypeJoined         = "joined"
+	MsgTypePresence       = "presence"
+	MsgTypePresenceSet    = "presence_set"
+	MsgTypePresenceJoin   = "presence_join"
+	MsgTypePresenceLeave  = "presence_leave"
+	MsgTypePresenceUpdate = "presence_update"
+	MsgTypeChanIn         = "chan_in"
+	MsgTypeChanOut        = "chan_out"
+	MsgTypeDBCreated      = "db_created"
+	MsgTypeDBUpdated      = "db_updated"
+	MsgTypeDBDeleted      = "db_deleted"
+	MsgTypeFunctionCall   = "fn_call"
+	MsgTypeHTTPResponse   = "http_response"
 
 	TelemetryLongRequestChannel = "telemetry-long-request"
 	MsgTypeTelemetryLongRequest = "telemetry_long_request"
+
+	MsgTypeInviteSent     = "invite_sent"
+	MsgTypeInviteAccepted = "invite_accepted"
 )
 
+// Command is a realtime message. The messages published to a channel have an
+// event id and the join command replays the messages published after the
+// Since event id.
 type Command struct {
+	ID            string `json:"id,omitempty"`
 	SID           string `json:"sid"`
 	Type          string `json:"type"`
 	Data          string `json:"data"`
@@ -78,6 +115,7 @@ type Command struct {
 	Token         string `json:"token"`
 	Auth          Auth   `json:"auth"`
 	Base          string `json:"base"`
+	Since         string `json:"since,omitempty"`
 	IsSystemEvent bool   `json:"-"`
 }
 
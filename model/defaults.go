@@ -0,0 +1,97 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	// SettingFieldDefaults is the database setting key holding the
+	// FieldDefaults configuration
+	SettingFieldDefaults = "field_defaults"
+
+	// GeneratorUUID sets the field to a new UUID
+	GeneratorUUID = "uuid"
+	// GeneratorNow sets the field to the current time
+	GeneratorNow = "now"
+	// GeneratorSequence sets the field to the next value of an incrementing
+	// sequence for this collection field
+	GeneratorSequence = "sequence"
+	// GeneratorSlugify sets the field to the slug of another field's value
+	GeneratorSlugify = "slugify"
+)
+
+var (
+	ErrInvalidFieldDefault = errors.New("invalid field default")
+)
+
+// FieldDefault is a value set on new documents when the field is missing.
+// Either a static Value or a Generator is used. The slugify generator
+// reads its input from the From field.
+type FieldDefault struct {
+	Field     string `json:"field"`
+	Value     any    `json:"value,omitempty"`
+	Generator string `json:"generator,omitempty"`
+	From      string `json:"from,omitempty"`
+}
+
+// Validate returns an error if the default cannot be applied
+func (fd FieldDefault) Validate() error {
+	if len(fd.Field) == 0 {
+		return fmt.Errorf("%w: field is required", ErrInvalidFieldDefault)
+	} else if fd.Field == "id" || fd.Field == "accountId" || strings.HasPrefix(fd.Field, "sb_") {
+		return fmt.Errorf("%w: %s is managed by the server", ErrInvalidFieldDefault, fd.Field)
+	}
+
+	switch fd.Generator {
+	case "":
+		if fd.Value == nil {
+			return fmt.Errorf("%w: %s needs a value or a generator", ErrInvalidFieldDefault, fd.Field)
+		}
+	case GeneratorUUID, GeneratorNow, GeneratorSequence:
+	case GeneratorSlugify:
+		if len(fd.From) == 0 {
+			return fmt.Errorf("%w: %s slugify generator needs a from field", ErrInvalidFieldDefault, fd.Field)
+		}
+	default:
+		return fmt.Errorf("%w: unknown generator %s", ErrInvalidFieldDefault, fd.Generator)
+	}
+	return nil
+}
+
+// FieldDefaults holds the field defaults applied to new documents per
+// collection.
+type FieldDefaults struct {
+	Collections map[string][]FieldDefault `json:"collections"`
+}
+
+// Fields returns the defaults for a collection. Permission suffixes in the
+// collection name are ignored.
+func (fd FieldDefaults) Fields(col string) []FieldDefault {
+	if len(fd.Collections) == 0 {
+		return nil
+	}
+	return fd.Collections[CleanCollectionName(col)]
+}
+
+// Slugify returns a lowercase URL-friendly version of s, i.e.
+// "Hello, World!" becomes "hello-world"
+func Slugify(s string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			dash = false
+			sb.WriteRune(unicode.ToLower(r))
+		default:
+			dash = true
+		}
+	}
+	return sb.String()
+}
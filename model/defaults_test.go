@@ -0,0 +1,61 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Hello, World!":           "hello-world",
+		"  leading and trailing ": "leading-and-trailing",
+		"already-a-slug":          "already-a-slug",
+		"Version 2.0 -- final":    "version-2-0-final",
+		"":                        "",
+	}
+
+	for in, want := range tests {
+		if got := Slugify(in); got != want {
+			t.Errorf("Slugify(%q) expected %q got %q", in, want, got)
+		}
+	}
+}
+
+func TestFieldDefaultValidate(t *testing.T) {
+	valid := []FieldDefault{
+		{Field: "status", Value: "draft"},
+		{Field: "ref", Generator: GeneratorUUID},
+		{Field: "number", Generator: GeneratorSequence},
+		{Field: "slug", Generator: GeneratorSlugify, From: "title"},
+	}
+	for _, fd := range valid {
+		if err := fd.Validate(); err != nil {
+			t.Errorf("expected %s to be valid got %v", fd.Field, err)
+		}
+	}
+
+	invalid := []FieldDefault{
+		{Value: "no field"},
+		{Field: "status"},
+		{Field: "slug", Generator: GeneratorSlugify},
+		{Field: "x", Generator: "random"},
+		{Field: "sb_updated", Generator: GeneratorNow},
+	}
+	for _, fd := range invalid {
+		if err := fd.Validate(); !errors.Is(err, ErrInvalidFieldDefault) {
+			t.Errorf("expected ErrInvalidFieldDefault for %v got %v", fd, err)
+		}
+	}
+}
+
+func TestFieldDefaultsFields(t *testing.T) {
+	fd := FieldDefaults{Collections: map[string][]FieldDefault{
+		"posts": {{Field: "status", Value: "draft"}},
+	}}
+
+	if len(fd.Fields("posts_774_")) != 1 {
+		t.Errorf("expected permission suffix to be ignored")
+	} else if len(fd.Fields("other")) != 0 {
+		t.Errorf("expected no defaults for other collection")
+	}
+}
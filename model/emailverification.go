@@ -0,0 +1,69 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// SettingEmailVerification is the database setting key holding the
+// EmailVerificationPolicy
+const SettingEmailVerification = "email_verification_policy"
+
+var ErrEmailNotVerified = errors.New("email address is not verified")
+
+// EmailVerification is the last email address a user verified. A user is
+// verified as long as it matches their current email.
+type EmailVerification struct {
+	UserID   string    `json:"userId"`
+	Email    string    `json:"email"`
+	Verified time.Time `json:"verified"`
+}
+
+// Verifies returns true if the verified address is email
+func (v EmailVerification) Verifies(email string) bool {
+	return len(v.UserID) > 0 && v.Email == strings.ToLower(email)
+}
+
+// EmailVerificationPolicy holds the email verification rules of a database.
+// The Allow flags are what users can do before verifying their email,
+// reading collections is always allowed.
+type EmailVerificationPolicy struct {
+	// Required sends a verification email to new users and new addresses
+	Required bool `json:"required"`
+
+	AllowLogin     bool `json:"allowLogin"`
+	AllowWrite     bool `json:"allowWrite"`
+	AllowStorage   bool `json:"allowStorage"`
+	AllowFunctions bool `json:"allowFunctions"`
+
+	// the verification email, [link] in Body is replaced by the link. Link
+	// defaults to the /email/verify endpoint, the code and email are added
+	// to its query string.
+	FromEmail string `json:"fromEmail"`
+	FromName  string `json:"fromName"`
+	Subject   string `json:"subject"`
+	Body      string `json:"body"`
+	Link      string `json:"link"`
+}
+
+// Allows returns true if unverified users can call a route of scope, scopes
+// are the ones of API keys and an empty scope is an account route.
+func (p EmailVerificationPolicy) Allows(scope string, write bool) bool {
+	switch {
+	case !p.Required, len(scope) == 0:
+		return true
+	case scope == APIKeyScopeStorage:
+		return p.AllowStorage
+	case scope == APIKeyScopeFunctions:
+		return p.AllowFunctions
+	default:
+		return !write || p.AllowWrite
+	}
+}
+
+// EmailVerificationPending is returned when a registration or an email
+// change waits for the user to verify their email
+type EmailVerificationPending struct {
+	EmailVerificationRequired bool `json:"emailVerificationRequired"`
+}
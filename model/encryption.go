@@ -0,0 +1,182 @@
+package model
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/staticbackendhq/core/config"
+)
+
+const (
+	// SettingFieldEncryption is the database setting key holding the
+	// FieldEncryption configuration
+	SettingFieldEncryption = "field_encryption"
+
+	// EncryptedValuePrefix prefixes every encrypted document value,
+	// i.e. sbenc:v1:base64(nonce+ciphertext)
+	EncryptedValuePrefix = "sbenc:"
+	// BlindIndexPrefix prefixes the document field holding the blind index
+	// of an encrypted field, i.e. ssn is indexed in sb_bidx_ssn
+	BlindIndexPrefix = "sb_bidx_"
+)
+
+var (
+	ErrInvalidFieldEncryptionKey = errors.New("APP_SECRET must be set before using field-level encryption")
+	ErrEncryptedFieldOperator    = errors.New("encrypted fields can only be queried with the = and != operators")
+	ErrEncryptedFieldIncrement   = errors.New("encrypted fields cannot be incremented")
+)
+
+// FieldEncryption holds which collection fields are encrypted at rest and the
+// key version used to encrypt new values.
+type FieldEncryption struct {
+	Collections map[string][]string `json:"collections"`
+	KeyVersion  int                 `json:"keyVersion"`
+}
+
+// Fields returns the encrypted fields for a collection. Permission suffixes
+// in the collection name are ignored.
+func (fe FieldEncryption) Fields(col string) []string {
+	if len(fe.Collections) == 0 {
+		return nil
+	}
+	return fe.Collections[CleanCollectionName(col)]
+}
+
+// IsEncrypted returns true if this collection's field is encrypted
+func (fe FieldEncryption) IsEncrypted(col, field string) bool {
+	return slices.Contains(fe.Fields(col), field)
+}
+
+// FieldCipher encrypts, decrypts and blind-indexes document values with keys
+// derived from APP_SECRET for a specific tenant.
+type FieldCipher struct {
+	TenantID string
+}
+
+func (fc FieldCipher) key(info string) ([]byte, error) {
+	if len(config.Current.AppSecret) == 0 {
+		return nil, ErrInvalidFieldEncryptionKey
+	}
+
+	return hkdf.Key(sha256.New, []byte(config.Current.AppSecret), []byte(fc.TenantID), info, 32)
+}
+
+// Encrypt encrypts the JSON representation of v with the key version
+func (fc FieldCipher) Encrypt(v any, version int) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := fc.gcm(version)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, b, nil)
+	return fmt.Sprintf("%sv%d:%s", EncryptedValuePrefix, version, base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt returns the original value of an encrypted value. The key version
+// is read from the value itself so values encrypted before a key rotation can
+// still be decrypted.
+func (fc FieldCipher) Decrypt(s string) (any, error) {
+	version, ok := EncryptedValueVersion(s)
+	if !ok {
+		return nil, errors.New("invalid encrypted value")
+	}
+
+	parts := strings.SplitN(s, ":", 3)
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := fc.gcm(version)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	b, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// BlindIndex returns a keyed hash of v used for equality lookups. The blind
+// index key does not change on key rotation so lookups keep working while
+// documents are being re-encrypted.
+func (fc FieldCipher) BlindIndex(v any) (string, error) {
+	key, err := fc.key("sb-field-blind-index")
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(fmt.Sprintf("%v", v)))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (fc FieldCipher) gcm(version int) (cipher.AEAD, error) {
+	key, err := fc.key(fmt.Sprintf("sb-field-encryption-v%d", version))
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(c)
+}
+
+// EncryptedValueVersion returns the key version of an encrypted value and
+// false if v is not an encrypted value.
+func EncryptedValueVersion(v any) (int, bool) {
+	s, ok := v.(string)
+	if !ok || !strings.HasPrefix(s, EncryptedValuePrefix) {
+		return 0, false
+	}
+
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 || !strings.HasPrefix(parts[1], "v") {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(parts[1], "v"))
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
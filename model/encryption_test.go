@@ -0,0 +1,107 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/staticbackendhq/core/config"
+)
+
+func TestFieldCipherEncryptDecrypt(t *testing.T) {
+	config.Current.AppSecret = "12345678901234567890123456789012"
+
+	fc := FieldCipher{TenantID: "tenant-1"}
+
+	s, err := fc.Encrypt("123-45-6789", 1)
+	if err != nil {
+		t.Fatal(err)
+	} else if v, ok := EncryptedValueVersion(s); !ok || v != 1 {
+		t.Fatalf("expected an encrypted value with version 1 got %s", s)
+	}
+
+	v, err := fc.Decrypt(s)
+	if err != nil {
+		t.Fatal(err)
+	} else if v != "123-45-6789" {
+		t.Errorf("expected 123-45-6789 got %v", v)
+	}
+
+	n, err := fc.Encrypt(42.0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err = fc.Decrypt(n)
+	if err != nil {
+		t.Fatal(err)
+	} else if v != 42.0 {
+		t.Errorf("expected 42 got %v", v)
+	}
+}
+
+func TestFieldCipherIsPerTenant(t *testing.T) {
+	config.Current.AppSecret = "12345678901234567890123456789012"
+
+	s, err := FieldCipher{TenantID: "tenant-1"}.Encrypt("secret", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (FieldCipher{TenantID: "tenant-2"}).Decrypt(s); err == nil {
+		t.Error("expected another tenant to be unable to decrypt the value")
+	}
+
+	idx1, err := FieldCipher{TenantID: "tenant-1"}.BlindIndex("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx2, err := FieldCipher{TenantID: "tenant-2"}.BlindIndex("secret")
+	if err != nil {
+		t.Fatal(err)
+	} else if idx1 == idx2 {
+		t.Error("expected blind indexes to differ between tenants")
+	}
+}
+
+func TestFieldCipherBlindIndex(t *testing.T) {
+	config.Current.AppSecret = "12345678901234567890123456789012"
+
+	fc := FieldCipher{TenantID: "tenant-1"}
+
+	a, err := fc.BlindIndex("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := fc.BlindIndex("abc")
+	if err != nil {
+		t.Fatal(err)
+	} else if a != b {
+		t.Error("expected blind index to be deterministic")
+	}
+
+	c, err := fc.BlindIndex("abd")
+	if err != nil {
+		t.Fatal(err)
+	} else if a == c {
+		t.Error("expected different values to have different blind indexes")
+	}
+}
+
+func TestFieldCipherRequiresAppSecret(t *testing.T) {
+	config.Current.AppSecret = ""
+	defer func() { config.Current.AppSecret = "12345678901234567890123456789012" }()
+
+	if _, err := (FieldCipher{TenantID: "tenant-1"}).Encrypt("x", 1); err != ErrInvalidFieldEncryptionKey {
+		t.Errorf("expected ErrInvalidFieldEncryptionKey got %v", err)
+	}
+}
+
+func TestFieldEncryptionIgnoresPermissionSuffix(t *testing.T) {
+	fe := FieldEncryption{Collections: map[string][]string{"patients": {"ssn"}}}
+	if !fe.IsEncrypted("patients_774_", "ssn") {
+		t.Error("expected ssn to be encrypted for patients_774_")
+	} else if fe.IsEncrypted("patients", "name") {
+		t.Error("expected name not to be encrypted")
+	}
+}
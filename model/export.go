@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// DataExportRetention is how long an export archive and its download link
+// are kept once requested
+const DataExportRetention = 7 * 24 * time.Hour
+
+// Scopes of a data export
+const (
+	// DataExportScopeUser exports the data owned by a user
+	DataExportScopeUser = "user"
+	// DataExportScopeAccount exports the data of all users of an account
+	DataExportScopeAccount = "account"
+)
+
+// Statuses of a data export
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport is an archive of everything owned by a user or an account. It
+// is generated in the background, URL is the signed download link once
+// Status is DataExportReady.
+type DataExport struct {
+	ID        string    `json:"id"`
+	Scope     string    `json:"scope"`
+	AccountID string    `json:"accountId"`
+	UserID    string    `json:"userId,omitempty"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Size      int64     `json:"size"`
+	URL       string    `json:"url,omitempty"`
+	Created   time.Time `json:"created"`
+	Completed time.Time `json:"completed,omitempty"`
+	Expires   time.Time `json:"expires"`
+}
+
+// Expired returns true once the archive and its link are no longer
+// available
+func (e DataExport) Expired(now time.Time) bool {
+	return now.After(e.Expires)
+}
+
+// DataExportRequest is the root request of an export, UserID is only used
+// with DataExportScopeUser
+type DataExportRequest struct {
+	Scope     string `json:"scope"`
+	AccountID string `json:"accountId"`
+	UserID    string `json:"userId"`
+}
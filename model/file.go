@@ -10,6 +10,8 @@ type UploadFileData struct {
 	File     io.ReadSeeker
 	Size     int64
 	Mimetype string
+	// Private files are not publicly readable from the storage provider
+	Private bool
 }
 
 type File struct {
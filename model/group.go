@@ -0,0 +1,45 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// FieldGroupID is the document field sharing a document with the members of
+// a group of its account. Group-scoped documents are only visible to the
+// group members and the account managers, in addition to the row scope of
+// their collection.
+const FieldGroupID = "sb_groupId"
+
+var ErrNotGroupMember = errors.New("you are not a member of this group")
+
+// Group is a sub-group of an account's users, like a department, sharing
+// the documents scoped to it
+type Group struct {
+	ID        string    `json:"id"`
+	AccountID string    `json:"accountId"`
+	Name      string    `json:"name"`
+	Created   time.Time `json:"created"`
+}
+
+// GroupMember is the membership of a user in a group
+type GroupMember struct {
+	GroupID   string    `json:"groupId"`
+	AccountID string    `json:"accountId"`
+	UserID    string    `json:"userId"`
+	Added     time.Time `json:"added"`
+}
+
+// InGroup returns true if the user is a member of the group in their
+// current account
+func (auth Auth) InGroup(groupID string) bool {
+	return len(groupID) > 0 && slices.Contains(auth.Groups, groupID)
+}
+
+// DocumentGroup returns the group a document is scoped to, empty when the
+// document is not group-scoped
+func DocumentGroup(doc map[string]any) string {
+	id, _ := doc[FieldGroupID].(string)
+	return id
+}
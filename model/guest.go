@@ -0,0 +1,48 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// SettingGuestPolicy is the database setting key holding the GuestPolicy
+const SettingGuestPolicy = "guest_policy"
+
+// DefaultGuestRetention is how long guests are kept without being seen when
+// the policy does not set it
+const DefaultGuestRetention = 30 * 24 * time.Hour
+
+// GuestEmailDomain is the domain of the placeholder emails of guests until
+// they are upgraded
+const GuestEmailDomain = "guest.invalid"
+
+// Guest is an anonymous user, it has its own account until upgraded with an
+// email. LastSeen is updated when its session is refreshed.
+type Guest struct {
+	UserID    string    `json:"userId"`
+	AccountID string    `json:"accountId"`
+	Created   time.Time `json:"created"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// IsGuestEmail returns true for the placeholder emails of guests
+func IsGuestEmail(email string) bool {
+	return strings.HasSuffix(email, "@"+GuestEmailDomain)
+}
+
+// GuestPolicy holds the anonymous users rules of a database
+type GuestPolicy struct {
+	// Enabled lets clients create guests with their public key
+	Enabled bool `json:"enabled"`
+	// RetentionDays is how long guests are kept without being seen,
+	// DefaultGuestRetention when 0
+	RetentionDays int `json:"retentionDays"`
+}
+
+// Retention returns how long guests are kept without being seen
+func (p GuestPolicy) Retention() time.Duration {
+	if p.RetentionDays <= 0 {
+		return DefaultGuestRetention
+	}
+	return time.Duration(p.RetentionDays) * 24 * time.Hour
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// SettingInvitations is the database setting key holding the
+// InvitationPolicy
+const SettingInvitations = "invitation_policy"
+
+// Invitation is a pending invite for an email to join an account with a
+// role. The invitee accepts it with a signed link sent by email, the link
+// stops working once the invitation expires, is resent or revoked.
+type Invitation struct {
+	ID        string    `json:"id"`
+	AccountID string    `json:"accountId"`
+	Email     string    `json:"email"`
+	Role      int       `json:"role"`
+	InvitedBy string    `json:"invitedBy"`
+	Created   time.Time `json:"created"`
+	Sent      time.Time `json:"sent"`
+	Expires   time.Time `json:"expires"`
+}
+
+// InvitationPolicy holds the invitation rules of a database
+type InvitationPolicy struct {
+	// ExpiresHours is how long an invitation link is valid, default is 7 days
+	ExpiresHours int `json:"expiresHours"`
+
+	// the invitation email, [link] in Body is replaced by the link. Link
+	// defaults to the /invitations/accept endpoint, the token is added to
+	// its query string.
+	FromEmail string `json:"fromEmail"`
+	FromName  string `json:"fromName"`
+	Subject   string `json:"subject"`
+	Body      string `json:"body"`
+	Link      string `json:"link"`
+}
+
+// TTL returns how long an invitation link is valid
+func (p InvitationPolicy) TTL() time.Duration {
+	if p.ExpiresHours <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(p.ExpiresHours) * time.Hour
+}
+
+// NewInvitation is the request to invite an email to the caller's account
+type NewInvitation struct {
+	Email string `json:"email"`
+	Role  int    `json:"role"`
+}
+
+// AcceptInvitation is the request to accept an invitation. New users choose
+// their password, existing users confirm theirs.
+type AcceptInvitation struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,303 @@
+package model
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/gbrlsnchs/jwt/v3/jwtutil"
+)
+
+// Session token signing algorithms
+const (
+	JWTAlgHS256 = "HS256"
+	JWTAlgRS256 = "RS256"
+	JWTAlgEdDSA = "EdDSA"
+)
+
+// SettingJWTMigrationStart is the system setting holding when the first
+// signing key was created, HS256 tokens are accepted for a while after
+const SettingJWTMigrationStart = "jwt_migration_start"
+
+var (
+	ErrUnknownJWTKey        = errors.New("unknown token signing key")
+	ErrUnsupportedJWTAlg    = errors.New("unsupported token signing algorithm")
+	ErrLegacyJWTNotAccepted = errors.New("HS256 tokens are no longer accepted")
+)
+
+// signingKeyCipher encrypts the private keys, they are not tied to a tenant
+var signingKeyCipher = FieldCipher{TenantID: SystemID}
+
+// JWTKeys signs and verifies the session tokens. It signs with HashSecret
+// until signing keys are loaded.
+var JWTKeys = &JWTKeyring{}
+
+// SigningKey is an asymmetric key signing session tokens. The private key is
+// PKCS #8 PEM encoded and encrypted with APP_SECRET.
+type SigningKey struct {
+	ID         string    `json:"id"`
+	Algorithm  string    `json:"algorithm"`
+	PrivateKey string    `json:"-"`
+	Created    time.Time `json:"created"`
+}
+
+// NewSigningKey generates an RS256 or EdDSA signing key
+func NewSigningKey(alg string) (SigningKey, error) {
+	var priv crypto.Signer
+	switch alg {
+	case JWTAlgRS256:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return SigningKey{}, err
+		}
+		priv = key
+	case JWTAlgEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return SigningKey{}, err
+		}
+		priv = key
+	default:
+		return SigningKey{}, fmt.Errorf("%w: %s", ErrUnsupportedJWTAlg, alg)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	enc, err := signingKeyCipher.Encrypt(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), 1)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	kid := make([]byte, 8)
+	if _, err := rand.Read(kid); err != nil {
+		return SigningKey{}, err
+	}
+
+	return SigningKey{
+		ID:         hex.EncodeToString(kid),
+		Algorithm:  alg,
+		PrivateKey: enc,
+		Created:    time.Now(),
+	}, nil
+}
+
+// edDSA names the Ed25519 algorithm as registered for JOSE
+type edDSA struct {
+	*jwt.Ed25519
+}
+
+func (edDSA) Name() string {
+	return JWTAlgEdDSA
+}
+
+type jwtKey struct {
+	id      string
+	alg     jwt.Algorithm
+	public  crypto.PublicKey
+	created time.Time
+}
+
+func parseSigningKey(k SigningKey) (jwtKey, error) {
+	v, err := signingKeyCipher.Decrypt(k.PrivateKey)
+	if err != nil {
+		return jwtKey{}, err
+	}
+
+	s, _ := v.(string)
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return jwtKey{}, errors.New("invalid signing key PEM")
+	}
+
+	priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return jwtKey{}, err
+	}
+
+	jk := jwtKey{id: k.ID, created: k.Created}
+	switch key := priv.(type) {
+	case *rsa.PrivateKey:
+		jk.alg = jwt.NewRS256(jwt.RSAPrivateKey(key))
+		jk.public = &key.PublicKey
+	case ed25519.PrivateKey:
+		jk.alg = edDSA{jwt.NewEd25519(jwt.Ed25519PrivateKey(key))}
+		jk.public = key.Public()
+	default:
+		return jwtKey{}, fmt.Errorf("%w: %T", ErrUnsupportedJWTAlg, priv)
+	}
+
+	if jk.alg.Name() != k.Algorithm {
+		return jwtKey{}, fmt.Errorf("%w: key %s is not %s", ErrUnsupportedJWTAlg, k.ID, k.Algorithm)
+	}
+	return jk, nil
+}
+
+// JWTKeyring holds the signing keys of the session tokens. A new key is only
+// used for signing after the publish delay so other instances and services
+// caching the JWKS know it before they see tokens signed with it. Older keys
+// keep verifying tokens until they are removed from the ring.
+type JWTKeyring struct {
+	// Reload is called when a token is signed with an unknown key, i.e. a
+	// key created by another instance. It is called at most every 10 seconds.
+	Reload func() error
+
+	mu           sync.RWMutex
+	keys         []jwtKey
+	publishDelay time.Duration
+	legacyUntil  time.Time
+	lastReload   time.Time
+}
+
+// Load replaces the keys of the ring. HS256 tokens are accepted until
+// legacyUntil once the ring has keys.
+func (kr *JWTKeyring) Load(keys []SigningKey, publishDelay time.Duration, legacyUntil time.Time) error {
+	ring := make([]jwtKey, 0, len(keys))
+	for _, k := range keys {
+		jk, err := parseSigningKey(k)
+		if err != nil {
+			return fmt.Errorf("error loading signing key %s: %w", k.ID, err)
+		}
+		ring = append(ring, jk)
+	}
+
+	slices.SortFunc(ring, func(a, b jwtKey) int {
+		return a.created.Compare(b.created)
+	})
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	kr.keys = ring
+	kr.publishDelay = publishDelay
+	kr.legacyUntil = legacyUntil
+	return nil
+}
+
+// signingKey returns the newest published key, or the oldest one while no
+// key was published yet.
+func (kr *JWTKeyring) signingKey(now time.Time) (jwtKey, bool) {
+	if len(kr.keys) == 0 {
+		return jwtKey{}, false
+	}
+
+	for i := len(kr.keys) - 1; i >= 0; i-- {
+		if !kr.keys[i].created.Add(kr.publishDelay).After(now) {
+			return kr.keys[i], true
+		}
+	}
+	return kr.keys[0], true
+}
+
+// KeyID returns the id of the key currently signing tokens, it is empty when
+// tokens are signed with HS256.
+func (kr *JWTKeyring) KeyID() string {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	k, _ := kr.signingKey(time.Now())
+	return k.id
+}
+
+// Sign signs the payload with the current signing key
+func (kr *JWTKeyring) Sign(payload any) ([]byte, error) {
+	kr.mu.RLock()
+	k, ok := kr.signingKey(time.Now())
+	kr.mu.RUnlock()
+
+	if !ok {
+		return jwt.Sign(payload, HashSecret)
+	}
+	return jwt.Sign(payload, k.alg, jwt.KeyID(k.id))
+}
+
+// Verify verifies the token's signature with the key of its kid header and
+// decodes its payload.
+func (kr *JWTKeyring) Verify(token []byte, payload any, opts ...jwt.VerifyOption) (jwt.Header, error) {
+	rv := &jwtutil.Resolver{New: func(hd jwt.Header) (jwt.Algorithm, error) {
+		alg, err := kr.verifyingKey(hd)
+		if errors.Is(err, ErrUnknownJWTKey) && kr.reload() {
+			return kr.verifyingKey(hd)
+		}
+		return alg, err
+	}}
+
+	return jwt.Verify(token, rv, payload, append([]jwt.VerifyOption{jwt.ValidateHeader}, opts...)...)
+}
+
+func (kr *JWTKeyring) verifyingKey(hd jwt.Header) (jwt.Algorithm, error) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	if hd.Algorithm == JWTAlgHS256 {
+		if len(kr.keys) > 0 && time.Now().After(kr.legacyUntil) {
+			return nil, ErrLegacyJWTNotAccepted
+		}
+		return HashSecret, nil
+	}
+
+	for _, k := range kr.keys {
+		if k.id == hd.KeyID && k.alg.Name() == hd.Algorithm {
+			return k.alg, nil
+		}
+	}
+	return nil, ErrUnknownJWTKey
+}
+
+// reload calls Reload unless it was called in the last 10 seconds and
+// returns true if the keys were reloaded
+func (kr *JWTKeyring) reload() bool {
+	kr.mu.Lock()
+	if kr.Reload == nil || time.Since(kr.lastReload) < 10*time.Second {
+		kr.mu.Unlock()
+		return false
+	}
+	kr.lastReload = time.Now()
+	kr.mu.Unlock()
+
+	return kr.Reload() == nil
+}
+
+// JWKS returns the JSON Web Key Set of the public keys verifying tokens
+func (kr *JWTKeyring) JWKS() map[string]any {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	keys := make([]map[string]string, 0, len(kr.keys))
+	for _, k := range kr.keys {
+		jwk := map[string]string{
+			"kid": k.id,
+			"alg": k.alg.Name(),
+			"use": "sig",
+		}
+
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(pub)
+		}
+
+		keys = append(keys, jwk)
+	}
+
+	return map[string]any{"keys": keys}
+}
@@ -0,0 +1,131 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/staticbackendhq/core/config"
+)
+
+func newTestSigningKey(t *testing.T, alg string, created time.Time) SigningKey {
+	t.Helper()
+
+	k, err := NewSigningKey(alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Created = created
+	return k
+}
+
+func TestJWTKeyringSignAndVerify(t *testing.T) {
+	config.Current.AppSecret = "12345678901234567890123456789012"
+
+	for _, alg := range []string{JWTAlgRS256, JWTAlgEdDSA} {
+		kr := &JWTKeyring{}
+		k := newTestSigningKey(t, alg, time.Now())
+		if err := kr.Load([]SigningKey{k}, time.Minute, time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := kr.Sign(JWTPayload{Token: "user|token"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var pl JWTPayload
+		hd, err := kr.Verify(b, &pl)
+		if err != nil {
+			t.Fatal(err)
+		} else if hd.Algorithm != alg || hd.KeyID != k.ID {
+			t.Errorf("expected %s signed with %s got %v", alg, k.ID, hd)
+		} else if pl.Token != "user|token" {
+			t.Errorf("expected the payload to be decoded got %v", pl)
+		}
+
+		other := &JWTKeyring{}
+		if err := other.Load([]SigningKey{newTestSigningKey(t, alg, time.Now())}, time.Minute, time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := other.Verify(b, &pl); !errors.Is(err, ErrUnknownJWTKey) {
+			t.Errorf("expected a token from another key ring to be refused got %v", err)
+		}
+
+		jwks := kr.JWKS()["keys"].([]map[string]string)
+		if len(jwks) != 1 || jwks[0]["kid"] != k.ID || jwks[0]["alg"] != alg {
+			t.Errorf("unexpected JWKS %v", jwks)
+		}
+	}
+}
+
+func TestJWTKeyringRotation(t *testing.T) {
+	config.Current.AppSecret = "12345678901234567890123456789012"
+
+	current := newTestSigningKey(t, JWTAlgRS256, time.Now().Add(-48*time.Hour))
+	next := newTestSigningKey(t, JWTAlgEdDSA, time.Now())
+
+	kr := &JWTKeyring{}
+	if err := kr.Load([]SigningKey{next, current}, time.Minute, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the new key is published but not used for signing yet
+	if kid := kr.KeyID(); kid != current.ID {
+		t.Errorf("expected %s to sign until the new key is published got %s", current.ID, kid)
+	}
+
+	signed, err := kr.Sign(JWTPayload{Token: "user|token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next.Created = time.Now().Add(-2 * time.Minute)
+	if err := kr.Load([]SigningKey{current, next}, time.Minute, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if kid := kr.KeyID(); kid != next.ID {
+		t.Errorf("expected %s to sign once published got %s", next.ID, kid)
+	}
+
+	var pl JWTPayload
+	if _, err := kr.Verify(signed, &pl); err != nil {
+		t.Errorf("expected tokens of the previous key to be valid got %v", err)
+	}
+}
+
+func TestJWTKeyringLegacyHS256(t *testing.T) {
+	config.Current.AppSecret = "12345678901234567890123456789012"
+
+	legacy, err := jwt.Sign(JWTPayload{Token: "user|token"}, HashSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kr := &JWTKeyring{}
+
+	var pl JWTPayload
+	if _, err := kr.Verify(legacy, &pl); err != nil {
+		t.Errorf("expected HS256 tokens without signing keys got %v", err)
+	}
+
+	if err := kr.Load([]SigningKey{newTestSigningKey(t, JWTAlgRS256, time.Now())}, time.Minute, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := kr.Verify(legacy, &pl); err != nil {
+		t.Errorf("expected HS256 tokens during the migration window got %v", err)
+	}
+
+	// the window does not depend on the keys, the oldest ones are pruned
+	if err := kr.Load([]SigningKey{newTestSigningKey(t, JWTAlgRS256, time.Now())}, time.Minute, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := kr.Verify(legacy, &pl); !errors.Is(err, ErrLegacyJWTNotAccepted) {
+		t.Errorf("expected HS256 tokens to be refused after the migration window got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// SettingLoginProtection is the database setting key holding the
+// LoginProtectionPolicy
+const SettingLoginProtection = "login_protection"
+
+// LoginProtectionPolicy holds the brute-force protection rules of a
+// database. Failed attempts are counted per email and IP address, each
+// failure doubles the delay before the next attempt and MaxAttempts
+// failures lock the email for that IP address.
+type LoginProtectionPolicy struct {
+	Disabled bool `json:"disabled"`
+	// MaxAttempts is the number of failures before a lockout, default is 5
+	MaxAttempts int `json:"maxAttempts"`
+	// LockoutMinutes is how long a lockout lasts, default is 15 minutes
+	LockoutMinutes int `json:"lockoutMinutes"`
+
+	// the user is emailed when locked out, [ip] and [until] in Body are
+	// replaced by the IP address and the end of the lockout.
+	DisableLockoutEmail bool   `json:"disableLockoutEmail"`
+	FromEmail           string `json:"fromEmail"`
+	FromName            string `json:"fromName"`
+	Subject             string `json:"subject"`
+	Body                string `json:"body"`
+}
+
+// MaxFailures returns the number of failures before a lockout
+func (p LoginProtectionPolicy) MaxFailures() int {
+	if p.MaxAttempts <= 0 {
+		return 5
+	}
+	return p.MaxAttempts
+}
+
+// Lockout returns how long a lockout lasts
+func (p LoginProtectionPolicy) Lockout() time.Duration {
+	if p.LockoutMinutes <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(p.LockoutMinutes) * time.Minute
+}
+
+// LoginLock is an email locked out for an IP address
+type LoginLock struct {
+	Email       string    `json:"email"`
+	IP          string    `json:"ip"`
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// SettingMFAPolicy is the database setting key holding the MFAPolicy
+const SettingMFAPolicy = "mfa_policy"
+
+// UserMFA is the TOTP enrollment of a user. The secret is encrypted and
+// recovery codes are stored as hashes. Enabled is false until the user
+// confirms the enrollment with a valid code. LastTOTPStep is the time step
+// of the last accepted code, codes up to that step cannot be used again.
+type UserMFA struct {
+	UserID        string    `json:"userId"`
+	Secret        string    `json:"-"`
+	Enabled       bool      `json:"enabled"`
+	RecoveryCodes []string  `json:"-"`
+	LastTOTPStep  int64     `json:"-"`
+	Created       time.Time `json:"created"`
+}
+
+// MFAPolicy holds the multi-factor authentication rules of a database
+type MFAPolicy struct {
+	// Required forces every user to enroll and use a TOTP code to log in
+	Required bool `json:"required"`
+}
+
+// MFAStatus is the multi-factor authentication state returned to a user
+type MFAStatus struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+// MFAEnrollment holds the TOTP secret and the otpauth URI to display as a QR
+// code in authenticator apps.
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// MFAChallenge is returned by a login requiring a TOTP code. The challenge
+// token must be exchanged with a valid code to receive the session token.
+type MFAChallenge struct {
+	MFARequired        bool   `json:"mfaRequired"`
+	EnrollmentRequired bool   `json:"enrollmentRequired"`
+	Challenge          string `json:"challenge"`
+}
+
+// MFALogin exchanges a challenge token and a TOTP or recovery code for a
+// session token.
+type MFALogin struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+// MFALoginResult is the session token of a completed MFA login. The refresh
+// token is set when the login asked for one. Recovery codes are only
+// returned once, when the login completed an enrollment.
+type MFALoginResult struct {
+	Token         string   `json:"token"`
+	RefreshToken  string   `json:"refreshToken,omitempty"`
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
@@ -0,0 +1,154 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// SettingOIDCSigningKeys is the database setting key holding the keys
+	// signing the id_tokens issued when the database is an OpenID Connect
+	// provider
+	SettingOIDCSigningKeys = "oidc_signing_keys"
+
+	OIDCScopeOpenID        = "openid"
+	OIDCScopeEmail         = "email"
+	OIDCScopeProfile       = "profile"
+	OIDCScopeOfflineAccess = "offline_access"
+)
+
+var (
+	ErrInvalidOAuthClient = errors.New("invalid OAuth client")
+
+	// OIDCScopes are the scopes clients can request
+	OIDCScopes = []string{OIDCScopeOpenID, OIDCScopeEmail, OIDCScopeProfile, OIDCScopeOfflineAccess}
+)
+
+// OAuthClient is an application signing its users in with the database as
+// OpenID Connect provider. Public clients, i.e. single-page and mobile apps,
+// have no secret and must use PKCE. Trusted clients skip the consent screen.
+type OAuthClient struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	SecretHash   string    `json:"-"`
+	RedirectURIs []string  `json:"redirectUris"`
+	Public       bool      `json:"public"`
+	Trusted      bool      `json:"trusted"`
+	Created      time.Time `json:"created"`
+}
+
+// AllowsRedirect returns true if the redirect URI is registered for the
+// client. URIs are compared exactly.
+func (c OAuthClient) AllowsRedirect(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
+
+// NewOAuthClient is a request to register an OAuth client
+type NewOAuthClient struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirectUris"`
+	Public       bool     `json:"public"`
+	Trusted      bool     `json:"trusted"`
+}
+
+// Validate returns an error if the client cannot be registered. Redirect
+// URIs must be absolute without fragment and use HTTPS, except for localhost.
+func (nc NewOAuthClient) Validate() error {
+	if len(strings.TrimSpace(nc.Name)) == 0 {
+		return fmt.Errorf("%w: name is required", ErrInvalidOAuthClient)
+	} else if len(nc.RedirectURIs) == 0 {
+		return fmt.Errorf("%w: at least one redirect URI is required", ErrInvalidOAuthClient)
+	}
+
+	for _, uri := range nc.RedirectURIs {
+		u, err := url.Parse(uri)
+		if err != nil || !u.IsAbs() || len(u.Host) == 0 {
+			return fmt.Errorf("%w: %s is not an absolute URL", ErrInvalidOAuthClient, uri)
+		} else if len(u.Fragment) > 0 {
+			return fmt.Errorf("%w: %s cannot have a fragment", ErrInvalidOAuthClient, uri)
+		} else if u.Scheme != "https" && u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1" {
+			return fmt.Errorf("%w: %s must use https", ErrInvalidOAuthClient, uri)
+		}
+	}
+	return nil
+}
+
+// CreatedOAuthClient is a new OAuth client. The secret of confidential
+// clients is only returned once.
+type CreatedOAuthClient struct {
+	OAuthClient
+	Secret string `json:"secret,omitempty"`
+}
+
+// OAuthConsent records the scopes a user granted to a client
+type OAuthConsent struct {
+	ID       string    `json:"id"`
+	UserID   string    `json:"userId"`
+	ClientID string    `json:"clientId"`
+	Scopes   []string  `json:"scopes"`
+	Created  time.Time `json:"created"`
+}
+
+// Covers returns true if the consent includes all the scopes
+func (c OAuthConsent) Covers(scopes []string) bool {
+	for _, s := range scopes {
+		if !slices.Contains(c.Scopes, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// OAuthAuthorizeRequest is an authorization request of the authorization
+// code flow. Scope is a space separated list of scopes.
+type OAuthAuthorizeRequest struct {
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	ResponseType        string `json:"response_type"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	Nonce               string `json:"nonce"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	Prompt              string `json:"prompt"`
+}
+
+// Scopes returns the requested scopes
+func (r OAuthAuthorizeRequest) Scopes() []string {
+	return strings.Fields(r.Scope)
+}
+
+// OAuthError is an OAuth 2.0 error response, see RFC 6749 section 5.2
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuthError) Error() string {
+	if len(e.Description) == 0 {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// OAuthTokens is the token endpoint response
+type OAuthTokens struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// OIDCSigningKey is a key signing id_tokens. The private key is PEM encoded
+// and encrypted at rest.
+type OIDCSigningKey struct {
+	ID         string    `json:"id"`
+	PrivateKey string    `json:"privateKey"`
+	Created    time.Time `json:"created"`
+}
@@ -0,0 +1,93 @@
+package model
+
+// SettingPasswordPolicy is the database setting key holding the
+// PasswordPolicy
+const SettingPasswordPolicy = "password_policy"
+
+// DefaultBcryptCost is the bcrypt cost when the policy does not set it
+const DefaultBcryptCost = 10
+
+// Password hashing algorithms
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+// Password policy violations, see PasswordViolation
+const (
+	PasswordViolationMinLength = "min_length"
+	PasswordViolationUppercase = "uppercase"
+	PasswordViolationLowercase = "lowercase"
+	PasswordViolationDigit     = "digit"
+	PasswordViolationSymbol    = "symbol"
+	PasswordViolationBanned    = "banned"
+	PasswordViolationEmail     = "email"
+)
+
+// PasswordPolicy holds the password rules and hashing of a database. The
+// rules apply to the passwords chosen by users, existing passwords keep
+// working when they change.
+type PasswordPolicy struct {
+	MinLength        int  `json:"minLength"`
+	RequireUppercase bool `json:"requireUppercase"`
+	RequireLowercase bool `json:"requireLowercase"`
+	RequireDigit     bool `json:"requireDigit"`
+	RequireSymbol    bool `json:"requireSymbol"`
+	// BannedListFile is the name of a file in the PASSWORD_BANNED_LISTS_DIR
+	// directory with one banned password per line, compared
+	// case-insensitively
+	BannedListFile string `json:"bannedListFile"`
+	// RejectEmail refuses passwords equal to the user's email
+	RejectEmail bool `json:"rejectEmail"`
+
+	// Hash is the algorithm of new password hashes, bcrypt when empty.
+	// Hashes using another algorithm or cost are upgraded when users sign in.
+	Hash string `json:"hash"`
+	// BcryptCost is the bcrypt cost, DefaultBcryptCost when 0
+	BcryptCost int `json:"bcryptCost"`
+	// Argon2Memory is the Argon2id memory in KiB from 8 MiB to 1 GiB, 64 MiB
+	// when 0
+	Argon2Memory uint32 `json:"argon2Memory"`
+	// Argon2Iterations is the Argon2id number of passes up to 10, 3 when 0
+	Argon2Iterations uint32 `json:"argon2Iterations"`
+	// Argon2Parallelism is the Argon2id number of threads up to 16, 2 when 0
+	Argon2Parallelism uint8 `json:"argon2Parallelism"`
+}
+
+// Algorithm returns the algorithm of new password hashes
+func (p PasswordPolicy) Algorithm() string {
+	if p.Hash == PasswordHashArgon2id {
+		return PasswordHashArgon2id
+	}
+	return PasswordHashBcrypt
+}
+
+// Cost returns the bcrypt cost of new password hashes
+func (p PasswordPolicy) Cost() int {
+	if p.BcryptCost <= 0 {
+		return DefaultBcryptCost
+	}
+	return p.BcryptCost
+}
+
+// Argon2Params returns the Argon2id memory in KiB, passes and threads of new
+// password hashes
+func (p PasswordPolicy) Argon2Params() (memory uint32, iterations uint32, parallelism uint8) {
+	memory, iterations, parallelism = 64*1024, 3, 2
+	if p.Argon2Memory > 0 {
+		memory = p.Argon2Memory
+	}
+	if p.Argon2Iterations > 0 {
+		iterations = p.Argon2Iterations
+	}
+	if p.Argon2Parallelism > 0 {
+		parallelism = p.Argon2Parallelism
+	}
+	return
+}
+
+// PasswordViolation is a password policy rule a password does not meet
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidPhone = errors.New("invalid phone number, use the international format like +15145551234")
+	ErrPhoneInUse   = errors.New("phone number is used by another user")
+)
+
+var phoneRe = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// UserPhone is the verified phone number of a user, used to sign in with
+// one-time codes sent by text-message
+type UserPhone struct {
+	UserID   string    `json:"userId"`
+	Number   string    `json:"number"`
+	Verified time.Time `json:"verified"`
+}
+
+// NormalizePhone returns number in the E.164 format, spaces, dashes, dots and
+// parenthesis are removed and 00 is accepted for the + prefix
+func NormalizePhone(number string) (string, error) {
+	number = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, number)
+
+	if strings.HasPrefix(number, "00") {
+		number = "+" + number[2:]
+	}
+
+	if !phoneRe.MatchString(number) {
+		return "", ErrInvalidPhone
+	}
+	return number, nil
+}
+
+// PhoneCodeData is the text-message sending a one-time code to a phone
+// number, [code] in Body is replaced by the code
+type PhoneCodeData struct {
+	Phone string `json:"phone"`
+	Body  string `json:"body"`
+}
+
+// PhoneCode validates the one-time code sent to a phone number
+type PhoneCode struct {
+	Phone string `json:"phone"`
+	Code  string `json:"code"`
+}
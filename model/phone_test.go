@@ -0,0 +1,27 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	valid := map[string]string{
+		"+1 (514) 555-1234":  "+15145551234",
+		"0033 6.12.34.56.78": "+33612345678",
+		"+15145551234":       "+15145551234",
+	}
+	for in, want := range valid {
+		if got, err := NormalizePhone(in); err != nil {
+			t.Errorf("%s: %v", in, err)
+		} else if got != want {
+			t.Errorf("%s: expected %s got %s", in, want, got)
+		}
+	}
+
+	for _, in := range []string{"", "5145551234", "+0145551234", "+1514abc1234", "+12345"} {
+		if _, err := NormalizePhone(in); !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("%s: expected ErrInvalidPhone got %v", in, err)
+		}
+	}
+}
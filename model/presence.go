@@ -0,0 +1,19 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PresenceMember is an authenticated realtime connection present in a
+// channel. A user has one member per connection, e.g. per browser tab.
+type PresenceMember struct {
+	// ID is the connection id
+	ID        string `json:"id"`
+	UserID    string `json:"userId"`
+	AccountID string `json:"accountId"`
+	// State is the custom JSON state of the member, e.g. {"typing": true}
+	State    json.RawMessage `json:"state,omitempty"`
+	Joined   time.Time       `json:"joined"`
+	LastSeen time.Time       `json:"lastSeen"`
+}
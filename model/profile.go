@@ -0,0 +1,131 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SettingProfilePolicy is the database setting key holding the
+// ProfilePolicy
+const SettingProfilePolicy = "profile_policy"
+
+const (
+	// ProfilePublic prefixes the fields of the public part of profiles
+	ProfilePublic = "public"
+	// ProfilePrivate prefixes the fields of the private part of profiles
+	ProfilePrivate = "private"
+)
+
+var (
+	ErrInvalidProfileField  = errors.New("invalid profile field")
+	ErrProfileFieldReadOnly = errors.New("profile field can only be changed by root")
+)
+
+// UserProfile holds the custom fields of a user. The public part is visible
+// to the users of their account, the private part only to the user and
+// root.
+type UserProfile struct {
+	UserID  string         `json:"userId"`
+	Public  map[string]any `json:"public"`
+	Private map[string]any `json:"private"`
+	Updated time.Time      `json:"updated"`
+}
+
+// Get returns the value of a field, see ProfilePolicy for the field names
+func (p UserProfile) Get(field string) (any, bool) {
+	part, name, _ := strings.Cut(field, ".")
+
+	var v any
+	var ok bool
+	switch part {
+	case ProfilePublic:
+		v, ok = p.Public[name]
+	case ProfilePrivate:
+		v, ok = p.Private[name]
+	}
+	return v, ok
+}
+
+// ProfileUpdate changes the fields of a profile, fields set to null are
+// removed and the others are kept.
+type ProfileUpdate struct {
+	Public  map[string]any `json:"public"`
+	Private map[string]any `json:"private"`
+}
+
+// Fields returns the names of the updated fields, see ProfilePolicy
+func (u ProfileUpdate) Fields() []string {
+	var fields []string
+	for name := range u.Public {
+		fields = append(fields, ProfilePublic+"."+name)
+	}
+	for name := range u.Private {
+		fields = append(fields, ProfilePrivate+"."+name)
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// Apply returns the profile with the update applied
+func (u ProfileUpdate) Apply(p UserProfile) UserProfile {
+	p.Public = applyProfileFields(p.Public, u.Public)
+	p.Private = applyProfileFields(p.Private, u.Private)
+	return p
+}
+
+func applyProfileFields(cur, update map[string]any) map[string]any {
+	if cur == nil {
+		cur = make(map[string]any)
+	}
+	for name, v := range update {
+		if v == nil {
+			delete(cur, name)
+			continue
+		}
+		cur[name] = v
+	}
+	return cur
+}
+
+// ProfilePolicy configures the profiles of a database. Fields are named
+// "public.{name}" or "private.{name}".
+type ProfilePolicy struct {
+	// Claims are the fields added to the session tokens under the "claims"
+	// claim, keyed by their name without the part.
+	Claims []string `json:"claims"`
+	// ReadOnly are the fields users cannot change on their own profile,
+	// fields used as claims are usually read-only.
+	ReadOnly []string `json:"readOnly"`
+}
+
+// Validate returns an error if a field name is invalid
+func (p ProfilePolicy) Validate() error {
+	for _, field := range slices.Concat(p.Claims, p.ReadOnly) {
+		part, name, ok := strings.Cut(field, ".")
+		if !ok || len(name) == 0 || (part != ProfilePublic && part != ProfilePrivate) {
+			return fmt.Errorf("%w: %q must be public.{name} or private.{name}", ErrInvalidProfileField, field)
+		}
+	}
+	return nil
+}
+
+// ClaimsOf returns the claims of a profile, nil when it has none
+func (p ProfilePolicy) ClaimsOf(profile UserProfile) map[string]any {
+	var claims map[string]any
+	for _, field := range p.Claims {
+		v, ok := profile.Get(field)
+		if !ok {
+			continue
+		}
+
+		if claims == nil {
+			claims = make(map[string]any)
+		}
+		_, name, _ := strings.Cut(field, ".")
+		claims[name] = v
+	}
+	return claims
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestProfileUpdateApply(t *testing.T) {
+	p := UserProfile{Public: map[string]any{"displayName": "Ada", "bio": "math"}}
+
+	u := ProfileUpdate{
+		Public:  map[string]any{"bio": nil, "avatar": "ada.png"},
+		Private: map[string]any{"phone": "555"},
+	}
+	if fields := u.Fields(); !slices.Equal(fields, []string{"private.phone", "public.avatar", "public.bio"}) {
+		t.Errorf("unexpected fields %v", fields)
+	}
+
+	p = u.Apply(p)
+	if _, ok := p.Public["bio"]; ok {
+		t.Error("expected null fields to be removed")
+	} else if p.Public["displayName"] != "Ada" || p.Public["avatar"] != "ada.png" || p.Private["phone"] != "555" {
+		t.Errorf("unexpected profile %v", p)
+	}
+}
+
+func TestProfilePolicy(t *testing.T) {
+	if err := (ProfilePolicy{Claims: []string{"tier"}}).Validate(); !errors.Is(err, ErrInvalidProfileField) {
+		t.Errorf("expected ErrInvalidProfileField got %v", err)
+	}
+	if err := (ProfilePolicy{ReadOnly: []string{"secret.tier"}}).Validate(); !errors.Is(err, ErrInvalidProfileField) {
+		t.Errorf("expected ErrInvalidProfileField got %v", err)
+	}
+
+	policy := ProfilePolicy{Claims: []string{"private.tier", "public.displayName", "public.missing"}}
+	if err := policy.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if claims := policy.ClaimsOf(UserProfile{}); claims != nil {
+		t.Errorf("expected no claims got %v", claims)
+	}
+
+	p := UserProfile{Public: map[string]any{"displayName": "Ada"}, Private: map[string]any{"tier": "gold", "phone": "555"}}
+	claims := policy.ClaimsOf(p)
+	if len(claims) != 2 || claims["tier"] != "gold" || claims["displayName"] != "Ada" {
+		t.Errorf("expected the tier and displayName claims got %v", claims)
+	}
+}
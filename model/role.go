@@ -0,0 +1,88 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// SettingCustomRoles is the database setting key holding the CustomRoles
+const SettingCustomRoles = "custom_roles"
+
+var (
+	ErrInvalidRole    = errors.New("invalid role")
+	ErrRoleNotAllowed = errors.New("your role does not allow this action")
+)
+
+var roleNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// RoleCapabilities are what users with a custom role can do. Functions lists
+// the functions they can execute, "*" matches all functions.
+type RoleCapabilities struct {
+	Collections []CollectionScope `json:"collections,omitempty"`
+	Functions   []string          `json:"functions,omitempty"`
+	Storage     bool              `json:"storage"`
+	ManageUsers bool              `json:"manageUsers"`
+}
+
+// Allows returns true if the capabilities grant access to a resource, scopes
+// are the ones of API keys. Routes of other scopes are not restricted by
+// custom roles.
+func (c RoleCapabilities) Allows(scope, name string, write bool) bool {
+	switch scope {
+	case APIKeyScopeCollections, APIKeyScopeFunctions, APIKeyScopeStorage:
+		s := APIKeyScopes{Collections: c.Collections, Functions: c.Functions, Storage: c.Storage}
+		return s.Allows(scope, name, write)
+	}
+	return true
+}
+
+// CustomRole is a named role of a database. Level is the integer role of
+// its users, used by the checks not aware of custom roles.
+type CustomRole struct {
+	Name         string           `json:"name"`
+	Level        int              `json:"level"`
+	Capabilities RoleCapabilities `json:"capabilities"`
+}
+
+// CustomRoles are the named roles defined by root for a database
+type CustomRoles []CustomRole
+
+// Find returns the role named name
+func (roles CustomRoles) Find(name string) (CustomRole, bool) {
+	i := slices.IndexFunc(roles, func(r CustomRole) bool { return r.Name == name })
+	if i < 0 {
+		return CustomRole{}, false
+	}
+	return roles[i], true
+}
+
+// Validate returns an error if the roles cannot be saved
+func (roles CustomRoles) Validate() error {
+	seen := make(map[string]bool)
+	for _, r := range roles {
+		if !roleNameRe.MatchString(r.Name) {
+			return fmt.Errorf("%w: %q must be lowercase letters, digits, - and _", ErrInvalidRole, r.Name)
+		} else if seen[r.Name] {
+			return fmt.Errorf("%w: %s is defined twice", ErrInvalidRole, r.Name)
+		} else if r.Level < 0 || r.Level >= 100 {
+			return fmt.Errorf("%w: %s level must be between 0 and 99", ErrInvalidRole, r.Name)
+		}
+		seen[r.Name] = true
+
+		for _, col := range r.Capabilities.Collections {
+			if len(col.Name) == 0 {
+				return fmt.Errorf("%w: %s collection name is required", ErrInvalidRole, r.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// UserRole is the custom role of a user in an account
+type UserRole struct {
+	UserID    string `json:"userId"`
+	AccountID string `json:"accountId"`
+	Name      string `json:"name"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCustomRolesValidate(t *testing.T) {
+	valid := CustomRoles{{Name: "editor", Level: 10}, {Name: "billing-admin", Level: 50}}
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []CustomRoles{
+		{{Name: "Editor"}},
+		{{Name: "editor"}, {Name: "editor"}},
+		{{Name: "owner", Level: 100}},
+		{{Name: "editor", Capabilities: RoleCapabilities{Collections: []CollectionScope{{}}}}},
+	}
+	for _, roles := range invalid {
+		if err := roles.Validate(); !errors.Is(err, ErrInvalidRole) {
+			t.Errorf("expected ErrInvalidRole for %v got %v", roles, err)
+		}
+	}
+}
+
+func TestRoleCapabilitiesAllows(t *testing.T) {
+	c := RoleCapabilities{
+		Collections: []CollectionScope{{Name: "tasks", Read: true, Write: true}, {Name: "reports", Read: true}},
+		Functions:   []string{"publish"},
+	}
+
+	tests := []struct {
+		scope, name string
+		write       bool
+		expected    bool
+	}{
+		{APIKeyScopeCollections, "tasks", true, true},
+		{APIKeyScopeCollections, "reports", false, true},
+		{APIKeyScopeCollections, "reports", true, false},
+		{APIKeyScopeCollections, "users", false, false},
+		{APIKeyScopeFunctions, "publish", true, true},
+		{APIKeyScopeFunctions, "refund", true, false},
+		{APIKeyScopeStorage, "", false, false},
+		{"", "", true, true},
+	}
+	for _, tc := range tests {
+		if got := c.Allows(tc.scope, tc.name, tc.write); got != tc.expected {
+			t.Errorf("Allows(%s, %s, %v) expected %v got %v", tc.scope, tc.name, tc.write, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// Session is a signed-in device of a user. The access tokens issued for a
+// session stop working once it is revoked. RefreshToken holds the hash of
+// the current refresh token, the token itself is only returned to the
+// client.
+type Session struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"userId"`
+	AccountID    string    `json:"accountId"`
+	RefreshToken string    `json:"-"`
+	UserAgent    string    `json:"userAgent"`
+	IP           string    `json:"ip"`
+	Created      time.Time `json:"created"`
+	LastSeen     time.Time `json:"lastSeen"`
+	Expires      time.Time `json:"expires"`
+	// ClientID is the OAuth client the session was started for by the
+	// database identity provider, Scope are the scopes granted to it
+	ClientID string `json:"clientId,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	Current  bool   `json:"current"`
+}
+
+// SessionTokens are the access and refresh tokens of a session. The refresh
+// token is exchanged for new tokens before the access token expires and can
+// only be used once.
+type SessionTokens struct {
+	SessionID    string    `json:"sessionId"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	Expires      time.Time `json:"expires"`
+}
+
+// RefreshSession exchanges a refresh token for new session tokens
+type RefreshSession struct {
+	RefreshToken string `json:"refreshToken"`
+}
@@ -8,11 +8,13 @@ import (
 
 	"github.com/staticbackendhq/core/backend"
 	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/internal"
 	"github.com/staticbackendhq/core/middleware"
 	"github.com/staticbackendhq/core/model"
 
 	"github.com/markbates/goth"
 	"github.com/markbates/goth/providers/facebook"
+	"github.com/markbates/goth/providers/github"
 	"github.com/markbates/goth/providers/google"
 	"github.com/markbates/goth/providers/twitter"
 )
@@ -21,8 +23,13 @@ const (
 	OAuthProviderTwitter  = "twitter"
 	OAuthProviderFacebook = "facebook"
 	OAuthProviderGoogle   = "google"
+	OAuthProviderGitHub   = "github"
 )
 
+// oauthCallbackAttempt identifies the failed OAuth callbacks of an IP
+// address for the login protection
+const oauthCallbackAttempt = "oauth-callback"
+
 type ExternalLogins struct {
 }
 
@@ -33,6 +40,8 @@ type ExternalUser struct {
 	FirstName string `json:"first"`
 	LastName  string `json:"last"`
 	AvatarURL string `json:"avatarUrl"`
+	// MFA is set instead of the token when the user must provide a TOTP code
+	MFA *model.MFAChallenge `json:"mfa,omitempty"`
 }
 
 func (el *ExternalLogins) login() http.Handler {
@@ -56,6 +65,21 @@ func (el *ExternalLogins) login() http.Handler {
 			return
 		}
 
+		// a guest signing in with a new email is upgraded instead of
+		// creating a new user
+		if guestToken := r.URL.Query().Get("guest"); len(guestToken) > 0 {
+			guest, err := middleware.ValidateAuthKey(backend.DB, backend.Cache, r.Context(), guestToken)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			if err := backend.Cache.SetTyped("oauth_guest_"+reqID, guest); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
 		customer, err := backend.DB.FindTenant(conf.TenantID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -104,12 +128,38 @@ func (el *ExternalLogins) callback() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		provider, reqID, baseID := el.fromState(el.getState(r))
 
+		// the database is unknown until the state is found, invalid
+		// states are counted per IP address with the default policy
+		guard, err := backend.Membership(model.DatabaseConfig{}).
+			WithClient(r.UserAgent(), middleware.ClientIP(r)).
+			GuardLogin(oauthCallbackAttempt)
+		if respondLoginThrottled(w, err) {
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		var conf model.DatabaseConfig
 		if err := backend.Cache.GetTyped("oauth_"+reqID, &conf); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			if err := guard.Fail(err); !respondLoginThrottled(w, err) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			}
 			return
 		} else if conf.ID != baseID {
-			http.Error(w, "invalid request", http.StatusBadRequest)
+			if err := guard.Fail(errors.New("invalid request")); !respondLoginThrottled(w, err) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			}
+			return
+		}
+
+		guard, err = backend.Membership(conf).
+			WithClient(r.UserAgent(), middleware.ClientIP(r)).
+			GuardLogin(oauthCallbackAttempt)
+		if respondLoginThrottled(w, err) {
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
@@ -139,7 +189,7 @@ func (el *ExternalLogins) callback() http.Handler {
 		}
 
 		sess, err := p.UnmarshalSession(value)
-		if err := backend.Cache.GetTyped(reqID+"_session", &value); err != nil {
+		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -157,7 +207,9 @@ func (el *ExternalLogins) callback() http.Handler {
 			// get new token and retry fetch
 			_, err = sess.Authorize(p, params)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				if err := guard.Fail(err); !respondLoginThrottled(w, err) {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
 				return
 			}
 
@@ -166,10 +218,20 @@ func (el *ExternalLogins) callback() http.Handler {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
+			guard.Succeed()
+
+			mship := backend.Membership(conf).WithClient(r.UserAgent(), middleware.ClientIP(r))
 
 			accessTokens := fmt.Sprintf("%s|%s", user.AccessToken, user.AccessTokenSecret)
-			sessionToken, err := el.registerOrLogin(conf, provider, user.Email, accessTokens)
-			if err != nil {
+			sessionToken, err := el.registerOrLogin(mship, conf, provider, reqID, user.Email, accessTokens)
+
+			var challenge *backend.MFAChallengeError
+			if errors.As(err, &challenge) {
+				err = nil
+			} else if errors.Is(err, model.ErrEmailNotVerified) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			} else if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
@@ -182,6 +244,9 @@ func (el *ExternalLogins) callback() http.Handler {
 				LastName:  user.LastName,
 				AvatarURL: user.AvatarURL,
 			}
+			if challenge != nil {
+				extuser.MFA = &challenge.Challenge
+			}
 
 			if err := backend.Cache.SetTyped("extuser_"+reqID, extuser); err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -207,7 +272,7 @@ func (*ExternalLogins) getUser(w http.ResponseWriter, r *http.Request) {
 	respond(w, http.StatusOK, extuser)
 }
 
-func (el *ExternalLogins) registerOrLogin(conf model.DatabaseConfig, provider, email, accessToken string) (sessionToken string, err error) {
+func (el *ExternalLogins) registerOrLogin(mship backend.User, conf model.DatabaseConfig, provider, reqID, email, accessToken string) (sessionToken string, err error) {
 	email = strings.ToLower(email)
 
 	exists, err := backend.DB.UserEmailExists(conf.Name, email)
@@ -215,42 +280,26 @@ func (el *ExternalLogins) registerOrLogin(conf model.DatabaseConfig, provider, e
 		return
 	}
 
+	// the sign in requires MFA and a verified email like other logins
 	if exists {
-		return el.signIn(conf, email)
+		return mship.ExternalLogin(email)
 	}
 
-	return el.signUp(conf, provider, email, accessToken)
+	return el.signUp(mship, provider, reqID, email, accessToken)
 }
 
-func (el *ExternalLogins) signIn(conf model.DatabaseConfig, email string) (sessionToken string, err error) {
-	tok, err := backend.DB.FindUserByEmail(conf.Name, email)
-	if err != nil {
-		return
-	}
-
-	token := fmt.Sprintf("%s|%s", tok.ID, tok.Token)
-
-	b, err := backend.GetJWT(token)
-	if err != nil {
-		return
-	}
-
-	sessionToken = string(b)
-	return
-}
-
-func (el *ExternalLogins) signUp(conf model.DatabaseConfig, provider, email, accessToken string) (sessionToken string, err error) {
+func (el *ExternalLogins) signUp(mship backend.User, provider, reqID, email, accessToken string) (sessionToken string, err error) {
 	pw := fmt.Sprintf("%s:%s", provider, accessToken)
 
-	mship := backend.Membership(conf)
-
-	b, _, err := mship.CreateAccountAndUser(email, pw, 0)
-	if err != nil {
-		return
+	var guest model.Auth
+	if err := backend.Cache.GetTyped("oauth_guest_"+reqID, &guest); err == nil && len(guest.UserID) > 0 {
+		if err := backend.Cache.Delete("oauth_guest_" + reqID); err != nil {
+			return "", err
+		}
+		return mship.UpgradeGuestVerified(guest, email, pw)
 	}
 
-	sessionToken = string(b)
-	return
+	return mship.ExternalRegister(email, pw)
 }
 
 func (el *ExternalLogins) getProvider(dbID, provider, reqID string, info model.OAuthConfig) (p goth.Provider, err error) {
@@ -259,6 +308,24 @@ func (el *ExternalLogins) getProvider(dbID, provider, reqID string, info model.O
 		config.Current.AppURL,
 	)
 
+	if oidc := info.OIDC; oidc != nil {
+		op, err := internal.NewOIDCProvider(
+			provider,
+			oidc.IssuerURL,
+			info.ConsumerKey,
+			info.ConsumerSecret,
+			callbackURL,
+			oidc.Scopes,
+			oidc.Claims,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		op.TrustEmails = oidc.TrustEmails
+		return op, nil
+	}
+
 	switch provider {
 	case OAuthProviderTwitter:
 		return twitter.New(info.ConsumerKey, info.ConsumerSecret, callbackURL), nil
@@ -266,6 +333,8 @@ func (el *ExternalLogins) getProvider(dbID, provider, reqID string, info model.O
 		return facebook.New(info.ConsumerKey, info.ConsumerSecret, callbackURL), nil
 	case OAuthProviderGoogle:
 		return google.New(info.ConsumerKey, info.ConsumerSecret, callbackURL), nil
+	case OAuthProviderGitHub:
+		return github.New(info.ConsumerKey, info.ConsumerSecret, callbackURL, "user:email"), nil
 	}
 	return twitter.New("", "", ""), errors.New("invalid auth provider")
 }
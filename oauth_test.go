@@ -0,0 +1,233 @@
+package staticbackend
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// newStandInOIDC starts an OpenID Connect provider approving every login as
+// email. It returns the function playing the user's approval at the
+// authorization URL.
+func newStandInOIDC(t *testing.T, clientID, email string) (*httptest.Server, func(authURL string) string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	// code -> PKCE challenge and nonce of the authorization request
+	codes := make(map[string][2]string)
+
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 srv.URL,
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+			"jwks_uri":               srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "standin",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		req, ok := codes[r.FormValue("code")]
+		mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != req[0] {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "standin"})
+		claims, _ := json.Marshal(map[string]any{
+			"iss":            srv.URL,
+			"sub":            "standin-user",
+			"aud":            clientID,
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"nonce":          req[1],
+			"email":          email,
+			"email_verified": true,
+			"name":           "Stand In",
+		})
+		signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+		digest := sha256.Sum256([]byte(signed))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "standin-access",
+			"token_type":   "Bearer",
+			"id_token":     signed + "." + base64.RawURLEncoding.EncodeToString(sig),
+		})
+	})
+
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	approve := func(authURL string) string {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		q := u.Query()
+		if q.Get("code_challenge_method") != "S256" {
+			t.Fatalf("expected a PKCE challenge in %s", authURL)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		codes["standin-code"] = [2]string{q.Get("code_challenge"), q.Get("nonce")}
+		return "standin-code"
+	}
+	return srv, approve
+}
+
+// oidcLogin signs in with a stand-in OpenID Connect provider approving the
+// login as email and returns the external user of the request
+func oidcLogin(t *testing.T, reqID, email string) ExternalUser {
+	t.Helper()
+
+	if err := loadTemplates(); err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cus, err := backend.DB.FindTenant(conf.TenantID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logins, err := cus.GetExternalLogins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = backend.DB.EnableExternalLogin(cus.ID, logins)
+	})
+
+	srv, approve := newStandInOIDC(t, "sb-client", email)
+
+	withOIDC := map[string]model.OAuthConfig{
+		"keycloak": {
+			ConsumerKey:    "sb-client",
+			ConsumerSecret: "sb-secret",
+			OIDC:           &model.OIDCConfig{IssuerURL: srv.URL},
+		},
+	}
+	if err := backend.DB.EnableExternalLogin(cus.ID, withOIDC); err != nil {
+		t.Fatal(err)
+	}
+
+	el := &ExternalLogins{}
+
+	req := httptest.NewRequest("GET", "/oauth/login?provider=keycloak&reqid="+reqID, nil)
+	req.Header.Set("SB-PUBLIC-KEY", pubKey)
+	w := httptest.NewRecorder()
+	middleware.Chain(el.login(), middleware.WithDB(backend.DB, backend.Cache, getStripePortalURL)).ServeHTTP(w, req)
+
+	if w.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("expected a redirect to the provider got %d: %s", w.Code, w.Body.String())
+	}
+
+	authURL := w.Header().Get("Location")
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := approve(authURL)
+
+	params := url.Values{"state": {u.Query().Get("state")}, "code": {code}}
+	w = httptest.NewRecorder()
+	el.callback().ServeHTTP(w, httptest.NewRequest("GET", "/oauth/callback/?"+params.Encode(), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected callback to succeed got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	el.getUser(w, httptest.NewRequest("GET", "/oauth/get-user?reqid="+reqID, nil))
+
+	var extuser ExternalUser
+	if err := json.NewDecoder(w.Body).Decode(&extuser); err != nil {
+		t.Fatal(err)
+	}
+	return extuser
+}
+
+func TestOAuthOIDCLogin(t *testing.T) {
+	extuser := oidcLogin(t, "oidcreq123", "oidc-login@test.com")
+
+	if extuser.Email != "oidc-login@test.com" || extuser.Name != "Stand In" {
+		t.Errorf("unexpected external user %v", extuser)
+	}
+
+	resp := authReqWithToken(t, extuser.Token, mship.me, "GET", "/me", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the OIDC token to authenticate got %s", GetResponseBody(t, resp))
+	}
+}
+
+func TestOAuthOIDCLoginRequiresMFA(t *testing.T) {
+	const email = "oidc-mfa@test.com"
+
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usr := backend.Membership(conf)
+	if _, _, err := usr.CreateAccountAndUser(email, "test1234!", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := usr.SetMFAPolicy(model.MFAPolicy{Required: true}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = usr.SetMFAPolicy(model.MFAPolicy{}) })
+
+	extuser := oidcLogin(t, "oidcreqmfa", email)
+	if len(extuser.Token) > 0 {
+		t.Error("expected no session token before the TOTP code")
+	} else if extuser.MFA == nil || !extuser.MFA.EnrollmentRequired || len(extuser.MFA.Challenge) == 0 {
+		t.Errorf("expected an MFA enrollment challenge got %v", extuser.MFA)
+	}
+}
@@ -0,0 +1,494 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// oidcSessionCookie keeps users signed in at the identity provider so they
+// are not asked to log in again by every client
+const oidcSessionCookie = "sb_oidc"
+
+// oidcAuthorizeView is the data of the sign in and consent page
+type oidcAuthorizeView struct {
+	Step      string
+	Request   model.OAuthAuthorizeRequest
+	Client    model.OAuthClient
+	Email     string
+	Challenge string
+	Scopes    []string
+}
+
+type identityProvider struct{}
+
+// oidcWithDB reads the database public key from /oidc/{publicKey}/... for
+// WithDB, clients only know the issuer URL.
+func oidcWithDB() middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Header.Set("SB-PUBLIC-KEY", getURLPart(r.URL.Path, 2))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (idp *identityProvider) route(w http.ResponseWriter, r *http.Request) {
+	switch getURLPart(r.URL.Path, 3) {
+	case ".well-known":
+		if getURLPart(r.URL.Path, 4) != "openid-configuration" {
+			http.NotFound(w, r)
+			return
+		}
+		idp.discovery(w, r)
+	case "jwks":
+		idp.jwks(w, r)
+	case "authorize":
+		idp.authorize(w, r)
+	case "token":
+		idp.token(w, r)
+	case "userinfo":
+		idp.userinfo(w, r)
+	case "revoke":
+		idp.revoke(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (idp *identityProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respond(w, http.StatusOK, backend.IdentityProvider(conf).Discovery())
+}
+
+func (idp *identityProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	keys, err := backend.IdentityProvider(conf).JWKS()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, keys)
+}
+
+// authorize signs the user in, asks for consent when needed and redirects
+// to the client with an authorization code. The page posts back to itself
+// for each step.
+func (idp *identityProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := model.OAuthAuthorizeRequest{
+		ClientID:            r.Form.Get("client_id"),
+		RedirectURI:         r.Form.Get("redirect_uri"),
+		ResponseType:        r.Form.Get("response_type"),
+		Scope:               r.Form.Get("scope"),
+		State:               r.Form.Get("state"),
+		Nonce:               r.Form.Get("nonce"),
+		CodeChallenge:       r.Form.Get("code_challenge"),
+		CodeChallengeMethod: r.Form.Get("code_challenge_method"),
+		Prompt:              r.Form.Get("prompt"),
+	}
+
+	provider := backend.IdentityProvider(conf)
+
+	client, err := provider.ValidateAuthorization(req)
+	if err != nil {
+		var oauthErr *model.OAuthError
+		if errors.As(err, &oauthErr) {
+			redirectOAuth(w, r, req, url.Values{"error": {oauthErr.Code}, "error_description": {oauthErr.Description}})
+			return
+		}
+
+		render(w, r, "oidc_authorize.html", oidcAuthorizeView{Step: "error"}, &Flash{Type: "danger", Message: err.Error()})
+		return
+	}
+
+	view := oidcAuthorizeView{Request: req, Client: client, Scopes: req.Scopes()}
+
+	if r.Method == http.MethodPost && r.Form.Get("action") == "deny" {
+		redirectOAuth(w, r, req, url.Values{"error": {"access_denied"}})
+		return
+	}
+
+	auth, signedIn := idp.signedIn(r)
+	if req.Prompt == "login" && r.Method == http.MethodGet {
+		signedIn = false
+	}
+
+	if r.Method == http.MethodPost && r.Form.Get("action") == "login" {
+		token, err := idp.login(conf, r)
+		if err != nil {
+			var flash *Flash
+
+			var mfaErr *backend.MFAChallengeError
+			if errors.As(err, &mfaErr) && !mfaErr.Challenge.EnrollmentRequired {
+				view.Step = "mfa"
+				view.Challenge = mfaErr.Challenge.Challenge
+			} else if errors.As(err, &mfaErr) {
+				view.Step = "login"
+				flash = &Flash{Type: "danger", Message: "multi-factor authentication must be set up in the app before signing in here"}
+			} else if challenge := r.Form.Get("challenge"); len(challenge) > 0 {
+				view.Step = "mfa"
+				view.Challenge = challenge
+				flash = &Flash{Type: "danger", Message: err.Error()}
+			} else {
+				view.Step = "login"
+				flash = &Flash{Type: "danger", Message: err.Error()}
+			}
+
+			view.Email = r.Form.Get("email")
+			render(w, r, "oidc_authorize.html", view, flash)
+			return
+		}
+
+		auth, err = middleware.ValidateAuthKey(backend.DB, backend.Cache, r.Context(), token)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcSessionCookie,
+			Value:    token,
+			Path:     "/oidc/" + conf.ID,
+			MaxAge:   int((12 * time.Hour).Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil || strings.HasPrefix(backend.IdentityProvider(conf).Issuer, "https://"),
+			SameSite: http.SameSiteLaxMode,
+		})
+		signedIn = true
+	}
+
+	if !signedIn {
+		if req.Prompt == "none" {
+			redirectOAuth(w, r, req, url.Values{"error": {"login_required"}})
+			return
+		}
+
+		view.Step = "login"
+		render(w, r, "oidc_authorize.html", view, nil)
+		return
+	}
+
+	if r.Method == http.MethodPost && r.Form.Get("action") == "consent" {
+		if err := provider.Consent(auth, client, view.Scopes); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	needsConsent, err := provider.NeedsConsent(auth, client, view.Scopes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if needsConsent || (req.Prompt == "consent" && r.Method == http.MethodGet && !client.Trusted) {
+		if req.Prompt == "none" {
+			redirectOAuth(w, r, req, url.Values{"error": {"consent_required"}})
+			return
+		}
+
+		view.Step = "consent"
+		view.Email = auth.Email
+		render(w, r, "oidc_authorize.html", view, nil)
+		return
+	}
+
+	code, err := provider.IssueCode(auth, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	redirectOAuth(w, r, req, url.Values{"code": {code}})
+}
+
+// signedIn returns the user signed in at the identity provider, if any
+func (idp *identityProvider) signedIn(r *http.Request) (model.Auth, bool) {
+	ck, err := r.Cookie(oidcSessionCookie)
+	if err != nil || len(ck.Value) == 0 {
+		return model.Auth{}, false
+	}
+
+	auth, err := middleware.ValidateAuthKey(backend.DB, backend.Cache, r.Context(), ck.Value)
+	if err != nil {
+		return model.Auth{}, false
+	}
+	return auth, true
+}
+
+// login authenticates the email and password of the sign in form, or the
+// TOTP code when completing a multi-factor challenge
+func (idp *identityProvider) login(conf model.DatabaseConfig, r *http.Request) (string, error) {
+	u := backend.Membership(conf).WithClient(r.UserAgent(), middleware.ClientIP(r))
+
+	if challenge := r.Form.Get("challenge"); len(challenge) > 0 {
+		result, err := u.CompleteMFALogin(challenge, r.Form.Get("code"))
+		if err != nil {
+			return "", err
+		}
+		return result.Token, nil
+	}
+
+	return u.Authenticate(r.Form.Get("email"), r.Form.Get("password"))
+}
+
+// redirectOAuth sends the user back to the client's redirect URI with the
+// authorization response parameters
+func redirectOAuth(w http.ResponseWriter, r *http.Request, req model.OAuthAuthorizeRequest, params url.Values) {
+	u, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.State) > 0 {
+		params.Set("state", req.State)
+	}
+	if len(params.Get("error_description")) == 0 {
+		params.Del("error_description")
+	}
+
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+func (idp *identityProvider) token(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		respondOAuthError(w, &model.OAuthError{Code: "invalid_request", Description: err.Error()})
+		return
+	}
+
+	clientID, secret := oauthClientCredentials(r)
+	provider := backend.IdentityProvider(conf)
+
+	var tokens model.OAuthTokens
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		tokens, err = provider.Exchange(
+			clientID,
+			secret,
+			r.PostForm.Get("code"),
+			r.PostForm.Get("redirect_uri"),
+			r.PostForm.Get("code_verifier"),
+			middleware.ClientIP(r),
+		)
+	case "refresh_token":
+		tokens, err = provider.Refresh(clientID, secret, r.PostForm.Get("refresh_token"))
+	default:
+		err = &model.OAuthError{Code: "unsupported_grant_type"}
+	}
+
+	if err != nil {
+		respondOAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respond(w, http.StatusOK, tokens)
+}
+
+func (idp *identityProvider) userinfo(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	provider := backend.IdentityProvider(conf)
+
+	auth, scope, err := middleware.ValidateClientAccessToken(backend.DB, backend.Cache, r.Context(), token, provider.Issuer)
+	if err != nil || len(token) == 0 {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, "invalid access token", http.StatusUnauthorized)
+		return
+	}
+
+	respond(w, http.StatusOK, provider.UserInfo(auth, scope))
+}
+
+func (idp *identityProvider) revoke(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		respondOAuthError(w, &model.OAuthError{Code: "invalid_request", Description: err.Error()})
+		return
+	}
+
+	clientID, secret := oauthClientCredentials(r)
+	if err := backend.IdentityProvider(conf).Revoke(clientID, secret, r.PostForm.Get("token")); err != nil {
+		respondOAuthError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// oauthClientCredentials returns the client credentials from the basic
+// authorization header or from the form
+func oauthClientCredentials(r *http.Request) (clientID, secret string) {
+	if id, pw, ok := r.BasicAuth(); ok {
+		// credentials are form-urlencoded before being base64 encoded
+		clientID, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(pw)
+		return
+	}
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+}
+
+func respondOAuthError(w http.ResponseWriter, err error) {
+	var oauthErr *model.OAuthError
+	if !errors.As(err, &oauthErr) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusBadRequest
+	if oauthErr.Code == "invalid_client" {
+		status = http.StatusUnauthorized
+		w.Header().Set("WWW-Authenticate", "Basic")
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respond(w, status, oauthErr)
+}
+
+func sudoOAuthClients(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	provider := backend.IdentityProvider(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		clients, err := provider.ListClients()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, clients)
+	case http.MethodPost:
+		var data model.NewOAuthClient
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		client, err := provider.CreateClient(data)
+		if err != nil {
+			if errors.Is(err, model.ErrInvalidOAuthClient) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, client)
+	case http.MethodDelete:
+		if err := provider.DeleteClient(r.URL.Query().Get("id")); err != nil {
+			if errors.Is(err, backend.ErrOAuthClientNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// oauthConsents lists the apps the user signed in to with the database's
+// identity provider and revokes their access with DELETE ?clientId=
+func (m *membership) oauthConsents(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	provider := backend.IdentityProvider(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		consents, err := provider.ListConsents(auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, consents)
+	case http.MethodDelete:
+		if err := provider.RevokeConsent(auth, r.URL.Query().Get("clientId")); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
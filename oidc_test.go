@@ -0,0 +1,382 @@
+package staticbackend
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/config"
+	"github.com/staticbackendhq/core/internal"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+const oidcTestRedirect = "http://localhost:8787/callback"
+
+// newTestIdentityProvider serves the identity provider of the test database
+// and returns its issuer URL with a browser keeping cookies and not following
+// redirects.
+func newTestIdentityProvider(t *testing.T) (string, *http.Client) {
+	t.Helper()
+
+	idp := &identityProvider{}
+	h := middleware.Chain(http.HandlerFunc(idp.route), oidcWithDB(), middleware.WithDB(backend.DB, backend.Cache, getStripePortalURL))
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	appURL := config.Current.AppURL
+	config.Current.AppURL = srv.URL
+	t.Cleanup(func() { config.Current.AppURL = appURL })
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	browser := &http.Client{
+		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return srv.URL + "/oidc/" + pubKey, browser
+}
+
+func newTestOAuthClient(t *testing.T, public bool) model.CreatedOAuthClient {
+	t.Helper()
+
+	nc := model.NewOAuthClient{Name: "Test App", RedirectURIs: []string{oidcTestRedirect}, Public: public}
+	resp := dbReq(t, sudoOAuthClients, "POST", "/sudo/oauth/clients", nc, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var client model.CreatedOAuthClient
+	if err := parseBody(resp.Body, &client); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		resp := dbReq(t, sudoOAuthClients, "DELETE", "/sudo/oauth/clients?id="+client.ID, nil, true)
+		_ = resp.Body.Close()
+	})
+	return client
+}
+
+func oidcAuthorizeParams(clientID, verifier, scope string) url.Values {
+	sum := sha256.Sum256([]byte(verifier))
+	return url.Values{
+		"client_id":             {clientID},
+		"redirect_uri":          {oidcTestRedirect},
+		"response_type":         {"code"},
+		"scope":                 {scope},
+		"state":                 {"st4te"},
+		"nonce":                 {"n0nce"},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+}
+
+// authorizationCode returns the code of the redirect to the client
+func authorizationCode(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected a redirect to the client got %d: %s", resp.StatusCode, GetResponseBody(t, resp))
+	}
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(loc.String(), oidcTestRedirect) || loc.Query().Get("state") != "st4te" {
+		t.Fatalf("unexpected redirect %s", loc)
+	} else if len(loc.Query().Get("code")) == 0 {
+		t.Fatalf("expected a code in %s", loc)
+	}
+	return loc.Query().Get("code")
+}
+
+func oidcToken(t *testing.T, issuer string, client model.CreatedOAuthClient, params url.Values) (model.OAuthTokens, int) {
+	t.Helper()
+
+	req, err := http.NewRequest("POST", issuer+"/token", strings.NewReader(params.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(client.ID, client.Secret)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var tokens model.OAuthTokens
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tokens, resp.StatusCode
+}
+
+func TestOIDCProviderAuthorizationCode(t *testing.T) {
+	if err := loadTemplates(); err != nil {
+		t.Fatal(err)
+	}
+
+	issuer, browser := newTestIdentityProvider(t)
+	client := newTestOAuthClient(t, false)
+
+	params := oidcAuthorizeParams(client.ID, "verifier-verifier-verifier-verifier-1234", "openid email offline_access")
+
+	resp, err := browser.Get(issuer + "/authorize?" + params.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := GetResponseBody(t, resp)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `name="password"`) {
+		t.Fatalf("expected the sign in page got %d: %s", resp.StatusCode, body)
+	}
+
+	login := url.Values{"action": {"login"}, "email": {userEmail}, "password": {userPassword}}
+	for k, v := range params {
+		login[k] = v
+	}
+
+	resp, err = browser.PostForm(issuer+"/authorize", login)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body = GetResponseBody(t, resp)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `value="consent"`) {
+		t.Fatalf("expected the consent page got %d: %s", resp.StatusCode, body)
+	}
+
+	consent := url.Values{"action": {"consent"}}
+	for k, v := range params {
+		consent[k] = v
+	}
+
+	resp, err = browser.PostForm(issuer+"/authorize", consent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := authorizationCode(t, resp)
+
+	exchange := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {oidcTestRedirect},
+		"code_verifier": {"verifier-verifier-verifier-verifier-1234"},
+	}
+	tokens, status := oidcToken(t, issuer, client, exchange)
+	if status != http.StatusOK {
+		t.Fatalf("expected the code exchange to succeed got %d", status)
+	} else if len(tokens.RefreshToken) == 0 {
+		t.Error("expected a refresh token with the offline_access scope")
+	}
+
+	if _, status := oidcToken(t, issuer, client, exchange); status != http.StatusBadRequest {
+		t.Errorf("expected a used code to be refused got %d", status)
+	}
+
+	d, err := internal.DiscoverOIDC(issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := internal.VerifyIDToken(tokens.IDToken, d, client.ID, "n0nce", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	} else if claims["email"] != userEmail {
+		t.Errorf("expected email claim to be %s got %v", userEmail, claims["email"])
+	}
+
+	req, err := http.NewRequest("GET", d.UserInfoURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var info map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	} else if info["sub"] != claims["sub"] || info["email"] != userEmail {
+		t.Errorf("unexpected userinfo %v for id_token %v", info, claims)
+	}
+
+	// the access tokens of a client are not session tokens for the API
+	resp = authReqWithToken(t, tokens.AccessToken, mship.me, "GET", "/me", nil)
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		t.Error("expected the client access token to be refused by the API")
+	}
+
+	refresh := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {tokens.RefreshToken}}
+	refreshed, status := oidcToken(t, issuer, client, refresh)
+	if status != http.StatusOK || len(refreshed.AccessToken) == 0 {
+		t.Fatalf("expected the refresh to succeed got %d", status)
+	}
+
+	resp = authReqWithToken(t, refreshed.AccessToken, mship.me, "GET", "/me", nil)
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		t.Error("expected the refreshed client access token to be refused by the API")
+	}
+
+	// refresh tokens are bound to the client they were issued to
+	other := newTestOAuthClient(t, true)
+	refresh.Set("refresh_token", refreshed.RefreshToken)
+	if _, status := oidcToken(t, issuer, other, refresh); status != http.StatusBadRequest {
+		t.Errorf("expected another client's refresh token to be refused got %d", status)
+	}
+
+	req, err = http.NewRequest("POST", issuer+"/revoke", strings.NewReader(url.Values{"token": {refreshed.RefreshToken}}.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(other.ID, "")
+
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected another client's revocation to be refused got %d", resp.StatusCode)
+	}
+
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app, err := backend.Membership(conf).Login(userEmail, userPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refresh.Set("refresh_token", app.RefreshToken)
+	if _, status := oidcToken(t, issuer, client, refresh); status != http.StatusBadRequest {
+		t.Errorf("expected the app's refresh token to be refused got %d", status)
+	}
+
+	req, err = http.NewRequest("POST", issuer+"/revoke", strings.NewReader(url.Values{"token": {refreshed.RefreshToken}}.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(client.ID, client.Secret)
+
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the revocation to succeed got %d", resp.StatusCode)
+	}
+
+	refresh.Set("refresh_token", refreshed.RefreshToken)
+	if _, status := oidcToken(t, issuer, client, refresh); status != http.StatusBadRequest {
+		t.Errorf("expected a revoked refresh token to be refused got %d", status)
+	}
+
+	// the user is signed in and consented, a new authorization is immediate
+	params = oidcAuthorizeParams(client.ID, "another-verifier-another-verifier-5678", "openid email")
+	params.Set("prompt", "none")
+
+	resp, err = browser.Get(issuer + "/authorize?" + params.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	code = authorizationCode(t, resp)
+
+	exchange.Set("code", code)
+	if _, status := oidcToken(t, issuer, client, exchange); status != http.StatusBadRequest {
+		t.Errorf("expected a wrong code_verifier to be refused got %d", status)
+	}
+
+	resp = authReqWithToken(t, userToken, mship.oauthConsents, "DELETE", "/me/oauth/consents?clientId="+client.ID, nil)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the consent to be revoked got %d", resp.StatusCode)
+	}
+
+	resp, err = browser.Get(issuer + "/authorize?" + params.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	} else if loc.Query().Get("error") != "consent_required" {
+		t.Errorf("expected consent_required after revoking the consent got %s", loc)
+	}
+}
+
+func TestOIDCProviderInvalidRequests(t *testing.T) {
+	if err := loadTemplates(); err != nil {
+		t.Fatal(err)
+	}
+
+	issuer, browser := newTestIdentityProvider(t)
+	client := newTestOAuthClient(t, true)
+
+	params := oidcAuthorizeParams(client.ID, "verifier-verifier-verifier-verifier-1234", "openid")
+	params.Set("redirect_uri", "https://evil.example.com/callback")
+
+	resp, err := browser.Get(issuer + "/authorize?" + params.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected an unregistered redirect_uri to show an error page got %d", resp.StatusCode)
+	}
+
+	params = oidcAuthorizeParams(client.ID, "verifier-verifier-verifier-verifier-1234", "openid")
+	params.Del("code_challenge")
+
+	resp, err = browser.Get(issuer + "/authorize?" + params.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	} else if loc.Query().Get("error") != "invalid_request" || loc.Query().Get("state") != "st4te" {
+		t.Errorf("expected a public client without PKCE to be refused got %s", loc)
+	}
+
+	nc := model.NewOAuthClient{Name: "plain http", RedirectURIs: []string{"http://example.com/callback"}}
+	resp = dbReq(t, sudoOAuthClients, "POST", "/sudo/oauth/clients", nc, true)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a non-https redirect URI to be refused got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,68 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// PasswordPolicyErrorData is the body of the 400 responses for passwords
+// not meeting the password policy
+type PasswordPolicyErrorData struct {
+	Error      string                    `json:"error"`
+	Violations []model.PasswordViolation `json:"violations"`
+}
+
+// respondPasswordPolicy writes the violations when the password does not
+// meet the password policy and returns false for any other error.
+func respondPasswordPolicy(w http.ResponseWriter, err error) bool {
+	var pe *backend.PasswordPolicyError
+	if !errors.As(err, &pe) {
+		return false
+	}
+
+	respond(w, http.StatusBadRequest, PasswordPolicyErrorData{
+		Error:      backend.ErrWeakPassword.Error(),
+		Violations: pe.Violations,
+	})
+	return true
+}
+
+func (m *membership) sudoPasswordPolicy(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := mship.GetPasswordPolicy()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	case http.MethodPost:
+		var policy model.PasswordPolicy
+		if err := parseBody(r.Body, &policy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.SetPasswordPolicy(policy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		respond(w, http.StatusOK, policy)
+	default:
+		http.Error(w, "method not implemented", http.StatusNotImplemented)
+	}
+}
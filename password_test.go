@@ -0,0 +1,47 @@
+package staticbackend
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestPasswordPolicyViolations(t *testing.T) {
+	resp := dbReq(t, mship.sudoPasswordPolicy, "POST", "/sudo/passwordpolicy", model.PasswordPolicy{MinLength: 12, RequireDigit: true}, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+	t.Cleanup(func() {
+		if conf, err := backend.DB.FindDatabase(pubKey); err == nil {
+			_ = backend.Membership(conf).SetPasswordPolicy(model.PasswordPolicy{})
+		}
+	})
+
+	resp = dbReq(t, mship.register, "POST", "/register", model.Login{Email: "weak-password@test.com", Password: "weak"})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a weak password got %d", resp.StatusCode)
+	}
+
+	var data PasswordPolicyErrorData
+	if err := parseBody(resp.Body, &data); err != nil {
+		t.Fatal(err)
+	} else if len(data.Violations) != 2 || data.Violations[0].Code != model.PasswordViolationMinLength || data.Violations[1].Code != model.PasswordViolationDigit {
+		t.Errorf("expected the min length and digit violations got %v", data.Violations)
+	}
+
+	resp = dbReq(t, acct.addUser, "POST", "/account/users", model.Login{Email: "weak-member@test.com", Password: "weak"})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 when adding a user with a weak password got %d", resp.StatusCode)
+	}
+
+	resp = dbReq(t, mship.register, "POST", "/register", model.Login{Email: "strong-password@test.com", Password: "long-enough-42"})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Error(GetResponseBody(t, resp))
+	}
+}
@@ -0,0 +1,148 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+func phoneErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, model.ErrInvalidPhone), errors.Is(err, backend.ErrInvalidPhoneCode):
+		return http.StatusBadRequest
+	case errors.Is(err, model.ErrPhoneInUse):
+		return http.StatusConflict
+	case errors.Is(err, backend.ErrTooManyPhoneCodes):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// phone returns the verified phone number of the current user with GET,
+// texts a verification code to a new number with POST and removes it with
+// DELETE
+func (m *membership) phone(w http.ResponseWriter, r *http.Request) {
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		phone, err := mship.GetPhone(auth.UserID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, phone)
+	case http.MethodPost:
+		var data model.PhoneCodeData
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.SendPhoneVerification(auth, data); err != nil {
+			http.Error(w, err.Error(), phoneErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	case http.MethodDelete:
+		if err := mship.RemovePhone(auth); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, true)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verifyPhone validates the code texted to a new phone number and saves it
+// as the current user's phone
+func (m *membership) verifyPhone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, auth, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var data model.PhoneCode
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	phone, err := backend.Membership(conf).VerifyPhone(auth, data)
+	if err != nil {
+		http.Error(w, err.Error(), phoneErrorStatus(err))
+		return
+	}
+
+	respond(w, http.StatusOK, phone)
+}
+
+// phoneLogin texts a sign-in code to a verified phone number with POST
+// /login/sms and validates it with POST /login/sms/verify returning a
+// session token
+func (m *membership) phoneLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	mship := backend.Membership(conf).WithClient(r.UserAgent(), middleware.ClientIP(r))
+
+	if getURLPart(r.URL.Path, 3) == "verify" {
+		var data model.PhoneCode
+		if err := parseBody(r.Body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		token, err := mship.ValidatePhoneLogin(data.Phone, data.Code)
+		if respondMFAChallenge(w, err) || respondLoginThrottled(w, err) {
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), loginErrorStatus(err))
+			return
+		}
+
+		respond(w, http.StatusOK, token)
+		return
+	}
+
+	var data model.PhoneCodeData
+	if err := parseBody(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := mship.SetupPhoneLogin(data); err != nil {
+		http.Error(w, err.Error(), phoneErrorStatus(err))
+		return
+	}
+
+	respond(w, http.StatusOK, true)
+}
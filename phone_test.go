@@ -0,0 +1,125 @@
+package staticbackend
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+	"github.com/staticbackendhq/core/sms"
+)
+
+var smsCodeRe = regexp.MustCompile(`[0-9]{6}`)
+
+type smsStandIn struct {
+	code string
+}
+
+func (s *smsStandIn) Send(data sms.SMSData) error {
+	s.code = smsCodeRe.FindString(data.Body)
+	return nil
+}
+
+func TestPhoneVerificationAndLogin(t *testing.T) {
+	sender := &smsStandIn{}
+	prev := backend.SMSer
+	backend.SMSer = sender
+	t.Cleanup(func() { backend.SMSer = prev })
+
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		email  = "phone-user@test.com"
+		number = "+15145550102"
+	)
+
+	jwt, user, err := backend.Membership(conf).CreateUser(testAccountID, email, userPassword, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = backend.DB.RemoveUser(model.Auth{AccountID: testAccountID, UserID: user.ID, Role: 100}, dbName, user.ID)
+	})
+	token := string(jwt)
+
+	resp := authReqWithToken(t, token, mship.phone, "POST", "/me/phone", model.PhoneCodeData{Phone: "not a number"})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an invalid number to be refused got %d", resp.StatusCode)
+	}
+
+	resp = authReqWithToken(t, token, mship.phone, "POST", "/me/phone", model.PhoneCodeData{Phone: number})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	verify := model.PhoneCode{Phone: number, Code: sender.code}
+	resp = authReqWithToken(t, token, mship.verifyPhone, "POST", "/me/phone/verify", verify)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = authReqWithToken(t, userToken, mship.phone, "POST", "/me/phone", model.PhoneCodeData{Phone: number})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected a number of another user to be refused got %d", resp.StatusCode)
+	}
+
+	resp = authReqWithToken(t, token, mship.phone, "GET", "/me/phone", nil)
+	defer func() { _ = resp.Body.Close() }()
+
+	var phone model.UserPhone
+	if err := parseBody(resp.Body, &phone); err != nil {
+		t.Fatal(err)
+	} else if phone.Number != number {
+		t.Errorf("expected phone %s got %v", number, phone)
+	}
+
+	// skips the resend delay of the number
+	_ = backend.Cache.Delete("phone-code-sent-" + conf.Name + "-" + number)
+
+	resp = dbReq(t, mship.phoneLogin, "POST", "/login/sms", model.PhoneCodeData{Phone: number})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, mship.phoneLogin, "POST", "/login/sms/verify", model.PhoneCode{Phone: number, Code: sender.code})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var session string
+	if err := parseBody(resp.Body, &session); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = authReqWithToken(t, session, mship.me, "GET", "/me", nil)
+	defer func() { _ = resp.Body.Close() }()
+
+	var me model.Auth
+	if err := parseBody(resp.Body, &me); err != nil {
+		t.Fatal(err)
+	} else if me.Email != email {
+		t.Errorf("expected to be signed in as %s got %v", email, me)
+	}
+
+	resp = authReqWithToken(t, token, mship.phone, "DELETE", "/me/phone", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = dbReq(t, mship.phoneLogin, "POST", "/login/sms/verify", model.PhoneCode{Phone: number, Code: sender.code})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a used code to be refused got %d", resp.StatusCode)
+	}
+}
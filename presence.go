@@ -0,0 +1,39 @@
+package staticbackend
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/realtime"
+)
+
+// presence lists the members present in the realtime channel of the URL,
+// i.e. GET /presence/{channel}
+func presence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, "invalid StaticBackend key", http.StatusUnauthorized)
+		return
+	}
+
+	channel := strings.TrimPrefix(r.URL.Path, "/presence/")
+	if len(channel) == 0 {
+		http.Error(w, "missing channel", http.StatusBadRequest)
+		return
+	}
+
+	members, err := realtime.ListPresence(backend.Cache, conf.Name, channel)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, http.StatusOK, members)
+}
@@ -0,0 +1,71 @@
+package staticbackend
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestPresenceEndpoint(t *testing.T) {
+	dialer := ws.Dialer{Header: ws.HandshakeHeaderHTTP(http.Header{"SB-PUBLIC-KEY": []string{pubKey}})}
+
+	conn, br, _, err := dialer.Dial(context.Background(), wsURL+"?token="+userToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	rw := io.ReadWriter(conn)
+	if br != nil {
+		rw = bufferedConn{Conn: conn, r: br}
+	}
+
+	read := func() model.Command {
+		t.Helper()
+
+		b, err := wsutil.ReadServerText(rw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var msg model.Command
+		if err := json.Unmarshal(b, &msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	init := read()
+
+	b, _ := json.Marshal(model.Command{Type: model.MsgTypeJoin, Data: "lobby"})
+	if err := wsutil.WriteClientText(conn, b); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		if msg := read(); msg.Type == model.MsgTypePresenceJoin {
+			break
+		}
+	}
+
+	resp := dbReq(t, presence, "GET", "/presence/lobby", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var members []model.PresenceMember
+	if err := parseBody(resp.Body, &members); err != nil {
+		t.Fatal(err)
+	} else if len(members) != 1 || members[0].ID != init.Data {
+		t.Errorf("expected the connection to be present got %v", members)
+	}
+}
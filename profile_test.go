@@ -0,0 +1,102 @@
+package staticbackend
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestProfileAndClaims(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy := model.ProfilePolicy{
+		Claims:   []string{"public.displayName", "private.plan"},
+		ReadOnly: []string{"private.plan"},
+	}
+	resp := dbReq(t, mship.sudoProfilePolicy, "POST", "/sudo/profilepolicy", policy, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+	t.Cleanup(func() { _ = backend.Membership(conf).SetProfilePolicy(model.ProfilePolicy{}) })
+
+	const email = "profile-user@test.com"
+	jwt, user, err := backend.Membership(conf).CreateUser(testAccountID, email, userPassword, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = backend.DB.RemoveUser(model.Auth{AccountID: testAccountID, UserID: user.ID, Role: 100}, dbName, user.ID)
+	})
+	token := string(jwt)
+
+	update := model.ProfileUpdate{
+		Public:  map[string]any{"displayName": "Ada"},
+		Private: map[string]any{"phone": "555-0100"},
+	}
+	resp = authReqWithToken(t, token, mship.profile, "POST", "/me/profile", update)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	update = model.ProfileUpdate{Private: map[string]any{"plan": "pro"}}
+	resp = authReqWithToken(t, token, mship.profile, "POST", "/me/profile", update)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected read-only fields to be refused got %d", resp.StatusCode)
+	}
+
+	resp = dbReq(t, mship.sudoProfile, "POST", "/sudo/userprofiles/"+user.ID, update, true)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = authReqWithToken(t, userToken, mship.publicProfile, "GET", "/profiles/"+user.ID, nil)
+	defer func() { _ = resp.Body.Close() }()
+
+	var public model.UserProfile
+	if err := parseBody(resp.Body, &public); err != nil {
+		t.Fatal(err)
+	} else if public.Public["displayName"] != "Ada" || len(public.Private) > 0 {
+		t.Errorf("expected only the public part got %v", public)
+	}
+
+	otherToken, other, err := backend.Membership(conf).CreateAccountAndUser("profile-other@test.com", userPassword, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = backend.DB.DeleteAccount(dbName, other.AccountID) })
+
+	resp = authReqWithToken(t, string(otherToken), mship.publicProfile, "GET", "/profiles/"+user.ID, nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected users of other accounts to be refused got %d", resp.StatusCode)
+	}
+
+	resp = dbReq(t, mship.login, "POST", "/login", model.Login{Email: email, Password: userPassword})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	if err := parseBody(resp.Body, &token); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = authReqWithToken(t, token, mship.me, "GET", "/me", nil)
+	defer func() { _ = resp.Body.Close() }()
+
+	var me model.Auth
+	if err := parseBody(resp.Body, &me); err != nil {
+		t.Fatal(err)
+	} else if me.Claims["displayName"] != "Ada" || me.Claims["plan"] != "pro" || len(me.Claims) != 2 {
+		t.Errorf("expected the displayName and plan claims got %v", me.Claims)
+	}
+}
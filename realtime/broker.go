@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -19,10 +20,22 @@ import (
 // Validator validates a session token
 type Validator func(context.Context, string) (string, error)
 
-// ConnectionData holds a channel for each web socket connection
+// ConnectionData holds a channel for each SSE and web socket connection
 type ConnectionData struct {
+	id       string
 	ctx      context.Context
 	messages chan model.Command
+	// token is the session token the connection authenticated with
+	token string
+	// lastEventID is the last event id received by the client before
+	// reconnecting
+	lastEventID string
+}
+
+// replay holds the missed messages of a connection joining a channel
+type replay struct {
+	id       string
+	messages []model.Command
 }
 
 // Broker is used to hold all web socket connections
@@ -33,7 +46,11 @@ type Broker struct {
 	clients            map[chan model.Command]string
 	ids                map[string]chan model.Command
 	conf               map[string]context.Context
+	tokens             map[string]string
 	subscriptions      map[string][]chan bool
+	presence           map[string][]presence
+	resume             map[string]string
+	replays            chan replay
 	validateAuth       Validator
 
 	pubsub cache.Volatilizer
@@ -41,6 +58,10 @@ type Broker struct {
 	shutdown chan struct{}
 	done     chan struct{}
 	once     sync.Once
+
+	// replaying tracks the replays in progress, the connections are closed
+	// once they stopped sending
+	replaying sync.WaitGroup
 }
 
 // NewBroker returns a ready to use Broker for accepting web socket connections
@@ -52,7 +73,11 @@ func NewBroker(v Validator, pubsub cache.Volatilizer) *Broker {
 		clients:            make(map[chan model.Command]string),
 		ids:                make(map[string]chan model.Command),
 		conf:               make(map[string]context.Context),
+		tokens:             make(map[string]string),
 		subscriptions:      make(map[string][]chan bool),
+		presence:           make(map[string][]presence),
+		resume:             make(map[string]string),
+		replays:            make(chan replay),
 		validateAuth:       v,
 		pubsub:             pubsub,
 		shutdown:           make(chan struct{}),
@@ -67,21 +92,25 @@ func NewBroker(v Validator, pubsub cache.Volatilizer) *Broker {
 func (b *Broker) start() {
 	defer close(b.done)
 
+	heartbeat := time.NewTicker(presenceHeartbeat)
+	defer heartbeat.Stop()
+
 	for {
 		select {
 		case data := <-b.newConnections:
-			id, err := uuid.NewUUID()
-			if err != nil {
-				slog.Error("error creating connection id", "error", err)
+			b.clients[data.messages] = data.id
+			b.ids[data.id] = data.messages
+			b.conf[data.id] = data.ctx
+			if len(data.token) > 0 {
+				b.tokens[data.id] = data.token
+			}
+			if len(data.lastEventID) > 0 {
+				b.resume[data.id] = data.lastEventID
 			}
-
-			b.clients[data.messages] = id.String()
-			b.ids[id.String()] = data.messages
-			b.conf[id.String()] = data.ctx
 
 			msg := model.Command{
 				Type: model.MsgTypeInit,
-				Data: id.String(),
+				Data: data.id,
 			}
 
 			data.messages <- msg
@@ -92,14 +121,24 @@ func (b *Broker) start() {
 			for _, c := range clients {
 				c <- payload
 			}
+		case r := <-b.replays:
+			// a slow client must not block the other connections
+			if c, ok := b.ids[r.id]; ok {
+				b.replaying.Add(1)
+				go b.sendReplay(b.conf[r.id], c, r.messages)
+			}
+		case <-heartbeat.C:
+			b.heartbeatPresence()
 		case <-b.shutdown:
+			b.replaying.Wait()
 			b.closeClients()
 			return
 		}
 	}
 }
 
-// Close stops the broker loop and closes active SSE connections.
+// Close stops the broker loop and closes active SSE and web socket
+// connections.
 func (b *Broker) Close(ctx context.Context) error {
 	b.once.Do(func() {
 		close(b.shutdown)
@@ -135,7 +174,46 @@ func (b *Broker) unsub(c chan model.Command) {
 		}
 	}
 
+	b.leavePresence(id)
+
 	delete(b.ids, id)
+	delete(b.conf, id)
+	delete(b.tokens, id)
+	delete(b.resume, id)
+	delete(b.subscriptions, id)
+}
+
+// connect registers a new connection with its own message channel. It
+// returns false when the broker is closed or the client went away.
+func (b *Broker) connect(ctx context.Context, token, lastEventID string) (ConnectionData, bool) {
+	id, err := uuid.NewUUID()
+	if err != nil {
+		slog.Error("error creating connection id", "error", err)
+	}
+
+	data := ConnectionData{
+		id:          id.String(),
+		ctx:         ctx,
+		messages:    make(chan model.Command),
+		token:       token,
+		lastEventID: lastEventID,
+	}
+
+	select {
+	case b.newConnections <- data:
+		return data, true
+	case <-b.done:
+	case <-ctx.Done():
+	}
+	return data, false
+}
+
+// disconnect removes a connection from the broker
+func (b *Broker) disconnect(messages chan model.Command) {
+	select {
+	case b.closingConnections <- messages:
+	case <-b.done:
+	}
 }
 
 // Accept turns a request into a web socket request and creates a new
@@ -155,27 +233,15 @@ func (b *Broker) Accept(w http.ResponseWriter, r *http.Request) {
 	//w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// each connection has their own message channel
-	messages := make(chan model.Command)
-	data := ConnectionData{
-		ctx:      r.Context(),
-		messages: messages,
-	}
-	select {
-	case b.newConnections <- data:
-	case <-b.done:
-		return
-	case <-r.Context().Done():
+	data, ok := b.connect(r.Context(), "", lastEventID(r))
+	if !ok {
 		return
 	}
+	messages := data.messages
 
 	// make sure we'r removing this connection
 	// when the handler completes.
-	defer func() {
-		select {
-		case b.closingConnections <- messages:
-		case <-b.done:
-		}
-	}()
+	defer b.disconnect(messages)
 
 	// handles the client-side disconnection
 	ctx := r.Context()
@@ -195,6 +261,14 @@ func (b *Broker) Accept(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 
+			// the browser sends the last id as Last-Event-ID when reconnecting
+			if len(msg.ID) > 0 {
+				if _, err := fmt.Fprintf(w, "id: %s\n", msg.ID); err != nil {
+					slog.Warn("error writing server sent event", "error", err)
+					continue
+				}
+			}
+
 			if _, err := fmt.Fprintf(w, "data: %s\n\n", bytes); err != nil {
 				slog.Warn("error writing server sent event", "error", err)
 				continue
@@ -203,15 +277,61 @@ func (b *Broker) Accept(w http.ResponseWriter, r *http.Request) {
 			// flush immediately.
 			flusher.Flush()
 		case <-ctx.Done():
-			select {
-			case b.closingConnections <- messages:
-			case <-b.done:
-			}
 			return
 		}
 	}
 }
 
+// sendReplay sends the missed messages unless the client went away or the
+// broker is closing
+func (b *Broker) sendReplay(ctx context.Context, c chan model.Command, msgs []model.Command) {
+	defer b.replaying.Done()
+
+	for _, msg := range msgs {
+		select {
+		case c <- msg:
+		case <-ctx.Done():
+			return
+		case <-b.shutdown:
+			return
+		}
+	}
+}
+
+// lastEventID returns the last event id received by a reconnecting client,
+// EventSource sends it as the Last-Event-ID header
+func lastEventID(r *http.Request) string {
+	if id := r.Header.Get("Last-Event-ID"); len(id) > 0 {
+		return id
+	}
+	return r.URL.Query().Get("lastEventId")
+}
+
+// replayHistory sends the messages of a channel published after the since
+// event id. Messages published while replaying may be received twice, clients
+// ignore the event ids they already received.
+func (b *Broker) replayHistory(sid, token, channel, since string) {
+	var auth model.Auth
+	var conf model.DatabaseConfig
+	if len(token) > 0 {
+		_ = b.pubsub.GetTyped(token, &auth)
+		_ = b.pubsub.GetTyped("base:"+token, &conf)
+	}
+
+	msgs, err := cache.History(b.pubsub, auth, conf.Name, channel, since)
+	if err != nil {
+		slog.Error("error getting channel history", "channel", channel, "error", err)
+		return
+	} else if len(msgs) == 0 {
+		return
+	}
+
+	select {
+	case b.replays <- replay{id: sid, messages: msgs}:
+	case <-b.done:
+	}
+}
+
 func (b *Broker) getTargets(msg model.Command) (sockets []chan model.Command, payload model.Command) {
 	var sender chan model.Command
 
@@ -225,6 +345,10 @@ func (b *Broker) getTargets(msg model.Command) (sockets []chan model.Command, pa
 		sockets = append(sockets, sender)
 	}
 
+	if tok, ok := b.tokens[msg.SID]; ok {
+		msg.Token = tok
+	}
+
 	switch msg.Type {
 	case model.MsgTypeEcho:
 		payload = msg
@@ -236,13 +360,30 @@ func (b *Broker) getTargets(msg model.Command) (sockets []chan model.Command, pa
 			return
 		}
 
-		if _, err := b.validateAuth(ctx, msg.Data); err != nil {
+		key, err := b.validateAuth(ctx, msg.Data)
+		if err != nil {
 			payload = model.Command{Type: model.MsgTypeError, Data: "invalid token"}
 			return
 		}
 
+		// the following commands of the connection use this token
+		b.tokens[msg.SID] = key
+
 		payload = model.Command{Type: model.MsgTypeToken, Data: msg.Data}
 	case model.MsgTypeJoin:
+		// a reconnecting client resumes from its last event id
+		since := msg.Since
+		if len(since) == 0 {
+			since = b.resume[msg.SID]
+		}
+
+		if len(since) > 0 {
+			if _, err := strconv.ParseInt(since, 10, 64); err != nil {
+				payload = model.Command{Type: model.MsgTypeError, Data: cache.ErrInvalidEventID.Error()}
+				return
+			}
+		}
+
 		subs, ok := b.subscriptions[msg.SID]
 		if !ok {
 			subs = make([]chan bool, 0)
@@ -255,28 +396,74 @@ func (b *Broker) getTargets(msg model.Command) (sockets []chan model.Command, pa
 
 		go b.pubsub.Subscribe(sender, msg.Token, msg.Data, closesub)
 
+		if len(since) > 0 {
+			go b.replayHistory(msg.SID, msg.Token, msg.Data, since)
+		}
+
 		joinedMsg := model.Command{
 			Type:    model.MsgTypeJoined,
 			Data:    msg.SID,
 			Channel: msg.Data,
 		}
+
+		// authenticated connections are members of the channel
+		p, joined := b.joinPresence(msg.SID, msg.Token, msg.Data)
+
 		// make sure the subscription had time to kick-off
 		go func(m model.Command) {
 			time.Sleep(250 * time.Millisecond)
 			if err := b.pubsub.Publish(joinedMsg); err != nil {
 				slog.Error("error publishing joined message", "error", err)
 			}
+
+			if !joined {
+				return
+			}
+
+			if err := savePresence(b.pubsub, p); err != nil {
+				slog.Error("error saving presence", "channel", p.channel, "error", err)
+				return
+			}
+			publishPresence(b.pubsub, model.MsgTypePresenceJoin, p.dbName, p.channel, p.member)
 		}(joinedMsg)
 
 		payload = model.Command{Type: model.MsgTypeOk, Data: msg.Data}
 	case model.MsgTypePresence:
-		v, err := b.pubsub.Get(msg.Data)
+		var conf model.DatabaseConfig
+		if err := b.pubsub.GetTyped("base:"+msg.Token, &conf); err != nil || len(msg.Token) == 0 {
+			payload = model.Command{Type: model.MsgTypeError, Data: ErrPresenceAuth.Error()}
+			return
+		}
+
+		members, err := ListPresence(b.pubsub, conf.Name, msg.Data)
 		if err != nil {
-			//TODO: Make sure it's because the channel key does not exists
-			v = "0"
+			payload = model.Command{Type: model.MsgTypeError, Data: err.Error()}
+			return
 		}
 
-		payload = model.Command{Type: model.MsgTypePresence, Data: v}
+		v, err := json.Marshal(members)
+		if err != nil {
+			payload = model.Command{Type: model.MsgTypeError, Data: err.Error()}
+			return
+		}
+
+		payload = model.Command{Type: model.MsgTypePresence, Channel: msg.Data, Data: string(v)}
+	case model.MsgTypePresenceSet:
+		p, err := b.setPresenceState(msg.SID, msg.Channel, msg.Data)
+		if err != nil {
+			payload = model.Command{Type: model.MsgTypeError, Data: err.Error()}
+			return
+		}
+
+		go func() {
+			if err := savePresence(b.pubsub, p); err != nil {
+				slog.Error("error saving presence", "channel", p.channel, "error", err)
+				return
+			}
+			publishPresence(b.pubsub, model.MsgTypePresenceUpdate, p.dbName, p.channel, p.member)
+		}()
+
+		payload = model.Command{Type: model.MsgTypeOk, Channel: msg.Channel}
 	case model.MsgTypeChanIn:
 		if len(msg.Channel) == 0 {
 			payload = model.Command{Type: model.MsgTypeError, Data: "no channel was specified"}
@@ -289,6 +476,14 @@ func (b *Broker) getTargets(msg model.Command) (sockets []chan model.Command, pa
 			return
 		}
 
+		// the history of the channel is kept per database, the base sent by
+		// the client is not trusted
+		msg.Base = ""
+		var conf model.DatabaseConfig
+		if len(msg.Token) > 0 && b.pubsub.GetTyped("base:"+msg.Token, &conf) == nil {
+			msg.Base = conf.Name
+		}
+
 		go func() {
 			if err := b.pubsub.Publish(msg); err != nil {
 				slog.Error("error publishing channel message", "error", err)
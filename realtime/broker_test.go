@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/model"
 )
 
 func TestBrokerCloseIsIdempotent(t *testing.T) {
@@ -23,3 +24,42 @@ func TestBrokerCloseIsIdempotent(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestBrokerReplayDoesNotBlock(t *testing.T) {
+	b := NewBroker(func(context.Context, string) (string, error) {
+		return "", nil
+	}, cache.NewDevCache())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	slow, ok := b.connect(ctx, "", "")
+	if !ok {
+		t.Fatal("expected the connection to be accepted")
+	}
+	<-slow.messages
+
+	// the slow client never reads its replay
+	msgs := []model.Command{{Type: model.MsgTypeChanOut, Data: "1"}, {Type: model.MsgTypeChanOut, Data: "2"}}
+	b.replays <- replay{id: slow.id, messages: msgs}
+
+	other, ok := b.connect(ctx, "", "")
+	if !ok {
+		t.Fatal("expected the connection to be accepted")
+	}
+
+	select {
+	case msg := <-other.messages:
+		if msg.Type != model.MsgTypeInit {
+			t.Errorf("expected the init command got %v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the broker is blocked by the replay")
+	}
+
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), time.Second)
+	defer closeCancel()
+	if err := b.Close(closeCtx); err != nil {
+		t.Fatal(err)
+	}
+}
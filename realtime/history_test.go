@@ -0,0 +1,164 @@
+package realtime
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/model"
+)
+
+// publishHistory publishes chat messages and returns their event ids
+func publishHistory(t *testing.T, pubsub cache.Volatilizer, n int) []string {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		msg := model.Command{Type: model.MsgTypeChanIn, Channel: "chat", Data: strconv.Itoa(i)}
+		if err := pubsub.Publish(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msgs, err := cache.History(pubsub, model.Auth{}, "", "chat", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, msg := range msgs {
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+func TestWebSocketJoinSince(t *testing.T) {
+	b, url := newWebSocketServer(t)
+	ids := publishHistory(t, b.pubsub, 3)
+
+	conn := dialWebSocket(t, url)
+	readCommand(t, conn)
+
+	sendCommand(t, conn, model.Command{Type: model.MsgTypeJoin, Data: "chat", Since: "abc"})
+	if msg := readCommand(t, conn); msg.Type != model.MsgTypeError {
+		t.Errorf("expected an error for an invalid event id got %v", msg)
+	}
+
+	sendCommand(t, conn, model.Command{Type: model.MsgTypeJoin, Data: "chat", Since: ids[0]})
+	if msg := readCommand(t, conn); msg.Type != model.MsgTypeOk {
+		t.Fatalf("expected the join to be accepted got %v", msg)
+	}
+
+	for i, id := range ids[1:] {
+		msg := waitCommand(t, conn, model.MsgTypeChanOut)
+		if msg.ID != id || msg.Data != strconv.Itoa(i+1) {
+			t.Errorf("expected message %s to be replayed got %v", id, msg)
+		}
+	}
+}
+
+func TestSSELastEventID(t *testing.T) {
+	b, _ := newWebSocketServer(t)
+	ids := publishHistory(t, b.pubsub, 3)
+
+	srv := httptest.NewServer(http.HandlerFunc(b.Accept))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", ids[1])
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	rd := bufio.NewReader(resp.Body)
+
+	// next returns the id and the command of the next event
+	next := func() (string, model.Command) {
+		t.Helper()
+
+		var id string
+		for {
+			line, err := rd.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "id: ") {
+				id = strings.TrimPrefix(line, "id: ")
+			} else if strings.HasPrefix(line, "data: ") {
+				var msg model.Command
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &msg); err != nil {
+					t.Fatal(err)
+				}
+				return id, msg
+			}
+		}
+	}
+
+	_, init := next()
+	if init.Type != model.MsgTypeInit {
+		t.Fatalf("expected the init command got %v", init)
+	}
+
+	b.Broadcast <- model.Command{SID: init.Data, Type: model.MsgTypeJoin, Data: "chat"}
+
+	for {
+		id, msg := next()
+		if msg.Type != model.MsgTypeChanOut {
+			continue
+		}
+
+		if id != ids[2] || msg.ID != ids[2] || msg.Data != "2" {
+			t.Errorf("expected only the last message to be replayed got %s %v", id, msg)
+		}
+		break
+	}
+}
+
+func TestChanInHistoryDatabase(t *testing.T) {
+	pubsub := cache.NewDevCache()
+	setPresenceToken(t, pubsub, "token-a", "user-a")
+
+	url := newPresenceServer(t, pubsub)
+	conn := dialWebSocket(t, url+"?token=token-a")
+	readCommand(t, conn)
+
+	// the base of the sender's token is used, not the one sent
+	sendCommand(t, conn, model.Command{Type: model.MsgTypeChanIn, Channel: "chat", Data: "hello", Base: "otherdb"})
+	waitCommand(t, conn, model.MsgTypeOk)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		msgs, err := cache.History(pubsub, model.Auth{}, "presencedb", "chat", "")
+		if err != nil {
+			t.Fatal(err)
+		} else if len(msgs) == 1 && msgs[0].Data == "hello" {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("expected the message in the history of the database got %v", msgs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if msgs, err := cache.History(pubsub, model.Auth{}, "otherdb", "chat", ""); err != nil {
+		t.Fatal(err)
+	} else if len(msgs) != 0 {
+		t.Errorf("expected no message in the history of another database got %v", msgs)
+	}
+}
@@ -0,0 +1,218 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/model"
+)
+
+var (
+	// presenceHeartbeat is how often the broker refreshes the presence of
+	// its connections
+	presenceHeartbeat = 20 * time.Second
+
+	// presenceTimeout is how long a member stays present without a
+	// heartbeat, i.e. when the instance holding its connection died
+	presenceTimeout = 60 * time.Second
+)
+
+var (
+	ErrPresenceAuth      = errors.New("presence requires an authenticated connection")
+	ErrPresenceNotJoined = errors.New("you have not joined this channel")
+	ErrPresenceState     = errors.New("presence state must be JSON")
+)
+
+// presence is a channel joined by an authenticated connection
+type presence struct {
+	dbName  string
+	channel string
+	member  model.PresenceMember
+}
+
+func presenceKey(dbName, channel string) string {
+	return "presence-" + dbName + "-" + channel
+}
+
+// ListPresence returns the members present in a channel of a database,
+// oldest first. Members without a recent heartbeat are removed and their
+// leave event is published.
+func ListPresence(pubsub cache.Volatilizer, dbName, channel string) ([]model.PresenceMember, error) {
+	fields, err := pubsub.GetFields(presenceKey(dbName, channel))
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]model.PresenceMember, 0, len(fields))
+	for id, v := range fields {
+		var m model.PresenceMember
+		if err := json.Unmarshal([]byte(v), &m); err != nil {
+			slog.Warn("invalid presence member", "channel", channel, "error", err)
+			continue
+		}
+
+		if time.Since(m.LastSeen) > presenceTimeout {
+			if err := pubsub.DeleteField(presenceKey(dbName, channel), id); err != nil {
+				return nil, err
+			}
+			publishPresence(pubsub, model.MsgTypePresenceLeave, dbName, channel, m)
+			continue
+		}
+
+		members = append(members, m)
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Joined.Before(members[j].Joined)
+	})
+	return members, nil
+}
+
+// savePresence saves a member of a channel
+func savePresence(pubsub cache.Volatilizer, p presence) error {
+	b, err := json.Marshal(p.member)
+	if err != nil {
+		return err
+	}
+	return pubsub.SetField(presenceKey(p.dbName, p.channel), p.member.ID, string(b))
+}
+
+// publishPresence sends a presence event to the members of a channel
+func publishPresence(pubsub cache.Volatilizer, typ, dbName, channel string, m model.PresenceMember) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		slog.Error("error converting presence to JSON", "error", err)
+		return
+	}
+
+	msg := model.Command{
+		Type:    typ,
+		Channel: channel,
+		Data:    string(b),
+		Base:    dbName,
+	}
+	if err := pubsub.Publish(msg); err != nil {
+		slog.Error("error publishing presence event", "channel", channel, "error", err)
+	}
+}
+
+// joinPresence adds the connection of an authenticated user to the members
+// of a channel, it returns false for anonymous connections and channels the
+// connection already joined
+func (b *Broker) joinPresence(sid, token, channel string) (presence, bool) {
+	if len(token) == 0 {
+		return presence{}, false
+	}
+
+	var auth model.Auth
+	if err := b.pubsub.GetTyped(token, &auth); err != nil {
+		return presence{}, false
+	}
+
+	var conf model.DatabaseConfig
+	if err := b.pubsub.GetTyped("base:"+token, &conf); err != nil {
+		return presence{}, false
+	}
+
+	for _, p := range b.presence[sid] {
+		if p.channel == channel {
+			return p, false
+		}
+	}
+
+	now := time.Now()
+	p := presence{
+		dbName:  conf.Name,
+		channel: channel,
+		member: model.PresenceMember{
+			ID:        sid,
+			UserID:    auth.UserID,
+			AccountID: auth.AccountID,
+			Joined:    now,
+			LastSeen:  now,
+		},
+	}
+
+	b.presence[sid] = append(b.presence[sid], p)
+	return p, true
+}
+
+// setPresenceState changes the custom state of a connection in a channel
+func (b *Broker) setPresenceState(sid, channel, state string) (presence, error) {
+	if len(state) > 0 && !json.Valid([]byte(state)) {
+		return presence{}, ErrPresenceState
+	}
+
+	for i, p := range b.presence[sid] {
+		if p.channel != channel {
+			continue
+		}
+
+		p.member.State = nil
+		if len(state) > 0 {
+			p.member.State = json.RawMessage(state)
+		}
+		p.member.LastSeen = time.Now()
+
+		b.presence[sid][i] = p
+		return p, nil
+	}
+	return presence{}, ErrPresenceNotJoined
+}
+
+// leavePresence removes the connection from the members of its channels
+func (b *Broker) leavePresence(sid string) {
+	list, ok := b.presence[sid]
+	if !ok {
+		return
+	}
+	delete(b.presence, sid)
+
+	go func() {
+		for _, p := range list {
+			if err := b.pubsub.DeleteField(presenceKey(p.dbName, p.channel), sid); err != nil {
+				slog.Error("error removing presence", "channel", p.channel, "error", err)
+				continue
+			}
+			publishPresence(b.pubsub, model.MsgTypePresenceLeave, p.dbName, p.channel, p.member)
+		}
+	}()
+}
+
+// heartbeatPresence refreshes the presence of the connections and removes
+// the members of the channels without a recent heartbeat
+func (b *Broker) heartbeatPresence() {
+	now := time.Now()
+
+	var list []presence
+	for sid := range b.presence {
+		for i := range b.presence[sid] {
+			b.presence[sid][i].member.LastSeen = now
+			list = append(list, b.presence[sid][i])
+		}
+	}
+
+	if len(list) == 0 {
+		return
+	}
+
+	go func() {
+		channels := make(map[[2]string]bool)
+		for _, p := range list {
+			if err := savePresence(b.pubsub, p); err != nil {
+				slog.Error("error refreshing presence", "channel", p.channel, "error", err)
+			}
+			channels[[2]string{p.dbName, p.channel}] = true
+		}
+
+		for c := range channels {
+			if _, err := ListPresence(b.pubsub, c[0], c[1]); err != nil {
+				slog.Error("error expiring presence", "channel", c[1], "error", err)
+			}
+		}
+	}()
+}
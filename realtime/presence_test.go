@@ -0,0 +1,192 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws/wsutil"
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/model"
+)
+
+// newPresenceServer starts a broker instance sharing the cache of the
+// other instances, tokens are accepted when they are in the cache
+func newPresenceServer(t *testing.T, pubsub cache.Volatilizer) string {
+	t.Helper()
+
+	b := NewBroker(func(_ context.Context, key string) (string, error) {
+		var auth model.Auth
+		if err := pubsub.GetTyped(key, &auth); err != nil {
+			return "", err
+		}
+		return key, nil
+	}, pubsub)
+
+	srv := httptest.NewServer(http.HandlerFunc(b.AcceptWebSocket))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = b.Close(ctx)
+		srv.Close()
+	})
+
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func setPresenceToken(t *testing.T, pubsub cache.Volatilizer, token, userID string) {
+	t.Helper()
+
+	auth := model.Auth{AccountID: "acct-" + userID, UserID: userID}
+	if err := pubsub.SetTyped(token, auth); err != nil {
+		t.Fatal(err)
+	}
+	if err := pubsub.SetTyped("base:"+token, model.DatabaseConfig{Name: "presencedb"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sendCommand(t *testing.T, conn net.Conn, msg model.Command) {
+	t.Helper()
+
+	b, _ := json.Marshal(msg)
+	if err := wsutil.WriteClientText(conn, b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitCommand reads the commands until one of the type is received
+func waitCommand(t *testing.T, conn net.Conn, typ string) model.Command {
+	t.Helper()
+
+	for {
+		if msg := readCommand(t, conn); msg.Type == typ {
+			return msg
+		}
+	}
+}
+
+func TestPresenceAcrossInstances(t *testing.T) {
+	pubsub := cache.NewDevCache()
+	setPresenceToken(t, pubsub, "token-a", "user-a")
+	setPresenceToken(t, pubsub, "token-b", "user-b")
+
+	url1 := newPresenceServer(t, pubsub)
+	url2 := newPresenceServer(t, pubsub)
+
+	connA := dialWebSocket(t, url1+"?token=token-a")
+	readCommand(t, connA)
+	connB := dialWebSocket(t, url2+"?token=token-b")
+	readCommand(t, connB)
+
+	sendCommand(t, connB, model.Command{Type: model.MsgTypeJoin, Data: "room"})
+	waitCommand(t, connB, model.MsgTypeOk)
+	waitCommand(t, connB, model.MsgTypePresenceJoin)
+
+	sendCommand(t, connA, model.Command{Type: model.MsgTypeJoin, Data: "room"})
+
+	join := waitCommand(t, connB, model.MsgTypePresenceJoin)
+	var m model.PresenceMember
+	if err := json.Unmarshal([]byte(join.Data), &m); err != nil {
+		t.Fatal(err)
+	} else if m.UserID != "user-a" || join.Channel != "room" {
+		t.Errorf("expected user-a joining room got %v", join)
+	}
+
+	sendCommand(t, connA, model.Command{Type: model.MsgTypePresenceSet, Channel: "room", Data: `{"typing":true}`})
+
+	update := waitCommand(t, connB, model.MsgTypePresenceUpdate)
+	if err := json.Unmarshal([]byte(update.Data), &m); err != nil {
+		t.Fatal(err)
+	} else if string(m.State) != `{"typing":true}` {
+		t.Errorf("expected the typing state got %s", m.State)
+	}
+
+	sendCommand(t, connB, model.Command{Type: model.MsgTypePresence, Data: "room"})
+
+	list := waitCommand(t, connB, model.MsgTypePresence)
+	var members []model.PresenceMember
+	if err := json.Unmarshal([]byte(list.Data), &members); err != nil {
+		t.Fatal(err)
+	} else if len(members) != 2 {
+		t.Fatalf("expected 2 members got %d", len(members))
+	} else if members[0].UserID != "user-b" || members[1].UserID != "user-a" {
+		t.Errorf("expected members in join order got %v", members)
+	}
+
+	_ = connA.Close()
+
+	leave := waitCommand(t, connB, model.MsgTypePresenceLeave)
+	if err := json.Unmarshal([]byte(leave.Data), &m); err != nil {
+		t.Fatal(err)
+	} else if m.UserID != "user-a" {
+		t.Errorf("expected user-a to leave got %v", m)
+	}
+
+	members, err := ListPresence(pubsub, "presencedb", "room")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(members) != 1 || members[0].UserID != "user-b" {
+		t.Errorf("expected only user-b present got %v", members)
+	}
+}
+
+func TestPresenceRequiresAuth(t *testing.T) {
+	_, url := newWebSocketServer(t)
+
+	conn := dialWebSocket(t, url)
+	readCommand(t, conn)
+
+	sendCommand(t, conn, model.Command{Type: model.MsgTypePresence, Data: "room"})
+	if msg := readCommand(t, conn); msg.Type != model.MsgTypeError {
+		t.Errorf("expected an error for an anonymous connection got %v", msg)
+	}
+
+	sendCommand(t, conn, model.Command{Type: model.MsgTypePresenceSet, Channel: "room", Data: "{}"})
+	if msg := readCommand(t, conn); msg.Type != model.MsgTypeError || msg.Data != ErrPresenceNotJoined.Error() {
+		t.Errorf("expected the not joined error got %v", msg)
+	}
+}
+
+func TestPresenceExpiry(t *testing.T) {
+	pubsub := cache.NewDevCache()
+
+	stale := model.PresenceMember{
+		ID:       "dead-connection",
+		UserID:   "user-a",
+		Joined:   time.Now().Add(-2 * presenceTimeout),
+		LastSeen: time.Now().Add(-2 * presenceTimeout),
+	}
+	alive := model.PresenceMember{
+		ID:       "live-connection",
+		UserID:   "user-b",
+		Joined:   time.Now(),
+		LastSeen: time.Now(),
+	}
+
+	for _, m := range []model.PresenceMember{stale, alive} {
+		p := presence{dbName: "presencedb", channel: "room", member: m}
+		if err := savePresence(pubsub, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	members, err := ListPresence(pubsub, "presencedb", "room")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(members) != 1 || members[0].ID != alive.ID {
+		t.Errorf("expected only the live member got %v", members)
+	}
+
+	fields, err := pubsub.GetFields(presenceKey("presencedb", "room"))
+	if err != nil {
+		t.Fatal(err)
+	} else if _, ok := fields[stale.ID]; ok {
+		t.Error("expected the stale member to be removed from the cache")
+	}
+}
@@ -0,0 +1,245 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/staticbackendhq/core/model"
+)
+
+const (
+	// time allowed to write a message to the client
+	wsWriteWait = 10 * time.Second
+
+	// time allowed to read the next message or pong from the client
+	wsPongWait = 60 * time.Second
+
+	// pings are sent with this period, must be less than wsPongWait
+	wsPingPeriod = (wsPongWait * 9) / 10
+
+	// maximum command size accepted from the client
+	wsMaxMessageSize = 64 << 10
+)
+
+// wsConn is a web socket connection, writes are serialized since pongs are
+// written by the reader while messages and pings are written by the writer.
+type wsConn struct {
+	conn    net.Conn
+	rd      *wsutil.Reader
+	control wsutil.ControlHandler
+	mu      sync.Mutex
+}
+
+func newWSConn(conn net.Conn) *wsConn {
+	c := &wsConn{conn: conn}
+	c.rd = &wsutil.Reader{
+		Source:       conn,
+		State:        ws.StateServerSide,
+		CheckUTF8:    true,
+		MaxFrameSize: wsMaxMessageSize,
+	}
+	c.control = wsutil.ControlHandler{
+		Src:                 c.rd,
+		Dst:                 c,
+		State:               ws.StateServerSide,
+		DisableSrcCiphering: true,
+	}
+	c.rd.OnIntermediate = func(h ws.Header, _ io.Reader) error {
+		return c.control.Handle(h)
+	}
+	return c
+}
+
+// Write writes raw frame bytes, it is used by the control frames handler
+func (c *wsConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait)); err != nil {
+		return 0, err
+	}
+	return c.conn.Write(p)
+}
+
+func (c *wsConn) writeFrame(f ws.Frame) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait)); err != nil {
+		return err
+	}
+	return ws.WriteFrame(c.conn, f)
+}
+
+// read returns the next command of the client, the control frames are
+// handled while waiting for it
+func (c *wsConn) read() (msg model.Command, err error) {
+	for {
+		if err = c.conn.SetReadDeadline(time.Now().Add(wsPongWait)); err != nil {
+			return
+		}
+
+		var hdr ws.Header
+		hdr, err = c.rd.NextFrame()
+		if err != nil {
+			return
+		}
+
+		if hdr.OpCode.IsControl() {
+			if err = c.control.Handle(hdr); err != nil {
+				return
+			}
+			continue
+		}
+
+		if hdr.OpCode&ws.OpText == 0 {
+			if err = c.rd.Discard(); err != nil {
+				return
+			}
+			continue
+		}
+
+		var b []byte
+		b, err = io.ReadAll(io.LimitReader(c.rd, wsMaxMessageSize+1))
+		if err != nil {
+			return
+		} else if len(b) > wsMaxMessageSize {
+			err = wsutil.ErrFrameTooLarge
+			return
+		}
+
+		if err = json.Unmarshal(b, &msg); err != nil {
+			slog.Warn("invalid web socket command", "error", err)
+			continue
+		}
+		return
+	}
+}
+
+// AcceptWebSocket upgrades the request to a web socket connection handled
+// like the SSE connections of the Broker. Clients send their commands as
+// JSON text messages on the connection instead of POSTing them.
+//
+// A session token in the token query parameter or the Authorization header
+// authenticates the connection when it opens, otherwise clients send an
+// auth command like with SSE.
+//
+// Like with SSE, the lastEventId query parameter replays the messages missed
+// before reconnecting when joining channels.
+func (b *Broker) AcceptWebSocket(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if len(token) == 0 {
+		token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+
+	if len(token) > 0 {
+		key, err := b.validateAuth(r.Context(), token)
+		if err != nil {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		token = key
+	}
+
+	conn, _, _, err := ws.UpgradeHTTP(r, w)
+	if err != nil {
+		slog.Warn("error upgrading web socket connection", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	data, ok := b.connect(r.Context(), token, lastEventID(r))
+	if !ok {
+		return
+	}
+
+	c := newWSConn(conn)
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		b.writeWebSocket(c, data.messages, stop)
+	}()
+
+	for {
+		msg, err := c.read()
+		if err != nil {
+			var closed wsutil.ClosedError
+			if !errors.As(err, &closed) && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				slog.Info("web socket connection closed", "error", err)
+			}
+			break
+		}
+
+		// commands always come from this connection
+		msg.SID = data.id
+
+		select {
+		case b.Broadcast <- msg:
+		case <-b.done:
+		}
+	}
+
+	// the writer drains the messages until the broker removed the
+	// connection
+	b.disconnect(data.messages)
+	close(stop)
+	<-stopped
+}
+
+// writeWebSocket writes the messages of a connection and pings the client
+// until the broker closes the messages channel or the reader stops.
+func (b *Broker) writeWebSocket(c *wsConn, messages chan model.Command, stop chan struct{}) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	failed := false
+
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				// the broker is shutting down
+				body := ws.NewCloseFrameBody(ws.StatusGoingAway, "server shutting down")
+				_ = c.writeFrame(ws.NewCloseFrame(body))
+				_ = c.conn.Close()
+				return
+			} else if failed {
+				continue
+			}
+
+			bytes, err := json.Marshal(msg)
+			if err != nil {
+				slog.Warn("error converting to JSON", "error", err)
+				continue
+			}
+
+			if err := c.writeFrame(ws.NewTextFrame(bytes)); err != nil {
+				// the reader stops on the closed connection
+				failed = true
+				_ = c.conn.Close()
+			}
+		case <-ticker.C:
+			if failed {
+				continue
+			}
+
+			if err := c.writeFrame(ws.NewPingFrame(nil)); err != nil {
+				failed = true
+				_ = c.conn.Close()
+			}
+		case <-stop:
+			return
+		}
+	}
+}
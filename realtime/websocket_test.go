@@ -0,0 +1,169 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/staticbackendhq/core/cache"
+	"github.com/staticbackendhq/core/model"
+)
+
+func newWebSocketServer(t *testing.T) (*Broker, string) {
+	t.Helper()
+
+	b := NewBroker(func(_ context.Context, key string) (string, error) {
+		if key != "valid-token" {
+			return "", errors.New("invalid token")
+		}
+		return key, nil
+	}, cache.NewDevCache())
+
+	srv := httptest.NewServer(http.HandlerFunc(b.AcceptWebSocket))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = b.Close(ctx)
+		srv.Close()
+	})
+
+	return b, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// bufferedConn reads the frames the dialer buffered with the handshake
+type bufferedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func dialWebSocket(t *testing.T, url string) net.Conn {
+	t.Helper()
+
+	conn, br, _, err := ws.Dial(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if br != nil {
+		return bufferedConn{Conn: conn, r: br}
+	}
+	return conn
+}
+
+func readCommand(t *testing.T, conn net.Conn) model.Command {
+	t.Helper()
+
+	b, err := wsutil.ReadServerText(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msg model.Command
+	if err := json.Unmarshal(b, &msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestWebSocketCommands(t *testing.T) {
+	_, url := newWebSocketServer(t)
+
+	conn := dialWebSocket(t, url)
+
+	init := readCommand(t, conn)
+	if init.Type != model.MsgTypeInit || len(init.Data) == 0 {
+		t.Fatalf("expected the init command with the connection id got %v", init)
+	}
+
+	// the connection id is set by the server
+	b, _ := json.Marshal(model.Command{SID: "someone-else", Type: model.MsgTypeEcho, Data: "hello"})
+	if err := wsutil.WriteClientText(conn, b); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg := readCommand(t, conn); msg.Type != model.MsgTypeEcho || msg.Data != "echo: hello" {
+		t.Errorf("expected the echo reply got %v", msg)
+	}
+
+	b, _ = json.Marshal(model.Command{Type: model.MsgTypeAuth, Data: "invalid"})
+	if err := wsutil.WriteClientText(conn, b); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg := readCommand(t, conn); msg.Type != model.MsgTypeError {
+		t.Errorf("expected an error for an invalid token got %v", msg)
+	}
+}
+
+func TestWebSocketConnectionAuth(t *testing.T) {
+	_, url := newWebSocketServer(t)
+
+	if _, _, _, err := ws.Dial(context.Background(), url+"?token=invalid"); err == nil {
+		t.Fatal("expected the upgrade to be refused with an invalid token")
+	} else if se, ok := err.(ws.StatusError); !ok || int(se) != http.StatusUnauthorized {
+		t.Errorf("expected a 401 status got %v", err)
+	}
+
+	conn := dialWebSocket(t, url+"?token=valid-token")
+	if msg := readCommand(t, conn); msg.Type != model.MsgTypeInit {
+		t.Errorf("expected the init command got %v", msg)
+	}
+}
+
+func TestWebSocketPingPong(t *testing.T) {
+	_, url := newWebSocketServer(t)
+
+	conn := dialWebSocket(t, url)
+	readCommand(t, conn)
+
+	if err := ws.WriteFrame(conn, ws.MaskFrame(ws.NewPingFrame([]byte("keepalive")))); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ws.ReadFrame(conn)
+	if err != nil {
+		t.Fatal(err)
+	} else if f.Header.OpCode != ws.OpPong || string(f.Payload) != "keepalive" {
+		t.Errorf("expected a pong with the ping payload got %v %s", f.Header.OpCode, f.Payload)
+	}
+}
+
+func TestWebSocketBrokerClose(t *testing.T) {
+	b, url := newWebSocketServer(t)
+
+	conn := dialWebSocket(t, url)
+	readCommand(t, conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := b.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ws.ReadFrame(conn)
+	if err != nil {
+		t.Fatal(err)
+	} else if f.Header.OpCode != ws.OpClose {
+		t.Fatalf("expected a close frame got %v", f.Header.OpCode)
+	}
+
+	if code, _ := ws.ParseCloseFrameData(f.Payload); code != ws.StatusGoingAway {
+		t.Errorf("expected the going away status got %d", code)
+	}
+}
@@ -0,0 +1,87 @@
+package staticbackend
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestWebSocketDatabaseEvents(t *testing.T) {
+	dialer := ws.Dialer{Header: ws.HandshakeHeaderHTTP(http.Header{"SB-PUBLIC-KEY": []string{pubKey}})}
+
+	conn, br, _, err := dialer.Dial(context.Background(), wsURL+"?token="+userToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// the dialer may have buffered the first frames with the handshake
+	rw := io.ReadWriter(conn)
+	if br != nil {
+		rw = bufferedConn{Conn: conn, r: br}
+	}
+
+	read := func() model.Command {
+		t.Helper()
+
+		b, err := wsutil.ReadServerText(rw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var msg model.Command
+		if err := json.Unmarshal(b, &msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	if msg := read(); msg.Type != model.MsgTypeInit {
+		t.Fatalf("expected the init command got %v", msg)
+	}
+
+	// the connection is authenticated, the join command does not need the token
+	b, _ := json.Marshal(model.Command{Type: model.MsgTypeJoin, Data: "db-ws_tasks"})
+	if err := wsutil.WriteClientText(conn, b); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg := read(); msg.Type != model.MsgTypeOk {
+		t.Fatalf("expected the join to be accepted got %v", msg)
+	}
+
+	// let the subscription start
+	time.Sleep(300 * time.Millisecond)
+
+	resp := authReqWithToken(t, userToken, db.add, "POST", "/db/ws_tasks", map[string]any{"title": "realtime"})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	for {
+		msg := read()
+		if msg.Type == model.MsgTypeDBCreated {
+			break
+		}
+	}
+}
+
+// bufferedConn reads the frames the dialer buffered with the handshake
+type bufferedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
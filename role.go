@@ -0,0 +1,51 @@
+package staticbackend
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+// sudoRoles returns the custom roles of the database with GET and replaces
+// them with POST. Users are assigned a role with /setrole.
+func (m *membership) sudoRoles(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mship := backend.Membership(conf)
+
+	switch r.Method {
+	case http.MethodGet:
+		roles, err := mship.GetCustomRoles()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, roles)
+	case http.MethodPost:
+		var roles model.CustomRoles
+		if err := parseBody(r.Body, &roles); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := mship.SetCustomRoles(roles); errors.Is(err, model.ErrInvalidRole) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		respond(w, http.StatusOK, roles)
+	default:
+		http.Error(w, "method not implemented", http.StatusNotImplemented)
+	}
+}
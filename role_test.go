@@ -0,0 +1,147 @@
+package staticbackend
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/middleware"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestCustomRoles(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roles := model.CustomRoles{
+		{
+			Name:  "editor",
+			Level: 10,
+			Capabilities: model.RoleCapabilities{
+				Collections: []model.CollectionScope{{Name: "roletasks", Read: true, Write: true}},
+				Functions:   []string{"publish"},
+			},
+		},
+		{Name: "billing-admin", Level: 10, Capabilities: model.RoleCapabilities{ManageUsers: true}},
+	}
+
+	resp := dbReq(t, mship.sudoRoles, "POST", "/sudo/roles", model.CustomRoles{{Name: "Bad Name"}})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an invalid role name to be refused got %d", resp.StatusCode)
+	}
+
+	resp = dbReq(t, mship.sudoRoles, "POST", "/sudo/roles", roles)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+	t.Cleanup(func() { _ = backend.Membership(conf).SetCustomRoles(nil) })
+
+	jwt, user, err := backend.Membership(conf).CreateUser(testAccountID, "custom-role-editor@test.com", userPassword, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = backend.DB.RemoveUser(model.Auth{AccountID: testAccountID, UserID: user.ID, Role: 100}, dbName, user.ID)
+	})
+	token := string(jwt)
+
+	setRole := func(name string) {
+		t.Helper()
+
+		resp := authReqWithToken(t, string(adminToken), mship.setRole, "POST", "/setrole", map[string]any{
+			"accountId": testAccountID,
+			"email":     user.Email,
+			"roleName":  name,
+		})
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatal(GetResponseBody(t, resp))
+		}
+	}
+
+	resp = authReqWithToken(t, string(adminToken), mship.setRole, "POST", "/setrole", map[string]any{
+		"accountId": testAccountID,
+		"email":     user.Email,
+		"roleName":  "unknown",
+	})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an unknown role to be refused got %d", resp.StatusCode)
+	}
+
+	setRole("editor")
+
+	resp = authReqWithToken(t, token, mship.me, "GET", "/me", nil)
+	defer func() { _ = resp.Body.Close() }()
+
+	var me model.Auth
+	if err := parseBody(resp.Body, &me); err != nil {
+		t.Fatal(err)
+	} else if me.RoleName != "editor" || me.Role != 10 {
+		t.Errorf("expected the editor role with level 10 got %v", me)
+	}
+
+	colScope := middleware.RouteScope{Scope: model.APIKeyScopeCollections, NamePart: 2}
+	resp = apiKeyReq(t, token, &colScope, db.add, "POST", "/db/roletasks", map[string]any{"title": "edited"})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the editor to write roletasks got %s", GetResponseBody(t, resp))
+	}
+
+	resp = apiKeyReq(t, token, &colScope, db.list, "GET", "/db/othertasks", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the editor to be refused othertasks got %d", resp.StatusCode)
+	}
+
+	noop := func(w http.ResponseWriter, r *http.Request) { respond(w, http.StatusOK, true) }
+
+	fnScope := middleware.RouteScope{Scope: model.APIKeyScopeFunctions, NamePart: 3}
+	resp = apiKeyReq(t, token, &fnScope, noop, "POST", "/fn/exec/publish", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the editor to execute publish got %d", resp.StatusCode)
+	}
+
+	resp = apiKeyReq(t, token, &fnScope, noop, "POST", "/fn/exec/refund", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the editor to be refused refund got %d", resp.StatusCode)
+	}
+
+	storageScope := middleware.RouteScope{Scope: model.APIKeyScopeStorage}
+	resp = apiKeyReq(t, token, &storageScope, noop, "GET", "/storage/usage", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the editor to be refused storage got %d", resp.StatusCode)
+	}
+
+	resp = authReqWithToken(t, token, acct.invitations, "GET", "/account/invitations", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected the editor to be refused managing users got %d", resp.StatusCode)
+	}
+
+	setRole("billing-admin")
+
+	resp = authReqWithToken(t, token, acct.invitations, "GET", "/account/invitations", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the billing-admin to manage users got %s", GetResponseBody(t, resp))
+	}
+
+	// removing the role falls back to the integer role
+	if err := backend.Membership(conf).SetCustomRoles(model.CustomRoles{}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = authReqWithToken(t, token, acct.invitations, "GET", "/account/invitations", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a removed role to fall back to level 10 got %d", resp.StatusCode)
+	}
+}
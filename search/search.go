@@ -193,11 +193,19 @@ func (s *Search) Delete(dbName, col, id string) error {
 type SearchResult struct {
 	DBName string
 	Col    string
-	IDs    []string
+	// IDs of the matching documents, most relevant first
+	IDs []string
+	// Scores holds the relevance score of each matching document id
+	Scores map[string]float64
 }
 
 func (s *Search) Search(dbName, col, keywords string) (SearchResult, error) {
-	sr := SearchResult{DBName: dbName, Col: col}
+	return s.SearchWithSize(dbName, col, keywords, defaultSearchSize)
+}
+
+// SearchWithSize returns up to size matching documents
+func (s *Search) SearchWithSize(dbName, col, keywords string, size int) (SearchResult, error) {
+	sr := SearchResult{DBName: dbName, Col: col, Scores: make(map[string]float64)}
 
 	if s == nil || s.index == nil {
 		return sr, errors.New("search index is not initialized")
@@ -246,7 +254,7 @@ func (s *Search) Search(dbName, col, keywords string) (SearchResult, error) {
 	if req == nil {
 		return sr, errors.New("search request is nil")
 	}
-	req.Size = defaultSearchSize
+	req.Size = size
 
 	results, err := s.index.Search(req)
 	if err != nil {
@@ -260,6 +268,7 @@ func (s *Search) Search(dbName, col, keywords string) (SearchResult, error) {
 		}
 
 		sr.IDs = append(sr.IDs, id)
+		sr.Scores[id] = r.Score
 	}
 
 	return sr, nil
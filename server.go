@@ -16,7 +16,6 @@ import (
 
 	"github.com/staticbackendhq/core/backend"
 	"github.com/staticbackendhq/core/config"
-	"github.com/staticbackendhq/core/internal"
 	"github.com/staticbackendhq/core/logger"
 	"github.com/staticbackendhq/core/middleware"
 	"github.com/staticbackendhq/core/model"
@@ -56,55 +55,8 @@ func Start(c config.AppConfig) {
 	// all services like the Datastore, Filestore, Emailers, etc.
 	backend.Setup(c)
 
-	// websockets
-	//TODO: need to rename the hub.tofix and socket.tofix files
-	// when returning to full WebSocket instead of SSE
-	// should move the hub and socket to the realtime package
-	//hub := newHub(backend.Cache)
-	//go hub.run()
-
-	// Server Send Event, alternative to websocket
-	b := realtime.NewBroker(func(ctx context.Context, key string) (string, error) {
-		//TODO: Experimental, let un-authenticated user connect
-		// useful for an Intercom-like SaaS I'm building.
-		if strings.HasPrefix(key, "__tmp__experimental_public") {
-			// let's create the most minimal authentication possible
-			a := model.Auth{
-				AccountID: internal.RandStringRunes(30),
-				UserID:    internal.RandStringRunes(30),
-				Email:     "exp@tmp.com",
-				Role:      0,
-				Token:     key,
-			}
-
-			if err := backend.Cache.SetTyped(key, a); err != nil {
-				return key, err
-			}
-
-			return key, nil
-		}
-
-		auth, err := middleware.ValidateAuthKey(backend.DB, backend.Cache, ctx, key)
-		if err != nil {
-			return "", err
-		}
-
-		// set base:token useful when executing pubsub event message / function
-		conf, ok := ctx.Value(middleware.ContextBase).(model.DatabaseConfig)
-		if !ok {
-			return "", errors.New("could not find base config")
-		}
-
-		//TODO: Lots of repetition of this, needs to be refactor
-		if err := backend.Cache.SetTyped(key, auth); err != nil {
-			return "", err
-		}
-		if err := backend.Cache.SetTyped("base:"+key, conf); err != nil {
-			return "", err
-		}
-
-		return key, nil
-	}, backend.Cache)
+	// realtime broker for the WebSocket and Server Sent Event connections
+	b := realtime.NewBroker(validateRealtimeAuth, backend.Cache)
 
 	database := &Database{
 		cache: backend.Cache,
@@ -131,22 +83,63 @@ func Start(c config.AppConfig) {
 		middleware.WithDB(backend.DB, backend.Cache, getStripePortalURL),
 		middleware.RequireRoot(backend.DB, backend.Cache),
 		middleware.LongRequestTelemetry(backend.Cache),
+		middleware.AuditRoot(backend.DB, backend.Cache),
 	}
 
+	// scoped lets API keys granted the scope call a route, API keys are
+	// refused on all other routes
+	scoped := func(scope middleware.RouteScope, chain []middleware.Middleware) []middleware.Middleware {
+		return append([]middleware.Middleware{middleware.WithScope(scope)}, chain...)
+	}
+	colScope := func(namePart int, read bool) middleware.RouteScope {
+		return middleware.RouteScope{Scope: model.APIKeyScopeCollections, NamePart: namePart, Read: read}
+	}
+	fnScope := middleware.RouteScope{Scope: model.APIKeyScopeFunctions, NamePart: 3}
+	storageScope := middleware.RouteScope{Scope: model.APIKeyScopeStorage}
+
 	// static assets
 	http.Handle("/static/", http.StripPrefix("/", http.FileServer(http.FS(content))))
 
+	// public keys verifying the session tokens
+	http.Handle("/.well-known/jwks.json", middleware.Chain(http.HandlerFunc(jwks), stdPub...))
+
 	m := &membership{}
 
 	http.Handle("/login/magic", middleware.Chain(http.HandlerFunc(m.magicLink), pubWithDB...))
+	http.Handle("/login/sms", middleware.Chain(http.HandlerFunc(m.phoneLogin), pubWithDB...))
+	http.Handle("/login/sms/verify", middleware.Chain(http.HandlerFunc(m.phoneLogin), pubWithDB...))
+	http.Handle("/guest", middleware.Chain(http.HandlerFunc(m.createGuest), pubWithDB...))
+	http.Handle("/guest/upgrade", middleware.Chain(http.HandlerFunc(m.upgradeGuest), stdAuth...))
+	http.Handle("/guest/upgrade/magic", middleware.Chain(http.HandlerFunc(m.upgradeGuest), stdAuth...))
 	http.Handle("/login", middleware.Chain(http.HandlerFunc(m.login), pubWithDB...))
+	http.Handle("/login/mfa", middleware.Chain(http.HandlerFunc(m.loginMFA), pubWithDB...))
+	http.Handle("/login/mfa/enroll", middleware.Chain(http.HandlerFunc(m.loginMFAEnroll), pubWithDB...))
+	http.Handle("/login/refresh", middleware.Chain(http.HandlerFunc(m.refreshSession), pubWithDB...))
+	http.Handle("/logout", middleware.Chain(http.HandlerFunc(m.logout), stdAuth...))
 	http.Handle("/register", middleware.Chain(http.HandlerFunc(m.register), pubWithDB...))
 	http.Handle("/email", middleware.Chain(http.HandlerFunc(m.emailExists), pubWithDB...))
+	http.Handle("/email/verify", middleware.Chain(http.HandlerFunc(m.verifyEmail), pubWithDB...))
+	http.Handle("/email/verify/resend", middleware.Chain(http.HandlerFunc(m.resendEmailVerification), pubWithDB...))
+	http.Handle("/invitations/accept", middleware.Chain(http.HandlerFunc(m.acceptInvitation), pubWithDB...))
 	http.Handle("/password/resetcode", middleware.Chain(http.HandlerFunc(m.setResetCode), stdRoot...))
 	http.Handle("/password/reset", middleware.Chain(http.HandlerFunc(m.resetPassword), pubWithDB...))
 	http.Handle("/setrole", middleware.Chain(http.HandlerFunc(m.setRole), stdAuth...))
 	http.Handle("/me", middleware.Chain(http.HandlerFunc(m.me), stdAuth...))
+	http.Handle("/me/profile", middleware.Chain(http.HandlerFunc(m.profile), stdAuth...))
+	http.Handle("/profiles/", middleware.Chain(http.HandlerFunc(m.publicProfile), stdAuth...))
 	http.Handle("/me/email", middleware.Chain(http.HandlerFunc(m.changeEmail), stdAuth...))
+	http.Handle("/me/phone", middleware.Chain(http.HandlerFunc(m.phone), stdAuth...))
+	http.Handle("/me/phone/verify", middleware.Chain(http.HandlerFunc(m.verifyPhone), stdAuth...))
+	http.Handle("/me/mfa", middleware.Chain(http.HandlerFunc(m.mfaStatus), stdAuth...))
+	http.Handle("/me/mfa/enroll", middleware.Chain(http.HandlerFunc(m.mfaEnroll), stdAuth...))
+	http.Handle("/me/mfa/confirm", middleware.Chain(http.HandlerFunc(m.mfaConfirm), stdAuth...))
+	http.Handle("/me/mfa/disable", middleware.Chain(http.HandlerFunc(m.mfaDisable), stdAuth...))
+	http.Handle("/me/mfa/recovery", middleware.Chain(http.HandlerFunc(m.mfaRecoveryCodes), stdAuth...))
+	http.Handle("/me/sessions", middleware.Chain(http.HandlerFunc(m.sessions), stdAuth...))
+	http.Handle("/me/exports", middleware.Chain(http.HandlerFunc(m.exports), stdAuth...))
+	http.Handle("/me/exports/", middleware.Chain(http.HandlerFunc(m.exports), stdAuth...))
+	http.Handle("/exports/download", middleware.Chain(http.HandlerFunc(m.downloadExport), pubWithDB...))
+	http.Handle("/me/oauth/consents", middleware.Chain(http.HandlerFunc(m.oauthConsents), stdAuth...))
 	http.Handle("/account", middleware.Chain(http.HandlerFunc(m.deleteAccount), stdAuth...))
 
 	// oauth handlers
@@ -155,19 +148,45 @@ func Start(c config.AppConfig) {
 	http.Handle("/oauth/callback/", middleware.Chain(el.callback(), stdPub...))
 	http.Handle("/oauth/get-user", middleware.Chain(http.HandlerFunc(el.getUser), pubWithDB...))
 
+	// OpenID Connect provider, /oidc/{publicKey} is the issuer of a database
+	idp := &identityProvider{}
+	http.Handle("/oidc/", middleware.Chain(http.HandlerFunc(idp.route), append([]middleware.Middleware{oidcWithDB()}, pubWithDB...)...))
+
 	http.Handle("/sudogettoken/", middleware.Chain(http.HandlerFunc(m.sudoGetTokenFromAccountID), stdRoot...))
 	http.Handle("/sudogetauthtokenbyuserid/", middleware.Chain(http.HandlerFunc(m.getAuthTokenByUserID), stdRoot...))
 	http.Handle("/sudogetuserbyid/", middleware.Chain(http.HandlerFunc(m.getUserByID), stdRoot...))
+	http.Handle("/sudo/mfa", middleware.Chain(http.HandlerFunc(m.sudoMFAPolicy), stdRoot...))
+	http.Handle("/sudo/mfa/reset", middleware.Chain(http.HandlerFunc(m.sudoResetMFA), stdRoot...))
+	http.Handle("/sudo/emailverification", middleware.Chain(http.HandlerFunc(m.sudoEmailVerificationPolicy), stdRoot...))
+	http.Handle("/sudo/loginprotection", middleware.Chain(http.HandlerFunc(m.sudoLoginProtection), stdRoot...))
+	http.Handle("/sudo/loginprotection/locks", middleware.Chain(http.HandlerFunc(m.sudoLoginLocks), stdRoot...))
+	http.Handle("/sudo/invitations", middleware.Chain(http.HandlerFunc(m.sudoInvitationPolicy), stdRoot...))
+	http.Handle("/sudo/audit", middleware.Chain(http.HandlerFunc(m.sudoAudit), stdRoot...))
+	http.Handle("/sudo/audit/policy", middleware.Chain(http.HandlerFunc(m.sudoAuditPolicy), stdRoot...))
+	http.Handle("/sudo/userprofiles/", middleware.Chain(http.HandlerFunc(m.sudoProfile), stdRoot...))
+	http.Handle("/sudo/profilepolicy", middleware.Chain(http.HandlerFunc(m.sudoProfilePolicy), stdRoot...))
+	http.Handle("/sudo/guestpolicy", middleware.Chain(http.HandlerFunc(m.sudoGuestPolicy), stdRoot...))
+	http.Handle("/sudo/passwordpolicy", middleware.Chain(http.HandlerFunc(m.sudoPasswordPolicy), stdRoot...))
+	http.Handle("/sudo/exports", middleware.Chain(http.HandlerFunc(m.sudoExports), stdRoot...))
+	http.Handle("/sudo/exports/", middleware.Chain(http.HandlerFunc(m.sudoExports), stdRoot...))
+	http.Handle("/sudo/roles", middleware.Chain(http.HandlerFunc(m.sudoRoles), stdRoot...))
+	http.Handle("/sudo/sessions/revoke", middleware.Chain(http.HandlerFunc(m.sudoRevokeSessions), stdRoot...))
+	http.Handle("/sudo/apikeys", middleware.Chain(http.HandlerFunc(sudoAPIKeys), stdRoot...))
+	http.Handle("/sudo/oauth/clients", middleware.Chain(http.HandlerFunc(sudoOAuthClients), stdRoot...))
 
 	// database routes
-	http.Handle("/db/", middleware.Chain(http.HandlerFunc(database.dbreq), stdAuth...))
-	http.Handle("/db/count/", middleware.Chain(http.HandlerFunc(database.count), stdAuth...))
-	http.Handle("/query/", middleware.Chain(http.HandlerFunc(database.query), stdAuth...))
-	http.Handle("/inc/", middleware.Chain(http.HandlerFunc(database.increase), stdAuth...))
-	http.Handle("/sudoquery/", middleware.Chain(http.HandlerFunc(database.query), stdRoot...))
+	http.Handle("/db/", middleware.Chain(http.HandlerFunc(database.dbreq), scoped(colScope(2, false), stdAuth)...))
+	http.Handle("/db/count/", middleware.Chain(http.HandlerFunc(database.count), scoped(colScope(3, true), stdAuth)...))
+	http.Handle("/query/", middleware.Chain(http.HandlerFunc(database.query), scoped(colScope(2, true), stdAuth)...))
+	http.Handle("/inc/", middleware.Chain(http.HandlerFunc(database.increase), scoped(colScope(2, false), stdAuth)...))
+	http.Handle("/sudoquery/", middleware.Chain(http.HandlerFunc(database.query), scoped(colScope(2, true), stdRoot)...))
 	http.Handle("/sudolistall/", middleware.Chain(http.HandlerFunc(database.listCollections), stdRoot...))
 	http.Handle("/sudo/index", middleware.Chain(http.HandlerFunc(database.index), stdRoot...))
-	http.Handle("/sudo/", middleware.Chain(http.HandlerFunc(database.dbreq), stdRoot...))
+	http.Handle("/sudo/encryption", middleware.Chain(http.HandlerFunc(database.encryption), stdRoot...))
+	http.Handle("/sudo/encryption/rotate", middleware.Chain(http.HandlerFunc(database.rotateEncryptionKey), stdRoot...))
+	http.Handle("/sudo/defaults", middleware.Chain(http.HandlerFunc(database.fieldDefaults), stdRoot...))
+	http.Handle("/sudo/dbstats", middleware.Chain(http.HandlerFunc(database.poolStats), stdRoot...))
+	http.Handle("/sudo/", middleware.Chain(http.HandlerFunc(database.dbreq), scoped(colScope(2, false), stdRoot)...))
 	http.Handle("/newid", middleware.Chain(http.HandlerFunc(database.newID), stdAuth...))
 	http.Handle("/search", middleware.Chain(http.HandlerFunc(database.search), stdAuth...))
 
@@ -176,14 +195,14 @@ func Start(c config.AppConfig) {
 	http.Handle("/form", middleware.Chain(http.HandlerFunc(listForm), stdRoot...))
 
 	// storage
-	http.Handle("/storage/upload", middleware.Chain(http.HandlerFunc(upload), stdAuth...))
-	http.Handle("/storage/usage", middleware.Chain(http.HandlerFunc(storageUsage), stdAuth...))
-	http.Handle("/storage/files", middleware.Chain(http.HandlerFunc(listFiles), stdAuth...))
-	http.Handle("/sudostorage/delete", middleware.Chain(http.HandlerFunc(deleteFile), stdRoot...))
+	http.Handle("/storage/upload", middleware.Chain(http.HandlerFunc(upload), scoped(storageScope, stdAuth)...))
+	http.Handle("/storage/usage", middleware.Chain(http.HandlerFunc(storageUsage), scoped(storageScope, stdAuth)...))
+	http.Handle("/storage/files", middleware.Chain(http.HandlerFunc(listFiles), scoped(storageScope, stdAuth)...))
+	http.Handle("/sudostorage/delete", middleware.Chain(http.HandlerFunc(deleteFile), scoped(storageScope, stdRoot)...))
 
 	// sudo actions
-	http.Handle("/sudo/sendmail", middleware.Chain(http.HandlerFunc(sudoSendMail), stdRoot...))
-	http.Handle("/sudo/cache", middleware.Chain(http.HandlerFunc(sudoCache), stdRoot...))
+	http.Handle("/sudo/sendmail", middleware.Chain(http.HandlerFunc(sudoSendMail), scoped(middleware.RouteScope{Scope: model.APIKeyScopeSendMail}, stdRoot)...))
+	http.Handle("/sudo/cache", middleware.Chain(http.HandlerFunc(sudoCache), scoped(middleware.RouteScope{Scope: model.APIKeyScopeCache}, stdRoot)...))
 
 	// account
 	acct := &accounts{}
@@ -192,6 +211,10 @@ func Start(c config.AppConfig) {
 	http.Handle("/account/portal", middleware.Chain(http.HandlerFunc(acct.portal), stdRoot...))
 	http.Handle("/account/users/", middleware.Chain(http.HandlerFunc(acct.deleteUser), stdAuth...))
 	http.Handle("/account/users", middleware.Chain(http.HandlerFunc(acct.addUser), stdAuth...))
+	http.Handle("/account/invitations", middleware.Chain(http.HandlerFunc(acct.invitations), stdAuth...))
+	http.Handle("/account/invitations/", middleware.Chain(http.HandlerFunc(acct.invitation), stdAuth...))
+	http.Handle("/account/groups", middleware.Chain(http.HandlerFunc(acct.groups), stdAuth...))
+	http.Handle("/account/groups/", middleware.Chain(http.HandlerFunc(acct.group), stdAuth...))
 	http.Handle("/account/add-db", middleware.Chain(http.HandlerFunc(acct.addDatabase), stdAuth...))
 	http.Handle("/account/associations", middleware.Chain(http.HandlerFunc(acct.listAssociations), stdAuth...))
 	http.Handle("/account/promote", middleware.Chain(http.HandlerFunc(acct.promoteUser), stdAuth...))
@@ -203,11 +226,7 @@ func Start(c config.AppConfig) {
 
 	http.HandleFunc("/ping", ping)
 
-	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		//TODO: when we move from SSE to full WebSocket re-enable this upgrade
-		//serveWs(log, hub, w, r)
-	})
-
+	http.Handle("/ws", middleware.Chain(http.HandlerFunc(b.AcceptWebSocket), pubWithDB...))
 	http.Handle("/sse/connect", middleware.Chain(http.HandlerFunc(b.Accept), pubWithDB...))
 	receiveMessage := func(w http.ResponseWriter, r *http.Request) {
 		var msg model.Command
@@ -221,6 +240,8 @@ func Start(c config.AppConfig) {
 		respond(w, http.StatusOK, true)
 	}
 	http.Handle("/sse/msg", middleware.Chain(http.HandlerFunc(receiveMessage), pubWithDB...))
+	http.Handle("/presence/", middleware.Chain(http.HandlerFunc(presence), stdAuth...))
+	http.Handle("/history/", middleware.Chain(http.HandlerFunc(channelHistory), stdAuth...))
 
 	// server-side functions
 	f := &functions{datastore: backend.DB}
@@ -229,8 +250,8 @@ func Start(c config.AppConfig) {
 	http.Handle("/fn/delete/", middleware.Chain(http.HandlerFunc(f.del), stdRoot...))
 	http.Handle("/fn/del/", middleware.Chain(http.HandlerFunc(f.del), stdRoot...))
 	http.Handle("/fn/info/", middleware.Chain(http.HandlerFunc(f.info), stdRoot...))
-	http.Handle("/fn/sudoexec/", middleware.Chain(http.HandlerFunc(f.exec), stdRoot...))
-	http.Handle("/fn/exec/", middleware.Chain(http.HandlerFunc(f.exec), stdAuth...))
+	http.Handle("/fn/sudoexec/", middleware.Chain(http.HandlerFunc(f.exec), scoped(fnScope, stdRoot)...))
+	http.Handle("/fn/exec/", middleware.Chain(http.HandlerFunc(f.exec), scoped(fnScope, stdAuth)...))
 	http.Handle("/fn", middleware.Chain(http.HandlerFunc(f.list), stdRoot...))
 
 	// schedule tasks
@@ -277,6 +298,7 @@ func Start(c config.AppConfig) {
 	http.Handle("/ui/forms/del/", middleware.Chain(http.HandlerFunc(webUI.formDel), stdRoot...))
 	http.Handle("/ui/fs", middleware.Chain(http.HandlerFunc(webUI.fsList), stdRoot...))
 	http.Handle("/ui/fs/del/", middleware.Chain(http.HandlerFunc(webUI.fsDel), stdRoot...))
+	http.Handle("/ui/audit", middleware.Chain(http.HandlerFunc(webUI.audit), stdRoot...))
 	http.Handle("/ui/my-account/", middleware.Chain(http.HandlerFunc(webUI.myAccount), stdRoot...))
 	http.HandleFunc("/", webUI.login)
 
@@ -301,11 +323,13 @@ func Start(c config.AppConfig) {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
+		// the realtime connections are closed first, the server does not
+		// track the hijacked web sockets and waits for the SSE requests
 		var errs []error
-		if err := httpsvr.Shutdown(shutdownCtx); err != nil {
+		if err := b.Close(shutdownCtx); err != nil {
 			errs = append(errs, err)
 		}
-		if err := b.Close(shutdownCtx); err != nil {
+		if err := httpsvr.Shutdown(shutdownCtx); err != nil {
 			errs = append(errs, err)
 		}
 		if err := backend.Close(shutdownCtx); err != nil {
@@ -320,6 +344,31 @@ func Start(c config.AppConfig) {
 	}
 }
 
+// validateRealtimeAuth validates the session token of a realtime connection
+// and caches its user and database for the pubsub messages and functions
+func validateRealtimeAuth(ctx context.Context, key string) (string, error) {
+	auth, err := middleware.ValidateAuthKey(backend.DB, backend.Cache, ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	// set base:token useful when executing pubsub event message / function
+	conf, ok := ctx.Value(middleware.ContextBase).(model.DatabaseConfig)
+	if !ok {
+		return "", errors.New("could not find base config")
+	}
+
+	//TODO: Lots of repetition of this, needs to be refactor
+	if err := backend.Cache.SetTyped(key, auth); err != nil {
+		return "", err
+	}
+	if err := backend.Cache.SetTyped("base:"+key, conf); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
 func ping(w http.ResponseWriter, r *http.Request) {
 	if err := backend.DB.Ping(); err != nil {
 		http.Error(w, "connection failed to database, I'm down.", http.StatusInternalServerError)
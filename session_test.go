@@ -0,0 +1,129 @@
+package staticbackend
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/model"
+)
+
+func TestSessionLoginRefreshLogout(t *testing.T) {
+	conf, err := backend.DB.FindDatabase(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := "session-http@test.com"
+	_, user, err := backend.Membership(conf).CreateUser(testAccountID, email, userPassword, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = backend.DB.RemoveUser(model.Auth{AccountID: testAccountID, UserID: user.ID, Role: 100}, dbName, user.ID)
+	})
+
+	login := model.Login{Email: email, Password: userPassword, Refresh: true}
+	resp := dbReq(t, mship.login, "POST", "/login", login)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var tokens model.SessionTokens
+	if err := parseBody(resp.Body, &tokens); err != nil {
+		t.Fatal(err)
+	} else if len(tokens.RefreshToken) == 0 {
+		t.Fatal("expected a refresh token")
+	}
+
+	resp = authReqWithToken(t, tokens.Token, mship.sessions, "GET", "/me/sessions", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var sessions []model.Session
+	if err := parseBody(resp.Body, &sessions); err != nil {
+		t.Fatal(err)
+	} else if len(sessions) != 2 || sessions[0].Current == sessions[1].Current {
+		// creating the user started a session too
+		t.Fatalf("expected the current session got %v", sessions)
+	}
+
+	resp = dbReq(t, mship.refreshSession, "POST", "/login/refresh", model.RefreshSession{RefreshToken: tokens.RefreshToken})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	var refreshed model.SessionTokens
+	if err := parseBody(resp.Body, &refreshed); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = authReqWithToken(t, refreshed.Token, mship.logout, "POST", "/logout", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode > 299 {
+		t.Fatal(GetResponseBody(t, resp))
+	}
+
+	resp = authReqWithToken(t, refreshed.Token, mship.me, "GET", "/me", nil)
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected revoked token to be refused got status %d", resp.StatusCode)
+	}
+
+	resp = dbReq(t, mship.refreshSession, "POST", "/login/refresh", model.RefreshSession{RefreshToken: refreshed.RefreshToken})
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected refresh of a revoked session to be refused got status %d", resp.StatusCode)
+	}
+}
+
+func TestJWKSVerifiesSessionTokens(t *testing.T) {
+	w := httptest.NewRecorder()
+	jwks(w, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&set); err != nil {
+		t.Fatal(err)
+	} else if len(set.Keys) == 0 {
+		t.Fatal("expected the session token signing keys to be published")
+	}
+
+	var hd jwt.Header
+	parts := strings.Split(userToken, ".")
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		t.Fatal(err)
+	} else if err := json.Unmarshal(b, &hd); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range set.Keys {
+		if k["kid"] != hd.KeyID || k["kty"] != "RSA" {
+			continue
+		}
+
+		n, _ := base64.RawURLEncoding.DecodeString(k["n"])
+		e, _ := base64.RawURLEncoding.DecodeString(k["e"])
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+
+		var pl model.JWTPayload
+		if _, err := jwt.Verify([]byte(userToken), jwt.NewRS256(jwt.RSAPublicKey(pub)), &pl); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	t.Errorf("expected the key %s signing the session token in %v", hd.KeyID, set.Keys)
+}
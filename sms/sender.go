@@ -0,0 +1,45 @@
+package sms
+
+import (
+	"fmt"
+
+	"github.com/staticbackendhq/core/config"
+)
+
+const (
+	SMSProviderDev    = "dev"
+	SMSProviderTwilio = "twilio"
+)
+
+// Sender is the text-message sending interface
+type Sender interface {
+	Send(data SMSData) error
+}
+
+// Twilio sends text-messages using the Twilio account of the configuration
+// when data has no credentials or from number
+type Twilio struct{}
+
+func (Twilio) Send(data SMSData) error {
+	if len(data.AccountSID) == 0 {
+		data.AccountSID = config.Current.TwilioAccountID
+		data.AuthToken = config.Current.TwilioAuthToken
+	}
+	if len(data.FromNumber) == 0 {
+		data.FromNumber = config.Current.TwilioNumber
+	}
+	return Send(data)
+}
+
+// Dev prints text-messages instead of sending them
+type Dev struct{}
+
+func (Dev) Send(data SMSData) error {
+	fmt.Println("==========")
+	fmt.Printf("From: %s\n", data.FromNumber)
+	fmt.Printf("To: %s\n\n", data.ToNumber)
+	fmt.Println(data.Body)
+	fmt.Println("==========")
+
+	return nil
+}
@@ -32,6 +32,10 @@ func (Local) Save(data model.UploadFileData) (string, error) {
 	return url, nil
 }
 
+func (Local) Get(fileKey string) (io.ReadCloser, error) {
+	return os.Open(path.Join(os.TempDir(), fileKey))
+}
+
 func (Local) Delete(fileKey string) error {
 	filename := path.Join(os.TempDir(), fileKey)
 	return os.Remove(filename)
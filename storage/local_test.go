@@ -3,6 +3,7 @@ package storage
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 
@@ -24,3 +25,26 @@ func TestLocalSave(t *testing.T) {
 
 	fmt.Println(url)
 }
+
+func TestLocalGet(t *testing.T) {
+	local := Local{}
+
+	data := model.UploadFileData{FileKey: "unit/test/get.txt", File: bytes.NewReader([]byte("unit get"))}
+	if _, err := local.Save(data); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = local.Delete(data.FileKey) }()
+
+	rc, err := local.Get(data.FileKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	} else if string(b) != "unit get" {
+		t.Errorf("expected unit get got %s", b)
+	}
+}
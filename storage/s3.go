@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/staticbackendhq/core/config"
 	"github.com/staticbackendhq/core/model"
@@ -34,11 +35,11 @@ func (S3) Save(data model.UploadFileData) (string, error) {
 		contentType = "application/octet-stream"
 	}
 
-	opts := minio.PutObjectOptions{
-		ContentType: contentType,
-		UserMetadata: map[string]string{
+	opts := minio.PutObjectOptions{ContentType: contentType}
+	if !data.Private {
+		opts.UserMetadata = map[string]string{
 			"x-amz-acl": "public-read",
-		},
+		}
 	}
 	_, err = c.PutObject(ctx, bucketName, data.FileKey, data.File, data.Size, opts)
 	if err != nil {
@@ -54,6 +55,24 @@ func (S3) Save(data model.UploadFileData) (string, error) {
 	return url, nil
 }
 
+func (S3) Get(fileKey string) (io.ReadCloser, error) {
+	ctx := context.Background()
+	endpoint := config.Current.S3Endpoint
+	accessKeyID := config.Current.S3AccessKey
+	secretAccessKey := config.Current.S3SecretKey
+
+	// Initialize minio client object.
+	c, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
+		Secure: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetObject(ctx, config.Current.S3Bucket, fileKey, minio.GetObjectOptions{})
+}
+
 func (S3) Delete(fileKey string) error {
 	ctx := context.Background()
 	endpoint := config.Current.S3Endpoint
@@ -1,16 +1,22 @@
 package storage
 
-import "github.com/staticbackendhq/core/model"
+import (
+	"io"
+
+	"github.com/staticbackendhq/core/model"
+)
 
 const (
 	StorageProviderLocal = "local"
 	StorageProviderS3    = "s3"
 )
 
-// Storer handles file saving/deleting
+// Storer handles file saving/reading/deleting
 type Storer interface {
 	// Save saves a file via a storage provider
 	Save(model.UploadFileData) (string, error)
+	// Get opens a file saved via a storage provider
+	Get(fileKey string) (io.ReadCloser, error)
 	// Delete removes a file via a storage provider
 	Delete(string) error
 }
@@ -2,6 +2,7 @@ package staticbackend
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/staticbackendhq/core/backend"
+	"github.com/staticbackendhq/core/internal"
 	"github.com/staticbackendhq/core/middleware"
 	"github.com/staticbackendhq/core/model"
 )
@@ -138,6 +140,16 @@ func (x ui) enableExternalLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var oidc *model.OIDCConfig
+	if provider == "oidc" {
+		provider = strings.ToLower(strings.TrimSpace(r.Form.Get("name")))
+		oidc, err = oidcConfigFromForm(provider, r)
+		if err != nil {
+			render(w, r, "logins.html", logins, &Flash{Type: "danger", Message: err.Error()})
+			return
+		}
+	}
+
 	keys, ok := logins[provider]
 	if !ok {
 		keys = model.OAuthConfig{}
@@ -145,6 +157,7 @@ func (x ui) enableExternalLogin(w http.ResponseWriter, r *http.Request) {
 
 	keys.ConsumerKey = apikey
 	keys.ConsumerSecret = secret
+	keys.OIDC = oidc
 
 	logins[provider] = keys
 
@@ -157,6 +170,50 @@ func (x ui) enableExternalLogin(w http.ResponseWriter, r *http.Request) {
 	render(w, r, "logins.html", logins, flash)
 }
 
+// oidcConfigFromForm reads a generic OpenID Connect provider from the logins
+// form. The issuer must serve a valid discovery document and claims are
+// entered as field=claim pairs separated by commas.
+func oidcConfigFromForm(name string, r *http.Request) (*model.OIDCConfig, error) {
+	if len(name) == 0 {
+		return nil, errors.New("a provider name is required")
+	} else if strings.ContainsAny(name, "_ /?&") {
+		return nil, errors.New("the provider name cannot contain spaces, underscores or URL characters")
+	}
+
+	switch name {
+	case OAuthProviderTwitter, OAuthProviderFacebook, OAuthProviderGoogle, OAuthProviderGitHub:
+		return nil, fmt.Errorf("%s is a built-in provider", name)
+	}
+
+	cfg := &model.OIDCConfig{}
+
+	cfg.IssuerURL = strings.TrimSuffix(strings.TrimSpace(r.Form.Get("issuer")), "/")
+	if _, err := internal.DiscoverOIDC(cfg.IssuerURL); err != nil {
+		return nil, fmt.Errorf("unable to read the issuer discovery document: %w", err)
+	}
+
+	cfg.Scopes = strings.Fields(r.Form.Get("scopes"))
+	cfg.TrustEmails = r.Form.Get("trustemails") == "true"
+
+	for _, pair := range strings.Split(r.Form.Get("claims"), ",") {
+		if len(strings.TrimSpace(pair)) == 0 {
+			continue
+		}
+
+		field, claim, ok := strings.Cut(pair, "=")
+		field, claim = strings.TrimSpace(field), strings.TrimSpace(claim)
+		if _, known := internal.DefaultOIDCClaims[field]; !ok || !known || len(claim) == 0 {
+			return nil, fmt.Errorf("invalid claim mapping %q", pair)
+		}
+
+		if cfg.Claims == nil {
+			cfg.Claims = make(map[string]string)
+		}
+		cfg.Claims[field] = claim
+	}
+	return cfg, nil
+}
+
 func (x *ui) dbCols(w http.ResponseWriter, r *http.Request) {
 	conf, auth, err := middleware.Extract(r, false)
 	if err != nil {
@@ -611,6 +668,38 @@ func (x ui) users(w http.ResponseWriter, r *http.Request) {
 	render(w, r, "users_list.html", users, nil)
 }
 
+func (x ui) audit(w http.ResponseWriter, r *http.Request) {
+	conf, _, err := middleware.Extract(r, false)
+	if err != nil {
+		renderErr(w, r, err)
+		return
+	}
+
+	filter, err := auditFilter(r)
+	if err != nil {
+		renderErr(w, r, err)
+		return
+	}
+
+	entries, err := backend.Membership(conf).ListAuditEntries(filter)
+	if err != nil {
+		renderErr(w, r, err)
+		return
+	}
+
+	qs := r.URL.Query()
+	qs.Set("format", "csv")
+
+	data := struct {
+		Entries   []model.AuditEntry
+		Actions   []string
+		Action    string
+		ExportURL string
+	}{entries, model.AuditActions, filter.Action, "/sudo/audit?" + qs.Encode()}
+
+	render(w, r, "audit_log.html", data, nil)
+}
+
 func (x ui) tasks(w http.ResponseWriter, r *http.Request) {
 	conf, _, err := middleware.Extract(r, false)
 	if err != nil {